@@ -0,0 +1,142 @@
+package ksql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+// newDB is the fixture used by the unit tests for building a DB
+// for the input driver on top of a mock adapter.
+//
+// If the adapter is a mockDBAdapter the DB can also start transactions,
+// whose queries are sent to the same adapter.
+func newDB(t *testing.T, driver string, adapter DBAdapter, opts ...Option) DB {
+	if m, ok := adapter.(mockDBAdapter); ok {
+		adapter = mockTxBeginner{
+			mockDBAdapter: m,
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{mockDBAdapter: m}, nil
+			},
+		}
+	}
+
+	db, err := NewWithAdapter(adapter, driver, opts...)
+	tt.AssertNoErr(t, err)
+	return db
+}
+
+// mockDBAdapter is a minimal DBAdapter used on the unit tests
+// where running against a real database would be unnecessary.
+type mockDBAdapter struct {
+	ExecContextFn  func(ctx context.Context, query string, args ...interface{}) (Result, error)
+	QueryContextFn func(ctx context.Context, query string, args ...interface{}) (Rows, error)
+}
+
+func (m mockDBAdapter) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	return m.ExecContextFn(ctx, query, args...)
+}
+
+func (m mockDBAdapter) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return m.QueryContextFn(ctx, query, args...)
+}
+
+// mockTxBeginner is a mockDBAdapter that also implements the TxBeginner interface.
+type mockTxBeginner struct {
+	mockDBAdapter
+	BeginTxFn func(ctx context.Context) (Tx, error)
+}
+
+func (m mockTxBeginner) BeginTx(ctx context.Context) (Tx, error) {
+	return m.BeginTxFn(ctx)
+}
+
+// mockTx implements the Tx interface, if the Fn attributes
+// for Rollback or Commit are not set they do nothing.
+type mockTx struct {
+	mockDBAdapter
+	RollbackFn func(ctx context.Context) error
+	CommitFn   func(ctx context.Context) error
+}
+
+func (m mockTx) Rollback(ctx context.Context) error {
+	if m.RollbackFn == nil {
+		return nil
+	}
+	return m.RollbackFn(ctx)
+}
+
+func (m mockTx) Commit(ctx context.Context) error {
+	if m.CommitFn == nil {
+		return nil
+	}
+	return m.CommitFn(ctx)
+}
+
+// mockRows implements the Rows interface returning
+// the values from a static list of rows.
+type mockRows struct {
+	columns []string
+	rows    [][]interface{}
+
+	idx int
+}
+
+func newMockRows(columns []string, rows ...[]interface{}) *mockRows {
+	return &mockRows{
+		columns: columns,
+		rows:    rows,
+		idx:     -1,
+	}
+}
+
+func (m *mockRows) Scan(args ...interface{}) error {
+	row := m.rows[m.idx]
+	if len(args) != len(row) {
+		return fmt.Errorf("mockRows: expected %d scan args but got %d", len(row), len(args))
+	}
+
+	for i, arg := range args {
+		if scanner, ok := arg.(sql.Scanner); ok {
+			if err := scanner.Scan(row[i]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		dest := reflect.ValueOf(arg).Elem()
+		if row[i] == nil {
+			dest.Set(reflect.Zero(dest.Type()))
+			continue
+		}
+
+		src := reflect.ValueOf(row[i])
+		if !src.Type().ConvertibleTo(dest.Type()) {
+			return fmt.Errorf("mockRows: can't convert %T to %v", row[i], dest.Type())
+		}
+		dest.Set(src.Convert(dest.Type()))
+	}
+
+	return nil
+}
+
+func (m *mockRows) Close() error {
+	return nil
+}
+
+func (m *mockRows) Next() bool {
+	m.idx++
+	return m.idx < len(m.rows)
+}
+
+func (m *mockRows) Err() error {
+	return nil
+}
+
+func (m *mockRows) Columns() ([]string, error) {
+	return m.columns, nil
+}
@@ -0,0 +1,514 @@
+package kbigquery
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+func init() {
+	sql.Register("bigquery", Driver{})
+}
+
+// Driver implements the database/sql driver interfaces on top of
+// the BigQuery client, it is registered with the name "bigquery"
+// and its connection strings have the format:
+//
+//	bigquery://project/dataset?location=US&credentials_file=/path/to/key.json
+//
+// The dataset is used for the tables that are not qualified in the queries,
+// and the queries use positional `?` parameters.
+type Driver struct{}
+
+var _ driver.DriverContext = Driver{}
+
+// Open implements the driver.Driver interface
+func (d Driver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+// OpenConnector implements the driver.DriverContext interface
+func (Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	cfg, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := bigquery.NewClient(context.Background(), cfg.projectID, cfg.options...)
+	if err != nil {
+		return nil, err
+	}
+	client.Location = cfg.location
+
+	return newConnector(client, cfg.datasetID, cfg.options), nil
+}
+
+type dsnConfig struct {
+	projectID string
+	datasetID string
+	location  string
+	options   []option.ClientOption
+}
+
+func parseDSN(dsn string) (dsnConfig, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme != "bigquery" || u.Host == "" || strings.Contains(strings.Trim(u.Path, "/"), "/") {
+		return dsnConfig{}, fmt.Errorf("kbigquery: expected a connection string with the format bigquery://project/dataset but got: %s", dsn)
+	}
+
+	cfg := dsnConfig{
+		projectID: u.Host,
+		datasetID: strings.Trim(u.Path, "/"),
+	}
+	for key, values := range u.Query() {
+		switch key {
+		case "location":
+			cfg.location = values[0]
+		case "credentials_file":
+			cfg.options = append(cfg.options, option.WithAuthCredentialsFile(option.ServiceAccount, values[0]))
+		default:
+			return dsnConfig{}, fmt.Errorf("kbigquery: unknown connection string param: %s", key)
+		}
+	}
+
+	return cfg, nil
+}
+
+// connector keeps the client shared by the connections of a *sql.DB,
+// it is also returned as the driver of the *sql.DB so the SQLAdapter can
+// reach the Storage Write API client from it.
+type connector struct {
+	client    *bigquery.Client
+	datasetID string
+	appender  *rowAppender
+}
+
+func newConnector(client *bigquery.Client, datasetID string, options []option.ClientOption) *connector {
+	return &connector{
+		client:    client,
+		datasetID: datasetID,
+		appender: &rowAppender{
+			client:        client,
+			datasetID:     datasetID,
+			clientOptions: options,
+		},
+	}
+}
+
+var (
+	_ driver.Connector = &connector{}
+	_ driver.Driver    = &connector{}
+	_ io.Closer        = &connector{}
+)
+
+// Connect implements the driver.Connector interface
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{connector: c}, nil
+}
+
+// Driver implements the driver.Connector interface
+func (c *connector) Driver() driver.Driver {
+	return c
+}
+
+// Open implements the driver.Driver interface
+func (c *connector) Open(name string) (driver.Conn, error) {
+	return c.Connect(context.Background())
+}
+
+// Close is called by the *sql.DB when it is closed
+func (c *connector) Close() error {
+	return errors.Join(c.appender.close(), c.client.Close())
+}
+
+// appenderFromDB returns the rowAppender of a *sql.DB opened with
+// the bigquery driver or nil if it uses another driver.
+func appenderFromDB(db *sql.DB) *rowAppender {
+	c, ok := db.Driver().(*connector)
+	if !ok {
+		return nil
+	}
+	return c.appender
+}
+
+// conn represents a BigQuery session while a transaction is open,
+// the queries done outside of transactions are independent jobs.
+type conn struct {
+	*connector
+
+	sessionID string
+}
+
+var (
+	_ driver.ConnBeginTx        = &conn{}
+	_ driver.ExecerContext      = &conn{}
+	_ driver.QueryerContext     = &conn{}
+	_ driver.NamedValueChecker  = &conn{}
+	_ driver.ConnPrepareContext = &conn{}
+)
+
+// Prepare implements the driver.Conn interface
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements the driver.ConnPrepareContext interface,
+// BigQuery has no prepared statements so the query is sent on each use.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+// Close implements the driver.Conn interface
+func (c *conn) Close() error {
+	return nil
+}
+
+// Begin implements the driver.Conn interface
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements the driver.ConnBeginTx interface by creating
+// a session for running the statements of the transaction.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, fmt.Errorf("kbigquery: transactions only support the default isolation level")
+	}
+	if c.sessionID != "" {
+		return nil, fmt.Errorf("kbigquery: nested transactions are not supported")
+	}
+
+	q := c.newQuery("BEGIN TRANSACTION", nil)
+	q.CreateSession = true
+	status, err := runJob(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if status.Statistics == nil || status.Statistics.SessionInfo == nil {
+		return nil, fmt.Errorf("kbigquery: no session was created for the transaction")
+	}
+
+	c.sessionID = status.Statistics.SessionInfo.SessionID
+	return &tx{conn: c}, nil
+}
+
+// ExecContext implements the driver.ExecerContext interface
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	status, err := runJob(ctx, c.newQuery(query, args))
+	if err != nil {
+		return nil, err
+	}
+
+	var rowsAffected int64
+	if status.Statistics != nil {
+		if stats, ok := status.Statistics.Details.(*bigquery.QueryStatistics); ok {
+			rowsAffected = stats.NumDMLAffectedRows
+		}
+	}
+	return result{rowsAffected: rowsAffected}, nil
+}
+
+// QueryContext implements the driver.QueryerContext interface
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	job, err := c.newQuery(query, args).Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := job.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The schema might only be available after the first call to Next:
+	r := &rows{it: it}
+	err = it.Next(&r.next)
+	if err == iterator.Done {
+		r.done = true
+	} else if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface,
+// the values that database/sql doesn't know, e.g. civil.Date or
+// bigquery.NullInt64, are sent as they are to the BigQuery client.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case civil.Date, civil.Time, civil.DateTime:
+		// Their Value methods return strings, which
+		// wouldn't be typed as DATE, TIME or DATETIME:
+		return nil
+	}
+
+	if valuer, ok := nv.Value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return err
+		}
+		nv.Value = v
+	}
+
+	if v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value); err == nil {
+		nv.Value = v
+	}
+	return nil
+}
+
+func (c *conn) newQuery(query string, args []driver.NamedValue) *bigquery.Query {
+	q := c.client.Query(query)
+	if c.datasetID != "" {
+		q.DefaultProjectID = c.client.Project()
+		q.DefaultDatasetID = c.datasetID
+	}
+	if c.sessionID != "" {
+		q.ConnectionProperties = []*bigquery.ConnectionProperty{
+			{Key: "session_id", Value: c.sessionID},
+		}
+	}
+
+	for _, arg := range args {
+		value := arg.Value
+		if value == nil {
+			// The client can't infer the type of untyped nils:
+			value = bigquery.NullString{}
+		}
+		q.Parameters = append(q.Parameters, bigquery.QueryParameter{
+			Name:  arg.Name,
+			Value: value,
+		})
+	}
+
+	return q
+}
+
+// runJob runs the query and waits for it to finish
+func runJob(ctx context.Context, q *bigquery.Query) (*bigquery.JobStatus, error) {
+	job, err := q.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return status, status.Err()
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+var (
+	_ driver.StmtExecContext   = &stmt{}
+	_ driver.StmtQueryContext  = &stmt{}
+	_ driver.NamedValueChecker = &stmt{}
+)
+
+// Close implements the driver.Stmt interface
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput implements the driver.Stmt interface
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+// Exec implements the driver.Stmt interface
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// Query implements the driver.Stmt interface
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// ExecContext implements the driver.StmtExecContext interface
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+// QueryContext implements the driver.StmtQueryContext interface
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return s.conn.CheckNamedValue(nv)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return values
+}
+
+type tx struct {
+	conn *conn
+}
+
+// Commit implements the driver.Tx interface
+func (t *tx) Commit() error {
+	return t.end("COMMIT TRANSACTION")
+}
+
+// Rollback implements the driver.Tx interface
+func (t *tx) Rollback() error {
+	return t.end("ROLLBACK TRANSACTION")
+}
+
+// end runs the input statement and terminates the session of the transaction
+func (t *tx) end(statement string) error {
+	ctx := context.Background()
+	_, err := runJob(ctx, t.conn.newQuery(statement, nil))
+	if err == nil {
+		_, err = runJob(ctx, t.conn.newQuery("CALL BQ.ABORT_SESSION()", nil))
+	}
+
+	t.conn.sessionID = ""
+	return err
+}
+
+type result struct {
+	rowsAffected int64
+}
+
+// LastInsertId implements the driver.Result interface
+func (r result) LastInsertId() (int64, error) {
+	return 0, fmt.Errorf("kbigquery: LastInsertId is not supported by BigQuery")
+}
+
+// RowsAffected implements the driver.Result interface
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+type rows struct {
+	it   *bigquery.RowIterator
+	next []bigquery.Value
+	done bool
+}
+
+// Columns implements the driver.Rows interface
+func (r *rows) Columns() []string {
+	columns := make([]string, len(r.it.Schema))
+	for i, field := range r.it.Schema {
+		columns[i] = field.Name
+	}
+	return columns
+}
+
+// Close implements the driver.Rows interface
+func (r *rows) Close() error {
+	r.done = true
+	return nil
+}
+
+// Next implements the driver.Rows interface
+func (r *rows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+
+	for i, value := range r.next {
+		dest[i] = convertValue(r.it.Schema[i], value)
+	}
+
+	r.next = nil
+	err := r.it.Next(&r.next)
+	if err == iterator.Done {
+		r.done = true
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// convertValue converts the values returned by the BigQuery client
+// into the types supported by database/sql, the nested and repeated
+// fields are returned as JSON so they can be scanned by `json` attributes.
+func convertValue(field *bigquery.FieldSchema, value bigquery.Value) driver.Value {
+	if field.Repeated || field.Type == bigquery.RecordFieldType {
+		if value == nil {
+			return nil
+		}
+		b, _ := json.Marshal(jsonValue(field, value))
+		return b
+	}
+
+	switch v := value.(type) {
+	case civil.Date:
+		return time.Date(v.Year, v.Month, v.Day, 0, 0, 0, 0, time.UTC)
+	case civil.DateTime:
+		return v.In(time.UTC)
+	case civil.Time:
+		return v.String()
+	case *big.Rat:
+		if field.Type == bigquery.BigNumericFieldType {
+			return bigquery.BigNumericString(v)
+		}
+		return bigquery.NumericString(v)
+	case nil, int64, float64, bool, string, []byte, time.Time:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func jsonValue(field *bigquery.FieldSchema, value bigquery.Value) interface{} {
+	if values, ok := value.([]bigquery.Value); ok && field.Repeated {
+		elemField := *field
+		elemField.Repeated = false
+
+		list := make([]interface{}, len(values))
+		for i, v := range values {
+			list[i] = jsonValue(&elemField, v)
+		}
+		return list
+	}
+
+	if values, ok := value.([]bigquery.Value); ok && field.Type == bigquery.RecordFieldType {
+		record := map[string]interface{}{}
+		for i, v := range values {
+			if i < len(field.Schema) {
+				record[field.Schema[i].Name] = jsonValue(field.Schema[i], v)
+			}
+		}
+		return record
+	}
+
+	if field.Type == bigquery.JSONFieldType {
+		if s, ok := value.(string); ok {
+			return json.RawMessage(s)
+		}
+	}
+
+	return convertValue(field, value)
+}
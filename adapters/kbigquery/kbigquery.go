@@ -0,0 +1,53 @@
+package kbigquery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/vingarcia/ksql"
+)
+
+// NewFromSQLDB builds a ksql.DB from a *sql.DB instance, the Insert and
+// InsertMany methods only use the Storage Write API if it was opened
+// with the "bigquery" driver of this package.
+func NewFromSQLDB(db *sql.DB, opts ...ksql.Option) (ksql.DB, error) {
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "bigquery", opts...)
+}
+
+// SQLDBFromDB returns the *sql.DB used by a ksql.DB built by this package,
+// an error is returned if the ksql.DB is using a transaction or another adapter.
+func SQLDBFromDB(db ksql.DB) (*sql.DB, error) {
+	sqlDB, ok := db.Unwrap().(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("kbigquery: expected the ksql.DB to be using a *sql.DB but got: %T", db.Unwrap())
+	}
+	return sqlDB, nil
+}
+
+// New instantiates a new KissSQL client using the "bigquery" driver,
+// the connection string has the format:
+//
+//	bigquery://project/dataset?location=US&credentials_file=/path/to/key.json
+//
+// The queries are run as BigQuery jobs while the Insert and InsertMany
+// methods stream the records with the Storage Write API, except inside
+// transactions. Since BigQuery tables have no primary keys the Patch,
+// Delete, UpdateMany and Upsert methods return ksql.ErrNotSupported.
+func New(
+	ctx context.Context,
+	connectionString string,
+	config ksql.Config,
+	opts ...ksql.Option,
+) (ksql.DB, error) {
+	config.SetDefaultValues()
+
+	db, err := sql.Open("bigquery", connectionString)
+	if err != nil {
+		return ksql.DB{}, err
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "bigquery", opts...)
+}
@@ -0,0 +1,260 @@
+package kbigquery
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestParseDSN(t *testing.T) {
+	t.Run("should parse the project, dataset and location", func(t *testing.T) {
+		cfg, err := parseDSN("bigquery://fake-project/fake_dataset?location=US&credentials_file=/fake/key.json")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if cfg.projectID != "fake-project" || cfg.datasetID != "fake_dataset" || cfg.location != "US" {
+			t.Errorf("unexpected config: %#v", cfg)
+		}
+		if len(cfg.options) != 1 {
+			t.Errorf("expected the credentials option to be set but got %d options", len(cfg.options))
+		}
+	})
+
+	t.Run("should reject invalid connection strings", func(t *testing.T) {
+		for _, dsn := range []string{
+			"fake-project/fake_dataset",
+			"postgres://fake-project/fake_dataset",
+			"bigquery://fake-project/fake_dataset/extra",
+			"bigquery://fake-project/fake_dataset?fake_param=1",
+		} {
+			_, err := parseDSN(dsn)
+			if err == nil {
+				t.Errorf("expected an error for the connection string: %s", dsn)
+			}
+		}
+	})
+}
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		desc     string
+		field    *bigquery.FieldSchema
+		value    bigquery.Value
+		expected driver.Value
+	}{
+		{
+			desc:     "integers",
+			field:    &bigquery.FieldSchema{Type: bigquery.IntegerFieldType},
+			value:    int64(42),
+			expected: int64(42),
+		},
+		{
+			desc:     "dates",
+			field:    &bigquery.FieldSchema{Type: bigquery.DateFieldType},
+			value:    civil.Date{Year: 2024, Month: 1, Day: 2},
+			expected: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "numerics",
+			field:    &bigquery.FieldSchema{Type: bigquery.NumericFieldType},
+			value:    big.NewRat(3, 2),
+			expected: "1.500000000",
+		},
+		{
+			desc:  "records",
+			field: &bigquery.FieldSchema{Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "city", Type: bigquery.StringFieldType}}},
+			value: []bigquery.Value{"fake-city"},
+			// The nested fields are returned as JSON:
+			expected: []byte(`{"city":"fake-city"}`),
+		},
+		{
+			desc:     "repeated fields",
+			field:    &bigquery.FieldSchema{Type: bigquery.IntegerFieldType, Repeated: true},
+			value:    []bigquery.Value{int64(1), int64(2)},
+			expected: []byte(`[1,2]`),
+		},
+		{
+			desc:     "nulls",
+			field:    &bigquery.FieldSchema{Type: bigquery.StringFieldType},
+			value:    nil,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got := convertValue(test.field, test.value)
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %#v but got %#v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestCheckNamedValue(t *testing.T) {
+	c := &conn{}
+
+	age := 30
+	date := civil.Date{Year: 2024, Month: 1, Day: 2}
+	for _, test := range []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{value: &age, expected: int64(30)},
+		{value: uint32(7), expected: int64(7)},
+		{value: date, expected: date},
+		{value: bigquery.NullInt64{}, expected: bigquery.NullInt64{}},
+	} {
+		nv := driver.NamedValue{Ordinal: 1, Value: test.value}
+		if err := c.CheckNamedValue(&nv); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(nv.Value, test.expected) {
+			t.Errorf("expected %#v but got %#v", test.expected, nv.Value)
+		}
+	}
+}
+
+func TestEncodeRow(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "id", Type: bigquery.IntegerFieldType},
+		{Name: "name", Type: bigquery.StringFieldType},
+		{Name: "score", Type: bigquery.FloatFieldType},
+		{Name: "created_at", Type: bigquery.TimestampFieldType},
+		{Name: "birthday", Type: bigquery.DateFieldType},
+		{Name: "address", Type: bigquery.JSONFieldType},
+		{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+	}
+
+	t.Run("should encode the values with the types of the columns", func(t *testing.T) {
+		fields, descriptor, err := buildDescriptor(schema, []string{"ID", "name", "score", "created_at", "birthday", "address"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		id := 42
+		createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		data, err := encodeRow(fields, descriptor, []interface{}{
+			&id, nil, 1, createdAt, time.Date(2000, 5, 6, 0, 0, 0, 0, time.UTC), fakeValuer(`{"city":"fake-city"}`),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		message := dynamicpb.NewMessage(descriptor)
+		if err := proto.Unmarshal(data, message); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		protoFields := descriptor.Fields()
+		if got := message.Get(protoFields.ByName("id")).Int(); got != 42 {
+			t.Errorf("expected id 42 but got %d", got)
+		}
+		if message.Has(protoFields.ByName("name")) {
+			t.Errorf("expected the nil value to be left unset")
+		}
+		if got := message.Get(protoFields.ByName("score")).Float(); got != 1 {
+			t.Errorf("expected score 1 but got %f", got)
+		}
+		if got := message.Get(protoFields.ByName("created_at")).Int(); got != createdAt.UnixMicro() {
+			t.Errorf("expected the timestamp in microseconds but got %d", got)
+		}
+		if got := message.Get(protoFields.ByName("birthday")).String(); got != "2000-05-06" {
+			t.Errorf("expected the date as a string but got %s", got)
+		}
+		if got := message.Get(protoFields.ByName("address")).String(); got != `{"city":"fake-city"}` {
+			t.Errorf("expected the JSON as a string but got %s", got)
+		}
+	})
+
+	t.Run("should report invalid values", func(t *testing.T) {
+		fields, descriptor, err := buildDescriptor(schema, []string{"id"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		_, err = encodeRow(fields, descriptor, []interface{}{"fake-id"})
+		if err == nil || !strings.Contains(err.Error(), "`id`") {
+			t.Errorf("expected an error about the id column but got: %v", err)
+		}
+	})
+
+	t.Run("should reject unknown and unsupported columns", func(t *testing.T) {
+		for _, column := range []string{"fake_column", "tags"} {
+			_, _, err := buildDescriptor(schema, []string{column})
+			if err == nil || !strings.Contains(err.Error(), column) {
+				t.Errorf("expected an error about the column %s but got: %v", column, err)
+			}
+		}
+	})
+}
+
+type fakeValuer string
+
+func (f fakeValuer) Value() (driver.Value, error) {
+	return string(f), nil
+}
+
+func TestSQLAdapter(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := bigquery.NewClient(ctx, "fake-project", option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	t.Run("should find the appender of the bigquery driver", func(t *testing.T) {
+		db := sql.OpenDB(newConnector(client, "fake_dataset", nil))
+
+		adapter := NewSQLAdapter(db)
+		if adapter.appender == nil {
+			t.Fatalf("expected the appender to be set")
+		}
+
+		for _, test := range []struct {
+			tableName string
+			expected  []string
+		}{
+			{tableName: "users", expected: []string{"fake-project", "fake_dataset", "users"}},
+			{tableName: "`analytics`.`users`", expected: []string{"fake-project", "analytics", "users"}},
+			{tableName: "other-project.analytics.users", expected: []string{"other-project", "analytics", "users"}},
+		} {
+			projectID, datasetID, tableID, err := adapter.appender.parseTableName(test.tableName)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := []string{projectID, datasetID, tableID}; !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %v but got %v", test.expected, got)
+			}
+		}
+	})
+
+	t.Run("should require a dataset for unqualified tables", func(t *testing.T) {
+		appender := newConnector(client, "", nil).appender
+
+		_, _, _, err := appender.parseTableName("users")
+		if err == nil {
+			t.Errorf("expected an error for the unqualified table")
+		}
+	})
+
+	t.Run("should fail to append rows with other drivers", func(t *testing.T) {
+		adapter := SQLAdapter{}
+
+		err := adapter.AppendRows(ctx, "users", []string{"id"}, [][]interface{}{{1}})
+		if err == nil || !strings.Contains(err.Error(), "bigquery driver") {
+			t.Errorf("expected an error about the driver but got: %v", err)
+		}
+	})
+}
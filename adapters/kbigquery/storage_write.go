@@ -0,0 +1,350 @@
+package kbigquery
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// rowAppender sends rows to the default stream of the tables
+// using the Storage Write API, the rows are encoded as protocol
+// buffer messages built from the schema of the tables.
+type rowAppender struct {
+	client        *bigquery.Client
+	datasetID     string
+	clientOptions []option.ClientOption
+
+	mu     sync.Mutex
+	writer *managedwriter.Client
+
+	// schemas caches the schema of the tables by their path
+	schemas sync.Map
+}
+
+func (a *rowAppender) appendRows(ctx context.Context, tableName string, columns []string, rows [][]interface{}) error {
+	if a == nil {
+		return fmt.Errorf("kbigquery: can't append rows: the *sql.DB was not opened with the bigquery driver")
+	}
+
+	projectID, datasetID, tableID, err := a.parseTableName(tableName)
+	if err != nil {
+		return err
+	}
+	tablePath := managedwriter.TableParentFromParts(projectID, datasetID, tableID)
+
+	schema, err := a.tableSchema(ctx, tablePath, a.client.DatasetInProject(projectID, datasetID).Table(tableID))
+	if err != nil {
+		return err
+	}
+
+	fields, descriptor, err := buildDescriptor(schema, columns)
+	if err != nil {
+		return err
+	}
+
+	data := make([][]byte, len(rows))
+	for i, row := range rows {
+		data[i], err = encodeRow(fields, descriptor, row)
+		if err != nil {
+			return fmt.Errorf("kbigquery: error encoding row %d: %w", i, err)
+		}
+	}
+
+	writer, err := a.writerClient()
+	if err != nil {
+		return err
+	}
+
+	// The columns that were not sent receive their default values,
+	// while the ones that were sent without a value are set to NULL:
+	nullColumns := map[string]storagepb.AppendRowsRequest_MissingValueInterpretation{}
+	for _, field := range fields {
+		nullColumns[field.Name] = storagepb.AppendRowsRequest_NULL_VALUE
+	}
+
+	stream, err := writer.NewManagedStream(ctx,
+		managedwriter.WithDestinationTable(tablePath),
+		managedwriter.WithType(managedwriter.DefaultStream),
+		managedwriter.WithSchemaDescriptor(protodesc.ToDescriptorProto(descriptor)),
+		managedwriter.WithDefaultMissingValueInterpretation(storagepb.AppendRowsRequest_DEFAULT_VALUE),
+		managedwriter.WithMissingValueInterpretations(nullColumns),
+	)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	result, err := stream.AppendRows(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	resp, err := result.FullResponse(ctx)
+	if rowErrors := resp.GetRowErrors(); len(rowErrors) > 0 {
+		return fmt.Errorf("kbigquery: error appending row %d: %s", rowErrors[0].GetIndex(), rowErrors[0].GetMessage())
+	}
+	if err != nil {
+		// The schema might have changed, so it is loaded again on the next call:
+		a.schemas.Delete(tablePath)
+		return err
+	}
+
+	return nil
+}
+
+// parseTableName splits table names of the formats `table`,
+// `dataset.table` and `project.dataset.table`.
+func (a *rowAppender) parseTableName(tableName string) (projectID, datasetID, tableID string, err error) {
+	parts := strings.Split(strings.ReplaceAll(tableName, "`", ""), ".")
+	switch len(parts) {
+	case 1:
+		projectID, datasetID, tableID = a.client.Project(), a.datasetID, parts[0]
+	case 2:
+		projectID, datasetID, tableID = a.client.Project(), parts[0], parts[1]
+	case 3:
+		projectID, datasetID, tableID = parts[0], parts[1], parts[2]
+	}
+
+	if projectID == "" || datasetID == "" || tableID == "" {
+		return "", "", "", fmt.Errorf("kbigquery: can't find the dataset of the table `%s`, either qualify it or set the default dataset on the connection string", tableName)
+	}
+
+	return projectID, datasetID, tableID, nil
+}
+
+func (a *rowAppender) tableSchema(ctx context.Context, tablePath string, table *bigquery.Table) (bigquery.Schema, error) {
+	if schema, found := a.schemas.Load(tablePath); found {
+		return schema.(bigquery.Schema), nil
+	}
+
+	meta, err := table.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.schemas.Store(tablePath, meta.Schema)
+	return meta.Schema, nil
+}
+
+// writerClient creates the Storage Write API client on the first append,
+// so the ones that only run queries never open its gRPC connections.
+func (a *rowAppender) writerClient() (*managedwriter.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.writer == nil {
+		// The context is only used for creating the connections
+		// so it shouldn't be canceled with the appends:
+		writer, err := managedwriter.NewClient(context.Background(), a.client.Project(), a.clientOptions...)
+		if err != nil {
+			return nil, err
+		}
+		a.writer = writer
+	}
+
+	return a.writer, nil
+}
+
+func (a *rowAppender) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.writer == nil {
+		return nil
+	}
+	return a.writer.Close()
+}
+
+// buildDescriptor builds a message with one field for each of the input columns,
+// the TIMESTAMP columns are sent as microseconds and the columns of types with
+// no direct protocol buffer equivalent, e.g. NUMERIC, DATE or JSON, as strings.
+func buildDescriptor(schema bigquery.Schema, columns []string) ([]*bigquery.FieldSchema, protoreflect.MessageDescriptor, error) {
+	message := &descriptorpb.DescriptorProto{Name: proto.String("Row")}
+	fields := make([]*bigquery.FieldSchema, len(columns))
+	for i, column := range columns {
+		for _, field := range schema {
+			// Column names are case insensitive on BigQuery:
+			if strings.EqualFold(field.Name, column) {
+				fields[i] = field
+			}
+		}
+
+		field := fields[i]
+		if field == nil {
+			return nil, nil, fmt.Errorf("kbigquery: column `%s` not found on the schema of the table", column)
+		}
+		if field.Repeated || field.Type == bigquery.RecordFieldType || field.Type == bigquery.RangeFieldType {
+			return nil, nil, fmt.Errorf("kbigquery: can't append rows to column `%s` of type %s", column, field.Type)
+		}
+
+		message.Field = append(message.Field, &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(field.Name),
+			Number: proto.Int32(int32(i + 1)),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   protoType(field.Type).Enum(),
+		})
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("kbigquery_row.proto"),
+		Syntax:      proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{message},
+	}, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("kbigquery: error building the row descriptor: %w", err)
+	}
+
+	return fields, file.Messages().Get(0), nil
+}
+
+func protoType(fieldType bigquery.FieldType) descriptorpb.FieldDescriptorProto_Type {
+	switch fieldType {
+	case bigquery.IntegerFieldType, bigquery.TimestampFieldType:
+		return descriptorpb.FieldDescriptorProto_TYPE_INT64
+	case bigquery.FloatFieldType:
+		return descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+	case bigquery.BooleanFieldType:
+		return descriptorpb.FieldDescriptorProto_TYPE_BOOL
+	case bigquery.BytesFieldType:
+		return descriptorpb.FieldDescriptorProto_TYPE_BYTES
+	default:
+		return descriptorpb.FieldDescriptorProto_TYPE_STRING
+	}
+}
+
+// encodeRow serializes the values of a row, the nil values are left
+// unset so they are stored as NULL.
+func encodeRow(fields []*bigquery.FieldSchema, descriptor protoreflect.MessageDescriptor, row []interface{}) ([]byte, error) {
+	if len(row) != len(fields) {
+		return nil, fmt.Errorf("expected %d values but got %d", len(fields), len(row))
+	}
+
+	message := dynamicpb.NewMessage(descriptor)
+	for i, value := range row {
+		if valuer, ok := value.(driver.Valuer); ok {
+			var err error
+			value, err = valuer.Value()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		v := reflect.ValueOf(value)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		if !v.IsValid() || v.Kind() == reflect.Ptr {
+			continue
+		}
+
+		protoValue, err := convertToProto(fields[i], v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for column `%s`: %w", fields[i].Name, err)
+		}
+		message.Set(descriptor.Fields().Get(i), protoValue)
+	}
+
+	return proto.Marshal(message)
+}
+
+func convertToProto(field *bigquery.FieldSchema, v reflect.Value) (protoreflect.Value, error) {
+	value := v.Interface()
+	switch field.Type {
+	case bigquery.IntegerFieldType:
+		switch {
+		case v.CanInt():
+			return protoreflect.ValueOfInt64(v.Int()), nil
+		case v.CanUint() && v.Uint() <= 1<<63-1:
+			return protoreflect.ValueOfInt64(int64(v.Uint())), nil
+		}
+	case bigquery.FloatFieldType:
+		switch {
+		case v.CanFloat():
+			return protoreflect.ValueOfFloat64(v.Float()), nil
+		case v.CanInt():
+			return protoreflect.ValueOfFloat64(float64(v.Int())), nil
+		case v.CanUint():
+			return protoreflect.ValueOfFloat64(float64(v.Uint())), nil
+		}
+	case bigquery.BooleanFieldType:
+		if v.Kind() == reflect.Bool {
+			return protoreflect.ValueOfBool(v.Bool()), nil
+		}
+	case bigquery.BytesFieldType:
+		switch {
+		case v.Kind() == reflect.String:
+			return protoreflect.ValueOfBytes([]byte(v.String())), nil
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			return protoreflect.ValueOfBytes(v.Bytes()), nil
+		}
+	case bigquery.TimestampFieldType:
+		if t, ok := value.(time.Time); ok {
+			return protoreflect.ValueOfInt64(t.UnixMicro()), nil
+		}
+	default:
+		s, ok := formatString(field.Type, v)
+		if ok {
+			return protoreflect.ValueOfString(s), nil
+		}
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("can't convert %T to %s", value, field.Type)
+}
+
+// formatString formats the values sent as strings in the
+// formats accepted by BigQuery for each of the column types.
+func formatString(fieldType bigquery.FieldType, v reflect.Value) (string, bool) {
+	switch value := v.Interface().(type) {
+	case time.Time:
+		switch fieldType {
+		case bigquery.DateFieldType:
+			return value.Format("2006-01-02"), true
+		case bigquery.DateTimeFieldType:
+			return value.Format("2006-01-02 15:04:05.999999"), true
+		case bigquery.TimeFieldType:
+			return value.Format("15:04:05.999999"), true
+		default:
+			return value.Format(time.RFC3339Nano), true
+		}
+	case big.Rat:
+		if fieldType == bigquery.BigNumericFieldType {
+			return bigquery.BigNumericString(&value), true
+		}
+		return bigquery.NumericString(&value), true
+	case []byte:
+		return string(value), true
+	case fmt.Stringer:
+		return value.String(), true
+	}
+
+	switch {
+	case v.Kind() == reflect.String:
+		return v.String(), true
+	case v.CanInt():
+		return strconv.FormatInt(v.Int(), 10), true
+	case v.CanUint():
+		return strconv.FormatUint(v.Uint(), 10), true
+	case v.CanFloat():
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), true
+	case v.Kind() == reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	}
+
+	return "", false
+}
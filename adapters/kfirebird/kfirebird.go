@@ -0,0 +1,53 @@
+package kfirebird
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/vingarcia/ksql"
+
+	// This is imported here so the user don't
+	// have to worry about it when he uses it.
+	_ "github.com/nakagami/firebirdsql"
+)
+
+// NewFromSQLDB builds a ksql.DB from a *sql.DB instance
+func NewFromSQLDB(db *sql.DB, opts ...ksql.Option) (ksql.DB, error) {
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "firebird", opts...)
+}
+
+// SQLDBFromDB returns the *sql.DB used by a ksql.DB built by this package,
+// an error is returned if the ksql.DB is using a transaction or another adapter.
+func SQLDBFromDB(db ksql.DB) (*sql.DB, error) {
+	sqlDB, ok := db.Unwrap().(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("kfirebird: expected the ksql.DB to be using a *sql.DB but got: %T", db.Unwrap())
+	}
+	return sqlDB, nil
+}
+
+// New instantiates a new KissSQL client using the "firebirdsql" driver,
+// the connection string has the format:
+//
+//	user:password@host:port/path/to/database.fdb
+func New(
+	_ context.Context,
+	connectionString string,
+	config ksql.Config,
+	opts ...ksql.Option,
+) (ksql.DB, error) {
+	config.SetDefaultValues()
+
+	db, err := sql.Open("firebirdsql", connectionString)
+	if err != nil {
+		return ksql.DB{}, err
+	}
+	if err = db.Ping(); err != nil {
+		return ksql.DB{}, err
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "firebird", opts...)
+}
@@ -0,0 +1,199 @@
+package kfirebird
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest"
+	"github.com/ory/dockertest/docker"
+	"github.com/vingarcia/ksql"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type user struct {
+	ID   uint   `ksql:"id"`
+	Name string `ksql:"name"`
+	Age  int    `ksql:"age"`
+}
+
+var usersTable = ksql.NewTable("users")
+
+// The shared test suite of `ksql.RunTestsForAdapter()` writes its queries with
+// unquoted identifiers, which Firebird folds to uppercase and therefore can't
+// match the quoted lowercase identifiers generated by the firebird dialect,
+// so this adapter runs the health check of the suite and the tests below.
+func TestAdapter(t *testing.T) {
+	firebirdURL, closeFirebird := startFirebirdDB("ksql")
+	defer closeFirebird()
+
+	newDBAdapter := func(t *testing.T) (ksql.DBAdapter, io.Closer) {
+		db, err := sql.Open("firebirdsql", firebirdURL)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return NewSQLAdapter(db), db
+	}
+
+	ksql.HealthCheckTest(t, "firebird", firebirdURL, newDBAdapter)
+
+	t.Run("should insert, find, patch and delete records", func(t *testing.T) {
+		ctx := context.Background()
+		db := newTestDB(t, firebirdURL)
+
+		u := user{Name: "Bia", Age: 22}
+		err := db.Insert(ctx, usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertNotEqual(t, u.ID, uint(0))
+
+		var found user
+		err = db.Find(ctx, usersTable, &found, u.ID)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, found, u)
+
+		err = db.Patch(ctx, usersTable, &struct {
+			ID  uint `ksql:"id"`
+			Age int  `ksql:"age"`
+		}{ID: u.ID, Age: 23})
+		tt.AssertNoErr(t, err)
+
+		err = db.QueryOne(ctx, &found, `FROM "users" WHERE "id" = ?`, u.ID)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, found, user{ID: u.ID, Name: "Bia", Age: 23})
+
+		err = db.Delete(ctx, usersTable, u.ID)
+		tt.AssertNoErr(t, err)
+
+		err = db.Find(ctx, usersTable, &found, u.ID)
+		tt.AssertEqual(t, err, ksql.ErrRecordNotFound)
+	})
+
+	t.Run("should query the records in chunks", func(t *testing.T) {
+		ctx := context.Background()
+		db := newTestDB(t, firebirdURL)
+
+		for _, name := range []string{"Ana", "Bia", "Cris"} {
+			err := db.Insert(ctx, usersTable, &user{Name: name})
+			tt.AssertNoErr(t, err)
+		}
+
+		var names []string
+		err := db.QueryChunks(ctx, ksql.ChunkParser{
+			Query:     `FROM "users" ORDER BY "name"`,
+			ChunkSize: 2,
+			ForEachChunk: func(users []user) error {
+				for _, u := range users {
+					names = append(names, u.Name)
+				}
+				return nil
+			},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, names, []string{"Ana", "Bia", "Cris"})
+	})
+
+	t.Run("should rollback the transactions that fail", func(t *testing.T) {
+		ctx := context.Background()
+		db := newTestDB(t, firebirdURL)
+
+		u := user{Name: "Bia"}
+		err := db.Transaction(ctx, func(db ksql.Provider) error {
+			err := db.Insert(ctx, usersTable, &u)
+			tt.AssertNoErr(t, err)
+			return fmt.Errorf("fake-error")
+		})
+		tt.AssertErrContains(t, err, "fake-error")
+
+		var found user
+		err = db.Find(ctx, usersTable, &found, u.ID)
+		tt.AssertEqual(t, err, ksql.ErrRecordNotFound)
+	})
+}
+
+// newTestDB recreates the users table with quoted identifiers
+// and returns a ksql.DB connected to the test database.
+func newTestDB(t *testing.T, firebirdURL string) ksql.DB {
+	sqlDB, err := sql.Open("firebirdsql", firebirdURL)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	sqlDB.Exec(`DROP TABLE "users"`)
+	_, err = sqlDB.Exec(`CREATE TABLE "users" (
+		"id" INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		"name" VARCHAR(50),
+		"age" INTEGER
+	)`)
+	if err != nil {
+		t.Fatal("could not create test table!, reason:", err.Error())
+	}
+
+	db, err := NewFromSQLDB(sqlDB)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return db
+}
+
+func startFirebirdDB(dbName string) (databaseURL string, closer func()) {
+	// uses a sensible default on windows (tcp/http) and linux/osx (socket)
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	// pulls an image, creates a container based on it and runs it
+	resource, err := pool.RunWithOptions(
+		&dockertest.RunOptions{
+			Repository: "firebirdsql/firebird",
+			Tag:        "5",
+			Env: []string{
+				"FIREBIRD_ROOT_PASSWORD=firebird",
+				"FIREBIRD_USER=ksql",
+				"FIREBIRD_PASSWORD=ksql",
+				"FIREBIRD_DATABASE=" + dbName + ".fdb",
+			},
+		},
+		func(config *docker.HostConfig) {
+			// set AutoRemove to true so that stopped container goes away by itself
+			config.AutoRemove = true
+			config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+		},
+	)
+	if err != nil {
+		log.Fatalf("Could not start resource: %s", err)
+	}
+
+	hostAndPort := resource.GetHostPort("3050/tcp")
+	databaseUrl := fmt.Sprintf("ksql:ksql@%s//var/lib/firebird/data/%s.fdb", hostAndPort, dbName)
+
+	fmt.Println("Connecting to firebird on url: ", databaseUrl)
+
+	resource.Expire(40) // Tell docker to hard kill the container in 40 seconds
+
+	var sqlDB *sql.DB
+	// exponential backoff-retry, because the application in the container might not be ready to accept connections yet
+	pool.MaxWait = 10 * time.Second
+	err = pool.Retry(func() error {
+		sqlDB, err = sql.Open("firebirdsql", databaseUrl)
+		if err != nil {
+			return err
+		}
+		return sqlDB.Ping()
+	})
+	if err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+	sqlDB.Close()
+
+	return databaseUrl, func() {
+		if err := pool.Purge(resource); err != nil {
+			log.Fatalf("Could not purge resource: %s", err)
+		}
+	}
+}
@@ -20,3 +20,5 @@ require (
 	golang.org/x/sys v0.0.0-20220315194320-039c03cc5b86 // indirect
 	gotest.tools v2.2.0+incompatible // indirect
 )
+
+replace github.com/vingarcia/ksql => ../../
@@ -3,6 +3,7 @@ package kmysql
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/vingarcia/ksql"
 
@@ -12,15 +13,49 @@ import (
 )
 
 // NewFromSQLDB builds a ksql.DB from a *sql.DB instance
-func NewFromSQLDB(db *sql.DB) (ksql.DB, error) {
-	return ksql.NewWithAdapter(NewSQLAdapter(db), "mysql")
+func NewFromSQLDB(db *sql.DB, opts ...ksql.Option) (ksql.DB, error) {
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "mysql", opts...)
+}
+
+// SQLDBFromDB returns the *sql.DB used by a ksql.DB built by this package,
+// an error is returned if the ksql.DB is using a transaction or another adapter.
+func SQLDBFromDB(db ksql.DB) (*sql.DB, error) {
+	sqlDB, ok := db.Unwrap().(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("kmysql: expected the ksql.DB to be using a *sql.DB but got: %T", db.Unwrap())
+	}
+	return sqlDB, nil
 }
 
 // New instantiates a new KissSQL client using the "mysql" driver
 func New(
+	ctx context.Context,
+	connectionString string,
+	config ksql.Config,
+	opts ...ksql.Option,
+) (ksql.DB, error) {
+	return newWithDialect(ctx, connectionString, config, "mysql", opts)
+}
+
+// NewTiDB instantiates a new KissSQL client for TiDB using the "mysql"
+// driver and the "tidb" dialect, which allows explicit values on
+// AUTO_RANDOM columns with the ksql.IdentityInsert option and
+// accepts the ksql.WithTiDBBatchHints option.
+func NewTiDB(
+	ctx context.Context,
+	connectionString string,
+	config ksql.Config,
+	opts ...ksql.Option,
+) (ksql.DB, error) {
+	return newWithDialect(ctx, connectionString, config, "tidb", opts)
+}
+
+func newWithDialect(
 	_ context.Context,
 	connectionString string,
 	config ksql.Config,
+	dialectName string,
+	opts []ksql.Option,
 ) (ksql.DB, error) {
 	config.SetDefaultValues()
 
@@ -34,5 +69,5 @@ func New(
 
 	db.SetMaxOpenConns(config.MaxOpenConns)
 
-	return ksql.NewWithAdapter(NewSQLAdapter(db), "mysql")
+	return ksql.NewWithAdapter(NewSQLAdapter(db), dialectName, opts...)
 }
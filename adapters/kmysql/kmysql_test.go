@@ -22,7 +22,7 @@ func TestAdapter(t *testing.T) {
 		if err != nil {
 			t.Fatal(err.Error())
 		}
-		return SQLAdapter{db}, db
+		return NewSQLAdapter(db), db
 	})
 }
 
@@ -19,3 +19,5 @@ require (
 	github.com/vingarcia/ksql v1.4.6
 	gotest.tools v2.2.0+incompatible // indirect
 )
+
+replace github.com/vingarcia/ksql => ../../
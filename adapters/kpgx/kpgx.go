@@ -2,6 +2,7 @@ package kpgx
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/vingarcia/ksql"
@@ -12,8 +13,43 @@ import (
 )
 
 // NewFromPgxPool builds a ksql.DB from a *pgxpool.Pool instance
-func NewFromPgxPool(pool *pgxpool.Pool) (db ksql.DB, err error) {
-	return ksql.NewWithAdapter(NewPGXAdapter(pool), "postgres")
+func NewFromPgxPool(pool *pgxpool.Pool, opts ...ksql.Option) (db ksql.DB, err error) {
+	return ksql.NewWithAdapter(NewPGXAdapter(pool), "postgres", opts...)
+}
+
+// PoolFromDB returns the *pgxpool.Pool used by a ksql.DB built by this package,
+// an error is returned if the ksql.DB is using a transaction or another adapter.
+func PoolFromDB(db ksql.DB) (*pgxpool.Pool, error) {
+	pool, ok := db.Unwrap().(*pgxpool.Pool)
+	if !ok {
+		return nil, fmt.Errorf("kpgx: expected the ksql.DB to be using a *pgxpool.Pool but got: %T", db.Unwrap())
+	}
+	return pool, nil
+}
+
+// ConfigureForTransactionPooling changes the config of a pool so it works
+// behind proxies that pool connections per transaction, e.g. PgBouncer with
+// `pool_mode = transaction`, where consecutive statements might run on
+// different server connections, breaking the prepared statements.
+//
+// It disables the statement cache of pgx and makes it use the simple
+// protocol, so each query is sent in a single message. The adapters
+// created for pools with this config also skip the statements passed
+// to `ksql.DB.Prepare()` and return ksql.ErrNotSupported from Listen,
+// since LISTEN depends on the state of the server connection.
+//
+// The New function calls it when the TransactionPooling
+// attribute of the ksql.Config is set.
+func ConfigureForTransactionPooling(pgxConf *pgxpool.Config) {
+	pgxConf.ConnConfig.BuildStatementCache = nil
+	pgxConf.ConnConfig.PreferSimpleProtocol = true
+}
+
+// usesTransactionPooling checks if the pool was
+// configured by ConfigureForTransactionPooling
+func usesTransactionPooling(pool *pgxpool.Pool) bool {
+	connConfig := pool.Config().ConnConfig
+	return connConfig.BuildStatementCache == nil && connConfig.PreferSimpleProtocol
 }
 
 // New instantiates a new ksql.Client using pgx as the backend driver
@@ -21,6 +57,7 @@ func New(
 	ctx context.Context,
 	connectionString string,
 	config ksql.Config,
+	opts ...ksql.Option,
 ) (db ksql.DB, err error) {
 	config.SetDefaultValues()
 
@@ -30,6 +67,12 @@ func New(
 	}
 
 	pgxConf.MaxConns = int32(config.MaxOpenConns)
+	if config.TransactionPooling {
+		ConfigureForTransactionPooling(pgxConf)
+	}
+	if config.ApplicationName != "" {
+		pgxConf.ConnConfig.RuntimeParams["application_name"] = config.ApplicationName
+	}
 
 	pool, err := pgxpool.ConnectConfig(ctx, pgxConf)
 	if err != nil {
@@ -39,6 +82,6 @@ func New(
 		return ksql.DB{}, err
 	}
 
-	db, err = ksql.NewWithAdapter(NewPGXAdapter(pool), "postgres")
+	db, err = ksql.NewWithAdapter(NewPGXAdapter(pool), "postgres", opts...)
 	return db, err
 }
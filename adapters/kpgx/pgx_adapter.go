@@ -13,12 +13,15 @@ import (
 // PGXAdapter adapts the sql.DB type to be compatible with the `DBAdapter` interface
 type PGXAdapter struct {
 	db *pgxpool.Pool
+
+	transactionPooling bool
 }
 
 // NewPGXAdapter instantiates a new pgx adapter
 func NewPGXAdapter(db *pgxpool.Pool) PGXAdapter {
 	return PGXAdapter{
-		db: db,
+		db:                 db,
+		transactionPooling: usesTransactionPooling(db),
 	}
 }
 
@@ -38,7 +41,7 @@ func (p PGXAdapter) QueryContext(ctx context.Context, query string, args ...inte
 
 // BeginTx implements the Tx interface
 func (p PGXAdapter) BeginTx(ctx context.Context) (ksql.Tx, error) {
-	tx, err := p.db.Begin(ctx)
+	tx, err := p.db.BeginTx(ctx, txOptions(ctx))
 	return PGXTx{tx}, err
 }
 
@@ -48,6 +51,63 @@ func (p PGXAdapter) Close() error {
 	return nil
 }
 
+// Unwrap implements the ksql.Unwrapper interface
+func (p PGXAdapter) Unwrap() interface{} {
+	return p.db
+}
+
+// PoolStats implements the ksql.PoolStatsReporter interface
+func (p PGXAdapter) PoolStats() ksql.PoolStats {
+	stats := p.db.Stat()
+	return ksql.PoolStats{
+		MaxOpenConns: int(stats.MaxConns()),
+		OpenConns:    int(stats.TotalConns()),
+		InUse:        int(stats.AcquiredConns()),
+		Idle:         int(stats.IdleConns()),
+		WaitCount:    stats.EmptyAcquireCount(),
+		WaitDuration: stats.AcquireDuration(),
+	}
+}
+
+// AcquireConn implements the ConnAcquirer interface
+func (p PGXAdapter) AcquireConn(ctx context.Context) (ksql.Conn, error) {
+	conn, err := p.db.Acquire(ctx)
+	return PGXConn{conn}, err
+}
+
+// Prepare implements the ksql.StatementPreparer interface
+//
+// The statements are prepared on all idle connections of the pool, using the
+// query as their names so that pgx uses them for queries with the same SQL.
+// Connections opened later prepare the queries on their first use thanks to
+// the statement cache of pgx.
+//
+// If the pool is configured for transaction pooling the queries are not
+// prepared, since they might be executed on other server connections.
+func (p PGXAdapter) Prepare(ctx context.Context, queries ...string) error {
+	if p.transactionPooling {
+		return nil
+	}
+
+	conns := p.db.AcquireAllIdle(ctx)
+	defer func() {
+		for _, conn := range conns {
+			conn.Release()
+		}
+	}()
+
+	for _, conn := range conns {
+		for _, query := range queries {
+			_, err := conn.Conn().Prepare(ctx, query, query)
+			if err != nil {
+				return fmt.Errorf("error preparing query '%s': %w", query, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 // PGXResult is used to implement the DBAdapter interface and implements
 // the Result interface
 type PGXResult struct {
@@ -94,8 +154,60 @@ func (p PGXTx) Commit(ctx context.Context) error {
 	return p.tx.Commit(ctx)
 }
 
+// Unwrap implements the ksql.Unwrapper interface
+func (p PGXTx) Unwrap() interface{} {
+	return p.tx
+}
+
 var _ ksql.Tx = PGXTx{}
 
+// PGXConn is used to implement the DBAdapter interface and implements
+// the Conn interface
+type PGXConn struct {
+	conn *pgxpool.Conn
+}
+
+// ExecContext implements the Conn interface
+func (p PGXConn) ExecContext(ctx context.Context, query string, args ...interface{}) (ksql.Result, error) {
+	result, err := p.conn.Exec(ctx, query, args...)
+	return PGXResult{result}, err
+}
+
+// QueryContext implements the Conn interface
+func (p PGXConn) QueryContext(ctx context.Context, query string, args ...interface{}) (ksql.Rows, error) {
+	rows, err := p.conn.Query(ctx, query, args...)
+	return PGXRows{rows}, err
+}
+
+// BeginTx implements the Conn interface
+func (p PGXConn) BeginTx(ctx context.Context) (ksql.Tx, error) {
+	tx, err := p.conn.BeginTx(ctx, txOptions(ctx))
+	return PGXTx{tx}, err
+}
+
+// txOptions starts the transactions of the
+// ksql.DB.ReadOnly() Provider as read-only
+func txOptions(ctx context.Context) pgx.TxOptions {
+	if ksql.IsReadOnlyTx(ctx) {
+		return pgx.TxOptions{AccessMode: pgx.ReadOnly}
+	}
+	return pgx.TxOptions{}
+}
+
+// Close implements the Conn interface by
+// returning the connection to the pool
+func (p PGXConn) Close() error {
+	p.conn.Release()
+	return nil
+}
+
+// Unwrap implements the ksql.Unwrapper interface
+func (p PGXConn) Unwrap() interface{} {
+	return p.conn
+}
+
+var _ ksql.Conn = PGXConn{}
+
 // PGXRows implements the Rows interface and is used to help
 // the PGXAdapter to implement the DBAdapter interface.
 type PGXRows struct {
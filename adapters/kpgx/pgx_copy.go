@@ -0,0 +1,53 @@
+package kpgx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/vingarcia/ksql"
+)
+
+var (
+	_ ksql.CSVCopier = PGXAdapter{}
+	_ ksql.CSVCopier = PGXTx{}
+	_ ksql.CSVCopier = PGXConn{}
+)
+
+// CopyFromCSV implements the ksql.CSVCopier interface using the COPY command
+func (p PGXAdapter) CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+	conn, err := p.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	return copyFromCSV(ctx, conn.Conn().PgConn(), tableName, columns, r)
+}
+
+// CopyFromCSV implements the ksql.CSVCopier interface using the COPY command
+func (p PGXTx) CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+	return copyFromCSV(ctx, p.tx.Conn().PgConn(), tableName, columns, r)
+}
+
+// CopyFromCSV implements the ksql.CSVCopier interface using the COPY command
+func (p PGXConn) CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+	return copyFromCSV(ctx, p.conn.Conn().PgConn(), tableName, columns, r)
+}
+
+func copyFromCSV(ctx context.Context, conn *pgconn.PgConn, tableName string, columns []string, r io.Reader) error {
+	escapedColumns := make([]string, len(columns))
+	for i, column := range columns {
+		escapedColumns[i] = pgx.Identifier{column}.Sanitize()
+	}
+
+	_, err := conn.CopyFrom(ctx, r, fmt.Sprintf(
+		"COPY %s (%s) FROM STDIN WITH (FORMAT csv)",
+		pgx.Identifier(strings.Split(tableName, ".")).Sanitize(),
+		strings.Join(escapedColumns, ", "),
+	))
+	return err
+}
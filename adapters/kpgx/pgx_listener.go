@@ -0,0 +1,119 @@
+package kpgx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/vingarcia/ksql"
+)
+
+var _ ksql.Listener = PGXAdapter{}
+
+// These are the limits for the interval between reconnection attempts,
+// it starts with the min value and doubles on each failed attempt.
+const (
+	minReconnectInterval = 100 * time.Millisecond
+	maxReconnectInterval = 30 * time.Second
+)
+
+// Listen implements the ksql.Listener interface
+//
+// It acquires a dedicated connection from the pool for each channel,
+// so the pool should have enough connections for the listeners as
+// well as for the normal queries.
+//
+// If the connection is lost a new one is acquired from the pool
+// and the LISTEN command is executed again, notifications sent
+// while the client was reconnecting are lost.
+//
+// It returns ksql.ErrNotSupported if the pool is configured for transaction
+// pooling, since the proxy might not keep the server connection that ran
+// the LISTEN command for receiving the notifications.
+func (p PGXAdapter) Listen(ctx context.Context, channel string) (<-chan ksql.Notification, error) {
+	if p.transactionPooling {
+		return nil, fmt.Errorf("kpgx: %w: LISTEN can't be used with transaction pooling", ksql.ErrNotSupported)
+	}
+
+	conn, err := listenOnNewConn(ctx, p.db, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make(chan ksql.Notification)
+	go func() {
+		defer close(notifications)
+
+		interval := minReconnectInterval
+		for {
+			if conn != nil {
+				n, err := conn.Conn().WaitForNotification(ctx)
+				if err == nil {
+					interval = minReconnectInterval
+					select {
+					case notifications <- ksql.Notification{
+						PID:     n.PID,
+						Channel: n.Channel,
+						Payload: n.Payload,
+					}:
+					case <-ctx.Done():
+						releaseListenerConn(conn, channel)
+						return
+					}
+					continue
+				}
+
+				releaseListenerConn(conn, channel)
+				conn = nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+
+			conn, err = listenOnNewConn(ctx, p.db, channel)
+			if err != nil {
+				interval *= 2
+				if interval > maxReconnectInterval {
+					interval = maxReconnectInterval
+				}
+			}
+		}
+	}()
+
+	return notifications, nil
+}
+
+func listenOnNewConn(ctx context.Context, pool *pgxpool.Pool, channel string) (*pgxpool.Conn, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// releaseListenerConn makes sure the connection will stop listening
+// to the channel before returning it to the pool, and if that is not
+// possible the connection is closed so the pool won't reuse it.
+func releaseListenerConn(conn *pgxpool.Conn, channel string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := conn.Exec(ctx, "UNLISTEN "+pgx.Identifier{channel}.Sanitize())
+	if err != nil {
+		conn.Conn().Close(ctx)
+	}
+
+	conn.Release()
+}
@@ -0,0 +1,7 @@
+module github.com/vingarcia/ksql/adapters/kplanetscale
+
+go 1.14
+
+require github.com/vingarcia/ksql v1.4.6
+
+replace github.com/vingarcia/ksql => ../../
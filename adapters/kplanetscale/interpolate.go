@@ -0,0 +1,151 @@
+package kplanetscale
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// interpolateParams replaces the `?` placeholders of the query by the
+// escaped arguments, since the HTTP API doesn't support bind parameters.
+//
+// The placeholders inside strings, quoted identifiers and comments are ignored.
+func interpolateParams(query string, args []driver.NamedValue) (string, error) {
+	var b strings.Builder
+	var argIdx int
+	for i := 0; i < len(query); i++ {
+		if n := skipLen(query[i:]); n > 0 {
+			b.WriteString(query[i : i+n])
+			i += n - 1
+			continue
+		}
+
+		if query[i] != '?' {
+			b.WriteByte(query[i])
+			continue
+		}
+
+		if argIdx >= len(args) {
+			return "", fmt.Errorf("kplanetscale: the query has more placeholders than the %d arguments provided", len(args))
+		}
+
+		err := writeValue(&b, args[argIdx].Value)
+		if err != nil {
+			return "", fmt.Errorf("kplanetscale: error encoding argument %d: %w", argIdx+1, err)
+		}
+		argIdx++
+	}
+
+	if argIdx != len(args) {
+		return "", fmt.Errorf("kplanetscale: the query has %d placeholders but %d arguments were provided", argIdx, len(args))
+	}
+
+	return b.String(), nil
+}
+
+// skipLen returns the length of the string, quoted identifier
+// or comment starting at the beginning of the input query, if any.
+func skipLen(query string) int {
+	switch {
+	case isDashComment(query) || query[0] == '#':
+		if end := strings.IndexByte(query, '\n'); end != -1 {
+			return end + 1
+		}
+		return len(query)
+	case strings.HasPrefix(query, "/*"):
+		if end := strings.Index(query[2:], "*/"); end != -1 {
+			return end + 4
+		}
+		return len(query)
+	case query[0] == '\'' || query[0] == '"' || query[0] == '`':
+		for i := 1; i < len(query); i++ {
+			if query[i] == '\\' && query[0] != '`' {
+				i++
+				continue
+			}
+			if query[i] == query[0] {
+				return i + 1
+			}
+		}
+		return len(query)
+	}
+
+	return 0
+}
+
+// isDashComment checks if the query starts with a `--` comment,
+// which on MySQL requires a whitespace after the dashes.
+func isDashComment(query string) bool {
+	if !strings.HasPrefix(query, "--") {
+		return false
+	}
+	return len(query) == 2 || query[2] == ' ' || query[2] == '\t' || query[2] == '\n' || query[2] == '\r'
+}
+
+// writeValue writes the MySQL literal of one of the values
+// produced by the default driver.ValueConverter.
+func writeValue(b *strings.Builder, value driver.Value) error {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("NULL")
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("MySQL doesn't support the float value: %v", v)
+		}
+		b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		if v {
+			b.WriteString("1")
+		} else {
+			b.WriteString("0")
+		}
+	case string:
+		writeString(b, v)
+	case []byte:
+		// Text, e.g. JSON attributes, is sent as a string so it can be stored
+		// on text and JSON columns, the other bytes are sent as hex literals:
+		if !utf8.Valid(v) {
+			b.WriteString("X'" + hex.EncodeToString(v) + "'")
+			return nil
+		}
+		writeString(b, string(v))
+	case time.Time:
+		b.WriteString("'" + v.UTC().Format("2006-01-02 15:04:05.999999") + "'")
+	default:
+		return fmt.Errorf("unsupported type: %T", value)
+	}
+
+	return nil
+}
+
+func writeString(b *strings.Builder, s string) {
+	b.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case 0:
+			b.WriteString(`\0`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\x1a':
+			b.WriteString(`\Z`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('\'')
+}
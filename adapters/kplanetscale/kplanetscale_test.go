@@ -0,0 +1,291 @@
+package kplanetscale
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vingarcia/ksql"
+)
+
+type fakeServer struct {
+	mu       sync.Mutex
+	queries  []string
+	sessions []string
+	respond  func(query string) executeResponse
+}
+
+func startFakeServer(t *testing.T, respond func(query string) executeResponse) (*fakeServer, string) {
+	s := &fakeServer{respond: respond}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		if r.URL.Path != executePath || username != "fake-user" || password != "fake-password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req executeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		s.queries = append(s.queries, req.Query)
+		s.sessions = append(s.sessions, string(req.Session))
+		s.mu.Unlock()
+
+		resp := s.respond(req.Query)
+		resp.Session = json.RawMessage(`{"id":"fake-session"}`)
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	return s, strings.Replace(server.URL, "http://", "http://fake-user:fake-password@", 1)
+}
+
+func encodeRow(values ...interface{}) rawRow {
+	var row rawRow
+	var buf []byte
+	for _, value := range values {
+		if value == nil {
+			row.Lengths = append(row.Lengths, "-1")
+			continue
+		}
+		s := value.(string)
+		row.Lengths = append(row.Lengths, strconv.Itoa(len(s)))
+		buf = append(buf, s...)
+	}
+	row.Values = base64.StdEncoding.EncodeToString(buf)
+	return row
+}
+
+var usersTable = ksql.NewTable("users")
+
+type user struct {
+	ID        uint       `ksql:"id"`
+	Name      string     `ksql:"name"`
+	Age       *int       `ksql:"age"`
+	Address   address    `ksql:"address,json"`
+	CreatedAt *time.Time `ksql:"created_at"`
+}
+
+type address struct {
+	City string `json:"city"`
+}
+
+func TestAdapter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should insert records using the insert ID", func(t *testing.T) {
+		server, url := startFakeServer(t, func(query string) executeResponse {
+			return executeResponse{Result: &queryResult{RowsAffected: "1", InsertID: "42"}}
+		})
+
+		db, err := New(ctx, url, ksql.Config{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		u := user{Name: "fake-'name'", Address: address{City: "fake-city"}}
+		err = db.Insert(ctx, usersTable, &u)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if u.ID != 42 {
+			t.Errorf("expected the ID to be loaded from the insert ID but got: %d", u.ID)
+		}
+
+		lastQuery := server.queries[len(server.queries)-1]
+		for _, expected := range []string{`'fake-\'name\''`, `'{\"city\":\"fake-city\"}'`} {
+			if !strings.Contains(lastQuery, expected) {
+				t.Errorf("expected the query to contain %s but got: %s", expected, lastQuery)
+			}
+		}
+	})
+
+	t.Run("should parse the typed rows of queries", func(t *testing.T) {
+		server, url := startFakeServer(t, func(query string) executeResponse {
+			return executeResponse{Result: &queryResult{
+				Fields: []field{
+					{Name: "id", Type: "UINT32"},
+					{Name: "name", Type: "VARCHAR"},
+					{Name: "age", Type: "INT32"},
+					{Name: "address", Type: "JSON"},
+					{Name: "created_at", Type: "DATETIME"},
+				},
+				Rows: []rawRow{
+					encodeRow("1", "fake-name-1", "30", `{"city":"fake-city"}`, "2024-01-02 03:04:05.6"),
+					encodeRow("2", "", nil, nil, nil),
+				},
+			}}
+		})
+
+		db, err := New(ctx, url, ksql.Config{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var users []user
+		err = db.Query(ctx, &users, "FROM users WHERE name LIKE ?", "fake-name%")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		age := 30
+		createdAt := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC)
+		expected := []user{
+			{ID: 1, Name: "fake-name-1", Age: &age, Address: address{City: "fake-city"}, CreatedAt: &createdAt},
+			{ID: 2},
+		}
+		if !reflect.DeepEqual(users, expected) {
+			t.Errorf("expected %#v but got %#v", expected, users)
+		}
+
+		lastQuery := server.queries[len(server.queries)-1]
+		if !strings.HasSuffix(lastQuery, "WHERE name LIKE 'fake-name%'") {
+			t.Errorf("expected the argument to be interpolated but got: %s", lastQuery)
+		}
+	})
+
+	t.Run("should keep the session of transactions", func(t *testing.T) {
+		server, url := startFakeServer(t, func(query string) executeResponse {
+			return executeResponse{Result: &queryResult{RowsAffected: "1"}}
+		})
+
+		db, err := New(ctx, url, ksql.Config{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		err = db.Transaction(ctx, func(db ksql.Provider) error {
+			_, err := db.Exec(ctx, "UPDATE users SET age = ? WHERE id = ?", 31, 1)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expectedQueries := []string{"SELECT 1", "START TRANSACTION", "UPDATE users SET age = 31 WHERE id = 1", "COMMIT"}
+		if !reflect.DeepEqual(server.queries, expectedQueries) {
+			t.Errorf("expected queries %v but got %v", expectedQueries, server.queries)
+		}
+		for _, session := range server.sessions[2:] {
+			if session != `{"id":"fake-session"}` {
+				t.Errorf("expected the session to be sent on each request but got: %s", session)
+			}
+		}
+	})
+
+	t.Run("should return the errors of the database", func(t *testing.T) {
+		_, url := startFakeServer(t, func(query string) executeResponse {
+			if query == "SELECT 1" {
+				return executeResponse{Result: &queryResult{}}
+			}
+			return executeResponse{Error: &DatabaseError{
+				Code:    "ALREADY_EXISTS",
+				Message: "Duplicate entry '1' for key 'users.PRIMARY'",
+			}}
+		})
+
+		db, err := New(ctx, url, ksql.Config{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		_, err = db.Exec(ctx, "INSERT INTO users (id) VALUES (1)")
+		var dbErr DatabaseError
+		if !errors.As(err, &dbErr) {
+			t.Fatalf("expected a DatabaseError but got: %v", err)
+		}
+		if ksql.ClassifyConstraintError(err) != ksql.UniqueViolation {
+			t.Errorf("expected the error to be classified as a unique violation: %s", err)
+		}
+	})
+
+	t.Run("should fail with invalid credentials", func(t *testing.T) {
+		_, url := startFakeServer(t, nil)
+
+		_, err := New(ctx, strings.Replace(url, "fake-password", "wrong-password", 1), ksql.Config{})
+		if err == nil || !strings.Contains(err.Error(), "401") {
+			t.Errorf("expected an unauthorized error but got: %v", err)
+		}
+	})
+}
+
+func TestInterpolateParams(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		desc          string
+		query         string
+		args          []driver.Value
+		expectedQuery string
+		expectedErr   string
+	}{
+		{
+			desc:          "basic types",
+			query:         "SELECT ?, ?, ?, ?, ?, ?",
+			args:          []driver.Value{int64(1), 1.5, true, nil, "it's", createdAt},
+			expectedQuery: `SELECT 1, 1.5, 1, NULL, 'it\'s', '2024-01-02 03:04:05'`,
+		},
+		{
+			desc:          "escaped strings",
+			query:         "SELECT ?",
+			args:          []driver.Value{"a\\b\n\x00\"c"},
+			expectedQuery: `SELECT 'a\\b\n\0\"c'`,
+		},
+		{
+			desc:          "binary values",
+			query:         "SELECT ?, ?",
+			args:          []driver.Value{[]byte("text"), []byte{0xff, 0x00}},
+			expectedQuery: `SELECT 'text', X'ff00'`,
+		},
+		{
+			desc:          "placeholders inside strings and comments",
+			query:         "SELECT '?', \"\\\"?\", `?` -- ?\n, # ?\n /* ? */ ?",
+			args:          []driver.Value{int64(1)},
+			expectedQuery: "SELECT '?', \"\\\"?\", `?` -- ?\n, # ?\n /* ? */ 1",
+		},
+		{
+			desc:        "missing arguments",
+			query:       "SELECT ?, ?",
+			args:        []driver.Value{int64(1)},
+			expectedErr: "more placeholders",
+		},
+		{
+			desc:        "extra arguments",
+			query:       "SELECT ?",
+			args:        []driver.Value{int64(1), int64(2)},
+			expectedErr: "1 placeholders but 2 arguments",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			query, err := interpolateParams(test.query, namedValues(test.args))
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Fatalf("expected an error containing %q but got: %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if query != test.expectedQuery {
+				t.Errorf("expected query %q but got %q", test.expectedQuery, query)
+			}
+		})
+	}
+}
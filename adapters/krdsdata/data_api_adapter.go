@@ -0,0 +1,274 @@
+package krdsdata
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rdsdata"
+	"github.com/aws/aws-sdk-go-v2/service/rdsdata/types"
+	"github.com/vingarcia/ksql"
+)
+
+// DataAPIClient is the subset of the *rdsdata.Client methods used by the adapter
+type DataAPIClient interface {
+	ExecuteStatement(ctx context.Context, params *rdsdata.ExecuteStatementInput, optFns ...func(*rdsdata.Options)) (*rdsdata.ExecuteStatementOutput, error)
+	BeginTransaction(ctx context.Context, params *rdsdata.BeginTransactionInput, optFns ...func(*rdsdata.Options)) (*rdsdata.BeginTransactionOutput, error)
+	CommitTransaction(ctx context.Context, params *rdsdata.CommitTransactionInput, optFns ...func(*rdsdata.Options)) (*rdsdata.CommitTransactionOutput, error)
+	RollbackTransaction(ctx context.Context, params *rdsdata.RollbackTransactionInput, optFns ...func(*rdsdata.Options)) (*rdsdata.RollbackTransactionOutput, error)
+}
+
+// DataAPIAdapter adapts a Data API client to be compatible with the `DBAdapter` interface
+type DataAPIAdapter struct {
+	client DataAPIClient
+	config Config
+
+	// transactionID is only set on the adapters used by DataAPITx
+	transactionID *string
+}
+
+var _ ksql.DBAdapter = DataAPIAdapter{}
+
+// NewDataAPIAdapter returns a new instance of DataAPIAdapter
+// with the provided client and config.
+func NewDataAPIAdapter(client DataAPIClient, config Config) DataAPIAdapter {
+	config.SetDefaultValues()
+	return DataAPIAdapter{
+		client: client,
+		config: config,
+	}
+}
+
+// ExecContext implements the DBAdapter interface
+func (d DataAPIAdapter) ExecContext(ctx context.Context, query string, args ...interface{}) (ksql.Result, error) {
+	output, err := d.executeStatement(ctx, query, args, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return DataAPIResult{
+		rowsAffected:    output.NumberOfRecordsUpdated,
+		generatedFields: output.GeneratedFields,
+	}, nil
+}
+
+// QueryContext implements the DBAdapter interface
+//
+// The Data API returns all rows on a single response,
+// so the rows are fully loaded into memory before returning.
+func (d DataAPIAdapter) QueryContext(ctx context.Context, query string, args ...interface{}) (ksql.Rows, error) {
+	output, err := d.executeStatement(ctx, query, args, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDataAPIRows(output.ColumnMetadata, output.Records), nil
+}
+
+func (d DataAPIAdapter) executeStatement(
+	ctx context.Context,
+	query string,
+	args []interface{},
+	includeMetadata bool,
+) (*rdsdata.ExecuteStatementOutput, error) {
+	query, names := rewritePlaceholders(d.config.Dialect, query)
+	if len(names) != len(args) {
+		return nil, fmt.Errorf("krdsdata: the query has %d placeholders but %d arguments were provided", len(names), len(args))
+	}
+
+	params := make([]types.SqlParameter, len(args))
+	for i, arg := range args {
+		param, err := encodeParam(names[i], arg)
+		if err != nil {
+			return nil, err
+		}
+		params[i] = param
+	}
+
+	return d.client.ExecuteStatement(ctx, &rdsdata.ExecuteStatementInput{
+		ResourceArn:           aws.String(d.config.ResourceARN),
+		SecretArn:             aws.String(d.config.SecretARN),
+		Database:              optionalString(d.config.Database),
+		Sql:                   aws.String(query),
+		Parameters:            params,
+		TransactionId:         d.transactionID,
+		IncludeResultMetadata: includeMetadata,
+		ResultSetOptions: &types.ResultSetOptions{
+			DecimalReturnType: types.DecimalReturnTypeString,
+			LongReturnType:    types.LongReturnTypeLong,
+		},
+	})
+}
+
+// BeginTx implements the Tx interface
+func (d DataAPIAdapter) BeginTx(ctx context.Context) (ksql.Tx, error) {
+	output, err := d.client.BeginTransaction(ctx, &rdsdata.BeginTransactionInput{
+		ResourceArn: aws.String(d.config.ResourceARN),
+		SecretArn:   aws.String(d.config.SecretARN),
+		Database:    optionalString(d.config.Database),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tx := d
+	tx.transactionID = output.TransactionId
+	return DataAPITx{DataAPIAdapter: tx}, nil
+}
+
+// Unwrap implements the ksql.Unwrapper interface
+func (d DataAPIAdapter) Unwrap() interface{} {
+	return d.client
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+// DataAPITx is used to implement the DBAdapter interface and implements
+// the Tx interface
+type DataAPITx struct {
+	DataAPIAdapter
+}
+
+// Rollback implements the Tx interface
+func (d DataAPITx) Rollback(ctx context.Context) error {
+	_, err := d.client.RollbackTransaction(ctx, &rdsdata.RollbackTransactionInput{
+		ResourceArn:   aws.String(d.config.ResourceARN),
+		SecretArn:     aws.String(d.config.SecretARN),
+		TransactionId: d.transactionID,
+	})
+	return err
+}
+
+// Commit implements the Tx interface
+func (d DataAPITx) Commit(ctx context.Context) error {
+	_, err := d.client.CommitTransaction(ctx, &rdsdata.CommitTransactionInput{
+		ResourceArn:   aws.String(d.config.ResourceARN),
+		SecretArn:     aws.String(d.config.SecretARN),
+		TransactionId: d.transactionID,
+	})
+	return err
+}
+
+// TransactionID returns the ID of the transaction on the Data API
+func (d DataAPITx) TransactionID() string {
+	return aws.ToString(d.transactionID)
+}
+
+var _ ksql.Tx = DataAPITx{}
+
+// DataAPIResult implements the ksql.Result interface
+type DataAPIResult struct {
+	rowsAffected    int64
+	generatedFields []types.Field
+}
+
+// LastInsertId implements the Result interface
+//
+// It returns the first generated field of the statement, which on MySQL
+// is the auto increment ID, on postgres the RETURNING clause should be used instead.
+func (r DataAPIResult) LastInsertId() (int64, error) {
+	if len(r.generatedFields) == 0 {
+		return 0, fmt.Errorf("krdsdata: the statement returned no generated fields")
+	}
+
+	id, ok := r.generatedFields[0].(*types.FieldMemberLongValue)
+	if !ok {
+		return 0, fmt.Errorf("krdsdata: expected the generated field to be a long value but got: %T", r.generatedFields[0])
+	}
+	return id.Value, nil
+}
+
+// RowsAffected implements the Result interface
+func (r DataAPIResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// DataAPIRows implements the ksql.Rows interface
+// over the records returned by the Data API.
+type DataAPIRows struct {
+	columns []types.ColumnMetadata
+	records [][]types.Field
+
+	// current is the index of the current record, it starts
+	// at -1 since Next is called before the first Scan
+	current int
+}
+
+var _ ksql.Rows = &DataAPIRows{}
+
+func newDataAPIRows(columns []types.ColumnMetadata, records [][]types.Field) *DataAPIRows {
+	return &DataAPIRows{
+		columns: columns,
+		records: records,
+		current: -1,
+	}
+}
+
+// Next implements the Rows interface
+func (r *DataAPIRows) Next() bool {
+	if r.current+1 >= len(r.records) {
+		r.current = len(r.records)
+		return false
+	}
+
+	r.current++
+	return true
+}
+
+// Scan implements the Rows interface
+func (r *DataAPIRows) Scan(dest ...interface{}) error {
+	if r.current < 0 || r.current >= len(r.records) {
+		return fmt.Errorf("krdsdata: Scan called without calling Next")
+	}
+
+	record := r.records[r.current]
+	if len(dest) != len(record) {
+		return fmt.Errorf("krdsdata: expected %d destination arguments in Scan, not %d", len(record), len(dest))
+	}
+
+	for i, field := range record {
+		var typeName string
+		if i < len(r.columns) {
+			typeName = aws.ToString(r.columns[i].TypeName)
+		}
+
+		value, err := decodeField(field, typeName)
+		if err != nil {
+			return fmt.Errorf("krdsdata: error decoding column %d: %w", i, err)
+		}
+
+		if err := convertAssign(dest[i], value); err != nil {
+			return fmt.Errorf("krdsdata: error scanning column %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Columns implements the Rows interface
+func (r *DataAPIRows) Columns() ([]string, error) {
+	names := make([]string, len(r.columns))
+	for i, column := range r.columns {
+		names[i] = aws.ToString(column.Label)
+		if names[i] == "" {
+			names[i] = aws.ToString(column.Name)
+		}
+	}
+	return names, nil
+}
+
+// Err implements the Rows interface
+func (r *DataAPIRows) Err() error {
+	return nil
+}
+
+// Close implements the Rows interface
+func (r *DataAPIRows) Close() error {
+	r.current = len(r.records)
+	return nil
+}
@@ -0,0 +1,23 @@
+module github.com/vingarcia/ksql/adapters/krdsdata
+
+go 1.24
+
+require (
+	github.com/aws/aws-sdk-go-v2 v1.41.9
+	github.com/aws/aws-sdk-go-v2/service/rdsdata v1.33.0
+	github.com/vingarcia/ksql v1.4.6
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.4.25 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.7.25 // indirect
+	github.com/aws/smithy-go v1.26.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/ditointernet/go-assert v0.0.0-20200120164340-9e13125a7018 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/testify v1.7.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
+
+replace github.com/vingarcia/ksql => ../../
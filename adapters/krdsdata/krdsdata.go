@@ -0,0 +1,67 @@
+package krdsdata
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rdsdata"
+	"github.com/vingarcia/ksql"
+)
+
+// Config describes the Aurora cluster and database
+// accessed by the adapters built by this package.
+type Config struct {
+	// ResourceARN is the ARN of the Aurora cluster
+	ResourceARN string
+
+	// SecretARN is the ARN of the Secrets Manager secret
+	// with the credentials used for accessing the cluster
+	SecretARN string
+
+	// Database is the name of the database, if empty
+	// the default database of the secret is used
+	Database string
+
+	// Dialect is either "postgres" or "mysql", defaults to "postgres"
+	Dialect string
+}
+
+// SetDefaultValues sets the default config values if unset.
+func (c *Config) SetDefaultValues() {
+	if c.Dialect == "" {
+		c.Dialect = "postgres"
+	}
+}
+
+func (c Config) validate() error {
+	if c.ResourceARN == "" || c.SecretARN == "" {
+		return fmt.Errorf("krdsdata: the ResourceARN and SecretARN are required")
+	}
+
+	if c.Dialect != "postgres" && c.Dialect != "mysql" {
+		return fmt.Errorf("krdsdata: unsupported dialect `%s`, it should be either postgres or mysql", c.Dialect)
+	}
+
+	return nil
+}
+
+// New instantiates a new ksql.DB that sends its queries
+// over the RDS Data API using the input AWS config.
+//
+// No connections are kept open, each query is an HTTPS request,
+// which makes it suitable for serverless functions that can't
+// keep a pool of connections open to the database.
+func New(awsConfig aws.Config, config Config, opts ...ksql.Option) (ksql.DB, error) {
+	return NewFromClient(rdsdata.NewFromConfig(awsConfig), config, opts...)
+}
+
+// NewFromClient builds a ksql.DB from a Data API client,
+// e.g. a *rdsdata.Client with custom options.
+func NewFromClient(client DataAPIClient, config Config, opts ...ksql.Option) (ksql.DB, error) {
+	config.SetDefaultValues()
+	if err := config.validate(); err != nil {
+		return ksql.DB{}, err
+	}
+
+	return ksql.NewWithAdapter(NewDataAPIAdapter(client, config), config.Dialect, opts...)
+}
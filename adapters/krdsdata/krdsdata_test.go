@@ -0,0 +1,368 @@
+package krdsdata
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rdsdata"
+	"github.com/aws/aws-sdk-go-v2/service/rdsdata/types"
+	"github.com/vingarcia/ksql"
+)
+
+type fakeClient struct {
+	executed    []*rdsdata.ExecuteStatementInput
+	output      *rdsdata.ExecuteStatementOutput
+	committed   []string
+	rolledBack  []string
+	numBeginTxs int
+}
+
+func (f *fakeClient) ExecuteStatement(ctx context.Context, params *rdsdata.ExecuteStatementInput, optFns ...func(*rdsdata.Options)) (*rdsdata.ExecuteStatementOutput, error) {
+	f.executed = append(f.executed, params)
+	if f.output == nil {
+		return &rdsdata.ExecuteStatementOutput{}, nil
+	}
+	return f.output, nil
+}
+
+func (f *fakeClient) BeginTransaction(ctx context.Context, params *rdsdata.BeginTransactionInput, optFns ...func(*rdsdata.Options)) (*rdsdata.BeginTransactionOutput, error) {
+	f.numBeginTxs++
+	return &rdsdata.BeginTransactionOutput{TransactionId: aws.String("fake-tx-id")}, nil
+}
+
+func (f *fakeClient) CommitTransaction(ctx context.Context, params *rdsdata.CommitTransactionInput, optFns ...func(*rdsdata.Options)) (*rdsdata.CommitTransactionOutput, error) {
+	f.committed = append(f.committed, aws.ToString(params.TransactionId))
+	return &rdsdata.CommitTransactionOutput{}, nil
+}
+
+func (f *fakeClient) RollbackTransaction(ctx context.Context, params *rdsdata.RollbackTransactionInput, optFns ...func(*rdsdata.Options)) (*rdsdata.RollbackTransactionOutput, error) {
+	f.rolledBack = append(f.rolledBack, aws.ToString(params.TransactionId))
+	return &rdsdata.RollbackTransactionOutput{}, nil
+}
+
+var fakeConfig = Config{
+	ResourceARN: "arn:aws:rds:us-east-1:123456789012:cluster:fake-cluster",
+	SecretARN:   "arn:aws:secretsmanager:us-east-1:123456789012:secret:fake-secret",
+	Database:    "fake-db",
+}
+
+var usersTable = ksql.NewTable("users")
+
+type user struct {
+	ID        uint       `ksql:"id"`
+	Name      string     `ksql:"name"`
+	Age       *int       `ksql:"age"`
+	CreatedAt time.Time  `ksql:"created_at"`
+	Address   address    `ksql:"address,json"`
+	DeletedAt *time.Time `ksql:"deleted_at"`
+}
+
+type address struct {
+	City string `json:"city"`
+}
+
+func TestRewritePlaceholders(t *testing.T) {
+	tests := []struct {
+		desc          string
+		dialect       string
+		query         string
+		expectedQuery string
+		expectedNames []string
+	}{
+		{
+			desc:          "postgres placeholders",
+			dialect:       "postgres",
+			query:         "SELECT * FROM users WHERE id = $1 AND age > $2::int OR id = $1",
+			expectedQuery: "SELECT * FROM users WHERE id = :p1 AND age > :p2::int OR id = :p1",
+			expectedNames: []string{"p1", "p2"},
+		},
+		{
+			desc:          "mysql placeholders",
+			dialect:       "mysql",
+			query:         "INSERT INTO `users` (`name`, `age`) VALUES (?, ?)",
+			expectedQuery: "INSERT INTO `users` (`name`, `age`) VALUES (:p1, :p2)",
+			expectedNames: []string{"p1", "p2"},
+		},
+		{
+			desc:          "placeholders inside strings and comments",
+			dialect:       "postgres",
+			query:         "SELECT '$1', \"$2\", $$ $3 $$, $body$ $4 $body$ -- $5\nFROM users /* $6 */ WHERE id = $1",
+			expectedQuery: "SELECT '$1', \"$2\", $$ $3 $$, $body$ $4 $body$ -- $5\nFROM users /* $6 */ WHERE id = :p1",
+			expectedNames: []string{"p1"},
+		},
+		{
+			desc:          "mysql escaped quotes",
+			dialect:       "mysql",
+			query:         `SELECT 'it\'s ?' FROM users WHERE id = ?`,
+			expectedQuery: `SELECT 'it\'s ?' FROM users WHERE id = :p1`,
+			expectedNames: []string{"p1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			query, names := rewritePlaceholders(test.dialect, test.query)
+			if query != test.expectedQuery {
+				t.Errorf("expected query %q but got %q", test.expectedQuery, query)
+			}
+			if !reflect.DeepEqual(names, test.expectedNames) {
+				t.Errorf("expected names %v but got %v", test.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestEncodeParam(t *testing.T) {
+	age := 42
+	var nilAge *int
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC)
+
+	tests := []struct {
+		desc             string
+		value            interface{}
+		expectedField    types.Field
+		expectedTypeHint types.TypeHint
+	}{
+		{desc: "nil", value: nil, expectedField: &types.FieldMemberIsNull{Value: true}},
+		{desc: "nil pointer", value: nilAge, expectedField: &types.FieldMemberIsNull{Value: true}},
+		{desc: "pointer", value: &age, expectedField: &types.FieldMemberLongValue{Value: 42}},
+		{desc: "string", value: "fake-name", expectedField: &types.FieldMemberStringValue{Value: "fake-name"}},
+		{desc: "bool", value: true, expectedField: &types.FieldMemberBooleanValue{Value: true}},
+		{desc: "uint", value: uint8(7), expectedField: &types.FieldMemberLongValue{Value: 7}},
+		{desc: "float", value: 1.5, expectedField: &types.FieldMemberDoubleValue{Value: 1.5}},
+		{desc: "bytes", value: []byte{0, 1}, expectedField: &types.FieldMemberBlobValue{Value: []byte{0, 1}}},
+		{
+			desc:             "time",
+			value:            createdAt,
+			expectedField:    &types.FieldMemberStringValue{Value: "2024-01-02 03:04:05.6"},
+			expectedTypeHint: types.TypeHintTimestamp,
+		},
+		{
+			desc:             "json valuer",
+			value:            jsonValuer(`{"city":"fake-city"}`),
+			expectedField:    &types.FieldMemberStringValue{Value: `{"city":"fake-city"}`},
+			expectedTypeHint: types.TypeHintJson,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			param, err := encodeParam("p1", test.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if aws.ToString(param.Name) != "p1" {
+				t.Errorf("expected name p1 but got %q", aws.ToString(param.Name))
+			}
+			if !reflect.DeepEqual(param.Value, test.expectedField) {
+				t.Errorf("expected field %#v but got %#v", test.expectedField, param.Value)
+			}
+			if param.TypeHint != test.expectedTypeHint {
+				t.Errorf("expected type hint %q but got %q", test.expectedTypeHint, param.TypeHint)
+			}
+		})
+	}
+
+	t.Run("should reject unsupported types", func(t *testing.T) {
+		_, err := encodeParam("p1", []int{1, 2})
+		if err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+	})
+
+	t.Run("should reject uint values that overflow", func(t *testing.T) {
+		_, err := encodeParam("p1", uint64(1<<63))
+		if err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+	})
+}
+
+type jsonValuer string
+
+func (j jsonValuer) Value() (driver.Value, error) {
+	return []byte(j), nil
+}
+
+func TestDataAPIAdapter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should send the typed parameters of inserts", func(t *testing.T) {
+		client := &fakeClient{
+			output: &rdsdata.ExecuteStatementOutput{
+				NumberOfRecordsUpdated: 1,
+				GeneratedFields:        []types.Field{&types.FieldMemberLongValue{Value: 42}},
+			},
+		}
+		db, err := NewFromClient(client, Config{
+			ResourceARN: fakeConfig.ResourceARN,
+			SecretARN:   fakeConfig.SecretARN,
+			Dialect:     "mysql",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		age := 30
+		u := user{Name: "fake-name", Age: &age, Address: address{City: "fake-city"}}
+		err = db.Insert(ctx, usersTable, &u)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if u.ID != 42 {
+			t.Errorf("expected the ID to be loaded from the generated fields but got: %d", u.ID)
+		}
+
+		if len(client.executed) != 1 {
+			t.Fatalf("expected 1 statement but got %d", len(client.executed))
+		}
+		input := client.executed[0]
+		if input.Database != nil {
+			t.Errorf("expected no database but got %q", aws.ToString(input.Database))
+		}
+		if aws.ToString(input.ResourceArn) != fakeConfig.ResourceARN {
+			t.Errorf("unexpected resource ARN: %q", aws.ToString(input.ResourceArn))
+		}
+
+		params := map[string]types.SqlParameter{}
+		for _, param := range input.Parameters {
+			params[aws.ToString(param.Name)] = param
+		}
+		if len(params) != 4 {
+			t.Fatalf("expected 4 parameters but got: %#v", input.Parameters)
+		}
+		for name, param := range params {
+			if !strings.Contains(aws.ToString(input.Sql), ":"+name) {
+				t.Errorf("expected the query to reference the parameter %s: %s", name, aws.ToString(input.Sql))
+			}
+			if param.TypeHint == types.TypeHintJson && !reflect.DeepEqual(param.Value, &types.FieldMemberStringValue{Value: `{"city":"fake-city"}`}) {
+				t.Errorf("unexpected JSON parameter: %#v", param.Value)
+			}
+		}
+	})
+
+	t.Run("should scan the typed records of queries", func(t *testing.T) {
+		client := &fakeClient{
+			output: &rdsdata.ExecuteStatementOutput{
+				ColumnMetadata: []types.ColumnMetadata{
+					{Label: aws.String("id"), TypeName: aws.String("serial")},
+					{Label: aws.String("name"), TypeName: aws.String("varchar")},
+					{Label: aws.String("age"), TypeName: aws.String("int4")},
+					{Label: aws.String("created_at"), TypeName: aws.String("timestamp")},
+					{Label: aws.String("address"), TypeName: aws.String("jsonb")},
+					{Label: aws.String("deleted_at"), TypeName: aws.String("timestamp")},
+				},
+				Records: [][]types.Field{
+					{
+						&types.FieldMemberLongValue{Value: 1},
+						&types.FieldMemberStringValue{Value: "fake-name-1"},
+						&types.FieldMemberLongValue{Value: 30},
+						&types.FieldMemberStringValue{Value: "2024-01-02 03:04:05.6"},
+						&types.FieldMemberStringValue{Value: `{"city":"fake-city"}`},
+						&types.FieldMemberStringValue{Value: "2024-02-03 00:00:00"},
+					},
+					{
+						&types.FieldMemberLongValue{Value: 2},
+						&types.FieldMemberStringValue{Value: "fake-name-2"},
+						&types.FieldMemberIsNull{Value: true},
+						&types.FieldMemberStringValue{Value: "2024-01-02 00:00:00"},
+						&types.FieldMemberIsNull{Value: true},
+						&types.FieldMemberIsNull{Value: true},
+					},
+				},
+			},
+		}
+		db, err := NewFromClient(client, fakeConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var users []user
+		err = db.Query(ctx, &users, "FROM users WHERE name LIKE $1", "fake-name%")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		age := 30
+		deletedAt := time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)
+		expected := []user{
+			{
+				ID:        1,
+				Name:      "fake-name-1",
+				Age:       &age,
+				CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC),
+				Address:   address{City: "fake-city"},
+				DeletedAt: &deletedAt,
+			},
+			{
+				ID:        2,
+				Name:      "fake-name-2",
+				CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			},
+		}
+		if !reflect.DeepEqual(users, expected) {
+			t.Errorf("expected %#v but got %#v", expected, users)
+		}
+
+		input := client.executed[0]
+		if !input.IncludeResultMetadata {
+			t.Errorf("expected the query to request the result metadata")
+		}
+		if aws.ToString(input.Database) != "fake-db" {
+			t.Errorf("expected the database fake-db but got %q", aws.ToString(input.Database))
+		}
+		if !strings.Contains(aws.ToString(input.Sql), "LIKE :p1") {
+			t.Errorf("expected the placeholders to be rewritten: %s", aws.ToString(input.Sql))
+		}
+	})
+
+	t.Run("should run the statements of transactions with the transaction ID", func(t *testing.T) {
+		client := &fakeClient{}
+		db, err := NewFromClient(client, fakeConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		err = db.Transaction(ctx, func(db ksql.Provider) error {
+			_, err := db.Exec(ctx, "UPDATE users SET age = $1 WHERE id = $2", 31, 1)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if client.numBeginTxs != 1 {
+			t.Errorf("expected 1 transaction but got %d", client.numBeginTxs)
+		}
+		if aws.ToString(client.executed[0].TransactionId) != "fake-tx-id" {
+			t.Errorf("expected the statement to run on the transaction but got %q", aws.ToString(client.executed[0].TransactionId))
+		}
+		if !reflect.DeepEqual(client.committed, []string{"fake-tx-id"}) {
+			t.Errorf("expected the transaction to be committed but got: %v", client.committed)
+		}
+		if len(client.rolledBack) != 0 {
+			t.Errorf("unexpected rollbacks: %v", client.rolledBack)
+		}
+	})
+
+	t.Run("should validate the config", func(t *testing.T) {
+		_, err := NewFromClient(&fakeClient{}, Config{ResourceARN: fakeConfig.ResourceARN})
+		if err == nil {
+			t.Errorf("expected an error for the missing SecretARN")
+		}
+
+		_, err = NewFromClient(&fakeClient{}, Config{
+			ResourceARN: fakeConfig.ResourceARN,
+			SecretARN:   fakeConfig.SecretARN,
+			Dialect:     "sqlite3",
+		})
+		if err == nil {
+			t.Errorf("expected an error for the unsupported dialect")
+		}
+	})
+}
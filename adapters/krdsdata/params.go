@@ -0,0 +1,243 @@
+package krdsdata
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rdsdata/types"
+)
+
+// timestampLayout is the format expected by the Data API for the TIMESTAMP type hint
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+// rewritePlaceholders replaces the placeholders of the dialect, i.e. `$1` on
+// postgres and `?` on mysql, by the named parameters used by the Data API.
+//
+// It returns the rewritten query and the name of the parameter of each argument,
+// the placeholders inside strings, quoted identifiers and comments are ignored.
+func rewritePlaceholders(dialect string, query string) (string, []string) {
+	var b strings.Builder
+	var numParams int
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if n := skipLen(dialect, query[i:]); n > 0 {
+			b.WriteString(query[i : i+n])
+			i += n - 1
+			continue
+		}
+
+		switch {
+		case dialect == "mysql" && c == '?':
+			numParams++
+			b.WriteString(":p" + strconv.Itoa(numParams))
+		case dialect == "postgres" && c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			end := i + 1
+			for end < len(query) && isDigit(query[end]) {
+				end++
+			}
+			idx, _ := strconv.Atoi(query[i+1 : end])
+			if idx > numParams {
+				numParams = idx
+			}
+			b.WriteString(":p" + query[i+1:end])
+			i = end - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	names := make([]string, numParams)
+	for i := range names {
+		names[i] = "p" + strconv.Itoa(i+1)
+	}
+	return b.String(), names
+}
+
+// skipLen returns the length of the string, quoted identifier
+// or comment starting at the beginning of the input query, if any.
+func skipLen(dialect string, query string) int {
+	switch {
+	case strings.HasPrefix(query, "--"):
+		if end := strings.IndexByte(query, '\n'); end != -1 {
+			return end + 1
+		}
+		return len(query)
+	case strings.HasPrefix(query, "/*"):
+		if end := strings.Index(query[2:], "*/"); end != -1 {
+			return end + 4
+		}
+		return len(query)
+	case query[0] == '\'' || query[0] == '"' || query[0] == '`':
+		for i := 1; i < len(query); i++ {
+			if dialect == "mysql" && query[i] == '\\' {
+				i++
+				continue
+			}
+			if query[i] == query[0] {
+				return i + 1
+			}
+		}
+		return len(query)
+	case dialect == "postgres" && query[0] == '$':
+		// Dollar-quoted strings, e.g. $body$ ... $body$:
+		end := strings.IndexByte(query[1:], '$')
+		if end == -1 || !isDollarTag(query[1:end+1]) {
+			return 0
+		}
+		tag := query[:end+2]
+		if closing := strings.Index(query[len(tag):], tag); closing != -1 {
+			return len(tag) + closing + len(tag)
+		}
+		return len(query)
+	}
+
+	return 0
+}
+
+func isDollarTag(tag string) bool {
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !(i > 0 && isDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// encodeParam converts the argument into the typed value expected by the Data API
+func encodeParam(name string, arg interface{}) (types.SqlParameter, error) {
+	param := types.SqlParameter{
+		Name: aws.String(name),
+	}
+
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr && !v.IsNil() && !v.Type().Implements(valuerType) {
+		v = v.Elem()
+	}
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		param.Value = &types.FieldMemberIsNull{Value: true}
+		return param, nil
+	}
+
+	arg = v.Interface()
+	if valuer, ok := arg.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return types.SqlParameter{}, fmt.Errorf("krdsdata: error encoding parameter %s: %w", name, err)
+		}
+
+		// JSON attributes are encoded by ksql as bytes,
+		// but the Data API expects them as strings:
+		if b, ok := value.([]byte); ok && json.Valid(b) {
+			param.Value = &types.FieldMemberStringValue{Value: string(b)}
+			param.TypeHint = types.TypeHintJson
+			return param, nil
+		}
+
+		if _, ok := value.(driver.Valuer); ok {
+			return types.SqlParameter{}, fmt.Errorf("krdsdata: parameter %s: Value returned another driver.Valuer: %T", name, value)
+		}
+		return encodeParam(name, value)
+	}
+
+	switch value := arg.(type) {
+	case time.Time:
+		param.Value = &types.FieldMemberStringValue{Value: value.UTC().Format(timestampLayout)}
+		param.TypeHint = types.TypeHintTimestamp
+		return param, nil
+	case []byte:
+		param.Value = &types.FieldMemberBlobValue{Value: value}
+		return param, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		param.Value = &types.FieldMemberStringValue{Value: v.String()}
+	case reflect.Bool:
+		param.Value = &types.FieldMemberBooleanValue{Value: v.Bool()}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		param.Value = &types.FieldMemberLongValue{Value: v.Int()}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return types.SqlParameter{}, fmt.Errorf("krdsdata: parameter %s overflows the Data API long values: %d", name, v.Uint())
+		}
+		param.Value = &types.FieldMemberLongValue{Value: int64(v.Uint())}
+	case reflect.Float32, reflect.Float64:
+		param.Value = &types.FieldMemberDoubleValue{Value: v.Float()}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return types.SqlParameter{}, fmt.Errorf("krdsdata: parameter %s has an unsupported type: %T", name, arg)
+		}
+		param.Value = &types.FieldMemberBlobValue{Value: v.Bytes()}
+	default:
+		return types.SqlParameter{}, fmt.Errorf("krdsdata: parameter %s has an unsupported type: %T", name, arg)
+	}
+
+	return param, nil
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// decodeField converts the typed value returned by the Data API into one of the
+// types returned by the database/sql drivers, the column type name is used for
+// parsing the dates and timestamps, which the Data API returns as strings.
+func decodeField(field types.Field, typeName string) (interface{}, error) {
+	switch f := field.(type) {
+	case *types.FieldMemberIsNull:
+		return nil, nil
+	case *types.FieldMemberStringValue:
+		if isTimeType(typeName) {
+			if t, err := parseTime(f.Value); err == nil {
+				return t, nil
+			}
+		}
+		return f.Value, nil
+	case *types.FieldMemberLongValue:
+		return f.Value, nil
+	case *types.FieldMemberDoubleValue:
+		return f.Value, nil
+	case *types.FieldMemberBooleanValue:
+		return f.Value, nil
+	case *types.FieldMemberBlobValue:
+		return f.Value, nil
+	case nil:
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unsupported Data API field type: %T", field)
+}
+
+func isTimeType(typeName string) bool {
+	switch strings.ToLower(typeName) {
+	case "timestamp", "timestamptz", "datetime", "date":
+		return true
+	}
+	return false
+}
+
+var timeLayouts = []string{
+	timestampLayout,
+	"2006-01-02 15:04:05.999999Z07",
+	"2006-01-02 15:04:05.999999Z07:00",
+	"2006-01-02",
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unexpected time format: %q", s)
+}
@@ -0,0 +1,157 @@
+package krdsdata
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// convertAssign copies the decoded value into the destination,
+// following the conversion rules of the database/sql package.
+func convertAssign(dest interface{}, src interface{}) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+
+	if d, ok := dest.(*interface{}); ok {
+		*d = src
+		return nil
+	}
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("destination not a pointer: %T", dest)
+	}
+
+	return assignValue(dv.Elem(), src)
+}
+
+func assignValue(dv reflect.Value, src interface{}) error {
+	if src == nil {
+		switch dv.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			dv.Set(reflect.Zero(dv.Type()))
+			return nil
+		}
+		return fmt.Errorf("can't scan NULL into %s", dv.Type())
+	}
+
+	if dv.Kind() == reflect.Ptr {
+		elem := reflect.New(dv.Type().Elem())
+		if err := convertAssign(elem.Interface(), src); err != nil {
+			return err
+		}
+		dv.Set(elem)
+		return nil
+	}
+
+	sv := reflect.ValueOf(src)
+	if b, ok := src.([]byte); ok {
+		// Copy the bytes so the destination doesn't share memory with the response:
+		sv = reflect.ValueOf(append([]byte(nil), b...))
+	}
+
+	if sv.Type().AssignableTo(dv.Type()) {
+		dv.Set(sv)
+		return nil
+	}
+
+	switch dv.Kind() {
+	case reflect.String:
+		s, ok := asString(src)
+		if !ok {
+			break
+		}
+		dv.SetString(s)
+		return nil
+	case reflect.Slice:
+		if dv.Type().Elem().Kind() != reflect.Uint8 {
+			break
+		}
+		s, ok := asString(src)
+		if !ok {
+			break
+		}
+		dv.SetBytes([]byte(s))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s, ok := asString(src)
+		if !ok {
+			break
+		}
+		i, err := strconv.ParseInt(s, 10, dv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("converting %T (%q) to a %s: %w", src, s, dv.Kind(), err)
+		}
+		dv.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s, ok := asString(src)
+		if !ok {
+			break
+		}
+		u, err := strconv.ParseUint(s, 10, dv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("converting %T (%q) to a %s: %w", src, s, dv.Kind(), err)
+		}
+		dv.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		s, ok := asString(src)
+		if !ok {
+			break
+		}
+		f, err := strconv.ParseFloat(s, dv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("converting %T (%q) to a %s: %w", src, s, dv.Kind(), err)
+		}
+		dv.SetFloat(f)
+		return nil
+	case reflect.Bool:
+		s, ok := asString(src)
+		if !ok {
+			break
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("converting %T (%q) to a bool: %w", src, s, err)
+		}
+		dv.SetBool(b)
+		return nil
+	case reflect.Struct:
+		s, ok := src.(string)
+		if !ok || dv.Type() != reflect.TypeOf(time.Time{}) {
+			break
+		}
+		t, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		dv.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	return fmt.Errorf("unsupported Scan, storing %T into type %s", src, dv.Type())
+}
+
+// asString formats the basic types returned by
+// the Data API so they can be parsed into other types.
+func asString(src interface{}) (string, bool) {
+	switch v := src.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	}
+	return "", false
+}
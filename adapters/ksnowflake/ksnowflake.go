@@ -0,0 +1,57 @@
+package ksnowflake
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/vingarcia/ksql"
+
+	// This is imported here so the user don't
+	// have to worry about it when he uses it.
+	_ "github.com/snowflakedb/gosnowflake"
+)
+
+// NewFromSQLDB builds a ksql.DB from a *sql.DB instance
+func NewFromSQLDB(db *sql.DB, opts ...ksql.Option) (ksql.DB, error) {
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "snowflake", opts...)
+}
+
+// SQLDBFromDB returns the *sql.DB used by a ksql.DB built by this package,
+// an error is returned if the ksql.DB is using a transaction or another adapter.
+func SQLDBFromDB(db ksql.DB) (*sql.DB, error) {
+	sqlDB, ok := db.Unwrap().(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("ksnowflake: expected the ksql.DB to be using a *sql.DB but got: %T", db.Unwrap())
+	}
+	return sqlDB, nil
+}
+
+// New instantiates a new KissSQL client using the "snowflake" driver,
+// the connection string has the format:
+//
+//	user:password@account/database/schema?warehouse=name
+//
+// Since snowflake can't return the generated IDs InsertMany uses multi-row
+// inserts, and InsertCSV uploads the records to the stage of the table with
+// a PUT command and loads them with COPY INTO.
+func New(
+	ctx context.Context,
+	connectionString string,
+	config ksql.Config,
+	opts ...ksql.Option,
+) (ksql.DB, error) {
+	config.SetDefaultValues()
+
+	db, err := sql.Open("snowflake", connectionString)
+	if err != nil {
+		return ksql.DB{}, err
+	}
+	if err = db.PingContext(ctx); err != nil {
+		return ksql.DB{}, err
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "snowflake", opts...)
+}
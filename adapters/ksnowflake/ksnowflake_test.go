@@ -0,0 +1,74 @@
+package ksnowflake
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type fakeExecer struct {
+	queries []string
+	err     error
+}
+
+func (f *fakeExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	f.queries = append(f.queries, query)
+	if f.err != nil && strings.HasPrefix(query, "COPY") {
+		return nil, f.err
+	}
+	return nil, nil
+}
+
+func TestCopyFromCSV(t *testing.T) {
+	ctx := context.Background()
+	putRegex := regexp.MustCompile(`^PUT 'file://(ksql_[0-9a-f]{32}\.csv)' @"app"\.%"users" AUTO_COMPRESS = TRUE$`)
+
+	t.Run("should upload the records to the table stage and load them", func(t *testing.T) {
+		db := &fakeExecer{}
+		err := copyFromCSV(ctx, db, "app.users", []string{"id", "name"}, strings.NewReader("1,fake-name\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if len(db.queries) != 2 {
+			t.Fatalf("expected 2 queries but got: %v", db.queries)
+		}
+		match := putRegex.FindStringSubmatch(db.queries[0])
+		if match == nil {
+			t.Fatalf("unexpected PUT command: %s", db.queries[0])
+		}
+
+		expected := `COPY INTO "app"."users" ("id", "name") FROM @"app".%"users" FILES = ('` + match[1] + `.gz') FILE_FORMAT = (TYPE = CSV FIELD_OPTIONALLY_ENCLOSED_BY = '"') PURGE = TRUE`
+		if db.queries[1] != expected {
+			t.Errorf("expected query:\n%s\nbut got:\n%s", expected, db.queries[1])
+		}
+	})
+
+	t.Run("should remove the staged file if loading fails", func(t *testing.T) {
+		db := &fakeExecer{err: errors.New("fake-copy-error")}
+		err := copyFromCSV(ctx, db, "app.users", []string{"id"}, strings.NewReader("1\n"))
+		if err != db.err {
+			t.Fatalf("expected the copy error but got: %v", err)
+		}
+
+		if len(db.queries) != 3 {
+			t.Fatalf("expected 3 queries but got: %v", db.queries)
+		}
+		match := putRegex.FindStringSubmatch(db.queries[0])
+		if match == nil {
+			t.Fatalf("unexpected PUT command: %s", db.queries[0])
+		}
+		if expected := `REMOVE @"app".%"users"/` + match[1] + `.gz`; db.queries[2] != expected {
+			t.Errorf("expected query %s but got: %s", expected, db.queries[2])
+		}
+	})
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	if got := quoteIdentifier(`my "table"`); got != `"my ""table"""` {
+		t.Errorf("unexpected quoted identifier: %s", got)
+	}
+}
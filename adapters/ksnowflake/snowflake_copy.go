@@ -0,0 +1,103 @@
+package ksnowflake
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	sf "github.com/snowflakedb/gosnowflake"
+	"github.com/vingarcia/ksql"
+)
+
+var (
+	_ ksql.CSVCopier = SQLAdapter{}
+	_ ksql.CSVCopier = SQLTx{}
+	_ ksql.CSVCopier = SQLConn{}
+)
+
+// CopyFromCSV implements the ksql.CSVCopier interface using the PUT and COPY INTO commands
+func (s SQLAdapter) CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+	return copyFromCSV(ctx, s.DB, tableName, columns, r)
+}
+
+// CopyFromCSV implements the ksql.CSVCopier interface using the PUT and COPY INTO commands
+func (s SQLTx) CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+	return copyFromCSV(ctx, s.Tx, tableName, columns, r)
+}
+
+// CopyFromCSV implements the ksql.CSVCopier interface using the PUT and COPY INTO commands
+func (s SQLConn) CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+	return copyFromCSV(ctx, s.Conn, tableName, columns, r)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// copyFromCSV uploads the records to the stage of the table, which every
+// snowflake table has, and then loads them into the table, the uploaded
+// file is removed from the stage once it is loaded or if loading fails.
+func copyFromCSV(ctx context.Context, db execer, tableName string, columns []string, r io.Reader) error {
+	parts := strings.Split(tableName, ".")
+	for i, part := range parts {
+		parts[i] = quoteIdentifier(part)
+	}
+	escapedTableName := strings.Join(parts, ".")
+
+	// The stage of a table is named `@%table`, e.g. `@"app".%"users"`:
+	parts[len(parts)-1] = "%" + parts[len(parts)-1]
+	stage := "@" + strings.Join(parts, ".")
+
+	escapedColumns := make([]string, len(columns))
+	for i, column := range columns {
+		escapedColumns[i] = quoteIdentifier(column)
+	}
+
+	fileName, err := newStageFileName()
+	if err != nil {
+		return err
+	}
+
+	// The file is read from the stream instead of the local file system:
+	_, err = db.ExecContext(
+		sf.WithFileStream(ctx, r),
+		fmt.Sprintf("PUT 'file://%s' %s AUTO_COMPRESS = TRUE", fileName, stage),
+	)
+	if err != nil {
+		return fmt.Errorf("ksnowflake: error uploading the csv to %s: %w", stage, err)
+	}
+
+	// The file is compressed with gzip by the PUT command:
+	stagedFile := fileName + ".gz"
+	_, err = db.ExecContext(ctx, fmt.Sprintf(
+		"COPY INTO %s (%s) FROM %s FILES = ('%s') FILE_FORMAT = (TYPE = CSV FIELD_OPTIONALLY_ENCLOSED_BY = '\"') PURGE = TRUE",
+		escapedTableName,
+		strings.Join(escapedColumns, ", "),
+		stage,
+		stagedFile,
+	))
+	if err != nil {
+		db.ExecContext(ctx, fmt.Sprintf("REMOVE %s/%s", stage, stagedFile))
+		return err
+	}
+
+	return nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// newStageFileName returns a random name so concurrent
+// loads into the same table never overwrite each other.
+func newStageFileName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "ksql_" + hex.EncodeToString(b) + ".csv", nil
+}
@@ -6,3 +6,5 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.12
 	github.com/vingarcia/ksql v1.4.6
 )
+
+replace github.com/vingarcia/ksql => ../../
@@ -3,6 +3,9 @@ package ksqlite3
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/vingarcia/ksql"
 
@@ -12,15 +15,26 @@ import (
 )
 
 // NewFromSQLDB builds a ksql.DB from a *sql.DB instance
-func NewFromSQLDB(db *sql.DB) (ksql.DB, error) {
-	return ksql.NewWithAdapter(NewSQLAdapter(db), "sqlite3")
+func NewFromSQLDB(db *sql.DB, opts ...ksql.Option) (ksql.DB, error) {
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "sqlite3", withReturningIfSupported(context.Background(), db, opts)...)
+}
+
+// SQLDBFromDB returns the *sql.DB used by a ksql.DB built by this package,
+// an error is returned if the ksql.DB is using a transaction or another adapter.
+func SQLDBFromDB(db ksql.DB) (*sql.DB, error) {
+	sqlDB, ok := db.Unwrap().(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("ksqlite3: expected the ksql.DB to be using a *sql.DB but got: %T", db.Unwrap())
+	}
+	return sqlDB, nil
 }
 
 // New instantiates a new KissSQL client using the "sqlite3" driver
 func New(
-	_ context.Context,
+	ctx context.Context,
 	connectionString string,
 	config ksql.Config,
+	opts ...ksql.Option,
 ) (ksql.DB, error) {
 	config.SetDefaultValues()
 
@@ -34,5 +48,37 @@ func New(
 
 	db.SetMaxOpenConns(config.MaxOpenConns)
 
-	return ksql.NewWithAdapter(NewSQLAdapter(db), "sqlite3")
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "sqlite3", withReturningIfSupported(ctx, db, opts)...)
+}
+
+// withReturningIfSupported adds the ksql.WithSQLiteReturning option
+// if the linked SQLite version supports the RETURNING clause.
+func withReturningIfSupported(ctx context.Context, db *sql.DB, opts []ksql.Option) []ksql.Option {
+	var version string
+	err := db.QueryRowContext(ctx, "SELECT sqlite_version()").Scan(&version)
+	if err != nil || !supportsReturning(version) {
+		return opts
+	}
+
+	return append(opts, ksql.WithSQLiteReturning())
+}
+
+// supportsReturning checks if the version is 3.35 or higher,
+// which is the first SQLite version with the RETURNING clause.
+func supportsReturning(version string) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+
+	return major > 3 || (major == 3 && minor >= 35)
 }
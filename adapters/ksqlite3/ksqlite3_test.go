@@ -14,6 +14,22 @@ func TestAdapter(t *testing.T) {
 		if err != nil {
 			t.Fatal(err.Error())
 		}
-		return SQLAdapter{db}, db
+		return NewSQLAdapter(db), db
 	})
 }
+
+func TestSupportsReturning(t *testing.T) {
+	for version, expected := range map[string]bool{
+		"3.34.1": false,
+		"3.35.0": true,
+		"3.38.0": true,
+		"4.0.0":  true,
+		"2.99.0": false,
+		"":       false,
+		"3.x":    false,
+	} {
+		if got := supportsReturning(version); got != expected {
+			t.Errorf("supportsReturning(%q): expected %v but got %v", version, expected, got)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package ksqlite3
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"sync"
 
 	"github.com/vingarcia/ksql"
 )
@@ -10,6 +12,9 @@ import (
 // SQLAdapter adapts the sql.DB type to be compatible with the `DBAdapter` interface
 type SQLAdapter struct {
 	*sql.DB
+
+	// stmts stores the statements created by Prepare by their query
+	stmts *sync.Map
 }
 
 var _ ksql.DBAdapter = SQLAdapter{}
@@ -18,20 +23,66 @@ var _ ksql.DBAdapter = SQLAdapter{}
 // the provided database instance.
 func NewSQLAdapter(db *sql.DB) SQLAdapter {
 	return SQLAdapter{
-		DB: db,
+		DB:    db,
+		stmts: &sync.Map{},
 	}
 }
 
 // ExecContext implements the DBAdapter interface
 func (s SQLAdapter) ExecContext(ctx context.Context, query string, args ...interface{}) (ksql.Result, error) {
+	if stmt := s.preparedStmt(query); stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
 	return s.DB.ExecContext(ctx, query, args...)
 }
 
 // QueryContext implements the DBAdapter interface
 func (s SQLAdapter) QueryContext(ctx context.Context, query string, args ...interface{}) (ksql.Rows, error) {
+	if stmt := s.preparedStmt(query); stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
 	return s.DB.QueryContext(ctx, query, args...)
 }
 
+// Prepare implements the ksql.StatementPreparer interface
+//
+// Each statement is prepared once and then database/sql prepares
+// it again on the other connections of the pool when it is used.
+func (s SQLAdapter) Prepare(ctx context.Context, queries ...string) error {
+	if s.stmts == nil {
+		return fmt.Errorf("the SQLAdapter must be created with NewSQLAdapter for preparing statements")
+	}
+
+	for _, query := range queries {
+		if _, found := s.stmts.Load(query); found {
+			continue
+		}
+
+		stmt, err := s.DB.PrepareContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("error preparing query '%s': %w", query, err)
+		}
+
+		if _, loaded := s.stmts.LoadOrStore(query, stmt); loaded {
+			stmt.Close()
+		}
+	}
+
+	return nil
+}
+
+func (s SQLAdapter) preparedStmt(query string) *sql.Stmt {
+	if s.stmts == nil {
+		return nil
+	}
+
+	stmt, found := s.stmts.Load(query)
+	if !found {
+		return nil
+	}
+	return stmt.(*sql.Stmt)
+}
+
 // BeginTx implements the Tx interface
 func (s SQLAdapter) BeginTx(ctx context.Context) (ksql.Tx, error) {
 	tx, err := s.DB.BeginTx(ctx, nil)
@@ -40,9 +91,68 @@ func (s SQLAdapter) BeginTx(ctx context.Context) (ksql.Tx, error) {
 
 // Close implements the io.Closer interface
 func (s SQLAdapter) Close() error {
+	if s.stmts != nil {
+		s.stmts.Range(func(query, stmt interface{}) bool {
+			stmt.(*sql.Stmt).Close()
+			return true
+		})
+	}
 	return s.DB.Close()
 }
 
+// Unwrap implements the ksql.Unwrapper interface
+func (s SQLAdapter) Unwrap() interface{} {
+	return s.DB
+}
+
+// PoolStats implements the ksql.PoolStatsReporter interface
+func (s SQLAdapter) PoolStats() ksql.PoolStats {
+	stats := s.DB.Stats()
+	return ksql.PoolStats{
+		MaxOpenConns: stats.MaxOpenConnections,
+		OpenConns:    stats.OpenConnections,
+		InUse:        stats.InUse,
+		Idle:         stats.Idle,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration,
+	}
+}
+
+// AcquireConn implements the ConnAcquirer interface
+func (s SQLAdapter) AcquireConn(ctx context.Context) (ksql.Conn, error) {
+	conn, err := s.DB.Conn(ctx)
+	return SQLConn{Conn: conn}, err
+}
+
+// SQLConn is used to implement the DBAdapter interface and implements
+// the Conn interface
+type SQLConn struct {
+	*sql.Conn
+}
+
+// ExecContext implements the Conn interface
+func (s SQLConn) ExecContext(ctx context.Context, query string, args ...interface{}) (ksql.Result, error) {
+	return s.Conn.ExecContext(ctx, query, args...)
+}
+
+// QueryContext implements the Conn interface
+func (s SQLConn) QueryContext(ctx context.Context, query string, args ...interface{}) (ksql.Rows, error) {
+	return s.Conn.QueryContext(ctx, query, args...)
+}
+
+// BeginTx implements the Conn interface
+func (s SQLConn) BeginTx(ctx context.Context) (ksql.Tx, error) {
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	return SQLTx{Tx: tx}, err
+}
+
+// Unwrap implements the ksql.Unwrapper interface
+func (s SQLConn) Unwrap() interface{} {
+	return s.Conn
+}
+
+var _ ksql.Conn = SQLConn{}
+
 // SQLTx is used to implement the DBAdapter interface and implements
 // the Tx interface
 type SQLTx struct {
@@ -69,4 +179,9 @@ func (s SQLTx) Commit(ctx context.Context) error {
 	return s.Tx.Commit()
 }
 
+// Unwrap implements the ksql.Unwrapper interface
+func (s SQLTx) Unwrap() interface{} {
+	return s.Tx
+}
+
 var _ ksql.Tx = SQLTx{}
@@ -10,6 +10,7 @@ require (
 	github.com/containerd/continuity v0.2.2 // indirect
 	github.com/denisenkom/go-mssqldb v0.10.0
 	github.com/docker/go-connections v0.4.0 // indirect
+	github.com/golang-sql/civil v0.0.0-20190719163853-cb61b32ac6fe
 	github.com/gotestyourself/gotestyourself v2.2.0+incompatible // indirect
 	github.com/lib/pq v1.10.4 // indirect
 	github.com/opencontainers/image-spec v1.0.2 // indirect
@@ -19,3 +20,5 @@ require (
 	golang.org/x/net v0.0.0-20220225172249-27dd8689420f // indirect
 	gotest.tools v2.2.0+incompatible // indirect
 )
+
+replace github.com/vingarcia/ksql => ../../
@@ -3,6 +3,9 @@ package ksqlserver
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/vingarcia/ksql"
 
@@ -12,8 +15,18 @@ import (
 )
 
 // NewFromSQLDB builds a ksql.DB from a *sql.DB instance
-func NewFromSQLDB(db *sql.DB) (ksql.DB, error) {
-	return ksql.NewWithAdapter(NewSQLAdapter(db), "sqlserver")
+func NewFromSQLDB(db *sql.DB, opts ...ksql.Option) (ksql.DB, error) {
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "sqlserver", opts...)
+}
+
+// SQLDBFromDB returns the *sql.DB used by a ksql.DB built by this package,
+// an error is returned if the ksql.DB is using a transaction or another adapter.
+func SQLDBFromDB(db ksql.DB) (*sql.DB, error) {
+	sqlDB, ok := db.Unwrap().(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("ksqlserver: expected the ksql.DB to be using a *sql.DB but got: %T", db.Unwrap())
+	}
+	return sqlDB, nil
 }
 
 // New instantiates a new KissSQL client using the "sqlserver" driver
@@ -21,9 +34,18 @@ func New(
 	_ context.Context,
 	connectionString string,
 	config ksql.Config,
+	opts ...ksql.Option,
 ) (ksql.DB, error) {
 	config.SetDefaultValues()
 
+	if config.ApplicationName != "" {
+		var err error
+		connectionString, err = setAppName(connectionString, config.ApplicationName)
+		if err != nil {
+			return ksql.DB{}, err
+		}
+	}
+
 	db, err := sql.Open("sqlserver", connectionString)
 	if err != nil {
 		return ksql.DB{}, err
@@ -34,5 +56,28 @@ func New(
 
 	db.SetMaxOpenConns(config.MaxOpenConns)
 
-	return ksql.NewWithAdapter(NewSQLAdapter(db), "sqlserver")
+	return ksql.NewWithAdapter(NewSQLAdapter(db), "sqlserver", opts...)
+}
+
+// setAppName adds the `app name` param to the connection string, which
+// is reported as the program_name of the sessions, e.g. on sp_who2,
+// for both the URL and the key/value formats of the connection strings.
+func setAppName(connectionString string, appName string) (string, error) {
+	if strings.HasPrefix(connectionString, "sqlserver://") {
+		u, err := url.Parse(connectionString)
+		if err != nil {
+			return "", fmt.Errorf("ksqlserver: unable to parse the connection string: %w", err)
+		}
+
+		query := u.Query()
+		query.Set("app name", appName)
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	if strings.ContainsAny(appName, ";{}") {
+		return "", fmt.Errorf("ksqlserver: the ApplicationName can't contain the characters ';', '{' or '}': %q", appName)
+	}
+
+	return strings.TrimRight(connectionString, "; ") + ";app name=" + appName, nil
 }
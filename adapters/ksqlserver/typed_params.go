@@ -0,0 +1,75 @@
+package ksqlserver
+
+import (
+	"fmt"
+	"time"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/golang-sql/civil"
+	"github.com/vingarcia/ksql"
+)
+
+var (
+	_ ksql.ArgEncoder = SQLAdapter{}
+	_ ksql.ArgEncoder = SQLConn{}
+	_ ksql.ArgEncoder = SQLTx{}
+)
+
+// EncodeArg implements the ksql.ArgEncoder interface, replacing the
+// ksql.TypedParam arguments, which are sent for the attributes tagged
+// with the `sqltype` modifier, by the types the driver uses for sending
+// the params with these SQL types.
+func (s SQLAdapter) EncodeArg(arg interface{}) (interface{}, error) {
+	return encodeArg(arg)
+}
+
+// EncodeArg implements the ksql.ArgEncoder interface, see SQLAdapter.EncodeArg
+func (s SQLConn) EncodeArg(arg interface{}) (interface{}, error) {
+	return encodeArg(arg)
+}
+
+// EncodeArg implements the ksql.ArgEncoder interface, see SQLAdapter.EncodeArg
+func (s SQLTx) EncodeArg(arg interface{}) (interface{}, error) {
+	return encodeArg(arg)
+}
+
+func encodeArg(arg interface{}) (interface{}, error) {
+	param, ok := arg.(ksql.TypedParam)
+	if !ok {
+		return arg, nil
+	}
+
+	return convertTypedParam(param)
+}
+
+func convertTypedParam(param ksql.TypedParam) (interface{}, error) {
+	value, err := param.Value()
+	if err != nil || value == nil {
+		return value, err
+	}
+
+	switch v := value.(type) {
+	case string:
+		switch param.SQLType {
+		case "varchar":
+			return mssql.VarChar(v), nil
+		case "varchar(max)":
+			return mssql.VarCharMax(v), nil
+		case "nvarchar(max)":
+			return mssql.NVarCharMax(v), nil
+		}
+	case time.Time:
+		switch param.SQLType {
+		case "datetime":
+			return mssql.DateTime1(v), nil
+		case "datetime2":
+			return civil.DateTimeOf(v), nil
+		case "datetimeoffset":
+			return mssql.DateTimeOffset(v), nil
+		case "date":
+			return civil.DateOf(v), nil
+		}
+	}
+
+	return nil, fmt.Errorf("ksqlserver: unable to send a param of type %T as %s", value, param.SQLType)
+}
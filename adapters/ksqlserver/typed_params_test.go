@@ -0,0 +1,53 @@
+package ksqlserver
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/golang-sql/civil"
+	"github.com/vingarcia/ksql"
+)
+
+func TestEncodeArg(t *testing.T) {
+	now := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	email := "john@example.com"
+	var nilEmail *string
+
+	var args []interface{}
+	for _, arg := range []interface{}{
+		ksql.TypedParam{Param: "john", SQLType: "varchar"},
+		ksql.TypedParam{Param: &email, SQLType: "varchar(max)"},
+		ksql.TypedParam{Param: nilEmail, SQLType: "varchar"},
+		ksql.TypedParam{Param: now, SQLType: "datetime"},
+		ksql.TypedParam{Param: now, SQLType: "datetime2"},
+		ksql.TypedParam{Param: &now, SQLType: "date"},
+		42,
+	} {
+		encoded, err := SQLAdapter{}.EncodeArg(arg)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		args = append(args, encoded)
+	}
+
+	expected := []interface{}{
+		mssql.VarChar("john"),
+		mssql.VarCharMax(email),
+		nil,
+		mssql.DateTime1(now),
+		civil.DateTimeOf(now),
+		civil.DateOf(now),
+		42,
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("expected %#v but got %#v", expected, args)
+	}
+
+	_, err := SQLTx{}.EncodeArg(ksql.TypedParam{Param: 42, SQLType: "varchar"})
+	if err == nil || !strings.Contains(err.Error(), "varchar") {
+		t.Fatalf("expected an error mentioning varchar but got: %v", err)
+	}
+}
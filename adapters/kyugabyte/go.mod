@@ -0,0 +1,15 @@
+module github.com/vingarcia/ksql/adapters/kyugabyte
+
+go 1.14
+
+require (
+	github.com/jackc/pgconn v1.10.0
+	github.com/jackc/pgx/v4 v4.13.0
+	github.com/vingarcia/ksql v1.4.6
+	github.com/vingarcia/ksql/adapters/kpgx v1.4.6
+)
+
+replace (
+	github.com/vingarcia/ksql => ../../
+	github.com/vingarcia/ksql/adapters/kpgx => ../kpgx
+)
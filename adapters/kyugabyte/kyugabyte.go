@@ -0,0 +1,66 @@
+package kyugabyte
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/vingarcia/ksql"
+	"github.com/vingarcia/ksql/adapters/kpgx"
+)
+
+// New instantiates a new ksql.DB for YugabyteDB using pgx as the backend driver
+// and the "postgres" dialect.
+//
+// It accepts the load balancing params of the Yugabyte smart drivers:
+//
+//	postgres://yugabyte@host:5433/yugabyte?load_balance=true&topology_keys=aws.us-east-1.us-east-1a
+//
+// With `load_balance=true` the tablet servers of the cluster are loaded with
+// yb_servers() and each new connection of the pool is opened to the server with
+// the fewest connections, the `topology_keys` param restricts the servers to
+// the listed placements, e.g. `cloud.region.zone` or `cloud.region.*`, and
+// accepts fallback placements with preference values, e.g. `aws.us-east-1.*:2`.
+// The list of servers is refreshed every `yb_servers_refresh_interval` seconds,
+// which defaults to 300.
+//
+// The errors caused by conflicts between transactions
+// can be detected with the IsRetryable function.
+func New(
+	ctx context.Context,
+	connectionString string,
+	config ksql.Config,
+	opts ...ksql.Option,
+) (db ksql.DB, err error) {
+	config.SetDefaultValues()
+
+	pgxConf, err := pgxpool.ParseConfig(connectionString)
+	if err != nil {
+		return ksql.DB{}, err
+	}
+
+	pgxConf.MaxConns = int32(config.MaxOpenConns)
+	if config.TransactionPooling {
+		kpgx.ConfigureForTransactionPooling(pgxConf)
+	}
+	if config.ApplicationName != "" {
+		pgxConf.ConnConfig.RuntimeParams["application_name"] = config.ApplicationName
+	}
+
+	lb, err := newLoadBalancer(pgxConf)
+	if err != nil {
+		return ksql.DB{}, err
+	}
+	if lb != nil {
+		lb.install(pgxConf)
+	}
+
+	pool, err := pgxpool.ConnectConfig(ctx, pgxConf)
+	if err != nil {
+		return ksql.DB{}, err
+	}
+	if err = pool.Ping(ctx); err != nil {
+		return ksql.DB{}, err
+	}
+
+	return ksql.NewWithAdapter(kpgx.NewPGXAdapter(pool), "postgres", opts...)
+}
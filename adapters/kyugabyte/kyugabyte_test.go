@@ -0,0 +1,241 @@
+package kyugabyte
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+func TestNewLoadBalancer(t *testing.T) {
+	t.Run("should remove the smart driver params from the runtime params", func(t *testing.T) {
+		config, err := pgxpool.ParseConfig("postgres://yugabyte@127.0.0.1:5433/yugabyte?load_balance=true&topology_keys=aws.us-east-1.us-east-1a,aws.us-east-1.*:2&yb_servers_refresh_interval=60&application_name=fake-app")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		lb, err := newLoadBalancer(config)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if lb == nil {
+			t.Fatal("expected the load balancer to be enabled")
+		}
+
+		expectedParams := map[string]string{"application_name": "fake-app"}
+		if !reflect.DeepEqual(config.ConnConfig.RuntimeParams, expectedParams) {
+			t.Errorf("expected runtime params %v but got %v", expectedParams, config.ConnConfig.RuntimeParams)
+		}
+
+		expectedKeys := []topologyKey{
+			{cloud: "aws", region: "us-east-1", zone: "us-east-1a", preference: 1},
+			{cloud: "aws", region: "us-east-1", zone: "*", preference: 2},
+		}
+		if !reflect.DeepEqual(lb.topologyKeys, expectedKeys) {
+			t.Errorf("expected topology keys %v but got %v", expectedKeys, lb.topologyKeys)
+		}
+		if lb.refreshInterval.Seconds() != 60 {
+			t.Errorf("expected refresh interval of 60s but got %s", lb.refreshInterval)
+		}
+	})
+
+	t.Run("should be disabled by default", func(t *testing.T) {
+		for _, connString := range []string{
+			"postgres://yugabyte@127.0.0.1:5433/yugabyte",
+			"host=127.0.0.1 port=5433 user=yugabyte load_balance=false",
+		} {
+			config, err := pgxpool.ParseConfig(connString)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			lb, err := newLoadBalancer(config)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if lb != nil {
+				t.Errorf("expected the load balancer to be disabled for: %s", connString)
+			}
+		}
+	})
+
+	t.Run("should reject invalid params", func(t *testing.T) {
+		for _, params := range []string{
+			"load_balance=maybe",
+			"topology_keys=aws.us-east-1.us-east-1a",
+			"load_balance=true&topology_keys=aws.us-east-1",
+			"load_balance=true&topology_keys=aws.us-east-1.*:0",
+			"load_balance=true&yb_servers_refresh_interval=-1",
+		} {
+			config, err := pgxpool.ParseConfig("postgres://yugabyte@127.0.0.1:5433/yugabyte?" + params)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			_, err = newLoadBalancer(config)
+			if err == nil {
+				t.Errorf("expected an error for the params: %s", params)
+			}
+		}
+	})
+}
+
+func TestLoadBalancerPick(t *testing.T) {
+	servers := []server{
+		{host: "10.0.0.1", port: 5433, cloud: "aws", region: "us-east-1", zone: "us-east-1a", publicIP: "3.0.0.1"},
+		{host: "10.0.0.2", port: 5433, cloud: "aws", region: "us-east-1", zone: "us-east-1b", publicIP: "3.0.0.2"},
+		{host: "10.0.0.3", port: 5433, cloud: "aws", region: "us-west-2", zone: "us-west-2a", publicIP: "3.0.0.3"},
+	}
+
+	newBalancer := func(seedHost string, keys ...topologyKey) *loadBalancer {
+		config, err := pgxpool.ParseConfig(fmt.Sprintf("postgres://yugabyte@%s:5433/yugabyte", seedHost))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		lb := &loadBalancer{
+			seedConfig:   config.ConnConfig,
+			topologyKeys: keys,
+			numConns:     map[string]int{},
+		}
+		lb.setServers(servers)
+		return lb
+	}
+
+	t.Run("should pick the server with the fewest connections", func(t *testing.T) {
+		lb := newBalancer("10.0.0.1")
+		lb.numConns["10.0.0.1:5433"] = 2
+		lb.numConns["10.0.0.2:5433"] = 1
+		lb.numConns["10.0.0.3:5433"] = 3
+
+		host, port, ok := lb.pick()
+		if !ok || host != "10.0.0.2" || port != 5433 {
+			t.Errorf("expected 10.0.0.2:5433 but got %s:%d (ok: %v)", host, port, ok)
+		}
+	})
+
+	t.Run("should prefer the servers of the topology keys", func(t *testing.T) {
+		lb := newBalancer("10.0.0.1",
+			topologyKey{cloud: "aws", region: "us-west-2", zone: "*", preference: 1},
+			topologyKey{cloud: "aws", region: "us-east-1", zone: "us-east-1b", preference: 2},
+		)
+		lb.numConns["10.0.0.3:5433"] = 10
+
+		host, _, _ := lb.pick()
+		if host != "10.0.0.3" {
+			t.Errorf("expected the server of the preferred placement but got %s", host)
+		}
+
+		lb.markUnavailable("10.0.0.3:5433")
+		host, _, _ = lb.pick()
+		if host != "10.0.0.2" {
+			t.Errorf("expected the server of the fallback placement but got %s", host)
+		}
+
+		lb.markUnavailable("10.0.0.2:5433")
+		host, _, _ = lb.pick()
+		if host != "10.0.0.1" {
+			t.Errorf("expected the servers outside of the placements to be used last but got %s", host)
+		}
+
+		lb.markUnavailable("10.0.0.1:5433")
+		_, _, ok := lb.pick()
+		if ok {
+			t.Errorf("expected no servers to be available")
+		}
+	})
+
+	t.Run("should use the public IPs if the seed host is a public IP", func(t *testing.T) {
+		lb := newBalancer("3.0.0.2")
+
+		host, _, _ := lb.pick()
+		if host != "3.0.0.1" {
+			t.Errorf("expected a public IP but got %s", host)
+		}
+	})
+
+	t.Run("should count the connections of each server", func(t *testing.T) {
+		lb := newBalancer("10.0.0.1")
+		client, server := net.Pipe()
+		defer server.Close()
+
+		conn := lb.track("10.0.0.1:5433", client)
+		if lb.numConns["10.0.0.1:5433"] != 1 {
+			t.Errorf("expected 1 connection but got %d", lb.numConns["10.0.0.1:5433"])
+		}
+
+		conn.Close()
+		conn.Close()
+		if lb.numConns["10.0.0.1:5433"] != 0 {
+			t.Errorf("expected 0 connections but got %d", lb.numConns["10.0.0.1:5433"])
+		}
+	})
+}
+
+func TestLoadBalancerInstall(t *testing.T) {
+	config, err := pgxpool.ParseConfig("postgres://yugabyte@10.0.0.1:5433/yugabyte?load_balance=true")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	lb, err := newLoadBalancer(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dialErr := errors.New("fake-dial-error")
+	config.ConnConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, dialErr
+	}
+	lb.install(config)
+
+	// Avoids connecting to the seed host for loading the servers:
+	lb.refreshInterval = 1 << 62
+	lb.setServers([]server{{host: "10.0.0.2", port: 5434}})
+
+	connConfig := config.ConnConfig.Copy()
+	err = config.BeforeConnect(context.Background(), connConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if connConfig.Host != "10.0.0.2" || connConfig.Port != 5434 {
+		t.Errorf("expected the connection to use the picked server but got %s:%d", connConfig.Host, connConfig.Port)
+	}
+
+	_, err = config.ConnConfig.DialFunc(context.Background(), "tcp", "10.0.0.2:5434")
+	if err != dialErr {
+		t.Fatalf("expected the dial error but got: %v", err)
+	}
+	if !lb.unavailable["10.0.0.2:5434"] {
+		t.Errorf("expected the server to be marked as unavailable")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		desc     string
+		err      error
+		expected bool
+	}{
+		{desc: "nil", err: nil, expected: false},
+		{desc: "other errors", err: errors.New("fake-error"), expected: false},
+		{desc: "serialization failure", err: fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "40001"}), expected: true},
+		{desc: "deadlock", err: &pgconn.PgError{Code: "40P01"}, expected: true},
+		{desc: "read restart", err: &pgconn.PgError{Code: "XX000", Message: "Restart read required at: ..."}, expected: true},
+		{desc: "aborted by a conflict", err: &pgconn.PgError{Code: "XX000", Message: "Transaction expired or aborted by a conflict"}, expected: true},
+		{desc: "unique violation", err: &pgconn.PgError{Code: "23505", Message: "duplicate key value"}, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := IsRetryable(test.err); got != test.expected {
+				t.Errorf("expected %v but got %v", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package kyugabyte
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// The connection parameters of the Yugabyte smart drivers, they are
+// removed from the runtime params since the server doesn't know them.
+const (
+	loadBalanceParam     = "load_balance"
+	topologyKeysParam    = "topology_keys"
+	refreshIntervalParam = "yb_servers_refresh_interval"
+)
+
+const defaultRefreshInterval = 5 * time.Minute
+
+// server is one of the tablet servers returned by yb_servers()
+type server struct {
+	host     string
+	port     uint16
+	cloud    string
+	region   string
+	zone     string
+	publicIP string
+}
+
+// topologyKey matches the servers of a placement, e.g. `aws.us-east-1.us-east-1a`,
+// where the zone might be `*`, servers matching keys with lower preference
+// values are used first and the others only if those are unavailable.
+type topologyKey struct {
+	cloud      string
+	region     string
+	zone       string
+	preference int
+}
+
+func (k topologyKey) matches(s server) bool {
+	return k.cloud == s.cloud && k.region == s.region && (k.zone == "*" || k.zone == s.zone)
+}
+
+func parseTopologyKeys(rawKeys string) ([]topologyKey, error) {
+	var keys []topologyKey
+	for _, rawKey := range strings.Split(rawKeys, ",") {
+		rawKey = strings.TrimSpace(rawKey)
+		if rawKey == "" {
+			continue
+		}
+
+		key := topologyKey{preference: 1}
+		if idx := strings.LastIndexByte(rawKey, ':'); idx != -1 {
+			preference, err := strconv.Atoi(rawKey[idx+1:])
+			if err != nil || preference < 1 {
+				return nil, fmt.Errorf("kyugabyte: invalid preference on topology key `%s`", rawKey)
+			}
+			key.preference = preference
+			rawKey = rawKey[:idx]
+		}
+
+		parts := strings.Split(rawKey, ".")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("kyugabyte: topology keys should have the format cloud.region.zone but got `%s`", rawKey)
+		}
+		key.cloud, key.region, key.zone = parts[0], parts[1], parts[2]
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// loadBalancer spreads the connections of the pool over the tablet servers
+// of the cluster, choosing the server with the fewest connections opened by
+// the pool among the ones in the preferred placements.
+type loadBalancer struct {
+	seedConfig      *pgx.ConnConfig
+	topologyKeys    []topologyKey
+	refreshInterval time.Duration
+
+	mu          sync.Mutex
+	servers     []server
+	lastRefresh time.Time
+	usePublicIP bool
+	numConns    map[string]int
+	unavailable map[string]bool
+}
+
+// newLoadBalancer reads and removes the load balancing params of the input
+// config, it returns nil if the `load_balance` param is not set to true.
+func newLoadBalancer(config *pgxpool.Config) (*loadBalancer, error) {
+	params := config.ConnConfig.RuntimeParams
+	loadBalance, rawKeys, rawInterval := params[loadBalanceParam], params[topologyKeysParam], params[refreshIntervalParam]
+	delete(params, loadBalanceParam)
+	delete(params, topologyKeysParam)
+	delete(params, refreshIntervalParam)
+
+	if loadBalance == "" {
+		if rawKeys != "" {
+			return nil, fmt.Errorf("kyugabyte: the %s param requires %s=true", topologyKeysParam, loadBalanceParam)
+		}
+		return nil, nil
+	}
+
+	enabled, err := strconv.ParseBool(loadBalance)
+	if err != nil {
+		return nil, fmt.Errorf("kyugabyte: invalid value for %s: `%s`", loadBalanceParam, loadBalance)
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	topologyKeys, err := parseTopologyKeys(rawKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshInterval := defaultRefreshInterval
+	if rawInterval != "" {
+		seconds, err := strconv.Atoi(rawInterval)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("kyugabyte: invalid value for %s: `%s`", refreshIntervalParam, rawInterval)
+		}
+		refreshInterval = time.Duration(seconds) * time.Second
+	}
+
+	return &loadBalancer{
+		seedConfig:      config.ConnConfig.Copy(),
+		topologyKeys:    topologyKeys,
+		refreshInterval: refreshInterval,
+		numConns:        map[string]int{},
+		unavailable:     map[string]bool{},
+	}, nil
+}
+
+// install sets the pool hooks that pick the server of each new connection
+// and keep track of the connections opened to each server.
+func (lb *loadBalancer) install(config *pgxpool.Config) {
+	dial := config.ConnConfig.DialFunc
+	config.ConnConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			lb.markUnavailable(addr)
+			return nil, err
+		}
+		return lb.track(addr, conn), nil
+	}
+
+	beforeConnect := config.BeforeConnect
+	config.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		if beforeConnect != nil {
+			if err := beforeConnect(ctx, connConfig); err != nil {
+				return err
+			}
+		}
+
+		lb.refreshIfExpired(ctx)
+		if host, port, ok := lb.pick(); ok {
+			connConfig.Host = host
+			connConfig.Port = port
+			// The seed hosts are only used when the servers are unknown:
+			connConfig.Fallbacks = nil
+		}
+		return nil
+	}
+}
+
+func (lb *loadBalancer) refreshIfExpired(ctx context.Context) {
+	lb.mu.Lock()
+	expired := time.Since(lb.lastRefresh) >= lb.refreshInterval
+	lb.mu.Unlock()
+	if !expired {
+		return
+	}
+
+	servers, err := lb.fetchServers(ctx)
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.lastRefresh = time.Now()
+	if err != nil {
+		// The last known servers are kept until the next refresh:
+		return
+	}
+	lb.setServers(servers)
+}
+
+// fetchServers loads the tablet servers using a connection to the seed hosts
+func (lb *loadBalancer) fetchServers(ctx context.Context) ([]server, error) {
+	conn, err := pgx.ConnectConfig(ctx, lb.seedConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	rows, err := conn.Query(ctx, "SELECT host, port, cloud, region, zone, public_ip FROM yb_servers()")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var servers []server
+	for rows.Next() {
+		var s server
+		var port int64
+		err := rows.Scan(&s.host, &port, &s.cloud, &s.region, &s.zone, &s.publicIP)
+		if err != nil {
+			return nil, err
+		}
+		s.port = uint16(port)
+		servers = append(servers, s)
+	}
+
+	return servers, rows.Err()
+}
+
+// setServers updates the known servers, it must be called with the lock held
+func (lb *loadBalancer) setServers(servers []server) {
+	lb.servers = servers
+	lb.unavailable = map[string]bool{}
+
+	// The public IPs are used when the application connects
+	// to the cluster through them, e.g. from outside of its network:
+	lb.usePublicIP = false
+	for _, s := range servers {
+		if s.publicIP != "" && s.publicIP == lb.seedConfig.Host {
+			lb.usePublicIP = true
+		}
+	}
+}
+
+func (lb *loadBalancer) host(s server) string {
+	if lb.usePublicIP && s.publicIP != "" {
+		return s.publicIP
+	}
+	return s.host
+}
+
+// address returns the address dialed for connecting to the server,
+// the connections and failures are tracked by this address.
+func (lb *loadBalancer) address(s server) string {
+	return net.JoinHostPort(lb.host(s), strconv.Itoa(int(s.port)))
+}
+
+// pick returns the available server with the fewest connections among the ones
+// matching the topology keys with the lowest preference value, or any available
+// server if none of them match.
+func (lb *loadBalancer) pick() (host string, port uint16, ok bool) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	var best *server
+	var bestPreference, bestConns int
+	for i := range lb.servers {
+		s := &lb.servers[i]
+		addr := lb.address(*s)
+		if lb.unavailable[addr] {
+			continue
+		}
+
+		preference, conns := lb.preference(*s), lb.numConns[addr]
+		if best == nil || preference < bestPreference || (preference == bestPreference && conns < bestConns) {
+			best, bestPreference, bestConns = s, preference, conns
+		}
+	}
+
+	if best == nil {
+		return "", 0, false
+	}
+	return lb.host(*best), best.port, true
+}
+
+// preference returns the preference of the first topology key matching
+// the server, servers matching no keys have the highest preference value.
+func (lb *loadBalancer) preference(s server) int {
+	if len(lb.topologyKeys) == 0 {
+		return 0
+	}
+
+	preference := int(^uint(0) >> 1)
+	for _, key := range lb.topologyKeys {
+		if key.matches(s) && key.preference < preference {
+			preference = key.preference
+		}
+	}
+	return preference
+}
+
+func (lb *loadBalancer) markUnavailable(addr string) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.unavailable[addr] = true
+}
+
+func (lb *loadBalancer) track(addr string, conn net.Conn) net.Conn {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.numConns[addr]++
+
+	return &trackedConn{
+		Conn: conn,
+		onClose: func() {
+			lb.mu.Lock()
+			defer lb.mu.Unlock()
+			lb.numConns[addr]--
+		},
+	}
+}
+
+// trackedConn decrements the connection count of its server when closed
+type trackedConn struct {
+	net.Conn
+
+	closeOnce sync.Once
+	onClose   func()
+}
+
+func (c *trackedConn) Close() error {
+	c.closeOnce.Do(c.onClose)
+	return c.Conn.Close()
+}
@@ -0,0 +1,53 @@
+package kyugabyte
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgconn"
+)
+
+// IsRetryable checks if the error was caused by a conflict between
+// transactions that YugabyteDB expects the client to retry, e.g.:
+//
+//	for attempt := 0; ; attempt++ {
+//		err = db.Transaction(ctx, func(db ksql.Provider) error {
+//			// ...
+//		})
+//		if attempt == maxAttempts || !kyugabyte.IsRetryable(err) {
+//			break
+//		}
+//	}
+//
+// Since YugabyteDB uses optimistic concurrency control by default
+// these errors are much more common than on Postgres, and they are
+// also returned for read restarts caused by clock skew between nodes.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	switch pgErr.Code {
+	case "40001", // serialization_failure
+		"40P01": // deadlock_detected
+		return true
+	}
+
+	// Older YugabyteDB versions report some of the
+	// conflicts with other codes, e.g. internal_error:
+	msg := strings.ToLower(pgErr.Message)
+	for _, retryMsg := range []string{
+		"restart read required",
+		"try again",
+		"conflicts with higher priority transaction",
+		"transaction aborted",
+		"expired or aborted by a conflict",
+	} {
+		if strings.Contains(msg, retryMsg) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,188 @@
+package ksql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// QueryAggregate runs a query whose results don't map to the columns of a
+// table, e.g. queries using GROUP BY, DISTINCT or aggregate functions,
+// and scans the results into the `result` argument, e.g.:
+//
+//	var statuses []struct {
+//		Status string
+//		Total  int
+//	}
+//	err := db.QueryAggregate(ctx, &statuses, "SELECT status, count(*) AS total FROM jobs GROUP BY status")
+//
+// The `result` argument must be a pointer to one of:
+//
+//   - A primitive type, e.g. `*int`, for queries returning a single column and a single row;
+//   - A slice of primitive types, e.g. `*[]string`, for queries returning a single column;
+//   - A struct or a slice of structs.
+//
+// When scanning into structs the columns are matched with the `ksql` tag
+// of the attribute if it has one, or with the name of the attribute otherwise,
+// ignoring case and underscores, so the `tablename` tag is never necessary.
+// Columns with no matching attribute are ignored.
+//
+// The SELECT part of the query is never generated by this function and
+// if the destination is not a slice QueryAggregate returns ErrRecordNotFound
+// if the query returns no rows.
+func (c DB) QueryAggregate(
+	ctx context.Context,
+	result interface{},
+	query string,
+	params ...interface{},
+) error {
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).QueryAggregate(ctx, result, query, params...)
+		})
+	}
+
+	v := reflect.ValueOf(result)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("ksql: expected to receive a non-nil pointer as result, but got: %T", result)
+	}
+
+	target := v.Elem()
+	elemType := target.Type()
+	isSlice := target.Kind() == reflect.Slice && elemType.Elem().Kind() != reflect.Uint8
+	if isSlice {
+		elemType = elemType.Elem()
+	}
+
+	opts, params := extractQueryOptions(params)
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	if opts.limit != nil {
+		var err error
+		query, err = buildLimitQuery(c.dialect, query, *opts.limit)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.forUpdate {
+		var err error
+		query, err = buildForUpdateQuery(c.dialect, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	rows, err := c.queryContext(ctx, OpInfo{Method: "QueryAggregate"}, opts, query, params...)
+	if err == errDryRun {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error running query")
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	slice := reflect.MakeSlice(reflect.SliceOf(elemType), 0, 0)
+	for rows.Next() {
+		elem := reflect.New(elemType).Elem()
+		scanArgs, err := getAggregateScanArgs(columns, elem, opts.strictScan)
+		if err != nil {
+			return err
+		}
+
+		err = rows.Scan(scanArgs...)
+		if err != nil {
+			return err
+		}
+
+		if !isSlice {
+			target.Set(elem)
+			return rows.Close()
+		}
+
+		slice = reflect.Append(slice, elem)
+	}
+
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	if !isSlice {
+		return ErrRecordNotFound
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	target.Set(slice)
+	return nil
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+func getAggregateScanArgs(columns []string, v reflect.Value, strict bool) ([]interface{}, error) {
+	t := v.Type()
+	if t.Kind() != reflect.Struct || t == timeType || reflect.PtrTo(t).Implements(scannerType) {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf(
+				"ksql: expected query to return a single column when scanning into %v, but got %d columns",
+				t, len(columns),
+			)
+		}
+		return []interface{}{v.Addr().Interface()}, nil
+	}
+
+	fieldsByName := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// Private attributes can't be set:
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("ksql"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldsByName[normalizeAggregateName(name)] = i
+	}
+
+	scanArgs := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		idx, found := fieldsByName[normalizeAggregateName(column)]
+		if !found && strict {
+			return nil, newStrictScanError(column, t)
+		}
+		if !found {
+			scanArgs = append(scanArgs, nopScannerValue)
+			continue
+		}
+
+		scanArgs = append(scanArgs, v.Field(idx).Addr().Interface())
+	}
+
+	return scanArgs, nil
+}
+
+func normalizeAggregateName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
@@ -0,0 +1,75 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestQueryAggregate(t *testing.T) {
+	t.Run("should match struct attributes ignoring case and underscores", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newMockRows(
+					[]string{"job_status", "TOTAL", "unknown_column"},
+					[]interface{}{"done", 10, "ignored"},
+					[]interface{}{"failed", 2, "ignored"},
+				), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var results []struct {
+			JobStatus string
+			Total     int
+			private   int
+		}
+		err = db.QueryAggregate(context.Background(), &results, "SELECT job_status, count(*) AS TOTAL, 1 AS unknown_column FROM jobs GROUP BY job_status")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, len(results), 2)
+		tt.AssertEqual(t, results[0].JobStatus, "done")
+		tt.AssertEqual(t, results[0].Total, 10)
+		tt.AssertEqual(t, results[1].JobStatus, "failed")
+		tt.AssertEqual(t, results[1].Total, 2)
+	})
+
+	t.Run("should treat time.Time as a primitive", func(t *testing.T) {
+		now := time.Now()
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"max"}, []interface{}{now}), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var lastUpdate time.Time
+		err = db.QueryAggregate(context.Background(), &lastUpdate, "SELECT max(updated_at) FROM jobs")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, lastUpdate, now)
+	})
+
+	t.Run("should report error if a primitive receives more than one column", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"status", "total"}, []interface{}{"done", 10}), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var totals []int
+		err = db.QueryAggregate(context.Background(), &totals, "SELECT status, count(*) FROM jobs GROUP BY status")
+		tt.AssertErrContains(t, err, "single column", "int", "2 columns")
+	})
+
+	t.Run("should report error if the result is not a pointer", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var total int
+		err = db.QueryAggregate(context.Background(), total, "SELECT count(*) FROM jobs")
+		tt.AssertErrContains(t, err, "non-nil pointer", "int")
+	})
+}
@@ -0,0 +1,225 @@
+// Package analyzer provides a go/analysis pass that reports misuses
+// of the `ksql` and `tablename` struct tags at build time, which
+// would otherwise only be detected at runtime by KSQL.
+//
+// It can be run with go vet using the ksqlvet command:
+//
+//	go install github.com/vingarcia/ksql/analyzer/cmd/ksqlvet@latest
+//	go vet -vettool=$(which ksqlvet) ./...
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer reports duplicated ksql tags, tags on unexported fields,
+// invalid modifiers and misuses of the `tablename` tag.
+var Analyzer = &analysis.Analyzer{
+	Name:     "ksqltags",
+	Doc:      "check the ksql and tablename struct tags",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	inspect.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(node ast.Node) {
+		structType, ok := pass.TypesInfo.Types[node.(*ast.StructType)].Type.(*types.Struct)
+		if !ok {
+			return
+		}
+
+		checkStruct(pass, node.(*ast.StructType), structType)
+	})
+
+	return nil, nil
+}
+
+func checkStruct(pass *analysis.Pass, node *ast.StructType, structType *types.Struct) {
+	var hasKsqlTags, hasTablenameTags bool
+	columns := map[string]bool{}
+	tablenames := map[string]bool{}
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		tag := reflect.StructTag(structType.Tag(i))
+		if value, found := tag.Lookup("ksql"); found {
+			hasKsqlTags = true
+			checkKsqlTag(pass, field, value, columns)
+		}
+
+		if value, found := tag.Lookup("tablename"); found {
+			hasTablenameTags = true
+			checkTablenameTag(pass, field, value, tablenames)
+		}
+	}
+
+	if hasKsqlTags {
+		// KSQL rejects these structs at runtime even if the unexported fields have no tags:
+		for i := 0; i < structType.NumFields(); i++ {
+			field := structType.Field(i)
+			if _, found := reflect.StructTag(structType.Tag(i)).Lookup("ksql"); !found && !field.Exported() {
+				pass.Reportf(field.Pos(), "unexported field %s: all fields of structs using the ksql tags must be exported", field.Name())
+			}
+		}
+	}
+
+	if hasKsqlTags && hasTablenameTags {
+		pass.Reportf(node.Pos(),
+			"struct mixes ksql and tablename tags, the tablename tags are ignored when the struct has ksql tags",
+		)
+	}
+}
+
+func checkKsqlTag(pass *analysis.Pass, field *types.Var, value string, columns map[string]bool) {
+	if !field.Exported() {
+		pass.Reportf(field.Pos(), "ksql tag on unexported field %s: all fields using the ksql tags must be exported", field.Name())
+	}
+
+	modifiers := strings.Split(value, ",")
+	name := modifiers[0]
+	if name == "" {
+		pass.Reportf(field.Pos(), "ksql tag of field %s has an empty column name", field.Name())
+	} else if columns[name] {
+		pass.Reportf(field.Pos(), "duplicated ksql tag name %s on field %s", strconv.Quote(name), field.Name())
+	}
+	columns[name] = true
+
+	for _, modifier := range modifiers[1:] {
+		switch {
+		case modifier == "json", modifier == "immutable", modifier == "generated", modifier == "default":
+		case strings.HasPrefix(modifier, "default="):
+		case modifier == "blob":
+			if _, ok := field.Type().Underlying().(*types.Interface); !ok {
+				pass.Reportf(field.Pos(), "the blob modifier requires an io.Reader interface, but field %s has type %s", field.Name(), field.Type())
+			}
+		case modifier == "decimal":
+			if !isDecimalType(field.Type()) {
+				pass.Reportf(field.Pos(),
+					"the decimal modifier requires a string or a type implementing encoding.TextMarshaler and encoding.TextUnmarshaler, but field %s has type %s",
+					field.Name(), field.Type(),
+				)
+			}
+		case modifier == "hstore":
+			if !isHstoreType(field.Type()) {
+				pass.Reportf(field.Pos(), "the hstore modifier requires a map[string]string or a map[string]*string, but field %s has type %s", field.Name(), field.Type())
+			}
+		case modifier == "duration", strings.HasPrefix(modifier, "duration="):
+			if !isDurationType(field.Type()) {
+				pass.Reportf(field.Pos(), "the duration modifier requires a time.Duration or a *time.Duration, but field %s has type %s", field.Name(), field.Type())
+			}
+			switch strings.TrimPrefix(modifier, "duration") {
+			case "", "=ns", "=us", "=ms", "=s", "=m", "=h":
+			default:
+				pass.Reportf(field.Pos(), "invalid unit on modifier %s of field %s, the units are ns, us, ms, s, m and h", strconv.Quote(modifier), field.Name())
+			}
+		case strings.HasPrefix(modifier, "sqltype="):
+			checkSQLType(pass, field, strings.TrimPrefix(modifier, "sqltype="))
+		case modifier == "trim":
+			if !isStringOrPointer(field.Type()) {
+				pass.Reportf(field.Pos(), "the trim modifier requires a string or a *string, but field %s has type %s", field.Name(), field.Type())
+			}
+		default:
+			pass.Reportf(field.Pos(), "unknown ksql modifier %s on field %s", strconv.Quote(modifier), field.Name())
+		}
+	}
+}
+
+// checkSQLType mirrors the runtime check of the sqltype modifier.
+func checkSQLType(pass *analysis.Pass, field *types.Var, sqlType string) {
+	switch sqlType {
+	case "varchar", "varchar(max)", "nvarchar(max)":
+		if !isStringOrPointer(field.Type()) {
+			pass.Reportf(field.Pos(), "the sqltype %s requires a string or a *string, but field %s has type %s", sqlType, field.Name(), field.Type())
+		}
+	case "datetime", "datetime2", "datetimeoffset", "date":
+		if !isTimeType(field.Type()) {
+			pass.Reportf(field.Pos(), "the sqltype %s requires a time.Time or a *time.Time, but field %s has type %s", sqlType, field.Name(), field.Type())
+		}
+	default:
+		pass.Reportf(field.Pos(), "unknown sqltype %s on field %s", strconv.Quote(sqlType), field.Name())
+	}
+}
+
+func checkTablenameTag(pass *analysis.Pass, field *types.Var, value string, tablenames map[string]bool) {
+	if value == "" {
+		pass.Reportf(field.Pos(), "tablename tag of field %s has an empty name", field.Name())
+	} else if tablenames[value] {
+		pass.Reportf(field.Pos(), "duplicated tablename tag %s on field %s", strconv.Quote(value), field.Name())
+	}
+	tablenames[value] = true
+
+	if _, ok := field.Type().Underlying().(*types.Struct); !ok {
+		pass.Reportf(field.Pos(), "the tablename tag can only be used on struct fields, but field %s has type %s", field.Name(), field.Type())
+	}
+}
+
+// isDecimalType mirrors the runtime check of the decimal modifier.
+func isDecimalType(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	methods := types.NewMethodSet(types.NewPointer(t))
+	if methods.Lookup(nil, "MarshalText") != nil && methods.Lookup(nil, "UnmarshalText") != nil {
+		return true
+	}
+
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.String
+}
+
+// isHstoreType mirrors the runtime check of the hstore modifier.
+func isHstoreType(t types.Type) bool {
+	m, ok := t.Underlying().(*types.Map)
+	if !ok || !isString(m.Key()) {
+		return false
+	}
+
+	elem := m.Elem()
+	if ptr, ok := elem.Underlying().(*types.Pointer); ok {
+		elem = ptr.Elem()
+	}
+	return isString(elem)
+}
+
+// isDurationType mirrors the runtime check of the duration modifier.
+func isDurationType(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Duration"
+}
+
+// isStringOrPointer mirrors the runtime check of the trim modifier.
+func isStringOrPointer(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	return isString(t)
+}
+
+// isTimeType checks if the type, or the type it points to, is a time.Time
+func isTimeType(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time"
+}
+
+func isString(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.String
+}
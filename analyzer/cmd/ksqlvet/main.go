@@ -0,0 +1,14 @@
+// Command ksqlvet runs the ksql struct tags analyzer,
+// it can be used standalone or as a go vet tool:
+//
+//	go vet -vettool=$(which ksqlvet) ./...
+package main
+
+import (
+	"github.com/vingarcia/ksql/analyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
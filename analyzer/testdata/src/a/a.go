@@ -0,0 +1,63 @@
+package a
+
+import (
+	"math/big"
+	"time"
+)
+
+type User struct {
+	ID      int               `ksql:"id"`
+	Name    string            `ksql:"name"`
+	Price   *big.Int          `ksql:"price,decimal"`
+	Attrs   map[string]string `ksql:"attrs,hstore"`
+	Address Address           `ksql:"address,json"`
+	Age     int               `ksql:"age,default=18"`
+	Timeout time.Duration     `ksql:"timeout,duration=ms"`
+	TTL     *time.Duration    `ksql:"ttl,duration"`
+	Country *string           `ksql:"country,trim"`
+	Email   string            `ksql:"email,sqltype=varchar"`
+	Created time.Time         `ksql:"created,sqltype=datetime2"`
+}
+
+type Address struct {
+	Country string `json:"country"`
+}
+
+type Post struct {
+	ID     int    `ksql:"id"`
+	Title  string `ksql:"title,immutable"`
+	UserID int    `ksql:"user_id,generated"`
+}
+
+type UserPost struct {
+	User User `tablename:"u"`
+	Post Post `tablename:"p"`
+}
+
+type Invalid struct {
+	ID      int           `ksql:"id"`
+	OtherID int           `ksql:"id"`                   // want `duplicated ksql tag name "id" on field OtherID`
+	name    string        `ksql:"name"`                 // want `ksql tag on unexported field name`
+	Empty   string        `ksql:",json"`                // want `ksql tag of field Empty has an empty column name`
+	Typo    string        `ksql:"typo,jsonb"`           // want `unknown ksql modifier "jsonb" on field Typo`
+	Price   float64       `ksql:"price,decimal"`        // want `the decimal modifier requires`
+	Attrs   []byte        `ksql:"attrs,hstore"`         // want `the hstore modifier requires`
+	Photo   []byte        `ksql:"photo,blob"`           // want `the blob modifier requires`
+	Timeout int64         `ksql:"timeout,duration"`     // want `the duration modifier requires`
+	TTL     time.Duration `ksql:"ttl,duration=days"`    // want `invalid unit on modifier "duration=days" of field TTL`
+	Code    []byte        `ksql:"code,trim"`            // want `the trim modifier requires`
+	Email   string        `ksql:"email,sqltype=text"`   // want `unknown sqltype "text" on field Email`
+	Created string        `ksql:"created,sqltype=date"` // want `the sqltype date requires a time.Time`
+	ignored string        // want `unexported field ignored: all fields of structs using the ksql tags must be exported`
+}
+
+type InvalidNested struct { // want `struct mixes ksql and tablename tags`
+	ID   int  `ksql:"id"`
+	User User `tablename:"u"`
+}
+
+type InvalidTablenames struct {
+	User  User `tablename:"u"`
+	Other User `tablename:"u"` // want `duplicated tablename tag "u" on field Other`
+	Count int  `tablename:"c"` // want `the tablename tag can only be used on struct fields`
+}
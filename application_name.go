@@ -0,0 +1,51 @@
+package ksql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+type applicationNameCtxKey struct{}
+
+// WithApplicationName returns a context that names the transactions started
+// with it, so DBAs can attribute the sessions to the services or to the
+// requests that started them when triaging locks, e.g.:
+//
+//	ctx = ksql.WithApplicationName(ctx, "billing-worker")
+//
+// On postgres it sets the `application_name` of the connection with
+// `set_config('application_name', name, true)` at the start of each
+// transaction, so it shows up on `pg_stat_activity` and is restored
+// when the transaction ends. Operations executed outside of transactions
+// keep the name set on the connection, e.g. by the ApplicationName
+// attribute of the ksql.Config.
+//
+// The other dialects can't rename a session after the connection is
+// established, so the context is ignored for them.
+func WithApplicationName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, applicationNameCtxKey{}, name)
+}
+
+// ApplicationName returns the name set on the context by
+// WithApplicationName, or an empty string if there is none.
+func ApplicationName(ctx context.Context) string {
+	name, _ := ctx.Value(applicationNameCtxKey{}).(string)
+	return name
+}
+
+// setApplicationName renames the session for the current
+// transaction on the dialects that support it.
+func (c DB) setApplicationName(ctx context.Context) error {
+	name := ApplicationName(ctx)
+	if name == "" || capabilitiesOf(c.dialect).SessionVars != SessionVarsSetConfig {
+		return nil
+	}
+
+	_, err := c.db.ExecContext(ctx, "SELECT set_config('application_name', "+c.dialect.Placeholder(0)+", true)", name)
+	if err != nil {
+		return errors.Wrap(err, "unable to set the application_name")
+	}
+
+	return nil
+}
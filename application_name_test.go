@@ -0,0 +1,65 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestWithApplicationName(t *testing.T) {
+	newAdapter := func(queries *[]string, params *[][]interface{}) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				*queries = append(*queries, query)
+				*params = append(*params, args)
+				return NewMockResult(0, 1), nil
+			},
+		}
+	}
+
+	t.Run("should set the application_name at the start of postgres transactions", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		db := newDB(t, "postgres", newAdapter(&queries, &params))
+
+		ctx := WithApplicationName(context.Background(), "billing-worker")
+		err := db.Transaction(ctx, func(db Provider) error {
+			_, err := db.Exec(ctx, "fake-query")
+			return err
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{
+			"SELECT set_config('application_name', $1, true)",
+			"fake-query",
+		})
+		tt.AssertEqual(t, params, [][]interface{}{{"billing-worker"}, nil})
+	})
+
+	t.Run("should do nothing if the context has no name", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		db := newDB(t, "postgres", newAdapter(&queries, &params))
+
+		err := db.Transaction(context.Background(), func(db Provider) error {
+			_, err := db.Exec(context.Background(), "fake-query")
+			return err
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{"fake-query"})
+	})
+
+	t.Run("should ignore the name on dialects that can't rename sessions", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		db := newDB(t, "mysql", newAdapter(&queries, &params))
+
+		ctx := WithApplicationName(context.Background(), "billing-worker")
+		err := db.Transaction(ctx, func(db Provider) error {
+			_, err := db.Exec(ctx, "fake-query")
+			return err
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{"fake-query"})
+	})
+}
@@ -0,0 +1,71 @@
+package ksql
+
+// ArgEncoder can be implemented by the DBAdapter in order to convert the params
+// of the queries into forms its driver supports before they are sent, e.g. the
+// ksqlserver adapter converts the ksql.TypedParam values into the param types
+// of its driver, so these conversions are done in a single place instead of
+// on each feature that generates params.
+//
+// It is called for each param of the queries sent by the ksql.DB, including
+// the ones generated internally, e.g. by Insert, after the query rewriters,
+// so the params reported by the DryRun option are also converted.
+type ArgEncoder interface {
+	EncodeArg(arg interface{}) (interface{}, error)
+}
+
+// ArgEncoderFunc implements the ArgEncoder interface with a function
+type ArgEncoderFunc func(arg interface{}) (interface{}, error)
+
+// EncodeArg implements the ArgEncoder interface
+func (fn ArgEncoderFunc) EncodeArg(arg interface{}) (interface{}, error) {
+	return fn(arg)
+}
+
+// WithArgEncoder configures an encoder for converting the params of all
+// queries before they are sent, e.g. for sending uuid.UUID values as strings
+// on drivers lacking support for them or for converting times to UTC:
+//
+//	db, err := ksql.NewWithAdapter(adapter, "sqlite3", ksql.WithArgEncoder(
+//		ksql.ArgEncoderFunc(func(arg interface{}) (interface{}, error) {
+//			if id, ok := arg.(uuid.UUID); ok {
+//				return id.String(), nil
+//			}
+//			return arg, nil
+//		}),
+//	))
+//
+// The encoders configured with this option run in the order they were
+// added and before the encoder of the adapter, if it implements the
+// ArgEncoder interface.
+func WithArgEncoder(encoder ArgEncoder) Option {
+	return func(db *DB) {
+		db.argEncoders = append(db.argEncoders, encoder)
+	}
+}
+
+// encodeArgs runs the configured encoders on each param, the input
+// slice is not modified since it might belong to the caller.
+func (c DB) encodeArgs(params []interface{}) ([]interface{}, error) {
+	encoders := c.argEncoders
+	if adapterEncoder, ok := innermostAdapter(c.db).(ArgEncoder); ok {
+		encoders = append(encoders[:len(encoders):len(encoders)], adapterEncoder)
+	}
+
+	if len(encoders) == 0 || len(params) == 0 {
+		return params, nil
+	}
+
+	encoded := make([]interface{}, len(params))
+	for i, param := range params {
+		for _, encoder := range encoders {
+			var err error
+			param, err = encoder.EncodeArg(param)
+			if err != nil {
+				return nil, err
+			}
+		}
+		encoded[i] = param
+	}
+
+	return encoded, nil
+}
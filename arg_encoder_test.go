@@ -0,0 +1,80 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type encoderAdapter struct {
+	mockDBAdapter
+}
+
+func (encoderAdapter) EncodeArg(arg interface{}) (interface{}, error) {
+	if s, ok := arg.(string); ok {
+		return "adapter:" + s, nil
+	}
+	return arg, nil
+}
+
+func TestArgEncoders(t *testing.T) {
+	t.Run("should encode the params with the options and then with the adapter", func(t *testing.T) {
+		var params []interface{}
+		adapter := encoderAdapter{mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+				params = args
+				return NewMockResult(42, 1), nil
+			},
+		}}
+
+		db, err := NewWithAdapter(WrapAdapter(adapter, AdapterHooks{}), "sqlite3", WithArgEncoder(
+			ArgEncoderFunc(func(arg interface{}) (interface{}, error) {
+				if s, ok := arg.(string); ok {
+					return strings.ToUpper(s), nil
+				}
+				return arg, nil
+			}),
+		))
+		tt.AssertNoErr(t, err)
+
+		input := []interface{}{"bia", 22}
+		_, err = db.Exec(context.Background(), "UPDATE users SET name = ? WHERE age = ?", input...)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, params, []interface{}{"adapter:BIA", 22})
+		tt.AssertEqual(t, input, []interface{}{"bia", 22})
+	})
+
+	t.Run("should encode the params reported by DryRun", func(t *testing.T) {
+		db, err := NewWithAdapter(encoderAdapter{}, "sqlite3")
+		tt.AssertNoErr(t, err)
+
+		var params []interface{}
+		ctx := WithQueryOptions(context.Background(), DryRun(func(q string, p []interface{}) {
+			params = p
+		}))
+		err = db.Insert(ctx, usersTable, &user{Name: "bia"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, params[2], "adapter:bia")
+	})
+
+	t.Run("should report encoding errors", func(t *testing.T) {
+		var called bool
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				called = true
+				return newMockRows([]string{"id"}), nil
+			},
+		}, "sqlite3", WithArgEncoder(ArgEncoderFunc(func(arg interface{}) (interface{}, error) {
+			return nil, errors.New("fake-encoding-error")
+		})))
+		tt.AssertNoErr(t, err)
+
+		var users []user
+		err = db.Query(context.Background(), &users, "FROM users WHERE id = ?", 42)
+		tt.AssertErrContains(t, err, "fake-encoding-error")
+		tt.AssertEqual(t, called, false)
+	})
+}
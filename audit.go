@@ -0,0 +1,309 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// AuditEvent describes a change made to a single record of the database
+type AuditEvent struct {
+	// Method is the name of the Provider method that caused the change, e.g. "Patch" or "Delete"
+	Method    string
+	TableName string
+
+	// Key contains the values of the ID columns of the changed record
+	Key map[string]interface{}
+
+	// Actor is the value returned by the AuditConfig.ActorFn
+	Actor string
+
+	// ChangedColumns lists the columns written by an update,
+	// if the Before image is available only the columns whose
+	// value actually changed are listed.
+	ChangedColumns []string
+
+	// Before is only set if AuditConfig.FetchBefore is true and the
+	// type of the record is known, i.e. it is not available when
+	// Delete is called with just the ID of the record.
+	Before map[string]interface{}
+
+	// After contains the values written by an update, it is always nil for deletions.
+	After map[string]interface{}
+}
+
+// AuditConfig describes the configurations for the AuditProvider.
+type AuditConfig struct {
+	// Sink is required and is called once for each change,
+	// if it returns an error this error is returned to the caller.
+	Sink func(ctx context.Context, event AuditEvent) error
+
+	// ActorFn is optional and is used to extract the actor responsible
+	// for the change from the context, e.g. the current user ID.
+	ActorFn func(ctx context.Context) string
+
+	// FetchBefore makes the AuditProvider read the current version of
+	// each record before changing it, in order to fill the AuditEvent.Before
+	// field.
+	//
+	// When it is enabled the read, the write and the call to the Sink run
+	// inside the same transaction, so if the Sink fails the change is rolled back.
+	FetchBefore bool
+}
+
+// AuditProvider is a middleware that wraps a ksql.DB and emits an AuditEvent
+// for each record changed using the Patch, Update and Delete methods.
+//
+// Note that changes made using the Exec method are not audited.
+type AuditProvider struct {
+	db      Provider
+	dialect Dialect
+	config  AuditConfig
+}
+
+var _ Provider = AuditProvider{}
+
+// NewAuditProvider instantiates a new AuditProvider
+func NewAuditProvider(db DB, config AuditConfig) (AuditProvider, error) {
+	if config.Sink == nil {
+		return AuditProvider{}, fmt.Errorf("ksql: the AuditConfig.Sink attribute is required")
+	}
+
+	return AuditProvider{
+		db:      db,
+		dialect: db.dialect,
+		config:  config,
+	}, nil
+}
+
+// Insert implements the Provider interface
+func (a AuditProvider) Insert(ctx context.Context, table Table, record interface{}) error {
+	return a.db.Insert(ctx, table, record)
+}
+
+// Patch implements the Provider interface
+func (a AuditProvider) Patch(ctx context.Context, table Table, record interface{}) error {
+	return a.auditPatch(ctx, "Patch", table, record)
+}
+
+// Update implements the Provider interface
+//
+// Deprecated: use the Patch() method instead.
+func (a AuditProvider) Update(ctx context.Context, table Table, record interface{}) error {
+	return a.auditPatch(ctx, "Update", table, record)
+}
+
+func (a AuditProvider) auditPatch(
+	ctx context.Context,
+	method string,
+	table Table,
+	record interface{},
+) error {
+	// The query options only apply to the write itself,
+	// and not to the Sink nor the query that fetches the Before image:
+	ctx, opts := popQueryOptions(ctx)
+	patchFn := func(db Provider) error {
+		if method == "Update" {
+			return db.Update(WithQueryOptions(ctx, opts...), table, record)
+		}
+		return db.Patch(WithQueryOptions(ctx, opts...), table, record)
+	}
+
+	// If it is a tracker only the changed columns will be written:
+	recordValue := record
+	tracker, isTracker := record.(*Tracker)
+	if isTracker {
+		recordValue = tracker.record
+	}
+
+	key, err := auditKey(table, recordValue)
+	if err != nil {
+		return err
+	}
+
+	after, err := structs.StructToMap(recordValue)
+	if err != nil {
+		return err
+	}
+	if isTracker {
+		after = tracker.filterChanges(after, nil)
+		if len(after) == 0 {
+			// Nothing will be written so there is nothing to audit:
+			return patchFn(a.db)
+		}
+	}
+	for _, idName := range table.idColumns {
+		delete(after, idName)
+	}
+
+	event := AuditEvent{
+		Method:    method,
+		TableName: table.name,
+		Key:       key,
+		After:     after,
+	}
+
+	if !a.config.FetchBefore {
+		err := patchFn(a.db)
+		if err != nil {
+			return err
+		}
+
+		event.ChangedColumns = sortedKeys(after)
+		return a.emit(ctx, event)
+	}
+
+	return a.db.Transaction(ctx, func(db Provider) error {
+		event.Before, err = a.fetchBefore(ctx, db, table, key, reflect.TypeOf(recordValue))
+		if err != nil {
+			return err
+		}
+
+		err = patchFn(db)
+		if err != nil {
+			return err
+		}
+
+		for _, col := range sortedKeys(after) {
+			if !reflect.DeepEqual(event.Before[col], after[col]) {
+				event.ChangedColumns = append(event.ChangedColumns, col)
+			}
+		}
+
+		return a.emit(ctx, event)
+	})
+}
+
+// Delete implements the Provider interface
+func (a AuditProvider) Delete(ctx context.Context, table Table, idOrRecord interface{}) error {
+	ctx, opts := popQueryOptions(ctx)
+	key, err := auditKey(table, idOrRecord)
+	if err != nil {
+		return err
+	}
+
+	event := AuditEvent{
+		Method:    "Delete",
+		TableName: table.name,
+		Key:       key,
+	}
+
+	recordType := reflect.TypeOf(idOrRecord)
+	if recordType.Kind() == reflect.Ptr {
+		recordType = recordType.Elem()
+	}
+
+	// We can only fetch the previous state of the record if we know its type:
+	if !a.config.FetchBefore || recordType.Kind() != reflect.Struct {
+		err := a.db.Delete(WithQueryOptions(ctx, opts...), table, idOrRecord)
+		if err != nil {
+			return err
+		}
+
+		return a.emit(ctx, event)
+	}
+
+	return a.db.Transaction(ctx, func(db Provider) error {
+		event.Before, err = a.fetchBefore(ctx, db, table, key, recordType)
+		if err != nil {
+			return err
+		}
+
+		err = db.Delete(WithQueryOptions(ctx, opts...), table, idOrRecord)
+		if err != nil {
+			return err
+		}
+
+		return a.emit(ctx, event)
+	})
+}
+
+// Query implements the Provider interface
+func (a AuditProvider) Query(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+	return a.db.Query(ctx, records, query, params...)
+}
+
+// QueryOne implements the Provider interface
+func (a AuditProvider) QueryOne(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+	return a.db.QueryOne(ctx, record, query, params...)
+}
+
+// QueryChunks implements the Provider interface
+func (a AuditProvider) QueryChunks(ctx context.Context, parser ChunkParser) error {
+	return a.db.QueryChunks(ctx, parser)
+}
+
+// Exec implements the Provider interface
+func (a AuditProvider) Exec(ctx context.Context, query string, params ...interface{}) (Result, error) {
+	return a.db.Exec(ctx, query, params...)
+}
+
+// Transaction implements the Provider interface
+func (a AuditProvider) Transaction(ctx context.Context, fn func(Provider) error) error {
+	return a.db.Transaction(ctx, func(db Provider) error {
+		txAudit := a
+		txAudit.db = db
+		return fn(txAudit)
+	})
+}
+
+func (a AuditProvider) emit(ctx context.Context, event AuditEvent) error {
+	if a.config.ActorFn != nil {
+		event.Actor = a.config.ActorFn(ctx)
+	}
+
+	return errors.Wrap(a.config.Sink(ctx, event), "ksql: audit sink returned an error")
+}
+
+func (a AuditProvider) fetchBefore(
+	ctx context.Context,
+	db Provider,
+	table Table,
+	key map[string]interface{},
+	recordType reflect.Type,
+) (map[string]interface{}, error) {
+	if recordType.Kind() == reflect.Ptr {
+		recordType = recordType.Elem()
+	}
+
+	whereQuery, params := buildWhereByIDs(a.dialect, table.idColumns, key)
+
+	before := reflect.New(recordType)
+	err := db.QueryOne(ctx, before.Interface(),
+		"FROM "+table.escapedName(a.dialect)+" WHERE "+whereQuery,
+		params...,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "ksql: unable to fetch record before change")
+	}
+
+	return structs.StructToMap(before.Interface())
+}
+
+// auditKey returns a map containing only the ID columns of the record
+func auditKey(table Table, idOrRecord interface{}) (map[string]interface{}, error) {
+	idMap, err := normalizeIDsAsMap(table.idColumns, idOrRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	key := make(map[string]interface{}, len(table.idColumns))
+	for _, idName := range table.idColumns {
+		key[idName] = idMap[idName]
+	}
+
+	return key, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,184 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestAuditProvider(t *testing.T) {
+	type auditedUser struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+		Age  int    `ksql:"age"`
+	}
+
+	actorFn := func(ctx context.Context) string {
+		actor, _ := ctx.Value(ctxKey("actor")).(string)
+		return actor
+	}
+	ctx := context.WithValue(context.Background(), ctxKey("actor"), "fake-actor")
+
+	t.Run("should report error if the sink is missing", func(t *testing.T) {
+		_, err := NewAuditProvider(DB{}, AuditConfig{})
+		tt.AssertErrContains(t, err, "Sink", "required")
+	})
+
+	t.Run("should emit events for Patch without fetching the previous state", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				return NewMockResult(0, 1), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var events []AuditEvent
+		auditDB, err := NewAuditProvider(db, AuditConfig{
+			Sink: func(ctx context.Context, event AuditEvent) error {
+				events = append(events, event)
+				return nil
+			},
+			ActorFn: actorFn,
+		})
+		tt.AssertNoErr(t, err)
+
+		err = auditDB.Patch(ctx, usersTable, &auditedUser{ID: 42, Name: "fake-name", Age: 22})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, events, []AuditEvent{
+			{
+				Method:         "Patch",
+				TableName:      "users",
+				Key:            map[string]interface{}{"id": 42},
+				Actor:          "fake-actor",
+				ChangedColumns: []string{"age", "name"},
+				After: map[string]interface{}{
+					"name": "fake-name",
+					"age":  22,
+				},
+			},
+		})
+	})
+
+	t.Run("should list only the changed columns when fetching the previous state", func(t *testing.T) {
+		var committed bool
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+							tt.AssertEqual(t, query, `SELECT "id", "name", "age" FROM "users" WHERE "id" = $1`)
+							tt.AssertEqual(t, args, []interface{}{42})
+							return newMockRows(
+								[]string{"id", "name", "age"},
+								[]interface{}{42, "fake-name", 21},
+							), nil
+						},
+						ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+							return NewMockResult(0, 1), nil
+						},
+					},
+					CommitFn: func(ctx context.Context) error {
+						committed = true
+						return nil
+					},
+				}, nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var events []AuditEvent
+		auditDB, err := NewAuditProvider(db, AuditConfig{
+			Sink: func(ctx context.Context, event AuditEvent) error {
+				events = append(events, event)
+				return nil
+			},
+			FetchBefore: true,
+		})
+		tt.AssertNoErr(t, err)
+
+		err = auditDB.Patch(ctx, usersTable, &auditedUser{ID: 42, Name: "fake-name", Age: 22})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, committed, true)
+
+		tt.AssertEqual(t, len(events), 1)
+		tt.AssertEqual(t, events[0].ChangedColumns, []string{"age"})
+		tt.AssertEqual(t, events[0].Before, map[string]interface{}{
+			"id":   42,
+			"name": "fake-name",
+			"age":  21,
+		})
+	})
+
+	t.Run("should emit events for Delete without the previous state when only the ID is known", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				return NewMockResult(0, 1), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var events []AuditEvent
+		auditDB, err := NewAuditProvider(db, AuditConfig{
+			Sink: func(ctx context.Context, event AuditEvent) error {
+				events = append(events, event)
+				return nil
+			},
+			ActorFn:     actorFn,
+			FetchBefore: true,
+		})
+		tt.AssertNoErr(t, err)
+
+		err = auditDB.Delete(ctx, usersTable, 42)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, events, []AuditEvent{
+			{
+				Method:    "Delete",
+				TableName: "users",
+				Key:       map[string]interface{}{"id": 42},
+				Actor:     "fake-actor",
+			},
+		})
+	})
+
+	t.Run("should rollback if the sink fails when fetching the previous state", func(t *testing.T) {
+		var rolledBack bool
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+							return newMockRows(
+								[]string{"id", "name", "age"},
+								[]interface{}{42, "fake-name", 21},
+							), nil
+						},
+						ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+							return NewMockResult(0, 1), nil
+						},
+					},
+					RollbackFn: func(ctx context.Context) error {
+						rolledBack = true
+						return nil
+					},
+				}, nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		auditDB, err := NewAuditProvider(db, AuditConfig{
+			Sink: func(ctx context.Context, event AuditEvent) error {
+				return fmt.Errorf("fake-sink-error")
+			},
+			FetchBefore: true,
+		})
+		tt.AssertNoErr(t, err)
+
+		err = auditDB.Delete(ctx, usersTable, &auditedUser{ID: 42})
+		tt.AssertErrContains(t, err, "audit sink", "fake-sink-error")
+		tt.AssertEqual(t, rolledBack, true)
+	})
+}
@@ -0,0 +1,164 @@
+package ksql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConstraintKind classifies the errors caused by constraint violations
+type ConstraintKind string
+
+// The constraint kinds returned by ClassifyConstraintError
+const (
+	NoConstraint        ConstraintKind = ""
+	UniqueViolation     ConstraintKind = "unique"
+	ForeignKeyViolation ConstraintKind = "foreign_key"
+	NotNullViolation    ConstraintKind = "not_null"
+	CheckViolation      ConstraintKind = "check"
+)
+
+// ClassifyConstraintError tells which kind of constraint was violated
+// by the statement that returned the input error, if any.
+//
+// It uses the SQLSTATE codes for the drivers that expose them, e.g. pgx,
+// and the error messages of the other supported drivers, it returns
+// NoConstraint if the error was not caused by a constraint violation.
+func ClassifyConstraintError(err error) ConstraintKind {
+	if err == nil {
+		return NoConstraint
+	}
+
+	var sqlStateErr interface {
+		SQLState() string
+	}
+	if errors.As(err, &sqlStateErr) {
+		switch sqlStateErr.SQLState() {
+		case "23505":
+			return UniqueViolation
+		case "23503":
+			return ForeignKeyViolation
+		case "23502":
+			return NotNullViolation
+		case "23514":
+			return CheckViolation
+		}
+		return NoConstraint
+	}
+
+	// The drivers that don't expose the SQLSTATE codes are classified by their messages:
+	msg := strings.ToLower(err.Error())
+	switch {
+	case containsAny(msg, "unique constraint", "unique key constraint", "duplicate key", "duplicate entry", "violation of primary key"):
+		return UniqueViolation
+	case containsAny(msg, "foreign key constraint"):
+		return ForeignKeyViolation
+	case containsAny(msg, "not null constraint", "not-null constraint", "cannot be null", "cannot insert the value null"):
+		return NotNullViolation
+	case containsAny(msg, "check constraint"):
+		return CheckViolation
+	}
+
+	return NoConstraint
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordError describes why one of the records
+// of a batch operation couldn't be saved.
+type RecordError struct {
+	// Index is the position of the record on the input slice
+	Index int
+
+	// Constraint is the kind of constraint violated by
+	// the record, or NoConstraint if there was none.
+	Constraint ConstraintKind
+
+	Err error
+}
+
+func (e RecordError) Error() string {
+	return "record " + strconv.Itoa(e.Index) + e.cause()
+}
+
+func (e RecordError) cause() string {
+	if e.Constraint != NoConstraint {
+		return fmt.Sprintf(" (%s violation): %s", e.Constraint, e.Err)
+	}
+	return ": " + e.Err.Error()
+}
+
+// Unwrap returns the error returned while saving the record
+func (e RecordError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is returned by the InsertMany and UpdateMany functions when
+// they fail to save some of the records, it describes which records failed
+// and why, so the callers can retry or report each failure.
+//
+// Since these functions run inside a transaction none of the records
+// are saved when they fail. Records updated by the same statement are
+// reported together, e.g. by the UpdateMany method of ksql.DB, which
+// updates several records per statement.
+type BatchError struct {
+	// Method is the name of the batch operation, e.g. "InsertMany"
+	Method string
+
+	Failures []RecordError
+}
+
+func newBatchError(method string, indexes []int, err error) BatchError {
+	constraint := ClassifyConstraintError(err)
+	failures := make([]RecordError, len(indexes))
+	for i, idx := range indexes {
+		failures[i] = RecordError{
+			Index:      idx,
+			Constraint: constraint,
+			Err:        err,
+		}
+	}
+
+	return BatchError{
+		Method:   method,
+		Failures: failures,
+	}
+}
+
+func (e BatchError) Error() string {
+	// Consecutive records that failed with the same error,
+	// e.g. on the same statement, are reported together:
+	var msgs []string
+	for i := 0; i < len(e.Failures); {
+		failure := e.Failures[i]
+		indexes := []string{strconv.Itoa(failure.Index)}
+		for i++; i < len(e.Failures) && e.Failures[i].Err.Error() == failure.Err.Error(); i++ {
+			indexes = append(indexes, strconv.Itoa(e.Failures[i].Index))
+		}
+
+		prefix := "record "
+		if len(indexes) > 1 {
+			prefix = "records "
+		}
+		msgs = append(msgs, prefix+strings.Join(indexes, ", ")+failure.cause())
+	}
+
+	return fmt.Sprintf("ksql: %s failed for %d record(s): %s", e.Method, len(e.Failures), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of each failed record
+func (e BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure
+	}
+	return errs
+}
@@ -0,0 +1,117 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type fakeSQLStateError struct {
+	code string
+}
+
+func (e fakeSQLStateError) Error() string {
+	return "fake-sqlstate-error"
+}
+
+func (e fakeSQLStateError) SQLState() string {
+	return e.code
+}
+
+func TestClassifyConstraintError(t *testing.T) {
+	tests := []struct {
+		desc     string
+		err      error
+		expected ConstraintKind
+	}{
+		{desc: "nil error", err: nil, expected: NoConstraint},
+		{desc: "unrelated error", err: errors.New("connection refused"), expected: NoConstraint},
+		{desc: "postgres unique", err: fmt.Errorf("wrapped: %w", fakeSQLStateError{code: "23505"}), expected: UniqueViolation},
+		{desc: "postgres foreign key", err: fakeSQLStateError{code: "23503"}, expected: ForeignKeyViolation},
+		{desc: "postgres not null", err: fakeSQLStateError{code: "23502"}, expected: NotNullViolation},
+		{desc: "postgres check", err: fakeSQLStateError{code: "23514"}, expected: CheckViolation},
+		{desc: "postgres other codes", err: fakeSQLStateError{code: "42P01"}, expected: NoConstraint},
+		{desc: "sqlite unique", err: errors.New("UNIQUE constraint failed: users.id"), expected: UniqueViolation},
+		{desc: "sqlite not null", err: errors.New("NOT NULL constraint failed: users.name"), expected: NotNullViolation},
+		{desc: "mysql unique", err: errors.New("Error 1062: Duplicate entry '1' for key 'PRIMARY'"), expected: UniqueViolation},
+		{desc: "mysql foreign key", err: errors.New("Error 1452: Cannot add or update a child row: a foreign key constraint fails"), expected: ForeignKeyViolation},
+		{desc: "mysql not null", err: errors.New("Error 1048: Column 'name' cannot be null"), expected: NotNullViolation},
+		{desc: "sqlserver unique", err: errors.New("mssql: Violation of PRIMARY KEY constraint 'PK_users'. Cannot insert duplicate key in object 'dbo.users'."), expected: UniqueViolation},
+		{desc: "sqlserver check", err: errors.New("mssql: The INSERT statement conflicted with the CHECK constraint \"CK_age\"."), expected: CheckViolation},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tt.AssertEqual(t, ClassifyConstraintError(test.err), test.expected)
+		})
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	t.Run("should report the record that failed on InsertMany", func(t *testing.T) {
+		var numInserts int
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+							numInserts++
+							if numInserts == 2 {
+								return nil, fakeSQLStateError{code: "23505"}
+							}
+							return newMockRows([]string{"id"}, []interface{}{numInserts}), nil
+						},
+					},
+				}, nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.InsertMany(context.Background(), usersTable, []*user{
+			{Name: "fake-name-1"},
+			{Name: "fake-name-2"},
+			{Name: "fake-name-3"},
+		})
+
+		var batchErr BatchError
+		tt.AssertEqual(t, errors.As(err, &batchErr), true)
+		tt.AssertEqual(t, batchErr.Method, "InsertMany")
+		tt.AssertEqual(t, len(batchErr.Failures), 1)
+		tt.AssertEqual(t, batchErr.Failures[0].Index, 1)
+		tt.AssertEqual(t, batchErr.Failures[0].Constraint, UniqueViolation)
+		tt.AssertEqual(t, errors.Is(err, fakeSQLStateError{code: "23505"}), true)
+		tt.AssertErrContains(t, err, "InsertMany", "record 1 (unique violation)", "fake-sqlstate-error")
+	})
+
+	t.Run("should report all records of the statement that failed on UpdateMany", func(t *testing.T) {
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+							return nil, errors.New("CHECK constraint failed: age")
+						},
+					},
+				}, nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.UpdateMany(context.Background(), usersTable, []user{
+			{ID: 1, Name: "fake-name-1"},
+			{ID: 2, Name: "fake-name-2"},
+		})
+
+		var batchErr BatchError
+		tt.AssertEqual(t, errors.As(err, &batchErr), true)
+		tt.AssertEqual(t, batchErr.Method, "UpdateMany")
+		tt.AssertEqual(t, len(batchErr.Failures), 2)
+		tt.AssertEqual(t, batchErr.Failures[0].Index, 0)
+		tt.AssertEqual(t, batchErr.Failures[1].Index, 1)
+		tt.AssertEqual(t, batchErr.Failures[1].Constraint, CheckViolation)
+		tt.AssertErrContains(t, err, "2 record(s)", "records 0, 1 (check violation)")
+	})
+}
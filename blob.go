@@ -0,0 +1,255 @@
+package ksql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+// blobChunkSize is the number of bytes read or written
+// on each query sent by QueryBlob and InsertLargeObject.
+var blobChunkSize = 1 << 20
+
+// QueryBlob streams the binary value returned by the query to the writer
+// loading only one chunk of it into memory at a time.
+//
+// The query must return a single column and only its first row is used,
+// ErrRecordNotFound is returned if it returns no rows and nothing is
+// written for NULL values.
+//
+// Each chunk is read by a separate query that wraps the input query,
+// so if the value might change while it is being read the call should
+// run inside a transaction with a suitable isolation level.
+//
+// On postgres the LargeObject() option can be used for queries that
+// return the OID of a large object instead of a bytea value.
+func (c DB) QueryBlob(ctx context.Context, w io.Writer, query string, params ...interface{}) error {
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).QueryBlob(ctx, w, query, params...)
+		})
+	}
+
+	opts, params := extractQueryOptions(params)
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	chunkQuery, err := buildBlobChunkQuery(c.dialect, query, len(params), opts.largeObject)
+	if err != nil {
+		return err
+	}
+
+	for offset := 0; ; offset += blobChunkSize {
+		chunk, found, err := c.queryBlobChunk(ctx, opts, chunkQuery, params, offset)
+		if err == errDryRun {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			if offset == 0 {
+				return ErrRecordNotFound
+			}
+			return nil
+		}
+
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+
+		if len(chunk) < blobChunkSize {
+			return nil
+		}
+	}
+}
+
+func (c DB) queryBlobChunk(
+	ctx context.Context,
+	opts queryOptions,
+	chunkQuery string,
+	params []interface{},
+	offset int,
+) (chunk []byte, found bool, err error) {
+	// Postgres large objects are indexed from 0 while the substring
+	// functions of all dialects are indexed from 1:
+	start := offset + 1
+	if opts.largeObject {
+		start = offset
+	}
+
+	chunkParams := append(append([]interface{}{}, params...), start, blobChunkSize)
+	rows, err := c.queryContext(ctx, OpInfo{Method: "QueryBlob"}, opts, chunkQuery, chunkParams...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, false, rows.Err()
+	}
+
+	var value interface{}
+	if err := rows.Scan(&value); err != nil {
+		return nil, false, err
+	}
+
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		chunk = v
+	case string:
+		chunk = []byte(v)
+	default:
+		return nil, false, fmt.Errorf("QueryBlob: expected a binary value but got: %T", value)
+	}
+
+	return chunk, true, rows.Close()
+}
+
+// buildBlobChunkQuery wraps the input query so that it returns only
+// a chunk of the binary value, the offset and size of the chunk are
+// expected as the last two params.
+func buildBlobChunkQuery(dialect Dialect, query string, numParams int, largeObject bool) (string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+
+	start := dialect.Placeholder(numParams)
+	size := dialect.Placeholder(numParams + 1)
+
+	caps := capabilitiesOf(dialect)
+	if largeObject && !caps.LargeObjects {
+		return "", fmt.Errorf("large objects are only supported by the postgres dialect")
+	}
+
+	var chunkExpr string
+	switch {
+	case largeObject:
+		chunkExpr = fmt.Sprintf("lo_get(data, %s, %s)", start, size)
+	case caps.BlobChunks == BlobChunkSubstringFromFor:
+		chunkExpr = fmt.Sprintf("substring(data FROM %s FOR %s)", start, size)
+	case caps.BlobChunks == BlobChunkSubstr:
+		chunkExpr = fmt.Sprintf("substr(data, %s, %s)", start, size)
+	case caps.BlobChunks == BlobChunkSubstring:
+		chunkExpr = fmt.Sprintf("SUBSTRING(data, %s, %s)", start, size)
+	default:
+		return "", fmt.Errorf("QueryBlob is not supported by the %s dialect", dialect.DriverName())
+	}
+
+	return "WITH ksql_blob(data) AS (" + query + ") SELECT " + chunkExpr + " FROM ksql_blob", nil
+}
+
+// InsertLargeObject creates a postgres large object with the contents
+// of the reader and returns its OID, the reader is consumed one chunk
+// at a time so the contents are never fully loaded into memory.
+//
+// All chunks are written in a single transaction, so if the operation
+// fails no large object is left behind.
+func (c DB) InsertLargeObject(ctx context.Context, r io.Reader, opts ...QueryOption) (oid uint32, err error) {
+	if !capabilitiesOf(c.dialect).LargeObjects {
+		return 0, fmt.Errorf("large objects are only supported by the postgres dialect")
+	}
+
+	o := newQueryOptions(opts)
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
+	err = c.Transaction(ctx, func(db Provider) error {
+		tx := db.(DB)
+
+		buf := make([]byte, blobChunkSize)
+		for offset := 0; ; {
+			n, readErr := io.ReadFull(r, buf)
+			if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+				return readErr
+			}
+
+			if offset == 0 {
+				rows, err := tx.queryContext(ctx, OpInfo{Method: "InsertLargeObject"}, o, `SELECT lo_from_bytea(0, $1)`, buf[:n])
+				if err != nil {
+					return err
+				}
+				err = scanLargeObjectOID(rows, &oid)
+				if err != nil {
+					return err
+				}
+			} else if n > 0 {
+				_, err := tx.execContext(ctx, OpInfo{Method: "InsertLargeObject"}, o, `SELECT lo_put($1, $2, $3)`, oid, offset, buf[:n])
+				if err != nil {
+					return err
+				}
+			}
+
+			offset += n
+			if readErr != nil {
+				return nil
+			}
+		}
+	})
+	if err == errDryRun {
+		return 0, nil
+	}
+
+	return oid, err
+}
+
+func scanLargeObjectOID(rows Rows, oid *uint32) error {
+	defer rows.Close()
+
+	if !rows.Next() {
+		if rows.Err() != nil {
+			return rows.Err()
+		}
+		return fmt.Errorf("InsertLargeObject: no OID was returned by the database")
+	}
+
+	var value int64
+	if err := rows.Scan(&value); err != nil {
+		return err
+	}
+	*oid = uint32(value)
+
+	return rows.Close()
+}
+
+// readBlob reads the value of an attribute tagged with the `blob`
+// modifier, since the database drivers need the whole value at once.
+func readBlob(value interface{}) (interface{}, error) {
+	r, _ := value.(io.Reader)
+	if r == nil {
+		// A typed nil is used so the drivers send a binary NULL:
+		return []byte(nil), nil
+	}
+
+	return ioutil.ReadAll(r)
+}
+
+// blobScanner implements the sql.Scanner interface in order to
+// load binary values into the attributes tagged with `blob`.
+type blobScanner struct {
+	Attr reflect.Value
+}
+
+// Scan Implements the Scanner interface
+func (b blobScanner) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		b.Attr.Set(reflect.Zero(b.Attr.Type()))
+		return nil
+	case []byte:
+		// The driver might reuse this buffer after Scan returns:
+		data = append([]byte{}, v...)
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unexpected type received to Scan a blob: %T", value)
+	}
+
+	b.Attr.Set(reflect.ValueOf(bytes.NewReader(data)))
+	return nil
+}
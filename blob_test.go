@@ -0,0 +1,109 @@
+package ksql
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestBuildBlobChunkQuery(t *testing.T) {
+	tests := []struct {
+		desc          string
+		dialect       Dialect
+		largeObject   bool
+		expectedQuery string
+	}{
+		{
+			desc:          "postgres",
+			dialect:       supportedDialects["postgres"],
+			expectedQuery: `WITH ksql_blob(data) AS (SELECT data FROM files WHERE id = $1) SELECT substring(data FROM $2 FOR $3) FROM ksql_blob`,
+		},
+		{
+			desc:          "postgres large objects",
+			dialect:       supportedDialects["postgres"],
+			largeObject:   true,
+			expectedQuery: `WITH ksql_blob(data) AS (SELECT data FROM files WHERE id = $1) SELECT lo_get(data, $2, $3) FROM ksql_blob`,
+		},
+		{
+			desc:          "sqlite3",
+			dialect:       supportedDialects["sqlite3"],
+			expectedQuery: `WITH ksql_blob(data) AS (SELECT data FROM files WHERE id = ?) SELECT substr(data, ?, ?) FROM ksql_blob`,
+		},
+		{
+			desc:          "sqlserver",
+			dialect:       supportedDialects["sqlserver"],
+			expectedQuery: `WITH ksql_blob(data) AS (SELECT data FROM files WHERE id = @p1) SELECT SUBSTRING(data, @p2, @p3) FROM ksql_blob`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			query := "SELECT data FROM files WHERE id = " + test.dialect.Placeholder(0) + ";"
+			chunkQuery, err := buildBlobChunkQuery(test.dialect, query, 1, test.largeObject)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, chunkQuery, test.expectedQuery)
+		})
+	}
+
+	t.Run("should report large objects on other dialects", func(t *testing.T) {
+		_, err := buildBlobChunkQuery(supportedDialects["mysql"], `SELECT data FROM files`, 0, true)
+		tt.AssertErrContains(t, err, "large objects", "postgres")
+	})
+}
+
+func TestInsertLargeObject(t *testing.T) {
+	defer func(size int) { blobChunkSize = size }(blobChunkSize)
+	blobChunkSize = 4
+
+	t.Run("should write the reader one chunk at a time", func(t *testing.T) {
+		var queries []string
+		var chunks []string
+		var committed bool
+		adapter := mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				queries = append(queries, query)
+				chunks = append(chunks, string(params[0].([]byte)))
+				return newMockRows([]string{"lo_from_bytea"}, []interface{}{int64(42)}), nil
+			},
+			ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				queries = append(queries, query)
+				tt.AssertEqual(t, params[0], uint32(42))
+				tt.AssertEqual(t, params[1], len(chunks)*4)
+				chunks = append(chunks, string(params[2].([]byte)))
+				return NewMockResult(0, 0), nil
+			},
+		}
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: adapter,
+					CommitFn: func(ctx context.Context) error {
+						committed = true
+						return nil
+					},
+				}, nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		oid, err := db.InsertLargeObject(context.Background(), strings.NewReader("abcdefghij"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, oid, uint32(42))
+		tt.AssertEqual(t, committed, true)
+		tt.AssertEqual(t, queries, []string{
+			`SELECT lo_from_bytea(0, $1)`,
+			`SELECT lo_put($1, $2, $3)`,
+			`SELECT lo_put($1, $2, $3)`,
+		})
+		tt.AssertEqual(t, chunks, []string{"abcd", "efgh", "ij"})
+	})
+
+	t.Run("should report an error for other dialects", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "sqlite3")
+		tt.AssertNoErr(t, err)
+
+		_, err = db.InsertLargeObject(context.Background(), strings.NewReader("abcd"))
+		tt.AssertErrContains(t, err, "large objects", "postgres")
+	})
+}
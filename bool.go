@@ -0,0 +1,187 @@
+package ksql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// boolScanner implements the sql.Scanner interface in order to load
+// booleans from the databases that store them as integers, e.g.
+// the BIT columns of sqlserver and the TINYINT(1) columns of mysql,
+// including on attributes whose types are based on bool.
+type boolScanner struct {
+	Attr reflect.Value
+}
+
+// Scan Implements the Scanner interface
+func (b boolScanner) Scan(value interface{}) error {
+	var boolean bool
+	switch v := value.(type) {
+	case nil:
+		b.Attr.Set(reflect.Zero(b.Attr.Type()))
+		return nil
+	case bool:
+		boolean = v
+	case int64:
+		if v != 0 && v != 1 {
+			return fmt.Errorf("unable to convert %d to bool, expected 0 or 1", v)
+		}
+		boolean = v == 1
+	case []byte:
+		var err error
+		boolean, err = strconv.ParseBool(string(v))
+		if err != nil {
+			return fmt.Errorf("unable to convert '%s' to bool: %w", v, err)
+		}
+	case string:
+		var err error
+		boolean, err = strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("unable to convert '%s' to bool: %w", v, err)
+		}
+	default:
+		return fmt.Errorf("unexpected type received to Scan a bool: %T", value)
+	}
+
+	attr := b.Attr
+	if attr.Kind() == reflect.Ptr {
+		attr.Set(reflect.New(attr.Type().Elem()))
+		attr = attr.Elem()
+	}
+	attr.SetBool(boolean)
+	return nil
+}
+
+// isBoolField tells if the attribute should be loaded with the boolScanner
+func isBoolField(field reflect.Value) bool {
+	t := field.Type()
+	if reflect.PtrTo(t).Implements(scannerType) {
+		return false
+	}
+
+	if t.Kind() == reflect.Ptr {
+		if t.Implements(scannerType) {
+			return false
+		}
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// unmarshalJSON works like json.Unmarshal but also accepts the numbers
+// 0 and 1 for bool attributes, since that is how the databases without
+// a boolean type write them on JSON documents, e.g. the JSON_OBJECT()
+// function of mysql when reading TINYINT(1) columns.
+func unmarshalJSON(data []byte, target interface{}) error {
+	err := json.Unmarshal(data, target)
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Value != "number" {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if decoder.Decode(&document) != nil {
+		return err
+	}
+
+	normalized, changed := normalizeJSONBools(document, reflect.TypeOf(target))
+	if !changed {
+		return err
+	}
+
+	data, marshalErr := json.Marshal(normalized)
+	if marshalErr != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// normalizeJSONBools replaces the numbers 0 and 1 of the document
+// by booleans wherever the type t expects a bool
+func normalizeJSONBools(document interface{}, t reflect.Type) (_ interface{}, changed bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch v := document.(type) {
+	case json.Number:
+		if t.Kind() == reflect.Bool && (v == "0" || v == "1") {
+			return v == "1", true
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			break
+		}
+		for i, item := range v {
+			var itemChanged bool
+			v[i], itemChanged = normalizeJSONBools(item, t.Elem())
+			changed = changed || itemChanged
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			itemType, found := jsonValueType(t, key)
+			if !found {
+				continue
+			}
+
+			var itemChanged bool
+			v[key], itemChanged = normalizeJSONBools(item, itemType)
+			changed = changed || itemChanged
+		}
+	}
+
+	return document, changed
+}
+
+// jsonValueType returns the type that receives the value of the
+// key when a JSON object is decoded into the type t, following
+// the rules of encoding/json for matching the struct fields.
+func jsonValueType(t reflect.Type, key string) (reflect.Type, bool) {
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem(), true
+	case reflect.Struct:
+	default:
+		return nil, false
+	}
+
+	var caseInsensitiveMatch reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+				name = tagName
+			}
+		} else if field.Anonymous {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if valueType, found := jsonValueType(fieldType, key); found {
+				return valueType, true
+			}
+			continue
+		}
+
+		if name == key {
+			return field.Type, true
+		}
+		if caseInsensitiveMatch == nil && strings.EqualFold(name, key) {
+			caseInsensitiveMatch = field.Type
+		}
+	}
+
+	return caseInsensitiveMatch, caseInsensitiveMatch != nil
+}
@@ -0,0 +1,102 @@
+package ksql
+
+import (
+	"reflect"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type flag bool
+
+func TestBoolNormalization(t *testing.T) {
+	t.Run("should scan the values returned by each driver", func(t *testing.T) {
+		tests := []struct {
+			desc         string
+			value        interface{}
+			expectedBool flag
+		}{
+			{desc: "booleans", value: true, expectedBool: true},
+			{desc: "integers", value: int64(1), expectedBool: true},
+			{desc: "zero", value: int64(0), expectedBool: false},
+			{desc: "bytes", value: []byte("1"), expectedBool: true},
+			{desc: "text", value: "false", expectedBool: false},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				f := !test.expectedBool
+				err := boolScanner{Attr: reflect.ValueOf(&f).Elem()}.Scan(test.value)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, f, test.expectedBool)
+			})
+		}
+	})
+
+	t.Run("should scan NULL into nil pointers", func(t *testing.T) {
+		b := new(bool)
+		err := boolScanner{Attr: reflect.ValueOf(&b).Elem()}.Scan(nil)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, b == nil, true)
+
+		err = boolScanner{Attr: reflect.ValueOf(&b).Elem()}.Scan(int64(1))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, *b, true)
+	})
+
+	t.Run("should reject integers other than 0 and 1", func(t *testing.T) {
+		var b bool
+		err := boolScanner{Attr: reflect.ValueOf(&b).Elem()}.Scan(int64(2))
+		tt.AssertErrContains(t, err, "2", "expected 0 or 1")
+	})
+
+	t.Run("should only scan with boolScanner the attributes based on bool", func(t *testing.T) {
+		var b bool
+		var f *flag
+		var i int
+		var n struct{ Bool bool }
+		tt.AssertEqual(t, isBoolField(reflect.ValueOf(&b).Elem()), true)
+		tt.AssertEqual(t, isBoolField(reflect.ValueOf(&f).Elem()), true)
+		tt.AssertEqual(t, isBoolField(reflect.ValueOf(&i).Elem()), false)
+		tt.AssertEqual(t, isBoolField(reflect.ValueOf(&n).Elem()), false)
+	})
+
+	t.Run("should decode 0 and 1 as booleans on JSON documents", func(t *testing.T) {
+		type Embedded struct {
+			Visible bool
+		}
+		type Item struct {
+			Embedded
+			Active flag   `json:"active"`
+			Count  int    `json:"count"`
+			Admin  *bool  `json:"admin,omitempty"`
+			Tags   []bool `json:"tags"`
+		}
+
+		var items []Item
+		err := unmarshalJSON([]byte(`[{"active":1,"count":1,"visible":0,"admin":1,"tags":[0,1,true]}]`), &items)
+		tt.AssertNoErr(t, err)
+
+		admin := true
+		tt.AssertEqual(t, items, []Item{{
+			Embedded: Embedded{Visible: false},
+			Active:   true,
+			Count:    1,
+			Admin:    &admin,
+			Tags:     []bool{false, true, true},
+		}})
+
+		var m map[string]bool
+		err = unmarshalJSON([]byte(`{"a":1,"b":0}`), &m)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, m, map[string]bool{"a": true, "b": false})
+	})
+
+	t.Run("should still report the other JSON type errors", func(t *testing.T) {
+		var item struct {
+			Active bool `json:"active"`
+		}
+		err := unmarshalJSON([]byte(`{"active":2}`), &item)
+		tt.AssertErrContains(t, err, "cannot unmarshal number")
+	})
+}
@@ -0,0 +1,205 @@
+package ksql
+
+// UpsertFlavor describes how the Upsert method is implemented for a dialect
+type UpsertFlavor string
+
+const (
+	// UpsertOnConflict uses `INSERT ... ON CONFLICT DO UPDATE` statements
+	UpsertOnConflict UpsertFlavor = "on_conflict"
+
+	// UpsertOnDuplicateKey uses `INSERT ... ON DUPLICATE KEY UPDATE` statements
+	UpsertOnDuplicateKey UpsertFlavor = "on_duplicate_key"
+
+	// UpsertEmulated tries to Patch the record and Inserts it if it was not
+	// found inside a single transaction, so concurrent Upserts might conflict.
+	UpsertEmulated UpsertFlavor = "emulated"
+
+	// UpsertNotSupported means Upsert returns ErrNotSupported
+	UpsertNotSupported UpsertFlavor = "not_supported"
+)
+
+// LimitFlavor describes the clauses used by the Limit
+// option and QueryChunks for limiting the number of rows
+type LimitFlavor string
+
+const (
+	// LimitClause uses the `LIMIT n OFFSET m` clauses
+	LimitClause LimitFlavor = "limit"
+
+	// LimitFetch uses the `OFFSET m ROWS FETCH NEXT n ROWS ONLY` clauses
+	LimitFetch LimitFlavor = "fetch"
+
+	// LimitTop uses the `TOP (n)` clause, or the `OFFSET ... FETCH`
+	// clauses if the query has an ORDER BY clause
+	LimitTop LimitFlavor = "top"
+)
+
+// SequenceFlavor describes the query that reads
+// the next value of the sequence of a table
+type SequenceFlavor string
+
+const (
+	// SequenceNextval uses `SELECT nextval('name')`
+	SequenceNextval SequenceFlavor = "nextval"
+
+	// SequenceNextValueFor uses `SELECT NEXT VALUE FOR name`
+	SequenceNextValueFor SequenceFlavor = "next_value_for"
+
+	// SequenceDotNextval uses `SELECT name.NEXTVAL`
+	SequenceDotNextval SequenceFlavor = "dot_nextval"
+)
+
+// IdentityInsertFlavor describes the statements that allow
+// the IdentityInsert option to write explicit IDs
+type IdentityInsertFlavor string
+
+const (
+	// IdentityInsertSetTable uses `SET IDENTITY_INSERT table ON`
+	IdentityInsertSetTable IdentityInsertFlavor = "set_identity_insert"
+
+	// IdentityInsertAutoRandom uses `SET @@allow_auto_random_explicit_insert = true`
+	IdentityInsertAutoRandom IdentityInsertFlavor = "auto_random"
+)
+
+// SessionVarsFlavor describes how the session variables are set
+type SessionVarsFlavor string
+
+const (
+	// SessionVarsSetConfig uses `set_config(name, value, true)`,
+	// which is discarded at the end of the transaction
+	SessionVarsSetConfig SessionVarsFlavor = "set_config"
+
+	// SessionVarsUserVariables uses `SET @name = value`
+	SessionVarsUserVariables SessionVarsFlavor = "user_variables"
+
+	// SessionVarsSessionContext uses the `sp_set_session_context` procedure
+	SessionVarsSessionContext SessionVarsFlavor = "session_context"
+
+	// SessionVarsRDBSetContext uses `RDB$SET_CONTEXT('USER_TRANSACTION', name, value)`,
+	// which is discarded at the end of the transaction
+	SessionVarsRDBSetContext SessionVarsFlavor = "rdb_set_context"
+)
+
+// transactionScoped reports if the variables are
+// discarded at the end of the transaction
+func (f SessionVarsFlavor) transactionScoped() bool {
+	return f == SessionVarsSetConfig || f == SessionVarsRDBSetContext
+}
+
+// RowLockFlavor describes the clause used for locking the rows read by a query
+type RowLockFlavor string
+
+const (
+	// RowLockForUpdate uses the `FOR UPDATE` clause
+	RowLockForUpdate RowLockFlavor = "for_update"
+
+	// RowLockForUpdateWithLock uses the `FOR UPDATE WITH LOCK` clause
+	RowLockForUpdateWithLock RowLockFlavor = "for_update_with_lock"
+)
+
+// DDLFlavor describes the column types and the syntax of the
+// statements used for creating tables and triggers
+type DDLFlavor string
+
+const (
+	// DDLPostgres uses the postgres types and triggers executing plpgsql functions
+	DDLPostgres DDLFlavor = "postgres"
+
+	// DDLSQLite uses the sqlite3 types and triggers with `BEGIN ... END` bodies
+	DDLSQLite DDLFlavor = "sqlite3"
+
+	// DDLMySQL uses the mysql types and triggers with single statement bodies
+	DDLMySQL DDLFlavor = "mysql"
+
+	// DDLSQLServer uses the sqlserver types, its triggers are not supported
+	DDLSQLServer DDLFlavor = "sqlserver"
+)
+
+// LockWaitsFlavor describes the system views used by WhoIsBlocking
+type LockWaitsFlavor string
+
+const (
+	// LockWaitsPgStatActivity uses the pg_stat_activity view
+	LockWaitsPgStatActivity LockWaitsFlavor = "pg_stat_activity"
+
+	// LockWaitsDMExecRequests uses the sys.dm_exec_requests view
+	LockWaitsDMExecRequests LockWaitsFlavor = "dm_exec_requests"
+
+	// LockWaitsPerformanceSchema uses the performance_schema.data_lock_waits table
+	LockWaitsPerformanceSchema LockWaitsFlavor = "performance_schema"
+)
+
+// BlobChunkFlavor describes the function used by QueryBlob
+// for reading a chunk of a binary value
+type BlobChunkFlavor string
+
+const (
+	// BlobChunkSubstringFromFor uses `substring(data FROM start FOR size)`
+	BlobChunkSubstringFromFor BlobChunkFlavor = "substring_from_for"
+
+	// BlobChunkSubstr uses `substr(data, start, size)`
+	BlobChunkSubstr BlobChunkFlavor = "substr"
+
+	// BlobChunkSubstring uses `SUBSTRING(data, start, size)`
+	BlobChunkSubstring BlobChunkFlavor = "substring"
+)
+
+// Capabilities describes the features supported by the dialect and
+// the adapter of a ksql.DB, so libraries built on top of ksql can decide
+// what to do without checking the name of the driver, e.g.:
+//
+//	if db.Capabilities().Copy {
+//		return db.InsertCSV(ctx, table, records)
+//	}
+//	return db.InsertMany(ctx, table, records)
+type Capabilities struct {
+	// Returning is true if the IDs of the inserted records are
+	// read by the INSERT statements themselves, with the RETURNING
+	// clause or the OUTPUT clause on SQL Server.
+	Returning bool `json:"returning"`
+
+	// Upsert describes how the Upsert method is implemented
+	Upsert UpsertFlavor `json:"upsert"`
+
+	// UpdateByID is false if the operations matching records by their IDs,
+	// i.e. Patch, Delete, UpdateMany and Upsert, return ErrNotSupported.
+	UpdateByID bool `json:"update_by_id"`
+
+	// Copy is true if the adapter implements the CSVCopier
+	// interface, which is required by the InsertCSV method.
+	Copy bool `json:"copy"`
+
+	// Savepoints is true if the dialect supports the SAVEPOINT statement
+	Savepoints bool `json:"savepoints"`
+
+	// ListenNotify is true if the adapter implements the
+	// Listener interface, which is required by the Listen method.
+	ListenNotify bool `json:"listen_notify"`
+
+	// MultiRowInsertMany is true if InsertMany sends the records with
+	// multi-row INSERT statements, in which case the IDs are only written
+	// to the records if the dialect can compute them from the first one.
+	MultiRowInsertMany bool `json:"multi_row_insert_many"`
+}
+
+// Capabilities reports the features supported by the dialect and the
+// adapter of the DB, the adapters wrapped by WrapAdapter are reported
+// according to the features of the base adapter.
+func (c DB) Capabilities() Capabilities {
+	dialectCaps := capabilitiesOf(c.dialect)
+	base := innermostAdapter(c.db)
+
+	_, isCopier := base.(CSVCopier)
+	_, isListener := base.(Listener)
+
+	insertMethod := c.dialect.InsertMethod()
+	return Capabilities{
+		Returning:          insertMethod == insertWithReturning || insertMethod == insertWithOutput,
+		Upsert:             dialectCaps.Upsert,
+		UpdateByID:         dialectCaps.UpdateByID,
+		Copy:               isCopier,
+		Savepoints:         dialectCaps.Savepoints,
+		ListenNotify:       isListener,
+		MultiRowInsertMany: dialectCaps.MultiRowInsertMany,
+	}
+}
@@ -0,0 +1,144 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type mockListener struct {
+	mockDBAdapter
+}
+
+func (m mockListener) Listen(ctx context.Context, channel string) (<-chan Notification, error) {
+	return nil, nil
+}
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		desc     string
+		dialect  string
+		expected Capabilities
+	}{
+		{
+			desc:    "postgres",
+			dialect: "postgres",
+			expected: Capabilities{
+				Returning:  true,
+				Upsert:     UpsertOnConflict,
+				UpdateByID: true,
+				Savepoints: true,
+			},
+		},
+		{
+			desc:    "mysql",
+			dialect: "mysql",
+			expected: Capabilities{
+				Upsert:     UpsertOnDuplicateKey,
+				UpdateByID: true,
+				Savepoints: true,
+			},
+		},
+		{
+			desc:    "sqlite3",
+			dialect: "sqlite3",
+			expected: Capabilities{
+				Upsert:     UpsertOnConflict,
+				UpdateByID: true,
+				Savepoints: true,
+			},
+		},
+		{
+			desc:    "tidb",
+			dialect: "tidb",
+			expected: Capabilities{
+				Upsert:     UpsertOnDuplicateKey,
+				UpdateByID: true,
+				Savepoints: true,
+			},
+		},
+		{
+			desc:    "firebird",
+			dialect: "firebird",
+			expected: Capabilities{
+				Returning:  true,
+				Upsert:     UpsertEmulated,
+				UpdateByID: true,
+				Savepoints: true,
+			},
+		},
+		{
+			desc:    "sqlserver",
+			dialect: "sqlserver",
+			expected: Capabilities{
+				Returning:  true,
+				Upsert:     UpsertEmulated,
+				UpdateByID: true,
+				Savepoints: true,
+			},
+		},
+		{
+			desc:    "snowflake",
+			dialect: "snowflake",
+			expected: Capabilities{
+				Upsert:             UpsertEmulated,
+				UpdateByID:         true,
+				MultiRowInsertMany: true,
+			},
+		},
+		{
+			desc:    "bigquery",
+			dialect: "bigquery",
+			expected: Capabilities{
+				Upsert:             UpsertNotSupported,
+				MultiRowInsertMany: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("should report the capabilities of the "+test.desc+" dialect", func(t *testing.T) {
+			db, err := NewWithAdapter(mockDBAdapter{}, test.dialect)
+			tt.AssertNoErr(t, err)
+
+			tt.AssertEqual(t, db.Capabilities(), test.expected)
+		})
+	}
+
+	t.Run("should report multi-row inserts for mysql with consecutive IDs", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "mysql", WithMySQLConsecutiveIDs())
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, db.Capabilities().MultiRowInsertMany, true)
+	})
+
+	t.Run("should have every supported dialect reporting its own capabilities", func(t *testing.T) {
+		for name, dialect := range supportedDialects {
+			_, ok := dialect.(DialectCapabilitiesReporter)
+			tt.AssertEqual(t, ok, true, name)
+		}
+	})
+
+	t.Run("should report the optional interfaces of the adapter", func(t *testing.T) {
+		db, err := NewWithAdapter(mockCSVCopier{}, "postgres")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, db.Capabilities().Copy, true)
+		tt.AssertEqual(t, db.Capabilities().ListenNotify, false)
+
+		db, err = NewWithAdapter(mockListener{}, "postgres")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, db.Capabilities().Copy, false)
+		tt.AssertEqual(t, db.Capabilities().ListenNotify, true)
+	})
+
+	t.Run("should report the interfaces of the base adapter when using WrapAdapter", func(t *testing.T) {
+		db, err := NewWithAdapter(WrapAdapter(mockDBAdapter{}, AdapterHooks{}), "postgres")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, db.Capabilities().Copy, false)
+		tt.AssertEqual(t, db.Capabilities().ListenNotify, false)
+
+		db, err = NewWithAdapter(WrapAdapter(mockListener{}, AdapterHooks{}), "postgres")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, db.Capabilities().ListenNotify, true)
+	})
+}
@@ -0,0 +1,47 @@
+package ksql
+
+// EqualFold returns a condition comparing the column with a param
+// ignoring the case of the letters, written for the dialect of the DB,
+// so lookups like email matching don't need dialect specific queries:
+//
+//	var user User
+//	err := db.QueryOne(ctx, &user, "FROM users WHERE "+db.EqualFold("email", 0), email)
+//
+// The paramIdx is the position of the param on the query starting at 0,
+// e.g. 0 generates `$1` on postgres, and the column is not escaped so
+// it might be any expression, e.g. `u.email`.
+//
+// On sqlite3 it uses the NOCASE collation and on the other dialects it
+// compares the LOWER() of both sides, note that in both cases the regular
+// indexes of the column can't be used, so for large tables create an index
+// for the same expression, e.g. `CREATE INDEX ON users (LOWER(email))`.
+func (c DB) EqualFold(column string, paramIdx int) string {
+	placeholder := c.dialect.Placeholder(paramIdx)
+	if capabilitiesOf(c.dialect).NoCaseCollation {
+		return column + " = " + placeholder + " COLLATE NOCASE"
+	}
+	return "LOWER(" + column + ") = LOWER(" + placeholder + ")"
+}
+
+// LikeFold returns a condition matching the column against a LIKE pattern
+// ignoring the case of the letters, written for the dialect of the DB, e.g.:
+//
+//	var users []User
+//	err := db.Query(ctx, &users, "FROM users WHERE "+db.LikeFold("name", 0), "%silva%")
+//
+// The paramIdx and the column work like on the EqualFold method.
+//
+// On postgres and snowflake it uses ILIKE, on sqlite3 LIKE is already
+// case insensitive and on the other dialects it matches the LOWER()
+// of both sides.
+func (c DB) LikeFold(column string, paramIdx int) string {
+	placeholder := c.dialect.Placeholder(paramIdx)
+	caps := capabilitiesOf(c.dialect)
+	switch {
+	case caps.ILike:
+		return column + " ILIKE " + placeholder
+	case caps.NoCaseCollation:
+		return column + " LIKE " + placeholder
+	}
+	return "LOWER(" + column + ") LIKE LOWER(" + placeholder + ")"
+}
@@ -0,0 +1,51 @@
+package ksql
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestCaseInsensitiveConditions(t *testing.T) {
+	tests := []struct {
+		driver            string
+		expectedEqualFold string
+		expectedLikeFold  string
+	}{
+		{
+			driver:            "postgres",
+			expectedEqualFold: "LOWER(u.email) = LOWER($2)",
+			expectedLikeFold:  "u.email ILIKE $2",
+		},
+		{
+			driver:            "sqlite3",
+			expectedEqualFold: "u.email = ? COLLATE NOCASE",
+			expectedLikeFold:  "u.email LIKE ?",
+		},
+		{
+			driver:            "mysql",
+			expectedEqualFold: "LOWER(u.email) = LOWER(?)",
+			expectedLikeFold:  "LOWER(u.email) LIKE LOWER(?)",
+		},
+		{
+			driver:            "sqlserver",
+			expectedEqualFold: "LOWER(u.email) = LOWER(@p2)",
+			expectedLikeFold:  "LOWER(u.email) LIKE LOWER(@p2)",
+		},
+		{
+			driver:            "snowflake",
+			expectedEqualFold: "LOWER(u.email) = LOWER(?)",
+			expectedLikeFold:  "u.email ILIKE ?",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.driver, func(t *testing.T) {
+			db, err := NewWithAdapter(mockDBAdapter{}, test.driver)
+			tt.AssertNoErr(t, err)
+
+			tt.AssertEqual(t, db.EqualFold("u.email", 1), test.expectedEqualFold)
+			tt.AssertEqual(t, db.LikeFold("u.email", 1), test.expectedLikeFold)
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package ksql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// The operations recorded on the ChangeEvent.Operation attribute
+const (
+	ChangeInsert = "INSERT"
+	ChangeUpdate = "UPDATE"
+	ChangeDelete = "DELETE"
+)
+
+// ChangeEvent describes a change captured by the triggers created by EnableCDC
+type ChangeEvent struct {
+	// ID is the position of the change on the shadow table,
+	// it should be passed to ReadChanges to read the next changes.
+	ID int64
+
+	// Operation is one of ChangeInsert, ChangeUpdate or ChangeDelete
+	Operation string
+	TableName string
+	ChangedAt time.Time
+
+	// Data is a JSON object with the columns of the record after
+	// the change, or before it for deletions.
+	Data json.RawMessage
+}
+
+// Decode loads the columns of the Data attribute into the input
+// record, which must be a pointer to a struct using the `ksql` tags.
+//
+// The values are decoded with the encoding/json package, so the
+// attributes must be compatible with the JSON encoding of the column
+// types used by each database, e.g. booleans are stored as
+// numbers on sqlite3 and timestamps use different formats.
+func (e ChangeEvent) Decode(record interface{}) error {
+	v := reflect.ValueOf(record)
+	t := v.Type()
+	if err := assertStructPtr(t); err != nil {
+		return fmt.Errorf("ksql: expected record to be a pointer to struct, but got: %T", record)
+	}
+
+	info, err := structs.GetTagInfo(t.Elem())
+	if err != nil {
+		return err
+	}
+
+	var columns map[string]json.RawMessage
+	err = json.Unmarshal(e.Data, &columns)
+	if err != nil {
+		return fmt.Errorf("ksql: unable to decode the data of change %d: %w", e.ID, err)
+	}
+
+	for name, value := range columns {
+		field := info.ByName(name)
+		if !field.Valid {
+			continue
+		}
+
+		err := unmarshalJSON(value, v.Elem().Field(field.Index).Addr().Interface())
+		if err != nil {
+			return fmt.Errorf("ksql: unable to decode column `%s` of change %d: %w", name, e.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// EnableCDC starts capturing the changes made to the table by creating
+// a shadow table named `<table>_changes` and the triggers that write
+// one row to it for each inserted, updated or deleted record, which
+// can then be read with ReadChanges, e.g.:
+//
+//	err := db.EnableCDC(ctx, UsersTable, User{})
+//
+// The record is a struct, or a pointer to a struct, describing the columns
+// of the table, which are used by the triggers of the mysql and sqlite3
+// dialects, while on postgres all the columns are recorded.
+//
+// It is supported by the postgres, sqlite3 and mysql dialects, and calling
+// it again recreates the triggers, e.g. after a migration. It is not supported
+// on sqlserver, which has its own native CDC feature.
+func (c DB) EnableCDC(ctx context.Context, table Table, record interface{}) error {
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't enable CDC for ksql.Table: %s", err)
+	}
+
+	columns, err := getCDCColumns(record)
+	if err != nil {
+		return err
+	}
+
+	queries, err := buildEnableCDCQueries(c.dialect, table, columns)
+	if err != nil {
+		return err
+	}
+
+	for _, query := range queries {
+		_, err := c.Exec(ctx, query)
+		if err != nil {
+			return fmt.Errorf("ksql: error enabling CDC for table %s: %w", table.qualifiedName(), err)
+		}
+	}
+
+	return nil
+}
+
+// DisableCDC drops the triggers created by EnableCDC,
+// the shadow table and its changes are kept.
+func (c DB) DisableCDC(ctx context.Context, table Table) error {
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't disable CDC for ksql.Table: %s", err)
+	}
+
+	queries, err := buildDisableCDCQueries(c.dialect, table)
+	if err != nil {
+		return err
+	}
+
+	for _, query := range queries {
+		_, err := c.Exec(ctx, query)
+		if err != nil {
+			return fmt.Errorf("ksql: error disabling CDC for table %s: %w", table.qualifiedName(), err)
+		}
+	}
+
+	return nil
+}
+
+// ReadChanges reads up to limit changes, or all of them if limit is 0,
+// recorded on the shadow table after the change with the input ID in
+// the order they were recorded, so the events can be consumed by passing
+// the ID of the last event read to the next call, starting with 0.
+//
+// Note that the IDs are generated when each change is made, so a
+// transaction committed after another one might have smaller IDs,
+// which would be skipped if the consumer is faster than the transaction.
+func (c DB) ReadChanges(ctx context.Context, table Table, afterID int64, limit int) ([]ChangeEvent, error) {
+	if err := table.validate(); err != nil {
+		return nil, fmt.Errorf("can't read the changes of ksql.Table: %s", err)
+	}
+
+	params := []interface{}{afterID}
+	if limit > 0 {
+		params = append(params, Limit(limit, 0))
+	}
+
+	var rows []struct {
+		ID        int64           `ksql:"change_id"`
+		Operation string          `ksql:"operation"`
+		ChangedAt changeTimestamp `ksql:"changed_at"`
+		Data      string          `ksql:"data"`
+	}
+	err := c.Query(ctx, &rows, fmt.Sprintf(
+		"SELECT change_id, operation, changed_at, data FROM %s WHERE change_id > %s ORDER BY change_id",
+		cdcShadowTable(table).escapedName(c.dialect),
+		c.dialect.Placeholder(0),
+	), params...)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]ChangeEvent, len(rows))
+	for i, row := range rows {
+		events[i] = ChangeEvent{
+			ID:        row.ID,
+			Operation: row.Operation,
+			TableName: table.qualifiedName(),
+			ChangedAt: row.ChangedAt.Time,
+			Data:      json.RawMessage(row.Data),
+		}
+	}
+
+	return events, nil
+}
+
+// changeTimestamp implements the sql.Scanner interface in order to
+// read the timestamps of the shadow tables on drivers that return
+// them as strings, e.g. mysql without the `parseTime` option.
+type changeTimestamp struct {
+	time.Time
+}
+
+// Scan Implements the Scanner interface
+func (c *changeTimestamp) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case time.Time:
+		c.Time = v
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("unexpected type received to Scan a change timestamp: %T", value)
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"} {
+		t, err := time.Parse(layout, text)
+		if err == nil {
+			c.Time = t
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unable to parse change timestamp: '%s'", text)
+}
+
+func getCDCColumns(record interface{}) ([]string, error) {
+	t := reflect.TypeOf(record)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ksql: expected record to be a struct or a pointer to struct, but got: %T", record)
+	}
+
+	info, err := structs.GetTagInfo(t)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsNestedStruct {
+		return nil, fmt.Errorf("ksql: can't enable CDC using structs with the `tablename` tag")
+	}
+
+	columns := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := info.ByIndex(i)
+		if !field.Valid {
+			continue
+		}
+
+		if strings.ContainsAny(field.Name, `'"`+"`[]") {
+			return nil, fmt.Errorf("ksql: invalid column name for CDC: '%s'", field.Name)
+		}
+		columns = append(columns, field.Name)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("ksql: the record used for enabling CDC has no attributes with the `ksql` tag")
+	}
+
+	return columns, nil
+}
+
+// cdcShadowTable returns the table where the changes of the input table are recorded
+func cdcShadowTable(table Table) Table {
+	return Table{
+		name:   table.name + "_changes",
+		schema: table.schema,
+	}
+}
+
+// cdcObjectName returns the escaped name of the triggers and functions
+// used for capturing the changes of the input table.
+func cdcObjectName(dialect Dialect, table Table, suffix string) string {
+	return Table{
+		name:   strings.ReplaceAll(table.name, ".", "_") + "_cdc" + suffix,
+		schema: table.schema,
+	}.escapedName(dialect)
+}
+
+func buildEnableCDCQueries(dialect Dialect, table Table, columns []string) ([]string, error) {
+	tableName := table.escapedName(dialect)
+	shadowName := cdcShadowTable(table).escapedName(dialect)
+
+	switch capabilitiesOf(dialect).DDL {
+	case DDLPostgres:
+		function := cdcObjectName(dialect, table, "")
+		return []string{
+			"CREATE TABLE IF NOT EXISTS " + shadowName + " (" +
+				"change_id BIGSERIAL PRIMARY KEY, " +
+				"operation VARCHAR(6) NOT NULL, " +
+				"changed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+				"data TEXT NOT NULL)",
+			"CREATE OR REPLACE FUNCTION " + function + "() RETURNS TRIGGER AS $$ BEGIN " +
+				"IF TG_OP = 'DELETE' THEN " +
+				"INSERT INTO " + shadowName + " (operation, data) VALUES (TG_OP, row_to_json(OLD)::text); " +
+				"RETURN OLD; " +
+				"END IF; " +
+				"INSERT INTO " + shadowName + " (operation, data) VALUES (TG_OP, row_to_json(NEW)::text); " +
+				"RETURN NEW; " +
+				"END; $$ LANGUAGE plpgsql",
+			"DROP TRIGGER IF EXISTS " + dialect.Escape(strings.ReplaceAll(table.name, ".", "_")+"_cdc") + " ON " + tableName,
+			"CREATE TRIGGER " + dialect.Escape(strings.ReplaceAll(table.name, ".", "_")+"_cdc") +
+				" AFTER INSERT OR UPDATE OR DELETE ON " + tableName +
+				" FOR EACH ROW EXECUTE PROCEDURE " + function + "()",
+		}, nil
+
+	case DDLMySQL, DDLSQLite:
+		queries := []string{}
+		if capabilitiesOf(dialect).DDL == DDLMySQL {
+			queries = append(queries, "CREATE TABLE IF NOT EXISTS "+shadowName+" ("+
+				"change_id BIGINT AUTO_INCREMENT PRIMARY KEY, "+
+				"operation VARCHAR(6) NOT NULL, "+
+				"changed_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6), "+
+				"data LONGTEXT NOT NULL)")
+		} else {
+			queries = append(queries, "CREATE TABLE IF NOT EXISTS "+shadowName+" ("+
+				"change_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+				"operation TEXT NOT NULL, "+
+				"changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, "+
+				"data TEXT NOT NULL)")
+		}
+
+		for _, operation := range []string{ChangeInsert, ChangeUpdate, ChangeDelete} {
+			row := "NEW"
+			if operation == ChangeDelete {
+				row = "OLD"
+			}
+
+			trigger := cdcObjectName(dialect, table, "_"+strings.ToLower(operation))
+			queries = append(queries,
+				"DROP TRIGGER IF EXISTS "+trigger,
+				buildCDCRowTrigger(dialect, trigger, operation, tableName, shadowName, buildCDCJSONObject(dialect, row, columns)),
+			)
+		}
+		return queries, nil
+
+	default:
+		return nil, fmt.Errorf("%w: CDC is not supported by the %s dialect", ErrNotSupported, dialect.DriverName())
+	}
+}
+
+func buildCDCRowTrigger(dialect Dialect, trigger string, operation string, tableName string, shadowName string, data string) string {
+	insert := "INSERT INTO " + shadowName + " (operation, data) VALUES ('" + operation + "', " + data + ")"
+	if capabilitiesOf(dialect).DDL == DDLSQLite {
+		insert = "BEGIN " + insert + "; END"
+	}
+
+	return "CREATE TRIGGER " + trigger + " AFTER " + operation + " ON " + tableName + " FOR EACH ROW " + insert
+}
+
+// buildCDCJSONObject builds the expression that encodes the
+// columns of the NEW or OLD row of a trigger as a JSON object
+func buildCDCJSONObject(dialect Dialect, row string, columns []string) string {
+	isSQLite := capabilitiesOf(dialect).DDL == DDLSQLite
+
+	args := []string{}
+	for _, column := range columns {
+		value := row + "." + dialect.Escape(column)
+		if isSQLite {
+			// The json_object function of sqlite3 doesn't accept blobs:
+			value = "CASE WHEN typeof(" + value + ") = 'blob' THEN CAST(" + value + " AS TEXT) ELSE " + value + " END"
+		}
+		args = append(args, "'"+column+"', "+value)
+	}
+
+	if isSQLite {
+		return "json_object(" + strings.Join(args, ", ") + ")"
+	}
+	return "JSON_OBJECT(" + strings.Join(args, ", ") + ")"
+}
+
+func buildDisableCDCQueries(dialect Dialect, table Table) ([]string, error) {
+	switch capabilitiesOf(dialect).DDL {
+	case DDLPostgres:
+		return []string{
+			"DROP TRIGGER IF EXISTS " + dialect.Escape(strings.ReplaceAll(table.name, ".", "_")+"_cdc") + " ON " + table.escapedName(dialect),
+			"DROP FUNCTION IF EXISTS " + cdcObjectName(dialect, table, "") + "()",
+		}, nil
+
+	case DDLMySQL, DDLSQLite:
+		queries := []string{}
+		for _, operation := range []string{ChangeInsert, ChangeUpdate, ChangeDelete} {
+			queries = append(queries, "DROP TRIGGER IF EXISTS "+cdcObjectName(dialect, table, "_"+strings.ToLower(operation)))
+		}
+		return queries, nil
+
+	default:
+		return nil, fmt.Errorf("%w: CDC is not supported by the %s dialect", ErrNotSupported, dialect.DriverName())
+	}
+}
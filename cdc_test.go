@@ -0,0 +1,106 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestEnableCDC(t *testing.T) {
+	ctx := context.Background()
+
+	type record struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newAdapter := func(queries *[]string) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				*queries = append(*queries, query)
+				return NewMockResult(0, 0), nil
+			},
+		}
+	}
+
+	t.Run("should create the shadow table and the row triggers on mysql", func(t *testing.T) {
+		var queries []string
+		err := newDB(t, "mysql", newAdapter(&queries)).EnableCDC(ctx, NewTable("users"), record{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(queries), 7)
+		tt.AssertEqual(t, strings.HasPrefix(queries[0], "CREATE TABLE IF NOT EXISTS `users_changes` ("), true)
+		tt.AssertEqual(t, queries[1], "DROP TRIGGER IF EXISTS `users_cdc_insert`")
+		tt.AssertEqual(t, queries[2], "CREATE TRIGGER `users_cdc_insert` AFTER INSERT ON `users` FOR EACH ROW "+
+			"INSERT INTO `users_changes` (operation, data) VALUES ('INSERT', JSON_OBJECT('id', NEW.`id`, 'name', NEW.`name`))")
+		tt.AssertEqual(t, queries[6], "CREATE TRIGGER `users_cdc_delete` AFTER DELETE ON `users` FOR EACH ROW "+
+			"INSERT INTO `users_changes` (operation, data) VALUES ('DELETE', JSON_OBJECT('id', OLD.`id`, 'name', OLD.`name`))")
+	})
+
+	t.Run("should create a trigger function on postgres", func(t *testing.T) {
+		var queries []string
+		err := newDB(t, "postgres", newAdapter(&queries)).EnableCDC(ctx, NewTable("users").WithSchema("app"), &record{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(queries), 4)
+		tt.AssertEqual(t, strings.HasPrefix(queries[0], `CREATE TABLE IF NOT EXISTS "app"."users_changes" (`), true)
+		tt.AssertEqual(t, strings.HasPrefix(queries[1], `CREATE OR REPLACE FUNCTION "app"."users_cdc"() RETURNS TRIGGER`), true)
+		tt.AssertEqual(t, queries[3], `CREATE TRIGGER "users_cdc" AFTER INSERT OR UPDATE OR DELETE ON "app"."users" FOR EACH ROW EXECUTE PROCEDURE "app"."users_cdc"()`)
+	})
+
+	t.Run("should drop the triggers with DisableCDC", func(t *testing.T) {
+		var queries []string
+		err := newDB(t, "sqlite3", newAdapter(&queries)).DisableCDC(ctx, NewTable("users"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{
+			"DROP TRIGGER IF EXISTS `users_cdc_insert`",
+			"DROP TRIGGER IF EXISTS `users_cdc_update`",
+			"DROP TRIGGER IF EXISTS `users_cdc_delete`",
+		})
+	})
+
+	t.Run("should report errors for unsupported dialects and invalid records", func(t *testing.T) {
+		var queries []string
+		err := newDB(t, "sqlserver", newAdapter(&queries)).EnableCDC(ctx, NewTable("users"), record{})
+		tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+
+		err = newDB(t, "postgres", newAdapter(&queries)).EnableCDC(ctx, NewTable("users"), []record{})
+		tt.AssertErrContains(t, err, "struct")
+
+		err = newDB(t, "postgres", newAdapter(&queries)).EnableCDC(ctx, NewTable("users"), struct {
+			Name string `ksql:"it's"`
+		}{})
+		tt.AssertErrContains(t, err, "invalid column name")
+		tt.AssertEqual(t, len(queries), 0)
+	})
+}
+
+func TestChangeEvent(t *testing.T) {
+	t.Run("should decode the columns into the record", func(t *testing.T) {
+		event := ChangeEvent{
+			ID:   1,
+			Data: []byte(`{"id": 42, "name": "fake-name", "unknown": true}`),
+		}
+
+		var u user
+		err := event.Decode(&u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, uint(42))
+		tt.AssertEqual(t, u.Name, "fake-name")
+
+		err = ChangeEvent{ID: 2, Data: []byte(`{"id": "not-a-number"}`)}.Decode(&u)
+		tt.AssertErrContains(t, err, "`id`", "change 2")
+	})
+
+	t.Run("should parse the change timestamps returned as text", func(t *testing.T) {
+		var ts changeTimestamp
+		err := ts.Scan([]byte("2024-01-02 03:04:05.123456"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, ts.Time, time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC))
+
+		err = ts.Scan("yesterday")
+		tt.AssertErrContains(t, err, "yesterday")
+	})
+}
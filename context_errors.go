@@ -0,0 +1,103 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ContextError is returned when a query fails because its context
+// was canceled or its deadline was exceeded, it describes where the
+// cancellation came from so timeouts are easier to investigate.
+//
+// It wraps the error returned by the adapter, so checks like
+// `errors.Is(err, context.DeadlineExceeded)` still work.
+type ContextError struct {
+	// Method is the name of the ksql.DB method that was interrupted
+	Method string
+
+	// OpName is the name set with ksql.WithOpName(), if any
+	OpName string
+
+	// Elapsed is the time between sending the query and receiving the error
+	Elapsed time.Duration
+
+	// Timeout is the duration passed to the ksql.Timeout option,
+	// it is zero if the option was not used.
+	Timeout time.Duration
+
+	// FromParent is true if the context passed to KSQL was done,
+	// and false if the cancellation came from the ksql.Timeout option.
+	FromParent bool
+
+	Err error
+}
+
+func (e ContextError) Error() string {
+	cause := "the ksql.Timeout of " + e.Timeout.String() + " expired"
+	if e.FromParent {
+		cause = "the context passed to ksql was done"
+	}
+
+	method := e.Method
+	if e.OpName != "" {
+		method += " (" + e.OpName + ")"
+	}
+
+	return fmt.Sprintf("ksql: %s interrupted after %s: %s: %s", method, e.Elapsed, cause, e.Err)
+}
+
+// Unwrap returns the error returned by the adapter
+func (e ContextError) Unwrap() error {
+	return e.Err
+}
+
+type timeoutCtxKey struct{}
+
+// timeoutInfo is stored on the contexts created by the ksql.Timeout
+// option so we can tell which context was done when a query fails.
+type timeoutInfo struct {
+	parent  context.Context
+	timeout time.Duration
+}
+
+func withTimeoutInfo(parent context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(parent, timeoutCtxKey{}, timeoutInfo{
+		parent:  parent,
+		timeout: timeout,
+	})
+}
+
+// newContextError returns err unchanged if the context is not done.
+func newContextError(ctx context.Context, op OpInfo, start time.Time, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+
+	ctxErr := ContextError{
+		Method:     op.Method,
+		OpName:     op.Name,
+		Elapsed:    time.Since(start),
+		FromParent: true,
+		Err:        err,
+	}
+
+	// Operations might be nested, e.g. Insert runs inside a Transaction,
+	// so we look for the outermost ksql.Timeout that expired:
+	for {
+		info, ok := ctx.Value(timeoutCtxKey{}).(timeoutInfo)
+		if !ok {
+			break
+		}
+
+		if info.parent.Err() == nil {
+			ctxErr.Timeout = info.timeout
+			ctxErr.FromParent = false
+			break
+		}
+
+		ctx = info.parent
+	}
+
+	return ctxErr
+}
@@ -0,0 +1,76 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestContextError(t *testing.T) {
+	newAdapter := func(err error) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				if err != nil {
+					return nil, err
+				}
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}
+	}
+
+	t.Run("should report when the ksql.Timeout expires", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(nil))
+
+		_, err := db.Exec(context.Background(), "SELECT pg_sleep(10)", Timeout(10*time.Millisecond))
+
+		var ctxErr ContextError
+		tt.AssertEqual(t, errors.As(err, &ctxErr), true)
+		tt.AssertEqual(t, ctxErr.Method, "Exec")
+		tt.AssertEqual(t, ctxErr.Timeout, 10*time.Millisecond)
+		tt.AssertEqual(t, ctxErr.FromParent, false)
+		tt.AssertEqual(t, ctxErr.Elapsed >= 10*time.Millisecond, true)
+		tt.AssertEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+		tt.AssertErrContains(t, err, "Exec", "ksql.Timeout of 10ms expired", "deadline exceeded")
+	})
+
+	t.Run("should report when the parent context is done", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(nil))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := db.Exec(ctx, "SELECT pg_sleep(10)", Timeout(time.Minute))
+
+		var ctxErr ContextError
+		tt.AssertEqual(t, errors.As(err, &ctxErr), true)
+		tt.AssertEqual(t, ctxErr.Timeout, time.Duration(0))
+		tt.AssertEqual(t, ctxErr.FromParent, true)
+		tt.AssertErrContains(t, err, "the context passed to ksql was done")
+	})
+
+	t.Run("should report the ksql.Timeout of outer operations", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(nil))
+
+		// Simulating an operation that runs others with its own timeout, e.g. InsertMany:
+		outerCtx, cancel := newQueryOptions([]QueryOption{Timeout(10 * time.Millisecond)}).withTimeout(context.Background())
+		defer cancel()
+
+		_, err := db.Exec(outerCtx, "SELECT pg_sleep(10)", Timeout(time.Minute))
+
+		var ctxErr ContextError
+		tt.AssertEqual(t, errors.As(err, &ctxErr), true)
+		tt.AssertEqual(t, ctxErr.Timeout, 10*time.Millisecond)
+		tt.AssertEqual(t, ctxErr.FromParent, false)
+	})
+
+	t.Run("should not change other errors", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(errors.New("fake error")))
+
+		_, err := db.Exec(context.Background(), "SELECT 1", Timeout(time.Minute))
+		tt.AssertEqual(t, err, errors.New("fake error"))
+	})
+}
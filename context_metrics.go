@@ -0,0 +1,82 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ContextMetrics counts the operations interrupted by timeouts and
+// cancellations, its hooks can be passed to WrapAdapter so the queries
+// sent inside transactions are counted too, e.g.:
+//
+//	metrics := &ksql.ContextMetrics{}
+//	db, err := ksql.NewWithAdapter(ksql.WrapAdapter(adapter, metrics.Hooks()), "postgres")
+//
+//	// Later, e.g. when exporting the metrics:
+//	stats := metrics.Stats()
+//
+// The Exec, Query, BeginTx and Commit operations are counted, the errors
+// returned while reading the rows of a query are not seen by the hooks.
+//
+// It is safe for concurrent use.
+type ContextMetrics struct {
+	timeouts         int64
+	deadlineExceeded int64
+	canceled         int64
+}
+
+// ContextStats describes the operations counted by the ContextMetrics
+type ContextStats struct {
+	// Timeouts counts the operations interrupted because
+	// the duration of the ksql.Timeout option expired.
+	Timeouts int64 `json:"timeouts"`
+
+	// DeadlineExceeded counts the operations interrupted because
+	// the deadline of the context passed to ksql expired.
+	DeadlineExceeded int64 `json:"deadline_exceeded"`
+
+	// Canceled counts the operations interrupted because
+	// the context passed to ksql was canceled.
+	Canceled int64 `json:"canceled"`
+}
+
+// Hooks returns the AdapterHooks that update the counters, they can be
+// combined with other hooks by wrapping the adapter more than once.
+func (m *ContextMetrics) Hooks() AdapterHooks {
+	afterQuery := func(ctx context.Context, query string, args []interface{}, err error) {
+		m.observe(ctx, err)
+	}
+	return AdapterHooks{
+		AfterExec:    afterQuery,
+		AfterQuery:   afterQuery,
+		AfterBeginTx: m.observe,
+		AfterCommit:  m.observe,
+	}
+}
+
+// Stats returns the current value of the counters
+func (m *ContextMetrics) Stats() ContextStats {
+	return ContextStats{
+		Timeouts:         atomic.LoadInt64(&m.timeouts),
+		DeadlineExceeded: atomic.LoadInt64(&m.deadlineExceeded),
+		Canceled:         atomic.LoadInt64(&m.canceled),
+	}
+}
+
+func (m *ContextMetrics) observe(ctx context.Context, err error) {
+	var ctxErr ContextError
+	if !errors.As(newContextError(ctx, OpInfo{}, time.Now(), err), &ctxErr) {
+		return
+	}
+
+	switch {
+	case !ctxErr.FromParent:
+		atomic.AddInt64(&m.timeouts, 1)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		atomic.AddInt64(&m.deadlineExceeded, 1)
+	default:
+		atomic.AddInt64(&m.canceled, 1)
+	}
+}
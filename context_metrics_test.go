@@ -0,0 +1,46 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestContextMetrics(t *testing.T) {
+	metrics := &ContextMetrics{}
+	db := newDB(t, "postgres", WrapAdapter(mockDBAdapter{
+		ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+			if query == "fake-error" {
+				return nil, errors.New("fake-error")
+			}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}, metrics.Hooks()))
+
+	_, err := db.Exec(context.Background(), "SELECT pg_sleep(10)", Timeout(10*time.Millisecond))
+	tt.AssertErrContains(t, err, "deadline exceeded")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = db.Exec(ctx, "SELECT pg_sleep(10)", Timeout(time.Minute))
+	tt.AssertErrContains(t, err, "deadline exceeded")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = db.Exec(ctx, "SELECT pg_sleep(10)")
+	tt.AssertErrContains(t, err, "canceled")
+
+	// Errors unrelated to the context are not counted:
+	_, err = db.Exec(context.Background(), "fake-error")
+	tt.AssertErrContains(t, err, "fake-error")
+
+	tt.AssertEqual(t, metrics.Stats(), ContextStats{
+		Timeouts:         1,
+		DeadlineExceeded: 1,
+		Canceled:         1,
+	})
+}
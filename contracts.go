@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -14,10 +15,23 @@ var ErrRecordNotFound error = errors.Wrap(sql.ErrNoRows, "ksql: the query return
 // ErrAbortIteration ...
 var ErrAbortIteration error = fmt.Errorf("ksql: abort iteration, should only be used inside QueryChunks function")
 
+// ErrImmutableColumn is returned when the StrictImmutable option is used and
+// an update tries to write an attribute tagged with the `immutable` modifier.
+var ErrImmutableColumn error = fmt.Errorf("ksql: can't update immutable column")
+
+// ErrTooManyRows is returned by the Query method when the query returns more
+// rows than allowed by the MaxRows or WithMaxRows options.
+var ErrTooManyRows error = fmt.Errorf("ksql: the query returned more rows than the configured maximum")
+
 // Provider describes the ksql public behavior.
 //
 // The Insert, Update, Delete and QueryOne functions return ksql.ErrRecordNotFound
-// if no record was found or no rows were changed during the operation.
+// if no record was found or no rows were changed during the operation, for
+// Update, Patch and Delete this can be disabled with the AllowZeroRows option.
+//
+// The operations receiving query params accept the optional `ksql.QueryOption`
+// arguments mixed with the params, the other ones read them from the context,
+// as described on the `ksql.WithQueryOptions()` function.
 type Provider interface {
 	Insert(ctx context.Context, table Table, record interface{}) error
 	Patch(ctx context.Context, table Table, record interface{}) error
@@ -45,6 +59,30 @@ type Table struct {
 
 	// IDColumns defaults to []string{"id"} if unset
 	idColumns []string
+
+	// sequence is the name of the database sequence used for generating
+	// the IDs on inserts, if unset the IDs are generated by the database.
+	sequence string
+
+	// schema is used for qualifying the name of the table if set
+	schema string
+
+	// findFilter is used by Find, QueryByKey and ksql.Repo
+	// and listOrderBy only by the List method of ksql.Repo
+	findFilter  string
+	listOrderBy string
+
+	// hasTriggers disables the OUTPUT clause on sqlserver
+	hasTriggers bool
+
+	// softDeleteColumn is set by WithSoftDelete
+	softDeleteColumn string
+
+	// historyTable is set by WithHistory
+	historyTable string
+
+	// systemVersioned is set by WithSystemVersioning
+	systemVersioned bool
 }
 
 // NewTable returns a Table instance that stores
@@ -75,11 +113,119 @@ func NewTable(tableName string, ids ...string) Table {
 	}
 }
 
+// WithSequence returns a copy of the Table that generates
+// the IDs of inserted records from the input database sequence,
+// which is useful for schemas where the ID column has no default value.
+//
+// On Insert the next value of the sequence is fetched and assigned to the
+// ID attribute of the record before the INSERT statement is executed,
+// records with a non-zero ID are inserted without consuming the sequence.
+//
+// It is only supported by the postgres, sqlserver, firebird and snowflake
+// dialects and only for tables with a single ID column, e.g.:
+//
+//	var UsersTable = ksql.NewTable("users").WithSequence("users_id_seq")
+func (t Table) WithSequence(sequenceName string) Table {
+	t.sequence = sequenceName
+	return t
+}
+
+// WithSchema returns a copy of the Table whose name is qualified with the
+// input schema in all the queries generated for it, e.g. `"app"."users"`.
+func (t Table) WithSchema(schema string) Table {
+	t.schema = schema
+	return t
+}
+
+// WithFindFilter returns a copy of the Table whose records are only found
+// if they match the input condition when loaded by the functions that look
+// records up by their keys, i.e. Find and QueryByKey, as well as by the Find
+// and List methods of ksql.Repo, which is useful for centralizing policies
+// such as soft deletes:
+//
+//	var UsersTable = ksql.NewTable("users").WithFindFilter("deleted_at IS NULL")
+//
+// Queries written manually, e.g. with Query or QueryOne, don't receive
+// the Table and are not affected, so they must filter the records themselves.
+func (t Table) WithFindFilter(condition string) Table {
+	t.findFilter = condition
+	return t
+}
+
+// WithListOrderBy returns a copy of the Table whose records are sorted by
+// the input expression, e.g. "created_at DESC", when listed by the List
+// method of ksql.Repo, it doesn't affect any of the other functions.
+func (t Table) WithListOrderBy(orderBy string) Table {
+	t.listOrderBy = orderBy
+	return t
+}
+
+// WithTriggers returns a copy of the Table for tables with triggers,
+// which on sqlserver can't be used with the OUTPUT clause that KSQL
+// uses for loading the IDs and the generated columns of inserted records.
+//
+// On sqlserver the Insert method then loads these columns with a SELECT
+// sent on the same batch as the INSERT, filtering the ID column
+// by SCOPE_IDENTITY(), which ignores the rows inserted by the triggers,
+// or by its value if the record has an explicit ID. Only one ID column
+// can be left unset, since tables have at most one IDENTITY column.
+//
+// It doesn't change the queries of the other dialects.
+func (t Table) WithTriggers() Table {
+	t.hasTriggers = true
+	return t
+}
+
+// escapedName returns the name of the table escaped for
+// the input dialect and qualified with its schema if set.
+func (t Table) escapedName(dialect Dialect) string {
+	if t.schema == "" {
+		return dialect.Escape(t.name)
+	}
+	return dialect.Escape(t.schema) + "." + dialect.Escape(t.name)
+}
+
+// qualifiedName returns the unescaped name of the table
+// qualified with its schema if set, e.g. `app.users`.
+func (t Table) qualifiedName() string {
+	if t.schema == "" {
+		return t.name
+	}
+	return t.schema + "." + t.name
+}
+
+// withFindFilter adds the find filter of the table to the condition.
+func (t Table) withFindFilter(dialect Dialect, condition string) string {
+	filter := t.filter(dialect)
+	if filter == "" {
+		return condition
+	}
+	return "(" + condition + ") AND (" + filter + ")"
+}
+
+// filter returns the find filter of the table combined
+// with the condition skipping the soft deleted records.
+func (t Table) filter(dialect Dialect) string {
+	if t.softDeleteColumn == "" {
+		return t.findFilter
+	}
+
+	notDeleted := dialect.Escape(t.softDeleteColumn) + " IS NULL"
+	if t.findFilter == "" {
+		return notDeleted
+	}
+	return "(" + t.findFilter + ") AND " + notDeleted
+}
+
 func (t Table) validate() error {
 	if t.name == "" {
 		return fmt.Errorf("table name cannot be an empty string")
 	}
 
+	if t.sequence != "" && len(t.idColumns) != 1 {
+		return fmt.Errorf("sequences can only be used with tables with a single ID column")
+	}
+
 	for _, fieldName := range t.idColumns {
 		if fieldName == "" {
 			return fmt.Errorf("ID columns cannot be empty strings")
@@ -90,11 +236,20 @@ func (t Table) validate() error {
 }
 
 func (t Table) insertMethodFor(dialect Dialect) insertMethod {
-	if len(t.idColumns) == 1 {
-		return dialect.InsertMethod()
+	if t.sequence != "" {
+		// The IDs are fetched from the sequence before the insert:
+		return insertWithNoIDRetrieval
 	}
 
 	insertMethod := dialect.InsertMethod()
+	if insertMethod == insertWithOutput && t.hasTriggers {
+		return insertWithScopeIdentity
+	}
+
+	if len(t.idColumns) == 1 {
+		return insertMethod
+	}
+
 	if insertMethod == insertWithLastInsertID {
 		return insertWithNoIDRetrieval
 	}
@@ -118,4 +273,47 @@ type ChunkParser struct {
 	// Where the actual Record type should be of a struct
 	// representing the rows you are expecting to receive.
 	ForEachChunk interface{}
+
+	// KeyColumns enables the keyset mode, where instead of streaming the
+	// results of a single query each chunk is loaded by a separate query
+	// that adds `WHERE <keys> > <last keys> ORDER BY <keys> LIMIT <ChunkSize>`
+	// to the input Query, which avoids keeping huge result sets or long
+	// running transactions open, e.g. on MySQL.
+	//
+	// The columns must uniquely identify each row and match attributes
+	// of the struct, and the Query can't contain GROUP BY, ORDER BY,
+	// LIMIT or similar clauses.
+	KeyColumns []string
+
+	// StartAfter optionally sets the values of the KeyColumns after which
+	// the iteration starts, which allows resuming an interrupted job from
+	// the LastKey reported by OnProgress.
+	StartAfter []interface{}
+
+	// OnProgress is optionally called after each chunk is processed by
+	// ForEachChunk, returning ErrAbortIteration from it stops the iteration
+	// and any other error is returned by QueryChunks.
+	OnProgress func(ctx context.Context, progress ChunkProgress) error
+}
+
+// ChunkProgress describes how much of a QueryChunks operation
+// was already processed when the OnProgress callback is called.
+type ChunkProgress struct {
+	Rows    int
+	Chunks  int
+	Elapsed time.Duration
+
+	// LastKey contains the values of the KeyColumns of the last
+	// record processed, it is only set when KeyColumns is used.
+	LastKey []interface{}
+}
+
+func (p ChunkParser) reportProgress(ctx context.Context, progress *ChunkProgress, start time.Time, size int) error {
+	progress.Rows += size
+	progress.Chunks++
+	progress.Elapsed = time.Since(start)
+	if p.OnProgress == nil {
+		return nil
+	}
+	return p.OnProgress(ctx, *progress)
 }
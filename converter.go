@@ -0,0 +1,78 @@
+package ksql
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// Converter converts the values of the attributes tagged with the
+// `converter=<name>` modifier, where the name is the one used for
+// registering it with the WithConverter option, so one-off conversions,
+// e.g. of third-party types, don't require implementing the sql.Scanner
+// and driver.Valuer interfaces on them.
+type Converter interface {
+	// ToDB returns the value sent to the database for the attribute
+	ToDB(value interface{}) (interface{}, error)
+
+	// FromDB loads the value read from the database into the
+	// attribute, which is received as a pointer.
+	FromDB(dbValue interface{}, attrPtr interface{}) error
+}
+
+// WithConverter registers a converter on the client with the name used
+// by the `converter` modifier, e.g. for storing an enum as text:
+//
+//	type User struct {
+//		ID     int       `ksql:"id"`
+//		Status pb.Status `ksql:"status,converter=statusEnum"`
+//	}
+//
+//	db, err := kpgx.NewFromPgxPool(pool, ksql.WithConverter("statusEnum", statusConverter{}))
+//
+// Using a converter name that was not registered on the client
+// makes the operations reading or writing the attribute fail.
+func WithConverter(name string, converter Converter) Option {
+	return func(db *DB) {
+		converters := make(map[string]Converter, len(db.converters)+1)
+		for k, v := range db.converters {
+			converters[k] = v
+		}
+		converters[name] = converter
+		db.converters = converters
+	}
+}
+
+// encodeWithConverter converts the value of an attribute tagged
+// with `converter` using the converter registered with its name.
+func encodeWithConverter(converters map[string]Converter, fieldInfo *structs.FieldInfo, value interface{}) (interface{}, error) {
+	converter, found := converters[fieldInfo.Converter]
+	if !found {
+		return nil, fmt.Errorf("ksql: no converter named '%s' was registered for the attribute '%s'", fieldInfo.Converter, fieldInfo.Name)
+	}
+
+	value, err := converter.ToDB(value)
+	if err != nil {
+		return nil, fmt.Errorf("error converting the attribute '%s' with the converter '%s': %w", fieldInfo.Name, fieldInfo.Converter, err)
+	}
+
+	return value, nil
+}
+
+// converterScanner implements the sql.Scanner interface in order
+// to load the attributes tagged with `converter`.
+type converterScanner struct {
+	Attr      reflect.Value
+	Name      string
+	Converter Converter
+}
+
+// Scan Implements the Scanner interface
+func (s converterScanner) Scan(value interface{}) error {
+	if s.Converter == nil {
+		return fmt.Errorf("ksql: no converter named '%s' was registered", s.Name)
+	}
+
+	return s.Converter.FromDB(value, s.Attr.Addr().Interface())
+}
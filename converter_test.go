@@ -0,0 +1,120 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type orderStatus int
+
+const (
+	statusPending orderStatus = iota + 1
+	statusShipped
+)
+
+type statusConverter struct{}
+
+func (statusConverter) ToDB(value interface{}) (interface{}, error) {
+	switch value.(orderStatus) {
+	case statusPending:
+		return "pending", nil
+	case statusShipped:
+		return "shipped", nil
+	default:
+		return nil, fmt.Errorf("invalid status: %v", value)
+	}
+}
+
+func (statusConverter) FromDB(dbValue interface{}, attrPtr interface{}) error {
+	status := attrPtr.(*orderStatus)
+	switch dbValue {
+	case "pending":
+		*status = statusPending
+	case "shipped":
+		*status = statusShipped
+	default:
+		return fmt.Errorf("invalid status: %v", dbValue)
+	}
+	return nil
+}
+
+type order struct {
+	ID     int         `ksql:"id"`
+	Status orderStatus `ksql:"status,converter=status"`
+}
+
+var ordersTable = NewTable("orders")
+
+func TestConverters(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should convert the attributes when writing", func(t *testing.T) {
+		var params []interface{}
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+				params = args
+				return NewMockResult(42, 1), nil
+			},
+		}, "sqlite3", WithConverter("status", statusConverter{}))
+		tt.AssertNoErr(t, err)
+
+		o := order{Status: statusShipped}
+		err = db.Insert(ctx, ordersTable, &o)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, params, []interface{}{"shipped"})
+
+		o.Status = statusPending
+		err = db.Patch(ctx, ordersTable, &o)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, params, []interface{}{"pending", 42})
+	})
+
+	t.Run("should convert the attributes when reading", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"id", "status"}, []interface{}{1, "shipped"}), nil
+			},
+		}, "sqlite3", WithConverter("status", statusConverter{}))
+		tt.AssertNoErr(t, err)
+
+		var o order
+		err = db.QueryOne(ctx, &o, "FROM orders")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, o, order{ID: 1, Status: statusShipped})
+	})
+
+	t.Run("should report the errors of the converters", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"id", "status"}, []interface{}{1, "lost"}), nil
+			},
+		}, "sqlite3", WithConverter("status", statusConverter{}))
+		tt.AssertNoErr(t, err)
+
+		err = db.Insert(ctx, ordersTable, &order{Status: 42})
+		tt.AssertErrContains(t, err, "status", "invalid status: 42")
+
+		var o order
+		err = db.QueryOne(ctx, &o, "FROM orders")
+		tt.AssertErrContains(t, err, "invalid status: lost")
+	})
+
+	t.Run("should return an error if the converter was not registered", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"id", "status"}, []interface{}{1, "shipped"}), nil
+			},
+		}, "sqlite3")
+		tt.AssertNoErr(t, err)
+
+		err = db.Insert(ctx, ordersTable, &order{Status: statusShipped})
+		tt.AssertErrContains(t, err, "no converter named 'status'")
+
+		var o order
+		err = db.QueryOne(ctx, &o, "FROM orders")
+		tt.AssertErrContains(t, err, "no converter named 'status'")
+	})
+}
@@ -0,0 +1,222 @@
+package ksql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// CreateTableQuery builds a CREATE TABLE statement for the input table
+// from the `ksql` tags of the record, which must be a struct or a pointer
+// to struct, mapping the type of each attribute to a column type of the
+// dialect, e.g. int64 attributes are created as BIGINT columns.
+//
+// It is meant for tests and prototypes, see `ksqltest.CreateTable()`,
+// so the columns have no constraints other than the primary key made of
+// the ID columns, which are auto incremented if the table has a single
+// integer ID column and no sequence, and all other columns are nullable.
+//
+// It is supported by the postgres, sqlite3, mysql, tidb and sqlserver dialects.
+func (c DB) CreateTableQuery(table Table, record interface{}) (string, error) {
+	if err := table.validate(); err != nil {
+		return "", fmt.Errorf("can't create ksql.Table: %s", err)
+	}
+
+	return buildCreateTableQuery(c.dialect, table, record)
+}
+
+func buildCreateTableQuery(dialect Dialect, table Table, record interface{}) (string, error) {
+	ddl := capabilitiesOf(dialect).DDL
+	switch ddl {
+	case DDLPostgres, DDLSQLite, DDLMySQL, DDLSQLServer:
+	default:
+		return "", fmt.Errorf("%w: CreateTableQuery is not supported by the %s dialect", ErrNotSupported, dialect.DriverName())
+	}
+
+	t := reflect.TypeOf(record)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("ksql: expected record to be a struct or a pointer to struct, but got: %T", record)
+	}
+
+	info, err := structs.GetTagInfo(t)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsNestedStruct {
+		return "", fmt.Errorf("ksql: can't create tables from structs with the `tablename` tag")
+	}
+
+	isID := map[string]bool{}
+	for _, idName := range table.idColumns {
+		if !info.ByName(idName).Valid {
+			return "", fmt.Errorf("ksql: the record has no attribute tagged as the ID column `%s`", idName)
+		}
+		isID[idName] = true
+	}
+
+	columns := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := info.ByIndex(i)
+		if !field.Valid {
+			continue
+		}
+
+		fieldType := t.Field(i).Type
+		autoIncrement := isID[field.Name] && len(table.idColumns) == 1 && table.sequence == "" && isIntegerType(fieldType)
+
+		columnType, err := getColumnType(dialect, field, fieldType, isID[field.Name], autoIncrement)
+		if err != nil {
+			return "", fmt.Errorf("ksql: can't create column `%s`: %w", field.Name, err)
+		}
+
+		columns = append(columns, dialect.Escape(field.Name)+" "+columnType)
+	}
+
+	// sqlite3 only auto increments INTEGER PRIMARY KEY columns,
+	// so in this case the primary key is declared on the column:
+	sqliteRowID := ddl == DDLSQLite && len(table.idColumns) == 1 &&
+		table.sequence == "" && isIntegerType(t.Field(info.ByName(table.idColumns[0]).Index).Type)
+	if !sqliteRowID {
+		escapedIDs := []string{}
+		for _, idName := range table.idColumns {
+			escapedIDs = append(escapedIDs, dialect.Escape(idName))
+		}
+		columns = append(columns, "PRIMARY KEY ("+strings.Join(escapedIDs, ", ")+")")
+	}
+
+	return fmt.Sprintf(
+		"CREATE TABLE %s (%s)",
+		table.escapedName(dialect),
+		strings.Join(columns, ", "),
+	), nil
+}
+
+func isIntegerType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// nullTypes maps the sql.Null* types to the types they wrap
+var nullTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+	reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(byte(0)),
+	reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+	reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+	reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+	reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(sql.NullTime{}):    timeType,
+}
+
+// getColumnType returns the type of the column
+// used for storing the attribute on each dialect
+func getColumnType(dialect Dialect, field *structs.FieldInfo, t reflect.Type, isID bool, autoIncrement bool) (string, error) {
+	ddl := capabilitiesOf(dialect).DDL
+
+	byDDL := func(postgres, sqlite3, mysql, sqlserver string) string {
+		return map[DDLFlavor]string{
+			DDLPostgres:  postgres,
+			DDLSQLite:    sqlite3,
+			DDLMySQL:     mysql,
+			DDLSQLServer: sqlserver,
+		}[ddl]
+	}
+
+	switch {
+	case field.SerializeAsJSON:
+		return byDDL("JSONB", "TEXT", "JSON", "NVARCHAR(MAX)"), nil
+	case field.Decimal:
+		return byDDL("NUMERIC", "NUMERIC", "DECIMAL(38, 10)", "DECIMAL(38, 10)"), nil
+	case field.Blob:
+		return byDDL("BYTEA", "BLOB", "LONGBLOB", "VARBINARY(MAX)"), nil
+	case field.Hstore:
+		if ddl != DDLPostgres {
+			return "", fmt.Errorf("the hstore type only exists on postgres")
+		}
+		return "HSTORE", nil
+	case field.Duration:
+		return byDDL("INTERVAL", "INTEGER", "BIGINT", "BIGINT"), nil
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if wrapped, ok := nullTypes[t]; ok {
+		t = wrapped
+	}
+
+	if autoIncrement {
+		switch ddl {
+		case DDLPostgres:
+			if t.Size() <= 4 {
+				return "SERIAL", nil
+			}
+			return "BIGSERIAL", nil
+		case DDLSQLite:
+			return "INTEGER PRIMARY KEY", nil
+		}
+	}
+
+	var columnType string
+	switch t.Kind() {
+	case reflect.Bool:
+		columnType = byDDL("BOOLEAN", "BOOLEAN", "BOOLEAN", "BIT")
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		columnType = byDDL("SMALLINT", "INTEGER", "SMALLINT", "SMALLINT")
+	case reflect.Int32, reflect.Uint16:
+		columnType = byDDL("INTEGER", "INTEGER", "INT", "INT")
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		columnType = byDDL("BIGINT", "INTEGER", "BIGINT", "BIGINT")
+	case reflect.Uint, reflect.Uint64:
+		columnType = byDDL("BIGINT", "INTEGER", "BIGINT UNSIGNED", "BIGINT")
+	case reflect.Float32:
+		columnType = byDDL("REAL", "REAL", "FLOAT", "REAL")
+	case reflect.Float64:
+		columnType = byDDL("DOUBLE PRECISION", "REAL", "DOUBLE", "FLOAT")
+	case reflect.String:
+		// Text columns can't be used as keys on mysql and sqlserver:
+		if isID {
+			columnType = byDDL("TEXT", "TEXT", "VARCHAR(255)", "NVARCHAR(255)")
+		} else {
+			columnType = byDDL("TEXT", "TEXT", "TEXT", "NVARCHAR(MAX)")
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return "", fmt.Errorf("unable to map type %v to a column type, use the `json` modifier for storing it as JSON", t)
+		}
+		columnType = byDDL("BYTEA", "BLOB", "LONGBLOB", "VARBINARY(MAX)")
+	case reflect.Struct:
+		if t != timeType {
+			return "", fmt.Errorf("unable to map type %v to a column type, use the `json` modifier for storing it as JSON", t)
+		}
+		columnType = byDDL("TIMESTAMPTZ", "TIMESTAMP", "DATETIME(6)", "DATETIME2")
+	default:
+		return "", fmt.Errorf("unable to map type %v to a column type, use the `json` modifier for storing it as JSON", t)
+	}
+
+	if autoIncrement {
+		switch ddl {
+		case DDLMySQL:
+			columnType += " AUTO_INCREMENT"
+		case DDLSQLServer:
+			columnType += " IDENTITY(1,1)"
+		}
+	}
+
+	return columnType, nil
+}
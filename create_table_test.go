@@ -0,0 +1,133 @@
+package ksql
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestCreateTableQuery(t *testing.T) {
+	type record struct {
+		ID        uint           `ksql:"id"`
+		Name      string         `ksql:"name"`
+		Age       *int32         `ksql:"age"`
+		Active    bool           `ksql:"active"`
+		Score     string         `ksql:"score,decimal"`
+		Address   address        `ksql:"address,json"`
+		CreatedAt time.Time      `ksql:"created_at"`
+		Nickname  sql.NullString `ksql:"nickname"`
+	}
+
+	tests := []struct {
+		desc          string
+		dialect       string
+		table         Table
+		record        interface{}
+		expectedQuery string
+		expectedErr   string
+	}{
+		{
+			desc:    "should map the types on postgres",
+			dialect: "postgres",
+			table:   NewTable("users"),
+			record:  record{},
+			expectedQuery: `CREATE TABLE "users" ("id" BIGSERIAL, "name" TEXT, "age" INTEGER, "active" BOOLEAN, ` +
+				`"score" NUMERIC, "address" JSONB, "created_at" TIMESTAMPTZ, "nickname" TEXT, PRIMARY KEY ("id"))`,
+		},
+		{
+			desc:    "should use INTEGER PRIMARY KEY on sqlite3",
+			dialect: "sqlite3",
+			table:   NewTable("users"),
+			record:  &record{},
+			expectedQuery: "CREATE TABLE `users` (`id` INTEGER PRIMARY KEY, `name` TEXT, `age` INTEGER, `active` BOOLEAN, " +
+				"`score` NUMERIC, `address` TEXT, `created_at` TIMESTAMP, `nickname` TEXT)",
+		},
+		{
+			desc:    "should use AUTO_INCREMENT on mysql",
+			dialect: "mysql",
+			table:   NewTable("users"),
+			record:  record{},
+			expectedQuery: "CREATE TABLE `users` (`id` BIGINT UNSIGNED AUTO_INCREMENT, `name` TEXT, `age` INT, `active` BOOLEAN, " +
+				"`score` DECIMAL(38, 10), `address` JSON, `created_at` DATETIME(6), `nickname` TEXT, PRIMARY KEY (`id`))",
+		},
+		{
+			desc:    "should use IDENTITY on sqlserver",
+			dialect: "sqlserver",
+			table:   NewTable("users"),
+			record:  record{},
+			expectedQuery: "CREATE TABLE [users] ([id] BIGINT IDENTITY(1,1), [name] NVARCHAR(MAX), [age] INT, [active] BIT, " +
+				"[score] DECIMAL(38, 10), [address] NVARCHAR(MAX), [created_at] DATETIME2, [nickname] NVARCHAR(MAX), PRIMARY KEY ([id]))",
+		},
+		{
+			desc:    "should create composite primary keys without auto increment",
+			dialect: "sqlite3",
+			table:   NewTable("user_permissions", "user_id", "perm_id"),
+			record: struct {
+				UserID int    `ksql:"user_id"`
+				PermID string `ksql:"perm_id"`
+			}{},
+			expectedQuery: "CREATE TABLE `user_permissions` (`user_id` INTEGER, `perm_id` TEXT, PRIMARY KEY (`user_id`, `perm_id`))",
+		},
+		{
+			desc:    "should not auto increment IDs generated by sequences",
+			dialect: "postgres",
+			table:   NewTable("users").WithSequence("users_seq"),
+			record: struct {
+				ID int32 `ksql:"id"`
+			}{},
+			expectedQuery: `CREATE TABLE "users" ("id" INTEGER, PRIMARY KEY ("id"))`,
+		},
+		{
+			desc:    "should map the duration modifier to intervals on postgres",
+			dialect: "postgres",
+			table:   NewTable("jobs"),
+			record: struct {
+				ID      int           `ksql:"id"`
+				Timeout time.Duration `ksql:"timeout,duration=ms"`
+			}{},
+			expectedQuery: `CREATE TABLE "jobs" ("id" BIGSERIAL, "timeout" INTERVAL, PRIMARY KEY ("id"))`,
+		},
+		{
+			desc:    "should report an error if the ID column is missing",
+			dialect: "postgres",
+			table:   NewTable("users"),
+			record: struct {
+				Name string `ksql:"name"`
+			}{},
+			expectedErr: "ID column `id`",
+		},
+		{
+			desc:        "should report an error for unsupported dialects",
+			dialect:     "bigquery",
+			table:       NewTable("users"),
+			record:      record{},
+			expectedErr: "not supported",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			db, err := NewWithAdapter(mockDBAdapter{}, test.dialect)
+			tt.AssertNoErr(t, err)
+
+			query, err := db.CreateTableQuery(test.table, test.record)
+			if test.expectedErr != "" {
+				tt.AssertErrContains(t, err, test.expectedErr)
+				return
+			}
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, query, test.expectedQuery)
+		})
+	}
+
+	t.Run("should report ErrNotSupported for unsupported dialects", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "snowflake")
+		tt.AssertNoErr(t, err)
+
+		_, err = db.CreateTableQuery(NewTable("users"), record{})
+		tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+	})
+}
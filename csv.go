@@ -0,0 +1,228 @@
+package ksql
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// CSVOptions describes the optional configurations of `ksql.InsertCSV()`
+type CSVOptions struct {
+	// Columns lists the name of the column that will receive each field of
+	// the CSV records, if it is empty the first record is used as header.
+	Columns []string
+
+	// Comma is the field delimiter, it defaults to ','
+	Comma rune
+}
+
+// QueryCSV runs the query and streams its results to the writer as CSV,
+// the first record written is a header containing the column names.
+//
+// NULL values are written as empty fields, time values are written using
+// the RFC3339 format and all other values use their default Go formatting.
+//
+// Just like with QueryJSON the SELECT part of the query can't be omitted.
+func (c DB) QueryCSV(ctx context.Context, w io.Writer, query string, params ...interface{}) error {
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).QueryCSV(ctx, w, query, params...)
+		})
+	}
+
+	opts, params := extractQueryOptions(params)
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := c.runMapQuery(ctx, OpInfo{Method: "QueryCSV"}, opts, query, params...)
+	if err == errDryRun {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for rows.Next() {
+		m, err := scanRowsIntoMap(c.dialect, rows, columns)
+		if err != nil {
+			return err
+		}
+
+		for i, column := range columns {
+			record[i] = formatCSVValue(m[column])
+		}
+
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// InsertCSV inserts all the records read from the CSV into the table,
+// by default the header of the CSV is used for deciding which column
+// receives each field, empty fields are inserted as NULL.
+//
+// If the adapter implements the `ksql.CSVCopier` interface the bulk loading
+// mechanism of the database is used, otherwise the records are inserted one by
+// one inside a transaction, so in both cases either all records are inserted
+// or none of them are.
+func (c DB) InsertCSV(ctx context.Context, table Table, r io.Reader, opts CSVOptions) error {
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).InsertCSV(ctx, table, r, opts)
+		})
+	}
+
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't insert csv on ksql.Table: %s", err)
+	}
+
+	csvReader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		csvReader.Comma = opts.Comma
+	}
+
+	columns := opts.Columns
+	if len(columns) == 0 {
+		header, err := csvReader.Read()
+		if err == io.EOF {
+			return fmt.Errorf("ksql: can't insert csv: the header is missing")
+		}
+		if err != nil {
+			return err
+		}
+
+		// Copying since the csv package might reuse the slice:
+		columns = make([]string, len(header))
+		for i, name := range header {
+			columns[i] = strings.TrimSpace(name)
+		}
+	}
+	csvReader.FieldsPerRecord = len(columns)
+
+	if copier, ok := getCSVCopier(c.db); ok {
+		return copyCSV(ctx, copier, table.qualifiedName(), columns, csvReader)
+	}
+
+	escapedColumns := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		escapedColumns[i] = c.dialect.Escape(column)
+		placeholders[i] = c.dialect.Placeholder(i)
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		table.escapedName(c.dialect),
+		strings.Join(escapedColumns, ", "),
+		strings.Join(placeholders, ", "),
+	)
+	query = addBatchHints(c.dialect, query)
+
+	return c.Transaction(ctx, func(db Provider) error {
+		params := make([]interface{}, len(columns))
+		for {
+			record, err := csvReader.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			for i, field := range record {
+				params[i] = field
+				if field == "" {
+					params[i] = nil
+				}
+			}
+
+			_, err = db.Exec(ctx, query, params...)
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// copyCSV normalizes the records of the csvReader into the
+// format expected by the CSVCopier interface while they are read.
+func copyCSV(
+	ctx context.Context,
+	copier CSVCopier,
+	tableName string,
+	columns []string,
+	csvReader *csv.Reader,
+) error {
+	pr, pw := io.Pipe()
+	go func() {
+		csvWriter := csv.NewWriter(pw)
+		for {
+			record, err := csvReader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+
+			if err := csvWriter.Write(record); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+
+		csvWriter.Flush()
+		pw.CloseWithError(csvWriter.Error())
+	}()
+
+	err := copier.CopyFromCSV(ctx, tableName, columns, pr)
+
+	// Closing the reader so the goroutine above
+	// stops in case the copier returned early:
+	pr.Close()
+
+	return err
+}
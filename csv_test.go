@@ -0,0 +1,73 @@
+package ksql
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type mockCSVCopier struct {
+	mockDBAdapter
+	CopyFromCSVFn func(ctx context.Context, tableName string, columns []string, r io.Reader) error
+}
+
+func (m mockCSVCopier) CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+	return m.CopyFromCSVFn(ctx, tableName, columns, r)
+}
+
+func TestInsertCSV(t *testing.T) {
+	t.Run("should use the CSVCopier if the adapter implements it", func(t *testing.T) {
+		var tableName string
+		var columns []string
+		var content string
+		db, err := NewWithAdapter(mockCSVCopier{
+			CopyFromCSVFn: func(ctx context.Context, t string, c []string, r io.Reader) error {
+				tableName = t
+				columns = c
+				b, err := ioutil.ReadAll(r)
+				content = string(b)
+				return err
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.InsertCSV(context.Background(), usersTable, strings.NewReader(
+			" name ;age\n"+
+				"\"Bia; Jr.\";22\n"+
+				"João;\n",
+		), CSVOptions{Comma: ';'})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, tableName, "users")
+		tt.AssertEqual(t, columns, []string{"name", "age"})
+		tt.AssertEqual(t, content, "Bia; Jr.,22\nJoão,\n")
+	})
+
+	t.Run("should report errors from the csv reader when using the CSVCopier", func(t *testing.T) {
+		db, err := NewWithAdapter(mockCSVCopier{
+			CopyFromCSVFn: func(ctx context.Context, t string, c []string, r io.Reader) error {
+				_, err := ioutil.ReadAll(r)
+				return err
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.InsertCSV(context.Background(), usersTable, strings.NewReader(
+			"name,age\n"+
+				"Bia,22,extra-field\n",
+		), CSVOptions{})
+		tt.AssertErrContains(t, err, "wrong number of fields")
+	})
+
+	t.Run("should report error if the header is missing", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.InsertCSV(context.Background(), usersTable, strings.NewReader(""), CSVOptions{})
+		tt.AssertErrContains(t, err, "header", "missing")
+	})
+}
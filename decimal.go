@@ -0,0 +1,77 @@
+package ksql
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// encodeDecimal converts the value of an attribute tagged with the `decimal`
+// modifier to a decimal string, which all supported databases are able to
+// convert to their NUMERIC or DECIMAL types without losing precision.
+//
+// Note that sqlite has no such type, so its columns should
+// be declared as TEXT in order to keep the exact values.
+func encodeDecimal(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+
+	return v.Elem().String(), nil
+}
+
+// decimalScanner implements the sql.Scanner interface in order to
+// load decimal values into the attributes tagged with `decimal`.
+type decimalScanner struct {
+	Attr reflect.Value
+}
+
+// Scan Implements the Scanner interface
+func (d decimalScanner) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		d.Attr.Set(reflect.Zero(d.Attr.Type()))
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		// Some drivers, e.g. sqlite3, might return floats for numeric columns:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("unexpected type received to Scan a decimal: %T", value)
+	}
+
+	attr := d.Attr
+	if attr.Kind() == reflect.Ptr {
+		attr.Set(reflect.New(attr.Type().Elem()))
+		attr = attr.Elem()
+	}
+
+	if unmarshaler, ok := attr.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(text))
+	}
+
+	attr.SetString(text)
+	return nil
+}
@@ -0,0 +1,82 @@
+package ksql
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestEncodeDecimal(t *testing.T) {
+	tests := []struct {
+		desc          string
+		value         interface{}
+		expectedValue interface{}
+	}{
+		{
+			desc:          "string",
+			value:         "12345678901234567890.0123456789",
+			expectedValue: "12345678901234567890.0123456789",
+		},
+		{
+			desc:          "TextMarshaler with pointer receiver",
+			value:         *big.NewInt(42),
+			expectedValue: "42",
+		},
+		{
+			desc:          "pointer to TextMarshaler",
+			value:         big.NewInt(-7),
+			expectedValue: "-7",
+		},
+		{
+			desc:          "nil",
+			value:         nil,
+			expectedValue: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			value, err := encodeDecimal(test.value)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, value, test.expectedValue)
+		})
+	}
+}
+
+func TestDecimalScanner(t *testing.T) {
+	t.Run("should load strings from all the types returned by the drivers", func(t *testing.T) {
+		tests := []struct {
+			value         interface{}
+			expectedPrice string
+		}{
+			{value: "1.10", expectedPrice: "1.10"},
+			{value: []byte("1.10"), expectedPrice: "1.10"},
+			{value: float64(1.1), expectedPrice: "1.1"},
+			{value: int64(7), expectedPrice: "7"},
+		}
+		for _, test := range tests {
+			var price string
+			err := decimalScanner{Attr: reflect.ValueOf(&price).Elem()}.Scan(test.value)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, price, test.expectedPrice)
+		}
+	})
+
+	t.Run("should use the TextUnmarshaler of the attribute", func(t *testing.T) {
+		var amount *big.Int
+		err := decimalScanner{Attr: reflect.ValueOf(&amount).Elem()}.Scan([]byte("12345678901234567890"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, amount.String(), "12345678901234567890")
+
+		err = decimalScanner{Attr: reflect.ValueOf(&amount).Elem()}.Scan(nil)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, amount, (*big.Int)(nil))
+	})
+
+	t.Run("should report invalid decimals", func(t *testing.T) {
+		var amount big.Int
+		err := decimalScanner{Attr: reflect.ValueOf(&amount).Elem()}.Scan("not a number")
+		tt.AssertNotEqual(t, err, nil)
+	})
+}
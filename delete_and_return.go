@@ -0,0 +1,215 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// DeleteAndReturn deletes the record identified by the input ID, record, map
+// or ksql.Key, just like Delete, and loads the deleted row into the record,
+// so callers can archive or publish it atomically with the deletion:
+//
+//	var deleted User
+//	err := db.DeleteAndReturn(ctx, usersTable, userID, &deleted)
+//
+// On postgres, firebird and on sqlite3 with the WithSQLiteReturning option
+// it sends a single `DELETE ... RETURNING` statement, on sqlserver it uses
+// the `OUTPUT DELETED` clause and on the other dialects, or on sqlserver
+// tables using WithTriggers, the row is read and then deleted inside a
+// transaction, locking it with `FOR UPDATE` on mysql and with the `UPDLOCK`
+// hint on sqlserver.
+//
+// If no row matches the ID it returns ErrRecordNotFound.
+func (c DB) DeleteAndReturn(
+	ctx context.Context,
+	table Table,
+	idOrRecord interface{},
+	record interface{},
+	opts ...QueryOption,
+) error {
+	if err := checkUpdateByID(c.dialect, "DeleteAndReturn"); err != nil {
+		return err
+	}
+
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).DeleteAndReturn(ctx, table, idOrRecord, record, opts...)
+		})
+	}
+
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't delete from ksql.Table: %s", err)
+	}
+
+	if table.softDeleteColumn != "" {
+		return fmt.Errorf("%w: DeleteAndReturn can't be used on tables with soft deletes", ErrNotSupported)
+	}
+
+	t := reflect.TypeOf(record)
+	if err := assertStructPtr(t); err != nil {
+		return fmt.Errorf("ksql: expected record to be a pointer to struct, but got: %T", record)
+	}
+
+	if reflect.ValueOf(record).IsNil() {
+		return fmt.Errorf("ksql: expected a valid pointer to struct as argument but received a nil pointer: %v", record)
+	}
+
+	info, err := structs.GetTagInfo(t.Elem())
+	if err != nil {
+		return err
+	}
+	if info.IsNestedStruct {
+		return fmt.Errorf("ksql: DeleteAndReturn doesn't support nested structs, but got: %T", record)
+	}
+
+	idMap, err := normalizeIDsAsMap(table.idColumns, idOrRecord)
+	if err != nil {
+		return err
+	}
+
+	o := newQueryOptions(opts)
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
+	op := OpInfo{Method: "DeleteAndReturn", TableName: table.name}
+
+	query, params, found := buildDeleteAndReturnQuery(c.dialect, table, t.Elem(), info, idMap)
+	if !found {
+		return c.Transaction(ctx, func(db Provider) error {
+			query, params := buildSelectForDeleteQuery(db.(DB).dialect, table, t.Elem(), info, idMap)
+			err := db.(DB).queryDeletedRecord(ctx, op, o, record, query, params)
+			if err != nil && err != errDryRun {
+				return err
+			}
+
+			return db.(DB).delete(ctx, table, idMap, opts)
+		})
+	}
+
+	query, err = addStatementHints(c.dialect, table, query, o)
+	if err != nil {
+		return err
+	}
+
+	err = c.queryDeletedRecord(ctx, op, o, record, query, params)
+	if err == errDryRun {
+		return nil
+	}
+	return err
+}
+
+// queryDeletedRecord runs the query and scans its single row into the record
+func (c DB) queryDeletedRecord(
+	ctx context.Context,
+	op OpInfo,
+	o queryOptions,
+	record interface{},
+	query string,
+	params []interface{},
+) error {
+	rows, err := c.queryContext(ctx, op, o, query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if rows.Err() != nil {
+			return rows.Err()
+		}
+		return ErrRecordNotFound
+	}
+
+	err = scanRows(c.dialect, rows, record, scanOptions{
+		converters: c.converters,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+// buildDeleteAndReturnQuery builds the DELETE statement returning the deleted
+// row, found is false for the dialects that can't return it from a DELETE.
+func buildDeleteAndReturnQuery(
+	dialect Dialect,
+	table Table,
+	structType reflect.Type,
+	info structs.StructInfo,
+	idMap map[string]interface{},
+) (query string, params []interface{}, found bool) {
+	whereQuery, params := buildWhereByIDs(dialect, table.idColumns, idMap)
+
+	switch dialect.InsertMethod() {
+	case insertWithReturning:
+		return fmt.Sprintf(
+			"DELETE FROM %s WHERE %s RETURNING %s",
+			table.escapedName(dialect),
+			whereQuery,
+			strings.Join(escapedRecordColumns(dialect, structType, info, ""), ", "),
+		), params, true
+	case insertWithOutput:
+		if table.hasTriggers {
+			// The OUTPUT clause can't be used on tables with triggers:
+			return "", nil, false
+		}
+		return fmt.Sprintf(
+			"DELETE FROM %s OUTPUT %s WHERE %s",
+			table.escapedName(dialect),
+			strings.Join(escapedRecordColumns(dialect, structType, info, "DELETED."), ", "),
+			whereQuery,
+		), params, true
+	default:
+		return "", nil, false
+	}
+}
+
+// buildSelectForDeleteQuery builds the query that reads the row before it is
+// deleted by the dialects that can't return it from the DELETE statement.
+func buildSelectForDeleteQuery(
+	dialect Dialect,
+	table Table,
+	structType reflect.Type,
+	info structs.StructInfo,
+	idMap map[string]interface{},
+) (query string, params []interface{}) {
+	whereQuery, params := buildWhereByIDs(dialect, table.idColumns, idMap)
+
+	tableName := table.escapedName(dialect)
+	if capabilitiesOf(dialect).TableHints {
+		tableName += " WITH (UPDLOCK, ROWLOCK)"
+	}
+
+	query = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s",
+		strings.Join(escapedRecordColumns(dialect, structType, info, ""), ", "),
+		tableName,
+		whereQuery,
+	) + rowLockClause(dialect)
+
+	return query, params
+}
+
+// escapedRecordColumns returns the escaped columns of
+// the attributes of the struct with the input prefix.
+func escapedRecordColumns(dialect Dialect, structType reflect.Type, info structs.StructInfo, prefix string) []string {
+	var columns []string
+	for i := 0; i < structType.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid {
+			continue
+		}
+
+		columns = append(columns, prefix+dialect.Escape(fieldInfo.Name))
+	}
+
+	return columns
+}
@@ -0,0 +1,163 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type deletedUser struct {
+	ID   uint   `ksql:"id"`
+	Name string `ksql:"name"`
+	Age  int    `ksql:"age"`
+}
+
+func TestDeleteAndReturn(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the deleted row with a single statement", func(t *testing.T) {
+		tests := []struct {
+			dialect       string
+			opts          []Option
+			expectedQuery string
+		}{
+			{
+				dialect:       "postgres",
+				expectedQuery: `DELETE FROM "users" WHERE "id" = $1 RETURNING "id", "name", "age"`,
+			},
+			{
+				dialect:       "sqlite3",
+				opts:          []Option{WithSQLiteReturning()},
+				expectedQuery: "DELETE FROM `users` WHERE `id` = ? RETURNING `id`, `name`, `age`",
+			},
+			{
+				dialect:       "sqlserver",
+				expectedQuery: "DELETE FROM [users] OUTPUT DELETED.[id], DELETED.[name], DELETED.[age] WHERE [id] = @p1",
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.dialect, func(t *testing.T) {
+				var queries []string
+				var params []interface{}
+				db, err := NewWithAdapter(mockDBAdapter{
+					QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+						queries = append(queries, q)
+						params = args
+						return newMockRows([]string{"id", "name", "age"}, []interface{}{42, "Bia", 22}), nil
+					},
+				}, test.dialect, test.opts...)
+				tt.AssertNoErr(t, err)
+
+				var u deletedUser
+				err = db.DeleteAndReturn(ctx, usersTable, 42, &u)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, u, deletedUser{ID: 42, Name: "Bia", Age: 22})
+				tt.AssertEqual(t, queries, []string{test.expectedQuery})
+				tt.AssertEqual(t, params, []interface{}{42})
+			})
+		}
+	})
+
+	t.Run("should read and then delete the row inside a transaction on mysql", func(t *testing.T) {
+		var calls []string
+		tx := mockTx{
+			mockDBAdapter: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+					calls = append(calls, q)
+					return newMockRows([]string{"id", "name", "age"}, []interface{}{42, "Bia", 22}), nil
+				},
+				ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+					calls = append(calls, q)
+					return NewMockResult(0, 1), nil
+				},
+			},
+			CommitFn: func(ctx context.Context) error {
+				calls = append(calls, "commit")
+				return nil
+			},
+		}
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				calls = append(calls, "begin")
+				return tx, nil
+			},
+		}, "mysql")
+		tt.AssertNoErr(t, err)
+
+		var u deletedUser
+		err = db.DeleteAndReturn(ctx, usersTable, &deletedUser{ID: 42}, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u, deletedUser{ID: 42, Name: "Bia", Age: 22})
+		tt.AssertEqual(t, calls, []string{
+			"begin",
+			"SELECT `id`, `name`, `age` FROM `users` WHERE `id` = ? FOR UPDATE",
+			"DELETE FROM `users` WHERE `id` = ?",
+			"commit",
+		})
+	})
+
+	t.Run("should read and then delete the row on sqlserver tables with triggers", func(t *testing.T) {
+		var calls []string
+		tx := mockTx{
+			mockDBAdapter: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+					calls = append(calls, q)
+					return newMockRows([]string{"id", "name", "age"}, []interface{}{42, "Bia", 22}), nil
+				},
+				ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+					calls = append(calls, q)
+					return NewMockResult(0, 1), nil
+				},
+			},
+			CommitFn: func(ctx context.Context) error {
+				calls = append(calls, "commit")
+				return nil
+			},
+		}
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				calls = append(calls, "begin")
+				return tx, nil
+			},
+		}, "sqlserver")
+		tt.AssertNoErr(t, err)
+
+		var u deletedUser
+		err = db.DeleteAndReturn(ctx, NewTable("users").WithTriggers(), 42, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u, deletedUser{ID: 42, Name: "Bia", Age: 22})
+		tt.AssertEqual(t, calls, []string{
+			"begin",
+			"SELECT [id], [name], [age] FROM [users] WITH (UPDLOCK, ROWLOCK) WHERE [id] = @p1",
+			"DELETE FROM [users] WHERE [id] = @p1",
+			"commit",
+		})
+	})
+
+	t.Run("should return ErrRecordNotFound if no row was deleted", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"id", "name", "age"}), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var u deletedUser
+		err = db.DeleteAndReturn(ctx, usersTable, 42, &u)
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+	})
+
+	t.Run("should report invalid records", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var u deletedUser
+		err = db.DeleteAndReturn(ctx, usersTable, 42, u)
+		tt.AssertErrContains(t, err, "expected record to be a pointer to struct")
+
+		err = db.DeleteAndReturn(ctx, usersTable, 0, &u)
+		tt.AssertErrContains(t, err, "invalid value", "id")
+	})
+}
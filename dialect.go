@@ -3,6 +3,7 @@ package ksql
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 type insertMethod int
@@ -10,6 +11,7 @@ type insertMethod int
 const (
 	insertWithReturning insertMethod = iota
 	insertWithOutput
+	insertWithScopeIdentity
 	insertWithLastInsertID
 	insertWithNoIDRetrieval
 )
@@ -18,7 +20,11 @@ var supportedDialects = map[string]Dialect{
 	"postgres":  &postgresDialect{},
 	"sqlite3":   &sqlite3Dialect{},
 	"mysql":     &mysqlDialect{},
+	"tidb":      &tidbDialect{},
 	"sqlserver": &sqlserverDialect{},
+	"firebird":  &firebirdDialect{},
+	"snowflake": &snowflakeDialect{},
+	"bigquery":  &bigqueryDialect{},
 }
 
 // Dialect is used to represent the different ways
@@ -48,13 +54,40 @@ func (postgresDialect) Placeholder(idx int) string {
 	return "$" + strconv.Itoa(idx+1)
 }
 
-type sqlite3Dialect struct{}
+func (postgresDialect) DialectCapabilities() DialectCapabilities {
+	return DialectCapabilities{
+		UpdateByID:     true,
+		Savepoints:     true,
+		Upsert:         UpsertOnConflict,
+		Intervals:      true,
+		Limit:          LimitClause,
+		Sequences:      SequenceNextval,
+		SessionVars:    SessionVarsSetConfig,
+		RowLocks:       RowLockForUpdate,
+		DDL:            DDLPostgres,
+		LockWaits:      LockWaitsPgStatActivity,
+		BlobChunks:     BlobChunkSubstringFromFor,
+		LargeObjects:   true,
+		TwoPhaseCommit: true,
+		ILike:          true,
+		JSONB:          true,
+	}
+}
+
+type sqlite3Dialect struct {
+	// returning is enabled by the WithSQLiteReturning option
+	// since only SQLite 3.35 onwards supports the RETURNING clause.
+	returning bool
+}
 
 func (sqlite3Dialect) DriverName() string {
 	return "sqlite3"
 }
 
-func (sqlite3Dialect) InsertMethod() insertMethod {
+func (d sqlite3Dialect) InsertMethod() insertMethod {
+	if d.returning {
+		return insertWithReturning
+	}
 	return insertWithLastInsertID
 }
 
@@ -66,6 +99,170 @@ func (sqlite3Dialect) Placeholder(idx int) string {
 	return "?"
 }
 
+func (sqlite3Dialect) DialectCapabilities() DialectCapabilities {
+	return DialectCapabilities{
+		UpdateByID:      true,
+		Savepoints:      true,
+		Upsert:          UpsertOnConflict,
+		Limit:           LimitClause,
+		DDL:             DDLSQLite,
+		BlobChunks:      BlobChunkSubstr,
+		NoCaseCollation: true,
+	}
+}
+
+// DialectCapabilities describes the features and the SQL syntax that
+// vary between the dialects, so the internals can check them instead of
+// comparing the driver names.
+//
+// Each built-in dialect reports its own by implementing the
+// DialectCapabilitiesReporter interface, and custom dialects can do
+// the same, see the WithDialect option. The zero value of each
+// attribute means the feature is not supported.
+type DialectCapabilities struct {
+	// UpdateByID is false on dialects where the ID columns don't identify
+	// the rows, so the operations matching records by their IDs, i.e.
+	// Patch, Delete, UpdateMany and Upsert, are not supported.
+	UpdateByID bool
+
+	// Savepoints is false on dialects without the SAVEPOINT statement
+	Savepoints bool
+
+	// SaveTransaction is true if the savepoints are created with
+	// the `SAVE TRANSACTION` statement instead of `SAVEPOINT`.
+	SaveTransaction bool
+
+	// Upsert describes how the Upsert method is implemented
+	Upsert UpsertFlavor
+
+	// MultiRowInsertMany is true if InsertMany should use multi-row inserts,
+	// which is only done for the dialects where the IDs are not retrieved
+	// after the inserts and where each statement has a high latency, or
+	// where the IDs can be computed from the first ID of each statement.
+	MultiRowInsertMany bool
+
+	// JSONAsText is true if the drivers expect the attributes tagged
+	// with `json` to be sent as strings instead of byte slices.
+	JSONAsText bool
+
+	// Intervals is true if the attributes tagged with `duration` are
+	// stored as INTERVAL columns instead of integer counts of the unit.
+	Intervals bool
+
+	// TypedParams is true if the attributes tagged with `sqltype` are
+	// sent as TypedParams so the adapter can declare their SQL types.
+	TypedParams bool
+
+	// TextAsBytes is true if the drivers return the text columns as
+	// byte slices, which are converted to strings when reading maps.
+	TextAsBytes bool
+
+	// Limit describes the clauses used by the Limit option and QueryChunks,
+	// if it is empty the `LIMIT` clause is used.
+	Limit LimitFlavor
+
+	// Sequences describes the query that reads the
+	// next value of the sequence of the tables.
+	Sequences SequenceFlavor
+
+	// DualTable is the table used by the SELECT statements that read
+	// no tables, it is empty if the FROM clause is optional.
+	DualTable string
+
+	// IdentityInsert describes the statements required by the IdentityInsert
+	// option, if it is empty explicit IDs are accepted without them.
+	IdentityInsert IdentityInsertFlavor
+
+	// SessionVars describes how the session variables are set
+	SessionVars SessionVarsFlavor
+
+	// RowLocks describes the clause used by the ForUpdate option
+	RowLocks RowLockFlavor
+
+	// SnapshotReads is true if the reads inside transactions don't see the
+	// rows committed after their first read, so the rows that must be
+	// up to date are read with the RowLocks clause.
+	SnapshotReads bool
+
+	// TableHints is true if the dialect accepts table
+	// hints like `WITH (UPDLOCK, ROWLOCK)`.
+	TableHints bool
+
+	// DDL describes the syntax used for creating tables and triggers
+	DDL DDLFlavor
+
+	// SystemVersioning is true if the system-versioned tables
+	// can be read with the `FOR SYSTEM_TIME AS OF` clause.
+	SystemVersioning bool
+
+	// LockWaits describes the system views used by WhoIsBlocking
+	LockWaits LockWaitsFlavor
+
+	// BlobChunks describes the function used by QueryBlob
+	BlobChunks BlobChunkFlavor
+
+	// LargeObjects is true if the dialect supports large objects
+	LargeObjects bool
+
+	// TwoPhaseCommit is true if the dialect supports
+	// the `PREPARE TRANSACTION` statement.
+	TwoPhaseCommit bool
+
+	// ILike is true if the dialect has the
+	// case insensitive `ILIKE` operator.
+	ILike bool
+
+	// NoCaseCollation is true if the dialect has the `NOCASE` collation
+	// and its `LIKE` operator is already case insensitive.
+	NoCaseCollation bool
+
+	// JSONB is true if the dialect has the jsonb operators
+	// used by the kbuilder, e.g. `@>`.
+	JSONB bool
+}
+
+// DialectCapabilitiesReporter is implemented by the
+// dialects that report their DialectCapabilities.
+type DialectCapabilitiesReporter interface {
+	DialectCapabilities() DialectCapabilities
+}
+
+// capabilitiesOf returns the capabilities reported by the dialect,
+// the dialects not reporting them are assumed to support the
+// operations by ID and savepoints with an emulated Upsert.
+func capabilitiesOf(dialect Dialect) DialectCapabilities {
+	if reporter, ok := dialect.(DialectCapabilitiesReporter); ok {
+		return reporter.DialectCapabilities()
+	}
+
+	return DialectCapabilities{
+		UpdateByID: true,
+		Savepoints: true,
+		Upsert:     UpsertEmulated,
+		Limit:      LimitClause,
+	}
+}
+
+// checkUpdateByID returns ErrNotSupported for the operations that
+// match records by their IDs on the dialects that can't do it.
+func checkUpdateByID(dialect Dialect, method string) error {
+	if capabilitiesOf(dialect).UpdateByID {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s is not supported by the %s dialect since it has no primary keys", ErrNotSupported, method, dialect.DriverName())
+}
+
+// selectWithoutTable builds a SELECT statement that reads no tables,
+// adding the DualTable of the dialect if it requires a FROM clause.
+func selectWithoutTable(dialect Dialect, expressions string) string {
+	query := "SELECT " + expressions
+	if dualTable := capabilitiesOf(dialect).DualTable; dualTable != "" {
+		query += " FROM " + dualTable
+	}
+	return query
+}
+
 // GetDriverDialect instantiantes the dialect for the
 // provided driver string, if the drive is not supported
 // it returns an error
@@ -78,7 +275,10 @@ func GetDriverDialect(driver string) (Dialect, error) {
 	return dialect, nil
 }
 
-type mysqlDialect struct{}
+type mysqlDialect struct {
+	// consecutiveIDs is set by the WithMySQLConsecutiveIDs option
+	consecutiveIDs bool
+}
 
 func (mysqlDialect) DriverName() string {
 	return "mysql"
@@ -96,6 +296,64 @@ func (mysqlDialect) Placeholder(idx int) string {
 	return "?"
 }
 
+func (d mysqlDialect) DialectCapabilities() DialectCapabilities {
+	return DialectCapabilities{
+		UpdateByID:         true,
+		Savepoints:         true,
+		Upsert:             UpsertOnDuplicateKey,
+		MultiRowInsertMany: d.consecutiveIDs,
+		TextAsBytes:        true,
+		Limit:              LimitClause,
+		SessionVars:        SessionVarsUserVariables,
+		RowLocks:           RowLockForUpdate,
+		SnapshotReads:      true,
+		DDL:                DDLMySQL,
+		SystemVersioning:   true,
+		LockWaits:          LockWaitsPerformanceSchema,
+		BlobChunks:         BlobChunkSubstring,
+	}
+}
+
+// tidbDialect is the mysql dialect with the TiDB extensions,
+// it keeps the "mysql" driver name so all the MySQL specific
+// queries are also used for TiDB.
+//
+// The IDs of AUTO_RANDOM columns are retrieved with LAST_INSERT_ID()
+// just like AUTO_INCREMENT ones, and inserting explicit values on them
+// is allowed by the IdentityInsert option.
+type tidbDialect struct {
+	mysqlDialect
+
+	// batchHints are set by the WithTiDBBatchHints option
+	batchHints string
+}
+
+func (d tidbDialect) DialectCapabilities() DialectCapabilities {
+	caps := d.mysqlDialect.DialectCapabilities()
+	caps.IdentityInsert = IdentityInsertAutoRandom
+	caps.SystemVersioning = false
+	caps.LockWaits = ""
+	return caps
+}
+
+// addBatchHints adds the TiDB optimizer hints to the statements of
+// the bulk helpers, right after their first keyword as TiDB requires,
+// e.g. `INSERT /*+ hints */ INTO ...`. For the other dialects it
+// returns the query unchanged.
+func addBatchHints(dialect Dialect, query string) string {
+	d, ok := dialect.(*tidbDialect)
+	if !ok || d.batchHints == "" {
+		return query
+	}
+
+	i := strings.IndexByte(query, ' ')
+	if i == -1 {
+		return query
+	}
+
+	return query[:i] + " /*+ " + d.batchHints + " */" + query[i:]
+}
+
 type sqlserverDialect struct{}
 
 func (sqlserverDialect) DriverName() string {
@@ -113,3 +371,131 @@ func (sqlserverDialect) Escape(str string) string {
 func (sqlserverDialect) Placeholder(idx int) string {
 	return "@p" + strconv.Itoa(idx+1)
 }
+
+func (sqlserverDialect) DialectCapabilities() DialectCapabilities {
+	return DialectCapabilities{
+		UpdateByID:       true,
+		Savepoints:       true,
+		SaveTransaction:  true,
+		Upsert:           UpsertEmulated,
+		JSONAsText:       true,
+		TypedParams:      true,
+		Limit:            LimitTop,
+		Sequences:        SequenceNextValueFor,
+		IdentityInsert:   IdentityInsertSetTable,
+		SessionVars:      SessionVarsSessionContext,
+		TableHints:       true,
+		DDL:              DDLSQLServer,
+		SystemVersioning: true,
+		LockWaits:        LockWaitsDMExecRequests,
+		BlobChunks:       BlobChunkSubstring,
+	}
+}
+
+// firebirdDialect targets Firebird 3.0 onwards, which is the first
+// version supporting the `OFFSET ... FETCH` clauses used by QueryChunks.
+//
+// Note that the identifiers are quoted, so the names of the tables and
+// columns are case sensitive, i.e. columns created without quotes, which
+// Firebird stores in uppercase, must be tagged with uppercase names.
+type firebirdDialect struct{}
+
+func (firebirdDialect) DriverName() string {
+	return "firebird"
+}
+
+func (firebirdDialect) InsertMethod() insertMethod {
+	return insertWithReturning
+}
+
+func (firebirdDialect) Escape(str string) string {
+	return `"` + str + `"`
+}
+
+func (firebirdDialect) Placeholder(idx int) string {
+	return "?"
+}
+
+func (firebirdDialect) DialectCapabilities() DialectCapabilities {
+	return DialectCapabilities{
+		UpdateByID:  true,
+		Savepoints:  true,
+		Upsert:      UpsertEmulated,
+		JSONAsText:  true,
+		Limit:       LimitFetch,
+		Sequences:   SequenceNextValueFor,
+		DualTable:   "RDB$DATABASE",
+		SessionVars: SessionVarsRDBSetContext,
+		RowLocks:    RowLockForUpdateWithLock,
+	}
+}
+
+// snowflakeDialect can't retrieve the IDs of inserted records since
+// snowflake has no RETURNING clause nor LAST_INSERT_ID(), so the IDs
+// are only written to the records if the ksql.Table uses a sequence.
+//
+// Just like on firebird the identifiers are quoted, so the names of
+// columns created without quotes, which snowflake stores in uppercase,
+// must be tagged in uppercase.
+type snowflakeDialect struct{}
+
+func (snowflakeDialect) DriverName() string {
+	return "snowflake"
+}
+
+func (snowflakeDialect) InsertMethod() insertMethod {
+	return insertWithNoIDRetrieval
+}
+
+func (snowflakeDialect) Escape(str string) string {
+	return `"` + str + `"`
+}
+
+func (snowflakeDialect) Placeholder(idx int) string {
+	return "?"
+}
+
+func (snowflakeDialect) DialectCapabilities() DialectCapabilities {
+	return DialectCapabilities{
+		UpdateByID:         true,
+		Savepoints:         false,
+		Upsert:             UpsertEmulated,
+		MultiRowInsertMany: true,
+		JSONAsText:         true,
+		Limit:              LimitClause,
+		Sequences:          SequenceDotNextval,
+		ILike:              true,
+	}
+}
+
+// bigqueryDialect is meant for analytics sinks: the records are inserted
+// without retrieving their IDs, and since bigquery doesn't enforce primary
+// keys the operations matching records by their IDs are not supported.
+type bigqueryDialect struct{}
+
+func (bigqueryDialect) DriverName() string {
+	return "bigquery"
+}
+
+func (bigqueryDialect) InsertMethod() insertMethod {
+	return insertWithNoIDRetrieval
+}
+
+func (bigqueryDialect) Escape(str string) string {
+	return "`" + str + "`"
+}
+
+func (bigqueryDialect) Placeholder(idx int) string {
+	return "?"
+}
+
+func (bigqueryDialect) DialectCapabilities() DialectCapabilities {
+	return DialectCapabilities{
+		UpdateByID:         false,
+		Savepoints:         false,
+		Upsert:             UpsertNotSupported,
+		MultiRowInsertMany: true,
+		JSONAsText:         true,
+		Limit:              LimitClause,
+	}
+}
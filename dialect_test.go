@@ -1,6 +1,10 @@
 package ksql
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	tt "github.com/vingarcia/ksql/internal/testtools"
@@ -22,3 +26,330 @@ func TestGetDriverDialect(t *testing.T) {
 		tt.AssertErrContains(t, err, "unsupported driver", "non-existing-driver")
 	})
 }
+
+func TestTiDBDialect(t *testing.T) {
+	ctx := context.Background()
+
+	type userRecord struct {
+		ID   uint64 `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newAdapter := func(queries *[]string, lastInsertID int64) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				*queries = append(*queries, query)
+				return NewMockResult(lastInsertID, 1), nil
+			},
+		}
+	}
+
+	t.Run("should back-fill unsigned AUTO_RANDOM IDs using the sign bit", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "tidb", newAdapter(&queries, -42))
+
+		u := userRecord{Name: "fake-name"}
+		err := db.Insert(ctx, usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, uint64(1<<64-42))
+		tt.AssertEqual(t, queries, []string{"INSERT INTO `users` (`name`) VALUES (?)"})
+	})
+
+	t.Run("should report an error if the ID overflows the attribute", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "tidb", newAdapter(&queries, 1<<40))
+
+		u := struct {
+			ID   int32  `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err := db.Insert(ctx, usersTable, &u)
+		tt.AssertErrContains(t, err, "1099511627776", "`id`", "int32", "overflows")
+	})
+
+	t.Run("should allow explicit AUTO_RANDOM IDs with IdentityInsert", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "tidb", newAdapter(&queries, 0))
+
+		err := db.Insert(WithQueryOptions(ctx, IdentityInsert()), usersTable, &userRecord{ID: 42, Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{
+			"SET @@allow_auto_random_explicit_insert = true",
+			"INSERT INTO `users` (`id`, `name`) VALUES (?, ?)",
+			"SET @@allow_auto_random_explicit_insert = false",
+		})
+	})
+
+	t.Run("should add the batch hints to the bulk helpers only", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "tidb", newAdapter(&queries, 1), WithTiDBBatchHints("SET_VAR(tidb_mem_quota_query=8589934592)", "MEMORY_QUOTA(8 GB)"))
+
+		hints := "/*+ SET_VAR(tidb_mem_quota_query=8589934592) MEMORY_QUOTA(8 GB) */"
+
+		err := db.Insert(ctx, usersTable, &userRecord{Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries[0], "INSERT INTO `users` (`name`) VALUES (?)")
+
+		queries = nil
+		err = db.InsertMany(ctx, usersTable, []*userRecord{{Name: "fake-name-1"}, {Name: "fake-name-2"}})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{
+			"INSERT " + hints + " INTO `users` (`name`) VALUES (?)",
+			"INSERT " + hints + " INTO `users` (`name`) VALUES (?)",
+		})
+
+		queries = nil
+		err = db.UpdateMany(ctx, usersTable, []*userRecord{{ID: 1, Name: "fake-name"}})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(queries), 1)
+		tt.AssertEqual(t, strings.HasPrefix(queries[0], "UPDATE "+hints+" `users` SET"), true)
+
+		queries = nil
+		err = db.InsertCSV(ctx, usersTable, strings.NewReader("name\nfake-name\n"), CSVOptions{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{"INSERT " + hints + " INTO `users` (`name`) VALUES (?)"})
+	})
+
+	t.Run("should ignore the batch hints on other dialects", func(t *testing.T) {
+		db := newDB(t, "mysql", mockDBAdapter{}, WithTiDBBatchHints("MEMORY_QUOTA(8 GB)"))
+		tt.AssertEqual(t, addBatchHints(db.dialect, "INSERT INTO `users` (`name`) VALUES (?)"), "INSERT INTO `users` (`name`) VALUES (?)")
+	})
+}
+
+func TestFirebirdDialect(t *testing.T) {
+	ctx := context.Background()
+
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	t.Run("should insert using RETURNING", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		db := newDB(t, "firebird", mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query, params = q, args
+				return newMockRows([]string{"id"}, []interface{}{42}), nil
+			},
+		})
+
+		u := userRecord{Name: "fake-name"}
+		err := db.Insert(ctx, usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `INSERT INTO "users" ("name") VALUES (?) RETURNING "id"`)
+		tt.AssertEqual(t, params, []interface{}{"fake-name"})
+		tt.AssertEqual(t, u.ID, 42)
+	})
+
+	t.Run("should build the firebird specific queries", func(t *testing.T) {
+		dialect := supportedDialects["firebird"]
+
+		query, _, err := buildNextSequenceValueQuery(dialect, "users_seq")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "SELECT NEXT VALUE FOR users_seq FROM RDB$DATABASE")
+
+		query, err = buildForUpdateQuery(dialect, `SELECT "id" FROM "users";`)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `SELECT "id" FROM "users" FOR UPDATE WITH LOCK`)
+
+		query, params := buildKeysetChunkQuery(dialect, `SELECT "id" FROM "users"`, nil, []string{"id"}, []interface{}{10}, 100)
+		tt.AssertEqual(t, query, `SELECT "id" FROM "users" WHERE ("id" > ?) ORDER BY "id" OFFSET 0 ROWS FETCH NEXT 100 ROWS ONLY`)
+		tt.AssertEqual(t, params, []interface{}{10})
+
+		count, ok := countPlaceholders(dialect, `SELECT '?', "?" FROM "users" WHERE "id" = ? -- ?`)
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, count, 1)
+	})
+}
+
+func TestSnowflakeDialect(t *testing.T) {
+	ctx := context.Background()
+
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+		Age  *int   `ksql:"age"`
+	}
+
+	newAdapter := func(queries *[]string, params *[][]interface{}) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				*queries = append(*queries, query)
+				*params = append(*params, args)
+				return NewMockResult(0, 1), nil
+			},
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				*queries = append(*queries, query)
+				*params = append(*params, args)
+				return newMockRows([]string{"NEXTVAL"}, []interface{}{len(*queries)}), nil
+			},
+		}
+	}
+
+	t.Run("should insert many records with multi-row inserts grouped by columns", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		db := newDB(t, "snowflake", newAdapter(&queries, &params))
+
+		age := 30
+		err := db.InsertMany(ctx, usersTable, []*userRecord{
+			{Name: "fake-name-1"},
+			{ID: 42, Name: "fake-name-2", Age: &age},
+			{Name: "fake-name-3"},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{
+			`INSERT INTO "users" ("name") VALUES (?), (?)`,
+			`INSERT INTO "users" ("age", "id", "name") VALUES (?, ?, ?)`,
+		})
+		tt.AssertEqual(t, params[0], []interface{}{"fake-name-1", "fake-name-3"})
+		tt.AssertEqual(t, params[1], []interface{}{30, 42, "fake-name-2"})
+	})
+
+	t.Run("should report the failed records on a BatchError", func(t *testing.T) {
+		db := newDB(t, "snowflake", mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				return nil, fmt.Errorf("fake-insert-error")
+			},
+		})
+
+		err := db.InsertMany(ctx, usersTable, []*userRecord{{Name: "fake-name-1"}, {Name: "fake-name-2"}})
+		var batchErr BatchError
+		tt.AssertEqual(t, errors.As(err, &batchErr), true)
+		tt.AssertEqual(t, len(batchErr.Failures), 2)
+		tt.AssertEqual(t, batchErr.Failures[0].Index, 0)
+		tt.AssertEqual(t, batchErr.Failures[1].Index, 1)
+		tt.AssertErrContains(t, err, "fake-insert-error")
+	})
+
+	t.Run("should read the IDs from the sequence of the table", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		db := newDB(t, "snowflake", newAdapter(&queries, &params))
+
+		users := []*userRecord{{Name: "fake-name-1"}, {Name: "fake-name-2"}}
+		err := db.InsertMany(ctx, usersTable.WithSequence("users_seq"), users)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{
+			"SELECT users_seq.NEXTVAL",
+			"SELECT users_seq.NEXTVAL",
+			`INSERT INTO "users" ("id", "name") VALUES (?, ?), (?, ?)`,
+		})
+		tt.AssertEqual(t, users[0].ID, 1)
+		tt.AssertEqual(t, users[1].ID, 2)
+	})
+
+	t.Run("should not count the placeholders of queries using numbered binds", func(t *testing.T) {
+		dialect := supportedDialects["snowflake"]
+
+		count, ok := countPlaceholders(dialect, `SELECT 'it\'s?', "?" FROM "users" WHERE "id" = ?`)
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, count, 1)
+
+		_, ok = countPlaceholders(dialect, `SELECT "id" FROM "users" WHERE "id" = :1 OR "parent_id" = :1`)
+		tt.AssertEqual(t, ok, false)
+	})
+}
+
+type mockRowAppender struct {
+	mockDBAdapter
+	AppendRowsFn func(ctx context.Context, tableName string, columns []string, rows [][]interface{}) error
+}
+
+func (m mockRowAppender) AppendRows(ctx context.Context, tableName string, columns []string, rows [][]interface{}) error {
+	return m.AppendRowsFn(ctx, tableName, columns, rows)
+}
+
+func TestBigQueryDialect(t *testing.T) {
+	ctx := context.Background()
+
+	type userRecord struct {
+		ID      int               `ksql:"id"`
+		Name    string            `ksql:"name"`
+		Address map[string]string `ksql:"address,json"`
+	}
+
+	type appendCall struct {
+		tableName string
+		columns   []string
+		rows      [][]interface{}
+	}
+
+	newAppender := func(calls *[]appendCall) mockRowAppender {
+		return mockRowAppender{
+			AppendRowsFn: func(ctx context.Context, tableName string, columns []string, rows [][]interface{}) error {
+				*calls = append(*calls, appendCall{tableName: tableName, columns: columns, rows: rows})
+				return nil
+			},
+		}
+	}
+
+	t.Run("should append the inserted records using the RowAppender", func(t *testing.T) {
+		var calls []appendCall
+		db := newDB(t, "bigquery", newAppender(&calls))
+
+		err := db.Insert(ctx, NewTable("users").WithSchema("analytics"), &userRecord{Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+
+		err = db.InsertMany(ctx, usersTable, []*userRecord{
+			{Name: "fake-name-1", Address: map[string]string{"city": "fake-city"}},
+			{ID: 42, Name: "fake-name-2"},
+			{Name: "fake-name-3"},
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, len(calls), 3)
+		tt.AssertEqual(t, calls[0].tableName, "analytics.users")
+		tt.AssertEqual(t, calls[0].columns, []string{"address", "name"})
+		tt.AssertEqual(t, calls[1].tableName, "users")
+		tt.AssertEqual(t, calls[1].columns, []string{"address", "name"})
+		tt.AssertEqual(t, len(calls[1].rows), 2)
+		tt.AssertEqual(t, calls[1].rows[0][1], "fake-name-1")
+		tt.AssertEqual(t, calls[1].rows[1][1], "fake-name-3")
+		tt.AssertEqual(t, calls[2].columns, []string{"address", "id", "name"})
+
+		city, err := calls[1].rows[0][0].(jsonSerializable).Value()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, city, `{"city":"fake-city"}`)
+	})
+
+	t.Run("should forward the rows through adapters created with WrapAdapter", func(t *testing.T) {
+		var calls []appendCall
+		db := newDB(t, "bigquery", WrapAdapter(newAppender(&calls), AdapterHooks{}))
+
+		err := db.Insert(ctx, usersTable, &userRecord{Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(calls), 1)
+	})
+
+	t.Run("should report the INSERT statement with DryRun", func(t *testing.T) {
+		var calls []appendCall
+		db := newDB(t, "bigquery", newAppender(&calls))
+
+		var query string
+		ctx := WithQueryOptions(ctx, DryRun(func(q string, params []interface{}) {
+			query = q
+		}))
+		err := db.Insert(ctx, usersTable, &userRecord{Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, strings.HasPrefix(query, "INSERT INTO `users` ("), true)
+		tt.AssertEqual(t, len(calls), 0)
+	})
+
+	t.Run("should not support the operations matching records by ID", func(t *testing.T) {
+		var calls []appendCall
+		db := newDB(t, "bigquery", newAppender(&calls))
+
+		u := &userRecord{ID: 42, Name: "fake-name"}
+		for method, err := range map[string]error{
+			"Patch":      db.Patch(ctx, usersTable, u),
+			"Delete":     db.Delete(ctx, usersTable, u),
+			"UpdateMany": db.UpdateMany(ctx, usersTable, []*userRecord{u}),
+			"Upsert":     db.Upsert(ctx, usersTable, u),
+		} {
+			tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+			tt.AssertErrContains(t, err, method, "bigquery")
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package ksql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hints adds optimizer hints to the statements generated by the Insert,
+// Patch, Delete, Upsert, InsertMany and UpdateMany methods as a `/*+ ... */`
+// comment right after their first keyword, e.g.:
+//
+//	err := db.Insert(ctx, UsersTable, &user, ksql.Hints("APPEND"))
+//
+// Generates:
+//
+//	INSERT /*+ APPEND */ INTO users (...) VALUES (...)
+//
+// Each hint may only contain letters, digits, spaces and the characters
+// `_.,=()@$#+-`, so the hints can't close the comment or add other
+// statements, and ksql doesn't validate them any further, i.e. the
+// database decides whether to use or ignore each of them.
+func Hints(hints ...string) QueryOption {
+	return func(opts *queryOptions) {
+		opts.hints = append(opts.hints, hints...)
+	}
+}
+
+// TableHints adds table hints right after the name of the table on the
+// statements generated by the Insert, Patch, Delete, Upsert, InsertMany
+// and UpdateMany methods, e.g.:
+//
+//	err := db.Patch(ctx, UsersTable, &user, ksql.TableHints("ROWLOCK"))
+//
+// Generates:
+//
+//	UPDATE [users] WITH (ROWLOCK) SET ...
+//
+// It is only supported by the sqlserver dialect, and the
+// hints are validated the same way as the ones of Hints.
+func TableHints(hints ...string) QueryOption {
+	return func(opts *queryOptions) {
+		opts.tableHints = append(opts.tableHints, hints...)
+	}
+}
+
+// addStatementHints adds the hints of the Hints and TableHints
+// options to the statements generated for the input table.
+func addStatementHints(dialect Dialect, table Table, query string, o queryOptions) (string, error) {
+	for _, hint := range append(append([]string{}, o.hints...), o.tableHints...) {
+		if err := validateHint(hint); err != nil {
+			return "", err
+		}
+	}
+
+	if len(o.tableHints) > 0 {
+		if !capabilitiesOf(dialect).TableHints {
+			return "", fmt.Errorf("%w: TableHints is not supported by the %s dialect", ErrNotSupported, dialect.DriverName())
+		}
+
+		escapedName := table.escapedName(dialect)
+		i := strings.Index(query, escapedName)
+		if i == -1 {
+			return "", fmt.Errorf("code error: table name `%s` not found on generated query: %s", escapedName, query)
+		}
+		i += len(escapedName)
+
+		query = query[:i] + " WITH (" + strings.Join(o.tableHints, ", ") + ")" + query[i:]
+	}
+
+	if len(o.hints) > 0 {
+		i := strings.IndexByte(query, ' ')
+		if i == -1 {
+			return "", fmt.Errorf("code error: unexpected generated query: %s", query)
+		}
+
+		// The hints are merged with the ones added by
+		// WithTiDBBatchHints since only one comment is allowed:
+		if strings.HasPrefix(query[i:], " /*+ ") {
+			i += len(" /*+")
+			query = query[:i] + " " + strings.Join(o.hints, " ") + query[i:]
+		} else {
+			query = query[:i] + " /*+ " + strings.Join(o.hints, " ") + " */" + query[i:]
+		}
+	}
+
+	return query, nil
+}
+
+func validateHint(hint string) error {
+	if strings.TrimSpace(hint) == "" {
+		return fmt.Errorf("ksql: hints can't be empty")
+	}
+
+	depth := 0
+	for _, c := range hint {
+		isAllowed := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune(" _.,=()@$#+-", c)
+		if !isAllowed {
+			return fmt.Errorf("ksql: invalid character %q on hint: '%s'", c, hint)
+		}
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth < 0 {
+			break
+		}
+	}
+
+	if depth != 0 || strings.Contains(hint, "--") {
+		return fmt.Errorf("ksql: invalid hint: '%s'", hint)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestHints(t *testing.T) {
+	ctx := context.Background()
+
+	captureQuery := func(query *string) QueryOption {
+		return DryRun(func(q string, params []interface{}) {
+			*query = q
+		})
+	}
+
+	t.Run("should add the hints after the first keyword of the generated statements", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var query string
+		err = db.Insert(WithQueryOptions(ctx, Hints("APPEND", "PARALLEL(4)"), captureQuery(&query)), usersTable, &user{Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, strings.HasPrefix(query, `INSERT /*+ APPEND PARALLEL(4) */ INTO "users"`), true)
+
+		err = db.Patch(WithQueryOptions(ctx, Hints("INDEX(users users_pkey)"), captureQuery(&query)), usersTable, &user{ID: 1, Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, strings.HasPrefix(query, `UPDATE /*+ INDEX(users users_pkey) */ "users" SET`), true)
+
+		err = db.Delete(WithQueryOptions(ctx, Hints("NO_INDEX(users)"), captureQuery(&query)), usersTable, 1)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, strings.HasPrefix(query, `DELETE /*+ NO_INDEX(users) */ FROM "users"`), true)
+	})
+
+	t.Run("should merge the hints with the TiDB batch hints", func(t *testing.T) {
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{}, nil
+			},
+		}, "tidb", WithTiDBBatchHints("MEMORY_QUOTA(1 GB)"))
+		tt.AssertNoErr(t, err)
+
+		var query string
+		err = db.UpdateMany(ctx, usersTable, []*user{{ID: 1, Name: "fake-name"}}, Hints("NO_INDEX_MERGE()"), captureQuery(&query))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, strings.HasPrefix(query, "UPDATE /*+ NO_INDEX_MERGE() MEMORY_QUOTA(1 GB) */ "), true)
+	})
+
+	t.Run("should add the table hints after the table name on sqlserver", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "sqlserver")
+		tt.AssertNoErr(t, err)
+
+		var query string
+		err = db.Patch(WithQueryOptions(ctx, TableHints("ROWLOCK", "UPDLOCK"), captureQuery(&query)), usersTable, &user{ID: 1, Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, strings.HasPrefix(query, "UPDATE [users] WITH (ROWLOCK, UPDLOCK) SET"), true)
+
+		err = db.Insert(WithQueryOptions(ctx, TableHints("TABLOCK"), captureQuery(&query)), usersTable, &user{Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, strings.HasPrefix(query, "INSERT INTO [users] WITH (TABLOCK) ("), true)
+	})
+
+	t.Run("should not support table hints on other dialects", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.Delete(WithQueryOptions(ctx, TableHints("ROWLOCK")), usersTable, 1)
+		tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+	})
+
+	t.Run("should reject hints that could change the statement", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "sqlserver")
+		tt.AssertNoErr(t, err)
+
+		for _, hint := range []string{
+			"",
+			"APPEND */ DROP TABLE users; /*",
+			"ROWLOCK) SET name = 'x' --",
+			"INDEX(users",
+			"A -- B",
+		} {
+			err := db.Delete(WithQueryOptions(ctx, Hints(hint)), usersTable, 1)
+			tt.AssertErrContains(t, err, "hint")
+
+			err = db.Delete(WithQueryOptions(ctx, TableHints(hint)), usersTable, 1)
+			tt.AssertErrContains(t, err, "hint")
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package ksql
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// encodeDuration converts the value of an attribute tagged with the
+// `duration` modifier to an interval on postgres, written as a number
+// of microseconds which is the precision of its INTERVAL type, and to
+// an integer count of the unit of the modifier on the other databases.
+//
+// Note that the conversion truncates the values that are not
+// a multiple of the unit, e.g. 1.5s is stored as 1 with `duration=s`.
+func encodeDuration(dialect Dialect, value interface{}, unit time.Duration) interface{} {
+	d, ok := value.(time.Duration)
+	if !ok {
+		return value
+	}
+
+	if capabilitiesOf(dialect).Intervals {
+		return strconv.FormatInt(int64(d/time.Microsecond), 10) + " microseconds"
+	}
+	return int64(d / unit)
+}
+
+// durationScanner implements the sql.Scanner interface in order to
+// load durations into the attributes tagged with `duration`.
+type durationScanner struct {
+	Attr    reflect.Value
+	Dialect Dialect
+	Unit    time.Duration
+}
+
+// Scan Implements the Scanner interface
+func (d durationScanner) Scan(value interface{}) error {
+	var duration time.Duration
+	switch v := value.(type) {
+	case nil:
+		d.Attr.Set(reflect.Zero(d.Attr.Type()))
+		return nil
+	case int64:
+		duration = time.Duration(v) * d.Unit
+	case float64:
+		duration = time.Duration(v * float64(d.Unit))
+	case []byte:
+		var err error
+		duration, err = d.parseText(string(v))
+		if err != nil {
+			return err
+		}
+	case string:
+		var err error
+		duration, err = d.parseText(v)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unexpected type received to Scan a duration: %T", value)
+	}
+
+	attr := d.Attr
+	if attr.Kind() == reflect.Ptr {
+		attr.Set(reflect.New(attr.Type().Elem()))
+		attr = attr.Elem()
+	}
+	attr.SetInt(int64(duration))
+	return nil
+}
+
+// parseText parses the durations returned as text, which are intervals
+// on postgres and counts of the unit on the other databases
+func (d durationScanner) parseText(text string) (time.Duration, error) {
+	if capabilitiesOf(d.Dialect).Intervals {
+		return parseInterval(text)
+	}
+	return parseDurationCount(text, d.Unit)
+}
+
+// parseDurationCount parses the integer or decimal count of units
+// that some drivers, e.g. mysql, return as text
+func parseDurationCount(text string, unit time.Duration) (time.Duration, error) {
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return time.Duration(n) * unit, nil
+	}
+
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse duration from '%s': %w", text, err)
+	}
+	return time.Duration(f * float64(unit)), nil
+}
+
+// parseInterval parses the text format of the postgres intervals,
+// e.g. `1 day 02:03:04.5` or `-00:00:01`, the intervals with months
+// or years are rejected since their length in time is not fixed.
+func parseInterval(text string) (time.Duration, error) {
+	var duration time.Duration
+	fields := strings.Fields(text)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if strings.Contains(field, ":") {
+			d, err := parseIntervalTime(field)
+			if err != nil {
+				return 0, fmt.Errorf("unable to parse interval '%s': %w", text, err)
+			}
+			duration += d
+			continue
+		}
+
+		if i+1 >= len(fields) {
+			return 0, fmt.Errorf("unable to parse interval '%s': missing the unit of '%s'", text, field)
+		}
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse interval '%s': %w", text, err)
+		}
+
+		i++
+		switch strings.TrimSuffix(fields[i], "s") {
+		case "day":
+			duration += time.Duration(n) * 24 * time.Hour
+		default:
+			return 0, fmt.Errorf("unable to convert interval '%s' to time.Duration: only days, hours, minutes and seconds are supported", text)
+		}
+	}
+
+	return duration, nil
+}
+
+// parseIntervalTime parses the `[-]HH:MM:SS[.ffffff]` part of an interval
+func parseIntervalTime(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+	s = strings.TrimPrefix(s, "+")
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("expected the format HH:MM:SS but got '%s'", s)
+	}
+
+	hours, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	d := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second)+0.5)
+	return sign * d, nil
+}
@@ -0,0 +1,62 @@
+package ksql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestDurationModifier(t *testing.T) {
+	t.Run("should encode durations for each dialect", func(t *testing.T) {
+		tt.AssertEqual(t, encodeDuration(supportedDialects["postgres"], 1500*time.Millisecond, time.Second), "1500000 microseconds")
+		tt.AssertEqual(t, encodeDuration(supportedDialects["mysql"], 1500*time.Millisecond, time.Millisecond), int64(1500))
+		tt.AssertEqual(t, encodeDuration(supportedDialects["sqlite3"], 1500*time.Millisecond, time.Second), int64(1))
+		tt.AssertEqual(t, encodeDuration(supportedDialects["sqlite3"], nil, time.Second), nil)
+	})
+
+	t.Run("should scan the values returned by each driver", func(t *testing.T) {
+		tests := []struct {
+			desc             string
+			driver           string
+			unit             time.Duration
+			value            interface{}
+			expectedDuration time.Duration
+		}{
+			{desc: "integers", driver: "sqlite3", unit: time.Millisecond, value: int64(1500), expectedDuration: 1500 * time.Millisecond},
+			{desc: "integers as text", driver: "mysql", unit: time.Second, value: []byte("42"), expectedDuration: 42 * time.Second},
+			{desc: "floats", driver: "sqlite3", unit: time.Second, value: 1.5, expectedDuration: 1500 * time.Millisecond},
+			{desc: "intervals", driver: "postgres", value: "01:02:03.5", expectedDuration: time.Hour + 2*time.Minute + 3500*time.Millisecond},
+			{desc: "intervals with days", driver: "postgres", value: "2 days 00:00:01", expectedDuration: 48*time.Hour + time.Second},
+			{desc: "negative intervals", driver: "postgres", value: []byte("-00:00:00.250000"), expectedDuration: -250 * time.Millisecond},
+			{desc: "intervals with only days", driver: "postgres", value: "-1 day", expectedDuration: -24 * time.Hour},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				var d time.Duration
+				err := durationScanner{
+					Attr:    reflect.ValueOf(&d).Elem(),
+					Dialect: supportedDialects[test.driver],
+					Unit:    test.unit,
+				}.Scan(test.value)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, d, test.expectedDuration)
+			})
+		}
+	})
+
+	t.Run("should scan NULL into nil pointers", func(t *testing.T) {
+		d := new(time.Duration)
+		err := durationScanner{Attr: reflect.ValueOf(&d).Elem(), Unit: time.Second}.Scan(nil)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, d == nil, true)
+	})
+
+	t.Run("should reject intervals with months", func(t *testing.T) {
+		var d time.Duration
+		err := durationScanner{Attr: reflect.ValueOf(&d).Elem(), Dialect: &postgresDialect{}}.Scan("1 mon 2 days")
+		tt.AssertErrContains(t, err, "1 mon 2 days", "only days, hours, minutes and seconds")
+	})
+}
@@ -0,0 +1,330 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// ErrNotSupported is returned by the helper functions of the extension
+// interfaces when the Provider doesn't support the requested feature
+// and there is no way of emulating it with the Provider methods.
+var ErrNotSupported error = fmt.Errorf("ksql: operation not supported by this provider")
+
+// The extension interfaces below describe optional features that
+// are not part of the Provider interface, so that the Provider interface
+// can stay stable while new features are added.
+//
+// Each of them has a helper function, e.g. `ksql.InsertMany()`, that checks
+// at runtime if the Provider implements the interface and, if it doesn't,
+// emulates the feature using the Provider methods whenever possible.
+//
+// The ksql.DB type implements all of them.
+
+// BatchProvider describes Providers capable of inserting
+// or updating many records at once
+type BatchProvider interface {
+	Provider
+	InsertMany(ctx context.Context, table Table, records interface{}, opts ...QueryOption) error
+	UpdateMany(ctx context.Context, table Table, records interface{}, opts ...QueryOption) error
+}
+
+// UpserterProvider describes Providers capable of inserting a record
+// or updating it if it already exists with a single operation
+type UpserterProvider interface {
+	Provider
+	Upsert(ctx context.Context, table Table, record interface{}, opts ...QueryOption) error
+}
+
+// CopyProvider describes Providers capable of bulk loading CSV data
+type CopyProvider interface {
+	Provider
+	InsertCSV(ctx context.Context, table Table, r io.Reader, opts CSVOptions) error
+}
+
+var (
+	_ BatchProvider    = DB{}
+	_ UpserterProvider = DB{}
+	_ CopyProvider     = DB{}
+)
+
+// InsertMany inserts all the records of the input slice, which must
+// be a slice of pointers to structs, so the IDs can be updated.
+//
+// If the Provider doesn't implement the BatchProvider interface the
+// records are inserted one by one inside a single transaction.
+//
+// If some records fail to be inserted it returns a BatchError
+// describing which records failed and why.
+func InsertMany(ctx context.Context, db Provider, table Table, records interface{}, opts ...QueryOption) error {
+	if batchProvider, ok := db.(BatchProvider); ok {
+		return batchProvider.InsertMany(ctx, table, records, opts...)
+	}
+
+	return insertOneByOne(ctx, db, table, records, opts)
+}
+
+// Upsert updates the record if it already exists on the database or
+// inserts it otherwise. Records with unset IDs are always inserted.
+//
+// If the Provider doesn't implement the UpserterProvider interface this
+// function runs a Patch followed by an Insert, if no record was found,
+// inside a single transaction, but note that if two concurrent Upserts
+// try to insert the same record one of them might fail with a unique
+// constraint violation.
+func Upsert(ctx context.Context, db Provider, table Table, record interface{}, opts ...QueryOption) error {
+	if upserter, ok := db.(UpserterProvider); ok {
+		return upserter.Upsert(ctx, table, record, opts...)
+	}
+
+	return upsertWithPatch(ctx, db, table, record, opts)
+}
+
+// InsertCSV loads the CSV records into the table as described on the
+// `DB.InsertCSV()` method, if the Provider doesn't implement the
+// CopyProvider interface it returns ErrNotSupported.
+func InsertCSV(ctx context.Context, db Provider, table Table, r io.Reader, opts CSVOptions) error {
+	copier, ok := db.(CopyProvider)
+	if !ok {
+		return fmt.Errorf("%w: %T doesn't implement the ksql.CopyProvider interface", ErrNotSupported, db)
+	}
+
+	return copier.InsertCSV(ctx, table, r, opts)
+}
+
+// InsertMany inserts all the records of the input slice
+// inside a single transaction, the records must be
+// a slice of pointers to structs.
+//
+// On snowflake and bigquery the records are inserted with multi-row INSERT
+// statements, or with the ksql.RowAppender interface if the adapter implements
+// it, and since these databases can't return the generated IDs they are only
+// written to the records if the ksql.Table uses a sequence.
+//
+// On mysql the records are also inserted with multi-row INSERT statements
+// if the DB was created with the ksql.WithMySQLConsecutiveIDs option, in
+// which case the generated IDs are computed from LAST_INSERT_ID().
+func (c DB) InsertMany(ctx context.Context, table Table, records interface{}, opts ...QueryOption) error {
+	if hasMultiRowInsertMany(c.dialect) {
+		return c.insertMultiRow(ctx, table, records, opts)
+	}
+
+	return insertOneByOne(ctx, c, table, records, opts)
+}
+
+func insertOneByOne(ctx context.Context, db Provider, table Table, records interface{}, opts []QueryOption) error {
+	v := reflect.ValueOf(records)
+	if v.Kind() != reflect.Slice || assertStructPtr(v.Type().Elem()) != nil {
+		return fmt.Errorf("ksql: expected records to be a slice of pointers to structs, but got: %T", records)
+	}
+
+	if v.Len() == 0 {
+		return nil
+	}
+
+	return db.Transaction(ctx, func(db Provider) error {
+		for i := 0; i < v.Len(); i++ {
+			err := db.Insert(WithQueryOptions(ctx, append(opts, batchOperation)...), table, v.Index(i).Interface())
+			if err != nil {
+				return newBatchError("InsertMany", []int{i}, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Upsert updates the record if it already exists on the database or inserts
+// it otherwise, records with unset IDs are always inserted.
+//
+// On postgres and sqlite3 it uses the `ON CONFLICT` clause and on mysql the
+// `ON DUPLICATE KEY UPDATE` clause, the other dialects run a Patch followed
+// by an Insert inside a transaction. Just like with Patch, nil pointer
+// attributes are not updated.
+func (c DB) Upsert(
+	ctx context.Context,
+	table Table,
+	record interface{},
+	opts ...QueryOption,
+) error {
+	if err := checkUpdateByID(c.dialect, "Upsert"); err != nil {
+		return err
+	}
+
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).Upsert(ctx, table, record, opts...)
+		})
+	}
+
+	v := reflect.ValueOf(record)
+	t := v.Type()
+	if err := assertStructPtr(t); err != nil {
+		return fmt.Errorf(
+			"ksql: expected record to be a pointer to struct, but got: %T",
+			record,
+		)
+	}
+
+	if v.IsNil() {
+		return fmt.Errorf("ksql: expected a valid pointer to struct as argument but received a nil pointer: %v", record)
+	}
+
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't upsert in ksql.Table: %s", err)
+	}
+
+	info, err := structs.GetTagInfo(t.Elem())
+	if err != nil {
+		return err
+	}
+
+	recordMap, err := structs.StructToMap(record)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range table.idColumns {
+		value, found := recordMap[id]
+		if !found || reflect.ValueOf(value).IsZero() {
+			// It is a new record so there is nothing to update:
+			return c.insert(ctx, table, record, opts)
+		}
+	}
+
+	if !hasNativeUpsert(c.dialect) {
+		return upsertWithPatch(ctx, c, table, record, opts)
+	}
+
+	// The other paths are validated by Insert and Patch:
+	if err := c.validateRecord(ctx, record); err != nil {
+		return err
+	}
+
+	o := newQueryOptions(opts)
+	if o.strictImmutable {
+		if err := checkImmutableColumns(info, recordMap, table.idColumns); err != nil {
+			return err
+		}
+	}
+
+	applyDefaultValues(v, info, recordMap, c.generators())
+	removeGeneratedColumns(info, recordMap)
+
+	if err := encodeColumnValues(c.dialect, c.converters, info, recordMap); err != nil {
+		return err
+	}
+
+	query, params := buildUpsertQuery(c.dialect, table, info, recordMap)
+	query, err = addStatementHints(c.dialect, table, query, o)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
+	_, err = c.execContext(ctx, OpInfo{Method: "Upsert", TableName: table.name}, o, query, params...)
+	if err == errDryRun {
+		return nil
+	}
+
+	return err
+}
+
+func upsertWithPatch(ctx context.Context, db Provider, table Table, record interface{}, opts []QueryOption) error {
+	return db.Transaction(ctx, func(db Provider) error {
+		ctx := WithQueryOptions(ctx, opts...)
+		err := db.Patch(ctx, table, record)
+		if err != ErrRecordNotFound {
+			return err
+		}
+
+		return db.Insert(ctx, table, record)
+	})
+}
+
+func hasNativeUpsert(dialect Dialect) bool {
+	flavor := capabilitiesOf(dialect).Upsert
+	return flavor == UpsertOnConflict || flavor == UpsertOnDuplicateKey
+}
+
+func buildUpsertQuery(
+	dialect Dialect,
+	table Table,
+	info structs.StructInfo,
+	recordMap map[string]interface{},
+) (query string, params []interface{}) {
+	onDuplicateKey := capabilitiesOf(dialect).Upsert == UpsertOnDuplicateKey
+
+	isID := map[string]bool{}
+	for _, id := range table.idColumns {
+		isID[id] = true
+	}
+
+	columns := sortedKeys(recordMap)
+	escapedColumns := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	updates := []string{}
+	params = make([]interface{}, len(columns))
+	for i, col := range columns {
+		params[i] = recordMap[col]
+		if info.ByName(col).SerializeAsJSON {
+			params[i] = jsonSerializable{
+				Dialect:   dialect,
+				Attr:      recordMap[col],
+				NilAsNull: info.ByName(col).NilAsNull,
+			}
+		}
+
+		escapedColumns[i] = dialect.Escape(col)
+		placeholders[i] = dialect.Placeholder(i)
+
+		if isID[col] || info.ByName(col).Immutable {
+			continue
+		}
+
+		if onDuplicateKey {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", escapedColumns[i], escapedColumns[i]))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", escapedColumns[i], escapedColumns[i]))
+		}
+	}
+
+	var conflictClause string
+	if onDuplicateKey {
+		if len(updates) == 0 {
+			// MySQL has no DO NOTHING so we update the ID with its own value:
+			escapedID := dialect.Escape(table.idColumns[0])
+			updates = append(updates, fmt.Sprintf("%s = %s", escapedID, escapedID))
+		}
+		conflictClause = " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+	} else {
+		escapedIDs := make([]string, len(table.idColumns))
+		for i, id := range table.idColumns {
+			escapedIDs[i] = dialect.Escape(id)
+		}
+
+		conflictClause = fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(escapedIDs, ", "))
+		if len(updates) > 0 {
+			conflictClause = fmt.Sprintf(
+				" ON CONFLICT (%s) DO UPDATE SET %s",
+				strings.Join(escapedIDs, ", "),
+				strings.Join(updates, ", "),
+			)
+		}
+	}
+
+	query = fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)%s",
+		table.escapedName(dialect),
+		strings.Join(escapedColumns, ", "),
+		strings.Join(placeholders, ", "),
+		conflictClause,
+	)
+
+	return query, params
+}
@@ -0,0 +1,341 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestUpsert(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	tests := []struct {
+		desc          string
+		driver        string
+		table         Table
+		record        interface{}
+		expectedQuery string
+	}{
+		{
+			desc:          "postgres",
+			driver:        "postgres",
+			table:         usersTable,
+			record:        &userRecord{ID: 42, Name: "fake-name"},
+			expectedQuery: `INSERT INTO "users" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`,
+		},
+		{
+			desc:          "sqlite3",
+			driver:        "sqlite3",
+			table:         usersTable,
+			record:        &userRecord{ID: 42, Name: "fake-name"},
+			expectedQuery: "INSERT INTO `users` (`id`, `name`) VALUES (?, ?) ON CONFLICT (`id`) DO UPDATE SET `name` = EXCLUDED.`name`",
+		},
+		{
+			desc:          "mysql",
+			driver:        "mysql",
+			table:         usersTable,
+			record:        &userRecord{ID: 42, Name: "fake-name"},
+			expectedQuery: "INSERT INTO `users` (`id`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+		},
+		{
+			desc:          "postgres with only ID columns",
+			driver:        "postgres",
+			table:         NewTable("users", "id", "name"),
+			record:        &userRecord{ID: 42, Name: "fake-name"},
+			expectedQuery: `INSERT INTO "users" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id", "name") DO NOTHING`,
+		},
+		{
+			desc:          "mysql with only ID columns",
+			driver:        "mysql",
+			table:         NewTable("users", "id", "name"),
+			record:        &userRecord{ID: 42, Name: "fake-name"},
+			expectedQuery: "INSERT INTO `users` (`id`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `id` = `id`",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var query string
+			var params []interface{}
+			db, err := NewWithAdapter(mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+					query = q
+					params = args
+					return NewMockResult(0, 1), nil
+				},
+			}, test.driver)
+			tt.AssertNoErr(t, err)
+
+			err = Upsert(context.Background(), db, test.table, test.record)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, query, test.expectedQuery)
+			tt.AssertEqual(t, params, []interface{}{42, "fake-name"})
+		})
+	}
+
+	t.Run("should insert records with unset IDs", func(t *testing.T) {
+		var query string
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query = q
+				return newMockRows([]string{"id"}, []interface{}{43}), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		u := userRecord{Name: "fake-name"}
+		err = Upsert(context.Background(), db, usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `INSERT INTO "users" ("name") VALUES ($1) RETURNING "id"`)
+		tt.AssertEqual(t, u.ID, 43)
+	})
+
+	t.Run("should fallback to Patch and Insert for providers with no native upsert", func(t *testing.T) {
+		var calls []string
+		mock := Mock{
+			PatchFn: func(ctx context.Context, table Table, record interface{}) error {
+				calls = append(calls, "Patch")
+				return ErrRecordNotFound
+			},
+			InsertFn: func(ctx context.Context, table Table, record interface{}) error {
+				calls = append(calls, "Insert")
+				return nil
+			},
+		}
+		mock.TransactionFn = func(ctx context.Context, fn func(db Provider) error) error {
+			calls = append(calls, "Transaction")
+			return fn(mock)
+		}
+
+		err := Upsert(context.Background(), mock, usersTable, &userRecord{ID: 42, Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"Transaction", "Patch", "Insert"})
+	})
+}
+
+func TestInsertMany(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	t.Run("should insert all records inside a transaction", func(t *testing.T) {
+		var insertedNames []interface{}
+		var committed bool
+		nextID := 42
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+							insertedNames = append(insertedNames, args...)
+							nextID++
+							return newMockRows([]string{"id"}, []interface{}{nextID}), nil
+						},
+					},
+					CommitFn: func(ctx context.Context) error {
+						committed = true
+						return nil
+					},
+				}, nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		users := []*userRecord{{Name: "fake-name1"}, {Name: "fake-name2"}}
+		err = InsertMany(context.Background(), db, usersTable, users)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, committed, true)
+		tt.AssertEqual(t, insertedNames, []interface{}{"fake-name1", "fake-name2"})
+		tt.AssertEqual(t, users[0].ID, 43)
+		tt.AssertEqual(t, users[1].ID, 44)
+	})
+
+	t.Run("should compute the consecutive IDs on mysql", func(t *testing.T) {
+		var queries []string
+		var params []interface{}
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+							queries = append(queries, q)
+							return newMockRows([]string{"@@auto_increment_increment"}, []interface{}{int64(2)}), nil
+						},
+						ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+							queries = append(queries, q)
+							params = append(params, args...)
+							return NewMockResult(10, int64(strings.Count(q, "(?"))), nil
+						},
+					},
+				}, nil
+			},
+		}, "mysql", WithMySQLConsecutiveIDs())
+		tt.AssertNoErr(t, err)
+
+		users := []*userRecord{{Name: "fake-name1"}, {ID: 5, Name: "fake-name2"}, {Name: "fake-name3"}}
+		err = InsertMany(context.Background(), db, usersTable, users)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{
+			"INSERT INTO `users` (`name`) VALUES (?), (?)",
+			"SELECT @@auto_increment_increment",
+			"INSERT INTO `users` (`id`, `name`) VALUES (?, ?)",
+		})
+		tt.AssertEqual(t, params, []interface{}{"fake-name1", "fake-name3", 5, "fake-name2"})
+		tt.AssertEqual(t, users[0].ID, 10)
+		tt.AssertEqual(t, users[1].ID, 5)
+		tt.AssertEqual(t, users[2].ID, 12)
+	})
+
+	t.Run("should report error if the affected rows don't match the records on mysql", func(t *testing.T) {
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+							return newMockRows([]string{"@@auto_increment_increment"}, []interface{}{int64(1)}), nil
+						},
+						ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+							return NewMockResult(10, 1), nil
+						},
+					},
+				}, nil
+			},
+		}, "mysql", WithMySQLConsecutiveIDs())
+		tt.AssertNoErr(t, err)
+
+		users := []*userRecord{{Name: "fake-name1"}, {Name: "fake-name2"}}
+		err = InsertMany(context.Background(), db, usersTable, users)
+		tt.AssertErrContains(t, err, "expected 2 rows", "got 1")
+		tt.AssertEqual(t, users[0].ID, 0)
+	})
+
+	t.Run("should report invalid records", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = InsertMany(context.Background(), db, usersTable, []userRecord{{Name: "fake-name"}})
+		tt.AssertErrContains(t, err, "slice of pointers to structs")
+	})
+}
+
+func TestUpdateMany(t *testing.T) {
+	type userRecord struct {
+		ID   int     `ksql:"id"`
+		Name *string `ksql:"name"`
+		Age  *int    `ksql:"age"`
+	}
+
+	name1, name2 := "fake-name1", "fake-name2"
+	age := 30
+
+	t.Run("should group the records by the updated columns", func(t *testing.T) {
+		type call struct {
+			query  string
+			params []interface{}
+		}
+
+		var calls []call
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+							calls = append(calls, call{query: query, params: args})
+							return NewMockResult(0, int64(len(args)/4)), nil
+						},
+					},
+				}, nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var n int64
+		err = db.UpdateMany(context.Background(), usersTable, []userRecord{
+			{ID: 1, Name: &name1},
+			{ID: 2, Name: &name2, Age: &age},
+			{ID: 3, Name: &name1},
+			{ID: 4},
+		}, RowsAffected(&n))
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, calls, []call{
+			{
+				query:  `UPDATE "users" SET "name" = CASE WHEN "id" = $1 THEN $2 WHEN "id" = $3 THEN $4 ELSE "name" END WHERE ("id" = $5) OR ("id" = $6)`,
+				params: []interface{}{1, "fake-name1", 3, "fake-name1", 1, 3},
+			},
+			{
+				query: `UPDATE "users" SET "age" = CASE WHEN "id" = $1 THEN $2 ELSE "age" END, ` +
+					`"name" = CASE WHEN "id" = $3 THEN $4 ELSE "name" END WHERE ("id" = $5)`,
+				params: []interface{}{2, 30, 2, "fake-name2", 2},
+			},
+		})
+		tt.AssertEqual(t, n, int64(2))
+	})
+
+	t.Run("should split large groups into multiple statements", func(t *testing.T) {
+		var numStatements int
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+							tt.AssertEqual(t, len(args) <= maxParamsPerStatement, true)
+							numStatements++
+							return NewMockResult(0, 0), nil
+						},
+					},
+				}, nil
+			},
+		}, "sqlite3")
+		tt.AssertNoErr(t, err)
+
+		records := make([]*userRecord, 1000)
+		for i := range records {
+			records[i] = &userRecord{ID: i + 1, Name: &name1}
+		}
+
+		err = db.UpdateMany(context.Background(), usersTable, &records)
+		tt.AssertNoErr(t, err)
+		// Each record uses 3 params so each statement updates up to 333 records:
+		tt.AssertEqual(t, numStatements, 4)
+	})
+
+	t.Run("should fallback to Patch for providers with no native support", func(t *testing.T) {
+		var patched []interface{}
+		err := UpdateMany(context.Background(), Mock{
+			PatchFn: func(ctx context.Context, table Table, record interface{}) error {
+				patched = append(patched, record)
+				return nil
+			},
+		}, usersTable, []userRecord{{ID: 1, Name: &name1}, {ID: 2, Age: &age}})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, patched, []interface{}{
+			userRecord{ID: 1, Name: &name1},
+			userRecord{ID: 2, Age: &age},
+		})
+	})
+
+	t.Run("should report invalid records", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.UpdateMany(context.Background(), usersTable, userRecord{ID: 1})
+		tt.AssertErrContains(t, err, "slice of structs")
+
+		err = db.UpdateMany(context.Background(), usersTable, []userRecord{{Name: &name1}})
+		tt.AssertErrContains(t, err, "record 0", "id")
+	})
+}
+
+func TestInsertCSVHelper(t *testing.T) {
+	err := InsertCSV(context.Background(), Mock{}, usersTable, strings.NewReader("name\nfoo\n"), CSVOptions{})
+	tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+	tt.AssertErrContains(t, err, "ksql.Mock", "CopyProvider")
+}
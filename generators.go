@@ -0,0 +1,79 @@
+package ksql
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// Clock provides the current time for the attributes
+// tagged with the `default=now` modifier.
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator provides the IDs for the attributes
+// tagged with the `default=newid` modifier.
+type IDGenerator interface {
+	NewID() string
+}
+
+// WithClock replaces the clock used for the `default=now`
+// modifier, which is useful for freezing the time on tests.
+func WithClock(clock Clock) Option {
+	return func(db *DB) {
+		db.clock = clock
+	}
+}
+
+// WithIDGenerator replaces the generator used for the `default=newid`
+// modifier, which by default generates random UUIDs (version 4),
+// this is useful for generating predictable IDs on tests or for
+// using other formats, e.g. ULIDs.
+func WithIDGenerator(generator IDGenerator) Option {
+	return func(db *DB) {
+		db.idGenerator = generator
+	}
+}
+
+// generators returns the generators used by the `default` modifiers
+func (c DB) generators() structs.Generators {
+	var clock Clock = systemClock{}
+	if c.clock != nil {
+		clock = c.clock
+	}
+
+	var idGenerator IDGenerator = uuidGenerator{}
+	if c.idGenerator != nil {
+		idGenerator = c.idGenerator
+	}
+
+	return structs.Generators{
+		Now:   clock.Now,
+		NewID: idGenerator.NewID,
+	}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand only fails if the OS can't provide random numbers:
+		panic(fmt.Sprintf("ksql: unable to generate random ID: %s", err))
+	}
+
+	// Setting the version (4) and the variant (RFC 4122) bits:
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
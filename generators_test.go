@@ -0,0 +1,83 @@
+package ksql
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f fakeClock) Now() time.Time {
+	return f.now
+}
+
+type fakeIDGenerator struct {
+	ids []string
+}
+
+func (f *fakeIDGenerator) NewID() string {
+	id := f.ids[0]
+	f.ids = f.ids[1:]
+	return id
+}
+
+func TestGenerators(t *testing.T) {
+	ctx := context.Background()
+
+	type event struct {
+		ID        int       `ksql:"id"`
+		Code      string    `ksql:"code,default=newid"`
+		CreatedAt time.Time `ksql:"created_at,default=now"`
+	}
+
+	newAdapter := func(params *[]interface{}) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				*params = args
+				return NewMockResult(1, 1), nil
+			},
+		}
+	}
+
+	t.Run("should use the configured clock and ID generator", func(t *testing.T) {
+		now := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+
+		var params []interface{}
+		db := newDB(t, "sqlite3", newAdapter(&params),
+			WithClock(fakeClock{now: now}),
+			WithIDGenerator(&fakeIDGenerator{ids: []string{"id-1", "id-2"}}),
+		)
+
+		e := event{}
+		err := db.Insert(ctx, NewTable("events"), &e)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, e.Code, "id-1")
+		tt.AssertEqual(t, e.CreatedAt, now)
+		tt.AssertEqual(t, params, []interface{}{"id-1", now})
+
+		e = event{Code: "explicit-code"}
+		err = db.Insert(ctx, NewTable("events"), &e)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, e.Code, "explicit-code")
+	})
+
+	t.Run("should generate random UUIDs and the current time by default", func(t *testing.T) {
+		var params []interface{}
+		db := newDB(t, "sqlite3", newAdapter(&params))
+
+		e1, e2 := event{}, event{}
+		tt.AssertNoErr(t, db.Insert(ctx, NewTable("events"), &e1))
+		tt.AssertNoErr(t, db.Insert(ctx, NewTable("events"), &e2))
+
+		uuidRegex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		tt.AssertEqual(t, uuidRegex.MatchString(e1.Code), true)
+		tt.AssertNotEqual(t, e1.Code, e2.Code)
+		tt.AssertApproxTime(t, time.Second, e1.CreatedAt, time.Now(), "")
+	})
+}
@@ -0,0 +1,154 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PoolStatsReporter can be implemented by the DBAdapter in order to
+// include the statistics of the connection pool on the reports
+// returned by the `DB.HealthCheck()` method.
+type PoolStatsReporter interface {
+	PoolStats() PoolStats
+}
+
+// PoolStats describes the current state of a connection pool
+type PoolStats struct {
+	// MaxOpenConns is 0 if there is no limit
+	MaxOpenConns int `json:"max_open_conns"`
+	OpenConns    int `json:"open_conns"`
+	InUse        int `json:"in_use"`
+	Idle         int `json:"idle"`
+
+	// WaitCount is the number of times a connection had to be waited for
+	// and WaitDuration is the total time spent waiting for them.
+	WaitCount    int64         `json:"wait_count"`
+	WaitDuration time.Duration `json:"wait_duration_ns"`
+}
+
+// HealthChecker is implemented by the Providers that can report their
+// health, e.g. the DB and the ReadWriteSplitter structs.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) HealthReport
+}
+
+// HealthReport is returned by `DB.HealthCheck()`, it can be encoded
+// as JSON and written directly as the response of /healthz endpoints, e.g.:
+//
+//	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+//		report := db.HealthCheck(r.Context())
+//		w.Header().Set("Content-Type", "application/json")
+//		w.WriteHeader(report.HTTPStatus())
+//		json.NewEncoder(w).Encode(report)
+//	})
+type HealthReport struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+
+	// Latency is the time it took for the database to answer the ping
+	Latency time.Duration `json:"latency_ns"`
+
+	// Pool is only set if the adapter implements the PoolStatsReporter interface
+	Pool *PoolStats `json:"pool,omitempty"`
+
+	// Saturation is the fraction of the maximum number of connections
+	// currently in use, it is only set if the pool has a limit.
+	Saturation float64 `json:"saturation,omitempty"`
+
+	// Replicas is only set by the `ReadWriteSplitter.HealthCheck()` method,
+	// it contains the report of each replica in the order they were passed
+	// to NewReadWriteSplitter, while the other attributes describe the primary.
+	Replicas []HealthReport `json:"replicas,omitempty"`
+}
+
+// HTTPStatus returns the status code that should be
+// used when reporting the health on HTTP endpoints.
+func (h HealthReport) HTTPStatus() int {
+	if !h.Healthy {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// HealthCheck pings the database measuring its latency and collects the
+// statistics of the connection pool, if available.
+//
+// It never returns an error, instead the report is marked as not healthy
+// and contains the error message.
+func (c DB) HealthCheck(ctx context.Context) HealthReport {
+	var report HealthReport
+
+	start := time.Now()
+	err := c.ping(ctx)
+	report.Latency = time.Since(start)
+
+	report.Healthy = err == nil
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	if statsReporter, ok := getPoolStatsReporter(c.db); ok {
+		stats := statsReporter.PoolStats()
+		report.Pool = &stats
+		if stats.MaxOpenConns > 0 {
+			report.Saturation = float64(stats.InUse) / float64(stats.MaxOpenConns)
+		}
+	}
+
+	return report
+}
+
+// ping runs a trivial query directly on the adapter so
+// the query rewriters don't interfere with the health check
+func (c DB) ping(ctx context.Context) error {
+	rows, err := c.db.QueryContext(ctx, pingQuery(c.dialect))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if rows.Err() != nil {
+			return rows.Err()
+		}
+		return fmt.Errorf("ksql: the ping query returned no rows")
+	}
+
+	return rows.Close()
+}
+
+// getPoolStatsReporter also looks for the PoolStatsReporter
+// on the adapters wrapped by other adapters, e.g. by WrapAdapter.
+func getPoolStatsReporter(db DBAdapter) (PoolStatsReporter, bool) {
+	for {
+		if statsReporter, ok := db.(PoolStatsReporter); ok {
+			return statsReporter, true
+		}
+
+		wrapper, ok := db.(adapterWrapper)
+		if !ok {
+			return nil, false
+		}
+		db = wrapper.unwrapAdapter()
+	}
+}
+
+// pingQuery returns the trivial query used by the health check,
+// e.g. `SELECT 1 FROM RDB$DATABASE` on firebird which requires a FROM clause.
+func pingQuery(dialect Dialect) string {
+	return selectWithoutTable(dialect, "1")
+}
+
+// providerHealthCheck returns the report of Providers implementing the
+// HealthChecker interface and an unhealthy report for the other ones.
+func providerHealthCheck(ctx context.Context, db Provider) HealthReport {
+	checker, ok := db.(HealthChecker)
+	if !ok {
+		return HealthReport{
+			Error: fmt.Sprintf("ksql: the Provider %T doesn't implement the HealthChecker interface", db),
+		}
+	}
+	return checker.HealthCheck(ctx)
+}
@@ -0,0 +1,98 @@
+package ksql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type mockStatsReporter struct {
+	mockDBAdapter
+	PoolStatsFn func() PoolStats
+}
+
+func (m mockStatsReporter) PoolStats() PoolStats {
+	return m.PoolStatsFn()
+}
+
+func TestHealthCheck(t *testing.T) {
+	t.Run("should report the pool stats of healthy databases", func(t *testing.T) {
+		var query string
+		adapter := mockStatsReporter{
+			mockDBAdapter: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+					query = q
+					return newMockRows([]string{"1"}, []interface{}{1}), nil
+				},
+			},
+			PoolStatsFn: func() PoolStats {
+				return PoolStats{
+					MaxOpenConns: 10,
+					OpenConns:    5,
+					InUse:        4,
+					Idle:         1,
+				}
+			},
+		}
+
+		// Using WrapAdapter to make sure the stats are found on wrapped adapters:
+		db, err := NewWithAdapter(WrapAdapter(adapter, AdapterHooks{}), "postgres")
+		tt.AssertNoErr(t, err)
+
+		report := db.HealthCheck(context.Background())
+		tt.AssertEqual(t, query, "SELECT 1")
+		tt.AssertEqual(t, report.Healthy, true)
+		tt.AssertEqual(t, report.Error, "")
+		tt.AssertEqual(t, report.HTTPStatus(), http.StatusOK)
+		tt.AssertEqual(t, report.Pool, &PoolStats{
+			MaxOpenConns: 10,
+			OpenConns:    5,
+			InUse:        4,
+			Idle:         1,
+		})
+		tt.AssertEqual(t, report.Saturation, 0.4)
+
+		b, err := json.Marshal(report)
+		tt.AssertNoErr(t, err)
+
+		var decoded map[string]interface{}
+		err = json.Unmarshal(b, &decoded)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, decoded["healthy"], true)
+		tt.AssertEqual(t, decoded["saturation"], 0.4)
+	})
+
+	t.Run("should ping firebird with a FROM clause", func(t *testing.T) {
+		var query string
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query = q
+				return newMockRows([]string{"1"}, []interface{}{1}), nil
+			},
+		}, "firebird")
+		tt.AssertNoErr(t, err)
+
+		report := db.HealthCheck(context.Background())
+		tt.AssertEqual(t, query, "SELECT 1 FROM RDB$DATABASE")
+		tt.AssertEqual(t, report.Healthy, true)
+	})
+
+	t.Run("should report unhealthy databases", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				return nil, fmt.Errorf("fake-connection-error")
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		report := db.HealthCheck(context.Background())
+		tt.AssertEqual(t, report.Healthy, false)
+		tt.AssertEqual(t, report.Error, "fake-connection-error")
+		tt.AssertEqual(t, report.HTTPStatus(), http.StatusServiceUnavailable)
+		tt.AssertEqual(t, report.Pool, (*PoolStats)(nil))
+	})
+}
@@ -0,0 +1,162 @@
+package ksql
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// encodeHstore converts the map of an attribute tagged with the `hstore`
+// modifier to the text representation of the postgres hstore type, e.g.:
+//
+//	"key1"=>"value1", "key2"=>NULL
+func encodeHstore(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	if value == nil || v.IsNil() {
+		return nil
+	}
+
+	pairs := make([]string, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		item := iter.Value()
+		if item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				pairs = append(pairs, quoteHstoreString(iter.Key().String())+"=>NULL")
+				continue
+			}
+			item = item.Elem()
+		}
+
+		pairs = append(pairs, quoteHstoreString(iter.Key().String())+"=>"+quoteHstoreString(item.String()))
+	}
+
+	// Sorting makes the encoding deterministic:
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ", ")
+}
+
+func quoteHstoreString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// hstoreScanner implements the sql.Scanner interface in order to
+// load hstore values into the attributes tagged with `hstore`.
+type hstoreScanner struct {
+	Attr reflect.Value
+}
+
+// Scan Implements the Scanner interface
+func (h hstoreScanner) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		h.Attr.Set(reflect.Zero(h.Attr.Type()))
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("unexpected type received to Scan an hstore: %T", value)
+	}
+
+	m := reflect.MakeMap(h.Attr.Type())
+	keyType := h.Attr.Type().Key()
+	elemType := h.Attr.Type().Elem()
+
+	p := hstoreParser{text: text}
+	for p.skipSpaces(); !p.done(); p.skipSpaces() {
+		key, isNull, err := p.parseString()
+		if err != nil {
+			return err
+		}
+		if isNull {
+			return fmt.Errorf("invalid hstore value, keys can't be NULL: '%s'", text)
+		}
+
+		p.skipSpaces()
+		if !strings.HasPrefix(p.text[p.pos:], "=>") {
+			return fmt.Errorf("invalid hstore value, expected '=>' at position %d: '%s'", p.pos, text)
+		}
+		p.pos += len("=>")
+		p.skipSpaces()
+
+		item, isNull, err := p.parseString()
+		if err != nil {
+			return err
+		}
+
+		itemValue := reflect.New(elemType).Elem()
+		if elemType.Kind() != reflect.Ptr {
+			itemValue.SetString(item)
+		} else if !isNull {
+			itemValue.Set(reflect.New(elemType.Elem()))
+			itemValue.Elem().SetString(item)
+		}
+		m.SetMapIndex(reflect.ValueOf(key).Convert(keyType), itemValue)
+
+		p.skipSpaces()
+		if !p.done() {
+			if p.text[p.pos] != ',' {
+				return fmt.Errorf("invalid hstore value, expected ',' at position %d: '%s'", p.pos, text)
+			}
+			p.pos++
+		}
+	}
+
+	h.Attr.Set(m)
+	return nil
+}
+
+type hstoreParser struct {
+	text string
+	pos  int
+}
+
+func (p *hstoreParser) done() bool {
+	return p.pos >= len(p.text)
+}
+
+func (p *hstoreParser) skipSpaces() {
+	for !p.done() && p.text[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// parseString parses either a quoted string or the unquoted NULL keyword.
+func (p *hstoreParser) parseString() (s string, isNull bool, _ error) {
+	if strings.HasPrefix(p.text[p.pos:], "NULL") {
+		p.pos += len("NULL")
+		return "", true, nil
+	}
+
+	if p.done() || p.text[p.pos] != '"' {
+		return "", false, fmt.Errorf("invalid hstore value, expected '\"' at position %d: '%s'", p.pos, p.text)
+	}
+	p.pos++
+
+	var b strings.Builder
+	for ; !p.done(); p.pos++ {
+		c := p.text[p.pos]
+		switch c {
+		case '\\':
+			p.pos++
+			if p.done() {
+				break
+			}
+			b.WriteByte(p.text[p.pos])
+		case '"':
+			p.pos++
+			return b.String(), false, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", false, fmt.Errorf("invalid hstore value, unterminated string: '%s'", p.text)
+}
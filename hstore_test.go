@@ -0,0 +1,60 @@
+package ksql
+
+import (
+	"reflect"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestHstore(t *testing.T) {
+	t.Run("should encode maps as hstore", func(t *testing.T) {
+		tt.AssertEqual(t, encodeHstore(map[string]string{
+			"b":         "2",
+			"a":         "1",
+			`"quoted"`:  `back\slash`,
+			"with, =>s": "",
+		}), `"\"quoted\""=>"back\\slash", "a"=>"1", "b"=>"2", "with, =>s"=>""`)
+
+		tt.AssertEqual(t, encodeHstore(map[string]*string{"null": nil}), `"null"=>NULL`)
+		tt.AssertEqual(t, encodeHstore(map[string]string(nil)), nil)
+	})
+
+	t.Run("should decode hstore values", func(t *testing.T) {
+		var m map[string]string
+		err := hstoreScanner{Attr: reflect.ValueOf(&m).Elem()}.Scan(
+			[]byte(`"\"quoted\""=>"back\\slash", "a"=>"1","with, =>s"=>""`),
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, m, map[string]string{
+			"a":         "1",
+			`"quoted"`:  `back\slash`,
+			"with, =>s": "",
+		})
+
+		err = hstoreScanner{Attr: reflect.ValueOf(&m).Elem()}.Scan("")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, m, map[string]string{})
+
+		err = hstoreScanner{Attr: reflect.ValueOf(&m).Elem()}.Scan(nil)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, m, map[string]string(nil))
+	})
+
+	t.Run("should decode NULL values as nil pointers", func(t *testing.T) {
+		var m map[string]*string
+		err := hstoreScanner{Attr: reflect.ValueOf(&m).Elem()}.Scan(`"a"=>"1", "b"=>NULL`)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(m), 2)
+		tt.AssertEqual(t, *m["a"], "1")
+		tt.AssertEqual(t, m["b"], (*string)(nil))
+	})
+
+	t.Run("should report invalid hstore values", func(t *testing.T) {
+		for _, value := range []string{`"a"`, `"a"=>"1" "b"=>"2"`, `"a=>"1"`, `NULL=>"1"`} {
+			var m map[string]string
+			err := hstoreScanner{Attr: reflect.ValueOf(&m).Elem()}.Scan(value)
+			tt.AssertErrContains(t, err, "invalid hstore value")
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package ksql
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// idScanner implements the sql.Scanner interface in order to
+// load the IDs returned by the database into the ID attributes
+// of the records, so every adapter converts them the same way.
+type idScanner struct {
+	Attr reflect.Value
+	Name string
+}
+
+// Scan Implements the Scanner interface
+func (s idScanner) Scan(value interface{}) error {
+	return setIDAttr(s.Attr, s.Name, value)
+}
+
+// setIDAttr writes an ID received from the database into the ID attribute
+// of a record, accepting any integer or string attribute, including named
+// types like `type UserID int64`, pointers to them and types implementing
+// the sql.Scanner interface, and reporting an error if the ID doesn't fit.
+func setIDAttr(attr reflect.Value, idName string, value interface{}) error {
+	if value == nil {
+		return fmt.Errorf("ksql: the database returned NULL for the ID column `%s`", idName)
+	}
+
+	if scanner, ok := attr.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+
+	if attr.Kind() == reflect.Ptr {
+		ptr := reflect.New(attr.Type().Elem())
+		err := setIDAttr(ptr.Elem(), idName, value)
+		if err != nil {
+			return err
+		}
+
+		attr.Set(ptr)
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if b, ok := value.([]byte); ok {
+		v = reflect.ValueOf(string(b))
+	}
+
+	convertErr := fmt.Errorf(
+		"ksql: can't convert the ID %v of type %T into field `%s` of type %v",
+		value, value, idName, attr.Type(),
+	)
+	overflowErr := fmt.Errorf(
+		"ksql: can't convert the ID %v into field `%s` of type %v: the value overflows the field",
+		v, idName, attr.Type(),
+	)
+
+	switch attr.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var id int64
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			id = v.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v.Uint() > math.MaxInt64 {
+				return overflowErr
+			}
+			id = int64(v.Uint())
+		case reflect.String:
+			var err error
+			id, err = strconv.ParseInt(v.String(), 10, 64)
+			if err != nil {
+				return convertErr
+			}
+		default:
+			return convertErr
+		}
+
+		if attr.OverflowInt(id) {
+			return overflowErr
+		}
+		attr.SetInt(id)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var id uint64
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			// The IDs generated by TiDB for unsigned AUTO_RANDOM columns might use
+			// the sign bit, so they are converted to uint64 without loss of bits,
+			// and negative values only fit the 64 bits attributes:
+			id = uint64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			id = v.Uint()
+		case reflect.String:
+			var err error
+			id, err = strconv.ParseUint(v.String(), 10, 64)
+			if err != nil {
+				return convertErr
+			}
+		default:
+			return convertErr
+		}
+
+		if attr.OverflowUint(id) {
+			return overflowErr
+		}
+		attr.SetUint(id)
+
+	case reflect.String:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			attr.SetString(strconv.FormatInt(v.Int(), 10))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			attr.SetString(strconv.FormatUint(v.Uint(), 10))
+		case reflect.String:
+			attr.SetString(v.String())
+		default:
+			return convertErr
+		}
+
+	default:
+		// Other types, e.g. UUIDs stored as byte arrays, are
+		// accepted as long as they are convertible to the field:
+		if !v.Type().ConvertibleTo(attr.Type()) {
+			return convertErr
+		}
+		attr.Set(v.Convert(attr.Type()))
+	}
+
+	return nil
+}
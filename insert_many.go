@@ -0,0 +1,305 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// hasMultiRowInsertMany tells if InsertMany should use multi-row inserts
+func hasMultiRowInsertMany(dialect Dialect) bool {
+	return capabilitiesOf(dialect).MultiRowInsertMany
+}
+
+// insertMultiRow inserts the records with as few statements as possible
+// using multi-row `INSERT INTO t (a, b) VALUES (?, ?), (?, ?)` statements.
+//
+// The records are grouped by the set of columns they insert, since unset IDs
+// and attributes with the `default` modifier are omitted, and the IDs are
+// not written back to the records unless they are read from the sequence
+// of the table before the insert, or the dialect generates consecutive IDs
+// for the rows of each statement, see WithMySQLConsecutiveIDs.
+//
+// If the adapter implements the RowAppender interface each group is sent
+// to it instead, without starting a transaction, since the streaming APIs
+// used by the appenders are not transactional.
+func (c DB) insertMultiRow(ctx context.Context, table Table, records interface{}, opts []QueryOption) error {
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't insert in ksql.Table: %s", err)
+	}
+
+	v := reflect.ValueOf(records)
+	if v.Kind() != reflect.Slice || assertStructPtr(v.Type().Elem()) != nil {
+		return fmt.Errorf("ksql: expected records to be a slice of pointers to structs, but got: %T", records)
+	}
+
+	if v.Len() == 0 {
+		return nil
+	}
+
+	info, err := structs.GetTagInfo(v.Type().Elem().Elem())
+	if err != nil {
+		return err
+	}
+
+	o := newQueryOptions(opts)
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
+	// The DryRun option reports the INSERT statements instead:
+	appender, useAppender := getRowAppender(c.db)
+	useAppender = useAppender && o.dryRunFn == nil
+
+	// The IDs are only computed for single ID tables without sequence,
+	// and only for the records that didn't set their IDs explicitly:
+	var idName string
+	var idIncrement int64
+	if hasConsecutiveIDs(c.dialect) && len(table.idColumns) == 1 && table.sequence == "" {
+		idName = table.idColumns[0]
+		if !info.ByName(idName).Valid {
+			idName = ""
+		}
+	}
+
+	op := OpInfo{Method: "InsertMany", TableName: table.name}
+	insertAll := func(db DB) error {
+		var shapes []string
+		recordsByShape := map[string][]map[string]interface{}{}
+		indexesByShape := map[string][]int{}
+		for i := 0; i < v.Len(); i++ {
+			record := v.Index(i)
+			if record.IsNil() {
+				return newBatchError("InsertMany", []int{i}, fmt.Errorf("ksql: expected a valid pointer to struct as argument but received a nil pointer"))
+			}
+
+			if err := db.validateRecord(ctx, record.Interface()); err != nil {
+				return newBatchError("InsertMany", []int{i}, err)
+			}
+
+			if table.sequence != "" && !hasExplicitIDs(record, info, table.idColumns) {
+				err := db.setIDFromSequence(ctx, op, o, table, record, info)
+				if err != nil && err != errDryRun {
+					return newBatchError("InsertMany", []int{i}, err)
+				}
+			}
+
+			recordMap, err := buildInsertRecordMap(db.dialect, db.converters, table, record, info, record.Interface(), db.generators())
+			if err != nil {
+				return newBatchError("InsertMany", []int{i}, err)
+			}
+
+			shape := strings.Join(sortedKeys(recordMap), ",")
+			if _, found := recordsByShape[shape]; !found {
+				shapes = append(shapes, shape)
+			}
+			recordsByShape[shape] = append(recordsByShape[shape], recordMap)
+			indexesByShape[shape] = append(indexesByShape[shape], i)
+		}
+
+		for _, shape := range shapes {
+			columns := strings.Split(shape, ",")
+			group := recordsByShape[shape]
+			indexes := indexesByShape[shape]
+
+			if useAppender {
+				rows := make([][]interface{}, len(group))
+				for i, recordMap := range group {
+					rows[i] = buildInsertRowValues(c.dialect, info, columns, recordMap)
+				}
+
+				err := appender.AppendRows(ctx, table.qualifiedName(), columns, rows)
+				if err != nil {
+					return newBatchError("InsertMany", indexes, err)
+				}
+				continue
+			}
+
+			batchSize := maxParamsPerStatement / len(columns)
+			if batchSize < 1 {
+				batchSize = 1
+			}
+
+			for start := 0; start < len(group); start += batchSize {
+				end := start + batchSize
+				if end > len(group) {
+					end = len(group)
+				}
+
+				query, params := buildMultiRowInsertQuery(c.dialect, table, info, columns, group[start:end])
+				query, err := addStatementHints(c.dialect, table, query, o)
+				if err != nil {
+					return err
+				}
+
+				result, err := db.execContext(ctx, op, o, query, params...)
+				if err == errDryRun {
+					continue
+				}
+				if err != nil {
+					return newBatchError("InsertMany", indexes[start:end], err)
+				}
+
+				if idName == "" || containsString(columns, idName) {
+					continue
+				}
+
+				if idIncrement == 0 {
+					idIncrement, err = db.getAutoIncrementIncrement(ctx, op, o)
+					if err != nil {
+						return newBatchError("InsertMany", indexes[start:end], err)
+					}
+				}
+
+				err = setConsecutiveIDs(result, v, info, indexes[start:end], idName, idIncrement)
+				if err != nil {
+					return newBatchError("InsertMany", indexes[start:end], err)
+				}
+			}
+		}
+
+		return nil
+	}
+
+	if useAppender {
+		return insertAll(c)
+	}
+
+	return c.Transaction(ctx, func(db Provider) error {
+		return insertAll(db.(DB))
+	})
+}
+
+// hasConsecutiveIDs tells if the dialect was configured
+// with the WithMySQLConsecutiveIDs option
+func hasConsecutiveIDs(dialect Dialect) bool {
+	d, ok := dialect.(*mysqlDialect)
+	return ok && d.consecutiveIDs
+}
+
+// getAutoIncrementIncrement reads the step between the IDs
+// generated for the AUTO_INCREMENT columns on the current session.
+func (c DB) getAutoIncrementIncrement(ctx context.Context, op OpInfo, opts queryOptions) (int64, error) {
+	rows, err := c.queryContext(ctx, op, opts, "SELECT @@auto_increment_increment")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		err := fmt.Errorf("ksql: unexpected error when reading @@auto_increment_increment")
+		if rows.Err() != nil {
+			err = rows.Err()
+		}
+		return 0, err
+	}
+
+	var increment int64
+	err = rows.Scan(&increment)
+	if err != nil {
+		return 0, err
+	}
+	if increment < 1 {
+		return 0, fmt.Errorf("ksql: unexpected value for @@auto_increment_increment: %d", increment)
+	}
+
+	return increment, rows.Close()
+}
+
+// setConsecutiveIDs writes the IDs generated by a multi-row INSERT into
+// the inserted records, given that the first of them is the LAST_INSERT_ID()
+// of the statement and that the next ones are separated by the increment.
+func setConsecutiveIDs(
+	result Result,
+	records reflect.Value,
+	info structs.StructInfo,
+	indexes []int,
+	idName string,
+	increment int64,
+) error {
+	firstID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != int64(len(indexes)) {
+		return fmt.Errorf(
+			"ksql: can't compute the IDs of the inserted records: expected %d rows to be affected but got %d",
+			len(indexes), n,
+		)
+	}
+
+	idIndex := info.ByName(idName).Index
+	for i, idx := range indexes {
+		attr := records.Index(idx).Elem().Field(idIndex)
+		err := setIDAttr(attr, idName, firstID+int64(i)*increment)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildMultiRowInsertQuery builds a query of the form:
+//
+//	INSERT INTO t (a, b) VALUES (?, ?), (?, ?)
+func buildMultiRowInsertQuery(
+	dialect Dialect,
+	table Table,
+	info structs.StructInfo,
+	columns []string,
+	records []map[string]interface{},
+) (query string, params []interface{}) {
+	escapedColumns := make([]string, len(columns))
+	for i, col := range columns {
+		escapedColumns[i] = dialect.Escape(col)
+	}
+
+	rows := make([]string, len(records))
+	for i, recordMap := range records {
+		placeholders := make([]string, len(columns))
+		for j := range columns {
+			placeholders[j] = dialect.Placeholder(len(params) + j)
+		}
+		params = append(params, buildInsertRowValues(dialect, info, columns, recordMap)...)
+		rows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	query = fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES %s",
+		table.escapedName(dialect),
+		strings.Join(escapedColumns, ", "),
+		strings.Join(rows, ", "),
+	)
+
+	return query, params
+}
+
+// buildInsertRowValues returns the values of the input columns
+// in the format expected by the drivers, e.g. serialized as JSON.
+func buildInsertRowValues(
+	dialect Dialect,
+	info structs.StructInfo,
+	columns []string,
+	recordMap map[string]interface{},
+) []interface{} {
+	values := make([]interface{}, len(columns))
+	for i, col := range columns {
+		values[i] = recordMap[col]
+		if info.ByName(col).SerializeAsJSON {
+			values[i] = jsonSerializable{
+				Dialect:   dialect,
+				Attr:      recordMap[col],
+				NilAsNull: info.ByName(col).NilAsNull,
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,160 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// insertOrGetAttempts limits how many times InsertOrGet retries when the
+// conflicting row is deleted by a concurrent transaction before it is read.
+const insertOrGetAttempts = 3
+
+// InsertOrGet inserts the record or, if the insert violates the unique
+// constraint covering the input columns, loads the existing row into the
+// record instead, which is the classic "get or create" operation:
+//
+//	user := User{Email: "bia@example.com", Name: "Bia"}
+//	inserted, err := db.InsertOrGet(ctx, usersTable, &user, "email")
+//
+// If no columns are given the ID columns of the table are used.
+//
+// It is safe to call it concurrently for the same values since it relies on
+// the unique constraint of the database: the insert runs inside a savepoint,
+// so a violation doesn't abort the surrounding transaction, and on mysql the
+// existing row is read with `FOR UPDATE` so it is visible even if it was
+// committed after the snapshot of the transaction was taken.
+//
+// Since snowflake and bigquery don't enforce unique constraints it
+// returns ErrNotSupported for these dialects.
+func (c DB) InsertOrGet(ctx context.Context, table Table, record interface{}, conflictColumns ...string) (inserted bool, err error) {
+	if !capabilitiesOf(c.dialect).Savepoints {
+		return false, fmt.Errorf("%w: InsertOrGet is not supported by the %s dialect since it doesn't enforce unique constraints", ErrNotSupported, c.dialect.DriverName())
+	}
+
+	if err := table.validate(); err != nil {
+		return false, fmt.Errorf("can't insert in ksql.Table: %s", err)
+	}
+
+	v := reflect.ValueOf(record)
+	t := v.Type()
+	if err := assertStructPtr(t); err != nil {
+		return false, fmt.Errorf(
+			"ksql: expected record to be a pointer to struct, but got: %T",
+			record,
+		)
+	}
+
+	if v.IsNil() {
+		return false, fmt.Errorf("ksql: expected a valid pointer to struct as argument but received a nil pointer: %v", record)
+	}
+
+	info, err := structs.GetTagInfo(t.Elem())
+	if err != nil {
+		return false, err
+	}
+
+	if len(conflictColumns) == 0 {
+		conflictColumns = table.idColumns
+	}
+	for _, col := range conflictColumns {
+		if !info.ByName(col).Valid {
+			return false, fmt.Errorf("ksql: the conflict column `%s` has no matching attribute on the record type %T", col, record)
+		}
+	}
+
+	savepoint, rollback, release := savepointStatements(c.dialect)
+
+	err = c.Transaction(ctx, func(provider Provider) error {
+		db := provider.(DB)
+		op := OpInfo{Method: "InsertOrGet", TableName: table.name}
+		exec := func(query string) error {
+			if query == "" {
+				return nil
+			}
+			_, err := db.execContext(ctx, op, queryOptions{}, query)
+			return err
+		}
+
+		for attempt := 0; attempt < insertOrGetAttempts; attempt++ {
+			if err := exec(savepoint); err != nil {
+				return err
+			}
+
+			err := db.Insert(ctx, table, record)
+			if err == nil {
+				inserted = true
+				return exec(release)
+			}
+
+			if ClassifyConstraintError(err) != UniqueViolation {
+				return err
+			}
+
+			if err := exec(rollback); err != nil {
+				return err
+			}
+			if err := exec(release); err != nil {
+				return err
+			}
+
+			query, params, err := buildInsertOrGetQuery(db.dialect, db.converters, table, info, record, conflictColumns)
+			if err != nil {
+				return err
+			}
+
+			err = db.QueryOne(ctx, record, query, params...)
+			if err != ErrRecordNotFound {
+				return err
+			}
+
+			// The conflicting row was deleted before we could read it,
+			// so the insert might succeed now:
+		}
+
+		return fmt.Errorf("ksql: InsertOrGet failed to insert or read the record after %d attempts due to concurrent changes", insertOrGetAttempts)
+	})
+
+	return inserted, err
+}
+
+// buildInsertOrGetQuery builds the query for loading the row that
+// has the same values as the record on the conflict columns.
+func buildInsertOrGetQuery(
+	dialect Dialect,
+	converters map[string]Converter,
+	table Table,
+	info structs.StructInfo,
+	record interface{},
+	conflictColumns []string,
+) (query string, params []interface{}, err error) {
+	recordMap, err := structs.StructToMap(record)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := encodeColumnValues(dialect, converters, info, recordMap); err != nil {
+		return "", nil, err
+	}
+
+	conditions := make([]string, len(conflictColumns))
+	for i, col := range conflictColumns {
+		value, found := recordMap[col]
+		if !found {
+			return "", nil, fmt.Errorf("ksql: the conflict column `%s` can't be NULL", col)
+		}
+
+		conditions[i] = dialect.Escape(col) + " = " + dialect.Placeholder(i)
+		params = append(params, value)
+	}
+
+	query = "FROM " + table.escapedName(dialect) + " WHERE " + strings.Join(conditions, " AND ")
+	if capabilitiesOf(dialect).SnapshotReads {
+		query += rowLockClause(dialect)
+	}
+
+	return query, params, nil
+}
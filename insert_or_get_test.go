@@ -0,0 +1,103 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestInsertOrGet(t *testing.T) {
+	type userRecord struct {
+		ID    int    `ksql:"id"`
+		Email string `ksql:"email"`
+		Name  string `ksql:"name"`
+	}
+
+	newAdapter := func(queries *[]string, insertErr error, rows ...[]interface{}) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+				*queries = append(*queries, q)
+				if len(args) > 0 && insertErr != nil {
+					return nil, insertErr
+				}
+				return NewMockResult(42, 1), nil
+			},
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				*queries = append(*queries, q)
+				if len(rows) == 0 {
+					return newMockRows([]string{"id", "email", "name"}), nil
+				}
+				return newMockRows([]string{"id", "email", "name"}, rows...), nil
+			},
+		}
+	}
+
+	uniqueErr := errors.New("Error 1062: Duplicate entry 'bia@example.com' for key 'email'")
+
+	t.Run("should insert the record if there is no conflict", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "mysql", newAdapter(&queries, nil))
+
+		user := userRecord{Email: "bia@example.com", Name: "Bia"}
+		inserted, err := db.InsertOrGet(context.Background(), usersTable, &user, "email")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, inserted, true)
+		tt.AssertEqual(t, user.ID, 42)
+		tt.AssertEqual(t, queries, []string{
+			"SAVEPOINT ksql_record",
+			"INSERT INTO `users` (`email`, `name`) VALUES (?, ?)",
+			"RELEASE SAVEPOINT ksql_record",
+		})
+	})
+
+	t.Run("should load the existing record on unique violations", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "mysql", newAdapter(&queries, uniqueErr, []interface{}{7, "bia@example.com", "Bia Existing"}))
+
+		user := userRecord{Email: "bia@example.com", Name: "Bia"}
+		inserted, err := db.InsertOrGet(context.Background(), usersTable, &user, "email")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, inserted, false)
+		tt.AssertEqual(t, user, userRecord{ID: 7, Email: "bia@example.com", Name: "Bia Existing"})
+		tt.AssertEqual(t, queries, []string{
+			"SAVEPOINT ksql_record",
+			"INSERT INTO `users` (`email`, `name`) VALUES (?, ?)",
+			"ROLLBACK TO SAVEPOINT ksql_record",
+			"RELEASE SAVEPOINT ksql_record",
+			"SELECT `id`, `email`, `name` FROM `users` WHERE `email` = ? FOR UPDATE",
+		})
+	})
+
+	t.Run("should give up if the conflicting record keeps disappearing", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "sqlite3", newAdapter(&queries, errors.New("UNIQUE constraint failed: users.email")))
+
+		_, err := db.InsertOrGet(context.Background(), usersTable, &userRecord{Email: "bia@example.com"}, "email")
+		tt.AssertErrContains(t, err, "after 3 attempts")
+		tt.AssertEqual(t, queries[len(queries)-1], "SELECT `id`, `email`, `name` FROM `users` WHERE `email` = ?")
+	})
+
+	t.Run("should return other errors", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "mysql", newAdapter(&queries, errors.New("fake-insert-error")))
+
+		_, err := db.InsertOrGet(context.Background(), usersTable, &userRecord{Email: "bia@example.com"}, "email")
+		tt.AssertErrContains(t, err, "fake-insert-error")
+	})
+
+	t.Run("should report invalid conflict columns", func(t *testing.T) {
+		db := newDB(t, "mysql", newAdapter(new([]string), nil))
+
+		_, err := db.InsertOrGet(context.Background(), usersTable, &userRecord{Email: "bia@example.com"}, "missing")
+		tt.AssertErrContains(t, err, "missing")
+	})
+
+	t.Run("should not be supported on dialects without unique constraints", func(t *testing.T) {
+		db := newDB(t, "bigquery", newAdapter(new([]string), nil))
+
+		_, err := db.InsertOrGet(context.Background(), usersTable, &userRecord{Email: "bia@example.com"}, "email")
+		tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+	})
+}
@@ -0,0 +1,141 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// InsertManySkipErrors inserts the records of the input slice, which must be
+// a slice of pointers to structs or a pointer to it, skipping the records that
+// violate the constraints of the table, e.g. duplicated unique keys, and
+// returning the errors of the skipped records, which is useful for tolerant
+// import jobs:
+//
+//	failures, err := db.InsertManySkipErrors(ctx, usersTable, &users)
+//	if err != nil {
+//		return err
+//	}
+//	for _, failure := range failures {
+//		log.Printf("skipped user %d: %s", failure.Index, failure)
+//	}
+//
+// The records are inserted inside a single transaction with a savepoint
+// around each insert, so a failed insert doesn't abort the transaction. On
+// the dialects without savepoints, i.e. snowflake and bigquery, each record
+// is inserted by its own statement without a transaction instead.
+//
+// If an insert fails for any other reason, e.g. a connection error, it stops
+// and returns the error, and unless the dialect has no savepoints none of the
+// records are saved.
+func (c DB) InsertManySkipErrors(ctx context.Context, table Table, records interface{}, opts ...QueryOption) ([]RecordError, error) {
+	v := reflect.ValueOf(records)
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Slice {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Slice || assertStructPtr(v.Type().Elem()) != nil {
+		return nil, fmt.Errorf("ksql: expected records to be a slice of pointers to structs, but got: %T", records)
+	}
+
+	if v.Len() == 0 {
+		return nil, nil
+	}
+
+	opts = append(opts, batchOperation)
+	if !capabilitiesOf(c.dialect).Savepoints {
+		return insertSkippingErrors(v, func(record interface{}) error {
+			return c.insert(ctx, table, record, opts)
+		})
+	}
+
+	savepoint, rollback, release := savepointStatements(c.dialect)
+
+	var failures []RecordError
+	err := c.Transaction(ctx, func(provider Provider) (err error) {
+		db := provider.(DB)
+		op := OpInfo{Method: "InsertManySkipErrors", TableName: table.name}
+		o := newQueryOptions(opts)
+
+		exec := func(query string) error {
+			if query == "" {
+				return nil
+			}
+			_, err := db.execContext(ctx, op, o, query)
+			if err == errDryRun {
+				return nil
+			}
+			return err
+		}
+
+		failures, err = insertSkippingErrors(v, func(record interface{}) error {
+			if err := exec(savepoint); err != nil {
+				return err
+			}
+
+			insertErr := db.insert(ctx, table, record, opts)
+			if insertErr != nil {
+				if ClassifyConstraintError(insertErr) == NoConstraint {
+					return insertErr
+				}
+
+				if err := exec(rollback); err != nil {
+					return err
+				}
+			}
+
+			if err := exec(release); err != nil {
+				return err
+			}
+
+			return insertErr
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return failures, nil
+}
+
+// insertSkippingErrors calls insert for each record, collecting the
+// constraint violations and stopping on any other error.
+func insertSkippingErrors(v reflect.Value, insert func(record interface{}) error) ([]RecordError, error) {
+	var failures []RecordError
+	for i := 0; i < v.Len(); i++ {
+		record := v.Index(i)
+		if record.IsNil() {
+			return nil, newBatchError("InsertManySkipErrors", []int{i}, fmt.Errorf("ksql: expected a valid pointer to struct as argument but received a nil pointer"))
+		}
+
+		err := insert(record.Interface())
+		if err == nil {
+			continue
+		}
+
+		constraint := ClassifyConstraintError(err)
+		if constraint == NoConstraint {
+			return nil, newBatchError("InsertManySkipErrors", []int{i}, err)
+		}
+
+		failures = append(failures, RecordError{
+			Index:      i,
+			Constraint: constraint,
+			Err:        err,
+		})
+	}
+
+	return failures, nil
+}
+
+// savepointStatements returns the statements for creating a savepoint,
+// rolling back to it and releasing it, the last one is empty on sqlserver
+// since its savepoints are released with the transaction.
+func savepointStatements(dialect Dialect) (savepoint string, rollback string, release string) {
+	if capabilitiesOf(dialect).SaveTransaction {
+		return "SAVE TRANSACTION ksql_record", "ROLLBACK TRANSACTION ksql_record", ""
+	}
+
+	return "SAVEPOINT ksql_record", "ROLLBACK TO SAVEPOINT ksql_record", "RELEASE SAVEPOINT ksql_record"
+}
@@ -0,0 +1,101 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestInsertManySkipErrors(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newAdapter := func(queries *[]string, execErrs map[string]error) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+				*queries = append(*queries, q)
+				if len(args) > 0 {
+					if err := execErrs[args[len(args)-1].(string)]; err != nil {
+						return nil, err
+					}
+				}
+				return NewMockResult(42, 1), nil
+			},
+		}
+	}
+
+	t.Run("should skip the records violating constraints using savepoints", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "mysql", newAdapter(&queries, map[string]error{
+			"fake-name2": errors.New("Error 1062: Duplicate entry 'fake-name2' for key 'name'"),
+		}))
+
+		users := []*userRecord{{Name: "fake-name1"}, {Name: "fake-name2"}, {Name: "fake-name3"}}
+		failures, err := db.InsertManySkipErrors(context.Background(), usersTable, &users)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(failures), 1)
+		tt.AssertEqual(t, failures[0].Index, 1)
+		tt.AssertEqual(t, failures[0].Constraint, UniqueViolation)
+		tt.AssertEqual(t, queries, []string{
+			"SAVEPOINT ksql_record",
+			"INSERT INTO `users` (`name`) VALUES (?)",
+			"RELEASE SAVEPOINT ksql_record",
+			"SAVEPOINT ksql_record",
+			"INSERT INTO `users` (`name`) VALUES (?)",
+			"ROLLBACK TO SAVEPOINT ksql_record",
+			"RELEASE SAVEPOINT ksql_record",
+			"SAVEPOINT ksql_record",
+			"INSERT INTO `users` (`name`) VALUES (?)",
+			"RELEASE SAVEPOINT ksql_record",
+		})
+		tt.AssertEqual(t, users[0].ID, 42)
+		tt.AssertEqual(t, users[2].ID, 42)
+	})
+
+	t.Run("should use SAVE TRANSACTION on sqlserver", func(t *testing.T) {
+		savepoint, rollback, release := savepointStatements(supportedDialects["sqlserver"])
+		tt.AssertEqual(t, savepoint, "SAVE TRANSACTION ksql_record")
+		tt.AssertEqual(t, rollback, "ROLLBACK TRANSACTION ksql_record")
+		tt.AssertEqual(t, release, "")
+	})
+
+	t.Run("should insert each record by its own statement on dialects without savepoints", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "snowflake", newAdapter(&queries, map[string]error{
+			"fake-name1": errors.New("NULL result in a non-nullable column: NOT NULL constraint"),
+		}))
+
+		failures, err := db.InsertManySkipErrors(context.Background(), usersTable, []*userRecord{{Name: "fake-name1"}, {Name: "fake-name2"}})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(failures), 1)
+		tt.AssertEqual(t, failures[0].Index, 0)
+		tt.AssertEqual(t, failures[0].Constraint, NotNullViolation)
+		tt.AssertEqual(t, queries, []string{
+			`INSERT INTO "users" ("name") VALUES (?)`,
+			`INSERT INTO "users" ("name") VALUES (?)`,
+		})
+	})
+
+	t.Run("should stop on errors that are not constraint violations", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "sqlite3", newAdapter(&queries, map[string]error{
+			"fake-name1": errors.New("fake-connection-error"),
+		}))
+
+		failures, err := db.InsertManySkipErrors(context.Background(), usersTable, []*userRecord{{Name: "fake-name1"}, {Name: "fake-name2"}})
+		tt.AssertErrContains(t, err, "record 0", "fake-connection-error")
+		tt.AssertEqual(t, len(failures), 0)
+		tt.AssertEqual(t, len(queries), 2)
+	})
+
+	t.Run("should report invalid records", func(t *testing.T) {
+		db := newDB(t, "postgres", mockDBAdapter{})
+
+		_, err := db.InsertManySkipErrors(context.Background(), usersTable, []userRecord{{Name: "fake-name"}})
+		tt.AssertErrContains(t, err, "slice of pointers to structs")
+	})
+}
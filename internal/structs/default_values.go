@@ -0,0 +1,132 @@
+package structs
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	scannerType  = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// Generators provides the values of the `default=now` and
+// `default=newid` modifiers, so they can be replaced on tests.
+type Generators struct {
+	Now   func() time.Time
+	NewID func() string
+}
+
+// parseDefaultValue parses the literal of the `default=<literal>` modifier
+// and returns a function that builds a value of type t from it.
+//
+// The literal is parsed only once, except for the special values
+// `now` for time.Time fields and `newid` for string fields, which
+// are evaluated each time using the input Generators.
+func parseDefaultValue(t reflect.Type, literal string) (func(gen Generators) reflect.Value, error) {
+	isPtr := t.Kind() == reflect.Ptr
+	if isPtr {
+		t = t.Elem()
+	}
+
+	var fn func(gen Generators) reflect.Value
+	switch {
+	case t == timeType && literal == "now":
+		fn = func(gen Generators) reflect.Value {
+			return reflect.ValueOf(gen.Now())
+		}
+	case t.Kind() == reflect.String && literal == "newid":
+		fn = func(gen Generators) reflect.Value {
+			v := reflect.New(t).Elem()
+			v.SetString(gen.NewID())
+			return v
+		}
+	default:
+		v, err := parseLiteral(t, literal)
+		if err != nil {
+			return nil, err
+		}
+		fn = func(gen Generators) reflect.Value {
+			return v
+		}
+	}
+
+	if !isPtr {
+		return fn, nil
+	}
+
+	return func(gen Generators) reflect.Value {
+		ptr := reflect.New(t)
+		ptr.Elem().Set(fn(gen))
+		return ptr
+	}, nil
+}
+
+func parseLiteral(t reflect.Type, literal string) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+
+	if t == timeType {
+		parsed, err := time.Parse(time.RFC3339, literal)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("expected `now` or a RFC3339 timestamp but got '%s'", literal)
+		}
+		v.Set(reflect.ValueOf(parsed))
+		return v, nil
+	}
+
+	if t == durationType {
+		parsed, err := time.ParseDuration(literal)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetInt(int64(parsed))
+		return v, nil
+	}
+
+	// Types like decimals and dates usually know how to parse
+	// themselves from the string received from the database:
+	if reflect.PtrTo(t).Implements(scannerType) {
+		err := v.Addr().Interface().(sql.Scanner).Scan(literal)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(literal)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(literal)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(literal, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(literal, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(literal, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetFloat(parsed)
+	default:
+		return reflect.Value{}, fmt.Errorf("default values are not supported for attributes of type %v", t)
+	}
+
+	return v, nil
+}
@@ -1,10 +1,15 @@
 package structs
 
 import (
+	"bytes"
+	"database/sql/driver"
+	"encoding"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 )
 
 // StructInfo stores metainformation of the struct
@@ -24,6 +29,52 @@ type FieldInfo struct {
 	Index           int
 	Valid           bool
 	SerializeAsJSON bool
+
+	// NilAsNull json fields are saved as NULL instead
+	// of the JSON `null` when they are nil slices or maps.
+	NilAsNull bool
+
+	// HasDefault is true for fields using the `default` modifier,
+	// if DefaultValue is nil the default is left for the database.
+	HasDefault   bool
+	DefaultValue func(gen Generators) reflect.Value
+
+	// Immutable fields are written on inserts but never on updates.
+	Immutable bool
+
+	// Generated fields are computed by the database, so
+	// they are never written, only read.
+	Generated bool
+
+	// Blob fields are io.Reader attributes that are read when
+	// writing and loaded into a *bytes.Reader when scanned.
+	Blob bool
+
+	// Decimal fields are sent to and read from the
+	// database as decimal strings instead of floats.
+	Decimal bool
+
+	// Hstore fields are maps stored on postgres hstore columns.
+	Hstore bool
+
+	// Duration fields are time.Duration attributes stored as
+	// intervals on postgres and as integer counts of the
+	// DurationUnit on the other databases.
+	Duration     bool
+	DurationUnit time.Duration
+
+	// Trim fields are strings loaded without the trailing
+	// spaces used for padding the CHAR(n) columns.
+	Trim bool
+
+	// SQLType is the type of the column set with the `sqltype` modifier,
+	// which is used for sending the values with the same type of the
+	// column, currently only on sqlserver.
+	SQLType string
+
+	// Converter is the name of the converter set with the `converter`
+	// modifier, which is registered on the client with WithConverter.
+	Converter string
 }
 
 // ByIndex returns either the *FieldInfo of a valid
@@ -130,22 +181,71 @@ func StructToMap(obj interface{}) (map[string]interface{}, error) {
 			continue
 		}
 
-		field := v.Field(i)
-		ft := field.Type()
-		if ft.Kind() == reflect.Ptr {
-			if field.IsNil() {
-				continue
-			}
-
-			field = field.Elem()
+		value, ok := FieldValue(v.Field(i))
+		if !ok {
+			continue
 		}
 
-		m[fieldInfo.Name] = field.Interface()
+		m[fieldInfo.Name] = value
 	}
 
 	return m, nil
 }
 
+// FieldValue returns the value of a struct field as it is copied by
+// StructToMap, dereferencing pointers, or false for nil pointers.
+//
+// The sql.Null* types with Valid set to false are treated like nil
+// pointers, so models written for database/sql behave the same way.
+func FieldValue(field reflect.Value) (interface{}, bool) {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil, false
+		}
+		field = field.Elem()
+	}
+
+	if isInvalidSQLNull(field) {
+		return nil, false
+	}
+
+	return field.Interface(), true
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// DriverValue returns the value as it should be sent to the database.
+//
+// Since the drivers only recognize the driver.Valuer implementations
+// with pointer receivers when they receive a pointer, the values of
+// these types are returned as pointers to a copy of the value.
+func DriverValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(value)
+	if t.Kind() == reflect.Ptr || t.Implements(valuerType) || !reflect.PtrTo(t).Implements(valuerType) {
+		return value
+	}
+
+	ptr := reflect.New(t)
+	ptr.Elem().Set(reflect.ValueOf(value))
+	return ptr.Interface()
+}
+
+// isInvalidSQLNull tells if the value is one of the sql.Null*
+// types, e.g. sql.NullString or sql.NullTime, with Valid set to false
+func isInvalidSQLNull(v reflect.Value) bool {
+	t := v.Type()
+	if t.Kind() != reflect.Struct || t.PkgPath() != "database/sql" || !strings.HasPrefix(t.Name(), "Null") {
+		return false
+	}
+
+	valid := v.FieldByName("Valid")
+	return valid.IsValid() && valid.Kind() == reflect.Bool && !valid.Bool()
+}
+
 // PtrConverter was created to make it easier
 // to handle conversion between ptr and non ptr types, e.g.:
 //
@@ -227,6 +327,156 @@ func (p PtrConverter) Convert(destType reflect.Type) (reflect.Value, error) {
 	return destValue, nil
 }
 
+var (
+	readerType          = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	bytesReaderType     = reflect.TypeOf(&bytes.Reader{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isBlobType checks if the type is an interface that can both be
+// read from and receive the *bytes.Reader created when scanning.
+func isBlobType(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface &&
+		t.Implements(readerType) &&
+		bytesReaderType.AssignableTo(t)
+}
+
+// isDurationType checks if the type, or the type it points to, is a time.Duration
+func isDurationType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == durationType
+}
+
+// durationUnits are the units accepted by the `duration=<unit>` modifier
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// parseDurationUnit parses the part of the modifier after
+// `duration`, which is either empty or `=<unit>`
+func parseDurationUnit(s string) (time.Duration, error) {
+	if s == "" {
+		return time.Second, nil
+	}
+
+	unit, found := durationUnits[strings.TrimPrefix(s, "=")]
+	if !found {
+		return 0, fmt.Errorf("expected one of the units ns, us, ms, s, m or h but got '%s'", strings.TrimPrefix(s, "="))
+	}
+	return unit, nil
+}
+
+// isStringType checks if the type, or the type it points to, is based on string
+func isStringType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String
+}
+
+// sqlTypes are the types accepted by the `sqltype` modifier,
+// grouped by the kind of attribute they can be used with.
+var sqlTypes = map[string]string{
+	"varchar":        "string",
+	"varchar(max)":   "string",
+	"nvarchar(max)":  "string",
+	"datetime":       "time",
+	"datetime2":      "time",
+	"datetimeoffset": "time",
+	"date":           "time",
+}
+
+// validateSQLType checks if the type of the column set with the
+// `sqltype` modifier is supported for the type of the attribute
+func validateSQLType(t reflect.Type, sqlType string) error {
+	kind, found := sqlTypes[sqlType]
+	if !found {
+		return fmt.Errorf("expected one of varchar, varchar(max), nvarchar(max), datetime, datetime2, datetimeoffset or date but got '%s'", sqlType)
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if kind == "string" && t.Kind() != reflect.String {
+		return fmt.Errorf("the type '%s' requires a string or a *string, but got %v", sqlType, t)
+	}
+	if kind == "time" && t != timeType {
+		return fmt.Errorf("the type '%s' requires a time.Time or a *time.Time, but got %v", sqlType, t)
+	}
+	return nil
+}
+
+// isDecimalType checks if the type, or the type it points to,
+// can be converted from and to a decimal string.
+func isDecimalType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	ptrType := reflect.PtrTo(t)
+	if ptrType.Implements(textMarshalerType) && ptrType.Implements(textUnmarshalerType) {
+		return true
+	}
+	return t.Kind() == reflect.String
+}
+
+// isHstoreType checks if the type is a map[string]string or a
+// map[string]*string, where nil pointers represent NULL values.
+func isHstoreType(t reflect.Type) bool {
+	if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+		return false
+	}
+
+	elem := t.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.String
+}
+
+// TagError is returned when the `ksql` or `tablename` tags
+// of a struct are invalid, it describes where the error is
+// so the callers can report it with more context.
+type TagError struct {
+	// StructType is the type of the struct with the invalid tags
+	StructType reflect.Type
+
+	// Field is the name of the invalid attribute, it is empty
+	// if the error is not related to a single attribute.
+	Field string
+
+	Err error
+}
+
+func newTagError(t reflect.Type, field string, err error) TagError {
+	return TagError{
+		StructType: t,
+		Field:      field,
+		Err:        err,
+	}
+}
+
+func (e TagError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid tags on %v: %s", e.StructType, e.Err)
+	}
+	return fmt.Sprintf("invalid tags on %v.%s: %s", e.StructType, e.Field, e.Err)
+}
+
+// Unwrap returns the error describing the invalid tag
+func (e TagError) Unwrap() error {
+	return e.Err
+}
+
 // This function collects only the names
 // that will be used from the input type.
 //
@@ -240,7 +490,7 @@ func getTagNames(t reflect.Type) (StructInfo, error) {
 	for i := 0; i < t.NumField(); i++ {
 		// If this field is private:
 		if t.Field(i).PkgPath != "" {
-			return StructInfo{}, fmt.Errorf("all fields using the ksql tags must be exported, but %v is unexported", t)
+			return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf("all fields using the ksql tags must be exported, but %v is unexported", t))
 		}
 
 		name := t.Field(i).Tag.Get("ksql")
@@ -249,24 +499,106 @@ func getTagNames(t reflect.Type) (StructInfo, error) {
 		}
 
 		tags := strings.Split(name, ",")
-		serializeAsJSON := false
-		if len(tags) > 1 {
-			name = tags[0]
-			serializeAsJSON = tags[1] == "json"
+		name = tags[0]
+		field := FieldInfo{
+			Name:  name,
+			Index: i,
+		}
+		for _, modifier := range tags[1:] {
+			switch {
+			case modifier == "json":
+				field.SerializeAsJSON = true
+			case modifier == "nilasnull":
+				field.NilAsNull = true
+			case modifier == "immutable":
+				field.Immutable = true
+			case modifier == "generated":
+				field.Generated = true
+			case modifier == "blob":
+				if !isBlobType(t.Field(i).Type) {
+					return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf(
+						"the blob modifier requires an io.Reader interface compatible with *bytes.Reader, but attribute '%s' has type %v",
+						name, t.Field(i).Type,
+					))
+				}
+				field.Blob = true
+			case modifier == "decimal":
+				if !isDecimalType(t.Field(i).Type) {
+					return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf(
+						"the decimal modifier requires a string or a type implementing encoding.TextMarshaler and encoding.TextUnmarshaler, but attribute '%s' has type %v",
+						name, t.Field(i).Type,
+					))
+				}
+				field.Decimal = true
+			case modifier == "hstore":
+				if !isHstoreType(t.Field(i).Type) {
+					return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf(
+						"the hstore modifier requires a map[string]string or a map[string]*string, but attribute '%s' has type %v",
+						name, t.Field(i).Type,
+					))
+				}
+				field.Hstore = true
+			case modifier == "duration" || strings.HasPrefix(modifier, "duration="):
+				if !isDurationType(t.Field(i).Type) {
+					return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf(
+						"the duration modifier requires a time.Duration or a *time.Duration, but attribute '%s' has type %v",
+						name, t.Field(i).Type,
+					))
+				}
+				unit, err := parseDurationUnit(strings.TrimPrefix(modifier, "duration"))
+				if err != nil {
+					return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf("invalid duration modifier for attribute '%s': %w", name, err))
+				}
+				field.Duration = true
+				field.DurationUnit = unit
+			case modifier == "trim":
+				if !isStringType(t.Field(i).Type) {
+					return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf(
+						"the trim modifier requires a string or a *string, but attribute '%s' has type %v",
+						name, t.Field(i).Type,
+					))
+				}
+				field.Trim = true
+			case strings.HasPrefix(modifier, "sqltype="):
+				sqlType := strings.TrimPrefix(modifier, "sqltype=")
+				err := validateSQLType(t.Field(i).Type, sqlType)
+				if err != nil {
+					return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf("invalid sqltype modifier for attribute '%s': %w", name, err))
+				}
+				field.SQLType = sqlType
+			case strings.HasPrefix(modifier, "converter="):
+				converter := strings.TrimPrefix(modifier, "converter=")
+				if converter == "" {
+					return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf("missing the name of the converter for attribute '%s'", name))
+				}
+				field.Converter = converter
+			case modifier == "default":
+				field.HasDefault = true
+			case strings.HasPrefix(modifier, "default="):
+				defaultValue, err := parseDefaultValue(t.Field(i).Type, strings.TrimPrefix(modifier, "default="))
+				if err != nil {
+					return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf("invalid default value for attribute '%s': %w", name, err))
+				}
+				field.HasDefault = true
+				field.DefaultValue = defaultValue
+			}
+		}
+
+		if field.NilAsNull && !field.SerializeAsJSON {
+			return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf(
+				"the nilasnull modifier can only be used together with the json modifier, but attribute '%s' has no json modifier",
+				name,
+			))
 		}
 
 		if _, found := info.byName[name]; found {
-			return StructInfo{}, fmt.Errorf(
+			return StructInfo{}, newTagError(t, t.Field(i).Name, fmt.Errorf(
 				"struct contains multiple attributes with the same ksql tag name: '%s'",
 				name,
-			)
+			))
 		}
 
-		info.add(FieldInfo{
-			Name:            name,
-			Index:           i,
-			SerializeAsJSON: serializeAsJSON,
-		})
+		info.add(field)
 	}
 
 	// If there were `ksql` tags present, then we are finished:
@@ -289,7 +621,7 @@ func getTagNames(t reflect.Type) (StructInfo, error) {
 	}
 
 	if len(info.byIndex) == 0 {
-		return StructInfo{}, fmt.Errorf("the struct must contain at least one attribute with the ksql tag")
+		return StructInfo{}, newTagError(t, "", fmt.Errorf("the struct must contain at least one attribute with the ksql tag"))
 	}
 
 	info.IsNestedStruct = true
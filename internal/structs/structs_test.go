@@ -0,0 +1,337 @@
+package structs_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vingarcia/ksql/internal/structs"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestGetTagInfo(t *testing.T) {
+	now := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	gen := structs.Generators{
+		Now:   func() time.Time { return now },
+		NewID: func() string { return "fake-id" },
+	}
+
+	t.Run("should parse the default modifiers", func(t *testing.T) {
+		type record struct {
+			ID        int           `ksql:"id,default"`
+			Name      *string       `ksql:"name,default=unnamed"`
+			Score     float64       `ksql:"score,default=1.5"`
+			Active    bool          `ksql:"active,default=true"`
+			TTL       time.Duration `ksql:"ttl,default=5m"`
+			StartedAt time.Time     `ksql:"started_at,default=2021-01-02T03:04:05Z"`
+			CreatedAt *time.Time    `ksql:"created_at,json,default=now"`
+			Code      string        `ksql:"code,default=newid"`
+			UpdatedAt time.Time     `ksql:"updated_at"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, info.ByName("id").HasDefault, true)
+		tt.AssertEqual(t, info.ByName("id").DefaultValue == nil, true)
+
+		tt.AssertEqual(t, *info.ByName("name").DefaultValue(gen).Interface().(*string), "unnamed")
+		tt.AssertEqual(t, info.ByName("score").DefaultValue(gen).Interface(), 1.5)
+		tt.AssertEqual(t, info.ByName("active").DefaultValue(gen).Interface(), true)
+		tt.AssertEqual(t, info.ByName("ttl").DefaultValue(gen).Interface(), 5*time.Minute)
+		tt.AssertEqual(t, info.ByName("started_at").DefaultValue(gen).Interface(), time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC))
+
+		tt.AssertEqual(t, info.ByName("created_at").SerializeAsJSON, true)
+		tt.AssertEqual(t, *info.ByName("created_at").DefaultValue(gen).Interface().(*time.Time), now)
+		tt.AssertEqual(t, info.ByName("code").DefaultValue(gen).Interface(), "fake-id")
+
+		tt.AssertEqual(t, info.ByName("updated_at").HasDefault, false)
+	})
+
+	t.Run("should parse default values for sql.Scanner types", func(t *testing.T) {
+		type record struct {
+			Name    sql.NullString  `ksql:"name,default=unnamed"`
+			Surname *sql.NullString `ksql:"surname,default=unknown"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, info.ByName("name").DefaultValue(gen).Interface(), sql.NullString{String: "unnamed", Valid: true})
+		tt.AssertEqual(t, info.ByName("surname").DefaultValue(gen).Interface(), &sql.NullString{String: "unknown", Valid: true})
+	})
+
+	t.Run("should parse the duration modifier", func(t *testing.T) {
+		type record struct {
+			Timeout time.Duration  `ksql:"timeout,duration=ms"`
+			TTL     *time.Duration `ksql:"ttl,duration"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, info.ByName("timeout").Duration, true)
+		tt.AssertEqual(t, info.ByName("timeout").DurationUnit, time.Millisecond)
+		tt.AssertEqual(t, info.ByName("ttl").DurationUnit, time.Second)
+
+		_, err = structs.GetTagInfo(reflect.TypeOf(struct {
+			Timeout int64 `ksql:"timeout,duration"`
+		}{}))
+		tt.AssertErrContains(t, err, "duration modifier requires", "timeout")
+
+		_, err = structs.GetTagInfo(reflect.TypeOf(struct {
+			Timeout time.Duration `ksql:"timeout,duration=days"`
+		}{}))
+		tt.AssertErrContains(t, err, "days", "ns, us, ms, s, m or h")
+	})
+
+	t.Run("should parse the trim modifier", func(t *testing.T) {
+		type record struct {
+			Code     string  `ksql:"code,trim"`
+			Optional *string `ksql:"optional,trim"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, info.ByName("code").Trim, true)
+		tt.AssertEqual(t, info.ByName("optional").Trim, true)
+
+		_, err = structs.GetTagInfo(reflect.TypeOf(struct {
+			Code []byte `ksql:"code,trim"`
+		}{}))
+		tt.AssertErrContains(t, err, "trim modifier requires", "code", "[]uint8")
+	})
+
+	t.Run("should parse the sqltype modifier", func(t *testing.T) {
+		type record struct {
+			Email     string     `ksql:"email,sqltype=varchar"`
+			CreatedAt *time.Time `ksql:"created_at,sqltype=datetime"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, info.ByName("email").SQLType, "varchar")
+		tt.AssertEqual(t, info.ByName("created_at").SQLType, "datetime")
+
+		_, err = structs.GetTagInfo(reflect.TypeOf(struct {
+			Email string `ksql:"email,sqltype=text"`
+		}{}))
+		tt.AssertErrContains(t, err, "sqltype", "email", "text")
+
+		_, err = structs.GetTagInfo(reflect.TypeOf(struct {
+			CreatedAt string `ksql:"created_at,sqltype=datetime"`
+		}{}))
+		tt.AssertErrContains(t, err, "sqltype", "created_at", "time.Time")
+	})
+
+	t.Run("should parse the converter modifier", func(t *testing.T) {
+		type record struct {
+			Status int `ksql:"status,converter=statusEnum"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, info.ByName("status").Converter, "statusEnum")
+
+		_, err = structs.GetTagInfo(reflect.TypeOf(struct {
+			Status int `ksql:"status,converter="`
+		}{}))
+		tt.AssertErrContains(t, err, "converter", "status")
+	})
+
+	t.Run("should parse the immutable modifier", func(t *testing.T) {
+		type record struct {
+			ID        int       `ksql:"id"`
+			CreatedAt time.Time `ksql:"created_at,immutable,default=now"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, info.ByName("id").Immutable, false)
+		tt.AssertEqual(t, info.ByName("created_at").Immutable, true)
+		tt.AssertEqual(t, info.ByName("created_at").HasDefault, true)
+	})
+
+	t.Run("should parse the nilasnull modifier", func(t *testing.T) {
+		type record struct {
+			Tags  []string          `ksql:"tags,json"`
+			Attrs map[string]string `ksql:"attrs,json,nilasnull"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, info.ByName("tags").NilAsNull, false)
+		tt.AssertEqual(t, info.ByName("attrs").NilAsNull, true)
+		tt.AssertEqual(t, info.ByName("attrs").SerializeAsJSON, true)
+	})
+
+	t.Run("should reject the nilasnull modifier without the json modifier", func(t *testing.T) {
+		type record struct {
+			Tags []string `ksql:"tags,nilasnull"`
+		}
+
+		_, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertErrContains(t, err, "nilasnull", "json", "tags")
+	})
+
+	t.Run("should parse the generated modifier", func(t *testing.T) {
+		type record struct {
+			ID       int    `ksql:"id"`
+			FullName string `ksql:"full_name,generated"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, info.ByName("id").Generated, false)
+		tt.AssertEqual(t, info.ByName("full_name").Generated, true)
+	})
+
+	t.Run("should parse the blob modifier", func(t *testing.T) {
+		type record struct {
+			ID     int           `ksql:"id"`
+			Avatar io.Reader     `ksql:"avatar,blob"`
+			Photo  io.ReadSeeker `ksql:"photo,blob"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, info.ByName("id").Blob, false)
+		tt.AssertEqual(t, info.ByName("avatar").Blob, true)
+		tt.AssertEqual(t, info.ByName("photo").Blob, true)
+	})
+
+	t.Run("should parse the decimal modifier", func(t *testing.T) {
+		type record struct {
+			Price    string   `ksql:"price,decimal"`
+			Discount *big.Int `ksql:"discount,decimal"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, info.ByName("price").Decimal, true)
+		tt.AssertEqual(t, info.ByName("discount").Decimal, true)
+	})
+
+	t.Run("should reject the decimal modifier on float attributes", func(t *testing.T) {
+		type record struct {
+			Price float64 `ksql:"price,decimal"`
+		}
+
+		_, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertErrContains(t, err, "decimal", "price", "float64")
+	})
+
+	t.Run("should parse the hstore modifier", func(t *testing.T) {
+		type record struct {
+			Attrs    map[string]string  `ksql:"attrs,hstore"`
+			Optional map[string]*string `ksql:"optional,hstore"`
+		}
+
+		info, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, info.ByName("attrs").Hstore, true)
+		tt.AssertEqual(t, info.ByName("optional").Hstore, true)
+	})
+
+	t.Run("should reject the hstore modifier on other map types", func(t *testing.T) {
+		type record struct {
+			Attrs map[string]int `ksql:"attrs,hstore"`
+		}
+
+		_, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertErrContains(t, err, "hstore", "attrs", "map[string]int")
+	})
+
+	t.Run("should reject the blob modifier on non reader attributes", func(t *testing.T) {
+		type record struct {
+			Avatar []byte `ksql:"avatar,blob"`
+		}
+
+		_, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+		tt.AssertErrContains(t, err, "blob", "avatar")
+	})
+
+	t.Run("should report invalid default values", func(t *testing.T) {
+		tests := []struct {
+			desc               string
+			record             interface{}
+			expectErrToContain []string
+		}{
+			{
+				desc: "non numeric literal for int attribute",
+				record: struct {
+					Age int `ksql:"age,default=old"`
+				}{},
+				expectErrToContain: []string{"age", "old"},
+			},
+			{
+				desc: "overflowing literal",
+				record: struct {
+					Age int8 `ksql:"age,default=300"`
+				}{},
+				expectErrToContain: []string{"age", "300"},
+			},
+			{
+				desc: "invalid timestamp",
+				record: struct {
+					CreatedAt time.Time `ksql:"created_at,default=yesterday"`
+				}{},
+				expectErrToContain: []string{"created_at", "RFC3339", "yesterday"},
+			},
+			{
+				desc: "unsupported type",
+				record: struct {
+					Tags []string `ksql:"tags,default=foo"`
+				}{},
+				expectErrToContain: []string{"tags", "not supported", "[]string"},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				_, err := structs.GetTagInfo(reflect.TypeOf(test.record))
+				tt.AssertErrContains(t, err, test.expectErrToContain...)
+			})
+		}
+	})
+
+	t.Run("should describe where the invalid tags are", func(t *testing.T) {
+		type record struct {
+			ID    int `ksql:"id"`
+			OldID int `ksql:"id"`
+		}
+
+		_, err := structs.GetTagInfo(reflect.TypeOf(record{}))
+
+		var tagErr structs.TagError
+		tt.AssertEqual(t, errors.As(err, &tagErr), true)
+		tt.AssertEqual(t, tagErr.StructType, reflect.TypeOf(record{}))
+		tt.AssertEqual(t, tagErr.Field, "OldID")
+		tt.AssertErrContains(t, err, "structs_test.record.OldID", "same ksql tag name", "id")
+	})
+}
+
+type ptrValuer string
+
+func (p *ptrValuer) Value() (driver.Value, error) {
+	return string(*p), nil
+}
+
+func TestDriverValue(t *testing.T) {
+	t.Run("should return pointers to the types implementing driver.Valuer with pointer receivers", func(t *testing.T) {
+		value := structs.DriverValue(ptrValuer("name"))
+		tt.AssertEqual(t, *value.(*ptrValuer), ptrValuer("name"))
+	})
+
+	t.Run("should return the other values unchanged", func(t *testing.T) {
+		name := ptrValuer("name")
+		tt.AssertEqual(t, structs.DriverValue(&name), &name)
+		tt.AssertEqual(t, structs.DriverValue(sql.NullString{String: "name", Valid: true}), sql.NullString{String: "name", Valid: true})
+		tt.AssertEqual(t, structs.DriverValue("name"), "name")
+		tt.AssertEqual(t, structs.DriverValue(nil), nil)
+	})
+}
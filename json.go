@@ -11,8 +11,11 @@ import (
 // input attributes to be convertible to and from JSON
 // before sending or receiving it from the database.
 type jsonSerializable struct {
-	DriverName string
-	Attr       interface{}
+	Dialect Dialect
+	Attr    interface{}
+
+	// NilAsNull is set by the `nilasnull` modifier
+	NilAsNull bool
 }
 
 // Scan Implements the Scanner interface in order to load
@@ -35,14 +38,22 @@ func (j *jsonSerializable) Scan(value interface{}) error {
 	if !ok {
 		return fmt.Errorf("unexpected type received to Scan: %T", value)
 	}
-	return json.Unmarshal(rawJSON, j.Attr)
+	return unmarshalJSON(rawJSON, j.Attr)
 }
 
 // Value Implements the Valuer interface in order to save
 // this field as JSON on the database.
+//
+// Nil slices and maps are saved as the JSON `null` unless
+// the attribute uses the `nilasnull` modifier, in which
+// case they are saved as NULL.
 func (j jsonSerializable) Value() (driver.Value, error) {
+	if v := reflect.ValueOf(j.Attr); j.NilAsNull && (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return nil, nil
+	}
+
 	b, err := json.Marshal(j.Attr)
-	if j.DriverName == "sqlserver" {
+	if capabilitiesOf(j.Dialect).JSONAsText {
 		return string(b), err
 	}
 	return b, err
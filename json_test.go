@@ -0,0 +1,35 @@
+package ksql
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestJSONSerializable(t *testing.T) {
+	t.Run("should save nil slices and maps as the JSON null by default", func(t *testing.T) {
+		for _, attr := range []interface{}{[]string(nil), map[string]int(nil)} {
+			value, err := jsonSerializable{Dialect: &postgresDialect{}, Attr: attr}.Value()
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, value, []byte("null"))
+		}
+	})
+
+	t.Run("should save nil slices and maps as NULL with the nilasnull modifier", func(t *testing.T) {
+		for _, attr := range []interface{}{[]string(nil), map[string]int(nil)} {
+			value, err := jsonSerializable{Dialect: &postgresDialect{}, Attr: attr, NilAsNull: true}.Value()
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, value, nil)
+		}
+	})
+
+	t.Run("should save empty slices and maps as JSON with the nilasnull modifier", func(t *testing.T) {
+		value, err := jsonSerializable{Dialect: &postgresDialect{}, Attr: []string{}, NilAsNull: true}.Value()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, []byte("[]"))
+
+		value, err = jsonSerializable{Dialect: &postgresDialect{}, Attr: map[string]int{}, NilAsNull: true}.Value()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, []byte("{}"))
+	})
+}
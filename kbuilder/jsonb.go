@@ -0,0 +1,76 @@
+package kbuilder
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// WhereJSONContains adds a condition matching the rows where the jsonb
+// column contains the input value, i.e. `column @> value`, the value
+// is encoded as JSON before being sent as a param.
+//
+// It is only supported by postgres.
+func (w WhereQueries) WhereJSONContains(column string, value interface{}) WhereQueries {
+	return append(w, jsonContains(column, value))
+}
+
+// WhereJSONContains adds a condition matching the rows where the jsonb
+// column contains the input value, i.e. `column @> value`, the value
+// is encoded as JSON before being sent as a param.
+//
+// It is only supported by postgres.
+func WhereJSONContains(column string, value interface{}) WhereQueries {
+	return WhereQueries{jsonContains(column, value)}
+}
+
+// WhereJSONPath adds a condition matching the rows where the text found
+// on the path of the jsonb column equals the input value, i.e.
+// `column #>> '{key1,key2}' = value`.
+//
+// It is only supported by postgres.
+func (w WhereQueries) WhereJSONPath(column string, path []string, value string) WhereQueries {
+	return append(w, jsonPath(column, path, value))
+}
+
+// WhereJSONPath adds a condition matching the rows where the text found
+// on the path of the jsonb column equals the input value, i.e.
+// `column #>> '{key1,key2}' = value`.
+//
+// It is only supported by postgres.
+func WhereJSONPath(column string, path []string, value string) WhereQueries {
+	return WhereQueries{jsonPath(column, path, value)}
+}
+
+func jsonContains(column string, value interface{}) WhereQuery {
+	rawJSON, err := json.Marshal(value)
+	return WhereQuery{
+		cond:      escapeFormatDirectives(column) + " @> %s::jsonb",
+		params:    []interface{}{string(rawJSON)},
+		err:       err,
+		jsonbOnly: true,
+	}
+}
+
+func jsonPath(column string, path []string, value string) WhereQuery {
+	return WhereQuery{
+		cond:      escapeFormatDirectives(column) + " #>> %s::text[] = %s",
+		params:    []interface{}{encodeTextArray(path), value},
+		jsonbOnly: true,
+	}
+}
+
+// encodeTextArray encodes the input as a postgres
+// array literal, e.g. `{"key1","key2"}`.
+func encodeTextArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		value = strings.ReplaceAll(value, `"`, `\"`)
+		quoted[i] = `"` + value + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
+
+func escapeFormatDirectives(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
@@ -61,7 +61,11 @@ func (q Query) BuildQuery(dialect ksql.Dialect) (sqlQuery string, params []inter
 
 	if len(q.Where) > 0 {
 		var whereQuery string
-		whereQuery, params = q.Where.build(dialect)
+		var err error
+		whereQuery, params, err = q.Where.build(dialect)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "error reading the Where field")
+		}
 		b.WriteString(" WHERE " + whereQuery)
 	}
 
@@ -101,15 +105,27 @@ type WhereQuery struct {
 	// for postgres or `?` for sqlite3.
 	cond   string
 	params []interface{}
+
+	// err is set by the helpers that fail to encode their params, and
+	// jsonbOnly by the ones that use the jsonb operators.
+	err       error
+	jsonbOnly bool
 }
 
 // WhereQueries is the helper for creating complex WHERE queries
 // in a dynamic way.
 type WhereQueries []WhereQuery
 
-func (w WhereQueries) build(dialect ksql.Dialect) (query string, params []interface{}) {
+func (w WhereQueries) build(dialect ksql.Dialect) (query string, params []interface{}, _ error) {
 	var conds []string
 	for _, whereQuery := range w {
+		if whereQuery.err != nil {
+			return "", nil, whereQuery.err
+		}
+		if whereQuery.jsonbOnly && !supportsJSONB(dialect) {
+			return "", nil, fmt.Errorf("the condition '%s' is only supported by postgres", whereQuery.cond)
+		}
+
 		var placeholders []interface{}
 		for i := range whereQuery.params {
 			placeholders = append(placeholders, dialect.Placeholder(len(params)+i))
@@ -119,7 +135,14 @@ func (w WhereQueries) build(dialect ksql.Dialect) (query string, params []interf
 		params = append(params, whereQuery.params...)
 	}
 
-	return strings.Join(conds, " AND "), params
+	return strings.Join(conds, " AND "), params, nil
+}
+
+// supportsJSONB checks if the dialect reports the jsonb operators
+// used by the WhereJSONContains and WhereJSONPath conditions.
+func supportsJSONB(dialect ksql.Dialect) bool {
+	reporter, ok := dialect.(ksql.DialectCapabilitiesReporter)
+	return ok && reporter.DialectCapabilities().JSONB
 }
 
 // Where adds a new boolean condition to an existing
@@ -101,6 +101,19 @@ func TestSelectQuery(t *testing.T) {
 			},
 			expectedQuery: `SELECT "name", "age" FROM users ORDER BY id DESC LIMIT 10 OFFSET 100`,
 		},
+		{
+			desc: "should build queries with jsonb conditions",
+			query: kbuilder.Query{
+				Select: &User{},
+				From:   "users",
+				Where: kbuilder.
+					WhereJSONContains("address", map[string]interface{}{"country": "BR"}).
+					WhereJSONPath("address", []string{"city", `"name"`}, "Rio").
+					Where("age > %s", 18),
+			},
+			expectedQuery:  `SELECT "name", "age" FROM users WHERE address @> $1::jsonb AND address #>> $2::text[] = $3 AND age > $4`,
+			expectedParams: []interface{}{`{"country":"BR"}`, `{"city","\"name\""}`, "Rio", 18},
+		},
 
 		/* * * * * Testing error cases: * * * * */
 		{
@@ -117,6 +130,16 @@ func TestSelectQuery(t *testing.T) {
 				Limit:   10,
 			},
 
+			expectedErr: true,
+		},
+		{
+			desc: "should report error if a jsonb value can't be encoded",
+			query: kbuilder.Query{
+				Select: &User{},
+				From:   "users",
+				Where:  kbuilder.WhereJSONContains("address", func() {}),
+			},
+
 			expectedErr: true,
 		},
 	}
@@ -135,6 +158,17 @@ func TestSelectQuery(t *testing.T) {
 	}
 }
 
+func TestJSONConditionsOnOtherDialects(t *testing.T) {
+	_, _, err := kbuilder.Query{
+		Select: &User{},
+		From:   "users",
+		Where:  kbuilder.WhereJSONContains("address", map[string]string{"country": "BR"}),
+	}.Build("sqlite3")
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only supported by postgres")
+}
+
 func expectError(t *testing.T, expect bool, err error) {
 	if expect {
 		require.Equal(t, true, err != nil, "expected an error, but got nothing")
@@ -0,0 +1,111 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+)
+
+// Key stores the values of the ID columns of a single record,
+// it is built with the `Table.Key()` method and can be used
+// to identify records of tables with composite keys without
+// having to build a map with the names of the ID columns, e.g.:
+//
+//	err := db.Delete(ctx, UserPermissionsTable, UserPermissionsTable.Key(userID, permID))
+type Key struct {
+	values map[string]interface{}
+	err    error
+}
+
+// Key returns the Key of the record identified by the input values,
+// they must be passed in the same order as the ID columns of the Table.
+//
+// If the number of values doesn't match the number of ID columns
+// the error is reported by the operation receiving the Key.
+func (t Table) Key(values ...interface{}) Key {
+	if len(values) != len(t.idColumns) {
+		return Key{
+			err: fmt.Errorf(
+				"ksql: table %s has %d ID column(s) but %d value(s) were passed to Table.Key()",
+				t.name, len(t.idColumns), len(values),
+			),
+		}
+	}
+
+	m := make(map[string]interface{}, len(values))
+	for i, id := range t.idColumns {
+		m[id] = values[i]
+	}
+
+	return Key{values: m}
+}
+
+// QueryByKey loads the record identified by the input key into the
+// `record` argument, it works just like the Find method but only
+// accepts keys built with the `Table.Key()` method, e.g.:
+//
+//	var perm UserPermission
+//	err := db.QueryByKey(ctx, UserPermissionsTable, &perm, UserPermissionsTable.Key(userID, permID))
+func (c DB) QueryByKey(
+	ctx context.Context,
+	table Table,
+	record interface{},
+	key Key,
+	opts ...QueryOption,
+) error {
+	return c.Find(ctx, table, record, key, opts...)
+}
+
+// Find loads the record identified by the input ID into the `record`
+// argument, which must be a pointer to struct, the SELECT part of the
+// query is generated from the struct just like in the QueryOne method
+// and ErrRecordNotFound is returned if there is no record with this ID.
+//
+// Just like with the Delete method the ID can be passed as a
+// struct, a map or a `ksql.Key` for tables with composite keys
+// or just as the ID itself for tables with a single ID column, e.g.:
+//
+//	var user User
+//	err := db.Find(ctx, UsersTable, &user, userID)
+//
+//	var perm UserPermission
+//	err = db.Find(ctx, UserPermissionsTable, &perm, UserPermissionsTable.Key(userID, permID))
+func (c DB) Find(
+	ctx context.Context,
+	table Table,
+	record interface{},
+	idOrKey interface{},
+	opts ...QueryOption,
+) error {
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't find record from ksql.Table: %s", err)
+	}
+
+	idMap, err := normalizeIDsAsMap(table.idColumns, idOrKey)
+	if err != nil {
+		return err
+	}
+
+	o := newQueryOptions(opts)
+	if o.withDeleted {
+		table.softDeleteColumn = ""
+	}
+
+	var query string
+	var params []interface{}
+	if o.asOf != nil {
+		query, params, err = buildAsOfQuery(c.dialect, table, idMap, *o.asOf)
+		if err != nil {
+			return err
+		}
+	} else {
+		whereQuery, idParams := buildWhereByIDs(c.dialect, table.idColumns, idMap)
+		query = fmt.Sprintf("FROM %s WHERE %s", table.escapedName(c.dialect), table.withFindFilter(c.dialect, whereQuery))
+		params = idParams
+	}
+
+	for _, opt := range opts {
+		params = append(params, opt)
+	}
+
+	return c.QueryOne(ctx, record, query, params...)
+}
@@ -0,0 +1,286 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// queryChunksByKey implements the keyset mode of QueryChunks, where
+// each chunk is loaded by a separate query that starts right after
+// the key of the last record of the previous chunk, so no long
+// running query or result set is kept open between the chunks.
+func (c DB) queryChunksByKey(
+	ctx context.Context,
+	opts queryOptions,
+	parser ChunkParser,
+	info structs.StructInfo,
+	structType reflect.Type,
+	isSliceOfPtrs bool,
+	parsed parsedQuery,
+) error {
+	if parser.ChunkSize <= 0 {
+		return fmt.Errorf("ksql: the ChunkSize must be positive when using KeyColumns, but got: %d", parser.ChunkSize)
+	}
+
+	for _, col := range parser.KeyColumns {
+		if !info.ByName(col).Valid {
+			return MappingError{
+				StructType:  structType,
+				Column:      col,
+				Query:       truncateQuery(parser.Query),
+				Fingerprint: queryFingerprint(parser.Query),
+				Err:         fmt.Errorf("the key column has no matching attribute on the struct"),
+			}
+		}
+
+		if len(opts.columns) > 0 && !containsString(opts.columns, col) {
+			return fmt.Errorf("ksql: the key column '%s' must be one of the columns passed to Columns()", col)
+		}
+	}
+
+	err := validateKeysetQuery(parser.Query)
+	if err != nil {
+		return err
+	}
+
+	lastKey := parser.StartAfter
+	if lastKey != nil && len(lastKey) != len(parser.KeyColumns) {
+		return fmt.Errorf(
+			"ksql: expected StartAfter to have %d value(s), one for each of the KeyColumns, but got %d",
+			len(parser.KeyColumns), len(lastKey),
+		)
+	}
+
+	fnValue := reflect.ValueOf(parser.ForEachChunk)
+	chunk := reflect.MakeSlice(fnValue.Type().In(0), 0, parser.ChunkSize)
+
+	start := time.Now()
+	var progress ChunkProgress
+	for {
+		query, params := buildKeysetChunkQuery(c.dialect, parser.Query, parser.Params, parser.KeyColumns, lastKey, parser.ChunkSize)
+		if opts.forUpdate {
+			query, err = buildForUpdateQuery(c.dialect, query)
+			if err != nil {
+				return err
+			}
+		}
+
+		var size int
+		chunk, size, err = c.scanChunk(ctx, opts, chunk, query, params, info, structType, isSliceOfPtrs, parsed)
+		if err == errDryRun {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if size == 0 {
+			return nil
+		}
+
+		err, _ = fnValue.Call([]reflect.Value{chunk.Slice(0, size)})[0].Interface().(error)
+		if err == nil {
+			lastRecord := reflect.Indirect(chunk.Index(size - 1))
+			lastKey = make([]interface{}, len(parser.KeyColumns))
+			for i, col := range parser.KeyColumns {
+				lastKey[i] = lastRecord.Field(info.ByName(col).Index).Interface()
+			}
+
+			progress.LastKey = lastKey
+			err = parser.reportProgress(ctx, &progress, start, size)
+		}
+		if err != nil {
+			if err == ErrAbortIteration {
+				return nil
+			}
+			return err
+		}
+
+		if size < parser.ChunkSize {
+			return nil
+		}
+	}
+}
+
+// scanChunk loads all the rows returned by the query into the chunk,
+// reusing the elements already allocated by previous calls.
+func (c DB) scanChunk(
+	ctx context.Context,
+	opts queryOptions,
+	chunk reflect.Value,
+	query string,
+	params []interface{},
+	info structs.StructInfo,
+	structType reflect.Type,
+	isSliceOfPtrs bool,
+	parsed parsedQuery,
+) (_ reflect.Value, size int, err error) {
+	rows, err := c.queryContext(ctx, OpInfo{Method: "QueryChunks"}, opts, query, params...)
+	if err != nil {
+		return chunk, 0, err
+	}
+	defer rows.Close()
+
+	aliasNestedStructs, err := c.shouldAliasNestedStructs(rows, structType, info, parsed)
+	if err != nil {
+		return chunk, 0, newMappingError(structType, query, err)
+	}
+
+	for rows.Next() {
+		if chunk.Len() <= size {
+			elemValue := reflect.New(structType)
+			if !isSliceOfPtrs {
+				elemValue = elemValue.Elem()
+			}
+			chunk = reflect.Append(chunk, elemValue)
+		}
+
+		err = scanRows(c.dialect, rows, chunk.Index(size).Addr().Interface(), scanOptions{
+			aliasNestedStructs: aliasNestedStructs,
+			strict:             opts.strictScan,
+			converters:         c.converters,
+		})
+		if err != nil {
+			return chunk, 0, newMappingError(structType, query, err)
+		}
+		size++
+	}
+
+	if err := rows.Close(); err != nil {
+		return chunk, 0, err
+	}
+
+	return chunk, size, rows.Err()
+}
+
+// buildKeysetChunkQuery adds to the query the condition for skipping the
+// records up to the last key, if there is one, and the ORDER BY and
+// LIMIT clauses for loading the next chunk.
+func buildKeysetChunkQuery(
+	dialect Dialect,
+	query string,
+	params []interface{},
+	keyColumns []string,
+	lastKey []interface{},
+	chunkSize int,
+) (string, []interface{}) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+
+	escapedColumns := make([]string, len(keyColumns))
+	for i, col := range keyColumns {
+		escapedColumns[i] = dialect.Escape(col)
+	}
+
+	if lastKey != nil {
+		params = append([]interface{}{}, params...)
+
+		// Row value comparisons, i.e. `(a, b) > (?, ?)`, are not supported
+		// by all dialects, so the condition is expanded to:
+		//
+		// `a > ? OR (a = ? AND b > ?)`
+		conditions := make([]string, len(keyColumns))
+		for i := range keyColumns {
+			terms := make([]string, 0, i+1)
+			for j := 0; j < i; j++ {
+				terms = append(terms, escapedColumns[j]+" = "+dialect.Placeholder(len(params)))
+				params = append(params, lastKey[j])
+			}
+			terms = append(terms, escapedColumns[i]+" > "+dialect.Placeholder(len(params)))
+			params = append(params, lastKey[i])
+
+			conditions[i] = "(" + strings.Join(terms, " AND ") + ")"
+		}
+		keyCondition := strings.Join(conditions, " OR ")
+
+		if wherePos := findTopLevelKeyword(query, "WHERE"); wherePos != -1 {
+			start := wherePos + len("WHERE")
+			query = query[:start] + " (" + strings.TrimSpace(query[start:]) + ") AND (" + keyCondition + ")"
+		} else {
+			query += " WHERE " + keyCondition
+		}
+	}
+
+	query += " ORDER BY " + strings.Join(escapedColumns, ", ")
+	if limit := capabilitiesOf(dialect).Limit; limit == LimitFetch || limit == LimitTop {
+		query += " OFFSET 0 ROWS FETCH NEXT " + strconv.Itoa(chunkSize) + " ROWS ONLY"
+	} else {
+		query += " LIMIT " + strconv.Itoa(chunkSize)
+	}
+
+	return query, params
+}
+
+// validateKeysetQuery makes sure the query has no clauses
+// that would conflict with the ones added by buildKeysetChunkQuery.
+func validateKeysetQuery(query string) error {
+	for _, keyword := range []string{"GROUP", "HAVING", "UNION", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR"} {
+		if findTopLevelKeyword(query, keyword) != -1 {
+			return fmt.Errorf(
+				"ksql: the query of a ChunkParser with KeyColumns can't contain a %s clause, but got: '%s'",
+				keyword, query,
+			)
+		}
+	}
+	return nil
+}
+
+// findTopLevelKeyword returns the position of the first occurrence of the
+// keyword that is neither inside parenthesis, quotes nor comments, or -1.
+func findTopLevelKeyword(query string, keyword string) int {
+	depth := 0
+	var quote rune
+	wordStart := -1
+	commentEnd := 0
+	for i, c := range query + " " {
+		if i < commentEnd {
+			continue
+		}
+
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		isWordChar := unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("_.$", c)
+		if isWordChar {
+			if wordStart == -1 {
+				wordStart = i
+			}
+			continue
+		}
+
+		if wordStart != -1 {
+			if depth == 0 && strings.EqualFold(query[wordStart:i], keyword) {
+				return wordStart
+			}
+			wordStart = -1
+		}
+
+		if n := commentLen(query[i:]); n > 0 {
+			commentEnd = i + n
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '[':
+			quote = ']'
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,91 @@
+package ksql
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestBuildKeysetChunkQuery(t *testing.T) {
+	tests := []struct {
+		desc           string
+		driver         string
+		query          string
+		params         []interface{}
+		keyColumns     []string
+		lastKey        []interface{}
+		expectedQuery  string
+		expectedParams []interface{}
+	}{
+		{
+			desc:          "should only add ORDER BY and LIMIT on the first chunk",
+			driver:        "postgres",
+			query:         `SELECT id, name FROM users WHERE age > $1;`,
+			params:        []interface{}{18},
+			keyColumns:    []string{"id"},
+			expectedQuery: `SELECT id, name FROM users WHERE age > $1 ORDER BY "id" LIMIT 10`,
+			expectedParams: []interface{}{
+				18,
+			},
+		},
+		{
+			desc:          "should add the key condition to the existing WHERE clause",
+			driver:        "postgres",
+			query:         `SELECT id, name FROM users WHERE age > $1 OR age IS NULL`,
+			params:        []interface{}{18},
+			keyColumns:    []string{"id"},
+			lastKey:       []interface{}{42},
+			expectedQuery: `SELECT id, name FROM users WHERE (age > $1 OR age IS NULL) AND (("id" > $2)) ORDER BY "id" LIMIT 10`,
+			expectedParams: []interface{}{
+				18, 42,
+			},
+		},
+		{
+			desc:          "should add a WHERE clause if the query has none at the top level",
+			driver:        "sqlite3",
+			query:         `SELECT * FROM (SELECT id, name FROM users WHERE age > ?) u`,
+			params:        []interface{}{18},
+			keyColumns:    []string{"name", "id"},
+			lastKey:       []interface{}{"Bia", 42},
+			expectedQuery: "SELECT * FROM (SELECT id, name FROM users WHERE age > ?) u WHERE (`name` > ?) OR (`name` = ? AND `id` > ?) ORDER BY `name`, `id` LIMIT 10",
+			expectedParams: []interface{}{
+				18, "Bia", "Bia", 42,
+			},
+		},
+		{
+			desc:           "should use OFFSET and FETCH on sqlserver",
+			driver:         "sqlserver",
+			query:          `SELECT id, name FROM users`,
+			keyColumns:     []string{"id"},
+			lastKey:        []interface{}{42},
+			expectedQuery:  `SELECT id, name FROM users WHERE ([id] > @p1) ORDER BY [id] OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`,
+			expectedParams: []interface{}{42},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			query, params := buildKeysetChunkQuery(supportedDialects[test.driver], test.query, test.params, test.keyColumns, test.lastKey, 10)
+			tt.AssertEqual(t, query, test.expectedQuery)
+			tt.AssertEqual(t, params, test.expectedParams)
+		})
+	}
+}
+
+func TestValidateKeysetQuery(t *testing.T) {
+	t.Run("should ignore keywords inside parenthesis and quotes", func(t *testing.T) {
+		err := validateKeysetQuery(`FROM users WHERE name <> 'order by' AND id IN (SELECT user_id FROM posts ORDER BY id LIMIT 10) AND u.limit > 0`)
+		tt.AssertNoErr(t, err)
+	})
+
+	t.Run("should reject top level clauses that conflict with the keyset ones", func(t *testing.T) {
+		for _, query := range []string{
+			`FROM users ORDER BY id`,
+			`FROM users LIMIT 10`,
+			`FROM users GROUP BY name`,
+			`FROM users WHERE id > 0 FOR UPDATE`,
+		} {
+			err := validateKeysetQuery(query)
+			tt.AssertErrContains(t, err, "KeyColumns")
+		}
+	})
+}
@@ -8,11 +8,11 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 
 	"github.com/pkg/errors"
 	"github.com/vingarcia/ksql/internal/structs"
-	"github.com/vingarcia/ksql/ksqltest"
 )
 
 var selectQueryCache = initializeQueryCache()
@@ -33,6 +33,45 @@ type DB struct {
 	driver  string
 	dialect Dialect
 	db      DBAdapter
+
+	// customSelectCache is set by the WithDialect option since the custom
+	// dialects can't share the caches of the built-in ones.
+	customSelectCache *sync.Map
+
+	queryRewriters     []QueryRewriter
+	argEncoders        []ArgEncoder
+	sessionVarsFn      SessionVarsFn
+	aliasNestedStructs bool
+
+	// lifecycle is shared by all copies of the DB,
+	// e.g. the ones passed to the Transaction callbacks.
+	lifecycle     *lifecycle
+	sharedAdapter bool
+
+	// connPinned is true inside the WithConn callbacks
+	connPinned bool
+
+	twoPhaseCommit       bool
+	skipParamsValidation bool
+	sqlCommenter         bool
+	maxRows              int
+
+	// converters are registered by the WithConverter option
+	converters map[string]Converter
+
+	validators []Validator
+
+	clock       Clock
+	idGenerator IDGenerator
+}
+
+// selectQueryCache returns the cache of the SELECT parts
+// of the queries built for the dialect of the DB.
+func (c DB) selectQueryCache() *sync.Map {
+	if c.customSelectCache != nil {
+		return c.customSelectCache
+	}
+	return selectQueryCache[c.dialect.DriverName()]
 }
 
 // DBAdapter is minimalistic interface to decouple our implementation
@@ -51,6 +90,67 @@ type TxBeginner interface {
 	BeginTx(ctx context.Context) (Tx, error)
 }
 
+// Listener needs to be implemented by the DBAdapter in order to make it possible
+// to use the `ksql.Listen()` function.
+//
+// Implementations are expected to keep the returned channel open until
+// the input context is canceled, reconnecting and subscribing again
+// to the channel if the connection is lost in the meantime.
+type Listener interface {
+	Listen(ctx context.Context, channel string) (<-chan Notification, error)
+}
+
+// Notification represents a message received on a channel
+// the client is listening to, e.g. using Postgres' LISTEN/NOTIFY.
+type Notification struct {
+	// The ID of the database process that sent the notification
+	PID uint32
+
+	Channel string
+	Payload string
+}
+
+// CSVCopier can be implemented by the DBAdapter in order to make the
+// `ksql.InsertCSV()` function use the bulk loading mechanism of the database,
+// e.g. Postgres' COPY command, instead of inserting the rows one by one.
+//
+// The reader contains CSV records without a header, separated by commas,
+// using double quotes for quoting, and where unquoted empty fields are NULL.
+//
+// The tableName is qualified with the schema of the
+// ksql.Table if it has one, e.g. `app.users`.
+type CSVCopier interface {
+	CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error
+}
+
+// RowAppender needs to be implemented by the DBAdapter in order to make the
+// Insert and InsertMany methods use the streaming ingestion API of the
+// database, e.g. BigQuery's Storage Write API, instead of INSERT statements.
+//
+// It is only used on the dialects that don't retrieve the IDs of inserted
+// records, the rows contain the values of the columns in the same order,
+// and just like with CSVCopier the tableName is qualified with the schema
+// of the ksql.Table if it has one.
+type RowAppender interface {
+	AppendRows(ctx context.Context, tableName string, columns []string, rows [][]interface{}) error
+}
+
+// ConnAcquirer needs to be implemented by the DBAdapter in order to make it
+// possible to use the `ksql.WithConn()` function.
+type ConnAcquirer interface {
+	AcquireConn(ctx context.Context) (Conn, error)
+}
+
+// Conn represents a single connection taken from the pool
+// and is expected to be returned by the ConnAcquirer.AcquireConn function,
+// the Close method should return the connection to the pool.
+type Conn interface {
+	DBAdapter
+	TxBeginner
+
+	Close() error
+}
+
 // Result stores information about the result of an Exec query
 type Result interface {
 	LastInsertId() (int64, error)
@@ -82,6 +182,23 @@ type Config struct {
 
 	// Used by some adapters (such as kpgx) where nil disables TLS
 	TLSConfig *tls.Config
+
+	// TransactionPooling is used by the postgres adapters (kpgx and
+	// kyugabyte) for running behind proxies that pool connections per
+	// transaction, e.g. PgBouncer with `pool_mode = transaction`, it
+	// disables the prepared statements and the features that depend
+	// on the state of the connection, e.g. LISTEN.
+	TransactionPooling bool
+
+	// ApplicationName identifies the connections of the service on the
+	// database, e.g. on `pg_stat_activity`, so DBAs can tell which service
+	// is holding a lock. It is sent as the `application_name` parameter
+	// on postgres and as the `app name` (program_name) on sqlserver.
+	//
+	// The go-sql-driver/mysql version used by kmysql doesn't send connection
+	// attributes, so it is ignored on mysql. The name of each transaction
+	// can also be set from the context with ksql.WithApplicationName().
+	ApplicationName string
 }
 
 // SetDefaultValues should be called by all adapters
@@ -97,32 +214,63 @@ func (c *Config) SetDefaultValues() {
 func NewWithAdapter(
 	db DBAdapter,
 	dialectName string,
+	opts ...Option,
 ) (DB, error) {
 	dialect := supportedDialects[dialectName]
 	if dialect == nil {
 		return DB{}, fmt.Errorf("unsupported driver `%s`", dialectName)
 	}
 
-	return DB{
-		dialect: dialect,
-		driver:  dialectName,
-		db:      db,
-	}, nil
+	client := DB{
+		dialect:   dialect,
+		driver:    dialectName,
+		db:        db,
+		lifecycle: &lifecycle{},
+	}
+	for _, opt := range opts {
+		opt(&client)
+	}
+
+	return client, nil
 }
 
 // Query queries several rows from the database,
 // the input should be a slice of structs (or *struct) passed
 // by reference and it will be filled with all the results.
 //
+// For queries whose columns are not known at compile time the input
+// can also be a *[]map[string]interface{}, in this case each row is
+// stored as a map using the column names as keys and the SELECT part
+// of the query can't be omitted.
+//
+// The SELECT part can also be omitted after leading comments and after
+// the CTEs of a query, e.g. `WITH adults AS (...) FROM adults`, and the
+// RawQuery and AutoSelect options can be used for disabling or forcing
+// the generation of the SELECT part.
+//
+// String attributes tagged with the `trim` modifier, e.g. `ksql:"code,trim"`,
+// are loaded without the trailing spaces used for padding CHAR(n) columns.
+//
 // Note: it is very important to make sure the query will
 // return a small known number of results, otherwise you risk
-// of overloading the available memory.
+// of overloading the available memory, the MaxRows, TruncateRows
+// and WithMaxRows options can be used for enforcing this.
 func (c DB) Query(
 	ctx context.Context,
 	records interface{},
 	query string,
 	params ...interface{},
 ) error {
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.Query(ctx, records, query, params...)
+		})
+	}
+
+	if maps, ok := records.(*[]map[string]interface{}); ok {
+		return c.queryMaps(ctx, maps, query, params...)
+	}
+
 	slicePtr := reflect.ValueOf(records)
 	slicePtrType := slicePtr.Type()
 	if slicePtrType.Kind() != reflect.Ptr {
@@ -144,30 +292,77 @@ func (c DB) Query(
 
 	info, err := structs.GetTagInfo(structType)
 	if err != nil {
-		return err
+		return newMappingError(structType, query, err)
+	}
+
+	opts, params := extractQueryOptions(params)
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	parsed := parseQuery(query, opts)
+	if len(opts.extraSelects) > 0 || len(opts.columns) > 0 {
+		if !parsed.autoSelect {
+			return fmt.Errorf("ksql: AddSelect and Columns can only be used if the SELECT part of the query is omitted")
+		}
+
+		// The extra or omitted columns would break the positional matching of nested structs:
+		c.aliasNestedStructs = true
 	}
 
-	firstToken := strings.ToUpper(getFirstToken(query))
-	if info.IsNestedStruct && firstToken == "SELECT" {
-		// This error check is necessary, since if we can't build the select part of the query this feature won't work.
-		return fmt.Errorf("can't generate SELECT query for nested struct: when using this feature omit the SELECT part of the query")
+	if parsed.autoSelect {
+		selectPrefix, err := buildSelectQuery(c.dialect, structType, info, c.aliasNestedStructs, opts, c.selectQueryCache())
+		if err != nil {
+			return newMappingError(structType, query, err)
+		}
+		query = parsed.withSelect(selectPrefix)
+	}
+	if err := c.checkNestedStructAliases(structType, info, parsed); err != nil {
+		return newMappingError(structType, query, err)
+	}
+
+	if opts.limit != nil {
+		query, err = buildLimitQuery(c.dialect, query, *opts.limit)
+		if err != nil {
+			return err
+		}
 	}
 
-	if firstToken == "FROM" {
-		selectPrefix, err := buildSelectQuery(c.dialect, structType, info, selectQueryCache[c.dialect.DriverName()])
+	if opts.forUpdate {
+		query, err = buildForUpdateQuery(c.dialect, query)
 		if err != nil {
 			return err
 		}
-		query = selectPrefix + query
 	}
 
-	rows, err := c.db.QueryContext(ctx, query, params...)
+	rows, err := c.queryContext(ctx, OpInfo{Method: "Query"}, opts, query, params...)
+	if err == errDryRun {
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("error running query: %s", err)
+		return errors.Wrap(err, "error running query")
 	}
 	defer rows.Close()
 
-	for idx := 0; rows.Next(); idx++ {
+	aliasNestedStructs, err := c.shouldAliasNestedStructs(rows, structType, info, parsed)
+	if err != nil {
+		return newMappingError(structType, query, err)
+	}
+
+	if opts.truncated != nil {
+		*opts.truncated = false
+	}
+
+	var rowErrs RowScanErrors
+	idx := 0
+	for row := 0; rows.Next(); row++ {
+		load, err := c.checkMaxRows(opts, idx)
+		if err != nil {
+			return err
+		}
+		if !load {
+			break
+		}
+
 		// Allocate new slice elements
 		// only if they are not already allocated:
 		if slice.Len() <= idx {
@@ -185,10 +380,24 @@ func (c DB) Query(
 			elemPtr = elemPtr.Elem()
 		}
 
-		err = scanRows(c.dialect, rows, elemPtr.Interface())
+		err = scanRows(c.dialect, rows, elemPtr.Interface(), scanOptions{
+			aliasNestedStructs: aliasNestedStructs,
+			strict:             opts.strictScan,
+			converters:         c.converters,
+		})
 		if err != nil {
-			return err
+			err = newMappingError(structType, query, err)
+			if !opts.skipInvalidRows {
+				return err
+			}
+
+			// The element might be partially filled, so we reset
+			// it before using it for the next row:
+			elemPtr.Elem().Set(reflect.Zero(structType))
+			rowErrs = append(rowErrs, RowScanError{Row: row, Err: err})
+			continue
 		}
+		idx++
 	}
 
 	if rows.Err() != nil {
@@ -199,9 +408,18 @@ func (c DB) Query(
 		return err
 	}
 
+	if len(rowErrs) > 0 {
+		// Removes the element allocated for the last row if it was skipped:
+		slice = slice.Slice(0, idx)
+	}
+
 	// Update the original slice passed by reference:
 	slicePtr.Elem().Set(slice)
 
+	if len(rowErrs) > 0 {
+		return rowErrs
+	}
+
 	return nil
 }
 
@@ -211,12 +429,25 @@ func (c DB) Query(
 //
 // QueryOne returns a ErrRecordNotFound if
 // the query returns no results.
+//
+// The input can also be a *map[string]interface{},
+// following the same rules described on the Query method.
 func (c DB) QueryOne(
 	ctx context.Context,
 	record interface{},
 	query string,
 	params ...interface{},
 ) error {
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.QueryOne(ctx, record, query, params...)
+		})
+	}
+
+	if m, ok := record.(*map[string]interface{}); ok {
+		return c.queryOneMap(ctx, m, query, params...)
+	}
+
 	v := reflect.ValueOf(record)
 	t := v.Type()
 	if t.Kind() != reflect.Ptr {
@@ -234,29 +465,62 @@ func (c DB) QueryOne(
 
 	info, err := structs.GetTagInfo(tStruct)
 	if err != nil {
-		return err
+		return newMappingError(tStruct, query, err)
+	}
+
+	opts, params := extractQueryOptions(params)
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	parsed := parseQuery(query, opts)
+	if len(opts.extraSelects) > 0 || len(opts.columns) > 0 {
+		if !parsed.autoSelect {
+			return fmt.Errorf("ksql: AddSelect and Columns can only be used if the SELECT part of the query is omitted")
+		}
+
+		// The extra or omitted columns would break the positional matching of nested structs:
+		c.aliasNestedStructs = true
+	}
+
+	if parsed.autoSelect {
+		selectPrefix, err := buildSelectQuery(c.dialect, tStruct, info, c.aliasNestedStructs, opts, c.selectQueryCache())
+		if err != nil {
+			return newMappingError(tStruct, query, err)
+		}
+		query = parsed.withSelect(selectPrefix)
+	}
+	if err := c.checkNestedStructAliases(tStruct, info, parsed); err != nil {
+		return newMappingError(tStruct, query, err)
 	}
 
-	firstToken := strings.ToUpper(getFirstToken(query))
-	if info.IsNestedStruct && firstToken == "SELECT" {
-		// This error check is necessary, since if we can't build the select part of the query this feature won't work.
-		return fmt.Errorf("can't generate SELECT query for nested struct: when using this feature omit the SELECT part of the query")
+	if opts.limit != nil {
+		query, err = buildLimitQuery(c.dialect, query, *opts.limit)
+		if err != nil {
+			return err
+		}
 	}
 
-	if firstToken == "FROM" {
-		selectPrefix, err := buildSelectQuery(c.dialect, tStruct, info, selectQueryCache[c.dialect.DriverName()])
+	if opts.forUpdate {
+		query, err = buildForUpdateQuery(c.dialect, query)
 		if err != nil {
 			return err
 		}
-		query = selectPrefix + query
 	}
 
-	rows, err := c.db.QueryContext(ctx, query, params...)
+	rows, err := c.queryContext(ctx, OpInfo{Method: "QueryOne"}, opts, query, params...)
+	if err == errDryRun {
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("error running query: %s", err)
+		return errors.Wrap(err, "error running query")
 	}
 	defer rows.Close()
 
+	aliasNestedStructs, err := c.shouldAliasNestedStructs(rows, tStruct, info, parsed)
+	if err != nil {
+		return newMappingError(tStruct, query, err)
+	}
+
 	if !rows.Next() {
 		if rows.Err() != nil {
 			return rows.Err()
@@ -264,9 +528,13 @@ func (c DB) QueryOne(
 		return ErrRecordNotFound
 	}
 
-	err = scanRowsFromType(c.dialect, rows, record, t, v)
+	err = scanRowsFromType(c.dialect, rows, record, t, v, scanOptions{
+		aliasNestedStructs: aliasNestedStructs,
+		strict:             opts.strictScan,
+		converters:         c.converters,
+	})
 	if err != nil {
-		return err
+		return newMappingError(tStruct, query, err)
 	}
 
 	return rows.Close()
@@ -288,10 +556,20 @@ func (c DB) QueryOne(
 // pointers to struct as its only argument and that reflection
 // will be used to instantiate this argument and to fill it
 // with the database rows.
+//
+// If the KeyColumns attribute is set each chunk is loaded by a separate
+// query ordered by these columns and the optional OnProgress callback
+// reports the progress after each chunk, see the ChunkParser docs for details.
 func (c DB) QueryChunks(
 	ctx context.Context,
 	parser ChunkParser,
 ) error {
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.QueryChunks(ctx, parser)
+		})
+	}
+
 	fnValue := reflect.ValueOf(parser.ForEachChunk)
 	chunkType, err := structs.ParseInputFunc(parser.ForEachChunk)
 	if err != nil {
@@ -307,29 +585,76 @@ func (c DB) QueryChunks(
 
 	info, err := structs.GetTagInfo(structType)
 	if err != nil {
-		return err
+		return newMappingError(structType, parser.Query, err)
+	}
+
+	var opts queryOptions
+	opts, parser.Params = extractQueryOptions(parser.Params)
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	parsed := parseQuery(parser.Query, opts)
+	if len(opts.extraSelects) > 0 || len(opts.columns) > 0 {
+		if !parsed.autoSelect {
+			return fmt.Errorf("ksql: AddSelect and Columns can only be used if the SELECT part of the query is omitted")
+		}
+
+		// The extra or omitted columns would break the positional matching of nested structs:
+		c.aliasNestedStructs = true
+	}
+
+	if parsed.autoSelect {
+		selectPrefix, err := buildSelectQuery(c.dialect, structType, info, c.aliasNestedStructs, opts, c.selectQueryCache())
+		if err != nil {
+			return newMappingError(structType, parser.Query, err)
+		}
+		parser.Query = parsed.withSelect(selectPrefix)
+	}
+	if err := c.checkNestedStructAliases(structType, info, parsed); err != nil {
+		return newMappingError(structType, parser.Query, err)
+	}
+
+	if len(parser.KeyColumns) > 0 {
+		if opts.limit != nil {
+			return fmt.Errorf("ksql: the Limit option can't be used with the KeyColumns of the ChunkParser")
+		}
+		return c.queryChunksByKey(ctx, opts, parser, info, structType, isSliceOfPtrs, parsed)
+	}
+	if len(parser.StartAfter) > 0 {
+		return fmt.Errorf("ksql: the StartAfter attribute of the ChunkParser can only be used with KeyColumns")
 	}
 
-	firstToken := strings.ToUpper(getFirstToken(parser.Query))
-	if info.IsNestedStruct && firstToken == "SELECT" {
-		// This error check is necessary, since if we can't build the select part of the query this feature won't work.
-		return fmt.Errorf("can't generate SELECT query for nested struct: when using this feature omit the SELECT part of the query")
+	if opts.limit != nil {
+		parser.Query, err = buildLimitQuery(c.dialect, parser.Query, *opts.limit)
+		if err != nil {
+			return err
+		}
 	}
 
-	if firstToken == "FROM" {
-		selectPrefix, err := buildSelectQuery(c.dialect, structType, info, selectQueryCache[c.dialect.DriverName()])
+	if opts.forUpdate {
+		parser.Query, err = buildForUpdateQuery(c.dialect, parser.Query)
 		if err != nil {
 			return err
 		}
-		parser.Query = selectPrefix + parser.Query
 	}
 
-	rows, err := c.db.QueryContext(ctx, parser.Query, parser.Params...)
+	rows, err := c.queryContext(ctx, OpInfo{Method: "QueryChunks"}, opts, parser.Query, parser.Params...)
+	if err == errDryRun {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
+	aliasNestedStructs, err := c.shouldAliasNestedStructs(rows, structType, info, parsed)
+	if err != nil {
+		return newMappingError(structType, parser.Query, err)
+	}
+
+	start := time.Now()
+	var progress ChunkProgress
+
 	var idx = 0
 	for rows.Next() {
 		// Allocate new slice elements
@@ -343,9 +668,13 @@ func (c DB) QueryChunks(
 			chunk = reflect.Append(chunk, elemValue)
 		}
 
-		err = scanRows(c.dialect, rows, chunk.Index(idx).Addr().Interface())
+		err = scanRows(c.dialect, rows, chunk.Index(idx).Addr().Interface(), scanOptions{
+			aliasNestedStructs: aliasNestedStructs,
+			strict:             opts.strictScan,
+			converters:         c.converters,
+		})
 		if err != nil {
-			return err
+			return newMappingError(structType, parser.Query, err)
 		}
 
 		if idx < parser.ChunkSize-1 {
@@ -355,6 +684,9 @@ func (c DB) QueryChunks(
 
 		idx = 0
 		err, _ = fnValue.Call([]reflect.Value{chunk})[0].Interface().(error)
+		if err == nil {
+			err = parser.reportProgress(ctx, &progress, start, chunk.Len())
+		}
 		if err != nil {
 			if err == ErrAbortIteration {
 				return nil
@@ -378,6 +710,9 @@ func (c DB) QueryChunks(
 		chunk = chunk.Slice(0, idx)
 
 		err, _ = fnValue.Call([]reflect.Value{chunk})[0].Interface().(error)
+		if err == nil {
+			err = parser.reportProgress(ctx, &progress, start, idx)
+		}
 		if err != nil {
 			if err == ErrAbortIteration {
 				return nil
@@ -393,11 +728,62 @@ func (c DB) QueryChunks(
 //
 // If the original instances have been passed by reference
 // the ID is automatically updated after insertion is completed.
+//
+// Unset attributes tagged with the `default` modifier are handled specially:
+// `ksql:"age,default"` omits the column so the database default is used and
+// `ksql:"age,default=18"` inserts the literal, which is also written on the record.
+// For time.Time attributes the literal can be `now` or a RFC3339 timestamp,
+// for string attributes `newid` generates a random UUID and for types
+// implementing sql.Scanner it is parsed by their Scan method. The values of
+// `now` and `newid` can be replaced with the WithClock and WithIDGenerator options.
+//
+// Attributes tagged with the `blob` modifier, e.g. `ksql:"avatar,blob"`, must be
+// of type io.Reader, which is read when writing and receives a *bytes.Reader when
+// scanned. For streaming values larger than the memory use QueryBlob and, on
+// postgres, InsertLargeObject instead.
+//
+// Attributes tagged with the `decimal` modifier are written and read as decimal
+// strings so NUMERIC values never round-trip through float64, they can be strings
+// or any type implementing encoding.TextMarshaler and encoding.TextUnmarshaler.
+//
+// On postgres, attributes of type map[string]string or map[string]*string
+// tagged with the `hstore` modifier are stored on hstore columns.
+//
+// Attributes of type time.Duration tagged with the `duration` modifier are
+// stored on INTERVAL columns on postgres and as integers on the other
+// databases, counting seconds by default or the unit of the modifier,
+// e.g. `ksql:"timeout,duration=ms"`, where the units are ns, us, ms, s, m and h.
+//
+// On sqlserver, the attributes tagged with the `sqltype` modifier, e.g.
+// `ksql:"email,sqltype=varchar"`, are sent with the type of their columns
+// so their indexes can be used on the WHERE clauses, see TypedParam.
+//
+// The ksql.QueryOption arguments are read from the context, see WithQueryOptions.
 func (c DB) Insert(
 	ctx context.Context,
 	table Table,
 	record interface{},
 ) error {
+	ctx, opts := popQueryOptions(ctx)
+	return c.insert(ctx, table, record, opts)
+}
+
+func (c DB) insert(
+	ctx context.Context,
+	table Table,
+	record interface{},
+	opts []QueryOption,
+) error {
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).insert(ctx, table, record, opts)
+		})
+	}
+
+	o := newQueryOptions(opts)
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
 	v := reflect.ValueOf(record)
 	t := v.Type()
 	if err := assertStructPtr(t); err != nil {
@@ -420,35 +806,238 @@ func (c DB) Insert(
 		return err
 	}
 
-	query, params, scanValues, err := buildInsertQuery(c.dialect, table, t, v, info, record)
+	if err := c.validateRecord(ctx, record); err != nil {
+		return err
+	}
+
+	if o.identityInsert && hasExplicitIDs(v, info, table.idColumns) {
+		if enable, disable, ok := identityInsertStatements(c.dialect, table); ok {
+			return c.insertWithIdentityInsert(ctx, table, record, opts, enable, disable)
+		}
+	}
+
+	op := OpInfo{Method: "Insert", TableName: table.name}
+	if table.sequence != "" && !hasExplicitIDs(v, info, table.idColumns) {
+		err := c.setIDFromSequence(ctx, op, o, table, v, info)
+		if err != nil && err != errDryRun {
+			return err
+		}
+	}
+
+	// The DryRun option reports the INSERT statement instead:
+	appender, useAppender := getRowAppender(c.db)
+	if useAppender && o.dryRunFn == nil && c.dialect.InsertMethod() == insertWithNoIDRetrieval {
+		recordMap, err := buildInsertRecordMap(c.dialect, c.converters, table, v, info, record, c.generators())
+		if err != nil {
+			return err
+		}
+
+		columns := sortedKeys(recordMap)
+		return appender.AppendRows(ctx, table.qualifiedName(), columns, [][]interface{}{
+			buildInsertRowValues(c.dialect, info, columns, recordMap),
+		})
+	}
+
+	method := table.insertMethodFor(c.dialect)
+	if o.skipIDRetrieval {
+		method = insertWithNoIDRetrieval
+	}
+
+	query, params, scanValues, err := buildInsertQuery(c.dialect, c.converters, table, method, t, v, info, record, c.generators())
+	if err != nil {
+		return err
+	}
+	if o.batch {
+		query = addBatchHints(c.dialect, query)
+	}
+	query, err = addStatementHints(c.dialect, table, query, o)
 	if err != nil {
 		return err
 	}
 
-	switch table.insertMethodFor(c.dialect) {
-	case insertWithReturning, insertWithOutput:
-		err = c.insertReturningIDs(ctx, query, params, scanValues, table.idColumns)
+	switch method {
+	case insertWithReturning, insertWithOutput, insertWithScopeIdentity:
+		err = c.insertReturningIDs(ctx, op, o, query, params, scanValues, table.idColumns)
 	case insertWithLastInsertID:
-		err = c.insertWithLastInsertID(ctx, t, v, info, record, query, params, table.idColumns[0])
+		err = c.insertWithLastInsertID(ctx, op, o, t, v, info, record, query, params, table.idColumns[0])
 	case insertWithNoIDRetrieval:
-		err = c.insertWithNoIDRetrieval(ctx, query, params)
+		err = c.insertWithNoIDRetrieval(ctx, op, o, query, params)
 	default:
 		// Unsupported drivers should be detected on the New() function,
 		// So we don't expect the code to ever get into this default case.
 		err = fmt.Errorf("code error: unsupported driver `%s`", c.driver)
 	}
 
+	if err == errDryRun {
+		return nil
+	}
+
 	return err
 }
 
+func hasExplicitIDs(v reflect.Value, info structs.StructInfo, idNames []string) bool {
+	for _, id := range idNames {
+		field := info.ByName(id)
+		if field.Valid && !v.Elem().Field(field.Index).IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// identityInsertStatements returns the statements that allow and disallow
+// explicit values on the auto generated ID columns of the table, ok is false
+// if the dialect accepts explicit IDs by default.
+func identityInsertStatements(dialect Dialect, table Table) (enable string, disable string, ok bool) {
+	switch capabilitiesOf(dialect).IdentityInsert {
+	case IdentityInsertAutoRandom:
+		return "SET @@allow_auto_random_explicit_insert = true", "SET @@allow_auto_random_explicit_insert = false", true
+	case IdentityInsertSetTable:
+		escapedTableName := table.escapedName(dialect)
+		return "SET IDENTITY_INSERT " + escapedTableName + " ON", "SET IDENTITY_INSERT " + escapedTableName + " OFF", true
+	default:
+		return "", "", false
+	}
+}
+
+// insertWithIdentityInsert runs the insert inside a transaction so the
+// statements enabling and disabling the explicit IDs, e.g. `SET IDENTITY_INSERT`,
+// run on the same connection.
+func (c DB) insertWithIdentityInsert(
+	ctx context.Context,
+	table Table,
+	record interface{},
+	opts []QueryOption,
+	enable string,
+	disable string,
+) error {
+	// The options are passed to Exec so options like DryRun also apply to it:
+	execParams := make([]interface{}, len(opts))
+	for i, opt := range opts {
+		execParams[i] = opt
+	}
+
+	return c.Transaction(ctx, func(db Provider) error {
+		_, err := db.Exec(ctx, enable, execParams...)
+		if err != nil {
+			return err
+		}
+
+		err = db.(DB).insert(ctx, table, record, append(opts, disableIdentityInsert))
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(ctx, disable, execParams...)
+		return err
+	})
+}
+
+func disableIdentityInsert(opts *queryOptions) {
+	opts.identityInsert = false
+}
+
+// batchOperation marks the inserts of the bulk helpers
+// so the batch hints of the dialect are added to them.
+func batchOperation(opts *queryOptions) {
+	opts.batch = true
+}
+
+// setIDFromSequence fetches the next value of the sequence
+// of the table and writes it to the ID attribute of the record.
+func (c DB) setIDFromSequence(
+	ctx context.Context,
+	op OpInfo,
+	opts queryOptions,
+	table Table,
+	v reflect.Value,
+	info structs.StructInfo,
+) error {
+	query, params, err := buildNextSequenceValueQuery(c.dialect, table.sequence)
+	if err != nil {
+		return err
+	}
+
+	idName := table.idColumns[0]
+	idField := info.ByName(idName)
+	if !idField.Valid {
+		return fmt.Errorf("ksql: can't set the ID from the sequence: the record has no attribute tagged as `%s`", idName)
+	}
+
+	rows, err := c.queryContext(ctx, op, opts, query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		err := fmt.Errorf("unexpected error when fetching the next value of the sequence `%s`", table.sequence)
+		if rows.Err() != nil {
+			err = rows.Err()
+		}
+
+		return err
+	}
+
+	err = rows.Scan(idScanner{
+		Attr: v.Elem().Field(idField.Index),
+		Name: idName,
+	})
+	if err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+// buildReturnedColumnsScanValues returns the scan targets for the columns
+// returned by the insert queries, where the IDs are loaded by the idScanner
+// and the generated columns directly into their attributes.
+func buildReturnedColumnsScanValues(
+	table Table,
+	v reflect.Value,
+	info structs.StructInfo,
+	returnedColumns []string,
+) (scanValues []interface{}) {
+	for i, col := range returnedColumns {
+		field := v.Elem().Field(info.ByName(col).Index)
+		if i < len(table.idColumns) {
+			scanValues = append(scanValues, idScanner{
+				Attr: field,
+				Name: col,
+			})
+			continue
+		}
+
+		scanValues = append(scanValues, field.Addr().Interface())
+	}
+
+	return scanValues
+}
+
+func buildNextSequenceValueQuery(dialect Dialect, sequenceName string) (string, []interface{}, error) {
+	switch capabilitiesOf(dialect).Sequences {
+	case SequenceNextval:
+		return "SELECT nextval(" + dialect.Placeholder(0) + ")", []interface{}{sequenceName}, nil
+	case SequenceNextValueFor:
+		return selectWithoutTable(dialect, "NEXT VALUE FOR "+sequenceName), nil, nil
+	case SequenceDotNextval:
+		return selectWithoutTable(dialect, sequenceName+".NEXTVAL"), nil, nil
+	default:
+		return "", nil, fmt.Errorf("ksql: sequences are not supported by the %s dialect", dialect.DriverName())
+	}
+}
+
 func (c DB) insertReturningIDs(
 	ctx context.Context,
+	op OpInfo,
+	opts queryOptions,
 	query string,
 	params []interface{},
 	scanValues []interface{},
 	idNames []string,
 ) error {
-	rows, err := c.db.QueryContext(ctx, query, params...)
+	rows, err := c.queryContext(ctx, op, opts, query, params...)
 	if err != nil {
 		return err
 	}
@@ -473,6 +1062,8 @@ func (c DB) insertReturningIDs(
 
 func (c DB) insertWithLastInsertID(
 	ctx context.Context,
+	op OpInfo,
+	opts queryOptions,
 	t reflect.Type,
 	v reflect.Value,
 	info structs.StructInfo,
@@ -481,7 +1072,7 @@ func (c DB) insertWithLastInsertID(
 	params []interface{},
 	idName string,
 ) error {
-	result, err := c.db.ExecContext(ctx, query, params...)
+	result, err := c.execContext(ctx, op, opts, query, params...)
 	if err != nil {
 		return err
 	}
@@ -491,30 +1082,17 @@ func (c DB) insertWithLastInsertID(
 		return err
 	}
 
-	vID := reflect.ValueOf(id)
-	tID := vID.Type()
-
-	fieldAddr := v.Elem().Field(info.ByName(idName).Index).Addr()
-	fieldType := fieldAddr.Type().Elem()
-
-	if !tID.ConvertibleTo(fieldType) {
-		return fmt.Errorf(
-			"can't convert last insert id of type int64 into field `%s` of type %v",
-			idName,
-			fieldType,
-		)
-	}
-
-	fieldAddr.Elem().Set(vID.Convert(fieldType))
-	return nil
+	return setIDAttr(v.Elem().Field(info.ByName(idName).Index), idName, id)
 }
 
 func (c DB) insertWithNoIDRetrieval(
 	ctx context.Context,
+	op OpInfo,
+	opts queryOptions,
 	query string,
 	params []interface{},
 ) error {
-	_, err := c.db.ExecContext(ctx, query, params...)
+	_, err := c.execContext(ctx, op, opts, query, params...)
 	return err
 }
 
@@ -535,8 +1113,8 @@ func assertStructPtr(t reflect.Type) error {
 // to be deleted as a struct, as a map or just pass the ID itself.
 //
 // For tables with composite keys you must pass the record
-// as a struct or a map so that KSQL can read all the composite keys
-// from it.
+// as a struct, a map or a `ksql.Key` so that KSQL can read all
+// the composite keys from it.
 //
 // The examples below should work for both types of tables:
 //
@@ -547,29 +1125,67 @@ func assertStructPtr(t reflect.Type) error {
 //         "post_id": post.ID,
 //     })
 //
+//     err := c.Delete(ctx, UserPostsTable, UserPostsTable.Key(user.ID, post.ID))
+//
 // The example below is shorter but will only work for tables with a single primary key:
 //
 //     err := c.Delete(ctx, UsersTable, user.ID)
 //
+// The ksql.QueryOption arguments are read from the context, see WithQueryOptions.
 func (c DB) Delete(
 	ctx context.Context,
 	table Table,
 	idOrRecord interface{},
 ) error {
-	if err := table.validate(); err != nil {
-		return fmt.Errorf("can't delete from ksql.Table: %s", err)
-	}
+	ctx, opts := popQueryOptions(ctx)
+	return c.delete(ctx, table, idOrRecord, opts)
+}
 
-	idMap, err := normalizeIDsAsMap(table.idColumns, idOrRecord)
+func (c DB) delete(
+	ctx context.Context,
+	table Table,
+	idOrRecord interface{},
+	opts []QueryOption,
+) error {
+	if err := checkUpdateByID(c.dialect, "Delete"); err != nil {
+		return err
+	}
+
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).delete(ctx, table, idOrRecord, opts)
+		})
+	}
+
+	o := newQueryOptions(opts)
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't delete from ksql.Table: %s", err)
+	}
+
+	idMap, err := normalizeIDsAsMap(table.idColumns, idOrRecord)
 	if err != nil {
 		return err
 	}
 
 	var query string
 	var params []interface{}
-	query, params = buildDeleteQuery(c.dialect, table, idMap)
+	if table.softDeleteColumn != "" {
+		query, params = buildSoftDeleteQuery(c.dialect, table, idMap, c.generators().Now())
+	} else {
+		query, params = buildDeleteQuery(c.dialect, table, idMap)
+	}
+	query, err = addStatementHints(c.dialect, table, query, o)
+	if err != nil {
+		return err
+	}
 
-	result, err := c.db.ExecContext(ctx, query, params...)
+	result, err := c.execContext(ctx, OpInfo{Method: "Delete", TableName: table.name}, o, query, params...)
+	if err == errDryRun {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -579,11 +1195,7 @@ func (c DB) Delete(
 		return fmt.Errorf("unable to check if the record was succesfully deleted: %s", err)
 	}
 
-	if n == 0 {
-		return ErrRecordNotFound
-	}
-
-	return err
+	return o.checkRowsAffected(n)
 }
 
 func normalizeIDsAsMap(idNames []string, idOrMap interface{}) (idMap map[string]interface{}, err error) {
@@ -591,6 +1203,13 @@ func normalizeIDsAsMap(idNames []string, idOrMap interface{}) (idMap map[string]
 		return nil, fmt.Errorf("internal ksql error: missing idNames")
 	}
 
+	if key, ok := idOrMap.(Key); ok {
+		if key.err != nil {
+			return nil, key.err
+		}
+		idOrMap = key.values
+	}
+
 	t := reflect.TypeOf(idOrMap)
 	if t.Kind() == reflect.Ptr {
 		v := reflect.ValueOf(idOrMap)
@@ -602,7 +1221,7 @@ func normalizeIDsAsMap(idNames []string, idOrMap interface{}) (idMap map[string]
 
 	switch t.Kind() {
 	case reflect.Struct:
-		idMap, err = ksqltest.StructToMap(idOrMap)
+		idMap, err = structs.StructToMap(idOrMap)
 		if err != nil {
 			return nil, errors.Wrapf(err, "could not get ID(s) from input record")
 		}
@@ -648,12 +1267,48 @@ func (c DB) Update(
 // Patch applies a partial update (explained below) to the given instance on the database by id.
 //
 // Partial updates will ignore any nil pointer attributes from the struct, updating only
-// the non nil pointers and non pointer attributes.
+// the non nil pointers and non pointer attributes. The sql.Null* attributes, e.g.
+// sql.NullString, with Valid set to false are also ignored just like nil pointers.
+//
+// If the record is a *ksql.Tracker only the columns changed since the
+// record started being tracked are updated, and if there are
+// no changes no query is sent to the database.
+//
+// The ksql.QueryOption arguments are read from the context, see WithQueryOptions.
 func (c DB) Patch(
 	ctx context.Context,
 	table Table,
 	record interface{},
 ) error {
+	ctx, opts := popQueryOptions(ctx)
+	return c.patch(ctx, table, record, opts)
+}
+
+func (c DB) patch(
+	ctx context.Context,
+	table Table,
+	record interface{},
+	opts []QueryOption,
+) error {
+	if err := checkUpdateByID(c.dialect, "Patch"); err != nil {
+		return err
+	}
+
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).patch(ctx, table, record, opts)
+		})
+	}
+
+	o := newQueryOptions(opts)
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
+	tracker, isTracker := record.(*Tracker)
+	if isTracker {
+		record = tracker.record
+	}
+
 	v := reflect.ValueOf(record)
 	t := v.Type()
 	tStruct := t
@@ -668,12 +1323,51 @@ func (c DB) Patch(
 		return err
 	}
 
-	query, params, err := buildUpdateQuery(c.dialect, table.name, info, record, table.idColumns...)
+	if err := c.validateRecord(ctx, record); err != nil {
+		return err
+	}
+
+	recordMap, err := structs.StructToMap(record)
 	if err != nil {
 		return err
 	}
 
-	result, err := c.db.ExecContext(ctx, query, params...)
+	if isTracker {
+		recordMap = tracker.filterChanges(recordMap, table.idColumns)
+	}
+
+	removeGeneratedColumns(info, recordMap)
+	err = removeImmutableColumns(info, recordMap, table.idColumns, o.strictImmutable)
+	if err != nil {
+		return err
+	}
+
+	err = encodeColumnValues(c.dialect, c.converters, info, recordMap)
+	if err != nil {
+		return err
+	}
+
+	if isTracker && len(recordMap) <= len(table.idColumns) {
+		// Nothing to update:
+		if o.rowsAffected != nil {
+			*o.rowsAffected = 0
+		}
+		return nil
+	}
+
+	query, params, err := buildUpdateQuery(c.dialect, table, info, recordMap, table.idColumns...)
+	if err != nil {
+		return err
+	}
+	query, err = addStatementHints(c.dialect, table, query, o)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.execContext(ctx, OpInfo{Method: "Patch", TableName: table.name}, o, query, params...)
+	if err == errDryRun {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -685,8 +1379,12 @@ func (c DB) Patch(
 			err,
 		)
 	}
-	if n < 1 {
-		return ErrRecordNotFound
+	if err := o.checkRowsAffected(n); err != nil {
+		return err
+	}
+
+	if isTracker && n > 0 {
+		tracker.Reset()
 	}
 
 	return nil
@@ -694,33 +1392,22 @@ func (c DB) Patch(
 
 func buildInsertQuery(
 	dialect Dialect,
+	converters map[string]Converter,
 	table Table,
+	method insertMethod,
 	t reflect.Type,
 	v reflect.Value,
 	info structs.StructInfo,
 	record interface{},
+	gen structs.Generators,
 ) (query string, params []interface{}, scanValues []interface{}, err error) {
-	recordMap, err := ksqltest.StructToMap(record)
+	recordMap, err := buildInsertRecordMap(dialect, converters, table, v, info, record, gen)
 	if err != nil {
 		return "", nil, nil, err
 	}
 
-	for _, fieldName := range table.idColumns {
-		field, found := recordMap[fieldName]
-		if !found {
-			continue
-		}
-
-		// Remove any ID field that was not set:
-		if reflect.ValueOf(field).IsZero() {
-			delete(recordMap, fieldName)
-		}
-	}
-
-	columnNames := []string{}
-	for col := range recordMap {
-		columnNames = append(columnNames, col)
-	}
+	// The columns are sorted so the same record always generates the same query:
+	columnNames := sortedKeys(recordMap)
 
 	params = make([]interface{}, len(recordMap))
 	valuesQuery := make([]string, len(recordMap))
@@ -729,8 +1416,9 @@ func buildInsertQuery(
 		params[i] = recordValue
 		if info.ByName(col).SerializeAsJSON {
 			params[i] = jsonSerializable{
-				DriverName: dialect.DriverName(),
-				Attr:       recordValue,
+				Dialect:   dialect,
+				Attr:      recordValue,
+				NilAsNull: info.ByName(col).NilAsNull,
 			}
 		}
 
@@ -743,41 +1431,41 @@ func buildInsertQuery(
 		escapedColumnNames = append(escapedColumnNames, dialect.Escape(col))
 	}
 
+	// The generated columns are also returned so the record is refreshed:
+	returnedColumns := append(append([]string{}, table.idColumns...), getGeneratedColumns(v.Elem(), info)...)
+
 	var returningQuery, outputQuery string
-	switch dialect.InsertMethod() {
+	switch method {
 	case insertWithReturning:
-		escapedIDNames := []string{}
-		for _, id := range table.idColumns {
-			escapedIDNames = append(escapedIDNames, dialect.Escape(id))
+		escapedNames := []string{}
+		for _, col := range returnedColumns {
+			escapedNames = append(escapedNames, dialect.Escape(col))
 		}
-		returningQuery = " RETURNING " + strings.Join(escapedIDNames, ", ")
+		returningQuery = " RETURNING " + strings.Join(escapedNames, ", ")
 
-		for _, id := range table.idColumns {
-			scanValues = append(
-				scanValues,
-				v.Elem().Field(info.ByName(id).Index).Addr().Interface(),
-			)
-		}
+		scanValues = buildReturnedColumnsScanValues(table, v, info, returnedColumns)
 	case insertWithOutput:
-		escapedIDNames := []string{}
-		for _, id := range table.idColumns {
-			escapedIDNames = append(escapedIDNames, "INSERTED."+dialect.Escape(id))
+		escapedNames := []string{}
+		for _, col := range returnedColumns {
+			escapedNames = append(escapedNames, "INSERTED."+dialect.Escape(col))
 		}
-		outputQuery = " OUTPUT " + strings.Join(escapedIDNames, ", ")
+		outputQuery = " OUTPUT " + strings.Join(escapedNames, ", ")
 
-		for _, id := range table.idColumns {
-			scanValues = append(
-				scanValues,
-				v.Elem().Field(info.ByName(id).Index).Addr().Interface(),
-			)
+		scanValues = buildReturnedColumnsScanValues(table, v, info, returnedColumns)
+	case insertWithScopeIdentity:
+		returningQuery, err = buildScopeIdentitySelect(dialect, table, columnNames, returnedColumns)
+		if err != nil {
+			return "", nil, nil, err
 		}
+
+		scanValues = buildReturnedColumnsScanValues(table, v, info, returnedColumns)
 	}
 
 	// Note that the outputQuery and the returningQuery depend
 	// on the selected driver, thus, they might be empty strings.
 	query = fmt.Sprintf(
 		"INSERT INTO %s (%s)%s VALUES (%s)%s",
-		dialect.Escape(table.name),
+		table.escapedName(dialect),
 		strings.Join(escapedColumnNames, ", "),
 		outputQuery,
 		strings.Join(valuesQuery, ", "),
@@ -787,17 +1475,228 @@ func buildInsertQuery(
 	return query, params, scanValues, nil
 }
 
-func buildUpdateQuery(
+// buildScopeIdentitySelect builds the SELECT sent after the INSERT for loading
+// the returned columns of tables with triggers on sqlserver, the IDs are
+// filtered by their params when set or by SCOPE_IDENTITY() otherwise.
+func buildScopeIdentitySelect(
 	dialect Dialect,
-	tableName string,
+	table Table,
+	insertedColumns []string,
+	returnedColumns []string,
+) (string, error) {
+	paramIdx := map[string]int{}
+	for i, col := range insertedColumns {
+		paramIdx[col] = i
+	}
+
+	var conditions []string
+	usedScopeIdentity := false
+	for _, idName := range table.idColumns {
+		value := "SCOPE_IDENTITY()"
+		if i, found := paramIdx[idName]; found {
+			value = dialect.Placeholder(i)
+		} else if usedScopeIdentity {
+			return "", fmt.Errorf(
+				"ksql: can't insert on a table WithTriggers leaving more than one ID unset, the IDs are: %v",
+				table.idColumns,
+			)
+		} else {
+			usedScopeIdentity = true
+		}
+
+		conditions = append(conditions, dialect.Escape(idName)+" = "+value)
+	}
+
+	escapedNames := []string{}
+	for _, col := range returnedColumns {
+		escapedNames = append(escapedNames, dialect.Escape(col))
+	}
+
+	return fmt.Sprintf(
+		"; SELECT %s FROM %s WHERE %s",
+		strings.Join(escapedNames, ", "),
+		table.escapedName(dialect),
+		strings.Join(conditions, " AND "),
+	), nil
+}
+
+// buildInsertRecordMap returns the values of the columns that should be
+// inserted, without the unset IDs and the generated columns.
+func buildInsertRecordMap(
+	dialect Dialect,
+	converters map[string]Converter,
+	table Table,
+	v reflect.Value,
 	info structs.StructInfo,
 	record interface{},
-	idFieldNames ...string,
-) (query string, args []interface{}, err error) {
-	recordMap, err := ksqltest.StructToMap(record)
+	gen structs.Generators,
+) (map[string]interface{}, error) {
+	recordMap, err := structs.StructToMap(record)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fieldName := range table.idColumns {
+		field, found := recordMap[fieldName]
+		if !found {
+			continue
+		}
+
+		// Remove any ID field that was not set:
+		if reflect.ValueOf(field).IsZero() {
+			delete(recordMap, fieldName)
+		}
+	}
+
+	applyDefaultValues(v, info, recordMap, gen)
+	removeGeneratedColumns(info, recordMap)
+
+	err = encodeColumnValues(dialect, converters, info, recordMap)
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+
+	return recordMap, nil
+}
+
+// applyDefaultValues handles the attributes using the `default` modifier
+// whose values are unset, i.e. nil pointers and zero values:
+//
+// - For `ksql:"name,default"` the column is removed from the
+// recordMap so the database can use the default of the column;
+// - For `ksql:"name,default=<literal>"` the literal is written to the
+// record and to the recordMap so the caller can see the inserted value.
+func applyDefaultValues(v reflect.Value, info structs.StructInfo, recordMap map[string]interface{}, gen structs.Generators) {
+	structValue := v.Elem()
+	for i := 0; i < structValue.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.HasDefault {
+			continue
+		}
+
+		field := structValue.Field(i)
+		if !field.IsZero() {
+			continue
+		}
+
+		if fieldInfo.DefaultValue == nil {
+			delete(recordMap, fieldInfo.Name)
+			continue
+		}
+
+		field.Set(fieldInfo.DefaultValue(gen))
+		recordMap[fieldInfo.Name], _ = structs.FieldValue(field)
+	}
+}
+
+// encodeColumnValues converts the values of the attributes tagged with the
+// `blob`, `decimal`, `hstore`, `duration` and `sqltype` modifiers to what
+// the drivers expect, the other values are converted with structs.DriverValue.
+func encodeColumnValues(dialect Dialect, converters map[string]Converter, info structs.StructInfo, recordMap map[string]interface{}) (err error) {
+	for col, value := range recordMap {
+		fieldInfo := info.ByName(col)
+		switch {
+		case fieldInfo.Converter != "":
+			recordMap[col], err = encodeWithConverter(converters, fieldInfo, value)
+			if err != nil {
+				return err
+			}
+		case fieldInfo.Blob:
+			recordMap[col], err = readBlob(value)
+			if err != nil {
+				return fmt.Errorf("error reading the blob attribute '%s': %w", col, err)
+			}
+		case fieldInfo.Decimal:
+			recordMap[col], err = encodeDecimal(value)
+			if err != nil {
+				return fmt.Errorf("error encoding the decimal attribute '%s': %w", col, err)
+			}
+		case fieldInfo.Hstore:
+			recordMap[col] = encodeHstore(value)
+		case fieldInfo.Duration:
+			recordMap[col] = encodeDuration(dialect, value, fieldInfo.DurationUnit)
+		case fieldInfo.SQLType != "":
+			recordMap[col] = encodeTypedParam(dialect, value, fieldInfo.SQLType)
+		case !fieldInfo.SerializeAsJSON:
+			recordMap[col] = structs.DriverValue(value)
+		}
+	}
+
+	return nil
+}
+
+// removeGeneratedColumns removes the attributes tagged with
+// the `generated` modifier since they can't be written.
+func removeGeneratedColumns(info structs.StructInfo, recordMap map[string]interface{}) {
+	for col := range recordMap {
+		if info.ByName(col).Generated {
+			delete(recordMap, col)
+		}
+	}
+}
+
+// getGeneratedColumns returns the columns of the attributes tagged
+// with the `generated` modifier in the order they appear on the struct.
+func getGeneratedColumns(structValue reflect.Value, info structs.StructInfo) []string {
+	var columns []string
+	for i := 0; i < structValue.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if fieldInfo.Generated {
+			columns = append(columns, fieldInfo.Name)
+		}
+	}
+	return columns
+}
+
+// removeImmutableColumns removes the attributes tagged with the `immutable`
+// modifier from the recordMap, or returns an error if strict is true and any
+// of them has a non-zero value. The ID columns are never removed.
+func removeImmutableColumns(info structs.StructInfo, recordMap map[string]interface{}, idNames []string, strict bool) error {
+	if strict {
+		if err := checkImmutableColumns(info, recordMap, idNames); err != nil {
+			return err
+		}
+	}
+
+	for col := range recordMap {
+		if info.ByName(col).Immutable && !containsString(idNames, col) {
+			delete(recordMap, col)
+		}
+	}
+
+	return nil
+}
+
+func checkImmutableColumns(info structs.StructInfo, recordMap map[string]interface{}, idNames []string) error {
+	for _, col := range sortedKeys(recordMap) {
+		if !info.ByName(col).Immutable || containsString(idNames, col) {
+			continue
+		}
+
+		if !reflect.ValueOf(recordMap[col]).IsZero() {
+			return fmt.Errorf("%w: '%s'", ErrImmutableColumn, col)
+		}
 	}
+
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func buildUpdateQuery(
+	dialect Dialect,
+	table Table,
+	info structs.StructInfo,
+	recordMap map[string]interface{},
+	idFieldNames ...string,
+) (query string, args []interface{}, err error) {
 	numAttrs := len(recordMap)
 	args = make([]interface{}, numAttrs)
 	numNonIDArgs := numAttrs - len(idFieldNames)
@@ -815,18 +1714,16 @@ func buildUpdateQuery(
 		delete(recordMap, fieldName)
 	}
 
-	keys := []string{}
-	for key := range recordMap {
-		keys = append(keys, key)
-	}
+	keys := sortedKeys(recordMap)
 
 	var setQuery []string
 	for i, k := range keys {
 		recordValue := recordMap[k]
-		if info.ByName(k).SerializeAsJSON {
+		if info.ByName(k).SerializeAsJSON && recordValue != nil {
 			recordValue = jsonSerializable{
-				DriverName: dialect.DriverName(),
-				Attr:       recordValue,
+				Dialect:   dialect,
+				Attr:      recordValue,
+				NilAsNull: info.ByName(k).NilAsNull,
 			}
 		}
 		args[i] = recordValue
@@ -839,9 +1736,9 @@ func buildUpdateQuery(
 
 	query = fmt.Sprintf(
 		"UPDATE %s SET %s WHERE %s",
-		dialect.Escape(tableName),
+		table.escapedName(dialect),
 		strings.Join(setQuery, ", "),
-		strings.Join(whereQuery, ", "),
+		strings.Join(whereQuery, " AND "),
 	)
 
 	return query, args, nil
@@ -849,7 +1746,25 @@ func buildUpdateQuery(
 
 // Exec just runs an SQL command on the database returning no rows.
 func (c DB) Exec(ctx context.Context, query string, params ...interface{}) (Result, error) {
-	return c.db.ExecContext(ctx, query, params...)
+	if c.requiresSessionTx() {
+		var result Result
+		err := c.Transaction(ctx, func(db Provider) (err error) {
+			result, err = db.Exec(ctx, query, params...)
+			return err
+		})
+		return result, err
+	}
+
+	opts, params := extractQueryOptions(params)
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	result, err := c.execContext(ctx, OpInfo{Method: "Exec"}, opts, query, params...)
+	if err == errDryRun {
+		return dryRunResult{}, nil
+	}
+
+	return result, err
 }
 
 // Transaction just runs an SQL command on the database returning no rows.
@@ -862,8 +1777,20 @@ func (c DB) Transaction(ctx context.Context, fn func(Provider) error) error {
 		if err != nil {
 			return err
 		}
+
+		dbCopy := c
+		dbCopy.db = tx
+
+		// The session variables outlive the transaction on some dialects,
+		// so the ones set are reset before the connection is released:
+		var sessionVars []string
 		defer func() {
 			if r := recover(); r != nil {
+				if resetErr := dbCopy.resetSessionVars(ctx, sessionVars); resetErr != nil {
+					r = errors.Wrap(resetErr,
+						fmt.Sprintf("unable to reset the session variables after panic with value: %v", r),
+					)
+				}
 				rollbackErr := tx.Rollback(ctx)
 				if rollbackErr != nil {
 					r = errors.Wrap(rollbackErr,
@@ -874,10 +1801,18 @@ func (c DB) Transaction(ctx context.Context, fn func(Provider) error) error {
 			}
 		}()
 
-		dbCopy := c
-		dbCopy.db = tx
+		sessionVars, err = dbCopy.setSessionVars(ctx)
+		if err == nil {
+			err = dbCopy.setApplicationName(ctx)
+		}
+		if err == nil {
+			err = fn(dbCopy)
+		}
 
-		err = fn(dbCopy)
+		resetErr := dbCopy.resetSessionVars(ctx, sessionVars)
+		if err == nil {
+			err = resetErr
+		}
 		if err != nil {
 			rollbackErr := tx.Rollback(ctx)
 			if rollbackErr != nil {
@@ -895,13 +1830,151 @@ func (c DB) Transaction(ctx context.Context, fn func(Provider) error) error {
 	}
 }
 
-// Close implements the io.Closer interface
-func (c DB) Close() error {
-	closer, ok := c.db.(io.Closer)
-	if ok {
-		return closer.Close()
+// WithConn pins all the operations executed by the Provider received by
+// the callback to a single connection of the pool, which is necessary
+// for features bound to a connection, e.g. temporary tables, session
+// variables and advisory locks:
+//
+//	err := db.WithConn(ctx, func(db ksql.Provider) error {
+//		_, err := db.Exec(ctx, "CREATE TEMPORARY TABLE tmp_users (id INTEGER)")
+//		if err != nil {
+//			return err
+//		}
+//
+//		// ... all queries here will see the tmp_users table
+//	})
+//
+// The connection is returned to the pool after the callback returns.
+// Transactions started inside the callback also run on this connection,
+// and if WithConn is called inside a transaction the transaction
+// connection is used.
+//
+// This feature is only available for adapters that implement
+// the `ksql.ConnAcquirer` interface, such as kpgx and the adapters
+// based on the database/sql package.
+func (c DB) WithConn(ctx context.Context, fn func(Provider) error) error {
+	if _, isTx := c.db.(Tx); isTx || c.connPinned {
+		// We are already using a single connection:
+		return fn(c)
+	}
+
+	acquirer, ok := c.db.(ConnAcquirer)
+	if !ok {
+		return fmt.Errorf("can't acquire connection: The DBAdapter doesn't implement the ConnAcquirer interface")
 	}
-	return nil
+
+	conn, err := acquirer.AcquireConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	dbCopy := c
+	dbCopy.db = conn
+	dbCopy.connPinned = true
+	return fn(dbCopy)
+}
+
+// Listen subscribes to the input channel and returns a Go channel
+// where all notifications sent to it will be delivered, e.g.:
+//
+//	notifications, err := db.Listen(ctx, "cache_invalidation")
+//	if err != nil {
+//		return err
+//	}
+//
+//	for n := range notifications {
+//		cache.Delete(n.Payload)
+//	}
+//
+// The subscription lasts until the input context is canceled,
+// after which the returned channel is closed.
+//
+// This feature is only available for adapters that implement
+// the `ksql.Listener` interface, such as kpgx.
+func (c DB) Listen(ctx context.Context, channel string) (<-chan Notification, error) {
+	listener, ok := c.db.(Listener)
+	if !ok {
+		return nil, fmt.Errorf("can't listen on channel: The DBAdapter doesn't implement the Listener interface")
+	}
+
+	if channel == "" {
+		return nil, fmt.Errorf("can't listen on channel: the channel name cannot be an empty string")
+	}
+
+	return listener.Listen(ctx, channel)
+}
+
+// checkNestedStructAliases rejects the queries for nested structs whose SELECT part
+// was written by the user without any `<tablename>.<column>` alias, so the mapping
+// error is reported before the query is sent and can't be hidden by other errors.
+//
+// The columns are only fully checked by shouldAliasNestedStructs after the query runs.
+func (c DB) checkNestedStructAliases(structType reflect.Type, info structs.StructInfo, parsed parsedQuery) error {
+	if !info.IsNestedStruct || c.aliasNestedStructs || parsed.autoSelect || parsed.firstToken != "SELECT" {
+		return nil
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid {
+			continue
+		}
+
+		// The alias is escaped, so we look for the opening quote followed by the tablename:
+		escaped := c.dialect.Escape(fieldInfo.Name + ".")
+		if strings.Contains(parsed.main, escaped[:len(escaped)-1]) || strings.Contains(parsed.main, "'"+fieldInfo.Name+".") {
+			return nil
+		}
+	}
+
+	return MappingError{
+		StructType: structType,
+		Err: fmt.Errorf(
+			"can't generate SELECT query for nested struct: when using this feature omit the SELECT part of the query" +
+				" or alias all the columns as `<tablename>.<column>`",
+		),
+	}
+}
+
+// shouldAliasNestedStructs decides whether the columns of a query for nested structs
+// should be matched by their `<tablename>.<column>` aliases instead of their position.
+//
+// When the user writes the SELECT part of the query for nested structs we can't
+// rely on the order of the columns, so in this case all of them must be aliased.
+func (c DB) shouldAliasNestedStructs(rows Rows, structType reflect.Type, info structs.StructInfo, parsed parsedQuery) (bool, error) {
+	if !info.IsNestedStruct {
+		return false, nil
+	}
+
+	if c.aliasNestedStructs {
+		return true, nil
+	}
+
+	if parsed.autoSelect || parsed.firstToken != "SELECT" {
+		return false, nil
+	}
+
+	names, err := rows.Columns()
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range names {
+		sep := strings.Index(name, ".")
+		if sep == -1 || !info.ByName(name[:sep]).Valid {
+			return false, MappingError{
+				StructType: structType,
+				Column:     name,
+				Err: fmt.Errorf(
+					"can't generate SELECT query for nested struct: when using this feature omit the SELECT part of the query" +
+						" or alias all the columns as `<tablename>.<column>`",
+				),
+			}
+		}
+	}
+
+	return true, nil
 }
 
 type nopScanner struct{}
@@ -912,10 +1985,23 @@ func (nopScanner) Scan(value interface{}) error {
 	return nil
 }
 
-func scanRows(dialect Dialect, rows Rows, record interface{}) error {
+// scanOptions describes how the columns of a row are matched with the attributes of a struct
+type scanOptions struct {
+	// aliasNestedStructs makes nested structs be matched by the
+	// `<tablename>.<column>` aliases instead of by position
+	aliasNestedStructs bool
+
+	// strict makes the scan fail if a column has no matching attribute
+	strict bool
+
+	// converters are used for the attributes tagged with `converter`
+	converters map[string]Converter
+}
+
+func scanRows(dialect Dialect, rows Rows, record interface{}, opts scanOptions) error {
 	v := reflect.ValueOf(record)
 	t := v.Type()
-	return scanRowsFromType(dialect, rows, record, t, v)
+	return scanRowsFromType(dialect, rows, record, t, v, opts)
 }
 
 func scanRowsFromType(
@@ -924,6 +2010,7 @@ func scanRowsFromType(
 	record interface{},
 	t reflect.Type,
 	v reflect.Value,
+	opts scanOptions,
 ) error {
 	if t.Kind() != reflect.Ptr {
 		return fmt.Errorf("ksql: expected record to be a pointer to struct, but got: %T", record)
@@ -941,12 +2028,26 @@ func scanRowsFromType(
 		return err
 	}
 
-	var scanArgs []interface{}
-	if info.IsNestedStruct {
+	buf := getScanArgsBuffer()
+	defer putScanArgsBuffer(buf)
+
+	scanArgs := buf.args
+	if info.IsNestedStruct && opts.aliasNestedStructs {
+		names, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		// This version matches the columns using the `<tablename>.<column>`
+		// aliases so it works with any order of attributes/columns.
+		scanArgs, err = getScanArgsFromAliases(dialect, opts.converters, scanArgs, names, t, v, info, opts.strict)
+		if err != nil {
+			return err
+		}
+	} else if info.IsNestedStruct {
 		// This version is positional meaning that it expect the arguments
 		// to follow an specific order. It's ok because we don't allow the
 		// user to type the "SELECT" part of the query for nested structs.
-		scanArgs, err = getScanArgsForNestedStructs(dialect, rows, t, v, info)
+		scanArgs, err = getScanArgsForNestedStructs(dialect, opts.converters, scanArgs, rows, t, v, info)
 		if err != nil {
 			return err
 		}
@@ -957,14 +2058,17 @@ func scanRowsFromType(
 		}
 		// Since this version uses the names of the columns it works
 		// with any order of attributes/columns.
-		scanArgs = getScanArgsFromNames(dialect, names, v, info)
+		scanArgs, err = getScanArgsFromNames(dialect, opts.converters, scanArgs, names, t, v, info, opts.strict)
+		if err != nil {
+			return err
+		}
 	}
+	buf.args = scanArgs
 
 	return rows.Scan(scanArgs...)
 }
 
-func getScanArgsForNestedStructs(dialect Dialect, rows Rows, t reflect.Type, v reflect.Value, info structs.StructInfo) ([]interface{}, error) {
-	scanArgs := []interface{}{}
+func getScanArgsForNestedStructs(dialect Dialect, converters map[string]Converter, scanArgs []interface{}, rows Rows, t reflect.Type, v reflect.Value, info structs.StructInfo) ([]interface{}, error) {
 	for i := 0; i < v.NumField(); i++ {
 		if !info.ByIndex(i).Valid {
 			continue
@@ -985,13 +2089,7 @@ func getScanArgsForNestedStructs(dialect Dialect, rows Rows, t reflect.Type, v r
 
 			valueScanner := nopScannerValue
 			if fieldInfo.Valid {
-				valueScanner = nestedStructValue.Field(fieldInfo.Index).Addr().Interface()
-				if fieldInfo.SerializeAsJSON {
-					valueScanner = &jsonSerializable{
-						DriverName: dialect.DriverName(),
-						Attr:       valueScanner,
-					}
-				}
+				valueScanner = newFieldScanner(dialect, converters, fieldInfo, nestedStructValue.Field(fieldInfo.Index))
 			}
 
 			scanArgs = append(scanArgs, valueScanner)
@@ -1001,46 +2099,152 @@ func getScanArgsForNestedStructs(dialect Dialect, rows Rows, t reflect.Type, v r
 	return scanArgs, nil
 }
 
-func getScanArgsFromNames(dialect Dialect, names []string, v reflect.Value, info structs.StructInfo) []interface{} {
-	scanArgs := []interface{}{}
+func getScanArgsFromAliases(
+	dialect Dialect,
+	converters map[string]Converter,
+	scanArgs []interface{},
+	names []string,
+	t reflect.Type,
+	v reflect.Value,
+	info structs.StructInfo,
+	strict bool,
+) ([]interface{}, error) {
+	for _, name := range names {
+		valueScanner := nopScannerValue
+
+		sep := strings.Index(name, ".")
+		if sep == -1 || !info.ByName(name[:sep]).Valid {
+			if strict {
+				return nil, newStrictScanError(name, t)
+			}
+			scanArgs = append(scanArgs, valueScanner)
+			continue
+		}
+
+		nestedStructInfo := info.ByName(name[:sep])
+
+		nestedStructType := t.Field(nestedStructInfo.Index).Type
+		nestedStructTagInfo, err := structs.GetTagInfo(nestedStructType)
+		if err != nil {
+			return nil, err
+		}
+
+		fieldInfo := nestedStructTagInfo.ByName(name[sep+1:])
+		if !fieldInfo.Valid && strict {
+			return nil, newStrictScanError(name, t)
+		}
+		if fieldInfo.Valid {
+			nestedStructValue := v.Field(nestedStructInfo.Index)
+			valueScanner = newFieldScanner(dialect, converters, fieldInfo, nestedStructValue.Field(fieldInfo.Index))
+		}
+
+		scanArgs = append(scanArgs, valueScanner)
+	}
+
+	return scanArgs, nil
+}
+
+func getScanArgsFromNames(
+	dialect Dialect,
+	converters map[string]Converter,
+	scanArgs []interface{},
+	names []string,
+	t reflect.Type,
+	v reflect.Value,
+	info structs.StructInfo,
+	strict bool,
+) ([]interface{}, error) {
 	for _, name := range names {
 		fieldInfo := info.ByName(name)
+		if !fieldInfo.Valid && strict {
+			return nil, newStrictScanError(name, t)
+		}
 
 		valueScanner := nopScannerValue
 		if fieldInfo.Valid {
-			valueScanner = v.Field(fieldInfo.Index).Addr().Interface()
-			if fieldInfo.SerializeAsJSON {
-				valueScanner = &jsonSerializable{
-					DriverName: dialect.DriverName(),
-					Attr:       valueScanner,
-				}
-			}
+			valueScanner = newFieldScanner(dialect, converters, fieldInfo, v.Field(fieldInfo.Index))
 		}
 
 		scanArgs = append(scanArgs, valueScanner)
 	}
 
-	return scanArgs
+	return scanArgs, nil
+}
+
+// newFieldScanner returns the scan argument for loading a struct field,
+// wrapping it when its modifiers require a special decoding.
+func newFieldScanner(dialect Dialect, converters map[string]Converter, fieldInfo *structs.FieldInfo, field reflect.Value) interface{} {
+	switch {
+	case fieldInfo.Converter != "":
+		return converterScanner{
+			Attr:      field,
+			Name:      fieldInfo.Converter,
+			Converter: converters[fieldInfo.Converter],
+		}
+	case fieldInfo.SerializeAsJSON:
+		return &jsonSerializable{
+			Dialect: dialect,
+			Attr:    field.Addr().Interface(),
+		}
+	case fieldInfo.Blob:
+		return blobScanner{Attr: field}
+	case fieldInfo.Decimal:
+		return decimalScanner{Attr: field}
+	case fieldInfo.Hstore:
+		return hstoreScanner{Attr: field}
+	case fieldInfo.Duration:
+		return durationScanner{
+			Attr:    field,
+			Dialect: dialect,
+			Unit:    fieldInfo.DurationUnit,
+		}
+	case fieldInfo.Trim:
+		return trimScanner{Attr: field}
+	case isBoolField(field):
+		return boolScanner{Attr: field}
+	default:
+		return field.Addr().Interface()
+	}
+}
+
+func newStrictScanError(column string, structType reflect.Type) error {
+	return MappingError{
+		StructType: structType,
+		Column:     column,
+		Err:        fmt.Errorf("the column returned by the query has no matching attribute, and the StrictScan option is enabled"),
+	}
 }
 
 func buildDeleteQuery(
 	dialect Dialect,
 	table Table,
 	idMap map[string]interface{},
+) (query string, params []interface{}) {
+	whereQuery, params := buildWhereByIDs(dialect, table.idColumns, idMap)
+
+	return fmt.Sprintf(
+		"DELETE FROM %s WHERE %s",
+		table.escapedName(dialect),
+		whereQuery,
+	), params
+}
+
+// buildWhereByIDs builds the conditions for matching a
+// single record using its ID columns, without the WHERE keyword.
+func buildWhereByIDs(
+	dialect Dialect,
+	idColumns []string,
+	idMap map[string]interface{},
 ) (query string, params []interface{}) {
 	whereQuery := []string{}
-	for i, idName := range table.idColumns {
+	for i, idName := range idColumns {
 		whereQuery = append(whereQuery, fmt.Sprintf(
 			"%s = %s", dialect.Escape(idName), dialect.Placeholder(i),
 		))
 		params = append(params, idMap[idName])
 	}
 
-	return fmt.Sprintf(
-		"DELETE FROM %s WHERE %s",
-		dialect.Escape(table.name),
-		strings.Join(whereQuery, " AND "),
-	), params
+	return strings.Join(whereQuery, " AND "), params
 }
 
 // We implemented this function instead of using
@@ -1063,9 +2267,22 @@ func buildSelectQuery(
 	dialect Dialect,
 	structType reflect.Type,
 	info structs.StructInfo,
+	aliasNestedStructs bool,
+	opts queryOptions,
 	selectQueryCache *sync.Map,
 ) (query string, err error) {
-	if data, found := selectQueryCache.Load(structType); found {
+	if len(opts.extraSelects) > 0 || len(opts.columns) > 0 {
+		// Queries with extra expressions or projections are not cached
+		// since these options are usually different on each call:
+		return buildSelectQueryWithExtras(dialect, structType, info, opts.extraSelects, opts.columns)
+	}
+
+	var cacheKey interface{} = structType
+	if info.IsNestedStruct && aliasNestedStructs {
+		cacheKey = aliasedSelectCacheKey{structType}
+	}
+
+	if data, found := selectQueryCache.Load(cacheKey); found && !opts.noCache {
 		if selectQuery, ok := data.(string); !ok {
 			return "", fmt.Errorf("invalid cache entry, expected type string, found %T", data)
 		} else {
@@ -1074,27 +2291,137 @@ func buildSelectQuery(
 	}
 
 	if info.IsNestedStruct {
-		query, err = buildSelectQueryForNestedStructs(dialect, structType, info)
+		query, err = buildSelectQueryForNestedStructs(dialect, structType, info, aliasNestedStructs, nil)
 		if err != nil {
 			return "", err
 		}
 	} else {
-		query = buildSelectQueryForPlainStructs(dialect, structType, info)
+		query = buildSelectQueryForPlainStructs(dialect, structType, info, nil)
 	}
 
-	selectQueryCache.Store(structType, query)
+	if !opts.noCache {
+		selectQueryCache.Store(cacheKey, query)
+	}
 	return query, nil
 }
 
+// buildSelectQueryWithExtras builds the SELECT part of the query
+// appending the extra expressions passed with `ksql.AddSelect()`
+// and omitting the columns that are aliased by these expressions
+// as well as the ones not listed with `ksql.Columns()`, if used.
+//
+// Nested structs are always aliased in this case since
+// the extra columns would break the positional matching.
+func buildSelectQueryWithExtras(
+	dialect Dialect,
+	structType reflect.Type,
+	info structs.StructInfo,
+	extraSelects []string,
+	columns []string,
+) (query string, err error) {
+	skip := map[string]bool{}
+	if len(columns) > 0 {
+		skip, err = columnsToSkip(structType, info, columns)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, expression := range extraSelects {
+		if alias := selectAlias(expression); alias != "" {
+			skip[alias] = true
+		}
+	}
+
+	if info.IsNestedStruct {
+		query, err = buildSelectQueryForNestedStructs(dialect, structType, info, true, skip)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		query = buildSelectQueryForPlainStructs(dialect, structType, info, skip)
+	}
+
+	if len(extraSelects) == 0 {
+		return query, nil
+	}
+
+	// Replacing the trailing space with the extra expressions:
+	return query[:len(query)-1] + ", " + strings.Join(extraSelects, ", ") + " ", nil
+}
+
+// columnsToSkip returns the columns of the struct that were not
+// listed with `ksql.Columns()`, using the `<tablename>.<column>`
+// form for the attributes of nested structs.
+func columnsToSkip(structType reflect.Type, info structs.StructInfo, columns []string) (map[string]bool, error) {
+	selected := map[string]bool{}
+	for _, col := range columns {
+		selected[col] = true
+	}
+
+	skip := map[string]bool{}
+	for i := 0; i < structType.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid {
+			continue
+		}
+
+		if !info.IsNestedStruct {
+			if !selected[fieldInfo.Name] {
+				skip[fieldInfo.Name] = true
+			}
+			delete(selected, fieldInfo.Name)
+			continue
+		}
+
+		nestedInfo, err := structs.GetTagInfo(structType.Field(i).Type)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < structType.Field(i).Type.NumField(); j++ {
+			nestedFieldInfo := nestedInfo.ByIndex(j)
+			if !nestedFieldInfo.Valid {
+				continue
+			}
+
+			name := fieldInfo.Name + "." + nestedFieldInfo.Name
+			if !selected[name] {
+				skip[name] = true
+			}
+			delete(selected, name)
+		}
+	}
+
+	for _, col := range columns {
+		if selected[col] {
+			return nil, MappingError{
+				StructType: structType,
+				Column:     col,
+				Err:        fmt.Errorf("the column passed to Columns() has no matching attribute on the struct"),
+			}
+		}
+	}
+
+	return skip, nil
+}
+
+// aliasedSelectCacheKey is used for caching the SELECT queries of
+// nested structs built with the `WithAliasedNestedStructs()` option
+// separately from the ones built without it.
+type aliasedSelectCacheKey struct {
+	structType reflect.Type
+}
+
 func buildSelectQueryForPlainStructs(
 	dialect Dialect,
 	structType reflect.Type,
 	info structs.StructInfo,
+	skip map[string]bool,
 ) string {
 	var fields []string
 	for i := 0; i < structType.NumField(); i++ {
 		fieldInfo := info.ByIndex(i)
-		if !fieldInfo.Valid {
+		if !fieldInfo.Valid || skip[fieldInfo.Name] {
 			continue
 		}
 
@@ -1108,6 +2435,8 @@ func buildSelectQueryForNestedStructs(
 	dialect Dialect,
 	structType reflect.Type,
 	info structs.StructInfo,
+	aliasNestedStructs bool,
+	skip map[string]bool,
 ) (string, error) {
 	var fields []string
 	for i := 0; i < structType.NumField(); i++ {
@@ -1132,14 +2461,16 @@ func buildSelectQueryForNestedStructs(
 
 		for j := 0; j < structType.Field(i).Type.NumField(); j++ {
 			fieldInfo := nestedStructTagInfo.ByIndex(j)
-			if !fieldInfo.Valid {
+			if !fieldInfo.Valid || skip[nestedStructName+"."+fieldInfo.Name] {
 				continue
 			}
 
-			fields = append(
-				fields,
-				dialect.Escape(nestedStructName)+"."+dialect.Escape(fieldInfo.Name),
-			)
+			field := dialect.Escape(nestedStructName) + "." + dialect.Escape(fieldInfo.Name)
+			if aliasNestedStructs {
+				field += " AS " + dialect.Escape(nestedStructName+"."+fieldInfo.Name)
+			}
+
+			fields = append(fields, field)
 		}
 	}
 
@@ -1,6 +1,10 @@
 package ksql
 
 import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/ditointernet/go-assert"
@@ -40,3 +44,539 @@ func TestNewAdapterWith(t *testing.T) {
 		assert.NotEqual(t, nil, err)
 	})
 }
+
+func TestListen(t *testing.T) {
+	t.Run("should report error if the adapter doesn't implement the Listener interface", func(t *testing.T) {
+		db := newDB(t, "postgres", DBAdapter(nil))
+
+		_, err := db.Listen(context.Background(), "fake_channel")
+		tt.AssertErrContains(t, err, "Listener interface")
+	})
+}
+
+type mockConnAcquirer struct {
+	mockCloser
+	AcquireConnFn func(ctx context.Context) (Conn, error)
+}
+
+func (m mockConnAcquirer) AcquireConn(ctx context.Context) (Conn, error) {
+	return m.AcquireConnFn(ctx)
+}
+
+func TestWithConn(t *testing.T) {
+	t.Run("should run all operations on the acquired connection", func(t *testing.T) {
+		var queries []string
+		var acquired, released int
+		db := newDB(t, "postgres", mockConnAcquirer{
+			mockCloser: mockCloser{
+				mockTxBeginner: mockTxBeginner{
+					mockDBAdapter: mockDBAdapter{
+						ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+							t.Fatalf("the query should have been executed on the connection: %s", query)
+							return nil, nil
+						},
+					},
+				},
+			},
+			AcquireConnFn: func(ctx context.Context) (Conn, error) {
+				acquired++
+				return mockCloser{
+					mockTxBeginner: mockTxBeginner{
+						mockDBAdapter: mockDBAdapter{
+							ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+								queries = append(queries, query)
+								return NewMockResult(0, 0), nil
+							},
+						},
+					},
+					CloseFn: func() error {
+						released++
+						return nil
+					},
+				}, nil
+			},
+		})
+
+		err := db.WithConn(context.Background(), func(p Provider) error {
+			_, err := p.Exec(context.Background(), "SET search_path TO tenant1")
+			if err != nil {
+				return err
+			}
+
+			// Nested calls should reuse the same connection:
+			return p.(DB).WithConn(context.Background(), func(p Provider) error {
+				_, err := p.Exec(context.Background(), "SELECT 1")
+				return err
+			})
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{"SET search_path TO tenant1", "SELECT 1"})
+		tt.AssertEqual(t, acquired, 1)
+		tt.AssertEqual(t, released, 1)
+	})
+
+	t.Run("should report error if the adapter doesn't implement the ConnAcquirer interface", func(t *testing.T) {
+		db := newDB(t, "postgres", mockDBAdapter{})
+
+		err := db.WithConn(context.Background(), func(p Provider) error {
+			return nil
+		})
+		tt.AssertErrContains(t, err, "ConnAcquirer interface")
+	})
+}
+
+func TestInsertWithSequence(t *testing.T) {
+	type userRecord struct {
+		ID   uint   `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	type call struct {
+		query  string
+		params []interface{}
+	}
+
+	newAdapter := func(calls *[]call) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				*calls = append(*calls, call{query: query, params: args})
+				return NewMockResult(0, 1), nil
+			},
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				*calls = append(*calls, call{query: query, params: args})
+				return newMockRows([]string{"nextval"}, []interface{}{int64(42)}), nil
+			},
+		}
+	}
+
+	t.Run("should fetch the ID from the sequence before inserting", func(t *testing.T) {
+		var calls []call
+		db := newDB(t, "postgres", newAdapter(&calls))
+
+		u := userRecord{Name: "fake-name"}
+		err := db.Insert(context.Background(), NewTable("users").WithSequence("users_id_seq"), &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, uint(42))
+		tt.AssertEqual(t, len(calls), 2)
+		tt.AssertEqual(t, calls[0], call{query: "SELECT nextval($1)", params: []interface{}{"users_id_seq"}})
+		tt.AssertEqual(t, strings.HasPrefix(calls[1].query, `INSERT INTO "users"`), true)
+		tt.AssertEqual(t, strings.Contains(calls[1].query, "RETURNING"), false)
+		tt.AssertEqual(t, len(calls[1].params), 2)
+	})
+
+	t.Run("should use NEXT VALUE FOR on sqlserver", func(t *testing.T) {
+		var calls []call
+		db := newDB(t, "sqlserver", newAdapter(&calls))
+
+		u := userRecord{Name: "fake-name"}
+		err := db.Insert(context.Background(), NewTable("users").WithSequence("dbo.users_seq"), &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, uint(42))
+		tt.AssertEqual(t, calls[0].query, "SELECT NEXT VALUE FOR dbo.users_seq")
+		tt.AssertEqual(t, strings.Contains(calls[1].query, "OUTPUT"), false)
+	})
+
+	t.Run("should not consume the sequence for records with explicit IDs", func(t *testing.T) {
+		var calls []call
+		db := newDB(t, "postgres", newAdapter(&calls))
+
+		u := userRecord{ID: 7, Name: "fake-name"}
+		err := db.Insert(context.Background(), NewTable("users").WithSequence("users_id_seq"), &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, uint(7))
+		tt.AssertEqual(t, len(calls), 1)
+		tt.AssertEqual(t, strings.HasPrefix(calls[0].query, `INSERT INTO "users"`), true)
+	})
+
+	t.Run("should report errors for unsupported configurations", func(t *testing.T) {
+		var calls []call
+		db := newDB(t, "sqlite3", newAdapter(&calls))
+
+		err := db.Insert(context.Background(), NewTable("users").WithSequence("users_id_seq"), &userRecord{})
+		tt.AssertErrContains(t, err, "sequences", "sqlite3")
+
+		db = newDB(t, "postgres", newAdapter(&calls))
+		err = db.Insert(context.Background(), NewTable("user_permissions", "user_id", "perm_id").WithSequence("seq"), &userRecord{})
+		tt.AssertErrContains(t, err, "sequences", "single ID column")
+		tt.AssertEqual(t, len(calls), 0)
+	})
+}
+
+type userID int64
+
+func TestIDBackfill(t *testing.T) {
+	ctx := context.Background()
+
+	newAdapter := func(lastInsertID int64, returnedID interface{}) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				return NewMockResult(lastInsertID, 1), nil
+			},
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"id"}, []interface{}{returnedID}), nil
+			},
+		}
+	}
+
+	t.Run("should back-fill uint64 and typed IDs from the last insert id", func(t *testing.T) {
+		db := newDB(t, "mysql", newAdapter(42, nil))
+
+		u1 := struct {
+			ID   uint64 `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err := db.Insert(ctx, usersTable, &u1)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u1.ID, uint64(42))
+
+		u2 := struct {
+			ID   userID `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err = db.Insert(ctx, usersTable, &u2)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u2.ID, userID(42))
+
+		u3 := struct {
+			ID   *int64 `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err = db.Insert(ctx, usersTable, &u3)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, *u3.ID, int64(42))
+	})
+
+	t.Run("should format the last insert id for string IDs", func(t *testing.T) {
+		db := newDB(t, "sqlite3", newAdapter(42, nil))
+
+		u := struct {
+			ID   string `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err := db.Insert(ctx, usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, "42")
+	})
+
+	t.Run("should back-fill the IDs returned by RETURNING and OUTPUT", func(t *testing.T) {
+		u1 := struct {
+			ID   string `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err := newDB(t, "postgres", newAdapter(0, int64(42))).Insert(ctx, usersTable, &u1)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u1.ID, "42")
+
+		u2 := struct {
+			ID   userID `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err = newDB(t, "sqlserver", newAdapter(0, []byte("42"))).Insert(ctx, usersTable, &u2)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u2.ID, userID(42))
+
+		u3 := struct {
+			ID   uint64 `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err = newDB(t, "postgres", newAdapter(0, "18446744073709551615")).Insert(ctx, usersTable, &u3)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u3.ID, uint64(18446744073709551615))
+	})
+
+	t.Run("should report an error if the ID doesn't fit the attribute", func(t *testing.T) {
+		u1 := struct {
+			ID   int8   `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err := newDB(t, "postgres", newAdapter(0, int64(300))).Insert(ctx, usersTable, &u1)
+		tt.AssertErrContains(t, err, "300", "`id`", "int8", "overflows")
+
+		u2 := struct {
+			ID   uint32 `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err = newDB(t, "mysql", newAdapter(-1, nil)).Insert(ctx, usersTable, &u2)
+		tt.AssertErrContains(t, err, "-1", "`id`", "uint32", "overflows")
+
+		u3 := struct {
+			ID   int64  `ksql:"id"`
+			Name string `ksql:"name"`
+		}{Name: "fake-name"}
+		err = newDB(t, "postgres", newAdapter(0, "not-a-number")).Insert(ctx, usersTable, &u3)
+		tt.AssertErrContains(t, err, "not-a-number", "`id`", "int64")
+
+		err = newDB(t, "postgres", newAdapter(0, nil)).Insert(ctx, usersTable, &u3)
+		tt.AssertErrContains(t, err, "NULL", "`id`")
+	})
+}
+
+func TestGeneratedColumns(t *testing.T) {
+	type record struct {
+		ID        int    `ksql:"id"`
+		Name      string `ksql:"name"`
+		UpperName string `ksql:"upper_name,generated"`
+	}
+
+	tests := []struct {
+		driver        string
+		expectedQuery string
+	}{
+		{
+			driver:        "postgres",
+			expectedQuery: `INSERT INTO "users" ("name") VALUES ($1) RETURNING "id", "upper_name"`,
+		},
+		{
+			driver:        "sqlserver",
+			expectedQuery: `INSERT INTO [users] ([name]) OUTPUT INSERTED.[id], INSERTED.[upper_name] VALUES (@p1)`,
+		},
+		{
+			driver:        "sqlite3",
+			expectedQuery: "INSERT INTO `users` (`name`) VALUES (?)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("should not insert and should return generated columns on "+test.driver, func(t *testing.T) {
+			db := newDB(t, test.driver, mockDBAdapter{})
+
+			var query string
+			ctx := WithQueryOptions(context.Background(), DryRun(func(q string, params []interface{}) {
+				query = q
+			}))
+			err := db.Insert(ctx, usersTable, &record{Name: "fake-name", UpperName: "FAKE"})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, query, test.expectedQuery)
+		})
+	}
+}
+
+func TestTablesWithTriggers(t *testing.T) {
+	type record struct {
+		ID        int    `ksql:"id"`
+		Name      string `ksql:"name"`
+		UpperName string `ksql:"upper_name,generated"`
+	}
+
+	table := NewTable("users").WithTriggers()
+	ctx := context.Background()
+
+	t.Run("should load the returned columns with SCOPE_IDENTITY on sqlserver", func(t *testing.T) {
+		var query string
+		db := newDB(t, "sqlserver", mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query = q
+				return newMockRows([]string{"id", "upper_name"}, []interface{}{int64(42), "FAKE-NAME"}), nil
+			},
+		})
+
+		r := record{Name: "fake-name"}
+		err := db.Insert(ctx, table, &r)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `INSERT INTO [users] ([name]) VALUES (@p1); SELECT [id], [upper_name] FROM [users] WHERE [id] = SCOPE_IDENTITY()`)
+		tt.AssertEqual(t, r, record{ID: 42, Name: "fake-name", UpperName: "FAKE-NAME"})
+	})
+
+	t.Run("should filter the explicit IDs by their params", func(t *testing.T) {
+		type permission struct {
+			UserID int `ksql:"user_id"`
+			PermID int `ksql:"perm_id"`
+		}
+
+		var queries []string
+		dryRun := DryRun(func(q string, params []interface{}) {
+			queries = append(queries, q)
+		})
+
+		db := newDB(t, "sqlserver", mockDBAdapter{})
+
+		permissionsTable := NewTable("user_permissions", "user_id", "perm_id").WithTriggers()
+		err := db.Insert(WithQueryOptions(ctx, dryRun), permissionsTable, &permission{UserID: 1})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{
+			`INSERT INTO [user_permissions] ([user_id]) VALUES (@p1); SELECT [user_id], [perm_id] FROM [user_permissions] WHERE [user_id] = @p1 AND [perm_id] = SCOPE_IDENTITY()`,
+		})
+
+		err = db.Insert(WithQueryOptions(ctx, dryRun), permissionsTable, &permission{})
+		tt.AssertErrContains(t, err, "WithTriggers", "more than one ID unset")
+	})
+
+	t.Run("should not change the queries of the other dialects", func(t *testing.T) {
+		var query string
+		db := newDB(t, "postgres", mockDBAdapter{})
+
+		ctx := WithQueryOptions(ctx, DryRun(func(q string, params []interface{}) {
+			query = q
+		}))
+		err := db.Insert(ctx, table, &record{Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `INSERT INTO "users" ("name") VALUES ($1) RETURNING "id", "upper_name"`)
+	})
+}
+
+func TestTableDefaults(t *testing.T) {
+	type record struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	table := NewTable("users").WithSchema("app").WithFindFilter("deleted_at IS NULL")
+
+	var queries []string
+	dryRun := DryRun(func(q string, params []interface{}) {
+		queries = append(queries, q)
+	})
+
+	db := newDB(t, "sqlserver", mockDBAdapter{})
+
+	ctx := context.Background()
+	err := db.Insert(WithQueryOptions(ctx, dryRun), table, &record{Name: "fake-name"})
+	tt.AssertNoErr(t, err)
+	err = db.Patch(WithQueryOptions(ctx, dryRun), table, &record{ID: 42, Name: "fake-name"})
+	tt.AssertNoErr(t, err)
+	err = db.Delete(WithQueryOptions(ctx, dryRun), table, 42)
+	tt.AssertNoErr(t, err)
+	err = db.Find(ctx, table, &record{}, 42, dryRun)
+	tt.AssertNoErr(t, err)
+
+	tt.AssertEqual(t, queries, []string{
+		`INSERT INTO [app].[users] ([name]) OUTPUT INSERTED.[id] VALUES (@p1)`,
+		`UPDATE [app].[users] SET [name] = @p1 WHERE [id] = @p2`,
+		`DELETE FROM [app].[users] WHERE [id] = @p1`,
+		`SELECT [id], [name] FROM [app].[users] WHERE ([id] = @p1) AND (deleted_at IS NULL)`,
+	})
+}
+
+func TestExecResultInsideTransactions(t *testing.T) {
+	ctx := context.Background()
+	adapter := mockTxBeginner{
+		mockDBAdapter: mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				return NewMockResult(42, 3), nil
+			},
+		},
+		BeginTxFn: func(ctx context.Context) (Tx, error) {
+			return mockTx{
+				mockDBAdapter: mockDBAdapter{
+					ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+						return NewMockResult(42, 3), nil
+					},
+				},
+				CommitFn: func(ctx context.Context) error { return nil },
+			}, nil
+		},
+	}
+
+	db := newDB(t, "sqlite3", adapter)
+	wrapped := newDB(t, "sqlite3", WrapAdapter(adapter, AdapterHooks{}))
+	audited, err := NewAuditProvider(db, AuditConfig{
+		Sink: func(ctx context.Context, event AuditEvent) error { return nil },
+	})
+	tt.AssertNoErr(t, err)
+
+	providers := map[string]Provider{
+		"DB":            db,
+		"WrapAdapter":   wrapped,
+		"AuditProvider": audited,
+	}
+	for name, provider := range providers {
+		t.Run("should return the Result of the adapter when using "+name, func(t *testing.T) {
+			err := provider.Transaction(ctx, func(tx Provider) error {
+				result, err := tx.Exec(ctx, "UPDATE users SET age = 22")
+				tt.AssertNoErr(t, err)
+
+				lastID, err := result.LastInsertId()
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, lastID, int64(42))
+
+				rowsAffected, err := result.RowsAffected()
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, rowsAffected, int64(3))
+				return nil
+			})
+			tt.AssertNoErr(t, err)
+		})
+	}
+}
+
+// valuerName implements driver.Valuer with a pointer receiver
+type valuerName string
+
+func (v *valuerName) Value() (driver.Value, error) {
+	return strings.ToUpper(string(*v)), nil
+}
+
+func TestPointerReceiverValuers(t *testing.T) {
+	type record struct {
+		ID   valuerName `ksql:"id"`
+		Name valuerName `ksql:"name"`
+	}
+
+	var queries []string
+	var params [][]interface{}
+	db := newDB(t, "postgres", mockDBAdapter{
+		ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+			queries = append(queries, query)
+			params = append(params, args)
+			return NewMockResult(0, 1), nil
+		},
+	})
+
+	t.Run("should send pointers to the values but treat zero IDs as unset", func(t *testing.T) {
+		queries, params = nil, nil
+
+		name := valuerName("Bia")
+		err := db.Insert(WithQueryOptions(context.Background(), SkipIDRetrieval()), usersTable, &record{Name: name})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{`INSERT INTO "users" ("name") VALUES ($1)`})
+		tt.AssertEqual(t, params, [][]interface{}{{&name}})
+
+		// Records with unset IDs are inserted by Upsert:
+		err = db.Upsert(context.Background(), usersTable, &record{Name: name}, SkipIDRetrieval())
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries[1], `INSERT INTO "users" ("name") VALUES ($1)`)
+
+		err = db.Delete(context.Background(), usersTable, &record{})
+		tt.AssertErrContains(t, err, "invalid value", "id")
+		tt.AssertEqual(t, len(queries), 2)
+	})
+}
+
+func TestNestedStructAliases(t *testing.T) {
+	var queries []string
+	db := newDB(t, "sqlserver", mockDBAdapter{
+		QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+			queries = append(queries, query)
+			return nil, errors.New("fake-syntax-error")
+		},
+	})
+
+	var row struct {
+		User user `tablename:"u"`
+		Post post `tablename:"p"`
+	}
+
+	t.Run("should report the missing aliases before sending the query", func(t *testing.T) {
+		queries = nil
+
+		err := db.QueryOne(context.Background(), &row, `SELECT * FROM users u JOIN posts p ON u.id = p.user_id LIMIT 1`)
+		tt.AssertErrContains(t, err, "nested struct", "feature")
+		tt.AssertEqual(t, len(queries), 0)
+
+		var rows []struct {
+			User user `tablename:"u"`
+			Post post `tablename:"p"`
+		}
+		err = db.Query(context.Background(), &rows, `SELECT * FROM users u JOIN posts p ON u.id = p.user_id`)
+		tt.AssertErrContains(t, err, "nested struct", "feature")
+		tt.AssertEqual(t, len(queries), 0)
+	})
+
+	t.Run("should send the queries with aliased columns", func(t *testing.T) {
+		queries = nil
+
+		err := db.QueryOne(context.Background(), &row, `SELECT u.id AS [u.id], p.title AS [p.title] FROM users u JOIN posts p ON u.id = p.user_id`)
+		tt.AssertErrContains(t, err, "fake-syntax-error")
+		tt.AssertEqual(t, len(queries), 1)
+	})
+}
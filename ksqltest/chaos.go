@@ -0,0 +1,181 @@
+package ksqltest
+
+import (
+	"context"
+	"database/sql/driver"
+	"math/rand"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/vingarcia/ksql"
+)
+
+// ChaosRule describes a fault injected by the adapter
+// returned from `ksqltest.NewChaosAdapter()`.
+type ChaosRule struct {
+	// Query selects the queries affected by the rule, all queries are
+	// affected if it is nil. Transactions are matched as the queries
+	// "BEGIN" and "COMMIT", so they can also be targeted by the rules.
+	Query *regexp.Regexp
+
+	// Probability is the chance, between 0 and 1, of the rule being
+	// applied to a matching query, it is always applied if it is 0.
+	Probability float64
+
+	// Times limits how many times the rule is applied,
+	// which is useful for testing retries, 0 means no limit.
+	Times int
+
+	// Latency is added before sending the query to the database,
+	// if the context is done while waiting its error is returned.
+	Latency time.Duration
+
+	// Timeout makes the query hang until its context is done, like a
+	// database that stopped responding, and then return the context
+	// error. If the context has no deadline context.DeadlineExceeded
+	// is returned right away so tests can't hang forever.
+	Timeout bool
+
+	// DropConnection makes the query fail with driver.ErrBadConn
+	// as if the connection was closed by the database.
+	DropConnection bool
+
+	// SQLState makes the query fail with a *SQLStateError with this
+	// code, e.g. "40001" for serialization failures on postgres.
+	SQLState string
+
+	// Err makes the query fail with this error
+	Err error
+}
+
+// ChaosConfig configures the adapter
+// returned from `ksqltest.NewChaosAdapter()`.
+type ChaosConfig struct {
+	// Rules are evaluated in order for each query, and the first
+	// error injected by them is returned without running the query.
+	Rules []ChaosRule
+
+	// Seed is used for choosing which queries are affected by
+	// the rules with a Probability, so failures can be reproduced.
+	Seed int64
+}
+
+// SQLStateError is the error injected by the rules with a SQLState, it
+// implements the `SQLState() string` method like the errors of pgx, so
+// it is recognized by functions like `ksql.ClassifyConstraintError()`.
+type SQLStateError struct {
+	Code string
+}
+
+func (e *SQLStateError) Error() string {
+	return "ksqltest: injected error with SQLSTATE " + e.Code
+}
+
+// SQLState returns the SQLSTATE code of the error
+func (e *SQLStateError) SQLState() string {
+	return e.Code
+}
+
+// NewChaosAdapter returns a DBAdapter that injects latencies, timeouts,
+// dropped connections and errors on the queries sent to the base adapter,
+// so applications can test how their retries, circuit breakers and
+// timeouts behave when the database misbehaves, e.g.:
+//
+//	adapter := ksqltest.NewChaosAdapter(kpgx.NewPGXAdapter(pool), ksqltest.ChaosConfig{
+//		Rules: []ksqltest.ChaosRule{{
+//			Query:       regexp.MustCompile(`^UPDATE`),
+//			Probability: 0.1,
+//			SQLState:    "40001",
+//		}},
+//	})
+//	db, err := ksql.NewWithAdapter(adapter, "postgres")
+//
+// It is built with `ksql.WrapAdapter()`, so it also forwards
+// the optional features of the base adapter.
+func NewChaosAdapter(base ksql.DBAdapter, config ChaosConfig) ksql.DBAdapter {
+	c := &chaos{
+		rules:   config.Rules,
+		applied: make([]int, len(config.Rules)),
+		random:  rand.New(rand.NewSource(config.Seed)),
+	}
+
+	return ksql.WrapAdapter(base, ksql.AdapterHooks{
+		BeforeExec: func(ctx context.Context, query string, args []interface{}) (context.Context, error) {
+			return ctx, c.inject(ctx, query)
+		},
+		BeforeQuery: func(ctx context.Context, query string, args []interface{}) (context.Context, error) {
+			return ctx, c.inject(ctx, query)
+		},
+		BeforeBeginTx: func(ctx context.Context) (context.Context, error) {
+			return ctx, c.inject(ctx, "BEGIN")
+		},
+		BeforeCommit: func(ctx context.Context) error {
+			return c.inject(ctx, "COMMIT")
+		},
+	})
+}
+
+type chaos struct {
+	rules []ChaosRule
+
+	mutex   sync.Mutex
+	applied []int
+	random  *rand.Rand
+}
+
+func (c *chaos) inject(ctx context.Context, query string) error {
+	for i, rule := range c.rules {
+		if !c.shouldApply(i, query) {
+			continue
+		}
+
+		if rule.Latency > 0 {
+			timer := time.NewTimer(rule.Latency)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		switch {
+		case rule.Timeout:
+			if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+				return context.DeadlineExceeded
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		case rule.DropConnection:
+			return driver.ErrBadConn
+		case rule.SQLState != "":
+			return &SQLStateError{Code: rule.SQLState}
+		case rule.Err != nil:
+			return rule.Err
+		}
+	}
+
+	return nil
+}
+
+func (c *chaos) shouldApply(i int, query string) bool {
+	rule := c.rules[i]
+	if rule.Query != nil && !rule.Query.MatchString(query) {
+		return false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if rule.Times > 0 && c.applied[i] >= rule.Times {
+		return false
+	}
+
+	if rule.Probability > 0 && c.random.Float64() >= rule.Probability {
+		return false
+	}
+
+	c.applied[i]++
+	return true
+}
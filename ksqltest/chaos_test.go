@@ -0,0 +1,113 @@
+package ksqltest
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+
+	"github.com/vingarcia/ksql"
+)
+
+func TestNewChaosAdapter(t *testing.T) {
+	ctx := context.Background()
+
+	newDB := func(t *testing.T, config ChaosConfig) (ksql.DB, *execRecorder) {
+		adapter := &execRecorder{}
+		db, err := ksql.NewWithAdapter(NewChaosAdapter(adapter, config), "postgres")
+		tt.AssertNoErr(t, err)
+		return db, adapter
+	}
+
+	t.Run("should inject the errors only on the matching queries", func(t *testing.T) {
+		db, adapter := newDB(t, ChaosConfig{
+			Rules: []ChaosRule{
+				{Query: regexp.MustCompile(`^UPDATE`), SQLState: "40001"},
+				{Query: regexp.MustCompile(`^DELETE`), DropConnection: true},
+				{Query: regexp.MustCompile(`^INSERT`), Err: errors.New("fake-error")},
+			},
+		})
+
+		_, err := db.Exec(ctx, `UPDATE users SET age = 42`)
+		var sqlStateErr *SQLStateError
+		tt.AssertEqual(t, errors.As(err, &sqlStateErr), true)
+		tt.AssertEqual(t, sqlStateErr.SQLState(), "40001")
+
+		_, err = db.Exec(ctx, `DELETE FROM users`)
+		tt.AssertEqual(t, errors.Is(err, driver.ErrBadConn), true)
+
+		_, err = db.Exec(ctx, `INSERT INTO users (name) VALUES ('fake-name')`)
+		tt.AssertErrContains(t, err, "fake-error")
+
+		_, err = db.Exec(ctx, `SELECT 1`)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, adapter.queries, []string{`SELECT 1`})
+	})
+
+	t.Run("should stop applying a rule after the configured times", func(t *testing.T) {
+		db, adapter := newDB(t, ChaosConfig{
+			Rules: []ChaosRule{{Times: 2, DropConnection: true}},
+		})
+
+		for i := 0; i < 3; i++ {
+			_, err := db.Exec(ctx, `SELECT 1`)
+			tt.AssertEqual(t, err != nil, i < 2)
+		}
+		tt.AssertEqual(t, len(adapter.queries), 1)
+	})
+
+	t.Run("should apply the rules with the configured probability", func(t *testing.T) {
+		countFailures := func(seed int64) (failures int) {
+			db, _ := newDB(t, ChaosConfig{
+				Rules: []ChaosRule{{Probability: 0.5, DropConnection: true}},
+				Seed:  seed,
+			})
+			for i := 0; i < 1000; i++ {
+				if _, err := db.Exec(ctx, `SELECT 1`); err != nil {
+					failures++
+				}
+			}
+			return failures
+		}
+
+		failures := countFailures(42)
+		tt.AssertEqual(t, failures > 400 && failures < 600, true)
+		tt.AssertEqual(t, countFailures(42), failures)
+	})
+
+	t.Run("should add latencies and timeouts", func(t *testing.T) {
+		db, adapter := newDB(t, ChaosConfig{
+			Rules: []ChaosRule{
+				{Query: regexp.MustCompile(`slow`), Latency: 20 * time.Millisecond},
+				{Query: regexp.MustCompile(`stuck`), Timeout: true},
+			},
+		})
+
+		start := time.Now()
+		_, err := db.Exec(ctx, `SELECT 'slow'`)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, time.Since(start) >= 20*time.Millisecond, true)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = db.Exec(timeoutCtx, `SELECT 'stuck'`)
+		tt.AssertEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+
+		_, err = db.Exec(ctx, `SELECT 'stuck'`)
+		tt.AssertEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+		tt.AssertEqual(t, adapter.queries, []string{`SELECT 'slow'`})
+	})
+
+	t.Run("should be recognized by ClassifyConstraintError", func(t *testing.T) {
+		db, _ := newDB(t, ChaosConfig{
+			Rules: []ChaosRule{{SQLState: "23505"}},
+		})
+
+		_, err := db.Exec(ctx, `INSERT INTO users (name) VALUES ('fake-name')`)
+		tt.AssertEqual(t, ksql.ClassifyConstraintError(err), ksql.UniqueViolation)
+	})
+}
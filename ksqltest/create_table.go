@@ -0,0 +1,26 @@
+package ksqltest
+
+import (
+	"context"
+
+	"github.com/vingarcia/ksql"
+)
+
+// CreateTable creates a table for the input record on the database, which
+// is useful for setting up tests and prototypes without writing the
+// CREATE TABLE statements of each dialect by hand, e.g.:
+//
+//	err := ksqltest.CreateTable(ctx, db, UsersTable, &User{})
+//
+// The statement is built by the `ksql.DB.CreateTableQuery()` method
+// from the `ksql` tags of the record, so check its documentation
+// for the supported dialects and types.
+func CreateTable(ctx context.Context, db ksql.DB, table ksql.Table, record interface{}) error {
+	query, err := db.CreateTableQuery(table, record)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, query)
+	return err
+}
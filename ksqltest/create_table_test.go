@@ -0,0 +1,55 @@
+package ksqltest
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+
+	"github.com/vingarcia/ksql"
+)
+
+// execRecorder is a ksql.DBAdapter that records the executed queries
+type execRecorder struct {
+	queries []string
+}
+
+func (e *execRecorder) ExecContext(ctx context.Context, query string, args ...interface{}) (ksql.Result, error) {
+	e.queries = append(e.queries, query)
+	return ksql.NewMockResult(0, 0), nil
+}
+
+func (e *execRecorder) QueryContext(ctx context.Context, query string, args ...interface{}) (ksql.Rows, error) {
+	return nil, nil
+}
+
+func TestCreateTable(t *testing.T) {
+	t.Run("should execute the CREATE TABLE statement", func(t *testing.T) {
+		adapter := &execRecorder{}
+		db, err := ksql.NewWithAdapter(adapter, "postgres")
+		tt.AssertNoErr(t, err)
+
+		type user struct {
+			ID   int    `ksql:"id"`
+			Name string `ksql:"name"`
+		}
+		err = CreateTable(context.Background(), db, ksql.NewTable("users"), &user{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, adapter.queries, []string{
+			`CREATE TABLE "users" ("id" BIGSERIAL, "name" TEXT, PRIMARY KEY ("id"))`,
+		})
+	})
+
+	t.Run("should report errors for unsupported records", func(t *testing.T) {
+		adapter := &execRecorder{}
+		db, err := ksql.NewWithAdapter(adapter, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = CreateTable(context.Background(), db, ksql.NewTable("users"), struct {
+			ID   int                `ksql:"id"`
+			Tags map[string]float64 `ksql:"tags"`
+		}{})
+		tt.AssertErrContains(t, err, "tags", "json")
+		tt.AssertEqual(t, len(adapter.queries), 0)
+	})
+}
@@ -0,0 +1,97 @@
+package ksqltest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vingarcia/ksql"
+)
+
+// Seeder inserts deterministic test data on the database,
+// it is created by the `ksqltest.Seed()` function.
+type Seeder struct {
+	ctx context.Context
+	db  ksql.Provider
+	err error
+}
+
+// SeedHandle gives access to the records inserted by a call to
+// `Seeder.Insert()`, which have their IDs filled by the database,
+// so they can be referenced by the records inserted afterwards.
+type SeedHandle struct {
+	records []interface{}
+}
+
+// Seed creates a Seeder for inserting related records one table at a
+// time, where the records of each table can reference the IDs of the
+// records inserted before them, e.g.:
+//
+//	seed := ksqltest.Seed(ctx, db)
+//	users := seed.Insert(UsersTable, 10, func(i int) interface{} {
+//		return &User{Name: fmt.Sprintf("user-%d", i)}
+//	})
+//	posts := seed.Insert(PostsTable, 30, func(i int) interface{} {
+//		return &Post{
+//			UserID: users.Get(i % users.Len()).(*User).ID,
+//			Title:  fmt.Sprintf("post-%d", i),
+//		}
+//	})
+//	if err := seed.Err(); err != nil {
+//		t.Fatal(err)
+//	}
+//
+// After the first error the Seeder stops inserting records and the
+// next calls to Insert return empty handles, so the error only needs
+// to be checked once with the Err method.
+func Seed(ctx context.Context, db ksql.Provider) *Seeder {
+	return &Seeder{
+		ctx: ctx,
+		db:  db,
+	}
+}
+
+// Insert inserts n records on the table, the record with index i is
+// created by calling newRecord(i) and must be a pointer to struct.
+func (s *Seeder) Insert(table ksql.Table, n int, newRecord func(i int) interface{}) SeedHandle {
+	if s.err != nil {
+		return SeedHandle{}
+	}
+
+	records := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		record := newRecord(i)
+
+		err := s.db.Insert(s.ctx, table, record)
+		if err != nil {
+			s.err = fmt.Errorf("ksqltest: error seeding record %d: %w", i, err)
+			return SeedHandle{}
+		}
+
+		records = append(records, record)
+	}
+
+	return SeedHandle{
+		records: records,
+	}
+}
+
+// Err returns the first error that happened while seeding, if any
+func (s *Seeder) Err() error {
+	return s.err
+}
+
+// Len returns the number of records of the handle
+func (h SeedHandle) Len() int {
+	return len(h.records)
+}
+
+// Get returns the record with index i, as returned
+// by the function passed to `Seeder.Insert()`
+func (h SeedHandle) Get(i int) interface{} {
+	return h.records[i]
+}
+
+// Records returns all the records of the handle
+func (h SeedHandle) Records() []interface{} {
+	return append([]interface{}{}, h.records...)
+}
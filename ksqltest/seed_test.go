@@ -0,0 +1,95 @@
+package ksqltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+
+	"github.com/vingarcia/ksql"
+)
+
+func TestSeed(t *testing.T) {
+	type user struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	type post struct {
+		ID     int    `ksql:"id"`
+		UserID int    `ksql:"user_id"`
+		Title  string `ksql:"title"`
+	}
+
+	usersTable := ksql.NewTable("users")
+	postsTable := ksql.NewTable("posts")
+
+	t.Run("should insert the records with the IDs of the previous ones", func(t *testing.T) {
+		lastID := 0
+		var inserted []interface{}
+		db := ksql.Mock{
+			InsertFn: func(ctx context.Context, table ksql.Table, record interface{}) error {
+				lastID++
+				switch r := record.(type) {
+				case *user:
+					r.ID = lastID
+				case *post:
+					r.ID = lastID
+				}
+				inserted = append(inserted, record)
+				return nil
+			},
+		}
+
+		seed := Seed(context.Background(), db)
+		users := seed.Insert(usersTable, 2, func(i int) interface{} {
+			return &user{Name: fmt.Sprintf("user-%d", i)}
+		})
+		posts := seed.Insert(postsTable, 3, func(i int) interface{} {
+			return &post{
+				UserID: users.Get(i % users.Len()).(*user).ID,
+				Title:  fmt.Sprintf("post-%d", i),
+			}
+		})
+		tt.AssertNoErr(t, seed.Err())
+
+		tt.AssertEqual(t, users.Records(), []interface{}{
+			&user{ID: 1, Name: "user-0"},
+			&user{ID: 2, Name: "user-1"},
+		})
+		tt.AssertEqual(t, posts.Records(), []interface{}{
+			&post{ID: 3, UserID: 1, Title: "post-0"},
+			&post{ID: 4, UserID: 2, Title: "post-1"},
+			&post{ID: 5, UserID: 1, Title: "post-2"},
+		})
+		tt.AssertEqual(t, len(inserted), 5)
+	})
+
+	t.Run("should stop seeding after the first error", func(t *testing.T) {
+		calls := 0
+		db := ksql.Mock{
+			InsertFn: func(ctx context.Context, table ksql.Table, record interface{}) error {
+				calls++
+				if calls == 2 {
+					return errors.New("fake-error")
+				}
+				return nil
+			},
+		}
+
+		seed := Seed(context.Background(), db)
+		users := seed.Insert(usersTable, 3, func(i int) interface{} {
+			return &user{}
+		})
+		posts := seed.Insert(postsTable, 3, func(i int) interface{} {
+			return &post{}
+		})
+
+		tt.AssertErrContains(t, seed.Err(), "record 1", "fake-error")
+		tt.AssertEqual(t, users.Len(), 0)
+		tt.AssertEqual(t, posts.Len(), 0)
+		tt.AssertEqual(t, calls, 2)
+	})
+}
@@ -1,6 +1,8 @@
 package ksqltest
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"testing"
 
@@ -9,6 +11,12 @@ import (
 	"github.com/vingarcia/ksql/nullable"
 )
 
+type ptrValuer string
+
+func (p *ptrValuer) Value() (driver.Value, error) {
+	return string(*p), nil
+}
+
 func TestStructToMap(t *testing.T) {
 	type S1 struct {
 		Name string `ksql:"name_attr"`
@@ -70,6 +78,41 @@ func TestStructToMap(t *testing.T) {
 		assert.Equal(t, map[string]interface{}{}, m)
 	})
 
+	t.Run("should ignore invalid sql.Null* attributes like nil pointers", func(t *testing.T) {
+		m, err := StructToMap(struct {
+			Name    sql.NullString `ksql:"name"`
+			Age     sql.NullInt64  `ksql:"age"`
+			Deleted *sql.NullTime  `ksql:"deleted"`
+		}{
+			Name:    sql.NullString{String: "fake-name", Valid: true},
+			Age:     sql.NullInt64{Int64: 42},
+			Deleted: &sql.NullTime{},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, m, map[string]interface{}{
+			"name": sql.NullString{String: "fake-name", Valid: true},
+		})
+	})
+
+	t.Run("should copy the values of types implementing driver.Valuer with pointer receivers", func(t *testing.T) {
+		name := ptrValuer("name")
+		m, err := StructToMap(struct {
+			Name    ptrValuer  `ksql:"name"`
+			NamePtr *ptrValuer `ksql:"name_ptr"`
+			Empty   ptrValuer  `ksql:"empty"`
+		}{
+			Name:    "name",
+			NamePtr: &name,
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, m, map[string]interface{}{
+			"name":     ptrValuer("name"),
+			"name_ptr": ptrValuer("name"),
+			"empty":    ptrValuer(""),
+		})
+	})
+
 	t.Run("should ignore fields not tagged with ksql", func(t *testing.T) {
 		m, err := StructToMap(struct {
 			Name              string `ksql:"name_attr"`
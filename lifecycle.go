@@ -0,0 +1,89 @@
+package ksql
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+type lifecycle struct {
+	mu      sync.Mutex
+	closed  bool
+	onClose []func() error
+}
+
+// WithSharedAdapter informs the DB that it doesn't own the adapter it was
+// created with, e.g. because the same *sql.DB is also used by other parts
+// of the program, so calling `DB.Close()` won't close the adapter.
+//
+// By default the DB owns the adapter and closes it if it
+// implements the io.Closer interface.
+func WithSharedAdapter() Option {
+	return func(db *DB) {
+		db.sharedAdapter = true
+	}
+}
+
+// OnClose registers a function that will be called when the DB
+// is closed, which is useful for releasing resources that
+// depend on the DB, e.g. stopping background workers.
+//
+// The functions are called in the reverse order they were registered,
+// just like deferred functions, and before the adapter is closed,
+// so they can still use the DB.
+func (c DB) OnClose(fn func() error) {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+
+	c.lifecycle.onClose = append(c.lifecycle.onClose, fn)
+}
+
+// Close implements the io.Closer interface
+//
+// It calls all the functions registered with OnClose and then closes
+// the adapter, unless the WithSharedAdapter option was used. The adapters
+// of this repository wait for the operations in progress to finish before
+// closing their connections.
+//
+// Calling Close more than once has no effect, and calling it inside
+// a transaction or a WithConn callback returns an error.
+func (c DB) Close() error {
+	if _, isTx := c.db.(Tx); isTx || c.connPinned {
+		return fmt.Errorf("ksql: can't close the DB inside a transaction or a WithConn callback")
+	}
+
+	var err error
+	if c.lifecycle != nil {
+		c.lifecycle.mu.Lock()
+		if c.lifecycle.closed {
+			c.lifecycle.mu.Unlock()
+			return nil
+		}
+		c.lifecycle.closed = true
+		onClose := c.lifecycle.onClose
+		c.lifecycle.mu.Unlock()
+
+		for i := len(onClose) - 1; i >= 0; i-- {
+			// All functions are called even if one of them fails:
+			if fnErr := onClose[i](); err == nil {
+				err = fnErr
+			}
+		}
+	}
+
+	if c.sharedAdapter {
+		return err
+	}
+
+	closer, ok := c.db.(io.Closer)
+	if !ok {
+		return err
+	}
+
+	closeErr := closer.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,108 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type mockCloser struct {
+	mockTxBeginner
+	CloseFn func() error
+}
+
+func (m mockCloser) Close() error {
+	return m.CloseFn()
+}
+
+func TestClose(t *testing.T) {
+	t.Run("should call the OnClose functions in reverse order before closing the adapter", func(t *testing.T) {
+		var calls []string
+		db, err := NewWithAdapter(mockCloser{
+			CloseFn: func() error {
+				calls = append(calls, "adapter")
+				return nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		db.OnClose(func() error {
+			calls = append(calls, "first")
+			return nil
+		})
+		db.OnClose(func() error {
+			calls = append(calls, "second")
+			return nil
+		})
+
+		err = db.Close()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"second", "first", "adapter"})
+
+		// Closing twice should have no effect:
+		err = db.Close()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"second", "first", "adapter"})
+	})
+
+	t.Run("should report the first error but still close everything", func(t *testing.T) {
+		var calls []string
+		db, err := NewWithAdapter(mockCloser{
+			CloseFn: func() error {
+				calls = append(calls, "adapter")
+				return fmt.Errorf("fake-adapter-error")
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		db.OnClose(func() error {
+			calls = append(calls, "hook")
+			return fmt.Errorf("fake-hook-error")
+		})
+
+		err = db.Close()
+		tt.AssertErrContains(t, err, "fake-hook-error")
+		tt.AssertEqual(t, calls, []string{"hook", "adapter"})
+	})
+
+	t.Run("should not close shared adapters", func(t *testing.T) {
+		var hookCalled bool
+		db, err := NewWithAdapter(mockCloser{
+			CloseFn: func() error {
+				t.Fatalf("the adapter should not have been closed")
+				return nil
+			},
+		}, "postgres", WithSharedAdapter())
+		tt.AssertNoErr(t, err)
+
+		db.OnClose(func() error {
+			hookCalled = true
+			return nil
+		})
+
+		err = db.Close()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, hookCalled, true)
+	})
+
+	t.Run("should not allow closing the DB inside a transaction", func(t *testing.T) {
+		db, err := NewWithAdapter(mockCloser{
+			mockTxBeginner: mockTxBeginner{
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					return mockTx{}, nil
+				},
+			},
+			CloseFn: func() error {
+				return nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.Transaction(context.Background(), func(db Provider) error {
+			return db.(DB).Close()
+		})
+		tt.AssertErrContains(t, err, "can't close the DB inside a transaction")
+	})
+}
@@ -0,0 +1,95 @@
+package ksql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type queryLimit struct {
+	n      int
+	offset int
+}
+
+// Limit makes the Query, QueryOne, QueryChunks, QueryAggregate, QueryCSV and
+// QueryJSON methods load at most n rows after skipping the first offset rows,
+// using the clauses supported by each dialect, e.g.:
+//
+//	err := db.Query(ctx, &users, "FROM users ORDER BY id", ksql.Limit(10, 20))
+//
+// On postgres, sqlite3, mysql, snowflake and bigquery it appends `LIMIT n OFFSET offset`,
+// on firebird `OFFSET offset ROWS FETCH NEXT n ROWS ONLY`, and on sqlserver the same
+// clauses if the query has an ORDER BY clause or otherwise `SELECT TOP (n)`, since
+// sqlserver only allows skipping rows of ordered queries.
+//
+// The query should have no clauses limiting its rows, and it can't be
+// used with ChunkParsers with KeyColumns, which already limit their queries.
+func Limit(n int, offset int) QueryOption {
+	return func(opts *queryOptions) {
+		opts.limit = &queryLimit{
+			n:      n,
+			offset: offset,
+		}
+	}
+}
+
+// buildLimitQuery adds the clauses of the Limit option to the query
+func buildLimitQuery(dialect Dialect, query string, limit queryLimit) (string, error) {
+	if limit.n < 0 || limit.offset < 0 {
+		return "", fmt.Errorf("ksql: the arguments of the Limit option can't be negative, but got: Limit(%d, %d)", limit.n, limit.offset)
+	}
+
+	for _, keyword := range []string{"LIMIT", "OFFSET", "FETCH", "TOP", "FOR"} {
+		if findTopLevelKeyword(query, keyword) != -1 {
+			return "", fmt.Errorf(
+				"ksql: the Limit option can't be used with queries containing a %s clause, but got: '%s'",
+				keyword, query,
+			)
+		}
+	}
+
+	query = strings.TrimRight(query, " \t\n;")
+	n, offset := strconv.Itoa(limit.n), strconv.Itoa(limit.offset)
+
+	switch capabilitiesOf(dialect).Limit {
+	case LimitFetch:
+		if limit.offset == 0 {
+			return query + " FETCH FIRST " + n + " ROWS ONLY", nil
+		}
+		return query + " OFFSET " + offset + " ROWS FETCH NEXT " + n + " ROWS ONLY", nil
+
+	case LimitTop:
+		if findTopLevelKeyword(query, "ORDER") != -1 {
+			return query + " OFFSET " + offset + " ROWS FETCH NEXT " + n + " ROWS ONLY", nil
+		}
+		if limit.offset != 0 {
+			return "", fmt.Errorf("ksql: the Limit option requires an ORDER BY clause for skipping rows on the %s dialect, but got: '%s'", dialect.DriverName(), query)
+		}
+		return buildTopQuery(query, n)
+
+	default:
+		if limit.offset == 0 {
+			return query + " LIMIT " + n, nil
+		}
+		return query + " LIMIT " + n + " OFFSET " + offset, nil
+	}
+}
+
+// buildTopQuery adds the `TOP (n)` clause after the
+// SELECT keyword and its DISTINCT modifier if there is one.
+func buildTopQuery(query string, n string) (string, error) {
+	pos := findTopLevelKeyword(query, "SELECT")
+	if pos == -1 {
+		return "", fmt.Errorf("ksql: the Limit option expected the query to contain a SELECT clause, but got: '%s'", query)
+	}
+	pos += len("SELECT")
+
+	rest := strings.TrimLeft(query[pos:], " \t\n")
+	for _, modifier := range []string{"DISTINCT", "ALL"} {
+		if len(rest) > len(modifier) && strings.EqualFold(rest[:len(modifier)], modifier) && strings.ContainsRune(" \t\n", rune(rest[len(modifier)])) {
+			pos = len(query) - len(rest) + len(modifier)
+		}
+	}
+
+	return query[:pos] + " TOP (" + n + ")" + query[pos:], nil
+}
@@ -0,0 +1,162 @@
+package ksql
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestBuildLimitQuery(t *testing.T) {
+	tests := []struct {
+		desc          string
+		dialect       string
+		query         string
+		limit         queryLimit
+		expectedQuery string
+		expectedErr   string
+	}{
+		{
+			desc:          "should append LIMIT on postgres",
+			dialect:       "postgres",
+			query:         "SELECT * FROM users ORDER BY id",
+			limit:         queryLimit{n: 10},
+			expectedQuery: "SELECT * FROM users ORDER BY id LIMIT 10",
+		},
+		{
+			desc:          "should append LIMIT and OFFSET on mysql",
+			dialect:       "mysql",
+			query:         "SELECT * FROM users ORDER BY id;",
+			limit:         queryLimit{n: 10, offset: 20},
+			expectedQuery: "SELECT * FROM users ORDER BY id LIMIT 10 OFFSET 20",
+		},
+		{
+			desc:          "should append FETCH FIRST on firebird",
+			dialect:       "firebird",
+			query:         `SELECT * FROM "users"`,
+			limit:         queryLimit{n: 10},
+			expectedQuery: `SELECT * FROM "users" FETCH FIRST 10 ROWS ONLY`,
+		},
+		{
+			desc:          "should append OFFSET and FETCH on firebird",
+			dialect:       "firebird",
+			query:         `SELECT * FROM "users" ORDER BY "id"`,
+			limit:         queryLimit{n: 10, offset: 20},
+			expectedQuery: `SELECT * FROM "users" ORDER BY "id" OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY`,
+		},
+		{
+			desc:          "should append OFFSET and FETCH on ordered sqlserver queries",
+			dialect:       "sqlserver",
+			query:         "SELECT * FROM [users] ORDER BY [id]",
+			limit:         queryLimit{n: 10, offset: 20},
+			expectedQuery: "SELECT * FROM [users] ORDER BY [id] OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+		},
+		{
+			desc:          "should use TOP on unordered sqlserver queries",
+			dialect:       "sqlserver",
+			query:         "SELECT DISTINCT [name] FROM [users]",
+			limit:         queryLimit{n: 10},
+			expectedQuery: "SELECT DISTINCT TOP (10) [name] FROM [users]",
+		},
+		{
+			desc:          "should use TOP on the main query of CTEs",
+			dialect:       "sqlserver",
+			query:         "WITH u AS (SELECT * FROM [users]) SELECT * FROM u",
+			limit:         queryLimit{n: 10},
+			expectedQuery: "WITH u AS (SELECT * FROM [users]) SELECT TOP (10) * FROM u",
+		},
+		{
+			desc:        "should require ORDER BY for skipping rows on sqlserver",
+			dialect:     "sqlserver",
+			query:       "SELECT * FROM [users]",
+			limit:       queryLimit{n: 10, offset: 20},
+			expectedErr: "ORDER BY",
+		},
+		{
+			desc:        "should reject queries that are already limited",
+			dialect:     "postgres",
+			query:       "SELECT * FROM users LIMIT 5",
+			limit:       queryLimit{n: 10},
+			expectedErr: "LIMIT clause",
+		},
+		{
+			desc:        "should reject negative arguments",
+			dialect:     "postgres",
+			query:       "SELECT * FROM users",
+			limit:       queryLimit{n: 10, offset: -1},
+			expectedErr: "can't be negative",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			dialect, err := GetDriverDialect(test.dialect)
+			tt.AssertNoErr(t, err)
+
+			query, err := buildLimitQuery(dialect, test.query, test.limit)
+			if test.expectedErr != "" {
+				tt.AssertErrContains(t, err, test.expectedErr)
+				return
+			}
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, query, test.expectedQuery)
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should limit the queries of the Query method", func(t *testing.T) {
+		var queries []string
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				queries = append(queries, query)
+				return newMockRows([]string{"id", "name"}), nil
+			},
+		}, "sqlserver")
+		tt.AssertNoErr(t, err)
+
+		var users []user
+		err = db.Query(ctx, &users, "FROM users ORDER BY id", Limit(10, 20))
+		tt.AssertNoErr(t, err)
+		err = db.Query(ctx, &users, "FROM users", Limit(10, 0), ForUpdate())
+		tt.AssertErrContains(t, err, "ForUpdate is not supported")
+
+		tt.AssertEqual(t, len(queries), 1)
+		tt.AssertEqual(t, strings.HasSuffix(queries[0], "FROM users ORDER BY id OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"), true)
+	})
+
+	t.Run("should add the LIMIT clause before the FOR UPDATE clause", func(t *testing.T) {
+		var query string
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, params ...interface{}) (Rows, error) {
+				query = q
+				return newMockRows([]string{"id", "name"}), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var users []user
+		err = db.Query(ctx, &users, "SELECT id, name FROM users WHERE name = $1", "fake-name", Limit(1, 0), ForUpdate())
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "SELECT id, name FROM users WHERE name = $1 LIMIT 1 FOR UPDATE")
+	})
+
+	t.Run("should not be used with the KeyColumns of QueryChunks", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.QueryChunks(ctx, ChunkParser{
+			Query:      "FROM users",
+			ChunkSize:  10,
+			Params:     []interface{}{Limit(10, 0)},
+			KeyColumns: []string{"id"},
+			ForEachChunk: func(users []user) error {
+				return nil
+			},
+		})
+		tt.AssertErrContains(t, err, "KeyColumns")
+	})
+}
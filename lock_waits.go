@@ -0,0 +1,118 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LockWait describes a session waiting for a lock held by another
+// session, it is returned by the `DB.WhoIsBlocking()` method.
+type LockWait struct {
+	BlockedSessionID   string `json:"blocked_session_id" ksql:"blocked_session_id"`
+	BlockedQuery       string `json:"blocked_query" ksql:"blocked_query"`
+	BlockedApplication string `json:"blocked_application,omitempty" ksql:"blocked_application"`
+
+	BlockingSessionID   string `json:"blocking_session_id" ksql:"blocking_session_id"`
+	BlockingQuery       string `json:"blocking_query" ksql:"blocking_query"`
+	BlockingApplication string `json:"blocking_application,omitempty" ksql:"blocking_application"`
+
+	// WaitDuration is how long the blocked session has been waiting,
+	// on mysql it has a precision of seconds.
+	WaitDuration time.Duration `json:"wait_duration_ns" ksql:"wait_ms,duration=ms"`
+}
+
+// WhoIsBlocking lists the sessions waiting for locks held by other sessions,
+// starting by the longest waits, so the report can be returned by admin
+// endpoints when triaging lock contention, e.g.:
+//
+//	http.HandleFunc("/admin/locks", func(w http.ResponseWriter, r *http.Request) {
+//		waits, err := db.WhoIsBlocking(r.Context())
+//		if err != nil {
+//			http.Error(w, err.Error(), http.StatusInternalServerError)
+//			return
+//		}
+//		json.NewEncoder(w).Encode(waits)
+//	})
+//
+// The BlockingQuery is the query currently running on the blocking session,
+// which is often not the one that acquired the lock, and is empty if the
+// session is idle inside the transaction.
+//
+// On postgres it uses `pg_blocking_pids()`, which reads `pg_locks`, on
+// sqlserver it uses `sys.dm_exec_requests` and on mysql it uses the
+// `performance_schema.data_lock_waits` table, available since MySQL 8.0.
+// For the other dialects it returns ErrNotSupported.
+//
+// The user needs permission for seeing the other sessions, e.g. the
+// pg_read_all_stats role on postgres or VIEW SERVER STATE on sqlserver,
+// otherwise the queries of the other sessions might be hidden.
+func (c DB) WhoIsBlocking(ctx context.Context) ([]LockWait, error) {
+	query, err := buildLockWaitsQuery(c.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	waits := []LockWait{}
+	err = c.Query(ctx, &waits, query)
+	if err != nil {
+		return nil, fmt.Errorf("ksql: unable to list the lock waits: %w", err)
+	}
+
+	return waits, nil
+}
+
+// buildLockWaitsQuery returns the query listing the lock waits
+// with the columns of the LockWait struct for each dialect.
+func buildLockWaitsQuery(dialect Dialect) (string, error) {
+	switch capabilitiesOf(dialect).LockWaits {
+	case LockWaitsPgStatActivity:
+		return `SELECT
+			blocked.pid::text AS blocked_session_id,
+			COALESCE(blocked.query, '') AS blocked_query,
+			COALESCE(blocked.application_name, '') AS blocked_application,
+			blocking.pid::text AS blocking_session_id,
+			CASE WHEN blocking.state = 'active' THEN COALESCE(blocking.query, '') ELSE '' END AS blocking_query,
+			COALESCE(blocking.application_name, '') AS blocking_application,
+			COALESCE((EXTRACT(EPOCH FROM clock_timestamp() - blocked.query_start) * 1000)::bigint, 0) AS wait_ms
+		FROM pg_stat_activity blocked
+		JOIN LATERAL unnest(pg_blocking_pids(blocked.pid)) AS blocker(pid) ON true
+		JOIN pg_stat_activity blocking ON blocking.pid = blocker.pid
+		ORDER BY wait_ms DESC`, nil
+
+	case LockWaitsDMExecRequests:
+		return `SELECT
+			CAST(r.session_id AS VARCHAR(20)) AS blocked_session_id,
+			COALESCE(blocked_sql.text, '') AS blocked_query,
+			COALESCE(blocked.program_name, '') AS blocked_application,
+			CAST(r.blocking_session_id AS VARCHAR(20)) AS blocking_session_id,
+			COALESCE(blocking_sql.text, '') AS blocking_query,
+			COALESCE(blocking.program_name, '') AS blocking_application,
+			CAST(r.wait_time AS BIGINT) AS wait_ms
+		FROM sys.dm_exec_requests r
+		JOIN sys.dm_exec_sessions blocked ON blocked.session_id = r.session_id
+		JOIN sys.dm_exec_sessions blocking ON blocking.session_id = r.blocking_session_id
+		LEFT JOIN sys.dm_exec_requests blocking_request ON blocking_request.session_id = r.blocking_session_id
+		OUTER APPLY sys.dm_exec_sql_text(r.sql_handle) blocked_sql
+		OUTER APPLY sys.dm_exec_sql_text(blocking_request.sql_handle) blocking_sql
+		WHERE r.blocking_session_id <> 0
+		ORDER BY r.wait_time DESC`, nil
+
+	case LockWaitsPerformanceSchema:
+		return `SELECT DISTINCT
+			CAST(blocked.PROCESSLIST_ID AS CHAR) AS blocked_session_id,
+			COALESCE(blocked.PROCESSLIST_INFO, '') AS blocked_query,
+			'' AS blocked_application,
+			CAST(blocking.PROCESSLIST_ID AS CHAR) AS blocking_session_id,
+			COALESCE(blocking.PROCESSLIST_INFO, '') AS blocking_query,
+			'' AS blocking_application,
+			COALESCE(blocked.PROCESSLIST_TIME, 0) * 1000 AS wait_ms
+		FROM performance_schema.data_lock_waits w
+		JOIN performance_schema.threads blocked ON blocked.THREAD_ID = w.REQUESTING_THREAD_ID
+		JOIN performance_schema.threads blocking ON blocking.THREAD_ID = w.BLOCKING_THREAD_ID
+		ORDER BY wait_ms DESC`, nil
+
+	default:
+		return "", fmt.Errorf("%w: WhoIsBlocking is not supported by the %s dialect", ErrNotSupported, dialect.DriverName())
+	}
+}
@@ -0,0 +1,87 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestWhoIsBlocking(t *testing.T) {
+	columns := []string{
+		"blocked_session_id", "blocked_query", "blocked_application",
+		"blocking_session_id", "blocking_query", "blocking_application",
+		"wait_ms",
+	}
+
+	t.Run("should report the lock waits", func(t *testing.T) {
+		for _, driver := range []string{"postgres", "sqlserver", "mysql"} {
+			t.Run(driver, func(t *testing.T) {
+				var query string
+				db, err := NewWithAdapter(mockDBAdapter{
+					QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+						query = q
+						return newMockRows(columns, []interface{}{
+							"42", "UPDATE users SET name = 'Bia' WHERE id = 1", "billing-worker",
+							"43", "", "admin",
+							int64(1500),
+						}), nil
+					},
+				}, driver)
+				tt.AssertNoErr(t, err)
+
+				waits, err := db.WhoIsBlocking(context.Background())
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, strings.HasPrefix(query, "SELECT"), true)
+				tt.AssertEqual(t, waits, []LockWait{
+					{
+						BlockedSessionID:    "42",
+						BlockedQuery:        "UPDATE users SET name = 'Bia' WHERE id = 1",
+						BlockedApplication:  "billing-worker",
+						BlockingSessionID:   "43",
+						BlockingApplication: "admin",
+						WaitDuration:        1500 * time.Millisecond,
+					},
+				})
+			})
+		}
+	})
+
+	t.Run("should return an empty report if there are no waits", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				return newMockRows(columns), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		waits, err := db.WhoIsBlocking(context.Background())
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, waits, []LockWait{})
+	})
+
+	t.Run("should report errors", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				return nil, errors.New("fake-permission-error")
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		_, err = db.WhoIsBlocking(context.Background())
+		tt.AssertErrContains(t, err, "lock waits", "fake-permission-error")
+	})
+
+	t.Run("should not be supported on the other dialects", func(t *testing.T) {
+		for _, driver := range []string{"sqlite3", "tidb", "snowflake"} {
+			db, err := NewWithAdapter(mockDBAdapter{}, driver)
+			tt.AssertNoErr(t, err)
+
+			_, err = db.WhoIsBlocking(context.Background())
+			tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+		}
+	})
+}
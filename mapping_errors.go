@@ -0,0 +1,175 @@
+package ksql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// maxMappingErrorQueryLen is the maximum length of
+// the queries reported on the MappingError type.
+const maxMappingErrorQueryLen = 100
+
+// MappingError is returned when KSQL fails to map a struct to the
+// columns of a query, e.g. because of an invalid `ksql` tag, a column
+// with no matching attribute when using the StrictScan option or a
+// column whose type is not supported by the attribute.
+//
+// It wraps the original error, so checks like
+// `errors.Is(err, someErr)` still work.
+type MappingError struct {
+	// StructType is the type of the struct being mapped
+	StructType reflect.Type
+
+	// Field is the path of the attribute related to the error, e.g.
+	// `User.Name` for nested structs, it is empty if the error is
+	// not related to a single attribute.
+	Field string
+
+	// Column is the name of the column related to the error, if any
+	Column string
+
+	// Query is the query with its whitespaces collapsed and
+	// truncated to a maximum of 100 characters.
+	Query string
+
+	// Fingerprint identifies the query even when it is truncated,
+	// it is the same for queries that only differ by whitespace.
+	Fingerprint string
+
+	Err error
+}
+
+func (e MappingError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ksql: error mapping %v", e.StructType)
+	if e.Field != "" {
+		b.WriteString("." + e.Field)
+	}
+	if e.Column != "" {
+		fmt.Fprintf(&b, " to the column '%s'", e.Column)
+	}
+	if e.Query != "" {
+		fmt.Fprintf(&b, " on query `%s` (query fingerprint: %s)", e.Query, e.Fingerprint)
+	}
+	fmt.Fprintf(&b, ": %s", e.Err)
+
+	return b.String()
+}
+
+// Unwrap returns the error that caused the mapping to fail
+func (e MappingError) Unwrap() error {
+	return e.Err
+}
+
+// newMappingError adds the struct type, the path of the invalid
+// attribute and the query fingerprint to the input error.
+//
+// It returns err unchanged if it is nil or if it is the errDryRun error.
+func newMappingError(structType reflect.Type, query string, err error) error {
+	if err == nil || err == errDryRun {
+		return err
+	}
+
+	var mappingErr MappingError
+	if errors.As(err, &mappingErr) {
+		if mappingErr.Query == "" {
+			mappingErr.Query = truncateQuery(query)
+			mappingErr.Fingerprint = queryFingerprint(query)
+		}
+		return mappingErr
+	}
+
+	mappingErr = MappingError{
+		StructType:  structType,
+		Query:       truncateQuery(query),
+		Fingerprint: queryFingerprint(query),
+		Err:         err,
+	}
+
+	var tagErr structs.TagError
+	if errors.As(err, &tagErr) {
+		mappingErr.Field = fieldPath(structType, tagErr)
+		mappingErr.Err = tagErr.Err
+	}
+
+	return mappingErr
+}
+
+// fieldPath returns the path of the attribute described by the
+// TagError, which might belong to one of the nested structs.
+func fieldPath(structType reflect.Type, tagErr structs.TagError) string {
+	if tagErr.StructType == structType || structType.Kind() != reflect.Struct {
+		return tagErr.Field
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+		if structType.Field(i).Type != tagErr.StructType {
+			continue
+		}
+
+		if tagErr.Field == "" {
+			return structType.Field(i).Name
+		}
+		return structType.Field(i).Name + "." + tagErr.Field
+	}
+
+	return tagErr.Field
+}
+
+// truncateQuery collapses the whitespaces of the query and truncates it,
+// so it can be included on error messages without making them unreadable.
+func truncateQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+
+	runes := []rune(query)
+	if len(runes) <= maxMappingErrorQueryLen {
+		return query
+	}
+
+	return string(runes[:maxMappingErrorQueryLen-3]) + "..."
+}
+
+// RowScanError describes a row skipped by the
+// Query method because of the SkipInvalidRows option.
+type RowScanError struct {
+	// Row is the index of the row on the results of the query, starting at 0
+	Row int
+
+	Err error
+}
+
+func (e RowScanError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Err)
+}
+
+// Unwrap returns the error returned while scanning the row
+func (e RowScanError) Unwrap() error {
+	return e.Err
+}
+
+// RowScanErrors is returned by the Query method when the SkipInvalidRows
+// option is used and at least one row was skipped, the valid rows are
+// still loaded into the input slice.
+type RowScanErrors []RowScanError
+
+func (e RowScanErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("ksql: %d row(s) failed to scan and were skipped: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of each skipped row
+func (e RowScanErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,144 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestMappingError(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newAdapter := func(columns []string, row ...interface{}) mockDBAdapter {
+		return mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newMockRows(columns, row), nil
+			},
+		}
+	}
+
+	t.Run("should report the path of invalid tags on nested structs", func(t *testing.T) {
+		type invalidAddress struct {
+			ID     int    `ksql:"id"`
+			Street string `ksql:"id"`
+		}
+		type row struct {
+			User    userRecord     `tablename:"u"`
+			Address invalidAddress `tablename:"a"`
+		}
+		db := newDB(t, "postgres", newAdapter([]string{"u.id"}, 1))
+
+		var rows []row
+		err := db.Query(context.Background(), &rows, "FROM users u JOIN addresses a ON a.user_id = u.id")
+
+		var mappingErr MappingError
+		tt.AssertEqual(t, errors.As(err, &mappingErr), true)
+		tt.AssertEqual(t, mappingErr.StructType, reflect.TypeOf(row{}))
+		tt.AssertEqual(t, mappingErr.Field, "Address.Street")
+		tt.AssertEqual(t, mappingErr.Query, "FROM users u JOIN addresses a ON a.user_id = u.id")
+		tt.AssertEqual(t, mappingErr.Fingerprint, queryFingerprint("FROM users u JOIN addresses a ON a.user_id = u.id"))
+		tt.AssertErrContains(t, err, "row.Address.Street", "same ksql tag name", "FROM users u")
+	})
+
+	t.Run("should report the column rejected by the StrictScan option", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter([]string{"id", "name", "age"}, 42, "fake-name", 20))
+
+		var u userRecord
+		err := db.QueryOne(context.Background(), &u, "SELECT id, name, age\n  FROM users", StrictScan())
+
+		var mappingErr MappingError
+		tt.AssertEqual(t, errors.As(err, &mappingErr), true)
+		tt.AssertEqual(t, mappingErr.StructType, reflect.TypeOf(userRecord{}))
+		tt.AssertEqual(t, mappingErr.Column, "age")
+		tt.AssertEqual(t, mappingErr.Query, "SELECT id, name, age FROM users")
+		tt.AssertErrContains(t, err, "userRecord", "'age'", "StrictScan")
+	})
+
+	t.Run("should wrap the errors returned by the scan", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter([]string{"id", "name"}, "not-a-number", "fake-name"))
+
+		var users []userRecord
+		err := db.Query(context.Background(), &users, "FROM users")
+
+		var mappingErr MappingError
+		tt.AssertEqual(t, errors.As(err, &mappingErr), true)
+		tt.AssertEqual(t, mappingErr.StructType, reflect.TypeOf(userRecord{}))
+		tt.AssertEqual(t, strings.HasPrefix(mappingErr.Query, "SELECT"), true)
+		tt.AssertErrContains(t, err, "userRecord", "can't convert")
+	})
+
+	t.Run("should truncate long queries", func(t *testing.T) {
+		query := "FROM users WHERE " + strings.Repeat("id = 1 OR ", 20) + "id = 2"
+		db := newDB(t, "postgres", newAdapter([]string{"id", "name", "age"}, 42, "fake-name", 20))
+
+		var users []userRecord
+		err := db.Query(context.Background(), &users, query, Columns("id", "age"))
+
+		var mappingErr MappingError
+		tt.AssertEqual(t, errors.As(err, &mappingErr), true)
+		tt.AssertEqual(t, mappingErr.Column, "age")
+		tt.AssertEqual(t, len(mappingErr.Query), 100)
+		tt.AssertEqual(t, strings.HasSuffix(mappingErr.Query, "..."), true)
+		tt.AssertEqual(t, mappingErr.Fingerprint, queryFingerprint(query))
+	})
+}
+
+func TestSkipInvalidRows(t *testing.T) {
+	type userRecord struct {
+		ID      int               `ksql:"id"`
+		Name    string            `ksql:"name"`
+		Address map[string]string `ksql:"address,json"`
+	}
+
+	db := newDB(t, "postgres", mockDBAdapter{
+		QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+			return newMockRows([]string{"id", "name", "address"},
+				[]interface{}{1, "fake-name-1", `{"city":"fake-city"}`},
+				[]interface{}{2, "fake-name-2", `{invalid json`},
+				[]interface{}{3, "fake-name-3", nil},
+				[]interface{}{"not-a-number", "fake-name-4", nil},
+			), nil
+		},
+	})
+
+	t.Run("should abort the query by default", func(t *testing.T) {
+		var users []userRecord
+		err := db.Query(context.Background(), &users, "FROM users")
+		tt.AssertErrContains(t, err, "userRecord")
+		tt.AssertEqual(t, errors.As(err, &RowScanErrors{}), false)
+	})
+
+	t.Run("should skip and report the invalid rows", func(t *testing.T) {
+		users := []userRecord{{Name: "old-name-1"}, {Name: "old-name-2"}, {Name: "old-name-3"}}
+		err := db.Query(context.Background(), &users, "FROM users", SkipInvalidRows())
+
+		var rowErrs RowScanErrors
+		tt.AssertEqual(t, errors.As(err, &rowErrs), true)
+		tt.AssertEqual(t, len(rowErrs), 2)
+		tt.AssertEqual(t, rowErrs[0].Row, 1)
+		tt.AssertEqual(t, rowErrs[1].Row, 3)
+		tt.AssertEqual(t, errors.As(rowErrs[0], &MappingError{}), true)
+		tt.AssertErrContains(t, err, "2 row(s)", "row 1:", "row 3:")
+
+		tt.AssertEqual(t, users, []userRecord{
+			{ID: 1, Name: "fake-name-1", Address: map[string]string{"city": "fake-city"}},
+			{ID: 3, Name: "fake-name-3"},
+		})
+	})
+
+	t.Run("should skip the invalid rows of slices of pointers", func(t *testing.T) {
+		var users []*userRecord
+		err := db.Query(context.Background(), &users, "FROM users", SkipInvalidRows())
+		tt.AssertEqual(t, errors.As(err, &RowScanErrors{}), true)
+		tt.AssertEqual(t, len(users), 2)
+		tt.AssertEqual(t, users[1].ID, 3)
+		tt.AssertEqual(t, users[1].Address == nil, true)
+	})
+}
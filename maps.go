@@ -0,0 +1,191 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// queryMaps implements the Query method for when
+// the records argument is a *[]map[string]interface{}
+func (c DB) queryMaps(
+	ctx context.Context,
+	records *[]map[string]interface{},
+	query string,
+	params ...interface{},
+) error {
+	if records == nil {
+		return fmt.Errorf("ksql: expected a valid pointer to slice of maps as argument but received a nil pointer")
+	}
+
+	opts, params := extractQueryOptions(params)
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := c.runMapQuery(ctx, OpInfo{Method: "Query"}, opts, query, params...)
+	if err == errDryRun {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	if opts.truncated != nil {
+		*opts.truncated = false
+	}
+
+	results := []map[string]interface{}{}
+	for idx := 0; rows.Next(); idx++ {
+		load, err := c.checkMaxRows(opts, idx)
+		if err != nil {
+			return err
+		}
+		if !load {
+			break
+		}
+
+		m, err := scanRowsIntoMap(c.dialect, rows, columns)
+		if err != nil {
+			return err
+		}
+		results = append(results, m)
+	}
+
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	*records = results
+	return nil
+}
+
+// queryOneMap implements the QueryOne method for when
+// the record argument is a *map[string]interface{}
+func (c DB) queryOneMap(
+	ctx context.Context,
+	record *map[string]interface{},
+	query string,
+	params ...interface{},
+) error {
+	if record == nil {
+		return fmt.Errorf("ksql: expected a valid pointer to map as argument but received a nil pointer")
+	}
+
+	opts, params := extractQueryOptions(params)
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := c.runMapQuery(ctx, OpInfo{Method: "QueryOne"}, opts, query, params...)
+	if err == errDryRun {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if rows.Err() != nil {
+			return rows.Err()
+		}
+		return ErrRecordNotFound
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	m, err := scanRowsIntoMap(c.dialect, rows, columns)
+	if err != nil {
+		return err
+	}
+
+	*record = m
+	return rows.Close()
+}
+
+// runMapQuery runs queries whose results are not scanned into structs,
+// the params should have no QueryOptions since they are passed as the opts argument.
+func (c DB) runMapQuery(ctx context.Context, op OpInfo, opts queryOptions, query string, params ...interface{}) (Rows, error) {
+	if len(opts.extraSelects) > 0 {
+		return nil, fmt.Errorf("ksql: AddSelect can't be used when querying into maps")
+	}
+
+	if parseQuery(query, opts).autoSelect {
+		return nil, fmt.Errorf("ksql: can't generate the SELECT part of the query when querying into maps")
+	}
+
+	if opts.limit != nil {
+		var err error
+		query, err = buildLimitQuery(c.dialect, query, *opts.limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.forUpdate {
+		var err error
+		query, err = buildForUpdateQuery(c.dialect, query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := c.queryContext(ctx, op, opts, query, params...)
+	if err == errDryRun {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error running query")
+	}
+
+	return rows, nil
+}
+
+// scanRowsIntoMap scans the current row into a map using the column
+// names as keys, normalizing the types returned by some drivers.
+func scanRowsIntoMap(dialect Dialect, rows Rows, columns []string) (map[string]interface{}, error) {
+	values := make([]interface{}, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	err := rows.Scan(scanArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		m[column] = normalizeMapValue(dialect, values[i])
+	}
+
+	return m, nil
+}
+
+// normalizeMapValue converts the values returned by the drivers
+// into the types users would expect when reading from a map.
+func normalizeMapValue(dialect Dialect, value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		// Some drivers, e.g. mysql, return text columns as []byte:
+		if capabilitiesOf(dialect).TextAsBytes {
+			return string(v)
+		}
+	}
+
+	return value
+}
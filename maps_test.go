@@ -0,0 +1,43 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestQueryMaps(t *testing.T) {
+	t.Run("should convert []byte into string for mysql", func(t *testing.T) {
+		adapter := mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"id", "name"}, []interface{}{int64(1), []byte("fake-name")}), nil
+			},
+		}
+
+		var rows []map[string]interface{}
+		err := newDB(t, "mysql", adapter).Query(context.Background(), &rows, "SELECT id, name FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, rows, []map[string]interface{}{
+			{"id": int64(1), "name": "fake-name"},
+		})
+
+		err = newDB(t, "postgres", adapter).Query(context.Background(), &rows, "SELECT id, name FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, rows, []map[string]interface{}{
+			{"id": int64(1), "name": []byte("fake-name")},
+		})
+	})
+
+	t.Run("should return ErrRecordNotFound for QueryOne", func(t *testing.T) {
+		db := newDB(t, "postgres", mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"id"}), nil
+			},
+		})
+
+		var row map[string]interface{}
+		err := db.QueryOne(context.Background(), &row, "SELECT id FROM users")
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+	})
+}
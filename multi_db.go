@@ -0,0 +1,84 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+)
+
+type databaseCtxKey struct{}
+
+// WithDatabase returns a context that selects which of the databases
+// of a MultiDB is used by the operations executed with it, e.g. the
+// middleware of an HTTP server might select the database of the
+// region of each request:
+//
+//	ctx = ksql.WithDatabase(ctx, "eu")
+func WithDatabase(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, databaseCtxKey{}, name)
+}
+
+// DatabaseName returns the name selected on the context by WithDatabase,
+// or an empty string if no database was selected.
+func DatabaseName(ctx context.Context) string {
+	name, _ := ctx.Value(databaseCtxKey{}).(string)
+	return name
+}
+
+// MultiDB is a Provider for applications that serve requests from
+// several physical databases, e.g. one per region or environment, it
+// runs each operation on the database selected on its context with
+// WithDatabase, so the repository code can receive a single Provider
+// and stay unaware of the routing:
+//
+//	db, err := ksql.NewMultiDB(map[string]ksql.Provider{
+//		"eu": euDB,
+//		"us": usDB,
+//	})
+//
+//	// On the request handlers:
+//	err = db.QueryOne(ksql.WithDatabase(ctx, "eu"), &user, "FROM users WHERE id = $1", id)
+//
+// It is a ShardRouter using the selected name as the shard key, so the
+// operations fail if the context selects no database or an unknown one,
+// and the operations inside a transaction must select the same database
+// as the transaction.
+type MultiDB struct {
+	ShardRouter
+}
+
+var (
+	_ Provider      = MultiDB{}
+	_ BatchProvider = MultiDB{}
+)
+
+// NewMultiDB instantiates a new MultiDB with the databases
+// that can be selected by name with WithDatabase.
+func NewMultiDB(databases map[string]Provider) (MultiDB, error) {
+	if _, found := databases[""]; found {
+		return MultiDB{}, fmt.Errorf("ksql: the names of the databases of a MultiDB can't be empty")
+	}
+
+	router, err := NewShardRouter(DatabaseName, databases)
+	if err != nil {
+		return MultiDB{}, err
+	}
+
+	return MultiDB{ShardRouter: router}, nil
+}
+
+// FromContext returns the Provider of the database selected on the
+// context, which is useful for calling the methods of the underlying
+// client that are not part of the Provider interface.
+func (m MultiDB) FromContext(ctx context.Context) (Provider, error) {
+	name := DatabaseName(ctx)
+	if name == "" {
+		return nil, fmt.Errorf("ksql: no database selected on the context, use ksql.WithDatabase() for selecting one")
+	}
+
+	db, found := m.shards[name]
+	if !found {
+		return nil, fmt.Errorf("ksql: no database configured with the name %q", name)
+	}
+
+	return db, nil
+}
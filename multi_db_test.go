@@ -0,0 +1,84 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestMultiDB(t *testing.T) {
+	newDB := func(name string, calls *[]string) Mock {
+		return Mock{
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				*calls = append(*calls, name+": "+query)
+				return NewMockResult(0, 1), nil
+			},
+		}
+	}
+
+	t.Run("should run each operation on the database selected on the context", func(t *testing.T) {
+		var calls []string
+		db, err := NewMultiDB(map[string]Provider{
+			"eu": newDB("eu", &calls),
+			"us": newDB("us", &calls),
+		})
+		tt.AssertNoErr(t, err)
+
+		ctx := context.Background()
+		_, err = db.Exec(WithDatabase(ctx, "eu"), "DELETE FROM users")
+		tt.AssertNoErr(t, err)
+		_, err = db.Exec(WithDatabase(ctx, "us"), "DELETE FROM posts")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, calls, []string{
+			"eu: DELETE FROM users",
+			"us: DELETE FROM posts",
+		})
+	})
+
+	t.Run("should return the selected database with FromContext", func(t *testing.T) {
+		var calls []string
+		db, err := NewMultiDB(map[string]Provider{
+			"eu": newDB("eu", &calls),
+			"us": newDB("us", &calls),
+		})
+		tt.AssertNoErr(t, err)
+
+		selected, err := db.FromContext(WithDatabase(context.Background(), "us"))
+		tt.AssertNoErr(t, err)
+
+		_, err = selected.Exec(context.Background(), "DELETE FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"us: DELETE FROM users"})
+	})
+
+	t.Run("should report error if the context selects no database or an unknown one", func(t *testing.T) {
+		var calls []string
+		db, err := NewMultiDB(map[string]Provider{
+			"eu": newDB("eu", &calls),
+		})
+		tt.AssertNoErr(t, err)
+
+		_, err = db.FromContext(context.Background())
+		tt.AssertErrContains(t, err, "no database selected", "WithDatabase")
+
+		_, err = db.FromContext(WithDatabase(context.Background(), "br"))
+		tt.AssertErrContains(t, err, "no database configured", "br")
+
+		_, err = db.Exec(context.Background(), "DELETE FROM users")
+		tt.AssertErrContains(t, err, "no shard configured")
+		tt.AssertEqual(t, len(calls), 0)
+	})
+
+	t.Run("should report error for invalid databases", func(t *testing.T) {
+		_, err := NewMultiDB(map[string]Provider{})
+		tt.AssertErrContains(t, err, "at least one")
+
+		_, err = NewMultiDB(map[string]Provider{"": Mock{}})
+		tt.AssertErrContains(t, err, "can't be empty")
+
+		_, err = NewMultiDB(map[string]Provider{"eu": nil})
+		tt.AssertErrContains(t, err, "nil", "eu")
+	})
+}
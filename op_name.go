@@ -0,0 +1,57 @@
+package ksql
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+type opNameCtxKey struct{}
+
+// WithOpName returns a context that names the operations executed with it,
+// e.g. `ksql.WithOpName(ctx, "GetUserByEmail")`, so logs, traces and
+// metrics can be grouped by logical operation instead of by raw SQL.
+//
+// The name is available to the hooks configured on the client as the
+// `OpInfo.Name` attribute, it is reported on the ContextError type and,
+// if the WithSQLCommenter option is used, it is also sent to the
+// database as a sqlcommenter comment.
+func WithOpName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, opNameCtxKey{}, name)
+}
+
+// OpName returns the name set on the context by WithOpName,
+// or an empty string if the operation has no name.
+func OpName(ctx context.Context) string {
+	name, _ := ctx.Value(opNameCtxKey{}).(string)
+	return name
+}
+
+// WithSQLCommenter appends a comment in the sqlcommenter format
+// to the queries of named operations, e.g.:
+//
+//	SELECT "id", "name" FROM users WHERE email = $1 /*op_name='GetUserByEmail'*/
+//
+// so the name shows up on the database logs and on tools like
+// pg_stat_statements or Cloud SQL Insights.
+//
+// The queries of operations without a name are sent unchanged.
+func WithSQLCommenter() Option {
+	return func(db *DB) {
+		db.sqlCommenter = true
+	}
+}
+
+// addSQLComment appends the sqlcommenter comment to the query,
+// keeping the trailing semicolon at the end, if there is one.
+func addSQLComment(query string, opName string) string {
+	trimmed := strings.TrimRight(query, " \t\r\n")
+	semicolon := ""
+	if strings.HasSuffix(trimmed, ";") {
+		semicolon = ";"
+		trimmed = strings.TrimRight(strings.TrimSuffix(trimmed, ";"), " \t\r\n")
+	}
+
+	// The sqlcommenter values are URL encoded, which also escapes the quotes:
+	return trimmed + " /*op_name='" + url.PathEscape(opName) + "'*/" + semicolon
+}
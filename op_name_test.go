@@ -0,0 +1,119 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestWithOpName(t *testing.T) {
+	t.Run("should pass the name to the query rewriters", func(t *testing.T) {
+		var ops []OpInfo
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				return NewMockResult(0, 1), nil
+			},
+		}, "postgres",
+			WithQueryRewriter(func(ctx context.Context, op OpInfo, query string, params []interface{}) (string, []interface{}, error) {
+				ops = append(ops, op)
+				return query, params, nil
+			}),
+		)
+		tt.AssertNoErr(t, err)
+
+		err = db.Delete(WithOpName(context.Background(), "DeleteUser"), usersTable, 1)
+		tt.AssertNoErr(t, err)
+
+		_, err = db.Exec(context.Background(), "fake-query")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, ops, []OpInfo{
+			{Method: "Delete", TableName: "users", Name: "DeleteUser"},
+			{Method: "Exec"},
+		})
+	})
+
+	t.Run("should report the name on context errors", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		ctx := WithOpName(context.Background(), "ListUsers")
+		var users []user
+		err = db.Query(ctx, &users, "FROM users", Timeout(time.Millisecond))
+
+		var ctxErr ContextError
+		tt.AssertEqual(t, errors.As(err, &ctxErr), true)
+		tt.AssertEqual(t, ctxErr.OpName, "ListUsers")
+		tt.AssertErrContains(t, err, "Query (ListUsers)")
+	})
+
+	t.Run("should return the name set on the context", func(t *testing.T) {
+		tt.AssertEqual(t, OpName(context.Background()), "")
+		tt.AssertEqual(t, OpName(WithOpName(context.Background(), "GetUserByEmail")), "GetUserByEmail")
+	})
+}
+
+func TestWithSQLCommenter(t *testing.T) {
+	var queries []string
+	db, err := NewWithAdapter(mockDBAdapter{
+		ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+			queries = append(queries, query)
+			return NewMockResult(0, 1), nil
+		},
+	}, "postgres", WithSQLCommenter())
+	tt.AssertNoErr(t, err)
+
+	tests := []struct {
+		desc          string
+		opName        string
+		query         string
+		expectedQuery string
+	}{
+		{
+			desc:          "should not change the queries of unnamed operations",
+			query:         "DELETE FROM users",
+			expectedQuery: "DELETE FROM users",
+		},
+		{
+			desc:          "should append the name of the operation",
+			opName:        "DeleteUsers",
+			query:         "DELETE FROM users\n",
+			expectedQuery: "DELETE FROM users /*op_name='DeleteUsers'*/",
+		},
+		{
+			desc:          "should keep the trailing semicolon at the end",
+			opName:        "DeleteUsers",
+			query:         "DELETE FROM users ;",
+			expectedQuery: "DELETE FROM users /*op_name='DeleteUsers'*/;",
+		},
+		{
+			desc:          "should encode the name",
+			opName:        "it's */ a name",
+			query:         "DELETE FROM users",
+			expectedQuery: "DELETE FROM users /*op_name='it%27s%20%2A%2F%20a%20name'*/",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			queries = nil
+
+			ctx := context.Background()
+			if test.opName != "" {
+				ctx = WithOpName(ctx, test.opName)
+			}
+
+			_, err := db.Exec(ctx, test.query)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, queries, []string{test.expectedQuery})
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Option describes the optional configurations accepted by
+// `ksql.NewWithAdapter()` and by the constructors of all adapters.
+type Option func(*DB)
+
+// OpInfo describes the KSQL operation that is about to be executed,
+// it is passed as argument to the hooks configured on the client.
+type OpInfo struct {
+	// Method is the name of the ksql.DB method being executed, e.g. "Insert" or "Query"
+	Method string
+
+	// TableName is only set for the operations that receive a ksql.Table
+	TableName string
+
+	// Name is the name of the logical operation set with ksql.WithOpName(),
+	// e.g. "GetUserByEmail", it is empty if the context has no name.
+	Name string
+}
+
+// QueryRewriter is a hook that is called right before each query is sent to the
+// database, and is allowed to change both the query and its params.
+//
+// If it returns an error the query is not executed and the
+// operation fails with this same error.
+type QueryRewriter func(ctx context.Context, op OpInfo, query string, params []interface{}) (string, []interface{}, error)
+
+// WithQueryRewriter configures a hook that will be called before each query
+// executed by the client, including the ones generated internally, e.g. by Insert.
+//
+// This is useful for enforcing invariants centrally, e.g. blocking dangerous
+// statements or adding optimizer hints.
+//
+// If this option is used more than once the rewriters will be called
+// in the same order they were configured, each one receiving the
+// output of the previous one.
+func WithQueryRewriter(rewriter QueryRewriter) Option {
+	return func(db *DB) {
+		db.queryRewriters = append(db.queryRewriters, rewriter)
+	}
+}
+
+func (c DB) rewriteQuery(ctx context.Context, op OpInfo, query string, params []interface{}) (string, []interface{}, error) {
+	for _, rewrite := range c.queryRewriters {
+		var err error
+		query, params, err = rewrite(ctx, op, query, params)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	return query, params, nil
+}
+
+// checkMaxRows tells if the row with the input index should be loaded,
+// returning ErrTooManyRows or reporting the truncation if it should not.
+func (c DB) checkMaxRows(opts queryOptions, idx int) (load bool, _ error) {
+	maxRows := opts.maxRows
+	if maxRows == 0 {
+		maxRows = c.maxRows
+	}
+
+	if maxRows <= 0 || idx < maxRows {
+		return true, nil
+	}
+
+	if opts.truncated != nil {
+		*opts.truncated = true
+		return false, nil
+	}
+
+	return false, fmt.Errorf("%w: the maximum is %d", ErrTooManyRows, maxRows)
+}
+
+func (c DB) queryContext(ctx context.Context, op OpInfo, opts queryOptions, query string, params ...interface{}) (Rows, error) {
+	op.Name = OpName(ctx)
+	query, params, err := c.rewriteQuery(ctx, op, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err = c.encodeArgs(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.validateParams(query, params); err != nil {
+		return nil, err
+	}
+
+	if c.sqlCommenter && op.Name != "" {
+		query = addSQLComment(query, op.Name)
+	}
+
+	if opts.dryRunFn != nil {
+		opts.dryRunFn(query, params)
+		return nil, errDryRun
+	}
+
+	start := time.Now()
+	rows, err := c.db.QueryContext(ctx, query, params...)
+	return rows, newContextError(ctx, op, start, err)
+}
+
+func (c DB) execContext(ctx context.Context, op OpInfo, opts queryOptions, query string, params ...interface{}) (Result, error) {
+	op.Name = OpName(ctx)
+	query, params, err := c.rewriteQuery(ctx, op, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err = c.encodeArgs(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.validateParams(query, params); err != nil {
+		return nil, err
+	}
+
+	if c.sqlCommenter && op.Name != "" {
+		query = addSQLComment(query, op.Name)
+	}
+
+	if opts.dryRunFn != nil {
+		opts.dryRunFn(query, params)
+		return nil, errDryRun
+	}
+
+	start := time.Now()
+	result, err := c.db.ExecContext(ctx, query, params...)
+	return result, newContextError(ctx, op, start, err)
+}
+
+// WithAliasedNestedStructs changes how structs with the `tablename` tag are
+// filled: instead of relying on the order of the columns each column is matched
+// by its name, which is expected to have the form `<tablename>.<column>`.
+//
+// The SELECT part of the queries generated by KSQL will alias the columns
+// accordingly, e.g.:
+//
+//	SELECT "u"."id" AS "u.id", "u"."name" AS "u.name", "p"."id" AS "p.id", ...
+//
+// This is useful with views, CTEs or drivers where the order of the columns
+// can't be relied upon. Note that queries where the SELECT part is written
+// manually are always matched by alias regardless of this option.
+func WithAliasedNestedStructs() Option {
+	return func(db *DB) {
+		db.aliasNestedStructs = true
+	}
+}
+
+// WithMaxRows sets the maximum number of rows loaded by the Query method
+// of this client, queries returning more rows fail with ErrTooManyRows.
+//
+// It can be overridden per query with the MaxRows and TruncateRows options.
+func WithMaxRows(n int) Option {
+	return func(db *DB) {
+		db.maxRows = n
+	}
+}
+
+// WithDialect makes the DB write its queries with a custom dialect instead
+// of the one named on NewWithAdapter, e.g. for databases compatible with
+// one of the built-in dialects except for a few features:
+//
+//	type cockroachDialect struct {
+//		ksql.Dialect
+//	}
+//
+//	func (d cockroachDialect) DialectCapabilities() ksql.DialectCapabilities {
+//		caps := d.Dialect.(ksql.DialectCapabilitiesReporter).DialectCapabilities()
+//		caps.LargeObjects = false
+//		return caps
+//	}
+//
+//	postgres, err := ksql.GetDriverDialect("postgres")
+//	...
+//	db, err := ksql.NewWithAdapter(adapter, "postgres", ksql.WithDialect(cockroachDialect{postgres}))
+//
+// The custom dialects are built by embedding one of the dialects returned
+// by GetDriverDialect, and should describe their features by implementing
+// the DialectCapabilitiesReporter interface, otherwise the DB assumes they
+// only support the operations by ID and savepoints with an emulated Upsert.
+func WithDialect(dialect Dialect) Option {
+	return func(db *DB) {
+		db.dialect = dialect
+		db.customSelectCache = &sync.Map{}
+	}
+}
+
+// WithSQLiteReturning makes the sqlite3 dialect retrieve the IDs of inserted
+// records with the RETURNING clause instead of `last_insert_rowid()`, which
+// also works for WITHOUT ROWID and composite key tables and refreshes the
+// attributes tagged as `generated` just like on postgres.
+//
+// The RETURNING clause is only available since SQLite 3.35, and the ksqlite3
+// adapter enables this option automatically when the linked SQLite supports it.
+// For the other dialects this option has no effect.
+func WithSQLiteReturning() Option {
+	return func(db *DB) {
+		if d, ok := db.dialect.(*sqlite3Dialect); ok {
+			dialect := *d
+			dialect.returning = true
+			db.dialect = &dialect
+		}
+	}
+}
+
+// WithMySQLConsecutiveIDs makes InsertMany insert the records of the "mysql"
+// dialect with multi-row INSERT statements and still write the generated IDs
+// back to the records, computing them from LAST_INSERT_ID(), which is the ID
+// of the first row of each statement, the number of affected rows and the
+// @@auto_increment_increment of the session.
+//
+// This is only correct if the IDs generated by a multi-row INSERT are
+// consecutive, which MySQL guarantees when innodb_autoinc_lock_mode is 0
+// ("traditional") or 1 ("consecutive"), so only enable it if the server
+// is configured like that: with the mode 2 ("interleaved"), the default
+// since MySQL 8.0, concurrent inserts might interleave their IDs and the
+// records would silently receive the IDs of other rows.
+//
+// For the other dialects, including "tidb", this option has no effect.
+func WithMySQLConsecutiveIDs() Option {
+	return func(db *DB) {
+		if d, ok := db.dialect.(*mysqlDialect); ok {
+			dialect := *d
+			dialect.consecutiveIDs = true
+			db.dialect = &dialect
+		}
+	}
+}
+
+// WithTiDBBatchHints makes the bulk helpers of the "tidb" dialect, i.e.
+// InsertMany, UpdateMany and InsertCSV, add the input optimizer hints
+// to each of their statements, e.g.:
+//
+//	db, err := ksql.NewWithAdapter(adapter, "tidb",
+//		ksql.WithTiDBBatchHints("SET_VAR(tidb_mem_quota_query=8589934592)"),
+//	)
+//
+// which adds `/*+ SET_VAR(tidb_mem_quota_query=8589934592) */` right after
+// the first keyword of the statements. For the other dialects this option
+// has no effect.
+func WithTiDBBatchHints(hints ...string) Option {
+	return func(db *DB) {
+		if d, ok := db.dialect.(*tidbDialect); ok {
+			dialect := *d
+			dialect.batchHints = strings.Join(hints, " ")
+			db.dialect = &dialect
+		}
+	}
+}
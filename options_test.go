@@ -0,0 +1,198 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestWithQueryRewriter(t *testing.T) {
+	t.Run("should rewrite queries and params before they are executed", func(t *testing.T) {
+		var ops []OpInfo
+		var queries []string
+		var params [][]interface{}
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				queries = append(queries, query)
+				params = append(params, args)
+				return NewMockResult(0, 1), nil
+			},
+		}, "postgres",
+			WithQueryRewriter(func(ctx context.Context, op OpInfo, query string, params []interface{}) (string, []interface{}, error) {
+				ops = append(ops, op)
+				return query + " AND tenant_id = $2", append(params, 42), nil
+			}),
+		)
+		tt.AssertNoErr(t, err)
+
+		err = db.Delete(context.Background(), usersTable, 1)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, ops, []OpInfo{{Method: "Delete", TableName: "users"}})
+		tt.AssertEqual(t, queries, []string{`DELETE FROM "users" WHERE "id" = $1 AND tenant_id = $2`})
+		tt.AssertEqual(t, params, [][]interface{}{{1, 42}})
+	})
+
+	t.Run("should call multiple rewriters in order", func(t *testing.T) {
+		var query string
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+				query = q
+				return NewMockResult(0, 1), nil
+			},
+		}, "postgres",
+			WithQueryRewriter(func(ctx context.Context, op OpInfo, query string, params []interface{}) (string, []interface{}, error) {
+				return "/* first */ " + query, params, nil
+			}),
+			WithQueryRewriter(func(ctx context.Context, op OpInfo, query string, params []interface{}) (string, []interface{}, error) {
+				return "/* second */ " + query, params, nil
+			}),
+		)
+		tt.AssertNoErr(t, err)
+
+		_, err = db.Exec(context.Background(), "fake-query")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "/* second */ /* first */ fake-query")
+	})
+
+	t.Run("should abort the operation if the rewriter returns an error", func(t *testing.T) {
+		var numQueries int
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				numQueries++
+				return newMockRows(nil), nil
+			},
+		}, "postgres",
+			WithQueryRewriter(func(ctx context.Context, op OpInfo, query string, params []interface{}) (string, []interface{}, error) {
+				if strings.HasPrefix(query, "DROP") {
+					return "", nil, fmt.Errorf("fake-rewriter-error")
+				}
+				return query, params, nil
+			}),
+		)
+		tt.AssertNoErr(t, err)
+
+		var users []user
+		err = db.Query(context.Background(), &users, "DROP TABLE users")
+		tt.AssertErrContains(t, err, "fake-rewriter-error")
+		tt.AssertEqual(t, numQueries, 0)
+	})
+}
+
+func TestWithSQLiteReturning(t *testing.T) {
+	t.Run("should insert using RETURNING on sqlite3", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "sqlite3", WithSQLiteReturning())
+		tt.AssertNoErr(t, err)
+
+		var query string
+		ctx := WithQueryOptions(context.Background(), DryRun(func(q string, params []interface{}) {
+			query = q
+		}))
+		err = db.Insert(ctx, usersTable, &user{Name: "Bia"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, strings.HasSuffix(query, " RETURNING `id`"), true)
+	})
+
+	t.Run("should have no effect on other dialects", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "mysql", WithSQLiteReturning())
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, db.dialect.InsertMethod(), insertWithLastInsertID)
+	})
+}
+
+func TestWithMySQLConsecutiveIDs(t *testing.T) {
+	t.Run("should only configure the mysql dialect", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "mysql", WithMySQLConsecutiveIDs())
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, hasMultiRowInsertMany(db.dialect), true)
+
+		for _, driver := range []string{"tidb", "sqlite3", "postgres"} {
+			db, err := NewWithAdapter(mockDBAdapter{}, driver, WithMySQLConsecutiveIDs())
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, hasMultiRowInsertMany(db.dialect), false)
+		}
+	})
+}
+
+func TestDialectOptions(t *testing.T) {
+	t.Run("should keep the settings made by other options", func(t *testing.T) {
+		configured := &tidbDialect{mysqlDialect: mysqlDialect{consecutiveIDs: true}}
+		db := DB{dialect: configured}
+
+		WithTiDBBatchHints("MEMORY_QUOTA(1 GB)")(&db)
+		tt.AssertEqual(t, db.dialect, &tidbDialect{
+			mysqlDialect: mysqlDialect{consecutiveIDs: true},
+			batchHints:   "MEMORY_QUOTA(1 GB)",
+		})
+
+		// The dialect is copied instead of modified in place:
+		tt.AssertEqual(t, configured.batchHints, "")
+	})
+
+	t.Run("should not modify the dialects shared by other clients", func(t *testing.T) {
+		_, err := NewWithAdapter(mockDBAdapter{}, "sqlite3", WithSQLiteReturning())
+		tt.AssertNoErr(t, err)
+
+		db, err := NewWithAdapter(mockDBAdapter{}, "sqlite3")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, db.dialect.InsertMethod(), insertWithLastInsertID)
+	})
+}
+
+type customDialect struct {
+	Dialect
+}
+
+func (customDialect) Escape(str string) string {
+	return "`" + str + "`"
+}
+
+func (d customDialect) DialectCapabilities() DialectCapabilities {
+	caps := d.Dialect.(DialectCapabilitiesReporter).DialectCapabilities()
+	caps.Limit = LimitFetch
+	return caps
+}
+
+func TestWithDialect(t *testing.T) {
+	postgres, err := GetDriverDialect("postgres")
+	tt.AssertNoErr(t, err)
+
+	t.Run("should write the queries with the custom dialect", func(t *testing.T) {
+		var queries []string
+		adapter := mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				queries = append(queries, q)
+				return newMockRows([]string{"id", "name"}), nil
+			},
+		}
+
+		customDB := newDB(t, "postgres", adapter, WithDialect(customDialect{postgres}))
+		db := newDB(t, "postgres", adapter)
+
+		var users []user
+		err := customDB.Query(context.Background(), &users, "FROM users WHERE id > $1", 0, Limit(10, 0))
+		tt.AssertNoErr(t, err)
+		err = db.Query(context.Background(), &users, "FROM users WHERE id > $1", 0, Limit(10, 0))
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, queries, []string{
+			"SELECT `id`, `name`, `age`, `address` FROM users WHERE id > $1 FETCH FIRST 10 ROWS ONLY",
+			`SELECT "id", "name", "age", "address" FROM users WHERE id > $1 LIMIT 10`,
+		})
+	})
+
+	t.Run("should use the default capabilities if the dialect doesn't report them", func(t *testing.T) {
+		db := newDB(t, "postgres", mockDBAdapter{}, WithDialect(struct{ Dialect }{postgres}))
+
+		caps := db.Capabilities()
+		tt.AssertEqual(t, caps.UpdateByID, true)
+		tt.AssertEqual(t, caps.Upsert, UpsertEmulated)
+
+		_, err := db.HistoryTriggerQuery(NewTable("users").WithHistory("users_history"))
+		tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+	})
+}
@@ -0,0 +1,220 @@
+package ksql
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// ParamsMismatchError is returned when the number of params passed to
+// an operation doesn't match the number of placeholders on its query.
+//
+// It is reported by KSQL before the query is sent to the database,
+// since the errors returned by the drivers in this case are usually
+// hard to understand.
+type ParamsMismatchError struct {
+	Expected int
+	Provided int
+
+	// Fingerprint identifies the query without exposing its contents,
+	// it is the same for queries that only differ by whitespace.
+	Fingerprint string
+}
+
+func (e ParamsMismatchError) Error() string {
+	return fmt.Sprintf(
+		"ksql: the query expects %d param(s) but %d were provided (query fingerprint: %s)",
+		e.Expected, e.Provided, e.Fingerprint,
+	)
+}
+
+// SkipParamsValidation disables the validation of the number of params
+// passed to each query, which should only be necessary if the validation
+// fails to parse some valid query.
+func SkipParamsValidation() Option {
+	return func(db *DB) {
+		db.skipParamsValidation = true
+	}
+}
+
+func (c DB) validateParams(query string, params []interface{}) error {
+	if c.skipParamsValidation {
+		return nil
+	}
+
+	for _, param := range params {
+		if _, isNamed := param.(sql.NamedArg); isNamed {
+			// Named args are matched by name so we can't count them
+			return nil
+		}
+	}
+
+	expected, ok := countPlaceholders(c.dialect, query)
+	if !ok || expected == len(params) {
+		return nil
+	}
+
+	return ParamsMismatchError{
+		Expected:    expected,
+		Provided:    len(params),
+		Fingerprint: queryFingerprint(query),
+	}
+}
+
+// countPlaceholders returns the number of params expected by the query,
+// ignoring the placeholders inside strings, quoted identifiers and comments.
+//
+// It returns false if the query uses a syntax for placeholders
+// that can't be reliably counted, e.g. named placeholders on sqlite.
+func countPlaceholders(dialect Dialect, query string) (count int, ok bool) {
+	driver := dialect.DriverName()
+
+	for i := 0; i < len(query); i++ {
+		switch ch := query[i]; {
+		case (ch == 'E' || ch == 'e') && driver == "postgres" &&
+			i+1 < len(query) && query[i+1] == '\'' && (i == 0 || !isIdentifierChar(query[i-1])):
+			// Escape strings like E'it\'s' accept backslash escapes
+			i = skipQuoted(query, i+1, '\'', true)
+		case ch == '\'':
+			i = skipQuoted(query, i, '\'', driver == "mysql" || driver == "snowflake" || driver == "bigquery")
+		case ch == '"':
+			i = skipQuoted(query, i, '"', driver == "mysql" || driver == "bigquery")
+		case ch == '`' && (driver == "mysql" || driver == "sqlite3" || driver == "bigquery"):
+			i = skipQuoted(query, i, '`', false)
+		case ch == '[' && driver == "sqlserver":
+			i = skipQuoted(query, i, ']', false)
+		case ch == '-' && strings.HasPrefix(query[i:], "--"),
+			ch == '#' && (driver == "mysql" || driver == "bigquery"):
+			i = skipUntil(query, i, "\n")
+		case ch == '/' && strings.HasPrefix(query[i:], "/*"):
+			i = skipUntil(query, i+2, "*/")
+
+		case ch == '?' && (driver == "mysql" || driver == "sqlite3" || driver == "firebird" || driver == "snowflake" || driver == "bigquery"):
+			if driver == "sqlite3" && i+1 < len(query) && isDigit(query[i+1]) {
+				// Numbered placeholders like `?1` can be repeated
+				return 0, false
+			}
+			count++
+		case ch == '@' && driver == "bigquery":
+			// Named parameters like `@name` can be repeated
+			if i+1 < len(query) && isIdentifierChar(query[i+1]) {
+				return 0, false
+			}
+		case ch == ':' && driver == "snowflake":
+			// Numbered binds like `:1` can be repeated
+			if i+1 < len(query) && isDigit(query[i+1]) {
+				return 0, false
+			}
+		case (ch == ':' || ch == '@' || ch == '$') && driver == "sqlite3":
+			if i+1 < len(query) && isIdentifierChar(query[i+1]) {
+				return 0, false
+			}
+
+		case ch == '$' && driver == "postgres":
+			if i > 0 && isIdentifierChar(query[i-1]) {
+				// `$` is a valid char inside postgres identifiers
+				continue
+			}
+
+			n, end := readNumber(query, i+1)
+			if end > i+1 {
+				if n > count {
+					count = n
+				}
+				i = end - 1
+				continue
+			}
+
+			// It might be a dollar-quoted string, e.g. $$text$$ or $tag$text$tag$
+			tagEnd := i + 1
+			for tagEnd < len(query) && isIdentifierChar(query[tagEnd]) {
+				tagEnd++
+			}
+			if tagEnd < len(query) && query[tagEnd] == '$' {
+				tag := query[i : tagEnd+1]
+				i = skipUntil(query, tagEnd+1, tag)
+			}
+
+		case ch == '@' && driver == "sqlserver":
+			if i > 0 && (isIdentifierChar(query[i-1]) || query[i-1] == '@') {
+				continue
+			}
+			if !strings.HasPrefix(strings.ToLower(query[i:]), "@p") {
+				// Other variables are declared on the query itself
+				continue
+			}
+
+			n, end := readNumber(query, i+2)
+			if end > i+2 && (end == len(query) || !isIdentifierChar(query[end])) {
+				if n > count {
+					count = n
+				}
+				i = end - 1
+			}
+		}
+	}
+
+	return count, true
+}
+
+// skipQuoted returns the index of the char that closes the quoted text starting at `start`
+func skipQuoted(query string, start int, closingChar byte, backslashEscapes bool) int {
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case closingChar:
+			// Doubling the closing char escapes it:
+			if i+1 < len(query) && query[i+1] == closingChar {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+
+	return len(query)
+}
+
+// skipUntil returns the index of the last char of the
+// first occurrence of the terminator after `start`
+func skipUntil(query string, start int, terminator string) int {
+	idx := strings.Index(query[start:], terminator)
+	if idx == -1 {
+		return len(query)
+	}
+
+	return start + idx + len(terminator) - 1
+}
+
+func readNumber(query string, start int) (n int, end int) {
+	end = start
+	for end < len(query) && isDigit(query[end]) {
+		end++
+	}
+
+	if end == start {
+		return 0, start
+	}
+
+	n, _ = strconv.Atoi(query[start:end])
+	return n, end
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentifierChar(ch byte) bool {
+	return ch == '_' || isDigit(ch) || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch >= 0x80
+}
+
+func queryFingerprint(query string) string {
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(strings.Fields(query), " ")))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
@@ -0,0 +1,132 @@
+package ksql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestCountPlaceholders(t *testing.T) {
+	tests := []struct {
+		desc          string
+		driver        string
+		query         string
+		expectedCount int
+		expectedOk    bool
+	}{
+		{
+			desc:          "postgres placeholders",
+			driver:        "postgres",
+			query:         `SELECT * FROM users WHERE id = $1 AND (name = $2 OR nickname = $2)`,
+			expectedCount: 2,
+			expectedOk:    true,
+		},
+		{
+			desc:          "postgres placeholders inside strings, identifiers and comments",
+			driver:        "postgres",
+			query:         "SELECT '$3', \"col$4\", col$5 FROM users -- $6\n WHERE id = $1 /* $7 */",
+			expectedCount: 1,
+			expectedOk:    true,
+		},
+		{
+			desc:          "postgres dollar-quoted strings",
+			driver:        "postgres",
+			query:         `SELECT $$ $2 $$, $tag$ $3 $tag$ FROM users WHERE id = $1`,
+			expectedCount: 1,
+			expectedOk:    true,
+		},
+		{
+			desc:          "postgres escape strings",
+			driver:        "postgres",
+			query:         `SELECT E'it\'s $2', e'\\', 'E' FROM users WHERE id = $1`,
+			expectedCount: 1,
+			expectedOk:    true,
+		},
+		{
+			desc:          "postgres jsonb operators",
+			driver:        "postgres",
+			query:         `SELECT * FROM users WHERE data ? 'key' AND id = $1`,
+			expectedCount: 1,
+			expectedOk:    true,
+		},
+		{
+			desc:          "mysql placeholders",
+			driver:        "mysql",
+			query:         "SELECT '?', `?`, \"it\\'s ?\" FROM users # ?\n WHERE id = ? AND name = ?",
+			expectedCount: 2,
+			expectedOk:    true,
+		},
+		{
+			desc:          "sqlite placeholders",
+			driver:        "sqlite3",
+			query:         "SELECT 'it''s ?' FROM users WHERE id = ?",
+			expectedCount: 1,
+			expectedOk:    true,
+		},
+		{
+			desc:       "sqlite named placeholders",
+			driver:     "sqlite3",
+			query:      "SELECT * FROM users WHERE id = :id",
+			expectedOk: false,
+		},
+		{
+			desc:       "sqlite numbered placeholders",
+			driver:     "sqlite3",
+			query:      "SELECT * FROM users WHERE id = ?1",
+			expectedOk: false,
+		},
+		{
+			desc:          "sqlserver placeholders",
+			driver:        "sqlserver",
+			query:         "DECLARE @param INT; SELECT [@p3], @@ROWCOUNT FROM users WHERE id = @p1 AND name = @P2",
+			expectedCount: 2,
+			expectedOk:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			count, ok := countPlaceholders(supportedDialects[test.driver], test.query)
+			tt.AssertEqual(t, ok, test.expectedOk)
+			tt.AssertEqual(t, count, test.expectedCount)
+		})
+	}
+}
+
+func TestParamsValidation(t *testing.T) {
+	adapter := mockDBAdapter{
+		ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+			return NewMockResult(0, 0), nil
+		},
+	}
+
+	t.Run("should report mismatches before running the query", func(t *testing.T) {
+		db := newDB(t, "sqlserver", adapter)
+
+		_, err := db.Exec(context.Background(), "UPDATE users SET name = @p1 WHERE id = @p2", "fake-name")
+
+		var mismatchErr ParamsMismatchError
+		tt.AssertEqual(t, errors.As(err, &mismatchErr), true)
+		tt.AssertEqual(t, mismatchErr.Expected, 2)
+		tt.AssertEqual(t, mismatchErr.Provided, 1)
+		tt.AssertEqual(t, mismatchErr.Fingerprint, queryFingerprint("UPDATE users  SET name = @p1\n WHERE id = @p2"))
+		tt.AssertErrContains(t, err, "expects 2 param(s) but 1 were provided", mismatchErr.Fingerprint)
+	})
+
+	t.Run("should not validate named args", func(t *testing.T) {
+		db := newDB(t, "sqlserver", adapter)
+
+		_, err := db.Exec(context.Background(), "UPDATE users SET name = @name", sql.Named("name", "fake-name"))
+		tt.AssertNoErr(t, err)
+	})
+
+	t.Run("should not validate if SkipParamsValidation is used", func(t *testing.T) {
+		db := newDB(t, "sqlserver", adapter, SkipParamsValidation())
+
+		_, err := db.Exec(context.Background(), "UPDATE users SET name = @p1 WHERE id = @p2", "fake-name")
+		tt.AssertNoErr(t, err)
+	})
+}
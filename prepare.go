@@ -0,0 +1,40 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+)
+
+// StatementPreparer can be implemented by the DBAdapter in order to
+// support preparing statements ahead of time with `DB.Prepare()`.
+type StatementPreparer interface {
+	Prepare(ctx context.Context, queries ...string) error
+}
+
+// Prepare prepares the input queries on the connections of the pool
+// so that the first requests sent after the startup of a service
+// don't have to pay for the parsing and planning of these queries.
+//
+// The prepared statements are only used by the queries that reach the
+// adapter with the exact same SQL, so they should be written just like
+// they are sent to the database, e.g. including the SELECT part of the
+// query and with the placeholders of the dialect.
+//
+// How the statements are kept depends on the adapter, e.g. kpgx prepares
+// them on all idle connections, while the database/sql adapters prepare
+// them once and let database/sql prepare them again on other connections
+// when needed. An error is returned if the adapter doesn't support it.
+func (c DB) Prepare(ctx context.Context, queries ...string) error {
+	base := c.db
+	for {
+		if preparer, ok := base.(StatementPreparer); ok {
+			return preparer.Prepare(ctx, queries...)
+		}
+
+		wrapper, ok := base.(adapterWrapper)
+		if !ok {
+			return fmt.Errorf("ksql: the adapter %T doesn't support preparing statements", c.db)
+		}
+		base = wrapper.unwrapAdapter()
+	}
+}
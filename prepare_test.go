@@ -0,0 +1,47 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type mockStatementPreparer struct {
+	mockDBAdapter
+	PrepareFn func(ctx context.Context, queries ...string) error
+}
+
+func (m mockStatementPreparer) Prepare(ctx context.Context, queries ...string) error {
+	return m.PrepareFn(ctx, queries...)
+}
+
+func TestPrepare(t *testing.T) {
+	t.Run("should call the adapter even when it is wrapped", func(t *testing.T) {
+		var prepared []string
+		adapter := mockStatementPreparer{
+			PrepareFn: func(ctx context.Context, queries ...string) error {
+				prepared = append(prepared, queries...)
+				return nil
+			},
+		}
+
+		db, err := NewWithAdapter(WrapAdapter(adapter, AdapterHooks{}), "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.Prepare(context.Background(), `SELECT id FROM users WHERE id = $1`, `DELETE FROM users WHERE id = $1`)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, prepared, []string{
+			`SELECT id FROM users WHERE id = $1`,
+			`DELETE FROM users WHERE id = $1`,
+		})
+	})
+
+	t.Run("should report an error if the adapter doesn't support it", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.Prepare(context.Background(), `SELECT 1`)
+		tt.AssertErrContains(t, err, "mockDBAdapter", "preparing statements")
+	})
+}
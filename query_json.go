@@ -0,0 +1,103 @@
+package ksql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// QueryJSON runs the query and streams its results to the writer as a
+// JSON array of objects, using the column names as keys, e.g.:
+//
+//	[{"id":1,"name":"Bia"},{"id":2,"name":"João"}]
+//
+// Each row is written as soon as it is read, so the results are never
+// fully loaded into memory, which makes this method ideal for export
+// endpoints. Note that if an error happens in the middle of the process
+// part of the output might have already been written.
+//
+// As with the maps accepted by the Query method the SELECT part of the
+// query can't be omitted, so remember to alias the columns with the same
+// names used on the `ksql` tags of your structs.
+func (c DB) QueryJSON(ctx context.Context, w io.Writer, query string, params ...interface{}) error {
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).QueryJSON(ctx, w, query, params...)
+		})
+	}
+
+	opts, params := extractQueryOptions(params)
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := c.runMapQuery(ctx, OpInfo{Method: "QueryJSON"}, opts, query, params...)
+	if err == errDryRun {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	// The keys are encoded only once since they are the same for all rows:
+	encodedKeys := make([][]byte, len(columns))
+	for i, column := range columns {
+		encodedKeys[i], err = json.Marshal(column)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	var buf []byte
+	for idx := 0; rows.Next(); idx++ {
+		m, err := scanRowsIntoMap(c.dialect, rows, columns)
+		if err != nil {
+			return err
+		}
+
+		buf = buf[:0]
+		if idx > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '{')
+		for i, column := range columns {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+
+			encodedValue, err := json.Marshal(m[column])
+			if err != nil {
+				return err
+			}
+
+			buf = append(buf, encodedKeys[i]...)
+			buf = append(buf, ':')
+			buf = append(buf, encodedValue...)
+		}
+		buf = append(buf, '}')
+
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]")
+	return err
+}
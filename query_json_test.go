@@ -0,0 +1,58 @@
+package ksql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestQueryJSON(t *testing.T) {
+	t.Run("should stream the rows as a JSON array preserving the column order", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newMockRows(
+					[]string{"name", "id", "nickname"},
+					[]interface{}{"Bia", int64(1), nil},
+					[]interface{}{"João", int64(2), "jj"},
+				), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var buf bytes.Buffer
+		err = db.QueryJSON(context.Background(), &buf, "SELECT name, id, nickname FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, buf.String(), `[{"name":"Bia","id":1,"nickname":null},{"name":"João","id":2,"nickname":"jj"}]`)
+	})
+
+	t.Run("should write an empty array if there are no rows", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"id"}), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var buf bytes.Buffer
+		err = db.QueryJSON(context.Background(), &buf, "SELECT id FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, buf.String(), `[]`)
+	})
+
+	t.Run("should report query errors", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return nil, fmt.Errorf("fake-error")
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var buf bytes.Buffer
+		err = db.QueryJSON(context.Background(), &buf, "SELECT id FROM users")
+		tt.AssertErrContains(t, err, "error running query", "fake-error")
+		tt.AssertEqual(t, buf.String(), "")
+	})
+}
@@ -0,0 +1,422 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QueryOption describes the optional configurations that can be
+// passed to a single operation.
+//
+// The methods that receive query params, e.g. Query and Exec, accept them
+// mixed with the params, they are removed from the params before the query
+// is executed, while the Insert, Patch, Update and Delete methods read them
+// from the context, as described on the `ksql.WithQueryOptions()` function, e.g.:
+//
+//	err := db.Query(ctx, &users, "FROM users WHERE type = $1", "admin", ksql.Timeout(time.Second))
+//
+//	err = db.Insert(ksql.WithQueryOptions(ctx, ksql.Timeout(time.Second)), UsersTable, &user)
+type QueryOption func(*queryOptions)
+
+type queryOptionsKey struct{}
+
+// WithQueryOptions returns a copy of the context carrying the input options,
+// which are used by the Insert, Patch, Update and Delete methods, since the
+// signatures of these methods on the Provider interface can't receive them.
+//
+// Passing the options on the context also allows them to reach the DB through
+// the Provider wrappers, e.g. the AuditProvider or the ReadWriteSplitter,
+// as well as through any other implementation of the Provider interface.
+//
+// The options already carried by the context are kept, and the DB only uses
+// them for the operation receiving the context, not for the other operations
+// it runs internally, e.g. the ones run by the lifecycle hooks.
+func WithQueryOptions(ctx context.Context, opts ...QueryOption) context.Context {
+	previous := contextQueryOptions(ctx)
+	return context.WithValue(ctx, queryOptionsKey{}, append(previous[:len(previous):len(previous)], opts...))
+}
+
+func contextQueryOptions(ctx context.Context) []QueryOption {
+	opts, _ := ctx.Value(queryOptionsKey{}).([]QueryOption)
+	return opts
+}
+
+// popQueryOptions returns the options carried by the context
+// along with a copy of the context without these options.
+func popQueryOptions(ctx context.Context) (context.Context, []QueryOption) {
+	opts := contextQueryOptions(ctx)
+	if opts == nil {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, queryOptionsKey{}, []QueryOption(nil)), opts
+}
+
+type queryOptions struct {
+	extraSelects    []string
+	columns         []string
+	timeout         time.Duration
+	forUpdate       bool
+	limit           *queryLimit
+	hints           []string
+	tableHints      []string
+	noCache         bool
+	strictScan      bool
+	strictImmutable bool
+	identityInsert  bool
+	skipIDRetrieval bool
+	batch           bool
+	fromPrimary     bool
+	allowZeroRows   bool
+	rowsAffected    *int64
+	maxRows         int
+	skipInvalidRows bool
+	truncated       *bool
+	largeObject     bool
+	rawQuery        bool
+	autoSelect      bool
+	withDeleted     bool
+	asOf            *time.Time
+	dryRunFn        func(query string, params []interface{})
+}
+
+// Timeout cancels the operation if it takes longer than the input duration,
+// the context of the operation is also canceled after it returns.
+//
+// Queries interrupted by a timeout or a cancellation fail with a
+// ksql.ContextError, which tells if this timeout was the cause.
+func Timeout(d time.Duration) QueryOption {
+	return func(opts *queryOptions) {
+		opts.timeout = d
+	}
+}
+
+// ForUpdate appends the `FOR UPDATE` clause to the queries
+// of the Query, QueryOne and QueryChunks methods, locking
+// the selected rows until the end of the current transaction.
+//
+// It is only supported by the postgres, mysql and firebird dialects,
+// on firebird the `FOR UPDATE WITH LOCK` clause is used.
+func ForUpdate() QueryOption {
+	return func(opts *queryOptions) {
+		opts.forUpdate = true
+	}
+}
+
+// NoCache disables the cache of the SELECT part of the queries generated
+// by KSQL, which is useful for types that are rarely used and therefore
+// shouldn't be kept in memory for the whole lifetime of the program.
+func NoCache() QueryOption {
+	return func(opts *queryOptions) {
+		opts.noCache = true
+	}
+}
+
+// StrictScan makes queries fail if they return any column
+// that has no corresponding attribute on the destination struct,
+// by default these columns are silently ignored.
+func StrictScan() QueryOption {
+	return func(opts *queryOptions) {
+		opts.strictScan = true
+	}
+}
+
+// StrictImmutable makes the Patch, Update and Upsert operations return
+// ErrImmutableColumn if the record has a non-zero value on any attribute
+// tagged with the `immutable` modifier, by default these attributes
+// are silently left out of the update.
+//
+// Note that only the changed attributes are checked when the
+// record is a *ksql.Tracker, so it is the best way of using this option
+// with records loaded from the database.
+func StrictImmutable() QueryOption {
+	return func(opts *queryOptions) {
+		opts.strictImmutable = true
+	}
+}
+
+// IdentityInsert allows inserting records with explicit values on
+// SQL Server IDENTITY columns and TiDB AUTO_RANDOM columns, which is
+// useful for data migrations that must preserve the original IDs.
+//
+// When the record has non-zero IDs the insert runs inside a transaction
+// between `SET IDENTITY_INSERT <table> ON` and `SET IDENTITY_INSERT <table> OFF`
+// statements on SQL Server, and between statements setting the
+// `@@allow_auto_random_explicit_insert` variable on TiDB. It has no effect
+// on the other dialects since they accept explicit IDs by default.
+func IdentityInsert() QueryOption {
+	return func(opts *queryOptions) {
+		opts.identityInsert = true
+	}
+}
+
+// SkipIDRetrieval makes the Insert and InsertMany methods send plain INSERT
+// statements, i.e. without the RETURNING or OUTPUT clauses and without reading
+// the last insert ID, so the IDs and the generated columns of the records
+// are not updated after the insert.
+//
+// It is useful for bulk writes that don't need the IDs and for tables whose
+// IDs are natural keys already set by the caller. Note that the IDs of tables
+// with sequences are still read from the sequence before the insert.
+func SkipIDRetrieval() QueryOption {
+	return func(opts *queryOptions) {
+		opts.skipIDRetrieval = true
+	}
+}
+
+// FromPrimary makes the ksql.ReadWriteSplitter send the query to the
+// primary database instead of a replica, which is useful for reads that
+// must see the latest writes. It has no effect on other Providers.
+func FromPrimary() QueryOption {
+	return func(opts *queryOptions) {
+		opts.fromPrimary = true
+	}
+}
+
+// AllowZeroRows makes the Patch, Update and Delete operations return
+// nil instead of ErrRecordNotFound when no rows are affected, which
+// is useful for idempotent handlers, e.g. where deleting a record
+// that was already deleted is not an error.
+//
+// It can be used with the RowsAffected option for checking
+// if the record was actually changed.
+func AllowZeroRows() QueryOption {
+	return func(opts *queryOptions) {
+		opts.allowZeroRows = true
+	}
+}
+
+// RowsAffected stores on the input pointer the number of rows
+// affected by the Patch, Update and Delete operations.
+func RowsAffected(n *int64) QueryOption {
+	return func(opts *queryOptions) {
+		opts.rowsAffected = n
+	}
+}
+
+// MaxRows makes the Query method fail with ErrTooManyRows if the
+// query returns more than n rows, which protects the service from
+// loading unbounded result sets into memory by accident.
+//
+// It overrides the default set with the WithMaxRows option.
+func MaxRows(n int) QueryOption {
+	return func(opts *queryOptions) {
+		opts.maxRows = n
+		opts.truncated = nil
+	}
+}
+
+// TruncateRows makes the Query method load only the first n rows returned
+// by the query, instead of failing like the MaxRows option, and stores on the
+// input pointer if there were more rows, i.e. if the results were truncated.
+func TruncateRows(n int, truncated *bool) QueryOption {
+	return func(opts *queryOptions) {
+		opts.maxRows = n
+		opts.truncated = truncated
+	}
+}
+
+// SkipInvalidRows makes the Query method skip the rows that fail to be
+// scanned, e.g. because of invalid JSON on an attribute tagged with the
+// `json` modifier, instead of aborting the whole query.
+//
+// The valid rows are loaded as usual, and if any row was skipped Query
+// returns a RowScanErrors error describing each of the skipped rows.
+func SkipInvalidRows() QueryOption {
+	return func(opts *queryOptions) {
+		opts.skipInvalidRows = true
+	}
+}
+
+// LargeObject tells QueryBlob that its query returns the OID
+// of a postgres large object instead of the binary value itself.
+func LargeObject() QueryOption {
+	return func(opts *queryOptions) {
+		opts.largeObject = true
+	}
+}
+
+// RawQuery makes the Query, QueryOne and QueryChunks methods send the query
+// exactly as written, i.e. the SELECT part of the query is never generated,
+// even if the query starts with `FROM`.
+func RawQuery() QueryOption {
+	return func(opts *queryOptions) {
+		opts.rawQuery = true
+	}
+}
+
+// AutoSelect makes the Query, QueryOne and QueryChunks methods always
+// generate the SELECT part of the query, instead of detecting whether
+// the query starts with `FROM`, which is useful for queries built
+// dynamically, e.g. with prefixes KSQL can't recognize.
+//
+// The query should then be written from the FROM clause onwards.
+func AutoSelect() QueryOption {
+	return func(opts *queryOptions) {
+		opts.autoSelect = true
+	}
+}
+
+// DryRun prevents the operation from being executed, instead the
+// input function is called with the query and params that would
+// have been sent to the database and the operation returns no error.
+//
+// Note that for the Query methods no records are loaded and
+// for the Exec method the returned Result reports 0 affected rows.
+func DryRun(fn func(query string, params []interface{})) QueryOption {
+	return func(opts *queryOptions) {
+		opts.dryRunFn = fn
+	}
+}
+
+// AddSelect adds extra expressions to the SELECT part of the
+// query generated by KSQL when the query starts with `FROM`.
+//
+// Each expression should be aliased with the `ksql` tag of the struct
+// attribute that will receive its value, and this attribute is then
+// omitted from the columns generated automatically, e.g.:
+//
+//	var users []struct {
+//		ID         int    `ksql:"id"`
+//		Name       string `ksql:"name"`
+//		PostsCount int    `ksql:"posts_count"`
+//	}
+//	err := db.Query(ctx, &users,
+//		"FROM users u LEFT JOIN posts p ON p.user_id = u.id GROUP BY u.id, u.name",
+//		ksql.AddSelect("count(p.id) AS posts_count"),
+//	)
+//
+// When used with nested structs the columns are matched by their
+// `<tablename>.<column>` aliases, so the expressions should be aliased
+// the same way, e.g. `count(p.id) AS "u.posts_count"`.
+func AddSelect(expressions ...string) QueryOption {
+	return func(opts *queryOptions) {
+		opts.extraSelects = append(opts.extraSelects, expressions...)
+	}
+}
+
+// Columns restricts the SELECT part of the query generated by KSQL
+// to the input columns, the attributes of the other columns are not
+// loaded, so they are left with their zero values, e.g.:
+//
+//	var users []User
+//	err := db.Query(ctx, &users, "FROM users", ksql.Columns("id", "name"))
+//
+// For nested structs the columns should be written as
+// `<tablename>.<column>`, e.g. `ksql.Columns("u.id", "p.title")`.
+func Columns(columns ...string) QueryOption {
+	return func(opts *queryOptions) {
+		opts.columns = append(opts.columns, columns...)
+	}
+}
+
+func newQueryOptions(opts []QueryOption) queryOptions {
+	var o queryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// checkRowsAffected reports the number of affected rows to the
+// RowsAffected option and returns ErrRecordNotFound if it is zero,
+// unless the AllowZeroRows option was used.
+func (o queryOptions) checkRowsAffected(n int64) error {
+	if o.rowsAffected != nil {
+		*o.rowsAffected = n
+	}
+
+	if n < 1 && !o.allowZeroRows {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (o queryOptions) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout == 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(withTimeoutInfo(ctx, o.timeout), o.timeout)
+}
+
+// errDryRun is returned by the queryContext and execContext
+// methods when the DryRun option is used, it is never
+// returned to the user.
+var errDryRun = fmt.Errorf("ksql: dry run, the query was not executed")
+
+type dryRunResult struct{}
+
+func (dryRunResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (dryRunResult) RowsAffected() (int64, error) {
+	return 0, nil
+}
+
+// buildForUpdateQuery appends the `FOR UPDATE` clause to the query
+func buildForUpdateQuery(dialect Dialect, query string) (string, error) {
+	lockClause := rowLockClause(dialect)
+	if lockClause == "" {
+		return "", fmt.Errorf("ksql: ForUpdate is not supported by the %s dialect", dialect.DriverName())
+	}
+
+	return strings.TrimRight(query, " \t\n;") + lockClause, nil
+}
+
+// rowLockClause returns the clause that locks the rows read by a
+// query, or an empty string if the dialect has no such clause.
+func rowLockClause(dialect Dialect) string {
+	switch capabilitiesOf(dialect).RowLocks {
+	case RowLockForUpdate:
+		return " FOR UPDATE"
+	case RowLockForUpdateWithLock:
+		return " FOR UPDATE WITH LOCK"
+	default:
+		return ""
+	}
+}
+
+// extractQueryOptions removes all QueryOption values from the params
+// and returns them already applied to a queryOptions struct.
+func extractQueryOptions(params []interface{}) (queryOptions, []interface{}) {
+	var opts queryOptions
+
+	var filtered []interface{}
+	for i, param := range params {
+		opt, ok := param.(QueryOption)
+		if !ok {
+			if filtered != nil {
+				filtered = append(filtered, param)
+			}
+			continue
+		}
+
+		// Only copy the params if there is at least one option:
+		if filtered == nil {
+			filtered = append(make([]interface{}, 0, len(params)-1), params[:i]...)
+		}
+
+		opt(&opts)
+	}
+
+	if filtered == nil {
+		return opts, params
+	}
+
+	return opts, filtered
+}
+
+// selectAlias returns the unquoted alias of a SELECT expression
+// or an empty string if the expression has no alias.
+func selectAlias(expression string) string {
+	idx := strings.LastIndex(strings.ToUpper(expression), " AS ")
+	if idx == -1 {
+		return ""
+	}
+
+	return strings.Trim(expression[idx+len(" AS "):], " \t\n\"`[]")
+}
@@ -0,0 +1,696 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestExtractQueryOptions(t *testing.T) {
+	t.Run("should return the params unchanged if there are no options", func(t *testing.T) {
+		opts, params := extractQueryOptions([]interface{}{1, "foo"})
+		tt.AssertEqual(t, opts, queryOptions{})
+		tt.AssertEqual(t, params, []interface{}{1, "foo"})
+	})
+
+	t.Run("should remove the options from the params", func(t *testing.T) {
+		opts, params := extractQueryOptions([]interface{}{
+			1,
+			AddSelect("a AS b"),
+			"foo",
+			AddSelect("c AS d", "e"),
+		})
+		tt.AssertEqual(t, opts.extraSelects, []string{"a AS b", "c AS d", "e"})
+		tt.AssertEqual(t, params, []interface{}{1, "foo"})
+	})
+}
+
+func TestSelectAlias(t *testing.T) {
+	tests := []struct {
+		desc          string
+		expression    string
+		expectedAlias string
+	}{
+		{
+			desc:          "plain alias",
+			expression:    "count(p.id) AS posts_count",
+			expectedAlias: "posts_count",
+		},
+		{
+			desc:          "lowercase keyword",
+			expression:    "count(p.id) as posts_count",
+			expectedAlias: "posts_count",
+		},
+		{
+			desc:          "quoted alias",
+			expression:    `count(p.id) AS "u.posts_count"`,
+			expectedAlias: "u.posts_count",
+		},
+		{
+			desc:          "no alias",
+			expression:    "count(p.id)",
+			expectedAlias: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tt.AssertEqual(t, selectAlias(test.expression), test.expectedAlias)
+		})
+	}
+}
+
+func TestAddSelect(t *testing.T) {
+	type userWithCount struct {
+		ID         int    `ksql:"id"`
+		Name       string `ksql:"name"`
+		PostsCount int    `ksql:"posts_count"`
+	}
+
+	t.Run("should replace the aliased column of plain structs", func(t *testing.T) {
+		var query string
+		db := newDB(t, "postgres", mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query = q
+				tt.AssertEqual(t, args, []interface{}{42})
+				return newMockRows([]string{"id", "name", "posts_count"}, []interface{}{42, "fake-name", 3}), nil
+			},
+		})
+
+		var u userWithCount
+		err := db.QueryOne(context.Background(), &u, "FROM users WHERE id = $1", 42,
+			AddSelect("(SELECT count(*) FROM posts WHERE user_id = users.id) AS posts_count"),
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u, userWithCount{ID: 42, Name: "fake-name", PostsCount: 3})
+		tt.AssertEqual(t, query, `SELECT "id", "name", (SELECT count(*) FROM posts WHERE user_id = users.id) AS posts_count FROM users WHERE id = $1`)
+	})
+
+	t.Run("should alias the columns of nested structs", func(t *testing.T) {
+		var query string
+		db := newDB(t, "postgres", mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query = q
+				return newMockRows(
+					[]string{"u.id", "u.name", "p.id", "u.posts_count"},
+					[]interface{}{42, "fake-name", 43, 3},
+				), nil
+			},
+		})
+
+		var rows []struct {
+			User userWithCount `tablename:"u"`
+			Post struct {
+				ID int `ksql:"id"`
+			} `tablename:"p"`
+		}
+		err := db.Query(context.Background(), &rows, "FROM users u JOIN posts p ON p.user_id = u.id",
+			AddSelect(`1 AS "u.posts_count"`),
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(rows), 1)
+		tt.AssertEqual(t, rows[0].User, userWithCount{ID: 42, Name: "fake-name", PostsCount: 3})
+		tt.AssertEqual(t, rows[0].Post.ID, 43)
+		tt.AssertEqual(t, query, `SELECT "u"."id" AS "u.id", "u"."name" AS "u.name", "p"."id" AS "p.id", 1 AS "u.posts_count" FROM users u JOIN posts p ON p.user_id = u.id`)
+	})
+
+	t.Run("should report error if the query starts with SELECT", func(t *testing.T) {
+		db := newDB(t, "postgres", mockDBAdapter{})
+
+		var u userWithCount
+		err := db.QueryOne(context.Background(), &u, "SELECT * FROM users", AddSelect("1 AS posts_count"))
+		tt.AssertErrContains(t, err, "AddSelect", "SELECT part")
+	})
+}
+
+func TestColumns(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+		Age  int    `ksql:"age"`
+	}
+
+	t.Run("should select only the input columns of plain structs", func(t *testing.T) {
+		var query string
+		db := newDB(t, "postgres", mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query = q
+				return newMockRows([]string{"id", "name"}, []interface{}{42, "fake-name"}), nil
+			},
+		})
+
+		var users []userRecord
+		err := db.Query(context.Background(), &users, "FROM users", Columns("name", "id"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []userRecord{{ID: 42, Name: "fake-name"}})
+		tt.AssertEqual(t, query, `SELECT "id", "name" FROM users`)
+	})
+
+	t.Run("should alias the selected columns of nested structs", func(t *testing.T) {
+		var query string
+		db := newDB(t, "postgres", mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query = q
+				return newMockRows([]string{"u.name", "p.id"}, []interface{}{"fake-name", 43}), nil
+			},
+		})
+
+		var rows []struct {
+			User userRecord `tablename:"u"`
+			Post struct {
+				ID    int    `ksql:"id"`
+				Title string `ksql:"title"`
+			} `tablename:"p"`
+		}
+		err := db.Query(context.Background(), &rows, "FROM users u JOIN posts p ON p.user_id = u.id",
+			Columns("u.name", "p.id"),
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(rows), 1)
+		tt.AssertEqual(t, rows[0].User, userRecord{Name: "fake-name"})
+		tt.AssertEqual(t, rows[0].Post.ID, 43)
+		tt.AssertEqual(t, query, `SELECT "u"."name" AS "u.name", "p"."id" AS "p.id" FROM users u JOIN posts p ON p.user_id = u.id`)
+	})
+
+	t.Run("should work together with AddSelect", func(t *testing.T) {
+		var query string
+		db := newDB(t, "postgres", mockDBAdapter{})
+
+		var u userRecord
+		err := db.QueryOne(context.Background(), &u, "FROM users", Columns("id", "age"), AddSelect("2 * age AS age"),
+			DryRun(func(q string, params []interface{}) {
+				query = q
+			}),
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `SELECT "id", 2 * age AS age FROM users`)
+	})
+
+	t.Run("should report error for columns with no matching attributes", func(t *testing.T) {
+		db := newDB(t, "postgres", mockDBAdapter{})
+
+		var u userRecord
+		err := db.QueryOne(context.Background(), &u, "FROM users", Columns("id", "email"))
+		tt.AssertErrContains(t, err, "email", "Columns()")
+	})
+
+	t.Run("should report error if the key columns are not selected", func(t *testing.T) {
+		db := newDB(t, "postgres", mockDBAdapter{})
+
+		err := db.QueryChunks(context.Background(), ChunkParser{
+			Query:      "FROM users",
+			Params:     []interface{}{Columns("name")},
+			ChunkSize:  10,
+			KeyColumns: []string{"id"},
+			ForEachChunk: func(users []userRecord) error {
+				return nil
+			},
+		})
+		tt.AssertErrContains(t, err, "id", "Columns()")
+	})
+
+	t.Run("should report error if the query starts with SELECT", func(t *testing.T) {
+		db := newDB(t, "postgres", mockDBAdapter{})
+
+		var u userRecord
+		err := db.QueryOne(context.Background(), &u, "SELECT * FROM users", Columns("id"))
+		tt.AssertErrContains(t, err, "Columns", "SELECT part")
+	})
+}
+
+func TestDryRun(t *testing.T) {
+	type dryRunCall struct {
+		query  string
+		params []interface{}
+	}
+
+	newAdapter := func(t *testing.T) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				t.Fatalf("the query should not have been executed: %s", query)
+				return nil, nil
+			},
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				t.Fatalf("the query should not have been executed: %s", query)
+				return nil, nil
+			},
+		}
+	}
+
+	t.Run("should not run queries", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(t))
+
+		var calls []dryRunCall
+		dryRun := DryRun(func(query string, params []interface{}) {
+			calls = append(calls, dryRunCall{query: query, params: params})
+		})
+
+		var u struct {
+			ID   int    `ksql:"id"`
+			Name string `ksql:"name"`
+		}
+		err := db.QueryOne(context.Background(), &u, "FROM users WHERE id = $1", 42, dryRun)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []dryRunCall{
+			{query: `SELECT "id", "name" FROM users WHERE id = $1`, params: []interface{}{42}},
+		})
+		tt.AssertEqual(t, u.ID, 0)
+	})
+
+	t.Run("should not run Exec statements", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(t))
+
+		var calls []dryRunCall
+		result, err := db.Exec(context.Background(), "DELETE FROM users WHERE id = $1", 42,
+			DryRun(func(query string, params []interface{}) {
+				calls = append(calls, dryRunCall{query: query, params: params})
+			}),
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []dryRunCall{
+			{query: "DELETE FROM users WHERE id = $1", params: []interface{}{42}},
+		})
+
+		rowsAffected, err := result.RowsAffected()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, rowsAffected, int64(0))
+	})
+
+	t.Run("should not run Insert, Patch or Delete statements", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(t))
+
+		var queries []string
+		dryRun := DryRun(func(query string, params []interface{}) {
+			queries = append(queries, query)
+		})
+
+		type userRecord struct {
+			ID   int    `ksql:"id"`
+			Name string `ksql:"name"`
+		}
+
+		err := db.Insert(WithQueryOptions(context.Background(), dryRun), usersTable, &userRecord{Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+
+		err = db.Patch(WithQueryOptions(context.Background(), dryRun), usersTable, &userRecord{ID: 42, Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+
+		err = db.Delete(WithQueryOptions(context.Background(), dryRun), usersTable, 42)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, len(queries), 3)
+		tt.AssertEqual(t, strings.HasPrefix(queries[0], "INSERT INTO"), true)
+		tt.AssertEqual(t, strings.HasPrefix(queries[1], "UPDATE"), true)
+		tt.AssertEqual(t, strings.HasPrefix(queries[2], "DELETE FROM"), true)
+	})
+}
+
+func TestForUpdate(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	t.Run("should append the FOR UPDATE clause", func(t *testing.T) {
+		var query string
+		db := newDB(t, "postgres", mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query = q
+				return newMockRows([]string{"id", "name"}, []interface{}{42, "fake-name"}), nil
+			},
+		})
+
+		var u userRecord
+		err := db.QueryOne(context.Background(), &u, "FROM users WHERE id = $1;", 42, ForUpdate())
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `SELECT "id", "name" FROM users WHERE id = $1 FOR UPDATE`)
+		tt.AssertEqual(t, u, userRecord{ID: 42, Name: "fake-name"})
+	})
+
+	t.Run("should report an error for dialects with no support for it", func(t *testing.T) {
+		db := newDB(t, "sqlite3", mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				t.Fatalf("the query should not have been executed: %s", q)
+				return nil, nil
+			},
+		})
+
+		var users []userRecord
+		err := db.Query(context.Background(), &users, "FROM users", ForUpdate())
+		tt.AssertErrContains(t, err, "ForUpdate", "sqlite3")
+	})
+}
+
+func TestStrictScan(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	db := newDB(t, "postgres", mockDBAdapter{
+		QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+			return newMockRows([]string{"id", "name", "age"}, []interface{}{42, "fake-name", 20}), nil
+		},
+	})
+
+	t.Run("should ignore unknown columns by default", func(t *testing.T) {
+		var u userRecord
+		err := db.QueryOne(context.Background(), &u, "SELECT id, name, age FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u, userRecord{ID: 42, Name: "fake-name"})
+	})
+
+	t.Run("should report unknown columns when using StrictScan", func(t *testing.T) {
+		var u userRecord
+		err := db.QueryOne(context.Background(), &u, "SELECT id, name, age FROM users", StrictScan())
+		tt.AssertErrContains(t, err, "age", "userRecord")
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	db := newDB(t, "postgres", mockDBAdapter{
+		ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+			deadline, hasDeadline = ctx.Deadline()
+			return nil, nil
+		},
+	})
+
+	_, err := db.Exec(context.Background(), "DELETE FROM users", Timeout(time.Minute))
+	tt.AssertNoErr(t, err)
+	tt.AssertEqual(t, hasDeadline, true)
+	tt.AssertApproxTime(t, 2*time.Second, deadline, time.Now().Add(time.Minute), "unexpected deadline")
+}
+
+func TestNoCache(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	db := newDB(t, "postgres", mockDBAdapter{
+		QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+			return newMockRows([]string{"id", "name"}, []interface{}{42, "fake-name"}), nil
+		},
+	})
+
+	var u userRecord
+	err := db.QueryOne(context.Background(), &u, "FROM users", NoCache())
+	tt.AssertNoErr(t, err)
+	tt.AssertEqual(t, u, userRecord{ID: 42, Name: "fake-name"})
+
+	_, found := selectQueryCache["postgres"].Load(reflect.TypeOf(u))
+	tt.AssertEqual(t, found, false)
+}
+
+func TestIdentityInsert(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newAdapter := func(queries *[]string, committed *bool) mockTxBeginner {
+		tx := mockTx{
+			mockDBAdapter: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					*queries = append(*queries, query)
+					return NewMockResult(0, 0), nil
+				},
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					*queries = append(*queries, query)
+					return newMockRows([]string{"id"}, []interface{}{42}), nil
+				},
+			},
+			CommitFn: func(ctx context.Context) error {
+				*committed = true
+				return nil
+			},
+		}
+
+		return mockTxBeginner{
+			mockDBAdapter: tx.mockDBAdapter,
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return tx, nil
+			},
+		}
+	}
+
+	t.Run("should enable IDENTITY_INSERT for records with explicit IDs", func(t *testing.T) {
+		var queries []string
+		var committed bool
+		db := newDB(t, "sqlserver", newAdapter(&queries, &committed))
+
+		err := db.Insert(WithQueryOptions(context.Background(), IdentityInsert()), usersTable, &userRecord{ID: 42, Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(queries), 3)
+		tt.AssertEqual(t, queries[0], "SET IDENTITY_INSERT [users] ON")
+		tt.AssertEqual(t, strings.HasPrefix(queries[1], "INSERT INTO [users]"), true)
+		tt.AssertEqual(t, queries[2], "SET IDENTITY_INSERT [users] OFF")
+		tt.AssertEqual(t, committed, true)
+	})
+
+	t.Run("should not enable IDENTITY_INSERT for records without IDs", func(t *testing.T) {
+		var queries []string
+		var committed bool
+		db := newDB(t, "sqlserver", newAdapter(&queries, &committed))
+
+		u := userRecord{Name: "fake-name"}
+		err := db.Insert(WithQueryOptions(context.Background(), IdentityInsert()), usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(queries), 1)
+		tt.AssertEqual(t, strings.HasPrefix(queries[0], "INSERT INTO [users]"), true)
+		tt.AssertEqual(t, u.ID, 42)
+		tt.AssertEqual(t, committed, false)
+	})
+}
+
+func TestSkipIDRetrieval(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	for _, dialect := range []string{"postgres", "sqlserver", "mysql"} {
+		t.Run("should insert without reading the IDs on "+dialect, func(t *testing.T) {
+			var queries []string
+			db := newDB(t, dialect, mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					queries = append(queries, query)
+					// Calling LastInsertId would panic:
+					return MockResult{RowsAffectedFn: func() (int64, error) { return 1, nil }}, nil
+				},
+			})
+
+			u := userRecord{Name: "fake-name"}
+			err := db.Insert(WithQueryOptions(context.Background(), SkipIDRetrieval()), usersTable, &u)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(queries), 1)
+			tt.AssertEqual(t, strings.Contains(queries[0], "RETURNING"), false)
+			tt.AssertEqual(t, strings.Contains(queries[0], "OUTPUT"), false)
+			tt.AssertEqual(t, u.ID, 0)
+		})
+	}
+
+	t.Run("should keep the IDs set by the caller", func(t *testing.T) {
+		var params []interface{}
+		db := newDB(t, "postgres", mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				params = args
+				return NewMockResult(0, 1), nil
+			},
+		})
+
+		u := userRecord{ID: 42, Name: "fake-name"}
+		err := db.Insert(WithQueryOptions(context.Background(), SkipIDRetrieval()), usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, 42)
+		tt.AssertEqual(t, len(params), 2)
+	})
+}
+
+func TestAllowZeroRows(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newAdapter := func(rowsAffected int64) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				return NewMockResult(0, rowsAffected), nil
+			},
+		}
+	}
+
+	ctx := context.Background()
+
+	t.Run("should return ErrRecordNotFound by default", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(0))
+
+		err := db.Patch(ctx, usersTable, &userRecord{ID: 42, Name: "fake-name"})
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+
+		err = db.Delete(ctx, usersTable, 42)
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+	})
+
+	t.Run("should return nil and report zero rows when using AllowZeroRows", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(0))
+
+		n := int64(-1)
+		err := db.Patch(WithQueryOptions(ctx, AllowZeroRows(), RowsAffected(&n)), usersTable, &userRecord{ID: 42, Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, n, int64(0))
+
+		n = -1
+		err = db.Delete(WithQueryOptions(ctx, AllowZeroRows(), RowsAffected(&n)), usersTable, 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, n, int64(0))
+	})
+
+	t.Run("should report the affected rows", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(1))
+
+		var n int64
+		err := db.Delete(WithQueryOptions(ctx, RowsAffected(&n)), usersTable, 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, n, int64(1))
+
+		n = 0
+		err = db.Update(WithQueryOptions(ctx, RowsAffected(&n)), usersTable, &userRecord{ID: 42, Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, n, int64(1))
+	})
+
+	t.Run("should report ErrRecordNotFound even if the count is captured", func(t *testing.T) {
+		db := newDB(t, "postgres", newAdapter(0))
+
+		n := int64(-1)
+		err := db.Delete(WithQueryOptions(ctx, RowsAffected(&n)), usersTable, 42)
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+		tt.AssertEqual(t, n, int64(0))
+	})
+}
+
+func TestMaxRows(t *testing.T) {
+	type userRecord struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	adapter := mockDBAdapter{
+		QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+			return newMockRows([]string{"id", "name"},
+				[]interface{}{1, "fake-name-1"},
+				[]interface{}{2, "fake-name-2"},
+				[]interface{}{3, "fake-name-3"},
+			), nil
+		},
+	}
+
+	t.Run("should fail if the query returns more rows than allowed", func(t *testing.T) {
+		db := newDB(t, "postgres", adapter)
+
+		var users []userRecord
+		err := db.Query(context.Background(), &users, "FROM users", MaxRows(2))
+		tt.AssertEqual(t, errors.Is(err, ErrTooManyRows), true)
+		tt.AssertErrContains(t, err, "maximum is 2")
+
+		var maps []map[string]interface{}
+		err = db.Query(context.Background(), &maps, "SELECT * FROM users", MaxRows(2))
+		tt.AssertEqual(t, errors.Is(err, ErrTooManyRows), true)
+	})
+
+	t.Run("should load all rows if they are within the limit", func(t *testing.T) {
+		db := newDB(t, "postgres", adapter)
+
+		var users []userRecord
+		err := db.Query(context.Background(), &users, "FROM users", MaxRows(3))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(users), 3)
+	})
+
+	t.Run("should truncate the results with TruncateRows", func(t *testing.T) {
+		db := newDB(t, "postgres", adapter)
+
+		truncated := false
+		var users []userRecord
+		err := db.Query(context.Background(), &users, "FROM users", TruncateRows(2, &truncated))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, truncated, true)
+		tt.AssertEqual(t, users, []userRecord{{ID: 1, Name: "fake-name-1"}, {ID: 2, Name: "fake-name-2"}})
+
+		var maps []map[string]interface{}
+		err = db.Query(context.Background(), &maps, "SELECT * FROM users", TruncateRows(5, &truncated))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, truncated, false)
+		tt.AssertEqual(t, len(maps), 3)
+	})
+
+	t.Run("should use the limit configured on the client by default", func(t *testing.T) {
+		db := newDB(t, "postgres", adapter, WithMaxRows(1))
+
+		var users []userRecord
+		err := db.Query(context.Background(), &users, "FROM users")
+		tt.AssertEqual(t, errors.Is(err, ErrTooManyRows), true)
+		tt.AssertErrContains(t, err, "maximum is 1")
+
+		err = db.Query(context.Background(), &users, "FROM users", MaxRows(10))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(users), 3)
+	})
+}
+
+func TestWithQueryOptions(t *testing.T) {
+	t.Run("should apply the options read from the context", func(t *testing.T) {
+		var rowsAffected int64
+		var hasDeadline bool
+		db := newDB(t, "postgres", mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				_, hasDeadline = ctx.Deadline()
+				return NewMockResult(0, 1), nil
+			},
+		})
+
+		ctx := WithQueryOptions(context.Background(), RowsAffected(&rowsAffected))
+		ctx = WithQueryOptions(ctx, Timeout(time.Minute))
+		err := db.Delete(ctx, usersTable, 42)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, rowsAffected, int64(1))
+		tt.AssertEqual(t, hasDeadline, true)
+	})
+
+	t.Run("should not apply the options to the operations made by the audit Sink", func(t *testing.T) {
+		var queries []string
+		db := newDB(t, "postgres", mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				queries = append(queries, query)
+				return NewMockResult(0, 1), nil
+			},
+		})
+
+		var dryRunQueries []string
+		auditDB, err := NewAuditProvider(db, AuditConfig{
+			Sink: func(ctx context.Context, event AuditEvent) error {
+				return db.Delete(ctx, NewTable("audit_log"), 7)
+			},
+		})
+		tt.AssertNoErr(t, err)
+
+		ctx := WithQueryOptions(context.Background(), DryRun(func(query string, params []interface{}) {
+			dryRunQueries = append(dryRunQueries, query)
+		}))
+		err = auditDB.Delete(ctx, usersTable, 42)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, dryRunQueries, []string{`DELETE FROM "users" WHERE "id" = $1`})
+		tt.AssertEqual(t, queries, []string{`DELETE FROM "audit_log" WHERE "id" = $1`})
+	})
+}
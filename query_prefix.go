@@ -0,0 +1,115 @@
+package ksql
+
+import (
+	"strings"
+	"unicode"
+)
+
+// parsedQuery describes the parts of a query that are used for
+// deciding if KSQL should generate the SELECT part of the query.
+type parsedQuery struct {
+	// prefix contains the leading comments and the WITH clause of the
+	// query, if any, except for the optimizer hints.
+	prefix string
+
+	// hints contains the optimizer hints, i.e. the `/*+ ... */` comments,
+	// found before the main query, since they must be written right after
+	// the SELECT keyword when the SELECT part of the query is generated.
+	hints []string
+
+	// main is the query without the prefix and hints.
+	main string
+
+	// firstToken is the first token of the main query in uppercase.
+	firstToken string
+
+	// autoSelect tells if the SELECT part of the query should be generated.
+	autoSelect bool
+}
+
+// parseQuery separates the main query from the leading comments, optimizer
+// hints and the WITH clause, e.g. for the query below:
+//
+//	-- Lists the adults:
+//	WITH adults AS (SELECT * FROM users WHERE age >= 18) FROM adults
+//
+// the main query is `FROM adults`, so the SELECT part can
+// still be omitted when using comments or CTEs.
+//
+// The RawQuery and AutoSelect options can be used for skipping
+// this detection and deciding explicitly if the SELECT part
+// should be generated or not.
+func parseQuery(query string, opts queryOptions) parsedQuery {
+	var parsed parsedQuery
+	rest := query
+	for {
+		trimmed := strings.TrimLeftFunc(rest, unicode.IsSpace)
+		parsed.prefix += rest[:len(rest)-len(trimmed)]
+		rest = trimmed
+
+		end := commentLen(rest)
+		if end == 0 {
+			break
+		}
+
+		comment := rest[:end]
+		if strings.HasPrefix(comment, "/*+") {
+			parsed.hints = append(parsed.hints, comment)
+		} else {
+			parsed.prefix += comment
+		}
+		rest = rest[end:]
+	}
+
+	parsed.main = rest
+	parsed.firstToken = strings.ToUpper(getFirstToken(rest))
+	if parsed.firstToken == "WITH" {
+		// The main query starts with the first SELECT or FROM outside of the
+		// parenthesis of the CTEs, since none of the other words allowed at
+		// this level, e.g. RECURSIVE, AS or MATERIALIZED, can be confused with them:
+		start := findTopLevelKeyword(rest, "SELECT")
+		if idx := findTopLevelKeyword(rest, "FROM"); idx != -1 && (start == -1 || idx < start) {
+			start = idx
+		}
+		if start != -1 {
+			parsed.prefix += rest[:start]
+			parsed.main = rest[start:]
+			parsed.firstToken = strings.ToUpper(getFirstToken(parsed.main))
+		}
+	}
+
+	parsed.autoSelect = opts.autoSelect || (!opts.rawQuery && parsed.firstToken == "FROM")
+
+	return parsed
+}
+
+// withSelect rebuilds the query with the input SELECT part,
+// moving the optimizer hints to right after the SELECT keyword.
+func (p parsedQuery) withSelect(selectPrefix string) string {
+	if len(p.hints) > 0 {
+		selectPrefix = "SELECT " + strings.Join(p.hints, " ") + " " + strings.TrimPrefix(selectPrefix, "SELECT ")
+	}
+
+	return p.prefix + selectPrefix + p.main
+}
+
+// commentLen returns the length of the comment the input
+// starts with, or 0 if it doesn't start with a comment.
+func commentLen(s string) int {
+	switch {
+	case strings.HasPrefix(s, "--"):
+		end := strings.Index(s, "\n")
+		if end == -1 {
+			return len(s)
+		}
+		return end
+	case strings.HasPrefix(s, "/*"):
+		end := strings.Index(s[2:], "*/")
+		if end == -1 {
+			return len(s)
+		}
+		return end + 4
+	}
+
+	return 0
+}
@@ -0,0 +1,111 @@
+package ksql
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		desc               string
+		query              string
+		opts               queryOptions
+		expectedPrefix     string
+		expectedHints      []string
+		expectedMain       string
+		expectedFirstToken string
+		expectedAutoSelect bool
+	}{
+		{
+			desc:               "should return queries without prefixes unchanged",
+			query:              "from users",
+			expectedMain:       "from users",
+			expectedFirstToken: "FROM",
+			expectedAutoSelect: true,
+		},
+		{
+			desc:               "should not generate the SELECT part for queries starting with SELECT",
+			query:              "SELECT * FROM users",
+			expectedMain:       "SELECT * FROM users",
+			expectedFirstToken: "SELECT",
+		},
+		{
+			desc:               "should skip leading comments",
+			query:              "-- lists the users\n /* by name */ FROM users",
+			expectedPrefix:     "-- lists the users\n /* by name */ ",
+			expectedMain:       "FROM users",
+			expectedFirstToken: "FROM",
+			expectedAutoSelect: true,
+		},
+		{
+			desc:               "should separate the optimizer hints",
+			query:              "/* users */ /*+ MAX_EXECUTION_TIME(1000) */ FROM users",
+			expectedPrefix:     "/* users */  ",
+			expectedHints:      []string{"/*+ MAX_EXECUTION_TIME(1000) */"},
+			expectedMain:       "FROM users",
+			expectedFirstToken: "FROM",
+			expectedAutoSelect: true,
+		},
+		{
+			desc:               "should split the CTEs from a query with the SELECT part omitted",
+			query:              `WITH adults AS (SELECT * FROM users WHERE age >= 18), names (name) AS (SELECT name FROM adults) FROM names`,
+			expectedPrefix:     `WITH adults AS (SELECT * FROM users WHERE age >= 18), names (name) AS (SELECT name FROM adults) `,
+			expectedMain:       `FROM names`,
+			expectedFirstToken: "FROM",
+			expectedAutoSelect: true,
+		},
+		{
+			desc:               "should split the CTEs from a query with the SELECT part",
+			query:              "WITH RECURSIVE t AS MATERIALIZED (SELECT 1 AS n UNION ALL SELECT n+1 FROM t)\nselect n from t",
+			expectedPrefix:     "WITH RECURSIVE t AS MATERIALIZED (SELECT 1 AS n UNION ALL SELECT n+1 FROM t)\n",
+			expectedMain:       "select n from t",
+			expectedFirstToken: "SELECT",
+		},
+		{
+			desc:               "should ignore keywords inside quotes and comments",
+			query:              "WITH \"from\" AS (SELECT 'select') -- from\n/* select */ FROM \"from\"",
+			expectedPrefix:     "WITH \"from\" AS (SELECT 'select') -- from\n/* select */ ",
+			expectedMain:       `FROM "from"`,
+			expectedFirstToken: "FROM",
+			expectedAutoSelect: true,
+		},
+		{
+			desc:               "should keep invalid CTEs unchanged",
+			query:              `WITH adults AS (SELECT * FROM users)`,
+			expectedMain:       `WITH adults AS (SELECT * FROM users)`,
+			expectedFirstToken: "WITH",
+		},
+		{
+			desc:               "should not generate the SELECT part when using RawQuery",
+			query:              "FROM users",
+			opts:               queryOptions{rawQuery: true},
+			expectedMain:       "FROM users",
+			expectedFirstToken: "FROM",
+		},
+		{
+			desc:               "should always generate the SELECT part when using AutoSelect",
+			query:              "users",
+			opts:               queryOptions{autoSelect: true},
+			expectedMain:       "users",
+			expectedFirstToken: "USERS",
+			expectedAutoSelect: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			parsed := parseQuery(test.query, test.opts)
+			tt.AssertEqual(t, parsed.prefix, test.expectedPrefix)
+			tt.AssertEqual(t, parsed.hints, test.expectedHints)
+			tt.AssertEqual(t, parsed.main, test.expectedMain)
+			tt.AssertEqual(t, parsed.firstToken, test.expectedFirstToken)
+			tt.AssertEqual(t, parsed.autoSelect, test.expectedAutoSelect)
+		})
+	}
+
+	t.Run("should write the optimizer hints after the SELECT keyword", func(t *testing.T) {
+		parsed := parseQuery("/* users */ /*+ NO_INDEX(users) */ FROM users", queryOptions{})
+		tt.AssertEqual(t, parsed.withSelect("SELECT id, name "), "/* users */  SELECT /*+ NO_INDEX(users) */ id, name FROM users")
+	})
+}
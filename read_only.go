@@ -0,0 +1,96 @@
+package ksql
+
+import (
+	"context"
+)
+
+// ReadOnlyError is returned when a write operation is called
+// on the Provider returned by the DB.ReadOnly() method.
+type ReadOnlyError struct {
+	// Method is the name of the rejected method, e.g. "Insert"
+	Method string
+}
+
+func (e ReadOnlyError) Error() string {
+	return "ksql: " + e.Method + " is not allowed on a read-only Provider"
+}
+
+type readOnlyTxCtxKey struct{}
+
+// IsReadOnlyTx tells if the transaction being started with this
+// context should be read-only, i.e. if it was started by the
+// Provider returned by the DB.ReadOnly() method.
+//
+// It is meant to be used on the BeginTx method of the adapters.
+func IsReadOnlyTx(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyTxCtxKey{}).(bool)
+	return readOnly
+}
+
+// ReadOnly returns a Provider that runs the queries of this client but
+// whose Insert, Patch, Update, Delete and Exec methods always fail with
+// a ReadOnlyError, which is useful for code paths that must never write,
+// e.g. report generators or public APIs.
+//
+// The transactions of this Provider are also started as read-only, so
+// writes made through the Query methods, e.g. with `RETURNING` clauses,
+// are rejected by the database. Note that sqlite and sqlserver don't
+// support read-only transactions, so in these databases only the
+// write methods are rejected.
+func (c DB) ReadOnly() Provider {
+	return readOnlyProvider{db: c}
+}
+
+type readOnlyProvider struct {
+	db Provider
+}
+
+// Insert implements the Provider interface
+func (r readOnlyProvider) Insert(ctx context.Context, table Table, record interface{}) error {
+	return ReadOnlyError{Method: "Insert"}
+}
+
+// Patch implements the Provider interface
+func (r readOnlyProvider) Patch(ctx context.Context, table Table, record interface{}) error {
+	return ReadOnlyError{Method: "Patch"}
+}
+
+// Update implements the Provider interface
+//
+// Deprecated: use the Patch() method instead.
+func (r readOnlyProvider) Update(ctx context.Context, table Table, record interface{}) error {
+	return ReadOnlyError{Method: "Update"}
+}
+
+// Delete implements the Provider interface
+func (r readOnlyProvider) Delete(ctx context.Context, table Table, idOrRecord interface{}) error {
+	return ReadOnlyError{Method: "Delete"}
+}
+
+// Query implements the Provider interface
+func (r readOnlyProvider) Query(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+	return r.db.Query(ctx, records, query, params...)
+}
+
+// QueryOne implements the Provider interface
+func (r readOnlyProvider) QueryOne(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+	return r.db.QueryOne(ctx, record, query, params...)
+}
+
+// QueryChunks implements the Provider interface
+func (r readOnlyProvider) QueryChunks(ctx context.Context, parser ChunkParser) error {
+	return r.db.QueryChunks(ctx, parser)
+}
+
+// Exec implements the Provider interface
+func (r readOnlyProvider) Exec(ctx context.Context, query string, params ...interface{}) (Result, error) {
+	return nil, ReadOnlyError{Method: "Exec"}
+}
+
+// Transaction implements the Provider interface
+func (r readOnlyProvider) Transaction(ctx context.Context, fn func(Provider) error) error {
+	ctx = context.WithValue(ctx, readOnlyTxCtxKey{}, true)
+	return r.db.Transaction(ctx, func(tx Provider) error {
+		return fn(readOnlyProvider{db: tx})
+	})
+}
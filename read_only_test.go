@@ -0,0 +1,90 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestReadOnly(t *testing.T) {
+	t.Run("should reject the write methods", func(t *testing.T) {
+		var numQueries int
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				numQueries++
+				return NewMockResult(0, 1), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		ctx := context.Background()
+		readOnly := db.ReadOnly()
+		u := user{ID: 42, Name: "fake-name"}
+
+		tests := []struct {
+			method string
+			run    func() error
+		}{
+			{method: "Insert", run: func() error { return readOnly.Insert(ctx, usersTable, &u) }},
+			{method: "Patch", run: func() error { return readOnly.Patch(ctx, usersTable, u) }},
+			{method: "Update", run: func() error { return readOnly.Update(ctx, usersTable, u) }},
+			{method: "Delete", run: func() error { return readOnly.Delete(ctx, usersTable, 42) }},
+			{method: "Exec", run: func() error {
+				_, err := readOnly.Exec(ctx, "DELETE FROM users")
+				return err
+			}},
+		}
+
+		for _, test := range tests {
+			err := test.run()
+
+			var readOnlyErr ReadOnlyError
+			tt.AssertEqual(t, errors.As(err, &readOnlyErr), true)
+			tt.AssertEqual(t, readOnlyErr.Method, test.method)
+			tt.AssertErrContains(t, err, test.method, "read-only")
+		}
+		tt.AssertEqual(t, numQueries, 0)
+	})
+
+	t.Run("should run the queries", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newMockRows([]string{"id", "name", "age"}, []interface{}{42, "fake-name", 20}), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var u user
+		err = db.ReadOnly().QueryOne(context.Background(), &u, "FROM users WHERE id = $1", 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, uint(42))
+	})
+
+	t.Run("should start read-only transactions", func(t *testing.T) {
+		var readOnlyTx bool
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				readOnlyTx = IsReadOnlyTx(ctx)
+				return mockTx{}, nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var txErr error
+		err = db.ReadOnly().Transaction(context.Background(), func(tx Provider) error {
+			_, txErr = tx.Exec(context.Background(), "DELETE FROM users")
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, readOnlyTx, true)
+		tt.AssertEqual(t, errors.As(txErr, &ReadOnlyError{}), true)
+
+		err = db.Transaction(context.Background(), func(tx Provider) error {
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, readOnlyTx, false)
+	})
+}
@@ -0,0 +1,126 @@
+//go:build go1.18
+// +build go1.18
+
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Repo is a thin typed wrapper around the Provider methods
+// for working with the records of a single table, e.g.:
+//
+//	usersRepo := ksql.NewRepo[User](db, UsersTable)
+//
+//	user, err := usersRepo.Find(ctx, userID)
+//	if err != nil {
+//		return err
+//	}
+//
+//	admins, err := usersRepo.List(ctx, "type = $1", "admin")
+//
+// The type argument must be a struct using the `ksql` tags.
+type Repo[T any] struct {
+	db      Provider
+	dialect Dialect
+	table   Table
+}
+
+// NewRepo instantiates a new Repo for the input table
+func NewRepo[T any](db DB, table Table) Repo[T] {
+	return Repo[T]{
+		db:      db,
+		dialect: db.dialect,
+		table:   table,
+	}
+}
+
+// WithProvider returns a copy of the Repo that uses the input Provider for
+// running its operations, which is useful for using the repo inside transactions:
+//
+//	err := db.Transaction(ctx, func(tx ksql.Provider) error {
+//		user, err := usersRepo.WithProvider(tx).Find(ctx, userID)
+//		// ...
+//	})
+//
+// The Provider must be using the same dialect as the original one.
+func (r Repo[T]) WithProvider(db Provider) Repo[T] {
+	r.db = db
+	return r
+}
+
+// Find loads a single record using the ID columns of the table,
+// for tables with a single ID column the id can be passed directly,
+// for tables with composite keys pass a struct or a map
+// containing all the ID columns.
+//
+// Find returns ErrRecordNotFound if there is no record with this ID.
+func (r Repo[T]) Find(ctx context.Context, id interface{}) (record T, _ error) {
+	if err := r.table.validate(); err != nil {
+		return record, fmt.Errorf("can't find record on ksql.Table: %s", err)
+	}
+
+	idMap, err := normalizeIDsAsMap(r.table.idColumns, id)
+	if err != nil {
+		return record, err
+	}
+
+	whereQuery, params := buildWhereByIDs(r.dialect, r.table.idColumns, idMap)
+	err = r.db.QueryOne(ctx, &record,
+		"FROM "+r.table.escapedName(r.dialect)+" WHERE "+r.table.withFindFilter(r.dialect, whereQuery),
+		params...,
+	)
+	return record, err
+}
+
+// List loads all the records that match the input where condition,
+// the condition should not include the `WHERE` keyword and if it is
+// empty all the records of the table are returned.
+func (r Repo[T]) List(ctx context.Context, where string, params ...interface{}) ([]T, error) {
+	if err := r.table.validate(); err != nil {
+		return nil, fmt.Errorf("can't list records from ksql.Table: %s", err)
+	}
+
+	query := "FROM " + r.table.escapedName(r.dialect)
+	if strings.TrimSpace(where) != "" {
+		query += " WHERE " + r.table.withFindFilter(r.dialect, where)
+	} else if filter := r.table.filter(r.dialect); filter != "" {
+		query += " WHERE " + filter
+	}
+	if r.table.listOrderBy != "" {
+		query += " ORDER BY " + r.table.listOrderBy
+	}
+
+	var records []T
+	err := r.db.Query(ctx, &records, query, params...)
+	return records, err
+}
+
+// Insert inserts the record, updating its ID attributes
+// if they are generated by the database.
+func (r Repo[T]) Insert(ctx context.Context, record *T) error {
+	return r.db.Insert(ctx, r.table, record)
+}
+
+// Update updates the record on the database using the same
+// semantics of the Patch method, i.e. nil pointers are ignored.
+func (r Repo[T]) Update(ctx context.Context, record T) error {
+	return r.db.Patch(ctx, r.table, record)
+}
+
+// Delete deletes a record from the database, the input
+// accepts the same values accepted by the Find method
+// as well as the record itself.
+func (r Repo[T]) Delete(ctx context.Context, idOrRecord interface{}) error {
+	return r.db.Delete(ctx, r.table, idOrRecord)
+}
+
+// Upsert updates the record if it exists on the database or inserts it otherwise.
+//
+// It uses the native upsert of the Provider if it implements the
+// UpserterProvider interface, see `ksql.Upsert()` for more details.
+func (r Repo[T]) Upsert(ctx context.Context, record *T) error {
+	return Upsert(ctx, r.db, r.table, record)
+}
@@ -0,0 +1,134 @@
+//go:build go1.18
+// +build go1.18
+
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestRepo(t *testing.T) {
+	type repoUser struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	t.Run("Find should build the query using the ID columns", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query = q
+				params = args
+				return newMockRows([]string{"id", "name"}, []interface{}{42, "fake-name"}), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		repo := NewRepo[repoUser](db, usersTable)
+		u, err := repo.Find(context.Background(), 42)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, u, repoUser{ID: 42, Name: "fake-name"})
+		tt.AssertEqual(t, query, `SELECT "id", "name" FROM "users" WHERE "id" = $1`)
+		tt.AssertEqual(t, params, []interface{}{42})
+	})
+
+	t.Run("Find should work with composite keys", func(t *testing.T) {
+		var query string
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				query = q
+				return newMockRows([]string{"id"}), nil
+			},
+		}, "sqlite3")
+		tt.AssertNoErr(t, err)
+
+		repo := NewRepo[userPermission](db, userPermissionsTable)
+		_, err = repo.Find(context.Background(), map[string]interface{}{
+			"user_id": 1,
+			"perm_id": 2,
+		})
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+		tt.AssertEqual(t, query, "SELECT `id`, `user_id`, `perm_id` FROM `user_permissions` WHERE `user_id` = ? AND `perm_id` = ?")
+	})
+
+	t.Run("Find and List should apply the defaults of the table", func(t *testing.T) {
+		var queries []string
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				queries = append(queries, q)
+				return newMockRows([]string{"id", "name"}, []interface{}{42, "fake-name"}), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		table := usersTable.
+			WithSchema("app").
+			WithFindFilter("deleted_at IS NULL").
+			WithListOrderBy("name")
+
+		repo := NewRepo[repoUser](db, table)
+		_, err = repo.Find(context.Background(), 42)
+		tt.AssertNoErr(t, err)
+
+		_, err = repo.List(context.Background(), "")
+		tt.AssertNoErr(t, err)
+
+		_, err = repo.List(context.Background(), "name = $1 OR age > $2", "fake-name", 18)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, queries, []string{
+			`SELECT "id", "name" FROM "app"."users" WHERE ("id" = $1) AND (deleted_at IS NULL)`,
+			`SELECT "id", "name" FROM "app"."users" WHERE deleted_at IS NULL ORDER BY name`,
+			`SELECT "id", "name" FROM "app"."users" WHERE (name = $1 OR age > $2) AND (deleted_at IS NULL) ORDER BY name`,
+		})
+	})
+
+	t.Run("List should add the WHERE clause only if necessary", func(t *testing.T) {
+		var queries []string
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				queries = append(queries, q)
+				return newMockRows([]string{"id", "name"}, []interface{}{42, "fake-name"}), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		repo := NewRepo[repoUser](db, usersTable)
+		users, err := repo.List(context.Background(), "")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []repoUser{{ID: 42, Name: "fake-name"}})
+
+		_, err = repo.List(context.Background(), "name = $1", "fake-name")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, queries, []string{
+			`SELECT "id", "name" FROM "users"`,
+			`SELECT "id", "name" FROM "users" WHERE name = $1`,
+		})
+	})
+
+	t.Run("Upsert should use the native upsert of the dialect", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+				query = q
+				params = args
+				return NewMockResult(0, 1), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		repo := NewRepo[repoUser](db, usersTable)
+		err = repo.Upsert(context.Background(), &repoUser{ID: 42, Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, query, `INSERT INTO "users" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`)
+		tt.AssertEqual(t, params, []interface{}{42, "fake-name"})
+	})
+}
@@ -0,0 +1,40 @@
+package ksql
+
+import "sync"
+
+// maxPooledScanArgs is the capacity above which the scan
+// buffers are not returned to the pool, so a single query with
+// too many columns doesn't keep a large buffer alive forever.
+const maxPooledScanArgs = 256
+
+// scanArgsBuffer holds the arguments passed to rows.Scan for a single row,
+// which are only needed during that call and thus can be reused by
+// the next rows instead of being allocated again for each of them.
+type scanArgsBuffer struct {
+	args []interface{}
+}
+
+var scanArgsPool = sync.Pool{
+	New: func() interface{} {
+		return &scanArgsBuffer{}
+	},
+}
+
+func getScanArgsBuffer() *scanArgsBuffer {
+	return scanArgsPool.Get().(*scanArgsBuffer)
+}
+
+func putScanArgsBuffer(buf *scanArgsBuffer) {
+	if cap(buf.args) > maxPooledScanArgs {
+		return
+	}
+
+	// The references to the attributes of the scanned
+	// records are removed so they can be garbage collected:
+	for i := range buf.args {
+		buf.args[i] = nil
+	}
+	buf.args = buf.args[:0]
+
+	scanArgsPool.Put(buf)
+}
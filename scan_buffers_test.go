@@ -0,0 +1,68 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestScanArgsBuffers(t *testing.T) {
+	t.Run("should clear the buffers before returning them to the pool", func(t *testing.T) {
+		var u user
+		buf := &scanArgsBuffer{
+			args: append(make([]interface{}, 0, 4), &u.ID, &u.Name),
+		}
+
+		putScanArgsBuffer(buf)
+		tt.AssertEqual(t, len(buf.args), 0)
+		tt.AssertEqual(t, buf.args[:2], []interface{}{nil, nil})
+	})
+
+	t.Run("should reuse the buffers across rows with different columns", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				return newMockRows(
+					[]string{"id", "name", "age"},
+					[]interface{}{uint(1), "fake-name-1", 10},
+					[]interface{}{uint(2), "fake-name-2", 20},
+				), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var users []user
+		err = db.Query(context.Background(), &users, "SELECT id, name, age FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []user{
+			{ID: 1, Name: "fake-name-1", Age: 10},
+			{ID: 2, Name: "fake-name-2", Age: 20},
+		})
+
+		var names []struct {
+			Name string `ksql:"name"`
+		}
+		err = db.Query(context.Background(), &names, "SELECT id, name, age FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(names), 2)
+		tt.AssertEqual(t, names[1].Name, "fake-name-2")
+	})
+}
+
+func BenchmarkScanRows(b *testing.B) {
+	dialect := supportedDialects["postgres"]
+	columns := []string{"id", "name", "age"}
+	row := []interface{}{uint(1), "fake-name", 42}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rows := newMockRows(columns, row)
+		rows.Next()
+
+		var u user
+		err := scanRows(dialect, rows, &u, scanOptions{})
+		if err != nil {
+			b.Fatalf("unexpected error: %s", err)
+		}
+	}
+}
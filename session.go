@@ -0,0 +1,133 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// SessionVarsFn is used to extract the session variables that should
+// be set on the database connection from the context of each operation,
+// e.g. the ID of the user making the request.
+type SessionVarsFn func(ctx context.Context) (map[string]string, error)
+
+// WithSessionVars configures the client to set session variables at the
+// start of each transaction, which is useful for using Row Level Security
+// policies that depend on values such as the current user or tenant, e.g.:
+//
+//	db, err := kpgx.New(ctx, dbURL, ksql.Config{}, ksql.WithSessionVars(
+//		func(ctx context.Context) (map[string]string, error) {
+//			return map[string]string{
+//				"app.user_id": getUserIDFromCtx(ctx),
+//			}, nil
+//		},
+//	))
+//
+// When this option is set every operation executed outside of a transaction
+// will run on its own short transaction, so that the variables are only
+// visible to the connection running that operation.
+//
+// On Postgres the variables are set with `set_config(name, value, true)`
+// which is equivalent to `SET LOCAL`, so they are discarded when the transaction ends.
+//
+// On MySQL user variables (e.g. `@app.user_id`) are used and on SQLServer
+// `sp_set_session_context` is used, since these are session scoped KSQL will reset
+// them to NULL before the transaction ends so they don't leak to other operations
+// reusing the same connection.
+//
+// On Firebird the variables are set with `RDB$SET_CONTEXT` on the `USER_TRANSACTION`
+// namespace, so they are also discarded when the transaction ends.
+//
+// SQLite doesn't support session variables, so all transactions will
+// fail with an error if this option is used with it.
+func WithSessionVars(fn SessionVarsFn) Option {
+	return func(db *DB) {
+		db.sessionVarsFn = fn
+	}
+}
+
+// requiresSessionTx checks if the current operation needs to be executed
+// inside a transaction in order to set the session variables safely.
+func (c DB) requiresSessionTx() bool {
+	if c.sessionVarsFn == nil {
+		return false
+	}
+
+	_, isTx := c.db.(Tx)
+	return !isTx
+}
+
+func (c DB) setSessionVars(ctx context.Context) (names []string, _ error) {
+	if c.sessionVarsFn == nil {
+		return nil, nil
+	}
+
+	vars, err := c.sessionVarsFn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get session variables from context")
+	}
+
+	for name := range vars {
+		names = append(names, name)
+	}
+	// Sorting it so the queries are executed in a predictable order:
+	sort.Strings(names)
+
+	for i, name := range names {
+		query, params, err := buildSetSessionVarQuery(c.dialect, name, vars[name])
+		if err != nil {
+			return names[:i], err
+		}
+
+		_, err = c.db.ExecContext(ctx, query, params...)
+		if err != nil {
+			return names[:i], errors.Wrapf(err, "unable to set session variable '%s'", name)
+		}
+	}
+
+	return names, nil
+}
+
+// resetSessionVars sets the session variables back to NULL on the dialects
+// where the variables outlive the transaction.
+func (c DB) resetSessionVars(ctx context.Context, names []string) error {
+	if capabilitiesOf(c.dialect).SessionVars.transactionScoped() {
+		// The variables are discarded at the end of the transaction
+		return nil
+	}
+
+	for _, name := range names {
+		query, params, err := buildSetSessionVarQuery(c.dialect, name, nil)
+		if err != nil {
+			return err
+		}
+
+		_, err = c.db.ExecContext(ctx, query, params...)
+		if err != nil {
+			return errors.Wrapf(err, "unable to reset session variable '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// buildSetSessionVarQuery builds the query for setting a single session
+// variable, if value is nil the variable will be set to NULL.
+func buildSetSessionVarQuery(dialect Dialect, name string, value interface{}) (query string, params []interface{}, _ error) {
+	first, second := dialect.Placeholder(0), dialect.Placeholder(1)
+	switch capabilitiesOf(dialect).SessionVars {
+	case SessionVarsSetConfig:
+		return selectWithoutTable(dialect, "set_config("+first+", "+second+", true)"), []interface{}{name, value}, nil
+	case SessionVarsUserVariables:
+		// User variables don't accept placeholders for their names:
+		return "SET @" + dialect.Escape(name) + " = " + first, []interface{}{value}, nil
+	case SessionVarsSessionContext:
+		return "EXEC sp_set_session_context @key = " + first + ", @value = " + second, []interface{}{name, value}, nil
+	case SessionVarsRDBSetContext:
+		return selectWithoutTable(dialect, "RDB$SET_CONTEXT('USER_TRANSACTION', "+first+", "+second+")"), []interface{}{name, value}, nil
+	default:
+		return "", nil, fmt.Errorf("session variables are not supported by the %s dialect", dialect.DriverName())
+	}
+}
@@ -0,0 +1,209 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type ctxKey string
+
+func TestWithSessionVars(t *testing.T) {
+	sessionVarsFn := func(ctx context.Context) (map[string]string, error) {
+		userID, _ := ctx.Value(ctxKey("user_id")).(string)
+		if userID == "" {
+			return nil, fmt.Errorf("fake-missing-user-id")
+		}
+		return map[string]string{
+			"app.user_id": userID,
+			"app.role":    "admin",
+		}, nil
+	}
+
+	t.Run("should set the variables inside a transaction before each operation", func(t *testing.T) {
+		for _, test := range []struct {
+			dialect         string
+			expectedQueries []string
+			expectedParams  [][]interface{}
+		}{
+			{
+				dialect: "postgres",
+				expectedQueries: []string{
+					"SELECT set_config($1, $2, true)",
+					"SELECT set_config($1, $2, true)",
+					"fake-query",
+					"commit",
+				},
+				expectedParams: [][]interface{}{
+					{"app.role", "admin"},
+					{"app.user_id", "42"},
+					nil,
+					nil,
+				},
+			},
+			{
+				dialect: "mysql",
+				expectedQueries: []string{
+					"SET @`app.role` = ?",
+					"SET @`app.user_id` = ?",
+					"fake-query",
+					"SET @`app.role` = ?",
+					"SET @`app.user_id` = ?",
+					"commit",
+				},
+				expectedParams: [][]interface{}{
+					{"admin"},
+					{"42"},
+					nil,
+					{nil},
+					{nil},
+					nil,
+				},
+			},
+			{
+				dialect: "sqlserver",
+				expectedQueries: []string{
+					"EXEC sp_set_session_context @key = @p1, @value = @p2",
+					"EXEC sp_set_session_context @key = @p1, @value = @p2",
+					"fake-query",
+					"EXEC sp_set_session_context @key = @p1, @value = @p2",
+					"EXEC sp_set_session_context @key = @p1, @value = @p2",
+					"commit",
+				},
+				expectedParams: [][]interface{}{
+					{"app.role", "admin"},
+					{"app.user_id", "42"},
+					nil,
+					{"app.role", nil},
+					{"app.user_id", nil},
+					nil,
+				},
+			},
+		} {
+			t.Run(test.dialect, func(t *testing.T) {
+				var queries []string
+				var params [][]interface{}
+				execFn := func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					queries = append(queries, query)
+					params = append(params, args)
+					return NewMockResult(0, 1), nil
+				}
+
+				db, err := NewWithAdapter(mockTxBeginner{
+					BeginTxFn: func(ctx context.Context) (Tx, error) {
+						return mockTx{
+							mockDBAdapter: mockDBAdapter{ExecContextFn: execFn},
+							CommitFn: func(ctx context.Context) error {
+								queries = append(queries, "commit")
+								params = append(params, nil)
+								return nil
+							},
+						}, nil
+					},
+				}, test.dialect, WithSessionVars(sessionVarsFn))
+				tt.AssertNoErr(t, err)
+
+				ctx := context.WithValue(context.Background(), ctxKey("user_id"), "42")
+				_, err = db.Exec(ctx, "fake-query")
+				tt.AssertNoErr(t, err)
+
+				tt.AssertEqual(t, queries, test.expectedQueries)
+				tt.AssertEqual(t, params, test.expectedParams)
+			})
+		}
+	})
+
+	t.Run("should rollback and report error if the variables can't be extracted", func(t *testing.T) {
+		var rolledBack bool
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					RollbackFn: func(ctx context.Context) error {
+						rolledBack = true
+						return nil
+					},
+				}, nil
+			},
+		}, "postgres", WithSessionVars(sessionVarsFn))
+		tt.AssertNoErr(t, err)
+
+		err = db.Delete(context.Background(), usersTable, 42)
+		tt.AssertErrContains(t, err, "session variables", "fake-missing-user-id")
+		tt.AssertEqual(t, rolledBack, true)
+	})
+
+	t.Run("should reset the variables that were set if the transaction fails on mysql", func(t *testing.T) {
+		newDB := func(queries *[]string, failOn string) DB {
+			db, err := NewWithAdapter(mockTxBeginner{
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					return mockTx{
+						mockDBAdapter: mockDBAdapter{
+							ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+								*queries = append(*queries, fmt.Sprint(query, args))
+								if query == failOn && args[0] != nil {
+									return nil, fmt.Errorf("fake-exec-error")
+								}
+								return NewMockResult(0, 1), nil
+							},
+						},
+						RollbackFn: func(ctx context.Context) error {
+							*queries = append(*queries, "rollback")
+							return nil
+						},
+					}, nil
+				},
+			}, "mysql", WithSessionVars(sessionVarsFn))
+			tt.AssertNoErr(t, err)
+			return db
+		}
+		ctx := context.WithValue(context.Background(), ctxKey("user_id"), "42")
+
+		t.Run("when setting a variable fails", func(t *testing.T) {
+			var queries []string
+			db := newDB(&queries, "SET @`app.user_id` = ?")
+
+			_, err := db.Exec(ctx, "fake-query")
+			tt.AssertErrContains(t, err, "app.user_id", "fake-exec-error")
+			tt.AssertEqual(t, queries, []string{
+				"SET @`app.role` = ?[admin]",
+				"SET @`app.user_id` = ?[42]",
+				"SET @`app.role` = ?[<nil>]",
+				"rollback",
+			})
+		})
+
+		t.Run("when the callback panics", func(t *testing.T) {
+			var queries []string
+			db := newDB(&queries, "")
+
+			panicPayload := tt.PanicHandler(func() {
+				_ = db.Transaction(ctx, func(db Provider) error {
+					panic("fake-panic")
+				})
+			})
+			tt.AssertEqual(t, panicPayload, "fake-panic")
+			tt.AssertEqual(t, queries, []string{
+				"SET @`app.role` = ?[admin]",
+				"SET @`app.user_id` = ?[42]",
+				"SET @`app.role` = ?[<nil>]",
+				"SET @`app.user_id` = ?[<nil>]",
+				"rollback",
+			})
+		})
+	})
+
+	t.Run("should report error for dialects without support to session variables", func(t *testing.T) {
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{}, nil
+			},
+		}, "sqlite3", WithSessionVars(sessionVarsFn))
+		tt.AssertNoErr(t, err)
+
+		ctx := context.WithValue(context.Background(), ctxKey("user_id"), "42")
+		_, err = db.Exec(ctx, "fake-query")
+		tt.AssertErrContains(t, err, "session variables are not supported", "sqlite3")
+	})
+}
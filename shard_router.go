@@ -0,0 +1,216 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// ErrCrossShardTransaction is returned by the ShardRouter when an operation
+// inside a transaction resolves to a different shard than the transaction.
+var ErrCrossShardTransaction error = fmt.Errorf("ksql: a transaction can't span multiple shards")
+
+// ShardRouter is a Provider that routes each operation to one of
+// several Providers according to a key extracted from the context,
+// e.g. the tenant or the region of the request:
+//
+//	router, err := ksql.NewShardRouter(func(ctx context.Context) string {
+//		return ctx.Value(regionKey{}).(string)
+//	}, map[string]ksql.Provider{
+//		"eu": euDB,
+//		"us": usDB,
+//	})
+//
+// Transactions are started on the shard resolved from the context passed
+// to the Transaction method, and all operations inside the transaction
+// must resolve to this same shard, otherwise they fail with
+// ErrCrossShardTransaction.
+type ShardRouter struct {
+	resolver func(ctx context.Context) string
+	shards   map[string]Provider
+
+	// These are only set on the copies of the router
+	// passed to the Transaction callbacks:
+	txShard string
+	tx      Provider
+}
+
+var (
+	_ Provider         = ShardRouter{}
+	_ BatchProvider    = ShardRouter{}
+	_ UpserterProvider = ShardRouter{}
+	_ CopyProvider     = ShardRouter{}
+)
+
+// NewShardRouter instantiates a new ShardRouter, the resolver must
+// return one of the keys of the shards map for every context
+// used with the router.
+func NewShardRouter(resolver func(ctx context.Context) string, shards map[string]Provider) (ShardRouter, error) {
+	if resolver == nil {
+		return ShardRouter{}, fmt.Errorf("ksql: the shard resolver function is required")
+	}
+
+	if len(shards) == 0 {
+		return ShardRouter{}, fmt.Errorf("ksql: at least one shard is required")
+	}
+
+	// Copying the map so it can't be changed after validation:
+	shardsCopy := make(map[string]Provider, len(shards))
+	for key, shard := range shards {
+		if shard == nil {
+			return ShardRouter{}, fmt.Errorf("ksql: the Provider for shard %q is nil", key)
+		}
+		shardsCopy[key] = shard
+	}
+
+	return ShardRouter{
+		resolver: resolver,
+		shards:   shardsCopy,
+	}, nil
+}
+
+// Insert implements the Provider interface
+func (r ShardRouter) Insert(ctx context.Context, table Table, record interface{}) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return db.Insert(ctx, table, record)
+}
+
+// Patch implements the Provider interface
+func (r ShardRouter) Patch(ctx context.Context, table Table, record interface{}) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return db.Patch(ctx, table, record)
+}
+
+// Update implements the Provider interface
+//
+// Deprecated: use the Patch() method instead.
+func (r ShardRouter) Update(ctx context.Context, table Table, record interface{}) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return db.Update(ctx, table, record)
+}
+
+// Delete implements the Provider interface
+func (r ShardRouter) Delete(ctx context.Context, table Table, idOrRecord interface{}) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return db.Delete(ctx, table, idOrRecord)
+}
+
+// Query implements the Provider interface
+func (r ShardRouter) Query(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return db.Query(ctx, records, query, params...)
+}
+
+// QueryOne implements the Provider interface
+func (r ShardRouter) QueryOne(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return db.QueryOne(ctx, record, query, params...)
+}
+
+// QueryChunks implements the Provider interface
+func (r ShardRouter) QueryChunks(ctx context.Context, parser ChunkParser) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return db.QueryChunks(ctx, parser)
+}
+
+// Exec implements the Provider interface
+func (r ShardRouter) Exec(ctx context.Context, query string, params ...interface{}) (Result, error) {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return db.Exec(ctx, query, params...)
+}
+
+// Transaction implements the Provider interface
+func (r ShardRouter) Transaction(ctx context.Context, fn func(Provider) error) error {
+	db, shard, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(ctx, func(tx Provider) error {
+		txRouter := r
+		txRouter.txShard = shard
+		txRouter.tx = tx
+		return fn(txRouter)
+	})
+}
+
+// InsertMany implements the BatchProvider interface
+func (r ShardRouter) InsertMany(ctx context.Context, table Table, records interface{}, opts ...QueryOption) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return InsertMany(ctx, db, table, records, opts...)
+}
+
+// UpdateMany implements the BatchProvider interface
+func (r ShardRouter) UpdateMany(ctx context.Context, table Table, records interface{}, opts ...QueryOption) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return UpdateMany(ctx, db, table, records, opts...)
+}
+
+// Upsert implements the UpserterProvider interface
+func (r ShardRouter) Upsert(ctx context.Context, table Table, record interface{}, opts ...QueryOption) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return Upsert(ctx, db, table, record, opts...)
+}
+
+// InsertCSV implements the CopyProvider interface
+func (r ShardRouter) InsertCSV(ctx context.Context, table Table, reader io.Reader, opts CSVOptions) error {
+	db, _, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return InsertCSV(ctx, db, table, reader, opts)
+}
+
+func (r ShardRouter) route(ctx context.Context) (db Provider, shard string, _ error) {
+	shard = r.resolver(ctx)
+
+	if r.tx != nil {
+		if shard != r.txShard {
+			return nil, "", fmt.Errorf(
+				"%w: the transaction is running on shard %q but the operation resolved to shard %q",
+				ErrCrossShardTransaction, r.txShard, shard,
+			)
+		}
+		return r.tx, shard, nil
+	}
+
+	db, found := r.shards[shard]
+	if !found {
+		return nil, "", fmt.Errorf("ksql: no shard configured for the key %q", shard)
+	}
+
+	return db, shard, nil
+}
@@ -0,0 +1,116 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type shardKey struct{}
+
+func TestShardRouter(t *testing.T) {
+	resolver := func(ctx context.Context) string {
+		shard, _ := ctx.Value(shardKey{}).(string)
+		return shard
+	}
+
+	newShard := func(name string, calls *[]string) Mock {
+		return Mock{
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				*calls = append(*calls, name+": "+query)
+				return NewMockResult(0, 1), nil
+			},
+			TransactionFn: func(ctx context.Context, fn func(db Provider) error) error {
+				*calls = append(*calls, name+": transaction")
+				return fn(Mock{
+					ExecFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+						*calls = append(*calls, name+" tx: "+query)
+						return NewMockResult(0, 1), nil
+					},
+				})
+			},
+		}
+	}
+
+	euCtx := context.WithValue(context.Background(), shardKey{}, "eu")
+	usCtx := context.WithValue(context.Background(), shardKey{}, "us")
+
+	t.Run("should route each operation to the shard resolved from the context", func(t *testing.T) {
+		var calls []string
+		router, err := NewShardRouter(resolver, map[string]Provider{
+			"eu": newShard("eu", &calls),
+			"us": newShard("us", &calls),
+		})
+		tt.AssertNoErr(t, err)
+
+		_, err = router.Exec(euCtx, "DELETE FROM users")
+		tt.AssertNoErr(t, err)
+		_, err = router.Exec(usCtx, "DELETE FROM posts")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, calls, []string{
+			"eu: DELETE FROM users",
+			"us: DELETE FROM posts",
+		})
+	})
+
+	t.Run("should run transactions on the resolved shard", func(t *testing.T) {
+		var calls []string
+		router, err := NewShardRouter(resolver, map[string]Provider{
+			"eu": newShard("eu", &calls),
+			"us": newShard("us", &calls),
+		})
+		tt.AssertNoErr(t, err)
+
+		err = router.Transaction(usCtx, func(db Provider) error {
+			_, err := db.Exec(usCtx, "DELETE FROM users")
+			return err
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, calls, []string{
+			"us: transaction",
+			"us tx: DELETE FROM users",
+		})
+	})
+
+	t.Run("should report cross-shard transactions", func(t *testing.T) {
+		var calls []string
+		router, err := NewShardRouter(resolver, map[string]Provider{
+			"eu": newShard("eu", &calls),
+			"us": newShard("us", &calls),
+		})
+		tt.AssertNoErr(t, err)
+
+		err = router.Transaction(usCtx, func(db Provider) error {
+			_, err := db.Exec(euCtx, "DELETE FROM users")
+			return err
+		})
+		tt.AssertEqual(t, errors.Is(err, ErrCrossShardTransaction), true)
+		tt.AssertErrContains(t, err, `"us"`, `"eu"`)
+		tt.AssertEqual(t, calls, []string{"us: transaction"})
+	})
+
+	t.Run("should report unknown shards", func(t *testing.T) {
+		router, err := NewShardRouter(resolver, map[string]Provider{
+			"eu": Mock{},
+		})
+		tt.AssertNoErr(t, err)
+
+		_, err = router.Exec(usCtx, "DELETE FROM users")
+		tt.AssertErrContains(t, err, "no shard", `"us"`)
+	})
+
+	t.Run("should validate its arguments", func(t *testing.T) {
+		_, err := NewShardRouter(nil, map[string]Provider{"eu": Mock{}})
+		tt.AssertErrContains(t, err, "resolver")
+
+		_, err = NewShardRouter(resolver, nil)
+		tt.AssertErrContains(t, err, "at least one shard")
+
+		_, err = NewShardRouter(resolver, map[string]Provider{"eu": nil})
+		tt.AssertErrContains(t, err, `"eu"`, "nil")
+	})
+}
@@ -0,0 +1,145 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WithSoftDelete returns a copy of the Table whose records are soft deleted,
+// i.e. the Delete method sets the input column, which must be a nullable
+// timestamp, to the current time instead of removing the row:
+//
+//	var UsersTable = ksql.NewTable("users").WithSoftDelete("deleted_at")
+//
+// The soft deleted records are skipped by the helpers that apply the default
+// filter of the table, i.e. Find, QueryByKey and the Find and List methods of
+// ksql.Repo, unless the WithDeleted option is used. They can be brought back
+// with the Restore method or removed for good with the Purge method.
+//
+// The current time is read from the clock set by the WithClock option,
+// and DeleteAndReturn returns ErrNotSupported for these tables.
+func (t Table) WithSoftDelete(column string) Table {
+	t.softDeleteColumn = column
+	return t
+}
+
+// WithDeleted makes the Find and QueryByKey methods also
+// load the records soft deleted on tables using WithSoftDelete.
+func WithDeleted() QueryOption {
+	return func(opts *queryOptions) {
+		opts.withDeleted = true
+	}
+}
+
+// Restore undoes the soft delete of the record identified by the input ID,
+// record, map or ksql.Key on a table using WithSoftDelete, setting its soft
+// delete column back to NULL.
+//
+// It returns ErrRecordNotFound if there is no soft deleted record with this ID.
+func (c DB) Restore(ctx context.Context, table Table, idOrRecord interface{}, opts ...QueryOption) error {
+	if err := checkUpdateByID(c.dialect, "Restore"); err != nil {
+		return err
+	}
+
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).Restore(ctx, table, idOrRecord, opts...)
+		})
+	}
+
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't restore on ksql.Table: %s", err)
+	}
+
+	if table.softDeleteColumn == "" {
+		return fmt.Errorf("ksql: can't restore records of table %s since it doesn't use WithSoftDelete", table.name)
+	}
+
+	idMap, err := normalizeIDsAsMap(table.idColumns, idOrRecord)
+	if err != nil {
+		return err
+	}
+
+	o := newQueryOptions(opts)
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
+	query, params := buildRestoreQuery(c.dialect, table, idMap)
+	query, err = addStatementHints(c.dialect, table, query, o)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.execContext(ctx, OpInfo{Method: "Restore", TableName: table.name}, o, query, params...)
+	if err == errDryRun {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to check if the record was succesfully restored: %s", err)
+	}
+
+	return o.checkRowsAffected(n)
+}
+
+// Purge deletes the record identified by the input ID, record, map or
+// ksql.Key from the database even if the table uses WithSoftDelete,
+// which is useful for erasing data on request or for cleanup jobs.
+//
+// Just like Delete it returns ErrRecordNotFound if no row was deleted.
+func (c DB) Purge(ctx context.Context, table Table, idOrRecord interface{}, opts ...QueryOption) error {
+	table.softDeleteColumn = ""
+	return c.delete(ctx, table, idOrRecord, opts)
+}
+
+// buildSoftDeleteQuery builds the UPDATE statement used by Delete
+// on tables using WithSoftDelete, the records already deleted are
+// not matched so their deletion time is preserved.
+func buildSoftDeleteQuery(
+	dialect Dialect,
+	table Table,
+	idMap map[string]interface{},
+	now time.Time,
+) (query string, params []interface{}) {
+	column := dialect.Escape(table.softDeleteColumn)
+
+	// The time is the first param since it comes first on the query:
+	params = []interface{}{now}
+	conditions := make([]string, len(table.idColumns))
+	for i, idName := range table.idColumns {
+		conditions[i] = dialect.Escape(idName) + " = " + dialect.Placeholder(i+1)
+		params = append(params, idMap[idName])
+	}
+
+	return fmt.Sprintf(
+		"UPDATE %s SET %s = %s WHERE %s AND %s IS NULL",
+		table.escapedName(dialect),
+		column,
+		dialect.Placeholder(0),
+		strings.Join(conditions, " AND "),
+		column,
+	), params
+}
+
+func buildRestoreQuery(
+	dialect Dialect,
+	table Table,
+	idMap map[string]interface{},
+) (query string, params []interface{}) {
+	whereQuery, params := buildWhereByIDs(dialect, table.idColumns, idMap)
+	column := dialect.Escape(table.softDeleteColumn)
+
+	return fmt.Sprintf(
+		"UPDATE %s SET %s = NULL WHERE %s AND %s IS NOT NULL",
+		table.escapedName(dialect),
+		column,
+		whereQuery,
+		column,
+	), params
+}
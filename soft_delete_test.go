@@ -0,0 +1,137 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	softUsersTable := NewTable("users").WithSoftDelete("deleted_at")
+
+	clock := WithClock(fakeClock{now: now})
+	newAdapter := func(queries *[]string, params *[]interface{}, rowsAffected int64) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+				*queries = append(*queries, q)
+				*params = args
+				return NewMockResult(0, rowsAffected), nil
+			},
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				*queries = append(*queries, q)
+				*params = args
+				return newMockRows([]string{"id", "name", "age"}, []interface{}{42, "Bia", 22}), nil
+			},
+		}
+	}
+
+	t.Run("Delete should set the soft delete column", func(t *testing.T) {
+		tests := []struct {
+			dialect       string
+			expectedQuery string
+		}{
+			{
+				dialect:       "postgres",
+				expectedQuery: `UPDATE "users" SET "deleted_at" = $1 WHERE "id" = $2 AND "deleted_at" IS NULL`,
+			},
+			{
+				dialect:       "sqlite3",
+				expectedQuery: "UPDATE `users` SET `deleted_at` = ? WHERE `id` = ? AND `deleted_at` IS NULL",
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.dialect, func(t *testing.T) {
+				var queries []string
+				var params []interface{}
+				db := newDB(t, test.dialect, newAdapter(&queries, &params, 1), clock)
+
+				err := db.Delete(ctx, softUsersTable, 42)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, queries, []string{test.expectedQuery})
+				tt.AssertEqual(t, params, []interface{}{now, 42})
+			})
+		}
+	})
+
+	t.Run("Delete should return ErrRecordNotFound if the record was already deleted", func(t *testing.T) {
+		var queries []string
+		var params []interface{}
+		db := newDB(t, "postgres", newAdapter(&queries, &params, 0), clock)
+
+		err := db.Delete(ctx, softUsersTable, 42)
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+	})
+
+	t.Run("Find should skip the soft deleted records unless WithDeleted is used", func(t *testing.T) {
+		var queries []string
+		var params []interface{}
+		db := newDB(t, "postgres", newAdapter(&queries, &params, 1), clock)
+
+		var u deletedUser
+		err := db.Find(ctx, softUsersTable, &u, 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u, deletedUser{ID: 42, Name: "Bia", Age: 22})
+
+		err = db.Find(ctx, softUsersTable, &u, 42, WithDeleted())
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, queries, []string{
+			`SELECT "id", "name", "age" FROM "users" WHERE ("id" = $1) AND ("deleted_at" IS NULL)`,
+			`SELECT "id", "name", "age" FROM "users" WHERE "id" = $1`,
+		})
+	})
+
+	t.Run("Restore should clear the soft delete column", func(t *testing.T) {
+		var queries []string
+		var params []interface{}
+		db := newDB(t, "postgres", newAdapter(&queries, &params, 1), clock)
+
+		err := db.Restore(ctx, softUsersTable, &deletedUser{ID: 42})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{
+			`UPDATE "users" SET "deleted_at" = NULL WHERE "id" = $1 AND "deleted_at" IS NOT NULL`,
+		})
+		tt.AssertEqual(t, params, []interface{}{uint(42)})
+
+		db = newDB(t, "postgres", newAdapter(&queries, &params, 0), clock)
+		err = db.Restore(ctx, softUsersTable, 42)
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+	})
+
+	t.Run("Restore should report tables without soft deletes", func(t *testing.T) {
+		var queries []string
+		var params []interface{}
+		db := newDB(t, "postgres", newAdapter(&queries, &params, 1), clock)
+
+		err := db.Restore(ctx, usersTable, 42)
+		tt.AssertErrContains(t, err, "users", "WithSoftDelete")
+		tt.AssertEqual(t, len(queries), 0)
+	})
+
+	t.Run("Purge should delete the row", func(t *testing.T) {
+		var queries []string
+		var params []interface{}
+		db := newDB(t, "postgres", newAdapter(&queries, &params, 1), clock)
+
+		err := db.Purge(ctx, softUsersTable, 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{`DELETE FROM "users" WHERE "id" = $1`})
+		tt.AssertEqual(t, params, []interface{}{42})
+	})
+
+	t.Run("DeleteAndReturn should not be supported", func(t *testing.T) {
+		var queries []string
+		var params []interface{}
+		db := newDB(t, "postgres", newAdapter(&queries, &params, 1), clock)
+
+		var u deletedUser
+		err := db.DeleteAndReturn(ctx, softUsersTable, 42, &u)
+		tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+	})
+}
@@ -0,0 +1,429 @@
+package ksql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPrimaryUnavailable is returned by the ReadWriteSplitter for the
+// write operations sent while the primary is considered unreachable,
+// which only happens if the `SplitterConfig.DegradeOnPrimaryOutage`
+// option is enabled.
+var ErrPrimaryUnavailable error = fmt.Errorf("ksql: the primary database is unavailable")
+
+// ReadWriteSplitter is a Provider that sends the Query, QueryOne and
+// QueryChunks operations to a set of read replicas, in round-robin,
+// and all the other operations to the primary database, e.g.:
+//
+//	splitter, err := ksql.NewReadWriteSplitter(primaryDB, []ksql.Provider{replica1, replica2}, ksql.SplitterConfig{
+//		StickyWindow: 5 * time.Second,
+//	})
+//
+// Since replicas usually lag behind the primary, reads can be forced
+// to run on the primary in two ways:
+//
+//   - Per query, by passing the `ksql.FromPrimary()` option;
+//   - Per context, by using a context returned from `ksql.WithReadYourWrites()`:
+//     after any write made with this context, its reads are sent to the
+//     primary for the duration of the StickyWindow.
+//
+// Operations inside transactions always run on the primary.
+//
+// If the DegradeOnPrimaryOutage option is enabled the splitter keeps
+// serving reads from the replicas while the primary is unreachable,
+// see the SplitterConfig for details.
+type ReadWriteSplitter struct {
+	primary  Provider
+	replicas []Provider
+	config   SplitterConfig
+
+	next         *uint64
+	primaryState *primaryState
+
+	// This is only set on the copies of the splitter
+	// passed to the Transaction callbacks:
+	tx Provider
+}
+
+// SplitterConfig describes the optional configurations of the ReadWriteSplitter
+type SplitterConfig struct {
+	// StickyWindow is how long the reads made with a context returned by
+	// `ksql.WithReadYourWrites()` are sent to the primary after a write,
+	// if unset this stickiness is disabled.
+	StickyWindow time.Duration
+
+	// DegradeOnPrimaryOutage enables the degraded mode: after an operation
+	// fails on the primary with an error classified by IsPrimaryOutage the
+	// writes fail fast with ErrPrimaryUnavailable and the reads that would
+	// be sent to the primary, e.g. the ones using FromPrimary(), are sent
+	// to the replicas instead. After the PrimaryRetryInterval the next write
+	// is sent to the primary again, and the degraded mode ends on the first
+	// operation that reaches it.
+	DegradeOnPrimaryOutage bool
+
+	// PrimaryRetryInterval is how long the writes fail fast after
+	// the primary becomes unreachable, it defaults to 5 seconds.
+	PrimaryRetryInterval time.Duration
+
+	// IsPrimaryOutage tells if an error returned by the primary means it is
+	// unreachable, by default driver.ErrBadConn and the network errors are
+	// considered outages, note that the errors of canceled contexts are
+	// never passed to this function.
+	IsPrimaryOutage func(err error) bool
+
+	// OnPrimaryStateChange is called when the primary becomes unreachable,
+	// receiving the error that caused it, and when it becomes available
+	// again, e.g. for logging or updating metrics and alerts.
+	OnPrimaryStateChange func(available bool, err error)
+}
+
+var (
+	_ Provider         = ReadWriteSplitter{}
+	_ BatchProvider    = ReadWriteSplitter{}
+	_ UpserterProvider = ReadWriteSplitter{}
+	_ CopyProvider     = ReadWriteSplitter{}
+	_ HealthChecker    = ReadWriteSplitter{}
+)
+
+// NewReadWriteSplitter instantiates a new ReadWriteSplitter, if no
+// replicas are passed all operations are sent to the primary.
+func NewReadWriteSplitter(primary Provider, replicas []Provider, config SplitterConfig) (ReadWriteSplitter, error) {
+	if primary == nil {
+		return ReadWriteSplitter{}, fmt.Errorf("ksql: the primary Provider is required")
+	}
+
+	for i, replica := range replicas {
+		if replica == nil {
+			return ReadWriteSplitter{}, fmt.Errorf("ksql: the Provider for replica %d is nil", i)
+		}
+	}
+
+	if config.PrimaryRetryInterval == 0 {
+		config.PrimaryRetryInterval = 5 * time.Second
+	}
+
+	if config.IsPrimaryOutage == nil {
+		config.IsPrimaryOutage = isConnectionError
+	}
+
+	return ReadWriteSplitter{
+		primary:      primary,
+		replicas:     append([]Provider(nil), replicas...),
+		config:       config,
+		next:         new(uint64),
+		primaryState: &primaryState{},
+	}, nil
+}
+
+// primaryState tracks the outages of the primary for the degraded mode
+type primaryState struct {
+	mu       sync.Mutex
+	err      error
+	failedAt time.Time
+}
+
+// isConnectionError is the default classifier of the primary outages
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr)
+}
+
+// PrimaryAvailable returns false while the splitter is in degraded mode
+// because the primary is unreachable, it always returns true if the
+// DegradeOnPrimaryOutage option is disabled.
+func (s ReadWriteSplitter) PrimaryAvailable() bool {
+	s.primaryState.mu.Lock()
+	defer s.primaryState.mu.Unlock()
+	return s.primaryState.err == nil
+}
+
+// HealthCheck checks the primary and each of the replicas, see
+// `DB.HealthCheck()`, the top level attributes of the report describe
+// the primary and the report of each replica is on the Replicas slice.
+//
+// The splitter is only considered healthy if the primary is healthy and,
+// when there are replicas, at least one of them is healthy too, so a
+// single failing replica doesn't fail the readiness checks.
+//
+// Providers that don't implement the HealthChecker interface are
+// reported as not healthy.
+func (s ReadWriteSplitter) HealthCheck(ctx context.Context) HealthReport {
+	report := providerHealthCheck(ctx, s.primary)
+	if len(s.replicas) == 0 {
+		return report
+	}
+
+	anyReplicaHealthy := false
+	report.Replicas = make([]HealthReport, len(s.replicas))
+	for i, replica := range s.replicas {
+		report.Replicas[i] = providerHealthCheck(ctx, replica)
+		if report.Replicas[i].Healthy {
+			anyReplicaHealthy = true
+		}
+	}
+
+	if report.Healthy && !anyReplicaHealthy {
+		report.Healthy = false
+		report.Error = "ksql: none of the replicas is healthy"
+	}
+
+	return report
+}
+
+type writeSessionKey struct{}
+
+// writeSession tracks the last write made with a context
+// so its reads can be sent to the primary for a while.
+type writeSession struct {
+	mu        sync.Mutex
+	lastWrite time.Time
+}
+
+// WithReadYourWrites returns a context that makes the ReadWriteSplitter
+// send reads to the primary for a while after each write made with it,
+// as configured by the `SplitterConfig.StickyWindow` attribute.
+//
+// It is meant to be called once per request, e.g. on a middleware, so
+// all reads and writes of a request share the same session.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writeSessionKey{}, &writeSession{})
+}
+
+// Insert implements the Provider interface
+func (s ReadWriteSplitter) Insert(ctx context.Context, table Table, record interface{}) error {
+	db, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return s.observe(ctx, db.Insert(ctx, table, record))
+}
+
+// Patch implements the Provider interface
+func (s ReadWriteSplitter) Patch(ctx context.Context, table Table, record interface{}) error {
+	db, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return s.observe(ctx, db.Patch(ctx, table, record))
+}
+
+// Update implements the Provider interface
+//
+// Deprecated: use the Patch() method instead.
+func (s ReadWriteSplitter) Update(ctx context.Context, table Table, record interface{}) error {
+	db, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return s.observe(ctx, db.Update(ctx, table, record))
+}
+
+// Delete implements the Provider interface
+func (s ReadWriteSplitter) Delete(ctx context.Context, table Table, idOrRecord interface{}) error {
+	db, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return s.observe(ctx, db.Delete(ctx, table, idOrRecord))
+}
+
+// Query implements the Provider interface
+func (s ReadWriteSplitter) Query(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+	db, onPrimary := s.reader(ctx, params)
+	err := db.Query(ctx, records, query, params...)
+	if onPrimary {
+		err = s.observe(ctx, err)
+	}
+	return err
+}
+
+// QueryOne implements the Provider interface
+func (s ReadWriteSplitter) QueryOne(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+	db, onPrimary := s.reader(ctx, params)
+	err := db.QueryOne(ctx, record, query, params...)
+	if onPrimary {
+		err = s.observe(ctx, err)
+	}
+	return err
+}
+
+// QueryChunks implements the Provider interface
+func (s ReadWriteSplitter) QueryChunks(ctx context.Context, parser ChunkParser) error {
+	db, onPrimary := s.reader(ctx, parser.Params)
+	err := db.QueryChunks(ctx, parser)
+	if onPrimary {
+		err = s.observe(ctx, err)
+	}
+	return err
+}
+
+// Exec implements the Provider interface
+//
+// Exec always runs on the primary since the statement might change the database.
+func (s ReadWriteSplitter) Exec(ctx context.Context, query string, params ...interface{}) (Result, error) {
+	db, err := s.writer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := db.Exec(ctx, query, params...)
+	return result, s.observe(ctx, err)
+}
+
+// Transaction implements the Provider interface
+func (s ReadWriteSplitter) Transaction(ctx context.Context, fn func(Provider) error) error {
+	db, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return s.observe(ctx, db.Transaction(ctx, func(tx Provider) error {
+		txSplitter := s
+		txSplitter.tx = tx
+		return fn(txSplitter)
+	}))
+}
+
+// InsertMany implements the BatchProvider interface
+func (s ReadWriteSplitter) InsertMany(ctx context.Context, table Table, records interface{}, opts ...QueryOption) error {
+	db, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return s.observe(ctx, InsertMany(ctx, db, table, records, opts...))
+}
+
+// UpdateMany implements the BatchProvider interface
+func (s ReadWriteSplitter) UpdateMany(ctx context.Context, table Table, records interface{}, opts ...QueryOption) error {
+	db, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return s.observe(ctx, UpdateMany(ctx, db, table, records, opts...))
+}
+
+// Upsert implements the UpserterProvider interface
+func (s ReadWriteSplitter) Upsert(ctx context.Context, table Table, record interface{}, opts ...QueryOption) error {
+	db, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return s.observe(ctx, Upsert(ctx, db, table, record, opts...))
+}
+
+// InsertCSV implements the CopyProvider interface
+func (s ReadWriteSplitter) InsertCSV(ctx context.Context, table Table, r io.Reader, opts CSVOptions) error {
+	db, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return s.observe(ctx, InsertCSV(ctx, db, table, r, opts))
+}
+
+// writer returns the Provider for write operations and
+// records the write on the session of the context, if any.
+func (s ReadWriteSplitter) writer(ctx context.Context) (Provider, error) {
+	db := s.tx
+	if db == nil {
+		// The writes rejected during outages don't make the reads sticky:
+		if err := s.primaryOutage(); err != nil {
+			return nil, err
+		}
+		db = s.primary
+	}
+
+	if session, ok := ctx.Value(writeSessionKey{}).(*writeSession); ok {
+		session.mu.Lock()
+		session.lastWrite = time.Now()
+		session.mu.Unlock()
+	}
+
+	return db, nil
+}
+
+// reader returns the Provider for read operations and
+// whether it is the primary, outside of transactions.
+func (s ReadWriteSplitter) reader(ctx context.Context, params []interface{}) (_ Provider, onPrimary bool) {
+	if s.tx != nil {
+		return s.tx, false
+	}
+
+	if len(s.replicas) == 0 {
+		return s.primary, true
+	}
+
+	opts, _ := extractQueryOptions(params)
+	if (opts.fromPrimary || s.isSticky(ctx)) && s.primaryOutage() == nil {
+		return s.primary, true
+	}
+
+	idx := atomic.AddUint64(s.next, 1) - 1
+	return s.replicas[idx%uint64(len(s.replicas))], false
+}
+
+// primaryOutage returns an ErrPrimaryUnavailable error if the primary
+// failed less than PrimaryRetryInterval ago on the degraded mode.
+func (s ReadWriteSplitter) primaryOutage() error {
+	if !s.config.DegradeOnPrimaryOutage {
+		return nil
+	}
+
+	s.primaryState.mu.Lock()
+	defer s.primaryState.mu.Unlock()
+
+	if s.primaryState.err == nil || time.Since(s.primaryState.failedAt) >= s.config.PrimaryRetryInterval {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrPrimaryUnavailable, s.primaryState.err)
+}
+
+// observe updates the state of the primary for the degraded mode
+// according to the result of an operation sent to it.
+func (s ReadWriteSplitter) observe(ctx context.Context, err error) error {
+	if !s.config.DegradeOnPrimaryOutage || s.tx != nil || ctx.Err() != nil {
+		return err
+	}
+
+	outage := err != nil && s.config.IsPrimaryOutage(err)
+
+	s.primaryState.mu.Lock()
+	wasAvailable := s.primaryState.err == nil
+	if outage {
+		s.primaryState.err = err
+		s.primaryState.failedAt = time.Now()
+	} else {
+		s.primaryState.err = nil
+	}
+	s.primaryState.mu.Unlock()
+
+	if s.config.OnPrimaryStateChange != nil && wasAvailable == outage {
+		if outage {
+			s.config.OnPrimaryStateChange(false, err)
+		} else {
+			s.config.OnPrimaryStateChange(true, nil)
+		}
+	}
+
+	return err
+}
+
+func (s ReadWriteSplitter) isSticky(ctx context.Context) bool {
+	if s.config.StickyWindow == 0 {
+		return false
+	}
+
+	session, ok := ctx.Value(writeSessionKey{}).(*writeSession)
+	if !ok {
+		return false
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return !session.lastWrite.IsZero() && time.Since(session.lastWrite) < s.config.StickyWindow
+}
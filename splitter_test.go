@@ -0,0 +1,347 @@
+package ksql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestReadWriteSplitter(t *testing.T) {
+	newProvider := func(name string, calls *[]string) Mock {
+		return Mock{
+			QueryOneFn: func(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+				*calls = append(*calls, name+": "+query)
+				return nil
+			},
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				*calls = append(*calls, name+": "+query)
+				return NewMockResult(0, 1), nil
+			},
+			TransactionFn: func(ctx context.Context, fn func(db Provider) error) error {
+				*calls = append(*calls, name+": transaction")
+				return fn(Mock{
+					QueryOneFn: func(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+						*calls = append(*calls, name+" tx: "+query)
+						return nil
+					},
+				})
+			},
+		}
+	}
+
+	newSplitter := func(t *testing.T, calls *[]string, numReplicas int, config SplitterConfig) ReadWriteSplitter {
+		replicas := []Provider{}
+		for i := 0; i < numReplicas; i++ {
+			replicas = append(replicas, newProvider("replica"+string(rune('1'+i)), calls))
+		}
+
+		splitter, err := NewReadWriteSplitter(newProvider("primary", calls), replicas, config)
+		tt.AssertNoErr(t, err)
+		return splitter
+	}
+
+	ctx := context.Background()
+	var record struct{}
+
+	t.Run("should send reads to the replicas in round-robin and writes to the primary", func(t *testing.T) {
+		var calls []string
+		splitter := newSplitter(t, &calls, 2, SplitterConfig{})
+
+		for i := 0; i < 3; i++ {
+			err := splitter.QueryOne(ctx, &record, "SELECT 1")
+			tt.AssertNoErr(t, err)
+		}
+		_, err := splitter.Exec(ctx, "DELETE FROM users")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, calls, []string{
+			"replica1: SELECT 1",
+			"replica2: SELECT 1",
+			"replica1: SELECT 1",
+			"primary: DELETE FROM users",
+		})
+	})
+
+	t.Run("should send reads to the primary if there are no replicas", func(t *testing.T) {
+		var calls []string
+		splitter := newSplitter(t, &calls, 0, SplitterConfig{})
+
+		err := splitter.QueryOne(ctx, &record, "SELECT 1")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"primary: SELECT 1"})
+	})
+
+	t.Run("should send reads using FromPrimary to the primary", func(t *testing.T) {
+		var calls []string
+		splitter := newSplitter(t, &calls, 1, SplitterConfig{})
+
+		err := splitter.QueryOne(ctx, &record, "SELECT 1", FromPrimary())
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"primary: SELECT 1"})
+	})
+
+	t.Run("should run transactions on the primary", func(t *testing.T) {
+		var calls []string
+		splitter := newSplitter(t, &calls, 1, SplitterConfig{})
+
+		err := splitter.Transaction(ctx, func(db Provider) error {
+			return db.QueryOne(ctx, &record, "SELECT 1")
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{
+			"primary: transaction",
+			"primary tx: SELECT 1",
+		})
+	})
+
+	t.Run("should send reads to the primary after writes with WithReadYourWrites", func(t *testing.T) {
+		var calls []string
+		splitter := newSplitter(t, &calls, 1, SplitterConfig{
+			StickyWindow: 50 * time.Millisecond,
+		})
+
+		sessionCtx := WithReadYourWrites(ctx)
+
+		err := splitter.QueryOne(sessionCtx, &record, "SELECT 1")
+		tt.AssertNoErr(t, err)
+
+		_, err = splitter.Exec(sessionCtx, "DELETE FROM users")
+		tt.AssertNoErr(t, err)
+
+		err = splitter.QueryOne(sessionCtx, &record, "SELECT 2")
+		tt.AssertNoErr(t, err)
+
+		// Other contexts are not affected:
+		err = splitter.QueryOne(ctx, &record, "SELECT 3")
+		tt.AssertNoErr(t, err)
+
+		time.Sleep(60 * time.Millisecond)
+		err = splitter.QueryOne(sessionCtx, &record, "SELECT 4")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, calls, []string{
+			"replica1: SELECT 1",
+			"primary: DELETE FROM users",
+			"primary: SELECT 2",
+			"replica1: SELECT 3",
+			"replica1: SELECT 4",
+		})
+	})
+
+	t.Run("should degrade to the replicas while the primary is unreachable", func(t *testing.T) {
+		var calls []string
+		var stateChanges []string
+		primaryErr := driver.ErrBadConn
+
+		primary := Mock{
+			QueryOneFn: func(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+				calls = append(calls, "primary: "+query)
+				return primaryErr
+			},
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				calls = append(calls, "primary: "+query)
+				return NewMockResult(0, 1), primaryErr
+			},
+		}
+		splitter, err := NewReadWriteSplitter(primary, []Provider{newProvider("replica1", &calls)}, SplitterConfig{
+			DegradeOnPrimaryOutage: true,
+			PrimaryRetryInterval:   50 * time.Millisecond,
+			OnPrimaryStateChange: func(available bool, err error) {
+				if available {
+					stateChanges = append(stateChanges, "available")
+				} else {
+					stateChanges = append(stateChanges, "unavailable: "+err.Error())
+				}
+			},
+		})
+		tt.AssertNoErr(t, err)
+
+		_, err = splitter.Exec(ctx, "DELETE FROM users")
+		tt.AssertEqual(t, errors.Is(err, driver.ErrBadConn), true)
+		tt.AssertEqual(t, splitter.PrimaryAvailable(), false)
+
+		// Writes fail fast and the reads from the primary go to the replicas:
+		_, err = splitter.Exec(ctx, "DELETE FROM posts")
+		tt.AssertEqual(t, errors.Is(err, ErrPrimaryUnavailable), true)
+		tt.AssertErrContains(t, err, "bad connection")
+
+		err = splitter.QueryOne(ctx, &record, "SELECT 1", FromPrimary())
+		tt.AssertNoErr(t, err)
+
+		// After the retry interval the primary is tried again:
+		time.Sleep(60 * time.Millisecond)
+		primaryErr = nil
+		_, err = splitter.Exec(ctx, "DELETE FROM comments")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, splitter.PrimaryAvailable(), true)
+
+		err = splitter.QueryOne(ctx, &record, "SELECT 2", FromPrimary())
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, calls, []string{
+			"primary: DELETE FROM users",
+			"replica1: SELECT 1",
+			"primary: DELETE FROM comments",
+			"primary: SELECT 2",
+		})
+		tt.AssertEqual(t, stateChanges, []string{
+			"unavailable: driver: bad connection",
+			"available",
+		})
+	})
+
+	t.Run("should not make the reads sticky after writes rejected during outages", func(t *testing.T) {
+		var calls []string
+		splitter := newSplitter(t, &calls, 1, SplitterConfig{
+			StickyWindow:           time.Minute,
+			DegradeOnPrimaryOutage: true,
+			PrimaryRetryInterval:   time.Minute,
+		})
+		splitter.primaryState.err = driver.ErrBadConn
+		splitter.primaryState.failedAt = time.Now()
+
+		sessionCtx := WithReadYourWrites(ctx)
+		_, err := splitter.Exec(sessionCtx, "DELETE FROM users")
+		tt.AssertEqual(t, errors.Is(err, ErrPrimaryUnavailable), true)
+
+		session := sessionCtx.Value(writeSessionKey{}).(*writeSession)
+		tt.AssertEqual(t, session.lastWrite.IsZero(), true)
+
+		err = splitter.QueryOne(sessionCtx, &record, "SELECT 1")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"replica1: SELECT 1"})
+	})
+
+	t.Run("should not degrade on errors that are not outages", func(t *testing.T) {
+		primary := Mock{
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				return nil, errors.New("duplicate key")
+			},
+		}
+		splitter, err := NewReadWriteSplitter(primary, nil, SplitterConfig{
+			DegradeOnPrimaryOutage: true,
+		})
+		tt.AssertNoErr(t, err)
+
+		_, err = splitter.Exec(ctx, "INSERT INTO users")
+		tt.AssertErrContains(t, err, "duplicate key")
+		tt.AssertEqual(t, splitter.PrimaryAvailable(), true)
+
+		_, err = splitter.Exec(ctx, "INSERT INTO users")
+		tt.AssertErrContains(t, err, "duplicate key")
+	})
+
+	t.Run("should not degrade if the option is disabled", func(t *testing.T) {
+		var calls []string
+		primary := Mock{
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				calls = append(calls, "primary: "+query)
+				return nil, driver.ErrBadConn
+			},
+		}
+		splitter, err := NewReadWriteSplitter(primary, nil, SplitterConfig{})
+		tt.AssertNoErr(t, err)
+
+		for i := 0; i < 2; i++ {
+			_, err = splitter.Exec(ctx, "DELETE FROM users")
+			tt.AssertEqual(t, errors.Is(err, driver.ErrBadConn), true)
+		}
+		tt.AssertEqual(t, len(calls), 2)
+		tt.AssertEqual(t, splitter.PrimaryAvailable(), true)
+	})
+
+	t.Run("should validate its arguments", func(t *testing.T) {
+		_, err := NewReadWriteSplitter(nil, nil, SplitterConfig{})
+		tt.AssertErrContains(t, err, "primary", "required")
+
+		_, err = NewReadWriteSplitter(Mock{}, []Provider{Mock{}, nil}, SplitterConfig{})
+		tt.AssertErrContains(t, err, "replica 1", "nil")
+	})
+	t.Run("should report the health of the primary and of each replica", func(t *testing.T) {
+		newDB := func(err error) Provider {
+			db, dbErr := NewWithAdapter(mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+					if err != nil {
+						return nil, err
+					}
+					return newMockRows([]string{"1"}, []interface{}{1}), nil
+				},
+			}, "postgres")
+			tt.AssertNoErr(t, dbErr)
+			return db
+		}
+
+		tests := []struct {
+			desc             string
+			primary          Provider
+			replicas         []Provider
+			expectHealthy    bool
+			expectErr        string
+			expectReplicaErr []string
+		}{
+			{
+				desc:             "all healthy",
+				primary:          newDB(nil),
+				replicas:         []Provider{newDB(nil), newDB(nil)},
+				expectHealthy:    true,
+				expectReplicaErr: []string{"", ""},
+			},
+			{
+				desc:             "one replica failing",
+				primary:          newDB(nil),
+				replicas:         []Provider{newDB(errors.New("fake-replica-error")), newDB(nil)},
+				expectHealthy:    true,
+				expectReplicaErr: []string{"fake-replica-error", ""},
+			},
+			{
+				desc:             "all replicas failing",
+				primary:          newDB(nil),
+				replicas:         []Provider{newDB(errors.New("fake-replica-error"))},
+				expectHealthy:    false,
+				expectErr:        "ksql: none of the replicas is healthy",
+				expectReplicaErr: []string{"fake-replica-error"},
+			},
+			{
+				desc:             "primary failing",
+				primary:          newDB(errors.New("fake-primary-error")),
+				replicas:         []Provider{newDB(nil)},
+				expectHealthy:    false,
+				expectErr:        "fake-primary-error",
+				expectReplicaErr: []string{""},
+			},
+			{
+				desc:             "replica not implementing HealthChecker",
+				primary:          newDB(nil),
+				replicas:         []Provider{Mock{}, newDB(nil)},
+				expectHealthy:    true,
+				expectReplicaErr: []string{"ksql: the Provider ksql.Mock doesn't implement the HealthChecker interface", ""},
+			},
+			{
+				desc:          "no replicas",
+				primary:       newDB(nil),
+				expectHealthy: true,
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				splitter, err := NewReadWriteSplitter(test.primary, test.replicas, SplitterConfig{})
+				tt.AssertNoErr(t, err)
+
+				report := splitter.HealthCheck(ctx)
+				tt.AssertEqual(t, report.Healthy, test.expectHealthy)
+				tt.AssertEqual(t, report.Error, test.expectErr)
+
+				var replicaErrs []string
+				for _, replicaReport := range report.Replicas {
+					tt.AssertEqual(t, replicaReport.Healthy, replicaReport.Error == "")
+					replicaErrs = append(replicaErrs, replicaReport.Error)
+				}
+				tt.AssertEqual(t, replicaErrs, test.expectReplicaErr)
+			})
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+)
+
+// BuildInsertQuery returns the query and params that the Insert method would
+// send to the database for the record, without connecting to any database,
+// so tools can generate the exact SQL KSQL would run, e.g. for writing it to
+// a change-request file or for running it with a different executor:
+//
+//	query, params, err := ksql.BuildInsertQuery("postgres", usersTable, &user)
+//
+// The dialect is the name of one of the supported dialects, e.g. "postgres",
+// and the options are the same accepted by Insert, e.g. StatementHints.
+//
+// Since no database is available the IDs of tables using sequences are not
+// read, so they are omitted from the query unless they are set, and the
+// values generated for the attributes with the `default` modifier are
+// written to the record just like on Insert.
+func BuildInsertQuery(dialect string, table Table, record interface{}, opts ...QueryOption) (query string, params []interface{}, err error) {
+	return buildStatement(dialect, []string{"Insert"}, func(ctx context.Context, db DB, dryRun QueryOption) error {
+		return db.insert(ctx, table, record, append(opts, dryRun))
+	})
+}
+
+// BuildUpdateQuery returns the query and params that the Patch method would
+// send to the database for the record, see BuildInsertQuery for more details.
+//
+// Just like on Patch, the nil pointer attributes are omitted from the query
+// and the record can be a ksql.Tracker for updating only the changed columns.
+func BuildUpdateQuery(dialect string, table Table, record interface{}, opts ...QueryOption) (query string, params []interface{}, err error) {
+	return buildStatement(dialect, []string{"Patch"}, func(ctx context.Context, db DB, dryRun QueryOption) error {
+		return db.patch(ctx, table, record, append(opts, dryRun))
+	})
+}
+
+// BuildDeleteQuery returns the query and params that the Delete method would
+// send to the database for the input ID or record, see BuildInsertQuery for
+// more details.
+func BuildDeleteQuery(dialect string, table Table, idOrRecord interface{}, opts ...QueryOption) (query string, params []interface{}, err error) {
+	return buildStatement(dialect, []string{"Delete"}, func(ctx context.Context, db DB, dryRun QueryOption) error {
+		return db.delete(ctx, table, idOrRecord, append(opts, dryRun))
+	})
+}
+
+// BuildUpsertQuery returns the query and params that the Upsert method would
+// send to the database for the record, see BuildInsertQuery for more details.
+//
+// Since the dialects without native upserts run a Patch followed by an
+// Insert, which can't be expressed as a single statement, it returns
+// ErrNotSupported for them.
+func BuildUpsertQuery(dialect string, table Table, record interface{}, opts ...QueryOption) (query string, params []interface{}, err error) {
+	// Upsert inserts the records with unset IDs with the Insert method:
+	return buildStatement(dialect, []string{"Upsert", "Insert"}, func(ctx context.Context, db DB, dryRun QueryOption) error {
+		if !hasNativeUpsert(db.dialect) {
+			return fmt.Errorf("%w: the %s dialect has no native upsert, so Upsert runs more than one statement", ErrNotSupported, dialect)
+		}
+		return db.Upsert(ctx, table, record, append(opts, dryRun)...)
+	})
+}
+
+// buildStatement runs the operation with the DryRun option on a client
+// that can't reach any database and returns the last statement sent by
+// the input methods, so the statements sent around it, e.g. the ones
+// enabling IDENTITY_INSERT on sqlserver, are not returned.
+func buildStatement(
+	dialect string,
+	methods []string,
+	run func(ctx context.Context, db DB, dryRun QueryOption) error,
+) (query string, params []interface{}, err error) {
+	var found bool
+	db, err := NewWithAdapter(dryRunAdapter{}, dialect, WithQueryRewriter(
+		func(ctx context.Context, op OpInfo, q string, p []interface{}) (string, []interface{}, error) {
+			if containsString(methods, op.Method) {
+				query, params, found = q, p, true
+			}
+			return q, p, nil
+		},
+	))
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = run(context.Background(), db, DryRun(func(string, []interface{}) {}))
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !found {
+		return "", nil, fmt.Errorf("ksql: no statement was generated by %s for the %s dialect", methods[0], dialect)
+	}
+
+	return query, params, nil
+}
+
+// dryRunAdapter is used by the Build*Query functions, which run the operations
+// with the DryRun option, so none of the queries should ever reach it.
+type dryRunAdapter struct{}
+
+func (dryRunAdapter) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	return nil, fmt.Errorf("ksql: unexpected query sent while building the SQL: %s", query)
+}
+
+func (dryRunAdapter) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return nil, fmt.Errorf("ksql: unexpected query sent while building the SQL: %s", query)
+}
+
+func (a dryRunAdapter) BeginTx(ctx context.Context) (Tx, error) {
+	return dryRunTx{a}, nil
+}
+
+type dryRunTx struct {
+	dryRunAdapter
+}
+
+func (dryRunTx) Rollback(ctx context.Context) error {
+	return nil
+}
+
+func (dryRunTx) Commit(ctx context.Context) error {
+	return nil
+}
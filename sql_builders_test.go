@@ -0,0 +1,69 @@
+package ksql
+
+import (
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestSQLBuilders(t *testing.T) {
+	type userRecord struct {
+		ID   int     `ksql:"id"`
+		Name *string `ksql:"name"`
+		Age  int     `ksql:"age"`
+	}
+
+	name := "Bia"
+
+	t.Run("should build the INSERT statements", func(t *testing.T) {
+		query, params, err := BuildInsertQuery("postgres", usersTable, &userRecord{Name: &name, Age: 22})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `INSERT INTO "users" ("age", "name") VALUES ($1, $2) RETURNING "id"`)
+		tt.AssertEqual(t, params, []interface{}{22, "Bia"})
+
+		query, params, err = BuildInsertQuery("mysql", usersTable, &userRecord{Name: &name, Age: 22})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "INSERT INTO `users` (`age`, `name`) VALUES (?, ?)")
+		tt.AssertEqual(t, params, []interface{}{22, "Bia"})
+	})
+
+	t.Run("should return only the INSERT when other statements are sent around it", func(t *testing.T) {
+		query, params, err := BuildInsertQuery("sqlserver", usersTable, &userRecord{ID: 42, Age: 22}, IdentityInsert())
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `INSERT INTO [users] ([age], [id]) OUTPUT INSERTED.[id] VALUES (@p1, @p2)`)
+		tt.AssertEqual(t, params, []interface{}{22, 42})
+	})
+
+	t.Run("should build the UPDATE statements", func(t *testing.T) {
+		query, params, err := BuildUpdateQuery("postgres", usersTable, userRecord{ID: 42, Name: &name, Age: 22})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `UPDATE "users" SET "age" = $1, "name" = $2 WHERE "id" = $3`)
+		tt.AssertEqual(t, params, []interface{}{22, "Bia", 42})
+	})
+
+	t.Run("should build the DELETE statements", func(t *testing.T) {
+		query, params, err := BuildDeleteQuery("sqlite3", usersTable, 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "DELETE FROM `users` WHERE `id` = ?")
+		tt.AssertEqual(t, params, []interface{}{42})
+	})
+
+	t.Run("should build the upsert statements", func(t *testing.T) {
+		query, params, err := BuildUpsertQuery("postgres", usersTable, &userRecord{ID: 42, Age: 22})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `INSERT INTO "users" ("age", "id") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "age" = EXCLUDED."age"`)
+		tt.AssertEqual(t, params, []interface{}{22, 42})
+
+		_, _, err = BuildUpsertQuery("sqlserver", usersTable, &userRecord{ID: 42, Age: 22})
+		tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+	})
+
+	t.Run("should report errors", func(t *testing.T) {
+		_, _, err := BuildInsertQuery("fake-dialect", usersTable, &userRecord{})
+		tt.AssertErrContains(t, err, "unsupported driver", "fake-dialect")
+
+		_, _, err = BuildInsertQuery("postgres", usersTable, userRecord{})
+		tt.AssertErrContains(t, err, "pointer to struct")
+	})
+}
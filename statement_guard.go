@@ -0,0 +1,181 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// StatementGuard describes which statements should be rejected
+// by the WithStatementGuard option, e.g.:
+//
+//	db, err := kpgx.New(ctx, connURL, ksql.Config{},
+//		ksql.WithStatementGuard(ksql.StatementGuard{
+//			DenyDeleteWithoutWhere: true,
+//			DenyUpdateWithoutWhere: true,
+//			DenyDDL:                true,
+//		}),
+//	)
+type StatementGuard struct {
+	// DenyDeleteWithoutWhere rejects DELETE statements with no WHERE clause
+	DenyDeleteWithoutWhere bool
+
+	// DenyUpdateWithoutWhere rejects UPDATE statements with no WHERE clause
+	DenyUpdateWithoutWhere bool
+
+	// DenyDDL rejects the CREATE, ALTER, DROP, TRUNCATE and RENAME statements
+	DenyDDL bool
+
+	// Deny rejects the statements matching any of these patterns
+	Deny []*regexp.Regexp
+
+	// Allow, if set, rejects the statements matching none of these patterns
+	Allow []*regexp.Regexp
+}
+
+// StatementRejectedError is returned when a statement
+// is rejected by the WithStatementGuard option.
+type StatementRejectedError struct {
+	// Method is the name of the ksql.DB method that was rejected
+	Method string
+
+	// Reason describes the rule that rejected the statement
+	Reason string
+
+	// Fingerprint identifies the query without exposing its contents,
+	// it is the same for queries that only differ by whitespace.
+	Fingerprint string
+}
+
+func (e StatementRejectedError) Error() string {
+	return fmt.Sprintf(
+		"ksql: statement rejected on %s: %s (query fingerprint: %s)",
+		e.Method, e.Reason, e.Fingerprint,
+	)
+}
+
+// WithStatementGuard rejects the statements matching the rules of the input
+// StatementGuard before they reach the database, which is useful as a
+// safety net for admin tooling and scripts built on top of KSQL.
+//
+// The rules are checked against each statement of the query, including the ones
+// generated internally, e.g. by Insert, and the rejected operations fail with a
+// StatementRejectedError. It is implemented as a QueryRewriter, so the statements
+// are checked after the rewriters configured before it.
+func WithStatementGuard(guard StatementGuard) Option {
+	return WithQueryRewriter(func(ctx context.Context, op OpInfo, query string, params []interface{}) (string, []interface{}, error) {
+		for _, statement := range splitStatements(query) {
+			if reason := guard.check(statement); reason != "" {
+				return "", nil, StatementRejectedError{
+					Method:      op.Method,
+					Reason:      reason,
+					Fingerprint: queryFingerprint(query),
+				}
+			}
+		}
+
+		return query, params, nil
+	})
+}
+
+// check returns the reason for rejecting the statement,
+// or an empty string if the statement is allowed.
+func (g StatementGuard) check(statement string) string {
+	if len(g.Allow) > 0 && !matchesAny(g.Allow, statement) {
+		return "the statement matches none of the allowed patterns"
+	}
+
+	for _, pattern := range g.Deny {
+		if pattern.MatchString(statement) {
+			return fmt.Sprintf("the statement matches the denied pattern `%s`", pattern)
+		}
+	}
+
+	switch verb := statementVerb(statement); {
+	case verb == "DELETE" && g.DenyDeleteWithoutWhere && findTopLevelKeyword(statement, "WHERE") == -1:
+		return "DELETE statements without a WHERE clause are not allowed"
+	case verb == "UPDATE" && g.DenyUpdateWithoutWhere && findTopLevelKeyword(statement, "WHERE") == -1:
+		return "UPDATE statements without a WHERE clause are not allowed"
+	case isDDLVerb(verb) && g.DenyDDL:
+		return verb + " statements are not allowed"
+	}
+
+	return ""
+}
+
+func matchesAny(patterns []*regexp.Regexp, statement string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(statement) {
+			return true
+		}
+	}
+	return false
+}
+
+// statementVerb returns the keyword that defines what the statement does,
+// e.g. DELETE for `WITH old AS (...) DELETE FROM users WHERE ...`.
+func statementVerb(statement string) string {
+	parsed := parseQuery(statement, queryOptions{rawQuery: true})
+	if parsed.firstToken != "WITH" {
+		return parsed.firstToken
+	}
+
+	// The main statement is the first one outside of the parenthesis of the CTEs:
+	verb, start := "", -1
+	for _, keyword := range []string{"SELECT", "INSERT", "UPDATE", "DELETE", "MERGE"} {
+		if idx := findTopLevelKeyword(parsed.main, keyword); idx != -1 && (start == -1 || idx < start) {
+			verb, start = keyword, idx
+		}
+	}
+	return verb
+}
+
+func isDDLVerb(verb string) bool {
+	switch verb {
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME":
+		return true
+	}
+	return false
+}
+
+// splitStatements splits the query on the semicolons that
+// are not inside strings, quoted identifiers or comments.
+func splitStatements(query string) []string {
+	var statements []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		if n := commentLen(query[i:]); n > 0 {
+			i += n - 1
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '[':
+			quote = ']'
+		case ';':
+			statements = appendStatement(statements, query[start:i])
+			start = i + 1
+		}
+	}
+
+	return appendStatement(statements, query[start:])
+}
+
+func appendStatement(statements []string, statement string) []string {
+	if strings.TrimSpace(statement) == "" {
+		return statements
+	}
+	return append(statements, statement)
+}
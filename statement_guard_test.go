@@ -0,0 +1,128 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestWithStatementGuard(t *testing.T) {
+	tests := []struct {
+		desc           string
+		guard          StatementGuard
+		query          string
+		expectedReason string
+	}{
+		{
+			desc:           "should reject DELETE without WHERE",
+			guard:          StatementGuard{DenyDeleteWithoutWhere: true},
+			query:          "DELETE FROM users",
+			expectedReason: "DELETE statements without a WHERE clause",
+		},
+		{
+			desc:  "should allow DELETE with WHERE",
+			guard: StatementGuard{DenyDeleteWithoutWhere: true},
+			query: "DELETE FROM users WHERE id = $1",
+		},
+		{
+			desc:           "should ignore the WHERE clauses of subqueries, strings and comments",
+			guard:          StatementGuard{DenyDeleteWithoutWhere: true},
+			query:          "-- WHERE\nDELETE FROM users USING (SELECT id FROM old WHERE id > 1) o /* WHERE */ ; SELECT 'WHERE'",
+			expectedReason: "DELETE statements without a WHERE clause",
+		},
+		{
+			desc:           "should reject UPDATE without WHERE",
+			guard:          StatementGuard{DenyUpdateWithoutWhere: true},
+			query:          "WITH adults AS (SELECT id FROM users WHERE age > 18) UPDATE users SET age = 0",
+			expectedReason: "UPDATE statements without a WHERE clause",
+		},
+		{
+			desc:  "should not confuse upserts with updates",
+			guard: StatementGuard{DenyUpdateWithoutWhere: true},
+			query: "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = excluded.name",
+		},
+		{
+			desc:           "should check every statement of the query",
+			guard:          StatementGuard{DenyUpdateWithoutWhere: true},
+			query:          "UPDATE users SET age = 0 WHERE id = 1; UPDATE users SET age = 0",
+			expectedReason: "UPDATE statements without a WHERE clause",
+		},
+		{
+			desc:  "should ignore semicolons inside strings",
+			guard: StatementGuard{DenyUpdateWithoutWhere: true},
+			query: "UPDATE users SET name = 'a;UPDATE' WHERE id = 1",
+		},
+		{
+			desc:           "should reject DDL",
+			guard:          StatementGuard{DenyDDL: true},
+			query:          "/* cleanup */ drop table users",
+			expectedReason: "DROP statements are not allowed",
+		},
+		{
+			desc:           "should reject statements matching the denied patterns",
+			guard:          StatementGuard{Deny: []*regexp.Regexp{regexp.MustCompile(`(?i)\bpg_sleep\b`)}},
+			query:          "SELECT pg_sleep(10)",
+			expectedReason: "denied pattern",
+		},
+		{
+			desc:           "should reject statements matching none of the allowed patterns",
+			guard:          StatementGuard{Allow: []*regexp.Regexp{regexp.MustCompile(`(?i)^\s*SELECT\b`)}},
+			query:          "DELETE FROM users WHERE id = 1",
+			expectedReason: "none of the allowed patterns",
+		},
+		{
+			desc:  "should allow statements matching the allowed patterns",
+			guard: StatementGuard{Allow: []*regexp.Regexp{regexp.MustCompile(`(?i)^\s*SELECT\b`)}},
+			query: "SELECT 1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var executed bool
+			db, err := NewWithAdapter(mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					executed = true
+					return NewMockResult(0, 1), nil
+				},
+			}, "postgres", SkipParamsValidation(), WithStatementGuard(test.guard))
+			tt.AssertNoErr(t, err)
+
+			_, err = db.Exec(context.Background(), test.query)
+			if test.expectedReason == "" {
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, executed, true)
+				return
+			}
+
+			var rejectedErr StatementRejectedError
+			tt.AssertEqual(t, errors.As(err, &rejectedErr), true)
+			tt.AssertEqual(t, rejectedErr.Method, "Exec")
+			tt.AssertEqual(t, rejectedErr.Fingerprint, queryFingerprint(test.query))
+			tt.AssertErrContains(t, err, test.expectedReason)
+			tt.AssertEqual(t, executed, false)
+		})
+	}
+
+	t.Run("should allow the statements generated by the helpers", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				return NewMockResult(0, 1), nil
+			},
+		}, "postgres", WithStatementGuard(StatementGuard{
+			DenyDeleteWithoutWhere: true,
+			DenyUpdateWithoutWhere: true,
+			DenyDDL:                true,
+		}))
+		tt.AssertNoErr(t, err)
+
+		err = db.Delete(context.Background(), usersTable, 42)
+		tt.AssertNoErr(t, err)
+
+		err = db.Patch(context.Background(), usersTable, &user{ID: 42, Name: "fake-name"})
+		tt.AssertNoErr(t, err)
+	})
+}
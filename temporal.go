@@ -0,0 +1,192 @@
+package ksql
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AsOf makes the Find and QueryByKey methods load the record as it was at
+// the input time, which is useful for audits and for reproducing reports:
+//
+//	var user User
+//	err := db.Find(ctx, UsersTable, &user, userID, ksql.AsOf(lastMonth))
+//
+// On sqlserver and on mariadb, which uses the mysql dialect, the table must
+// be system-versioned and declared with Table.WithSystemVersioning, so the
+// `FOR SYSTEM_TIME AS OF` clause is used. On postgres, which has no
+// system-versioned tables, the record is read from the history table set
+// with Table.WithHistory, see HistoryTriggerQuery.
+//
+// It returns ErrNotSupported on the other dialects, including MySQL and TiDB.
+func AsOf(t time.Time) QueryOption {
+	return func(opts *queryOptions) {
+		opts.asOf = &t
+	}
+}
+
+// WithSystemVersioning returns a copy of the Table for system-versioned
+// tables, which keep the previous versions of their rows and are required
+// by the AsOf option on sqlserver and on mariadb.
+//
+// Since MariaDB uses the mysql dialect this is what tells KSQL that the
+// `FOR SYSTEM_TIME AS OF` clause can be used, MySQL has no such tables.
+func (t Table) WithSystemVersioning() Table {
+	t.systemVersioned = true
+	return t
+}
+
+// WithHistory returns a copy of the Table whose previous versions are kept
+// on the input history table, which is used by the AsOf option on postgres.
+//
+// The history table must have the columns of the table followed by the
+// `valid_from` and `valid_to` timestamps of each version, the current
+// versions having a NULL `valid_to`. The table and the trigger filling it
+// can be created with the query returned by HistoryTriggerQuery.
+func (t Table) WithHistory(historyTable string) Table {
+	t.historyTable = historyTable
+	return t
+}
+
+// HistoryTriggerQuery builds the statements that create the history table
+// of a table using WithHistory and the trigger that records the versions of
+// its rows on every INSERT, UPDATE and DELETE, emulating system-versioned
+// tables on postgres, e.g.:
+//
+//	query, err := db.HistoryTriggerQuery(UsersTable)
+//	...
+//	err = db.Transaction(ctx, func(db ksql.Provider) error {
+//		_, err := db.Exec(ctx, query)
+//		return err
+//	})
+//
+// The rows that already exist are copied to the history table as versions
+// valid from the moment the query runs, so AsOf can't find them for earlier
+// times, and running it again only copies the rows without a current version.
+// It should run inside a transaction so no write is missed between the copy
+// and the creation of the trigger.
+//
+// The versions are timestamped with the start time of the transaction
+// that wrote them and the history table is created with `LIKE`, so it
+// must be recreated if columns are added to the table.
+//
+// It is only supported by the postgres dialect.
+func (c DB) HistoryTriggerQuery(table Table) (string, error) {
+	if err := table.validate(); err != nil {
+		return "", fmt.Errorf("can't create history for ksql.Table: %s", err)
+	}
+
+	if capabilitiesOf(c.dialect).DDL != DDLPostgres {
+		return "", fmt.Errorf("%w: HistoryTriggerQuery is not supported by the %s dialect", ErrNotSupported, c.dialect.DriverName())
+	}
+
+	if table.historyTable == "" {
+		return "", fmt.Errorf("ksql: table %s has no history table, see Table.WithHistory", table.name)
+	}
+
+	return buildHistoryTriggerQuery(c.dialect, table), nil
+}
+
+func buildHistoryTriggerQuery(dialect Dialect, table Table) string {
+	history := table.escapedHistoryName(dialect)
+	function := Table{name: table.historyTable + "_fn", schema: table.schema}.escapedName(dialect)
+	trigger := dialect.Escape(table.historyTable + "_trigger")
+
+	conditions := make([]string, len(table.idColumns))
+	currentConditions := make([]string, len(table.idColumns))
+	for i, idName := range table.idColumns {
+		conditions[i] = dialect.Escape(idName) + " = OLD." + dialect.Escape(idName)
+		currentConditions[i] = "h." + dialect.Escape(idName) + " = t." + dialect.Escape(idName)
+	}
+
+	return strings.Join([]string{
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (LIKE %s, "valid_from" TIMESTAMPTZ NOT NULL, "valid_to" TIMESTAMPTZ)`,
+			history, table.escapedName(dialect),
+		),
+		// Copying the rows that have no current version yet:
+		fmt.Sprintf(
+			`INSERT INTO %s SELECT t.*, now(), NULL FROM %s t WHERE NOT EXISTS (SELECT 1 FROM %s h WHERE %s AND h."valid_to" IS NULL)`,
+			history, table.escapedName(dialect), history, strings.Join(currentConditions, " AND "),
+		),
+		fmt.Sprintf(
+			`CREATE OR REPLACE FUNCTION %s() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		UPDATE %s SET "valid_to" = now() WHERE %s AND "valid_to" IS NULL;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		INSERT INTO %s SELECT NEW.*, now(), NULL;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`,
+			function, history, strings.Join(conditions, " AND "), history,
+		),
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", trigger, table.escapedName(dialect)),
+		fmt.Sprintf(
+			"CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE PROCEDURE %s()",
+			trigger, table.escapedName(dialect), function,
+		),
+	}, ";\n") + ";"
+}
+
+// escapedHistoryName returns the escaped name of the history
+// table qualified with the schema of the table if set.
+func (t Table) escapedHistoryName(dialect Dialect) string {
+	return Table{name: t.historyTable, schema: t.schema}.escapedName(dialect)
+}
+
+// buildAsOfQuery builds the FROM and WHERE parts of the query used by Find
+// for loading the version of the record valid at the input time, the time
+// is the first param since it comes before the IDs on system-versioned tables.
+func buildAsOfQuery(
+	dialect Dialect,
+	table Table,
+	idMap map[string]interface{},
+	asOf time.Time,
+) (query string, params []interface{}, _ error) {
+	params = []interface{}{asOf}
+	conditions := make([]string, len(table.idColumns))
+	for i, idName := range table.idColumns {
+		conditions[i] = dialect.Escape(idName) + " = " + dialect.Placeholder(i+1)
+		params = append(params, idMap[idName])
+	}
+	whereQuery := strings.Join(conditions, " AND ")
+
+	caps := capabilitiesOf(dialect)
+	switch {
+	case caps.SystemVersioning:
+		if !table.systemVersioned {
+			return "", nil, fmt.Errorf(
+				"%w: AsOf requires a system-versioned table on the %s dialect, see Table.WithSystemVersioning",
+				ErrNotSupported, dialect.DriverName(),
+			)
+		}
+
+		return fmt.Sprintf(
+			"FROM %s FOR SYSTEM_TIME AS OF %s WHERE %s",
+			table.escapedName(dialect),
+			dialect.Placeholder(0),
+			table.withFindFilter(dialect, whereQuery),
+		), params, nil
+
+	case caps.DDL == DDLPostgres:
+		if table.historyTable == "" {
+			return "", nil, fmt.Errorf("ksql: AsOf requires a history table on postgres, see Table.WithHistory")
+		}
+
+		whereQuery += fmt.Sprintf(
+			` AND "valid_from" <= %s AND ("valid_to" IS NULL OR "valid_to" > %s)`,
+			dialect.Placeholder(0), dialect.Placeholder(0),
+		)
+		return fmt.Sprintf(
+			"FROM %s WHERE %s",
+			table.escapedHistoryName(dialect),
+			table.withFindFilter(dialect, whereQuery),
+		), params, nil
+
+	default:
+		return "", nil, fmt.Errorf("%w: AsOf is not supported by the %s dialect", ErrNotSupported, dialect.DriverName())
+	}
+}
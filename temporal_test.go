@@ -0,0 +1,143 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestAsOf(t *testing.T) {
+	ctx := context.Background()
+	asOf := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("should load the record valid at the input time", func(t *testing.T) {
+		tests := []struct {
+			dialect       string
+			table         Table
+			expectedQuery string
+		}{
+			{
+				dialect:       "sqlserver",
+				table:         NewTable("users").WithSystemVersioning(),
+				expectedQuery: "SELECT [id], [name], [age] FROM [users] FOR SYSTEM_TIME AS OF @p1 WHERE [id] = @p2",
+			},
+			{
+				dialect:       "mysql",
+				table:         NewTable("users").WithSystemVersioning(),
+				expectedQuery: "SELECT `id`, `name`, `age` FROM `users` FOR SYSTEM_TIME AS OF ? WHERE `id` = ?",
+			},
+			{
+				dialect: "postgres",
+				table:   NewTable("users").WithHistory("users_history"),
+				expectedQuery: `SELECT "id", "name", "age" FROM "users_history"` +
+					` WHERE "id" = $2 AND "valid_from" <= $1 AND ("valid_to" IS NULL OR "valid_to" > $1)`,
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.dialect, func(t *testing.T) {
+				var queries []string
+				var params []interface{}
+				db, err := NewWithAdapter(mockDBAdapter{
+					QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+						queries = append(queries, q)
+						params = args
+						return newMockRows([]string{"id", "name", "age"}, []interface{}{42, "Bia", 22}), nil
+					},
+				}, test.dialect)
+				tt.AssertNoErr(t, err)
+
+				var u deletedUser
+				err = db.Find(ctx, test.table, &u, 42, AsOf(asOf))
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, u, deletedUser{ID: 42, Name: "Bia", Age: 22})
+				tt.AssertEqual(t, queries, []string{test.expectedQuery})
+				tt.AssertEqual(t, params, []interface{}{asOf, 42})
+			})
+		}
+	})
+
+	t.Run("should apply the find filter of the table", func(t *testing.T) {
+		var queries []string
+		db, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, args ...interface{}) (Rows, error) {
+				queries = append(queries, q)
+				return newMockRows([]string{"id", "name", "age"}, []interface{}{42, "Bia", 22}), nil
+			},
+		}, "sqlserver")
+		tt.AssertNoErr(t, err)
+
+		var u deletedUser
+		err = db.Find(ctx, NewTable("users").WithSystemVersioning().WithSoftDelete("deleted_at"), &u, 42, AsOf(asOf))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{
+			"SELECT [id], [name], [age] FROM [users] FOR SYSTEM_TIME AS OF @p1 WHERE ([id] = @p2) AND ([deleted_at] IS NULL)",
+		})
+	})
+
+	t.Run("should report the dialects and tables without history", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		var u deletedUser
+		err = db.Find(ctx, usersTable, &u, 42, AsOf(asOf))
+		tt.AssertErrContains(t, err, "history table", "WithHistory")
+
+		for _, dialect := range []string{"sqlite3", "tidb", "mysql", "sqlserver"} {
+			db, err = NewWithAdapter(mockDBAdapter{}, dialect)
+			tt.AssertNoErr(t, err)
+
+			// Only the tables declared as system-versioned can be used on mysql and sqlserver:
+			err = db.Find(ctx, usersTable, &u, 42, AsOf(asOf))
+			tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+		}
+
+		db, err = NewWithAdapter(mockDBAdapter{}, "tidb")
+		tt.AssertNoErr(t, err)
+
+		err = db.Find(ctx, usersTable.WithSystemVersioning(), &u, 42, AsOf(asOf))
+		tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+	})
+}
+
+func TestHistoryTriggerQuery(t *testing.T) {
+	t.Run("should build the history table and its trigger", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		query, err := db.HistoryTriggerQuery(NewTable("users").WithSchema("app").WithHistory("users_history"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `CREATE TABLE IF NOT EXISTS "app"."users_history" (LIKE "app"."users", "valid_from" TIMESTAMPTZ NOT NULL, "valid_to" TIMESTAMPTZ);
+INSERT INTO "app"."users_history" SELECT t.*, now(), NULL FROM "app"."users" t WHERE NOT EXISTS (SELECT 1 FROM "app"."users_history" h WHERE h."id" = t."id" AND h."valid_to" IS NULL);
+CREATE OR REPLACE FUNCTION "app"."users_history_fn"() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		UPDATE "app"."users_history" SET "valid_to" = now() WHERE "id" = OLD."id" AND "valid_to" IS NULL;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		INSERT INTO "app"."users_history" SELECT NEW.*, now(), NULL;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS "users_history_trigger" ON "app"."users";
+CREATE TRIGGER "users_history_trigger" AFTER INSERT OR UPDATE OR DELETE ON "app"."users" FOR EACH ROW EXECUTE PROCEDURE "app"."users_history_fn"();`)
+	})
+
+	t.Run("should report invalid inputs", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		_, err = db.HistoryTriggerQuery(usersTable)
+		tt.AssertErrContains(t, err, "users", "WithHistory")
+
+		db, err = NewWithAdapter(mockDBAdapter{}, "sqlite3")
+		tt.AssertNoErr(t, err)
+
+		_, err = db.HistoryTriggerQuery(NewTable("users").WithHistory("users_history"))
+		tt.AssertEqual(t, errors.Is(err, ErrNotSupported), true)
+	})
+}
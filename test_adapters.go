@@ -1,13 +1,18 @@
 package ksql
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ditointernet/go-assert"
 	"github.com/pkg/errors"
@@ -28,6 +33,26 @@ type user struct {
 	AttrThatShouldBeIgnored string
 }
 
+// upperCaseName implements the sql.Scanner and the driver.Valuer
+// interfaces with pointer receivers, writing its value in upper case.
+type upperCaseName string
+
+func (u *upperCaseName) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*u = upperCaseName(v)
+	case []byte:
+		*u = upperCaseName(v)
+	default:
+		return fmt.Errorf("unexpected type received to Scan: %T", value)
+	}
+	return nil
+}
+
+func (u *upperCaseName) Value() (driver.Value, error) {
+	return strings.ToUpper(string(*u)), nil
+}
+
 type address struct {
 	Street string `json:"street"`
 	Number string `json:"number"`
@@ -63,19 +88,88 @@ func RunTestsForAdapter(
 	driver string,
 	connStr string,
 	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	RunTestsForAdapterWithOptions(t, adapterName, driver, connStr, AdapterTestOptions{}, newDBAdapter)
+}
+
+// AdapterTestOptions configures the tables used by
+// `RunTestsForAdapterWithOptions()`, which are named `users`,
+// `posts`, `user_permissions`, etc. if no options are set.
+type AdapterTestOptions struct {
+	// TablePrefix is added to the name of all tables
+	// created by the tests, e.g. `ksql_users`.
+	TablePrefix string
+
+	// Schema is the schema where the tables are created,
+	// it must already exist on the database.
+	Schema string
+}
+
+// RunTestsForAdapterWithOptions works like RunTestsForAdapter but
+// allows running the tests against databases where the tables
+// with the default names can't be created or dropped, e.g. on
+// managed databases shared with other applications.
+//
+// The same options can also be passed to each of the tests
+// when running them separatedly.
+func RunTestsForAdapterWithOptions(
+	t *testing.T,
+	adapterName string,
+	driver string,
+	connStr string,
+	opts AdapterTestOptions,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
 ) {
 	t.Run(adapterName+"."+driver, func(t *testing.T) {
-		QueryTest(t, driver, connStr, newDBAdapter)
-		QueryOneTest(t, driver, connStr, newDBAdapter)
-		InsertTest(t, driver, connStr, newDBAdapter)
-		DeleteTest(t, driver, connStr, newDBAdapter)
-		UpdateTest(t, driver, connStr, newDBAdapter)
-		QueryChunksTest(t, driver, connStr, newDBAdapter)
-		TransactionTest(t, driver, connStr, newDBAdapter)
-		ScanRowsTest(t, driver, connStr, newDBAdapter)
+		QueryTest(t, driver, connStr, newDBAdapter, opts)
+		QueryOneTest(t, driver, connStr, newDBAdapter, opts)
+		InsertTest(t, driver, connStr, newDBAdapter, opts)
+		DeleteTest(t, driver, connStr, newDBAdapter, opts)
+		UpdateTest(t, driver, connStr, newDBAdapter, opts)
+		QueryChunksTest(t, driver, connStr, newDBAdapter, opts)
+		TransactionTest(t, driver, connStr, newDBAdapter, opts)
+		ScanRowsTest(t, driver, connStr, newDBAdapter, opts)
+		QueryAggregateTest(t, driver, connStr, newDBAdapter, opts)
+		CSVTest(t, driver, connStr, newDBAdapter, opts)
+		ExtensionsTest(t, driver, connStr, newDBAdapter, opts)
+		WithConnTest(t, driver, connStr, newDBAdapter)
+		HealthCheckTest(t, driver, connStr, newDBAdapter)
+		KeysTest(t, driver, connStr, newDBAdapter, opts)
+		BlobTest(t, driver, connStr, newDBAdapter, opts)
+		PrepareTest(t, driver, connStr, newDBAdapter, opts)
+		UnwrapTest(t, driver, connStr, newDBAdapter)
+		DecimalTest(t, driver, connStr, newDBAdapter, opts)
+		HstoreTest(t, driver, connStr, newDBAdapter, opts)
+		DurationTest(t, driver, connStr, newDBAdapter, opts)
+		CDCTest(t, driver, connStr, newDBAdapter, opts)
+		CreateTableTest(t, driver, connStr, newDBAdapter, opts)
 	})
 }
 
+// testTables returns the options passed to one of the tests,
+// so the default table names are used if none were passed.
+func testTables(opts []AdapterTestOptions) AdapterTestOptions {
+	if len(opts) == 0 {
+		return AdapterTestOptions{}
+	}
+	return opts[0]
+}
+
+// tableName returns the name of the input table for
+// writing the queries of the tests, e.g. `app.ksql_users`
+func (o AdapterTestOptions) tableName(name string) string {
+	name = o.TablePrefix + name
+	if o.Schema != "" {
+		name = o.Schema + "." + name
+	}
+	return name
+}
+
+// table returns the ksql.Table used by the tests for the input table
+func (o AdapterTestOptions) table(name string, idColumns ...string) Table {
+	return NewTable(o.TablePrefix+name, idColumns...).WithSchema(o.Schema)
+}
+
 // QueryTest runs all tests for making sure the Query function is
 // working for a given adapter and driver.
 func QueryTest(
@@ -83,7 +177,10 @@ func QueryTest(
 	driver string,
 	connStr string,
 	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
 ) {
+	tables := testTables(opts)
+
 	t.Run("QueryTest", func(t *testing.T) {
 		variations := []struct {
 			desc        string
@@ -101,7 +198,7 @@ func QueryTest(
 		for _, variation := range variations {
 			t.Run(variation.desc, func(t *testing.T) {
 				t.Run("using slice of structs", func(t *testing.T) {
-					err := createTables(driver, connStr)
+					err := createTables(driver, connStr, tables)
 					if err != nil {
 						t.Fatal("could not create test table!, reason:", err.Error())
 					}
@@ -113,12 +210,12 @@ func QueryTest(
 						ctx := context.Background()
 						c := newTestDB(db, driver)
 						var users []user
-						err := c.Query(ctx, &users, variation.queryPrefix+`FROM users WHERE id=1;`)
+						err := c.Query(ctx, &users, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE id=1;`)
 						tt.AssertNoErr(t, err)
 						tt.AssertEqual(t, len(users), 0)
 
 						users = []user{}
-						err = c.Query(ctx, &users, variation.queryPrefix+`FROM users WHERE id=1;`)
+						err = c.Query(ctx, &users, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE id=1;`)
 						tt.AssertNoErr(t, err)
 						tt.AssertEqual(t, len(users), 0)
 					})
@@ -127,13 +224,13 @@ func QueryTest(
 						db, closer := newDBAdapter(t)
 						defer closer.Close()
 
-						_, err := db.ExecContext(context.TODO(), `INSERT INTO users (name, age, address) VALUES ('Bia', 0, '{"country":"BR"}')`)
+						_, err := db.ExecContext(context.TODO(), `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Bia', 0, '{"country":"BR"}')`)
 						tt.AssertNoErr(t, err)
 
 						ctx := context.Background()
 						c := newTestDB(db, driver)
 						var users []user
-						err = c.Query(ctx, &users, variation.queryPrefix+`FROM users WHERE name=`+c.dialect.Placeholder(0), "Bia")
+						err = c.Query(ctx, &users, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE name=`+c.dialect.Placeholder(0), "Bia")
 
 						tt.AssertNoErr(t, err)
 						tt.AssertEqual(t, len(users), 1)
@@ -146,16 +243,16 @@ func QueryTest(
 						db, closer := newDBAdapter(t)
 						defer closer.Close()
 
-						_, err := db.ExecContext(context.TODO(), `INSERT INTO users (name, age, address) VALUES ('João Garcia', 0, '{"country":"US"}')`)
+						_, err := db.ExecContext(context.TODO(), `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('João Garcia', 0, '{"country":"US"}')`)
 						tt.AssertNoErr(t, err)
 
-						_, err = db.ExecContext(context.TODO(), `INSERT INTO users (name, age, address) VALUES ('Bia Garcia', 0, '{"country":"BR"}')`)
+						_, err = db.ExecContext(context.TODO(), `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Bia Garcia', 0, '{"country":"BR"}')`)
 						tt.AssertNoErr(t, err)
 
 						ctx := context.Background()
 						c := newTestDB(db, driver)
 						var users []user
-						err = c.Query(ctx, &users, variation.queryPrefix+`FROM users WHERE name like `+c.dialect.Placeholder(0), "% Garcia")
+						err = c.Query(ctx, &users, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE name like `+c.dialect.Placeholder(0), "% Garcia")
 
 						tt.AssertNoErr(t, err)
 						tt.AssertEqual(t, len(users), 2)
@@ -178,22 +275,22 @@ func QueryTest(
 							return
 						}
 
-						_, err := db.ExecContext(context.TODO(), `INSERT INTO users (name, age, address) VALUES ('João Ribeiro', 0, '{"country":"US"}')`)
+						_, err := db.ExecContext(context.TODO(), `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('João Ribeiro', 0, '{"country":"US"}')`)
 						tt.AssertNoErr(t, err)
 						var joao user
-						getUserByName(db, driver, &joao, "João Ribeiro")
+						getUserByName(db, tables, driver, &joao, "João Ribeiro")
 						tt.AssertNoErr(t, err)
 
-						_, err = db.ExecContext(context.TODO(), `INSERT INTO users (name, age, address) VALUES ('Bia Ribeiro', 0, '{"country":"BR"}')`)
+						_, err = db.ExecContext(context.TODO(), `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Bia Ribeiro', 0, '{"country":"BR"}')`)
 						tt.AssertNoErr(t, err)
 						var bia user
-						getUserByName(db, driver, &bia, "Bia Ribeiro")
+						getUserByName(db, tables, driver, &bia, "Bia Ribeiro")
 
-						_, err = db.ExecContext(context.TODO(), fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, bia.ID, `, 'Bia Post1')`))
+						_, err = db.ExecContext(context.TODO(), fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, bia.ID, `, 'Bia Post1')`))
 						tt.AssertNoErr(t, err)
-						_, err = db.ExecContext(context.TODO(), fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, bia.ID, `, 'Bia Post2')`))
+						_, err = db.ExecContext(context.TODO(), fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, bia.ID, `, 'Bia Post2')`))
 						tt.AssertNoErr(t, err)
-						_, err = db.ExecContext(context.TODO(), fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, joao.ID, `, 'João Post1')`))
+						_, err = db.ExecContext(context.TODO(), fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, joao.ID, `, 'João Post1')`))
 						tt.AssertNoErr(t, err)
 
 						ctx := context.Background()
@@ -208,7 +305,7 @@ func QueryTest(
 							ExtraStructThatShouldBeIgnored user
 						}
 						err = c.Query(ctx, &rows, fmt.Sprint(
-							`FROM users u JOIN posts p ON p.user_id = u.id`,
+							`FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON p.user_id = u.id`,
 							` WHERE u.name like `, c.dialect.Placeholder(0),
 							` ORDER BY u.id, p.id`,
 						), "% Ribeiro")
@@ -228,10 +325,206 @@ func QueryTest(
 						tt.AssertEqual(t, rows[2].User.Name, "Bia Ribeiro")
 						tt.AssertEqual(t, rows[2].Post.Title, "Bia Post2")
 					})
+
+					t.Run("should query extra expressions added with AddSelect", func(t *testing.T) {
+						db, closer := newDBAdapter(t)
+						defer closer.Close()
+
+						// This test only makes sense with no query prefix
+						if variation.queryPrefix != "" {
+							return
+						}
+
+						_, err := db.ExecContext(context.TODO(), `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Eva Extra', 0, '{"country":"BR"}')`)
+						tt.AssertNoErr(t, err)
+						var eva user
+						getUserByName(db, tables, driver, &eva, "Eva Extra")
+
+						_, err = db.ExecContext(context.TODO(), fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, eva.ID, `, 'Eva Post1')`))
+						tt.AssertNoErr(t, err)
+						_, err = db.ExecContext(context.TODO(), fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, eva.ID, `, 'Eva Post2')`))
+						tt.AssertNoErr(t, err)
+
+						ctx := context.Background()
+						c := newTestDB(db, driver)
+						var rows []struct {
+							ID         uint   `ksql:"id"`
+							Name       string `ksql:"name"`
+							PostsCount int    `ksql:"posts_count"`
+						}
+						err = c.Query(ctx, &rows,
+							`FROM `+tables.tableName("users")+` u WHERE name = `+c.dialect.Placeholder(0),
+							"Eva Extra",
+							AddSelect("(SELECT count(*) FROM "+tables.tableName("posts")+" p WHERE p.user_id = u.id) AS posts_count"),
+						)
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, len(rows), 1)
+						tt.AssertEqual(t, rows[0].ID, eva.ID)
+						tt.AssertEqual(t, rows[0].Name, "Eva Extra")
+						tt.AssertEqual(t, rows[0].PostsCount, 2)
+					})
+
+					t.Run("should query joined tables by alias when using WithAliasedNestedStructs", func(t *testing.T) {
+						db, closer := newDBAdapter(t)
+						defer closer.Close()
+
+						// This test only makes sense with no query prefix
+						if variation.queryPrefix != "" {
+							return
+						}
+
+						_, err := db.ExecContext(context.TODO(), `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Caio Alias', 0, '{"country":"BR"}')`)
+						tt.AssertNoErr(t, err)
+						var caio user
+						getUserByName(db, tables, driver, &caio, "Caio Alias")
+
+						_, err = db.ExecContext(context.TODO(), fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, caio.ID, `, 'Caio Post1')`))
+						tt.AssertNoErr(t, err)
+
+						ctx := context.Background()
+						c := newTestDB(db, driver)
+						WithAliasedNestedStructs()(&c)
+
+						var rows []struct {
+							User user `tablename:"u"`
+							Post post `tablename:"p"`
+						}
+						err = c.Query(ctx, &rows, fmt.Sprint(
+							`FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON p.user_id = u.id`,
+							` WHERE u.name = `, c.dialect.Placeholder(0),
+						), "Caio Alias")
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, len(rows), 1)
+						tt.AssertEqual(t, rows[0].User.ID, caio.ID)
+						tt.AssertEqual(t, rows[0].User.Name, "Caio Alias")
+						tt.AssertEqual(t, rows[0].Post.Title, "Caio Post1")
+
+						// Since the columns are matched by name the SELECT part
+						// can be written manually and in any order:
+						var row struct {
+							User user `tablename:"u"`
+							Post post `tablename:"p"`
+						}
+						err = c.QueryOne(ctx, &row, fmt.Sprint(
+							`SELECT p.title AS `, c.dialect.Escape("p.title"),
+							`, u.name AS `, c.dialect.Escape("u.name"),
+							`, u.id AS `, c.dialect.Escape("u.id"),
+							` FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON p.user_id = u.id`,
+							` WHERE u.name = `, c.dialect.Placeholder(0),
+						), "Caio Alias")
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, row.User.ID, caio.ID)
+						tt.AssertEqual(t, row.User.Name, "Caio Alias")
+						tt.AssertEqual(t, row.Post.Title, "Caio Post1")
+					})
+
+					t.Run("should query CTEs", func(t *testing.T) {
+						db, closer := newDBAdapter(t)
+						defer closer.Close()
+
+						_, err := db.ExecContext(context.TODO(), `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Rui Cte', 0, '{"country":"PT"}')`)
+						tt.AssertNoErr(t, err)
+						var rui user
+						getUserByName(db, tables, driver, &rui, "Rui Cte")
+
+						_, err = db.ExecContext(context.TODO(), fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, rui.ID, `, 'Rui Post1')`))
+						tt.AssertNoErr(t, err)
+
+						ctx := context.Background()
+						c := newTestDB(db, driver)
+
+						var users []user
+						err = c.Query(ctx, &users, fmt.Sprint(
+							`WITH ctes AS (SELECT * FROM `+tables.tableName("users")+` WHERE name = `, c.dialect.Placeholder(0), `) `,
+							variation.queryPrefix, `FROM ctes`,
+						), "Rui Cte")
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, len(users), 1)
+						tt.AssertEqual(t, users[0].ID, rui.ID)
+						tt.AssertEqual(t, users[0].Address.Country, "PT")
+
+						// Nested structs only work with the SELECT part omitted:
+						if variation.queryPrefix != "" {
+							return
+						}
+
+						var rows []struct {
+							User user `tablename:"u"`
+							Post post `tablename:"p"`
+						}
+						err = c.Query(ctx, &rows, fmt.Sprint(
+							`WITH u AS (SELECT * FROM `+tables.tableName("users")+` WHERE name = `, c.dialect.Placeholder(0), `)`,
+							` FROM u JOIN `+tables.tableName("posts")+` p ON p.user_id = u.id`,
+						), "Rui Cte")
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, len(rows), 1)
+						tt.AssertEqual(t, rows[0].User.ID, rui.ID)
+						tt.AssertEqual(t, rows[0].User.Name, "Rui Cte")
+						tt.AssertEqual(t, rows[0].Post.Title, "Rui Post1")
+					})
+
+					t.Run("should query with leading comments and explicit prefix options", func(t *testing.T) {
+						db, closer := newDBAdapter(t)
+						defer closer.Close()
+
+						// This test only makes sense with no query prefix
+						if variation.queryPrefix != "" {
+							return
+						}
+
+						_, err := db.ExecContext(context.TODO(), `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Lia Comment', 0, '{"country":"BR"}')`)
+						tt.AssertNoErr(t, err)
+
+						ctx := context.Background()
+						c := newTestDB(db, driver)
+
+						var users []user
+						err = c.Query(ctx, &users, "-- lists the users by name:\n/* FROM users */ FROM "+tables.tableName("users")+" WHERE name = "+c.dialect.Placeholder(0), "Lia Comment")
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, len(users), 1)
+						tt.AssertEqual(t, users[0].Name, "Lia Comment")
+
+						users = nil
+						err = c.Query(ctx, &users, "FROM "+tables.tableName("users")+" WHERE name = "+c.dialect.Placeholder(0), "Lia Comment", AutoSelect())
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, len(users), 1)
+						tt.AssertEqual(t, users[0].Name, "Lia Comment")
+
+						users = nil
+						err = c.Query(ctx, &users, "SELECT * FROM "+tables.tableName("users")+" WHERE name = "+c.dialect.Placeholder(0), "Lia Comment", RawQuery())
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, len(users), 1)
+						tt.AssertEqual(t, users[0].Name, "Lia Comment")
+					})
+
+					t.Run("should query only the columns passed to Columns", func(t *testing.T) {
+						db, closer := newDBAdapter(t)
+						defer closer.Close()
+
+						// This test only makes sense with no query prefix
+						if variation.queryPrefix != "" {
+							return
+						}
+
+						_, err := db.ExecContext(context.TODO(), `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Ana Columns', 27, '{"country":"BR"}')`)
+						tt.AssertNoErr(t, err)
+
+						ctx := context.Background()
+						c := newTestDB(db, driver)
+
+						var users []user
+						err = c.Query(ctx, &users, "FROM "+tables.tableName("users")+" WHERE name = "+c.dialect.Placeholder(0), "Ana Columns", Columns("id", "name"))
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, len(users), 1)
+						tt.AssertNotEqual(t, users[0].ID, uint(0))
+						tt.AssertEqual(t, users[0].Name, "Ana Columns")
+						tt.AssertEqual(t, users[0].Age, 0)
+						tt.AssertEqual(t, users[0].Address, address{})
+					})
 				})
 
 				t.Run("using slice of pointers to structs", func(t *testing.T) {
-					err := createTables(driver, connStr)
+					err := createTables(driver, connStr, tables)
 					if err != nil {
 						t.Fatal("could not create test table!, reason:", err.Error())
 					}
@@ -243,12 +536,12 @@ func QueryTest(
 						ctx := context.Background()
 						c := newTestDB(db, driver)
 						var users []*user
-						err := c.Query(ctx, &users, variation.queryPrefix+`FROM users WHERE id=1;`)
+						err := c.Query(ctx, &users, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE id=1;`)
 						tt.AssertNoErr(t, err)
 						tt.AssertEqual(t, len(users), 0)
 
 						users = []*user{}
-						err = c.Query(ctx, &users, variation.queryPrefix+`FROM users WHERE id=1;`)
+						err = c.Query(ctx, &users, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE id=1;`)
 						tt.AssertNoErr(t, err)
 						tt.AssertEqual(t, len(users), 0)
 					})
@@ -259,12 +552,12 @@ func QueryTest(
 
 						ctx := context.Background()
 
-						_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Bia', 0, '{"country":"BR"}')`)
+						_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Bia', 0, '{"country":"BR"}')`)
 						tt.AssertNoErr(t, err)
 
 						c := newTestDB(db, driver)
 						var users []*user
-						err = c.Query(ctx, &users, variation.queryPrefix+`FROM users WHERE name=`+c.dialect.Placeholder(0), "Bia")
+						err = c.Query(ctx, &users, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE name=`+c.dialect.Placeholder(0), "Bia")
 
 						tt.AssertNoErr(t, err)
 						tt.AssertEqual(t, len(users), 1)
@@ -279,15 +572,15 @@ func QueryTest(
 
 						ctx := context.Background()
 
-						_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('João Garcia', 0, '{"country":"US"}')`)
+						_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('João Garcia', 0, '{"country":"US"}')`)
 						tt.AssertNoErr(t, err)
 
-						_, err = db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Bia Garcia', 0, '{"country":"BR"}')`)
+						_, err = db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Bia Garcia', 0, '{"country":"BR"}')`)
 						tt.AssertNoErr(t, err)
 
 						c := newTestDB(db, driver)
 						var users []*user
-						err = c.Query(ctx, &users, variation.queryPrefix+`FROM users WHERE name like `+c.dialect.Placeholder(0), "% Garcia")
+						err = c.Query(ctx, &users, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE name like `+c.dialect.Placeholder(0), "% Garcia")
 
 						tt.AssertNoErr(t, err)
 						tt.AssertEqual(t, len(users), 2)
@@ -312,21 +605,21 @@ func QueryTest(
 
 						ctx := context.Background()
 
-						_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('João Ribeiro', 0, '{"country":"US"}')`)
+						_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('João Ribeiro', 0, '{"country":"US"}')`)
 						tt.AssertNoErr(t, err)
 						var joao user
-						getUserByName(db, driver, &joao, "João Ribeiro")
+						getUserByName(db, tables, driver, &joao, "João Ribeiro")
 
-						_, err = db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Bia Ribeiro', 0, '{"country":"BR"}')`)
+						_, err = db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Bia Ribeiro', 0, '{"country":"BR"}')`)
 						assert.Equal(t, nil, err)
 						var bia user
-						getUserByName(db, driver, &bia, "Bia Ribeiro")
+						getUserByName(db, tables, driver, &bia, "Bia Ribeiro")
 
-						_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, bia.ID, `, 'Bia Post1')`))
+						_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, bia.ID, `, 'Bia Post1')`))
 						tt.AssertNoErr(t, err)
-						_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, bia.ID, `, 'Bia Post2')`))
+						_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, bia.ID, `, 'Bia Post2')`))
 						tt.AssertNoErr(t, err)
-						_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, joao.ID, `, 'João Post1')`))
+						_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, joao.ID, `, 'João Post1')`))
 						tt.AssertNoErr(t, err)
 
 						c := newTestDB(db, driver)
@@ -335,7 +628,7 @@ func QueryTest(
 							Post post `tablename:"p"`
 						}
 						err = c.Query(ctx, &rows, fmt.Sprint(
-							`FROM users u JOIN posts p ON p.user_id = u.id`,
+							`FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON p.user_id = u.id`,
 							` WHERE u.name like `, c.dialect.Placeholder(0),
 							` ORDER BY u.id, p.id`,
 						), "% Ribeiro")
@@ -360,7 +653,7 @@ func QueryTest(
 		}
 
 		t.Run("testing error cases", func(t *testing.T) {
-			err := createTables(driver, connStr)
+			err := createTables(driver, connStr, tables)
 			if err != nil {
 				t.Fatal("could not create test table!, reason:", err.Error())
 			}
@@ -371,24 +664,24 @@ func QueryTest(
 
 				ctx := context.Background()
 
-				_, err := db.ExecContext(ctx, `INSERT INTO users (name, age) VALUES ('Andréa Sá', 0)`)
+				_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('Andréa Sá', 0)`)
 				tt.AssertNoErr(t, err)
 
-				_, err = db.ExecContext(ctx, `INSERT INTO users (name, age) VALUES ('Caio Sá', 0)`)
+				_, err = db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('Caio Sá', 0)`)
 				tt.AssertNoErr(t, err)
 
 				c := newTestDB(db, driver)
-				err = c.Query(ctx, &user{}, `SELECT * FROM users WHERE name like `+c.dialect.Placeholder(0), "% Sá")
+				err = c.Query(ctx, &user{}, `SELECT * FROM `+tables.tableName("users")+` WHERE name like `+c.dialect.Placeholder(0), "% Sá")
 				tt.AssertErrContains(t, err, "expected", "to be a slice", "user")
 
-				err = c.Query(ctx, []*user{}, `SELECT * FROM users WHERE name like `+c.dialect.Placeholder(0), "% Sá")
+				err = c.Query(ctx, []*user{}, `SELECT * FROM `+tables.tableName("users")+` WHERE name like `+c.dialect.Placeholder(0), "% Sá")
 				tt.AssertErrContains(t, err, "expected", "slice of structs", "user")
 
 				var i int
-				err = c.Query(ctx, &i, `SELECT * FROM users WHERE name like `+c.dialect.Placeholder(0), "% Sá")
+				err = c.Query(ctx, &i, `SELECT * FROM `+tables.tableName("users")+` WHERE name like `+c.dialect.Placeholder(0), "% Sá")
 				tt.AssertErrContains(t, err, "expected", "to be a slice", "int")
 
-				err = c.Query(ctx, &[]int{}, `SELECT * FROM users WHERE name like `+c.dialect.Placeholder(0), "% Sá")
+				err = c.Query(ctx, &[]int{}, `SELECT * FROM `+tables.tableName("users")+` WHERE name like `+c.dialect.Placeholder(0), "% Sá")
 				tt.AssertErrContains(t, err, "expected", "slice of structs", "[]int")
 			})
 
@@ -403,6 +696,37 @@ func QueryTest(
 				tt.AssertErrContains(t, err, "error running query")
 			})
 
+			t.Run("should query into a slice of maps", func(t *testing.T) {
+				err := createTables(driver, connStr, tables)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				ctx := context.Background()
+				c := newTestDB(db, driver)
+				tt.AssertNoErr(t, c.Insert(ctx, tables.table("users"), &user{Name: "Map User1"}))
+				tt.AssertNoErr(t, c.Insert(ctx, tables.table("users"), &user{Name: "Map User2"}))
+
+				var rows []map[string]interface{}
+				err = c.Query(ctx, &rows, `SELECT name FROM `+tables.tableName("users")+` WHERE name like `+c.dialect.Placeholder(0)+` ORDER BY name`, "Map User%")
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, rows, []map[string]interface{}{
+					{"name": "Map User1"},
+					{"name": "Map User2"},
+				})
+
+				var row map[string]interface{}
+				err = c.QueryOne(ctx, &row, `SELECT name FROM `+tables.tableName("users")+` WHERE name = `+c.dialect.Placeholder(0), "Map User2")
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, row, map[string]interface{}{"name": "Map User2"})
+
+				err = c.Query(ctx, &rows, `FROM `+tables.tableName("users"))
+				tt.AssertErrContains(t, err, "SELECT part", "maps")
+			})
+
 			t.Run("should report error if using nested struct and the query starts with SELECT", func(t *testing.T) {
 				db, closer := newDBAdapter(t)
 				defer closer.Close()
@@ -413,7 +737,7 @@ func QueryTest(
 					User user `tablename:"users"`
 					Post post `tablename:"posts"`
 				}
-				err := c.Query(ctx, &rows, `SELECT * FROM users u JOIN posts p ON u.id = p.user_id`)
+				err := c.Query(ctx, &rows, `SELECT * FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON u.id = p.user_id`)
 				tt.AssertErrContains(t, err, "nested struct", "feature")
 			})
 
@@ -428,7 +752,7 @@ func QueryTest(
 						Foo int `tablename:"foo"`
 					}
 					err := c.Query(ctx, &rows, fmt.Sprint(
-						`FROM users u JOIN posts p ON p.user_id = u.id`,
+						`FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON p.user_id = u.id`,
 						` WHERE u.name like `, c.dialect.Placeholder(0),
 						` ORDER BY u.id, p.id`,
 					), "% Ribeiro")
@@ -446,7 +770,7 @@ func QueryTest(
 						Foo *user `tablename:"foo"`
 					}
 					err := c.Query(ctx, &rows, fmt.Sprint(
-						`FROM users u JOIN posts p ON p.user_id = u.id`,
+						`FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON p.user_id = u.id`,
 						` WHERE u.name like `, c.dialect.Placeholder(0),
 						` ORDER BY u.id, p.id`,
 					), "% Ribeiro")
@@ -468,7 +792,7 @@ func QueryTest(
 						Attr2 int `ksql:"invalid_repeated_name"`
 					} `tablename:"posts"`
 				}
-				err := c.Query(ctx, &rows, `FROM users u JOIN posts p ON u.id = p.user_id`)
+				err := c.Query(ctx, &rows, `FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON u.id = p.user_id`)
 				tt.AssertErrContains(t, err, "same ksql tag name", "invalid_repeated_name")
 			})
 		})
@@ -482,7 +806,10 @@ func QueryOneTest(
 	driver string,
 	connStr string,
 	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
 ) {
+	tables := testTables(opts)
+
 	t.Run("QueryOne", func(t *testing.T) {
 		variations := []struct {
 			desc        string
@@ -498,7 +825,7 @@ func QueryOneTest(
 			},
 		}
 		for _, variation := range variations {
-			err := createTables(driver, connStr)
+			err := createTables(driver, connStr, tables)
 			if err != nil {
 				t.Fatal("could not create test table!, reason:", err.Error())
 			}
@@ -511,7 +838,7 @@ func QueryOneTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 					u := user{}
-					err := c.QueryOne(ctx, &u, variation.queryPrefix+`FROM users WHERE id=1;`)
+					err := c.QueryOne(ctx, &u, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE id=1;`)
 					tt.AssertEqual(t, err, ErrRecordNotFound)
 				})
 
@@ -521,12 +848,12 @@ func QueryOneTest(
 
 					ctx := context.Background()
 
-					_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Bia', 0, '{"country":"BR"}')`)
+					_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Bia', 0, '{"country":"BR"}')`)
 					tt.AssertNoErr(t, err)
 
 					c := newTestDB(db, driver)
 					u := user{}
-					err = c.QueryOne(ctx, &u, variation.queryPrefix+`FROM users WHERE name=`+c.dialect.Placeholder(0), "Bia")
+					err = c.QueryOne(ctx, &u, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE name=`+c.dialect.Placeholder(0), "Bia")
 
 					tt.AssertNoErr(t, err)
 					tt.AssertNotEqual(t, u.ID, uint(0))
@@ -542,16 +869,16 @@ func QueryOneTest(
 
 					ctx := context.Background()
 
-					_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Andréa Sá', 0, '{"country":"US"}')`)
+					_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Andréa Sá', 0, '{"country":"US"}')`)
 					tt.AssertNoErr(t, err)
 
-					_, err = db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Caio Sá', 0, '{"country":"BR"}')`)
+					_, err = db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Caio Sá', 0, '{"country":"BR"}')`)
 					tt.AssertNoErr(t, err)
 
 					c := newTestDB(db, driver)
 
 					var u user
-					err = c.QueryOne(ctx, &u, variation.queryPrefix+`FROM users WHERE name like `+c.dialect.Placeholder(0)+` ORDER BY id ASC`, "% Sá")
+					err = c.QueryOne(ctx, &u, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE name like `+c.dialect.Placeholder(0)+` ORDER BY id ASC`, "% Sá")
 					tt.AssertNoErr(t, err)
 					tt.AssertEqual(t, u.Name, "Andréa Sá")
 					tt.AssertEqual(t, u.Age, 0)
@@ -571,12 +898,12 @@ func QueryOneTest(
 
 					ctx := context.Background()
 
-					_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('João Ribeiro', 0, '{"country":"US"}')`)
+					_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('João Ribeiro', 0, '{"country":"US"}')`)
 					tt.AssertNoErr(t, err)
 					var joao user
-					getUserByName(db, driver, &joao, "João Ribeiro")
+					getUserByName(db, tables, driver, &joao, "João Ribeiro")
 
-					_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, joao.ID, `, 'João Post1')`))
+					_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, joao.ID, `, 'João Post1')`))
 					tt.AssertNoErr(t, err)
 
 					c := newTestDB(db, driver)
@@ -585,7 +912,7 @@ func QueryOneTest(
 						Post post `tablename:"p"`
 					}
 					err = c.QueryOne(ctx, &row, fmt.Sprint(
-						`FROM users u JOIN posts p ON p.user_id = u.id`,
+						`FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON p.user_id = u.id`,
 						` WHERE u.name like `, c.dialect.Placeholder(0),
 						` ORDER BY u.id, p.id`,
 					), "% Ribeiro")
@@ -601,7 +928,7 @@ func QueryOneTest(
 					defer closer.Close()
 
 					ctx := context.Background()
-					_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Count Olivia', 0, '{"country":"US"}')`)
+					_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Count Olivia', 0, '{"country":"US"}')`)
 					tt.AssertNoErr(t, err)
 
 					c := newTestDB(db, driver)
@@ -609,10 +936,32 @@ func QueryOneTest(
 					var row struct {
 						Count int `ksql:"myCount"`
 					}
-					err = c.QueryOne(ctx, &row, `SELECT count(*) as myCount FROM users WHERE name='Count Olivia'`)
+					err = c.QueryOne(ctx, &row, `SELECT count(*) as myCount FROM `+tables.tableName("users")+` WHERE name='Count Olivia'`)
 					tt.AssertNoErr(t, err)
 					tt.AssertEqual(t, row.Count, 1)
 				})
+
+				t.Run("should match values ignoring their case with EqualFold and LikeFold", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					ctx := context.Background()
+					c := newTestDB(db, driver)
+
+					u := user{Name: "Case Insensitive " + variation.desc}
+					err := c.Insert(ctx, tables.table("users"), &u)
+					tt.AssertNoErr(t, err)
+
+					var result user
+					err = c.QueryOne(ctx, &result, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE `+c.EqualFold("name", 0), "CASE insensitive "+variation.desc)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, result.ID, u.ID)
+
+					result = user{}
+					err = c.QueryOne(ctx, &result, variation.queryPrefix+`FROM `+tables.tableName("users")+` WHERE `+c.LikeFold("name", 0), "case INSENSITIVE "+variation.desc+"%")
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, result.ID, u.ID)
+				})
 			})
 		}
 
@@ -622,18 +971,18 @@ func QueryOneTest(
 
 			ctx := context.Background()
 
-			_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Andréa Sá', 0, '{"country":"US"}')`)
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Andréa Sá', 0, '{"country":"US"}')`)
 			tt.AssertNoErr(t, err)
 
-			_, err = db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Caio Sá', 0, '{"country":"BR"}')`)
+			_, err = db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Caio Sá', 0, '{"country":"BR"}')`)
 			tt.AssertNoErr(t, err)
 
 			c := newTestDB(db, driver)
 
-			err = c.QueryOne(ctx, &[]user{}, `SELECT * FROM users WHERE name like `+c.dialect.Placeholder(0), "% Sá")
+			err = c.QueryOne(ctx, &[]user{}, `SELECT * FROM `+tables.tableName("users")+` WHERE name like `+c.dialect.Placeholder(0), "% Sá")
 			tt.AssertErrContains(t, err, "pointer to struct")
 
-			err = c.QueryOne(ctx, user{}, `SELECT * FROM users WHERE name like `+c.dialect.Placeholder(0), "% Sá")
+			err = c.QueryOne(ctx, user{}, `SELECT * FROM `+tables.tableName("users")+` WHERE name like `+c.dialect.Placeholder(0), "% Sá")
 			tt.AssertErrContains(t, err, "pointer to struct")
 		})
 
@@ -644,7 +993,7 @@ func QueryOneTest(
 			ctx := context.Background()
 			c := newTestDB(db, driver)
 			var u *user
-			err := c.QueryOne(ctx, u, `SELECT * FROM users`)
+			err := c.QueryOne(ctx, u, `SELECT * FROM `+tables.tableName("users"))
 			tt.AssertErrContains(t, err, "expected a valid pointer", "received a nil pointer")
 		})
 
@@ -659,6 +1008,52 @@ func QueryOneTest(
 			tt.AssertErrContains(t, err, "error running query")
 		})
 
+		t.Run("should scan integers into attributes based on bool", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			ctx := context.Background()
+			c := newTestDB(db, driver)
+
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('Bool Bianca', 1)`)
+			tt.AssertNoErr(t, err)
+			_, err = db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('Bool Bruno', 0)`)
+			tt.AssertNoErr(t, err)
+
+			type Flag bool
+			var rows []struct {
+				Active   Flag  `ksql:"active"`
+				Verified *bool `ksql:"verified"`
+			}
+			err = c.Query(ctx, &rows, `SELECT age AS active, age AS verified FROM `+tables.tableName("users")+` WHERE name LIKE 'Bool %' ORDER BY name`)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(rows), 2)
+			tt.AssertEqual(t, rows[0].Active, Flag(true))
+			tt.AssertEqual(t, *rows[0].Verified, true)
+			tt.AssertEqual(t, rows[1].Active, Flag(false))
+			tt.AssertEqual(t, *rows[1].Verified, false)
+		})
+
+		t.Run("should remove the padding of attributes with the trim modifier", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			ctx := context.Background()
+			c := newTestDB(db, driver)
+
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('  Padded Paula  ', 0)`)
+			tt.AssertNoErr(t, err)
+
+			var row struct {
+				Name     string  `ksql:"name,trim"`
+				Optional *string `ksql:"optional,trim"`
+			}
+			err = c.QueryOne(ctx, &row, `SELECT name, NULL AS optional FROM `+tables.tableName("users")+` WHERE name = '  Padded Paula  '`)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, row.Name, "  Padded Paula")
+			tt.AssertEqual(t, row.Optional, (*string)(nil))
+		})
+
 		t.Run("should report error if using nested struct and the query starts with SELECT", func(t *testing.T) {
 			db, closer := newDBAdapter(t)
 			defer closer.Close()
@@ -669,16 +1064,47 @@ func QueryOneTest(
 				User user `tablename:"users"`
 				Post post `tablename:"posts"`
 			}
-			err := c.QueryOne(ctx, &row, `SELECT * FROM users u JOIN posts p ON u.id = p.user_id LIMIT 1`)
+			err := c.QueryOne(ctx, &row, `SELECT * FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON u.id = p.user_id LIMIT 1`)
 			tt.AssertErrContains(t, err, "nested struct", "feature")
 		})
 
+		t.Run("should query nested structs with a SELECT part if all columns are aliased with the tablename", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			ctx := context.Background()
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Dora Aliased', 0, '{"country":"US"}')`)
+			tt.AssertNoErr(t, err)
+			var dora user
+			getUserByName(db, tables, driver, &dora, "Dora Aliased")
+
+			_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, dora.ID, `, 'Dora Post1')`))
+			tt.AssertNoErr(t, err)
+
+			c := newTestDB(db, driver)
+			var row struct {
+				User user `tablename:"u"`
+				Post post `tablename:"p"`
+			}
+			err = c.QueryOne(ctx, &row, fmt.Sprint(
+				`SELECT u.id AS `, c.dialect.Escape("u.id"),
+				`, UPPER(u.name) AS `, c.dialect.Escape("u.name"),
+				`, p.title AS `, c.dialect.Escape("p.title"),
+				` FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON p.user_id = u.id`,
+				` WHERE u.name = `, c.dialect.Placeholder(0),
+			), "Dora Aliased")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, row.User.ID, dora.ID)
+			tt.AssertEqual(t, row.User.Name, "DORA ALIASED")
+			tt.AssertEqual(t, row.Post.Title, "Dora Post1")
+		})
+
 		t.Run("should report error if a private field has a ksql tag", func(t *testing.T) {
 			db, closer := newDBAdapter(t)
 			defer closer.Close()
 
 			ctx := context.Background()
-			_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Olivia', 0, '{"country":"US"}')`)
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age, address) VALUES ('Olivia', 0, '{"country":"US"}')`)
 			tt.AssertNoErr(t, err)
 
 			c := newTestDB(db, driver)
@@ -686,7 +1112,7 @@ func QueryOneTest(
 			var row struct {
 				count int `ksql:"my_count"`
 			}
-			err = c.QueryOne(ctx, &row, `SELECT count(*) as my_count FROM users`)
+			err = c.QueryOne(ctx, &row, `SELECT count(*) as my_count FROM `+tables.tableName("users"))
 			tt.AssertErrContains(t, err, "unexported", "my_count")
 		})
 	})
@@ -699,11 +1125,14 @@ func InsertTest(
 	driver string,
 	connStr string,
 	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
 ) {
+	tables := testTables(opts)
+
 	t.Run("Insert", func(t *testing.T) {
 		t.Run("success cases", func(t *testing.T) {
 			t.Run("single primary key tables", func(t *testing.T) {
-				err := createTables(driver, connStr)
+				err := createTables(driver, connStr, tables)
 				if err != nil {
 					t.Fatal("could not create test table!, reason:", err.Error())
 				}
@@ -722,12 +1151,12 @@ func InsertTest(
 						},
 					}
 
-					err := c.Insert(ctx, usersTable, &u)
+					err := c.Insert(ctx, tables.table("users"), &u)
 					assert.Equal(t, nil, err)
 					assert.NotEqual(t, 0, u.ID)
 
 					result := user{}
-					err = getUserByID(c.db, c.dialect, &result, u.ID)
+					err = getUserByID(c.db, tables, c.dialect, &result, u.ID)
 					assert.Equal(t, nil, err)
 
 					assert.Equal(t, u.Name, result.Name)
@@ -740,7 +1169,7 @@ func InsertTest(
 					}
 
 					// Using columns "id" and "name" as IDs:
-					table := NewTable("users", "id", "name")
+					table := tables.table("users", "id", "name")
 
 					db, closer := newDBAdapter(t)
 					defer closer.Close()
@@ -762,7 +1191,7 @@ func InsertTest(
 					assert.Equal(t, uint(0), u.ID)
 
 					result := user{}
-					err = getUserByName(c.db, driver, &result, "No ID returned")
+					err = getUserByName(c.db, tables, driver, &result, "No ID returned")
 					assert.Equal(t, nil, err)
 
 					assert.Equal(t, u.Age, result.Age)
@@ -775,7 +1204,7 @@ func InsertTest(
 
 					ctx := context.Background()
 					c := newTestDB(db, driver)
-					err = c.Insert(ctx, usersTable, &struct {
+					err = c.Insert(ctx, tables.table("users"), &struct {
 						ID      int                    `ksql:"id"`
 						Name    string                 `ksql:"name"`
 						Address map[string]interface{} `ksql:"address,json"`
@@ -783,56 +1212,277 @@ func InsertTest(
 					assert.Equal(t, nil, err)
 				})
 
-				t.Run("should work with preset IDs", func(t *testing.T) {
+				t.Run("should round-trip slices of structs with the json modifier", func(t *testing.T) {
 					db, closer := newDBAdapter(t)
 					defer closer.Close()
 
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					usersByName := NewTable("users", "name")
+					type userWithAddresses struct {
+						ID        uint      `ksql:"id"`
+						Name      string    `ksql:"name"`
+						Addresses []address `ksql:"address,json"`
+					}
 
-					err = c.Insert(ctx, usersByName, &struct {
-						Name string `ksql:"name"`
-						Age  int    `ksql:"age"`
-					}{Name: "Preset Name", Age: 5455})
-					assert.Equal(t, nil, err)
+					for _, addresses := range [][]address{
+						{{City: "city1"}, {City: "city2", Country: "country2"}},
+						{},
+						nil,
+					} {
+						u := userWithAddresses{Name: "User With Addresses", Addresses: addresses}
+						err := c.Insert(ctx, tables.table("users"), &u)
+						tt.AssertNoErr(t, err)
 
-					var inserted user
-					err := getUserByName(db, driver, &inserted, "Preset Name")
-					assert.Equal(t, nil, err)
-					assert.Equal(t, 5455, inserted.Age)
-				})
-			})
+						var result userWithAddresses
+						err = c.QueryOne(ctx, &result, `FROM `+tables.tableName("users")+` WHERE id = `+c.dialect.Placeholder(0), u.ID)
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, result.Addresses, addresses)
+						tt.AssertEqual(t, result.Addresses == nil, addresses == nil)
+					}
 
-			t.Run("composite key tables", func(t *testing.T) {
-				err := createTables(driver, connStr)
-				if err != nil {
-					t.Fatal("could not create test table!, reason:", err.Error())
-				}
+					// Nil slices should be saved as the JSON `null` by default:
+					var nullCount struct {
+						Count int `ksql:"c"`
+					}
+					err := c.QueryOne(ctx, &nullCount, `SELECT count(*) AS c FROM `+tables.tableName("users")+` WHERE name = 'User With Addresses' AND address IS NULL`)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, nullCount.Count, 0)
+				})
 
-				t.Run("should insert in composite key tables correctly", func(t *testing.T) {
+				t.Run("should save nil slices as NULL with the nilasnull modifier", func(t *testing.T) {
 					db, closer := newDBAdapter(t)
 					defer closer.Close()
 
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					table := NewTable("user_permissions", "id", "user_id", "perm_id")
-					err = c.Insert(ctx, table, &userPermission{
-						UserID: 1,
-						PermID: 42,
-					})
-					tt.AssertNoErr(t, err)
+					type userWithAddresses struct {
+						ID        uint      `ksql:"id"`
+						Name      string    `ksql:"name"`
+						Addresses []address `ksql:"address,json,nilasnull"`
+					}
+
+					for _, addresses := range [][]address{
+						{{City: "city1"}},
+						{},
+						nil,
+					} {
+						u := userWithAddresses{Name: "User With Null Addresses", Addresses: addresses}
+						err := c.Insert(ctx, tables.table("users"), &u)
+						tt.AssertNoErr(t, err)
 
-					userPerms, err := getUserPermissionsByUser(db, driver, 1)
+						var result userWithAddresses
+						err = c.QueryOne(ctx, &result, `FROM `+tables.tableName("users")+` WHERE id = `+c.dialect.Placeholder(0), u.ID)
+						tt.AssertNoErr(t, err)
+						tt.AssertEqual(t, result.Addresses, addresses)
+						tt.AssertEqual(t, result.Addresses == nil, addresses == nil)
+					}
+
+					var nullCount struct {
+						Count int `ksql:"c"`
+					}
+					err := c.QueryOne(ctx, &nullCount, `SELECT count(*) AS c FROM `+tables.tableName("users")+` WHERE name = 'User With Null Addresses' AND address IS NULL`)
 					tt.AssertNoErr(t, err)
-					tt.AssertEqual(t, len(userPerms), 1)
-					tt.AssertEqual(t, userPerms[0].UserID, 1)
-					tt.AssertEqual(t, userPerms[0].PermID, 42)
+					tt.AssertEqual(t, nullCount.Count, 1)
 				})
 
-				t.Run("should accept partially provided values for composite key tables", func(t *testing.T) {
+				t.Run("should work with preset IDs", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					ctx := context.Background()
+					c := newTestDB(db, driver)
+
+					usersByName := tables.table("users", "name")
+
+					err = c.Insert(ctx, usersByName, &struct {
+						Name string `ksql:"name"`
+						Age  int    `ksql:"age"`
+					}{Name: "Preset Name", Age: 5455})
+					assert.Equal(t, nil, err)
+
+					var inserted user
+					err := getUserByName(db, tables, driver, &inserted, "Preset Name")
+					assert.Equal(t, nil, err)
+					assert.Equal(t, 5455, inserted.Age)
+				})
+
+				t.Run("should use the default=<literal> values for unset attributes", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					ctx := context.Background()
+					c := newTestDB(db, driver)
+
+					u := struct {
+						ID   uint   `ksql:"id"`
+						Name string `ksql:"name,default=Default Name"`
+						Age  int    `ksql:"age,default=18"`
+					}{}
+					err = c.Insert(ctx, tables.table("users"), &u)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, u.Name, "Default Name")
+					tt.AssertEqual(t, u.Age, 18)
+
+					var inserted user
+					err := getUserByID(db, tables, c.dialect, &inserted, u.ID)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, inserted.Name, "Default Name")
+					tt.AssertEqual(t, inserted.Age, 18)
+				})
+
+				t.Run("should not use the default values for non-nil pointers to zero values", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					ctx := context.Background()
+					c := newTestDB(db, driver)
+
+					zero := 0
+					u := struct {
+						ID   uint   `ksql:"id"`
+						Name string `ksql:"name"`
+						Age  *int   `ksql:"age,default=18"`
+					}{Name: "Intentional Zero", Age: &zero}
+					err = c.Insert(ctx, tables.table("users"), &u)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, *u.Age, 0)
+
+					var inserted user
+					err := getUserByID(db, tables, c.dialect, &inserted, u.ID)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, inserted.Age, 0)
+				})
+
+				t.Run("should omit unset attributes using the default modifier", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					ctx := context.Background()
+					c := newTestDB(db, driver)
+
+					u := struct {
+						ID   uint   `ksql:"id"`
+						Name string `ksql:"name"`
+						Age  int    `ksql:"age,default"`
+					}{Name: "Database Default"}
+					err = c.Insert(ctx, tables.table("users"), &u)
+					tt.AssertNoErr(t, err)
+
+					var inserted struct {
+						Age *int `ksql:"age"`
+					}
+					err := c.QueryOne(ctx, &inserted, `SELECT age FROM `+tables.tableName("users")+` WHERE id = `+c.dialect.Placeholder(0), u.ID)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, inserted.Age, (*int)(nil))
+				})
+
+				t.Run("should support custom Scanner and Valuer types with modifiers", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					ctx := context.Background()
+					c := newTestDB(db, driver)
+
+					type customUser struct {
+						ID   uint          `ksql:"id"`
+						Name upperCaseName `ksql:"name,default=anonymous"`
+					}
+
+					u := customUser{Name: "custom name"}
+					err = c.Insert(ctx, tables.table("users"), &u)
+					tt.AssertNoErr(t, err)
+
+					anonymous := customUser{}
+					err = c.Insert(ctx, tables.table("users"), &anonymous)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, anonymous.Name, upperCaseName("anonymous"))
+
+					var result customUser
+					err = c.Find(ctx, tables.table("users"), &result, u.ID)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, result.Name, upperCaseName("CUSTOM NAME"))
+
+					err = c.Find(ctx, tables.table("users"), &result, anonymous.ID)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, result.Name, upperCaseName("ANONYMOUS"))
+				})
+
+				t.Run("should not write generated columns", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					ctx := context.Background()
+					c := newTestDB(db, driver)
+
+					createQuery := map[string]string{
+						"sqlite3":   `CREATE TABLE ` + tables.tableName("generated_test") + ` (id INTEGER PRIMARY KEY, age INTEGER, double_age INTEGER GENERATED ALWAYS AS (age * 2) STORED)`,
+						"postgres":  `CREATE TABLE ` + tables.tableName("generated_test") + ` (id serial PRIMARY KEY, age INT, double_age INT GENERATED ALWAYS AS (age * 2) STORED)`,
+						"mysql":     `CREATE TABLE ` + tables.tableName("generated_test") + ` (id INT AUTO_INCREMENT PRIMARY KEY, age INT, double_age INT AS (age * 2) STORED)`,
+						"sqlserver": `CREATE TABLE ` + tables.tableName("generated_test") + ` (id INT IDENTITY(1,1) PRIMARY KEY, age INT, double_age AS (age * 2))`,
+					}[driver]
+
+					db.ExecContext(ctx, `DROP TABLE `+tables.tableName("generated_test"))
+					_, err := db.ExecContext(ctx, createQuery)
+					tt.AssertNoErr(t, err)
+
+					type generatedRecord struct {
+						ID        int `ksql:"id"`
+						Age       int `ksql:"age"`
+						DoubleAge int `ksql:"double_age,generated"`
+					}
+					generatedTable := tables.table("generated_test")
+
+					record := generatedRecord{Age: 21, DoubleAge: 1}
+					err = c.Insert(ctx, generatedTable, &record)
+					tt.AssertNoErr(t, err)
+
+					if supportedDialects[driver].InsertMethod() != insertWithLastInsertID {
+						// The generated columns are refreshed with RETURNING or OUTPUT:
+						tt.AssertEqual(t, record.DoubleAge, 42)
+					}
+
+					record.Age = 30
+					err = c.Patch(ctx, generatedTable, &record)
+					tt.AssertNoErr(t, err)
+
+					var result generatedRecord
+					err = c.Find(ctx, generatedTable, &result, record.ID)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, result.Age, 30)
+					tt.AssertEqual(t, result.DoubleAge, 60)
+				})
+			})
+
+			t.Run("composite key tables", func(t *testing.T) {
+				err := createTables(driver, connStr, tables)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				t.Run("should insert in composite key tables correctly", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					ctx := context.Background()
+					c := newTestDB(db, driver)
+
+					table := tables.table("user_permissions", "id", "user_id", "perm_id")
+					err = c.Insert(ctx, table, &userPermission{
+						UserID: 1,
+						PermID: 42,
+					})
+					tt.AssertNoErr(t, err)
+
+					userPerms, err := getUserPermissionsByUser(db, tables, driver, 1)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, len(userPerms), 1)
+					tt.AssertEqual(t, userPerms[0].UserID, 1)
+					tt.AssertEqual(t, userPerms[0].PermID, 42)
+				})
+
+				t.Run("should accept partially provided values for composite key tables", func(t *testing.T) {
 					db, closer := newDBAdapter(t)
 					defer closer.Close()
 
@@ -841,7 +1491,7 @@ func InsertTest(
 
 					// Table defined with 3 values, but we'll provide only 2,
 					// the third will be generated for the purposes of this test:
-					table := NewTable("user_permissions", "id", "user_id", "perm_id")
+					table := tables.table("user_permissions", "id", "user_id", "perm_id")
 					permission := userPermission{
 						UserID: 2,
 						PermID: 42,
@@ -849,7 +1499,7 @@ func InsertTest(
 					err = c.Insert(ctx, table, &permission)
 					tt.AssertNoErr(t, err)
 
-					userPerms, err := getUserPermissionsByUser(db, driver, 2)
+					userPerms, err := getUserPermissionsByUser(db, tables, driver, 2)
 					tt.AssertNoErr(t, err)
 
 					// Should retrieve the generated ID from the database,
@@ -868,11 +1518,50 @@ func InsertTest(
 						tt.AssertEqual(t, userPerms[0].PermID, 42)
 					}
 				})
+
+				t.Run("should retrieve the IDs with RETURNING on sqlite", func(t *testing.T) {
+					if driver != "sqlite3" {
+						return
+					}
+
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					ctx := context.Background()
+					c := newTestDB(db, driver)
+					WithSQLiteReturning()(&c)
+
+					db.ExecContext(ctx, `DROP TABLE `+tables.tableName("without_rowid"))
+					_, err := db.ExecContext(ctx, `CREATE TABLE `+tables.tableName("without_rowid")+` (
+						id TEXT DEFAULT (lower(hex(randomblob(8)))),
+						user_id INTEGER,
+						name TEXT,
+						PRIMARY KEY (id, user_id)
+					) WITHOUT ROWID`)
+					tt.AssertNoErr(t, err)
+
+					type withoutRowID struct {
+						ID     string `ksql:"id"`
+						UserID int    `ksql:"user_id"`
+						Name   string `ksql:"name"`
+					}
+					table := tables.table("without_rowid", "id", "user_id")
+
+					record := withoutRowID{UserID: 1, Name: "Bia"}
+					err = c.Insert(ctx, table, &record)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, len(record.ID), 16)
+
+					var result withoutRowID
+					err = c.QueryOne(ctx, &result, `FROM `+tables.tableName("without_rowid")+` WHERE id = ?`, record.ID)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, result, record)
+				})
 			})
 		})
 
 		t.Run("testing error cases", func(t *testing.T) {
-			err := createTables(driver, connStr)
+			err := createTables(driver, connStr, tables)
 			if err != nil {
 				t.Fatal("could not create test table!, reason:", err.Error())
 			}
@@ -884,13 +1573,13 @@ func InsertTest(
 				ctx := context.Background()
 				c := newTestDB(db, driver)
 
-				err = c.Insert(ctx, usersTable, "foo")
+				err = c.Insert(ctx, tables.table("users"), "foo")
 				assert.NotEqual(t, nil, err)
 
-				err = c.Insert(ctx, usersTable, nullable.String("foo"))
+				err = c.Insert(ctx, tables.table("users"), nullable.String("foo"))
 				assert.NotEqual(t, nil, err)
 
-				err = c.Insert(ctx, usersTable, map[string]interface{}{
+				err = c.Insert(ctx, tables.table("users"), map[string]interface{}{
 					"name": "foo",
 					"age":  12,
 				})
@@ -900,11 +1589,11 @@ func InsertTest(
 					&user{Name: "foo", Age: 22},
 					&user{Name: "bar", Age: 32},
 				}
-				err = c.Insert(ctx, usersTable, cantInsertSlice)
+				err = c.Insert(ctx, tables.table("users"), cantInsertSlice)
 				assert.NotEqual(t, nil, err)
 
 				// We might want to support this in the future, but not for now:
-				err = c.Insert(ctx, usersTable, user{Name: "not a ptr to user", Age: 42})
+				err = c.Insert(ctx, tables.table("users"), user{Name: "not a ptr to user", Age: 42})
 				assert.NotEqual(t, nil, err)
 			})
 
@@ -918,7 +1607,7 @@ func InsertTest(
 				// This is an invalid value:
 				c.dialect = brokenDialect{}
 
-				err = c.Insert(ctx, usersTable, &user{Name: "foo"})
+				err = c.Insert(ctx, tables.table("users"), &user{Name: "foo"})
 				assert.NotEqual(t, nil, err)
 			})
 
@@ -930,7 +1619,7 @@ func InsertTest(
 				c := newTestDB(db, driver)
 
 				var u *user
-				err := c.Insert(ctx, usersTable, u)
+				err := c.Insert(ctx, tables.table("users"), u)
 				assert.NotEqual(t, nil, err)
 			})
 
@@ -941,7 +1630,7 @@ func InsertTest(
 				ctx := context.Background()
 				c := newTestDB(db, driver)
 
-				err := c.Insert(ctx, NewTable("users", ""), &user{Name: "fake-name"})
+				err := c.Insert(ctx, tables.table("users", ""), &user{Name: "fake-name"})
 				tt.AssertErrContains(t, err, "ksql.Table", "ID", "empty string")
 			})
 
@@ -963,7 +1652,7 @@ func InsertTest(
 				ctx := context.Background()
 				c := newTestDB(db, driver)
 
-				err = c.Insert(ctx, usersTable, &struct {
+				err = c.Insert(ctx, tables.table("users"), &struct {
 					ID                string `ksql:"id"`
 					NonExistingColumn int    `ksql:"non_existing"`
 					Name              string `ksql:"name"`
@@ -981,7 +1670,7 @@ func InsertTest(
 				ctx := context.Background()
 				c := newTestDB(db, driver)
 
-				brokenTable := NewTable("users", "non_existing_id")
+				brokenTable := tables.table("users", "non_existing_id")
 
 				_ = c.Insert(ctx, brokenTable, &struct {
 					ID   string `ksql:"non_existing_id"`
@@ -997,14 +1686,14 @@ func InsertTest(
 				ctx := context.Background()
 				c := newTestDB(db, driver)
 
-				err = c.Insert(ctx, usersTable, &struct {
+				err = c.Insert(ctx, tables.table("users"), &struct {
 					Age  int    `ksql:"age"`
 					Name string `ksql:"name"`
 				}{Age: 42, Name: "Inserted With no ID"})
 				assert.Equal(t, nil, err)
 
 				var u user
-				err = getUserByName(db, driver, &u, "Inserted With no ID")
+				err = getUserByName(db, tables, driver, &u, "Inserted With no ID")
 				assert.Equal(t, nil, err)
 				assert.NotEqual(t, uint(0), u.ID)
 				assert.Equal(t, 42, u.Age)
@@ -1038,9 +1727,12 @@ func DeleteTest(
 	driver string,
 	connStr string,
 	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
 ) {
+	tables := testTables(opts)
+
 	t.Run("Delete", func(t *testing.T) {
-		err := createTables(driver, connStr)
+		err := createTables(driver, connStr, tables)
 		if err != nil {
 			t.Fatal("could not create test table!, reason:", err.Error())
 		}
@@ -1082,12 +1774,12 @@ func DeleteTest(
 						Name: "Fernanda",
 					}
 
-					err := c.Insert(ctx, usersTable, &u1)
+					err := c.Insert(ctx, tables.table("users"), &u1)
 					assert.Equal(t, nil, err)
 					assert.NotEqual(t, uint(0), u1.ID)
 
 					result := user{}
-					err = getUserByID(c.db, c.dialect, &result, u1.ID)
+					err = getUserByID(c.db, tables, c.dialect, &result, u1.ID)
 					assert.Equal(t, nil, err)
 					assert.Equal(t, u1.ID, result.ID)
 
@@ -1095,24 +1787,24 @@ func DeleteTest(
 						Name: "Won't be deleted",
 					}
 
-					err = c.Insert(ctx, usersTable, &u2)
+					err = c.Insert(ctx, tables.table("users"), &u2)
 					assert.Equal(t, nil, err)
 					assert.NotEqual(t, uint(0), u2.ID)
 
 					result = user{}
-					err = getUserByID(c.db, c.dialect, &result, u2.ID)
+					err = getUserByID(c.db, tables, c.dialect, &result, u2.ID)
 					assert.Equal(t, nil, err)
 					assert.Equal(t, u2.ID, result.ID)
 
-					err = c.Delete(ctx, usersTable, test.deletionKeyForUser(u1))
+					err = c.Delete(ctx, tables.table("users"), test.deletionKeyForUser(u1))
 					assert.Equal(t, nil, err)
 
 					result = user{}
-					err = getUserByID(c.db, c.dialect, &result, u1.ID)
+					err = getUserByID(c.db, tables, c.dialect, &result, u1.ID)
 					assert.Equal(t, sql.ErrNoRows, err)
 
 					result = user{}
-					err = getUserByID(c.db, c.dialect, &result, u2.ID)
+					err = getUserByID(c.db, tables, c.dialect, &result, u2.ID)
 					assert.Equal(t, nil, err)
 
 					assert.NotEqual(t, uint(0), result.ID)
@@ -1134,7 +1826,7 @@ func DeleteTest(
 					UserID: 1,
 					PermID: 44,
 				}
-				err = c.Insert(ctx, NewTable("user_permissions", "id"), &p0)
+				err = c.Insert(ctx, tables.table("user_permissions", "id"), &p0)
 				tt.AssertNoErr(t, err)
 				tt.AssertNotEqual(t, p0.ID, 0)
 
@@ -1142,13 +1834,13 @@ func DeleteTest(
 					UserID: 1,
 					PermID: 42,
 				}
-				err = c.Insert(ctx, NewTable("user_permissions", "id"), &p1)
+				err = c.Insert(ctx, tables.table("user_permissions", "id"), &p1)
 				tt.AssertNoErr(t, err)
 
-				err = c.Delete(ctx, userPermissionsTable, p1)
+				err = c.Delete(ctx, tables.table("user_permissions", "user_id", "perm_id"), p1)
 				tt.AssertNoErr(t, err)
 
-				userPerms, err := getUserPermissionsByUser(db, driver, 1)
+				userPerms, err := getUserPermissionsByUser(db, tables, driver, 1)
 				tt.AssertNoErr(t, err)
 				tt.AssertEqual(t, len(userPerms), 1)
 				tt.AssertEqual(t, userPerms[0].UserID, 1)
@@ -1167,7 +1859,7 @@ func DeleteTest(
 					UserID: 2,
 					PermID: 44,
 				}
-				err = c.Insert(ctx, NewTable("user_permissions", "id"), &p0)
+				err = c.Insert(ctx, tables.table("user_permissions", "id"), &p0)
 				tt.AssertNoErr(t, err)
 				tt.AssertNotEqual(t, p0.ID, 0)
 
@@ -1175,16 +1867,16 @@ func DeleteTest(
 					UserID: 2,
 					PermID: 42,
 				}
-				err = c.Insert(ctx, NewTable("user_permissions", "id"), &p1)
+				err = c.Insert(ctx, tables.table("user_permissions", "id"), &p1)
 				tt.AssertNoErr(t, err)
 
-				err = c.Delete(ctx, userPermissionsTable, map[string]interface{}{
+				err = c.Delete(ctx, tables.table("user_permissions", "user_id", "perm_id"), map[string]interface{}{
 					"user_id": 2,
 					"perm_id": 42,
 				})
 				tt.AssertNoErr(t, err)
 
-				userPerms, err := getUserPermissionsByUser(db, driver, 2)
+				userPerms, err := getUserPermissionsByUser(db, tables, driver, 2)
 				tt.AssertNoErr(t, err)
 				tt.AssertEqual(t, len(userPerms), 1)
 				tt.AssertEqual(t, userPerms[0].UserID, 2)
@@ -1199,7 +1891,7 @@ func DeleteTest(
 			ctx := context.Background()
 			c := newTestDB(db, driver)
 
-			err = c.Delete(ctx, usersTable, 4200)
+			err = c.Delete(ctx, tables.table("users"), 4200)
 			assert.Equal(t, ErrRecordNotFound, err)
 		})
 
@@ -1211,7 +1903,7 @@ func DeleteTest(
 			c := newTestDB(db, driver)
 
 			var u *user
-			err := c.Delete(ctx, usersTable, u)
+			err := c.Delete(ctx, tables.table("users"), u)
 			assert.NotEqual(t, nil, err)
 		})
 
@@ -1224,7 +1916,7 @@ func DeleteTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					err := c.Delete(ctx, NewTable("users", "id"), &struct {
+					err := c.Delete(ctx, tables.table("users", "id"), &struct {
 						// Missing ID
 						Name string `ksql:"name"`
 					}{Name: "fake-name"})
@@ -1238,7 +1930,7 @@ func DeleteTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					err := c.Delete(ctx, NewTable("users", "id"), &struct {
+					err := c.Delete(ctx, tables.table("users", "id"), &struct {
 						// Null ID
 						ID   *int   `ksql:"id"`
 						Name string `ksql:"name"`
@@ -1253,7 +1945,7 @@ func DeleteTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					err := c.Delete(ctx, NewTable("users", "id"), &struct {
+					err := c.Delete(ctx, tables.table("users", "id"), &struct {
 						// Uninitialized ID
 						ID   int    `ksql:"id"`
 						Name string `ksql:"name"`
@@ -1270,7 +1962,7 @@ func DeleteTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					err := c.Delete(ctx, NewTable("user_permissions", "user_id", "perm_id"), map[string]interface{}{
+					err := c.Delete(ctx, tables.table("user_permissions", "user_id", "perm_id"), map[string]interface{}{
 						// Missing PermID
 						"user_id": 1,
 						"name":    "fake-name",
@@ -1285,7 +1977,7 @@ func DeleteTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					err := c.Delete(ctx, NewTable("user_permissions", "user_id", "perm_id"), map[string]interface{}{
+					err := c.Delete(ctx, tables.table("user_permissions", "user_id", "perm_id"), map[string]interface{}{
 						// Null Perm ID
 						"user_id": 1,
 						"perm_id": nil,
@@ -1301,7 +1993,7 @@ func DeleteTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					err := c.Delete(ctx, NewTable("user_permissions", "user_id", "perm_id"), map[string]interface{}{
+					err := c.Delete(ctx, tables.table("user_permissions", "user_id", "perm_id"), map[string]interface{}{
 						// Zero Perm ID
 						"user_id": 1,
 						"perm_id": 0,
@@ -1319,7 +2011,7 @@ func DeleteTest(
 			ctx := context.Background()
 			c := newTestDB(db, driver)
 
-			err := c.Delete(ctx, NewTable("users", ""), &user{ID: 42, Name: "fake-name"})
+			err := c.Delete(ctx, tables.table("users", ""), &user{ID: 42, Name: "fake-name"})
 			tt.AssertErrContains(t, err, "ksql.Table", "ID", "empty string")
 		})
 
@@ -1343,9 +2035,12 @@ func UpdateTest(
 	driver string,
 	connStr string,
 	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
 ) {
+	tables := testTables(opts)
+
 	t.Run("Update", func(t *testing.T) {
-		err := createTables(driver, connStr)
+		err := createTables(driver, connStr, tables)
 		if err != nil {
 			t.Fatal("could not create test table!, reason:", err.Error())
 		}
@@ -1360,21 +2055,21 @@ func UpdateTest(
 			u := user{
 				Name: "Letícia",
 			}
-			_, err := db.ExecContext(ctx, `INSERT INTO users (name, age) VALUES ('Letícia', 0)`)
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('Letícia', 0)`)
 			assert.Equal(t, nil, err)
 
-			err = getUserByName(db, driver, &u, "Letícia")
+			err = getUserByName(db, tables, driver, &u, "Letícia")
 			assert.Equal(t, nil, err)
 			assert.NotEqual(t, uint(0), u.ID)
 
-			err = c.Update(ctx, usersTable, user{
+			err = c.Update(ctx, tables.table("users"), user{
 				ID:   u.ID,
 				Name: "Thayane",
 			})
 			assert.Equal(t, nil, err)
 
 			var result user
-			err = getUserByID(c.db, c.dialect, &result, u.ID)
+			err = getUserByID(c.db, tables, c.dialect, &result, u.ID)
 			assert.Equal(t, nil, err)
 			assert.Equal(t, "Thayane", result.Name)
 		})
@@ -1389,21 +2084,21 @@ func UpdateTest(
 			u := user{
 				Name: "Letícia",
 			}
-			_, err := db.ExecContext(ctx, `INSERT INTO users (name, age) VALUES ('Letícia', 0)`)
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('Letícia', 0)`)
 			assert.Equal(t, nil, err)
 
-			err = getUserByName(db, driver, &u, "Letícia")
+			err = getUserByName(db, tables, driver, &u, "Letícia")
 			assert.Equal(t, nil, err)
 			assert.NotEqual(t, uint(0), u.ID)
 
-			err = c.Update(ctx, usersTable, &user{
+			err = c.Update(ctx, tables.table("users"), &user{
 				ID:   u.ID,
 				Name: "Thayane",
 			})
 			assert.Equal(t, nil, err)
 
 			var result user
-			err = getUserByID(c.db, c.dialect, &result, u.ID)
+			err = getUserByID(c.db, tables, c.dialect, &result, u.ID)
 			assert.Equal(t, nil, err)
 			assert.Equal(t, "Thayane", result.Name)
 		})
@@ -1421,15 +2116,15 @@ func UpdateTest(
 				Age  *int   `ksql:"age"`
 			}
 
-			_, err := db.ExecContext(ctx, `INSERT INTO users (name, age) VALUES ('Letícia', 22)`)
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('Letícia', 22)`)
 			assert.Equal(t, nil, err)
 
 			var u user
-			err = getUserByName(db, driver, &u, "Letícia")
+			err = getUserByName(db, tables, driver, &u, "Letícia")
 			assert.Equal(t, nil, err)
 			assert.NotEqual(t, uint(0), u.ID)
 
-			err = c.Update(ctx, usersTable, partialUser{
+			err = c.Update(ctx, tables.table("users"), partialUser{
 				ID: u.ID,
 				// Should be updated because it is not null, just empty:
 				Name: "",
@@ -1439,7 +2134,7 @@ func UpdateTest(
 			assert.Equal(t, nil, err)
 
 			var result user
-			err = getUserByID(c.db, c.dialect, &result, u.ID)
+			err = getUserByID(c.db, tables, c.dialect, &result, u.ID)
 			assert.Equal(t, nil, err)
 			assert.Equal(t, "", result.Name)
 			assert.Equal(t, 22, result.Age)
@@ -1458,16 +2153,16 @@ func UpdateTest(
 				Age  *int   `ksql:"age"`
 			}
 
-			_, err := db.ExecContext(ctx, `INSERT INTO users (name, age) VALUES ('Letícia', 22)`)
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('Letícia', 22)`)
 			assert.Equal(t, nil, err)
 
 			var u user
-			err = getUserByName(db, driver, &u, "Letícia")
+			err = getUserByName(db, tables, driver, &u, "Letícia")
 			assert.Equal(t, nil, err)
 			assert.NotEqual(t, uint(0), u.ID)
 
 			// Should update all fields:
-			err = c.Update(ctx, usersTable, partialUser{
+			err = c.Update(ctx, tables.table("users"), partialUser{
 				ID:   u.ID,
 				Name: "Thay",
 				Age:  nullable.Int(42),
@@ -1475,13 +2170,151 @@ func UpdateTest(
 			assert.Equal(t, nil, err)
 
 			var result user
-			err = getUserByID(c.db, c.dialect, &result, u.ID)
+			err = getUserByID(c.db, tables, c.dialect, &result, u.ID)
 			assert.Equal(t, nil, err)
 
 			assert.Equal(t, "Thay", result.Name)
 			assert.Equal(t, 42, result.Age)
 		})
 
+		t.Run("should handle sql.Null* attributes like pointers", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			ctx := context.Background()
+			c := newTestDB(db, driver)
+
+			type nullUser struct {
+				ID   uint           `ksql:"id"`
+				Name sql.NullString `ksql:"name"`
+				Age  sql.NullInt64  `ksql:"age"`
+			}
+
+			u := nullUser{
+				Name: sql.NullString{String: "Null Letícia", Valid: true},
+			}
+			err := c.Insert(ctx, tables.table("users"), &u)
+			tt.AssertNoErr(t, err)
+			tt.AssertNotEqual(t, u.ID, uint(0))
+
+			var result nullUser
+			err = c.QueryOne(ctx, &result, `FROM `+tables.tableName("users")+` WHERE id = `+c.dialect.Placeholder(0), u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result, u)
+
+			// The invalid Name should be ignored just like nil pointers:
+			err = c.Patch(ctx, tables.table("users"), &nullUser{
+				ID:  u.ID,
+				Age: sql.NullInt64{Int64: 42, Valid: true},
+			})
+			tt.AssertNoErr(t, err)
+
+			err = c.QueryOne(ctx, &result, `FROM `+tables.tableName("users")+` WHERE id = `+c.dialect.Placeholder(0), u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result, nullUser{
+				ID:   u.ID,
+				Name: sql.NullString{String: "Null Letícia", Valid: true},
+				Age:  sql.NullInt64{Int64: 42, Valid: true},
+			})
+		})
+
+		t.Run("should update only the changed columns when using a Tracker", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			ctx := context.Background()
+			c := newTestDB(db, driver)
+
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('Letícia', 22)`)
+			assert.Equal(t, nil, err)
+
+			var u user
+			err = getUserByName(db, tables, driver, &u, "Letícia")
+			assert.Equal(t, nil, err)
+			assert.NotEqual(t, uint(0), u.ID)
+
+			tracker, err := Track(&u)
+			assert.Equal(t, nil, err)
+
+			// Changing the age concurrently, so we can check
+			// it is not overwritten by the tracked update:
+			_, err = db.ExecContext(ctx, `UPDATE `+tables.tableName("users")+` SET age = 23 WHERE name = 'Letícia'`)
+			assert.Equal(t, nil, err)
+
+			u.Name = "Thayane"
+			err = c.Patch(ctx, tables.table("users"), tracker)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, []string(nil), tracker.ChangedColumns())
+
+			var result user
+			err = getUserByID(c.db, tables, c.dialect, &result, u.ID)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, "Thayane", result.Name)
+			assert.Equal(t, 23, result.Age)
+		})
+
+		t.Run("should not update attributes tagged as immutable", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			ctx := context.Background()
+			c := newTestDB(db, driver)
+
+			_, err := db.ExecContext(ctx, `INSERT INTO `+tables.tableName("users")+` (name, age) VALUES ('Immutable Age', 22)`)
+			tt.AssertNoErr(t, err)
+
+			var u user
+			err = getUserByName(db, tables, driver, &u, "Immutable Age")
+			tt.AssertNoErr(t, err)
+
+			type immutableAgeUser struct {
+				ID   uint   `ksql:"id"`
+				Name string `ksql:"name"`
+				Age  int    `ksql:"age,immutable"`
+			}
+
+			err = c.Patch(ctx, tables.table("users"), immutableAgeUser{
+				ID:   u.ID,
+				Name: "Immutable Age Updated",
+				Age:  40,
+			})
+			tt.AssertNoErr(t, err)
+
+			var result user
+			err = getUserByID(c.db, tables, c.dialect, &result, u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Name, "Immutable Age Updated")
+			tt.AssertEqual(t, result.Age, 22)
+
+			t.Run("should return an error when using StrictImmutable", func(t *testing.T) {
+				err = c.Patch(WithQueryOptions(ctx, StrictImmutable()), tables.table("users"), immutableAgeUser{
+					ID:   u.ID,
+					Name: "Strict Immutable Age",
+					Age:  40,
+				})
+				tt.AssertErrContains(t, err, "immutable", "age")
+				tt.AssertEqual(t, errors.Is(err, ErrImmutableColumn), true)
+
+				err = getUserByID(c.db, tables, c.dialect, &result, u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, result.Name, "Immutable Age Updated")
+			})
+
+			t.Run("should only check the changed attributes of Trackers when using StrictImmutable", func(t *testing.T) {
+				record := immutableAgeUser{ID: u.ID, Name: "Immutable Age Updated", Age: 22}
+				tracker, err := Track(&record)
+				tt.AssertNoErr(t, err)
+
+				record.Name = "Strict Immutable Age"
+				err = c.Patch(WithQueryOptions(ctx, StrictImmutable()), tables.table("users"), tracker)
+				tt.AssertNoErr(t, err)
+
+				err = getUserByID(c.db, tables, c.dialect, &result, u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, result.Name, "Strict Immutable Age")
+			})
+		})
+
 		t.Run("should return ErrRecordNotFound when asked to update an inexistent user", func(t *testing.T) {
 			db, closer := newDBAdapter(t)
 			defer closer.Close()
@@ -1489,7 +2322,7 @@ func UpdateTest(
 			ctx := context.Background()
 			c := newTestDB(db, driver)
 
-			err = c.Update(ctx, usersTable, user{
+			err = c.Update(ctx, tables.table("users"), user{
 				ID:   4200,
 				Name: "Thayane",
 			})
@@ -1518,7 +2351,7 @@ func UpdateTest(
 			c := newTestDB(db, driver)
 
 			var u *user
-			err := c.Update(ctx, usersTable, u)
+			err := c.Update(ctx, tables.table("users"), u)
 			assert.NotEqual(t, nil, err)
 		})
 	})
@@ -1531,7 +2364,10 @@ func QueryChunksTest(
 	driver string,
 	connStr string,
 	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
 ) {
+	tables := testTables(opts)
+
 	t.Run("QueryChunks", func(t *testing.T) {
 		variations := []struct {
 			desc        string
@@ -1549,7 +2385,7 @@ func QueryChunksTest(
 		for _, variation := range variations {
 			t.Run(variation.desc, func(t *testing.T) {
 				t.Run("should query a single row correctly", func(t *testing.T) {
-					err := createTables(driver, connStr)
+					err := createTables(driver, connStr, tables)
 					if err != nil {
 						t.Fatal("could not create test table!, reason:", err.Error())
 					}
@@ -1560,7 +2396,7 @@ func QueryChunksTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					_ = c.Insert(ctx, usersTable, &user{
+					_ = c.Insert(ctx, tables.table("users"), &user{
 						Name:    "User1",
 						Address: address{Country: "BR"},
 					})
@@ -1568,7 +2404,7 @@ func QueryChunksTest(
 					var length int
 					var u user
 					err = c.QueryChunks(ctx, ChunkParser{
-						Query:  variation.queryPrefix + `FROM users WHERE name = ` + c.dialect.Placeholder(0),
+						Query:  variation.queryPrefix + `FROM ` + tables.tableName("users") + ` WHERE name = ` + c.dialect.Placeholder(0),
 						Params: []interface{}{"User1"},
 
 						ChunkSize: 100,
@@ -1589,7 +2425,7 @@ func QueryChunksTest(
 				})
 
 				t.Run("should query one chunk correctly", func(t *testing.T) {
-					err := createTables(driver, connStr)
+					err := createTables(driver, connStr, tables)
 					if err != nil {
 						t.Fatal("could not create test table!, reason:", err.Error())
 					}
@@ -1600,13 +2436,13 @@ func QueryChunksTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					_ = c.Insert(ctx, usersTable, &user{Name: "User1", Address: address{Country: "US"}})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User2", Address: address{Country: "BR"}})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1", Address: address{Country: "US"}})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User2", Address: address{Country: "BR"}})
 
 					var lengths []int
 					var users []user
 					err = c.QueryChunks(ctx, ChunkParser{
-						Query:  variation.queryPrefix + `from users where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
+						Query:  variation.queryPrefix + `from ` + tables.tableName("users") + ` where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
 						Params: []interface{}{"User%"},
 
 						ChunkSize: 2,
@@ -1631,7 +2467,7 @@ func QueryChunksTest(
 				})
 
 				t.Run("should query chunks of 1 correctly", func(t *testing.T) {
-					err := createTables(driver, connStr)
+					err := createTables(driver, connStr, tables)
 					if err != nil {
 						t.Fatal("could not create test table!, reason:", err.Error())
 					}
@@ -1642,13 +2478,13 @@ func QueryChunksTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					_ = c.Insert(ctx, usersTable, &user{Name: "User1", Address: address{Country: "US"}})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User2", Address: address{Country: "BR"}})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1", Address: address{Country: "US"}})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User2", Address: address{Country: "BR"}})
 
 					var lengths []int
 					var users []user
 					err = c.QueryChunks(ctx, ChunkParser{
-						Query:  variation.queryPrefix + `from users where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
+						Query:  variation.queryPrefix + `from ` + tables.tableName("users") + ` where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
 						Params: []interface{}{"User%"},
 
 						ChunkSize: 1,
@@ -1673,7 +2509,7 @@ func QueryChunksTest(
 				})
 
 				t.Run("should load partially filled chunks correctly", func(t *testing.T) {
-					err := createTables(driver, connStr)
+					err := createTables(driver, connStr, tables)
 					if err != nil {
 						t.Fatal("could not create test table!, reason:", err.Error())
 					}
@@ -1684,14 +2520,14 @@ func QueryChunksTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					_ = c.Insert(ctx, usersTable, &user{Name: "User1"})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User2"})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User3"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User2"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User3"})
 
 					var lengths []int
 					var users []user
 					err = c.QueryChunks(ctx, ChunkParser{
-						Query:  variation.queryPrefix + `from users where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
+						Query:  variation.queryPrefix + `from ` + tables.tableName("users") + ` where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
 						Params: []interface{}{"User%"},
 
 						ChunkSize: 2,
@@ -1734,14 +2570,14 @@ func QueryChunksTest(
 
 					ctx := context.Background()
 					c := newTestDB(db, driver)
-					_ = c.Insert(ctx, usersTable, &joao)
-					_ = c.Insert(ctx, usersTable, &thatiana)
+					_ = c.Insert(ctx, tables.table("users"), &joao)
+					_ = c.Insert(ctx, tables.table("users"), &thatiana)
 
-					_, err := db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, thatiana.ID, `, 'Thatiana Post1')`))
+					_, err := db.ExecContext(ctx, fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, thatiana.ID, `, 'Thatiana Post1')`))
 					assert.Equal(t, nil, err)
-					_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, thatiana.ID, `, 'Thatiana Post2')`))
+					_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, thatiana.ID, `, 'Thatiana Post2')`))
 					assert.Equal(t, nil, err)
-					_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, joao.ID, `, 'Thiago Post1')`))
+					_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO `+tables.tableName("posts")+` (user_id, title) VALUES (`, joao.ID, `, 'Thiago Post1')`))
 					assert.Equal(t, nil, err)
 
 					var lengths []int
@@ -1749,7 +2585,7 @@ func QueryChunksTest(
 					var posts []post
 					err = c.QueryChunks(ctx, ChunkParser{
 						Query: fmt.Sprint(
-							`FROM users u JOIN posts p ON p.user_id = u.id`,
+							`FROM `+tables.tableName("users")+` u JOIN `+tables.tableName("posts")+` p ON p.user_id = u.id`,
 							` WHERE u.name like `, c.dialect.Placeholder(0),
 							` ORDER BY u.id, p.id`,
 						),
@@ -1786,7 +2622,7 @@ func QueryChunksTest(
 				})
 
 				t.Run("should abort the first iteration when the callback returns an ErrAbortIteration", func(t *testing.T) {
-					err := createTables(driver, connStr)
+					err := createTables(driver, connStr, tables)
 					if err != nil {
 						t.Fatal("could not create test table!, reason:", err.Error())
 					}
@@ -1797,14 +2633,14 @@ func QueryChunksTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					_ = c.Insert(ctx, usersTable, &user{Name: "User1"})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User2"})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User3"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User2"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User3"})
 
 					var lengths []int
 					var users []user
 					err = c.QueryChunks(ctx, ChunkParser{
-						Query:  variation.queryPrefix + `from users where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
+						Query:  variation.queryPrefix + `from ` + tables.tableName("users") + ` where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
 						Params: []interface{}{"User%"},
 
 						ChunkSize: 2,
@@ -1825,7 +2661,7 @@ func QueryChunksTest(
 				})
 
 				t.Run("should abort the last iteration when the callback returns an ErrAbortIteration", func(t *testing.T) {
-					err := createTables(driver, connStr)
+					err := createTables(driver, connStr, tables)
 					if err != nil {
 						t.Fatal("could not create test table!, reason:", err.Error())
 					}
@@ -1836,15 +2672,15 @@ func QueryChunksTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					_ = c.Insert(ctx, usersTable, &user{Name: "User1"})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User2"})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User3"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User2"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User3"})
 
 					returnVals := []error{nil, ErrAbortIteration}
 					var lengths []int
 					var users []user
 					err = c.QueryChunks(ctx, ChunkParser{
-						Query:  variation.queryPrefix + `from users where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
+						Query:  variation.queryPrefix + `from ` + tables.tableName("users") + ` where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
 						Params: []interface{}{"User%"},
 
 						ChunkSize: 2,
@@ -1868,7 +2704,7 @@ func QueryChunksTest(
 				})
 
 				t.Run("should return error if the callback returns an error in the first iteration", func(t *testing.T) {
-					err := createTables(driver, connStr)
+					err := createTables(driver, connStr, tables)
 					if err != nil {
 						t.Fatal("could not create test table!, reason:", err.Error())
 					}
@@ -1879,14 +2715,14 @@ func QueryChunksTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					_ = c.Insert(ctx, usersTable, &user{Name: "User1"})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User2"})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User3"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User2"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User3"})
 
 					var lengths []int
 					var users []user
 					err = c.QueryChunks(ctx, ChunkParser{
-						Query:  variation.queryPrefix + `from users where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
+						Query:  variation.queryPrefix + `from ` + tables.tableName("users") + ` where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
 						Params: []interface{}{"User%"},
 
 						ChunkSize: 2,
@@ -1907,7 +2743,7 @@ func QueryChunksTest(
 				})
 
 				t.Run("should return error if the callback returns an error in the last iteration", func(t *testing.T) {
-					err := createTables(driver, connStr)
+					err := createTables(driver, connStr, tables)
 					if err != nil {
 						t.Fatal("could not create test table!, reason:", err.Error())
 					}
@@ -1918,15 +2754,15 @@ func QueryChunksTest(
 					ctx := context.Background()
 					c := newTestDB(db, driver)
 
-					_ = c.Insert(ctx, usersTable, &user{Name: "User1"})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User2"})
-					_ = c.Insert(ctx, usersTable, &user{Name: "User3"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User2"})
+					_ = c.Insert(ctx, tables.table("users"), &user{Name: "User3"})
 
 					returnVals := []error{nil, errors.New("fake error msg")}
 					var lengths []int
 					var users []user
 					err = c.QueryChunks(ctx, ChunkParser{
-						Query:  variation.queryPrefix + `from users where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
+						Query:  variation.queryPrefix + `from ` + tables.tableName("users") + ` where name like ` + c.dialect.Placeholder(0) + ` order by name asc;`,
 						Params: []interface{}{"User%"},
 
 						ChunkSize: 2,
@@ -1983,7 +2819,7 @@ func QueryChunksTest(
 
 					for _, fn := range funcs {
 						err := c.QueryChunks(ctx, ChunkParser{
-							Query:  variation.queryPrefix + `FROM users`,
+							Query:  variation.queryPrefix + `FROM ` + tables.tableName("users"),
 							Params: []interface{}{},
 
 							ChunkSize:    2,
@@ -2019,7 +2855,7 @@ func QueryChunksTest(
 					c := newTestDB(db, driver)
 
 					err := c.QueryChunks(ctx, ChunkParser{
-						Query:  `SELECT * FROM users u JOIN posts p ON u.id = p.user_id`,
+						Query:  `SELECT * FROM ` + tables.tableName("users") + ` u JOIN ` + tables.tableName("posts") + ` p ON u.id = p.user_id`,
 						Params: []interface{}{},
 
 						ChunkSize: 2,
@@ -2037,20 +2873,8 @@ func QueryChunksTest(
 				})
 			})
 		}
-	})
-}
-
-// TransactionTest runs all tests for making sure the Transaction function is
-// working for a given adapter and driver.
-func TransactionTest(
-	t *testing.T,
-	driver string,
-	connStr string,
-	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
-) {
-	t.Run("Transaction", func(t *testing.T) {
-		t.Run("should query a single row correctly", func(t *testing.T) {
-			err := createTables(driver, connStr)
+		t.Run("should report the progress after each chunk", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
 			if err != nil {
 				t.Fatal("could not create test table!, reason:", err.Error())
 			}
@@ -2061,44 +2885,253 @@ func TransactionTest(
 			ctx := context.Background()
 			c := newTestDB(db, driver)
 
-			_ = c.Insert(ctx, usersTable, &user{Name: "User1"})
-			_ = c.Insert(ctx, usersTable, &user{Name: "User2"})
+			for _, name := range []string{"User1", "User2", "User3"} {
+				err = c.Insert(ctx, tables.table("users"), &user{Name: name})
+				tt.AssertNoErr(t, err)
+			}
 
-			var users []user
-			err = c.Transaction(ctx, func(db Provider) error {
-				db.Query(ctx, &users, "SELECT * FROM users ORDER BY id ASC")
-				return nil
-			})
-			assert.Equal(t, nil, err)
+			var progresses []ChunkProgress
+			err = c.QueryChunks(ctx, ChunkParser{
+				Query: `FROM ` + tables.tableName("users"),
 
-			assert.Equal(t, 2, len(users))
-			assert.Equal(t, "User1", users[0].Name)
-			assert.Equal(t, "User2", users[1].Name)
+				ChunkSize: 2,
+				ForEachChunk: func(users []user) error {
+					return nil
+				},
+				OnProgress: func(ctx context.Context, progress ChunkProgress) error {
+					progresses = append(progresses, progress)
+					return nil
+				},
+			})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(progresses), 2)
+			tt.AssertEqual(t, progresses[0].Rows, 2)
+			tt.AssertEqual(t, progresses[0].Chunks, 1)
+			tt.AssertEqual(t, progresses[1].Rows, 3)
+			tt.AssertEqual(t, progresses[1].Chunks, 2)
+			tt.AssertEqual(t, progresses[1].LastKey, []interface{}(nil))
 		})
 
-		t.Run("should rollback when there are errors", func(t *testing.T) {
-			err := createTables(driver, connStr)
-			if err != nil {
-				t.Fatal("could not create test table!, reason:", err.Error())
-			}
-
-			db, closer := newDBAdapter(t)
-			defer closer.Close()
+		t.Run("using KeyColumns", func(t *testing.T) {
+			t.Run("should load each chunk with a separate query", func(t *testing.T) {
+				err := createTables(driver, connStr, tables)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				ctx := context.Background()
+				c := newTestDB(db, driver)
+
+				for _, name := range []string{"User1", "User2", "User3", "User4", "User5"} {
+					err = c.Insert(ctx, tables.table("users"), &user{Name: name})
+					tt.AssertNoErr(t, err)
+				}
+				err = c.Insert(ctx, tables.table("users"), &user{Name: "Other User"})
+				tt.AssertNoErr(t, err)
+
+				var lengths []int
+				var names []string
+				err = c.QueryChunks(ctx, ChunkParser{
+					Query:  `FROM ` + tables.tableName("users") + ` WHERE name LIKE ` + c.dialect.Placeholder(0),
+					Params: []interface{}{"User%"},
+
+					ChunkSize:  2,
+					KeyColumns: []string{"id"},
+					ForEachChunk: func(users []user) error {
+						lengths = append(lengths, len(users))
+						for _, u := range users {
+							names = append(names, u.Name)
+						}
+						return nil
+					},
+				})
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, lengths, []int{2, 2, 1})
+				tt.AssertEqual(t, names, []string{"User1", "User2", "User3", "User4", "User5"})
+			})
+
+			t.Run("should work with composite keys", func(t *testing.T) {
+				err := createTables(driver, connStr, tables)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				ctx := context.Background()
+				c := newTestDB(db, driver)
+
+				for _, name := range []string{"UserB", "UserA", "UserB", "UserA"} {
+					err = c.Insert(ctx, tables.table("users"), &user{Name: name})
+					tt.AssertNoErr(t, err)
+				}
+
+				var users []user
+				err = c.QueryChunks(ctx, ChunkParser{
+					Query: `FROM ` + tables.tableName("users"),
+
+					ChunkSize:  1,
+					KeyColumns: []string{"name", "id"},
+					ForEachChunk: func(chunk []user) error {
+						users = append(users, chunk...)
+						return nil
+					},
+				})
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, len(users), 4)
+				tt.AssertEqual(t, users[0].Name, "UserA")
+				tt.AssertEqual(t, users[1].Name, "UserA")
+				tt.AssertEqual(t, users[2].Name, "UserB")
+				tt.AssertEqual(t, users[3].Name, "UserB")
+				tt.AssertEqual(t, users[0].ID < users[1].ID, true)
+				tt.AssertEqual(t, users[2].ID < users[3].ID, true)
+			})
+
+			t.Run("should report the progress and resume from the last key", func(t *testing.T) {
+				err := createTables(driver, connStr, tables)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				ctx := context.Background()
+				c := newTestDB(db, driver)
+
+				for _, name := range []string{"User1", "User2", "User3", "User4", "User5"} {
+					err = c.Insert(ctx, tables.table("users"), &user{Name: name})
+					tt.AssertNoErr(t, err)
+				}
+
+				var checkpoint ChunkProgress
+				err = c.QueryChunks(ctx, ChunkParser{
+					Query: `FROM ` + tables.tableName("users"),
+
+					ChunkSize:  2,
+					KeyColumns: []string{"id"},
+					ForEachChunk: func(users []user) error {
+						return nil
+					},
+					OnProgress: func(ctx context.Context, progress ChunkProgress) error {
+						checkpoint = progress
+						if progress.Rows >= 2 {
+							return ErrAbortIteration
+						}
+						return nil
+					},
+				})
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, checkpoint.Rows, 2)
+				tt.AssertEqual(t, checkpoint.Chunks, 1)
+				tt.AssertEqual(t, len(checkpoint.LastKey), 1)
+
+				var names []string
+				err = c.QueryChunks(ctx, ChunkParser{
+					Query: `FROM ` + tables.tableName("users"),
+
+					ChunkSize:  2,
+					KeyColumns: []string{"id"},
+					StartAfter: checkpoint.LastKey,
+					ForEachChunk: func(users []user) error {
+						for _, u := range users {
+							names = append(names, u.Name)
+						}
+						return nil
+					},
+				})
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, names, []string{"User3", "User4", "User5"})
+			})
+
+			t.Run("should report error if the query contains an ORDER BY clause", func(t *testing.T) {
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				ctx := context.Background()
+				c := newTestDB(db, driver)
+
+				err := c.QueryChunks(ctx, ChunkParser{
+					Query: `FROM ` + tables.tableName("users") + ` ORDER BY name`,
+
+					ChunkSize:  2,
+					KeyColumns: []string{"id"},
+					ForEachChunk: func(users []user) error {
+						return nil
+					},
+				})
+				tt.AssertErrContains(t, err, "KeyColumns", "ORDER")
+			})
+		})
+	})
+}
+
+// TransactionTest runs all tests for making sure the Transaction function is
+// working for a given adapter and driver.
+func TransactionTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	t.Run("Transaction", func(t *testing.T) {
+		t.Run("should query a single row correctly", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			ctx := context.Background()
+			c := newTestDB(db, driver)
+
+			_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1"})
+			_ = c.Insert(ctx, tables.table("users"), &user{Name: "User2"})
+
+			var users []user
+			err = c.Transaction(ctx, func(db Provider) error {
+				db.Query(ctx, &users, "SELECT * FROM "+tables.tableName("users")+" ORDER BY id ASC")
+				return nil
+			})
+			assert.Equal(t, nil, err)
+
+			assert.Equal(t, 2, len(users))
+			assert.Equal(t, "User1", users[0].Name)
+			assert.Equal(t, "User2", users[1].Name)
+		})
+
+		t.Run("should rollback when there are errors", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
 
 			ctx := context.Background()
 			c := newTestDB(db, driver)
 
 			u1 := user{Name: "User1", Age: 42}
 			u2 := user{Name: "User2", Age: 42}
-			_ = c.Insert(ctx, usersTable, &u1)
-			_ = c.Insert(ctx, usersTable, &u2)
+			_ = c.Insert(ctx, tables.table("users"), &u1)
+			_ = c.Insert(ctx, tables.table("users"), &u2)
 
 			err = c.Transaction(ctx, func(db Provider) error {
-				err = db.Insert(ctx, usersTable, &user{Name: "User3"})
+				err = db.Insert(ctx, tables.table("users"), &user{Name: "User3"})
 				assert.Equal(t, nil, err)
-				err = db.Insert(ctx, usersTable, &user{Name: "User4"})
+				err = db.Insert(ctx, tables.table("users"), &user{Name: "User4"})
 				assert.Equal(t, nil, err)
-				_, err = db.Exec(ctx, "UPDATE users SET age = 22")
+				_, err = db.Exec(ctx, "UPDATE "+tables.tableName("users")+" SET age = 22")
 				assert.Equal(t, nil, err)
 
 				return errors.New("fake-error")
@@ -2107,11 +3140,92 @@ func TransactionTest(
 			assert.Equal(t, "fake-error", err.Error())
 
 			var users []user
-			err = c.Query(ctx, &users, "SELECT * FROM users ORDER BY id ASC")
+			err = c.Query(ctx, &users, "SELECT * FROM "+tables.tableName("users")+" ORDER BY id ASC")
 			assert.Equal(t, nil, err)
 
 			assert.Equal(t, []user{u1, u2}, users)
 		})
+
+		t.Run("should return the same Result from Exec inside transactions", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			ctx := context.Background()
+			c := newTestDB(db, driver)
+
+			_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1", Age: 42})
+			_ = c.Insert(ctx, tables.table("users"), &user{Name: "User2", Age: 42})
+
+			result, err := c.Exec(ctx, "UPDATE "+tables.tableName("users")+" SET age = 22")
+			tt.AssertNoErr(t, err)
+			rowsAffected, err := result.RowsAffected()
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, rowsAffected, int64(2))
+
+			result, err = c.Exec(ctx, "INSERT INTO "+tables.tableName("users")+" (name, age) VALUES ('User3', 42)")
+			tt.AssertNoErr(t, err)
+			lastID, lastIDErr := result.LastInsertId()
+
+			err = c.Transaction(ctx, func(db Provider) error {
+				result, err := db.Exec(ctx, "UPDATE "+tables.tableName("users")+" SET age = 23")
+				tt.AssertNoErr(t, err)
+				rowsAffected, err := result.RowsAffected()
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, rowsAffected, int64(3))
+
+				result, err = db.Exec(ctx, "INSERT INTO "+tables.tableName("users")+" (name, age) VALUES ('User4', 42)")
+				tt.AssertNoErr(t, err)
+				txLastID, txLastIDErr := result.LastInsertId()
+
+				// The drivers that don't support LastInsertId must fail the same way:
+				tt.AssertEqual(t, txLastIDErr == nil, lastIDErr == nil)
+				if lastIDErr == nil {
+					tt.AssertEqual(t, txLastID > lastID, true)
+				}
+				return nil
+			})
+			tt.AssertNoErr(t, err)
+		})
+
+		t.Run("should run read-only transactions with the ReadOnly provider", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			ctx := context.Background()
+			c := newTestDB(db, driver)
+
+			u := user{Name: "User1", Age: 42}
+			_ = c.Insert(ctx, tables.table("users"), &u)
+
+			var users []user
+			err = c.ReadOnly().Transaction(ctx, func(db Provider) error {
+				err := db.Insert(ctx, tables.table("users"), &user{Name: "User2"})
+				tt.AssertEqual(t, errors.As(err, &ReadOnlyError{}), true)
+
+				return db.Query(ctx, &users, "FROM "+tables.tableName("users")+" ORDER BY id ASC")
+			})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, users, []user{u})
+
+			if driver != "postgres" {
+				return
+			}
+
+			err = c.ReadOnly().Transaction(ctx, func(db Provider) error {
+				return db.Query(ctx, &users, "UPDATE "+tables.tableName("users")+" SET age = 22 RETURNING id, name, age, address")
+			})
+			tt.AssertErrContains(t, err, "read-only transaction")
+		})
 	})
 }
 
@@ -2122,10 +3236,13 @@ func ScanRowsTest(
 	driver string,
 	connStr string,
 	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
 ) {
+	tables := testTables(opts)
+
 	t.Run("ScanRows", func(t *testing.T) {
 		t.Run("should scan users correctly", func(t *testing.T) {
-			err := createTables(driver, connStr)
+			err := createTables(driver, connStr, tables)
 			if err != nil {
 				t.Fatal("could not create test table!, reason:", err.Error())
 			}
@@ -2135,18 +3252,18 @@ func ScanRowsTest(
 			db, closer := newDBAdapter(t)
 			defer closer.Close()
 			c := newTestDB(db, driver)
-			_ = c.Insert(ctx, usersTable, &user{Name: "User1", Age: 22})
-			_ = c.Insert(ctx, usersTable, &user{Name: "User2", Age: 14})
-			_ = c.Insert(ctx, usersTable, &user{Name: "User3", Age: 43})
+			_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1", Age: 22})
+			_ = c.Insert(ctx, tables.table("users"), &user{Name: "User2", Age: 14})
+			_ = c.Insert(ctx, tables.table("users"), &user{Name: "User3", Age: 43})
 
-			rows, err := db.QueryContext(ctx, "SELECT * FROM users WHERE name='User2'")
+			rows, err := db.QueryContext(ctx, "SELECT * FROM "+tables.tableName("users")+" WHERE name='User2'")
 			assert.Equal(t, nil, err)
 			defer rows.Close()
 
 			assert.Equal(t, true, rows.Next())
 
 			var u user
-			err = scanRows(dialect, rows, &u)
+			err = scanRows(dialect, rows, &u, scanOptions{})
 			assert.Equal(t, nil, err)
 
 			assert.Equal(t, "User2", u.Name)
@@ -2154,7 +3271,7 @@ func ScanRowsTest(
 		})
 
 		t.Run("should ignore extra columns from query", func(t *testing.T) {
-			err := createTables(driver, connStr)
+			err := createTables(driver, connStr, tables)
 			if err != nil {
 				t.Fatal("could not create test table!, reason:", err.Error())
 			}
@@ -2164,9 +3281,9 @@ func ScanRowsTest(
 			db, closer := newDBAdapter(t)
 			defer closer.Close()
 			c := newTestDB(db, driver)
-			_ = c.Insert(ctx, usersTable, &user{Name: "User1", Age: 22})
+			_ = c.Insert(ctx, tables.table("users"), &user{Name: "User1", Age: 22})
 
-			rows, err := db.QueryContext(ctx, "SELECT * FROM users WHERE name='User1'")
+			rows, err := db.QueryContext(ctx, "SELECT * FROM "+tables.tableName("users")+" WHERE name='User1'")
 			assert.Equal(t, nil, err)
 			defer rows.Close()
 
@@ -2179,14 +3296,14 @@ func ScanRowsTest(
 				// Omitted for testing purposes:
 				// Name string `ksql:"name"`
 			}
-			err = scanRows(dialect, rows, &u)
+			err = scanRows(dialect, rows, &u, scanOptions{})
 			assert.Equal(t, nil, err)
 
 			assert.Equal(t, 22, u.Age)
 		})
 
 		t.Run("should report error for closed rows", func(t *testing.T) {
-			err := createTables(driver, connStr)
+			err := createTables(driver, connStr, tables)
 			if err != nil {
 				t.Fatal("could not create test table!, reason:", err.Error())
 			}
@@ -2196,18 +3313,18 @@ func ScanRowsTest(
 			db, closer := newDBAdapter(t)
 			defer closer.Close()
 
-			rows, err := db.QueryContext(ctx, "SELECT * FROM users WHERE name='User2'")
+			rows, err := db.QueryContext(ctx, "SELECT * FROM "+tables.tableName("users")+" WHERE name='User2'")
 			assert.Equal(t, nil, err)
 
 			var u user
 			err = rows.Close()
 			assert.Equal(t, nil, err)
-			err = scanRows(dialect, rows, &u)
+			err = scanRows(dialect, rows, &u, scanOptions{})
 			assert.NotEqual(t, nil, err)
 		})
 
 		t.Run("should report if record is not a pointer", func(t *testing.T) {
-			err := createTables(driver, connStr)
+			err := createTables(driver, connStr, tables)
 			if err != nil {
 				t.Fatal("could not create test table!, reason:", err.Error())
 			}
@@ -2217,17 +3334,17 @@ func ScanRowsTest(
 			db, closer := newDBAdapter(t)
 			defer closer.Close()
 
-			rows, err := db.QueryContext(ctx, "SELECT * FROM users WHERE name='User2'")
+			rows, err := db.QueryContext(ctx, "SELECT * FROM "+tables.tableName("users")+" WHERE name='User2'")
 			tt.AssertNoErr(t, err)
 			defer rows.Close()
 
 			var u user
-			err = scanRows(dialect, rows, u)
+			err = scanRows(dialect, rows, u, scanOptions{})
 			tt.AssertErrContains(t, err, "ksql", "expected", "pointer to struct", "user")
 		})
 
 		t.Run("should report if record is not a pointer to struct", func(t *testing.T) {
-			err := createTables(driver, connStr)
+			err := createTables(driver, connStr, tables)
 			if err != nil {
 				t.Fatal("could not create test table!, reason:", err.Error())
 			}
@@ -2237,18 +3354,1329 @@ func ScanRowsTest(
 			db, closer := newDBAdapter(t)
 			defer closer.Close()
 
-			rows, err := db.QueryContext(ctx, "SELECT * FROM users WHERE name='User2'")
+			rows, err := db.QueryContext(ctx, "SELECT * FROM "+tables.tableName("users")+" WHERE name='User2'")
 			tt.AssertNoErr(t, err)
 			defer rows.Close()
 
 			var u map[string]interface{}
-			err = scanRows(dialect, rows, &u)
+			err = scanRows(dialect, rows, &u, scanOptions{})
 			tt.AssertErrContains(t, err, "ksql", "expected", "pointer to struct", "map[string]interface")
 		})
 	})
 }
 
-func createTables(driver string, connStr string) error {
+// QueryAggregateTest runs all tests for making sure the QueryAggregate
+// function is working for a given adapter and driver.
+func QueryAggregateTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	t.Run("QueryAggregate", func(t *testing.T) {
+		err := createTables(driver, connStr, tables)
+		if err != nil {
+			t.Fatal("could not create test table!, reason:", err.Error())
+		}
+
+		ctx := context.Background()
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		c := newTestDB(db, driver)
+		tt.AssertNoErr(t, c.Insert(ctx, tables.table("users"), &user{Name: "Agg1", Age: 22}))
+		tt.AssertNoErr(t, c.Insert(ctx, tables.table("users"), &user{Name: "Agg2", Age: 22}))
+		tt.AssertNoErr(t, c.Insert(ctx, tables.table("users"), &user{Name: "Agg3", Age: 43}))
+
+		t.Run("should scan grouped results into a slice of structs", func(t *testing.T) {
+			var results []struct {
+				Age   int
+				Total int `ksql:"total"`
+			}
+			err := c.QueryAggregate(ctx, &results, `SELECT age, count(*) AS total FROM `+tables.tableName("users")+` GROUP BY age ORDER BY age`)
+			tt.AssertNoErr(t, err)
+
+			tt.AssertEqual(t, len(results), 2)
+			tt.AssertEqual(t, results[0].Age, 22)
+			tt.AssertEqual(t, results[0].Total, 2)
+			tt.AssertEqual(t, results[1].Age, 43)
+			tt.AssertEqual(t, results[1].Total, 1)
+		})
+
+		t.Run("should scan a slice of primitives", func(t *testing.T) {
+			var ages []int
+			err := c.QueryAggregate(ctx, &ages, `SELECT DISTINCT age FROM `+tables.tableName("users")+` ORDER BY age`)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, ages, []int{22, 43})
+		})
+
+		t.Run("should scan a single primitive", func(t *testing.T) {
+			var total int
+			err := c.QueryAggregate(ctx, &total, `SELECT count(*) FROM `+tables.tableName("users")+` WHERE age = `+c.dialect.Placeholder(0), 22)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, total, 2)
+		})
+
+		t.Run("should return ErrRecordNotFound if there are no rows for a single result", func(t *testing.T) {
+			var name string
+			err := c.QueryAggregate(ctx, &name, `SELECT name FROM `+tables.tableName("users")+` WHERE age = `+c.dialect.Placeholder(0), 99)
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+		})
+	})
+}
+
+// CSVTest runs all tests for making sure the InsertCSV and QueryCSV
+// functions are working for a given adapter and driver.
+func CSVTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	t.Run("CSV", func(t *testing.T) {
+		t.Run("should insert and export records as csv", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+			err = c.InsertCSV(ctx, tables.table("users"), strings.NewReader(
+				"name,age\n"+
+					"\"Csv User1, Jr.\",22\n"+
+					"Csv User2,\n",
+			), CSVOptions{})
+			tt.AssertNoErr(t, err)
+
+			var buf bytes.Buffer
+			err = c.QueryCSV(ctx, &buf, `SELECT name, age FROM `+tables.tableName("users")+` ORDER BY name`)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, buf.String(), "name,age\n"+
+				"\"Csv User1, Jr.\",22\n"+
+				"Csv User2,\n",
+			)
+		})
+
+		t.Run("should insert records using explicit columns and delimiter", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+			err = c.InsertCSV(ctx, tables.table("users"), strings.NewReader("42;Csv User3\n"), CSVOptions{
+				Columns: []string{"age", "name"},
+				Comma:   ';',
+			})
+			tt.AssertNoErr(t, err)
+
+			var u user
+			err = c.QueryOne(ctx, &u, `FROM `+tables.tableName("users")+` WHERE name = `+c.dialect.Placeholder(0), "Csv User3")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, u.Age, 42)
+		})
+
+		t.Run("should not insert any records if one of them is invalid", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+			err = c.InsertCSV(ctx, tables.table("users"), strings.NewReader(
+				"name,age\n"+
+					"Csv User4,22\n"+
+					"Csv User5,22,extra-field\n",
+			), CSVOptions{})
+			tt.AssertErrContains(t, err, "wrong number of fields")
+
+			var users []user
+			err = c.Query(ctx, &users, `FROM `+tables.tableName("users"))
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(users), 0)
+		})
+	})
+}
+
+// WithConnTest runs all tests for making sure the WithConn function is
+// working for a given adapter and driver.
+func WithConnTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	t.Run("WithConn", func(t *testing.T) {
+		t.Run("should run all operations on the same connection", func(t *testing.T) {
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+
+			// Temporary tables are only visible to the connection that created them:
+			tableName := "conn_test"
+			createQuery := `CREATE TEMPORARY TABLE conn_test (id INTEGER, name VARCHAR(50))`
+			if driver == "sqlserver" {
+				tableName = "#conn_test"
+				createQuery = `CREATE TABLE #conn_test (id INTEGER, name VARCHAR(50))`
+			}
+
+			type connTestRecord struct {
+				ID   int    `ksql:"id"`
+				Name string `ksql:"name"`
+			}
+
+			var records []connTestRecord
+			err := c.WithConn(ctx, func(db Provider) error {
+				_, err := db.Exec(ctx, createQuery)
+				if err != nil {
+					return err
+				}
+
+				insertQuery := fmt.Sprintf(
+					"INSERT INTO %s (id, name) VALUES (%s, %s)",
+					tableName, c.dialect.Placeholder(0), c.dialect.Placeholder(1),
+				)
+				_, err = db.Exec(ctx, insertQuery, 1, "Conn User1")
+				if err != nil {
+					return err
+				}
+
+				err = db.Transaction(ctx, func(db Provider) error {
+					_, err := db.Exec(ctx, insertQuery, 2, "Conn User2")
+					return err
+				})
+				if err != nil {
+					return err
+				}
+
+				return db.Query(ctx, &records, "SELECT id, name FROM "+tableName+" ORDER BY id")
+			})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, records, []connTestRecord{
+				{ID: 1, Name: "Conn User1"},
+				{ID: 2, Name: "Conn User2"},
+			})
+		})
+	})
+}
+
+// HealthCheckTest runs all tests for making sure the HealthCheck function
+// is working for a given adapter and driver.
+func HealthCheckTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	t.Run("HealthCheck", func(t *testing.T) {
+		t.Run("should report the database as healthy", func(t *testing.T) {
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+
+			report := c.HealthCheck(ctx)
+			tt.AssertEqual(t, report.Error, "")
+			tt.AssertEqual(t, report.Healthy, true)
+			tt.AssertNotEqual(t, report.Latency, time.Duration(0))
+		})
+	})
+}
+
+// ExtensionsTest runs all tests for making sure the extension
+// interfaces implemented by the DB type are working for a given
+// adapter and driver.
+func ExtensionsTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	t.Run("Extensions", func(t *testing.T) {
+		t.Run("should insert and then update records with Upsert", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+
+			u := user{Name: "Upsert User", Age: 22}
+			err = Upsert(ctx, c, tables.table("users"), &u)
+			tt.AssertNoErr(t, err)
+			tt.AssertNotEqual(t, u.ID, uint(0))
+
+			u.Age = 23
+			err = Upsert(ctx, c, tables.table("users"), &u)
+			tt.AssertNoErr(t, err)
+
+			var result user
+			err = getUserByID(db, tables, c.dialect, &result, u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Name, "Upsert User")
+			tt.AssertEqual(t, result.Age, 23)
+
+			// Upserting with an ID that doesn't exist yet, SQL Server
+			// requires IDENTITY_INSERT for setting the ID explicitly:
+			newUser := user{ID: u.ID + 100, Name: "Upsert User2", Age: 30}
+			err = Upsert(ctx, c, tables.table("users"), &newUser, IdentityInsert())
+			tt.AssertNoErr(t, err)
+
+			err = getUserByID(db, tables, c.dialect, &result, newUser.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Name, "Upsert User2")
+			tt.AssertEqual(t, result.Age, 30)
+		})
+
+		t.Run("should not update immutable attributes with Upsert", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+
+			u := struct {
+				ID   uint   `ksql:"id"`
+				Name string `ksql:"name"`
+				Age  int    `ksql:"age,immutable"`
+			}{Name: "Upsert Immutable", Age: 22}
+			err = Upsert(ctx, c, tables.table("users"), &u)
+			tt.AssertNoErr(t, err)
+
+			u.Name = "Upsert Immutable Updated"
+			u.Age = 23
+			err = Upsert(ctx, c, tables.table("users"), &u)
+			tt.AssertNoErr(t, err)
+
+			var result user
+			err = getUserByID(db, tables, c.dialect, &result, u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Name, "Upsert Immutable Updated")
+			tt.AssertEqual(t, result.Age, 22)
+
+			err = Upsert(ctx, c, tables.table("users"), &u, StrictImmutable())
+			tt.AssertEqual(t, errors.Is(err, ErrImmutableColumn), true)
+		})
+
+		t.Run("should insert all records with InsertMany", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+
+			users := []*user{
+				{Name: "Batch User1", Age: 22},
+				{Name: "Batch User2", Age: 23},
+			}
+			err = InsertMany(ctx, c, tables.table("users"), users)
+			tt.AssertNoErr(t, err)
+
+			for _, u := range users {
+				tt.AssertNotEqual(t, u.ID, uint(0))
+
+				var result user
+				err = getUserByID(db, tables, c.dialect, &result, u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, result.Name, u.Name)
+				tt.AssertEqual(t, result.Age, u.Age)
+			}
+		})
+
+		t.Run("should report the records that failed on InsertMany", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+
+			existing := user{Name: "Batch User1", Age: 22}
+			err = c.Insert(ctx, tables.table("users"), &existing)
+			tt.AssertNoErr(t, err)
+
+			err = InsertMany(ctx, c, tables.table("users"), []*user{
+				{ID: existing.ID + 1, Name: "Batch User2", Age: 23},
+				{ID: existing.ID, Name: "Batch User3", Age: 24},
+			}, IdentityInsert())
+
+			var batchErr BatchError
+			tt.AssertEqual(t, errors.As(err, &batchErr), true)
+			tt.AssertEqual(t, batchErr.Method, "InsertMany")
+			tt.AssertEqual(t, len(batchErr.Failures), 1)
+			tt.AssertEqual(t, batchErr.Failures[0].Index, 1)
+			tt.AssertEqual(t, batchErr.Failures[0].Constraint, UniqueViolation)
+
+			var users []user
+			err = c.Query(ctx, &users, "FROM "+tables.tableName("users")+" WHERE name LIKE 'Batch User%'")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(users), 1)
+		})
+
+		t.Run("should skip the records violating constraints with InsertManySkipErrors", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+
+			existing := user{Name: "Batch User1", Age: 22}
+			err = c.Insert(ctx, tables.table("users"), &existing)
+			tt.AssertNoErr(t, err)
+
+			users := []*user{
+				{ID: existing.ID + 1, Name: "Batch User2", Age: 23},
+				{ID: existing.ID, Name: "Batch User3", Age: 24},
+				{ID: existing.ID + 2, Name: "Batch User4", Age: 25},
+			}
+			failures, err := c.InsertManySkipErrors(ctx, tables.table("users"), &users, IdentityInsert())
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(failures), 1)
+			tt.AssertEqual(t, failures[0].Index, 1)
+			tt.AssertEqual(t, failures[0].Constraint, UniqueViolation)
+
+			var names []struct {
+				Name string `ksql:"name"`
+			}
+			err = c.Query(ctx, &names, "SELECT name FROM "+tables.tableName("users")+" WHERE name LIKE 'Batch User%' ORDER BY name")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(names), 3)
+			tt.AssertEqual(t, names[0].Name, "Batch User1")
+			tt.AssertEqual(t, names[1].Name, "Batch User2")
+			tt.AssertEqual(t, names[2].Name, "Batch User4")
+		})
+
+		t.Run("should insert or get the existing record with InsertOrGet", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+
+			table := tables.table("user_permissions", "id")
+			first := userPermission{UserID: 1, PermID: 42}
+			inserted, err := c.InsertOrGet(ctx, table, &first, "user_id", "perm_id")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, inserted, true)
+			tt.AssertNotEqual(t, first.ID, 0)
+
+			err = c.Transaction(ctx, func(db Provider) error {
+				second := userPermission{UserID: 1, PermID: 42}
+				inserted, err := db.(DB).InsertOrGet(ctx, table, &second, "user_id", "perm_id")
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, inserted, false)
+				tt.AssertEqual(t, second.ID, first.ID)
+
+				// The transaction must still be usable after the conflict:
+				return db.Insert(ctx, table, &userPermission{UserID: 1, PermID: 43})
+			})
+			tt.AssertNoErr(t, err)
+
+			var permissions []userPermission
+			err = c.Query(ctx, &permissions, "FROM "+tables.tableName("user_permissions")+" WHERE user_id = 1 ORDER BY perm_id")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(permissions), 2)
+			tt.AssertEqual(t, permissions[0].PermID, 42)
+			tt.AssertEqual(t, permissions[1].PermID, 43)
+		})
+
+		t.Run("should return the deleted record with DeleteAndReturn", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+
+			u := user{Name: "Deleted User", Age: 22, Address: address{City: "Rio"}}
+			err = c.Insert(ctx, tables.table("users"), &u)
+			tt.AssertNoErr(t, err)
+
+			var deleted user
+			err = c.DeleteAndReturn(ctx, tables.table("users"), u.ID, &deleted)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, deleted, u)
+
+			err = c.QueryOne(ctx, &user{}, "FROM "+tables.tableName("users")+" WHERE id = "+c.dialect.Placeholder(0), u.ID)
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+
+			err = c.DeleteAndReturn(ctx, tables.table("users"), u.ID, &deleted)
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+		})
+
+		t.Run("should read the previous versions of records with AsOf and the history trigger", func(t *testing.T) {
+			if driver != "postgres" {
+				// The history trigger is only used on postgres
+				return
+			}
+
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+			_, err = c.Exec(ctx, "DROP TABLE IF EXISTS "+tables.tableName("users_history"))
+			tt.AssertNoErr(t, err)
+
+			historyTable := tables.table("users").WithHistory(tables.TablePrefix + "users_history")
+
+			// The rows inserted before the trigger are copied to the history table:
+			u := user{Name: "Versioned User", Age: 22, Address: address{City: "Rio"}}
+			err = c.Insert(ctx, historyTable, &u)
+			tt.AssertNoErr(t, err)
+
+			query, err := c.HistoryTriggerQuery(historyTable)
+			tt.AssertNoErr(t, err)
+			err = c.Transaction(ctx, func(db Provider) error {
+				_, err := db.Exec(ctx, query)
+				return err
+			})
+			tt.AssertNoErr(t, err)
+
+			var clock struct {
+				Now time.Time `ksql:"now"`
+			}
+			err = c.QueryOne(ctx, &clock, "SELECT now() AS now")
+			tt.AssertNoErr(t, err)
+			beforeUpdate := clock.Now
+
+			u.Age = 23
+			err = c.Patch(ctx, historyTable, &u)
+			tt.AssertNoErr(t, err)
+
+			var result user
+			err = c.Find(ctx, historyTable, &result, u.ID, AsOf(beforeUpdate))
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Age, 22)
+			tt.AssertEqual(t, result.Address, address{City: "Rio"})
+
+			err = c.QueryOne(ctx, &clock, "SELECT now() AS now")
+			tt.AssertNoErr(t, err)
+			afterUpdate := clock.Now
+
+			err = c.Find(ctx, historyTable, &result, u.ID, AsOf(afterUpdate))
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Age, 23)
+
+			err = c.Delete(ctx, historyTable, u.ID)
+			tt.AssertNoErr(t, err)
+
+			err = c.QueryOne(ctx, &clock, "SELECT now() AS now")
+			tt.AssertNoErr(t, err)
+
+			err = c.Find(ctx, historyTable, &result, u.ID, AsOf(clock.Now))
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+
+			err = c.Find(ctx, historyTable, &result, u.ID, AsOf(afterUpdate))
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Age, 23)
+		})
+
+		t.Run("should update all records with UpdateMany", func(t *testing.T) {
+			err := createTables(driver, connStr, tables)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			ctx := context.Background()
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, driver)
+
+			users := []*user{
+				{Name: "Batch User1", Age: 22},
+				{Name: "Batch User2", Age: 23},
+				{Name: "Batch User3", Age: 24},
+			}
+			err = InsertMany(ctx, c, tables.table("users"), users)
+			tt.AssertNoErr(t, err)
+
+			newAge := 40
+			newName := "Batch User2 Updated"
+			var n int64
+			err = UpdateMany(ctx, c, tables.table("users"), []struct {
+				ID   uint    `ksql:"id"`
+				Name *string `ksql:"name"`
+				Age  *int    `ksql:"age"`
+			}{
+				{ID: users[0].ID, Age: &newAge},
+				{ID: users[1].ID, Name: &newName, Age: &newAge},
+			}, RowsAffected(&n))
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, n, int64(2))
+
+			expected := []user{
+				{Name: "Batch User1", Age: 40},
+				{Name: "Batch User2 Updated", Age: 40},
+				{Name: "Batch User3", Age: 24},
+			}
+			for i, u := range users {
+				var result user
+				err = getUserByID(db, tables, c.dialect, &result, u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, result.Name, expected[i].Name)
+				tt.AssertEqual(t, result.Age, expected[i].Age)
+			}
+		})
+	})
+}
+
+// KeysTest runs all tests for making sure the operations using
+// ksql.Key values are working for a given adapter and driver.
+func KeysTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	t.Run("Keys", func(t *testing.T) {
+		err := createTables(driver, connStr, tables)
+		if err != nil {
+			t.Fatal("could not create test table!, reason:", err.Error())
+		}
+
+		ctx := context.Background()
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		c := newTestDB(db, driver)
+
+		for _, p := range []userPermission{
+			{UserID: 1, PermID: 42},
+			{UserID: 1, PermID: 43},
+			{UserID: 2, PermID: 42},
+		} {
+			err = c.Insert(ctx, tables.table("user_permissions", "id"), &p)
+			tt.AssertNoErr(t, err)
+		}
+
+		t.Run("should query records by composite keys", func(t *testing.T) {
+			var perm userPermission
+			err := c.QueryByKey(ctx, tables.table("user_permissions", "user_id", "perm_id"), &perm, tables.table("user_permissions", "user_id", "perm_id").Key(1, 43))
+			tt.AssertNoErr(t, err)
+			tt.AssertNotEqual(t, perm.ID, 0)
+			tt.AssertEqual(t, perm.UserID, 1)
+			tt.AssertEqual(t, perm.PermID, 43)
+
+			err = c.QueryByKey(ctx, tables.table("user_permissions", "user_id", "perm_id"), &perm, tables.table("user_permissions", "user_id", "perm_id").Key(2, 43))
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+		})
+
+		t.Run("should find records by ID", func(t *testing.T) {
+			u := user{Name: "Find User", Age: 32}
+			err := c.Insert(ctx, tables.table("users"), &u)
+			tt.AssertNoErr(t, err)
+
+			var result user
+			err = c.Find(ctx, tables.table("users"), &result, u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.ID, u.ID)
+			tt.AssertEqual(t, result.Name, "Find User")
+			tt.AssertEqual(t, result.Age, 32)
+
+			err = c.Find(ctx, tables.table("users"), &result, u.ID+1000)
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+		})
+
+		t.Run("should apply the find filter of the table", func(t *testing.T) {
+			u := user{Name: "Filtered User", Age: 70}
+			err := c.Insert(ctx, tables.table("users"), &u)
+			tt.AssertNoErr(t, err)
+
+			var result user
+			err = c.Find(ctx, tables.table("users").WithFindFilter("age < 65"), &result, u.ID)
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+
+			err = c.Find(ctx, tables.table("users").WithFindFilter("age >= 65"), &result, u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Name, "Filtered User")
+		})
+
+		t.Run("should find records by composite keys passed as structs or maps", func(t *testing.T) {
+			var perm userPermission
+			err := c.Find(ctx, tables.table("user_permissions", "user_id", "perm_id"), &perm, userPermission{UserID: 1, PermID: 43})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, perm.UserID, 1)
+			tt.AssertEqual(t, perm.PermID, 43)
+
+			perm = userPermission{}
+			err = c.Find(ctx, tables.table("user_permissions", "user_id", "perm_id"), &perm, map[string]interface{}{
+				"user_id": 2,
+				"perm_id": 42,
+			})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, perm.UserID, 2)
+			tt.AssertEqual(t, perm.PermID, 42)
+
+			err = c.Find(ctx, tables.table("user_permissions", "user_id", "perm_id"), &perm, 1)
+			tt.AssertErrContains(t, err, "missing required id field", "perm_id")
+		})
+
+		t.Run("should patch records from tables with composite keys", func(t *testing.T) {
+			var perm userPermission
+			err := c.QueryByKey(ctx, tables.table("user_permissions", "user_id", "perm_id"), &perm, tables.table("user_permissions", "user_id", "perm_id").Key(2, 42))
+			tt.AssertNoErr(t, err)
+
+			err = c.Patch(ctx, tables.table("user_permissions", "user_id", "perm_id"), &struct {
+				UserID int `ksql:"user_id"`
+				PermID int `ksql:"perm_id"`
+				ID     int `ksql:"id"`
+			}{UserID: 2, PermID: 42, ID: perm.ID + 1000})
+			tt.AssertNoErr(t, err)
+
+			var updated userPermission
+			err = c.QueryByKey(ctx, tables.table("user_permissions", "user_id", "perm_id"), &updated, tables.table("user_permissions", "user_id", "perm_id").Key(2, 42))
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, updated.ID, perm.ID+1000)
+
+			// Only the record with the same composite key should be updated:
+			userPerms, err := getUserPermissionsByUser(db, tables, driver, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(userPerms), 2)
+			tt.AssertNotEqual(t, userPerms[0].ID, perm.ID+1000)
+			tt.AssertNotEqual(t, userPerms[1].ID, perm.ID+1000)
+		})
+
+		t.Run("should delete records by composite keys", func(t *testing.T) {
+			err := c.Delete(ctx, tables.table("user_permissions", "user_id", "perm_id"), tables.table("user_permissions", "user_id", "perm_id").Key(1, 42))
+			tt.AssertNoErr(t, err)
+
+			userPerms, err := getUserPermissionsByUser(db, tables, driver, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(userPerms), 1)
+			tt.AssertEqual(t, userPerms[0].PermID, 43)
+		})
+
+		t.Run("should report keys with the wrong number of values", func(t *testing.T) {
+			err := c.Delete(ctx, tables.table("user_permissions", "user_id", "perm_id"), tables.table("user_permissions", "user_id", "perm_id").Key(1))
+			tt.AssertErrContains(t, err, "user_permissions", "2 ID column(s)", "1 value(s)")
+
+			var perm userPermission
+			err = c.QueryByKey(ctx, tables.table("user_permissions", "user_id", "perm_id"), &perm, tables.table("user_permissions", "user_id", "perm_id").Key(1, 42, 3))
+			tt.AssertErrContains(t, err, "user_permissions", "2 ID column(s)", "3 value(s)")
+		})
+	})
+}
+
+// PrepareTest runs all tests for making sure the Prepare method
+// is working for a given adapter and driver.
+func PrepareTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	t.Run("Prepare", func(t *testing.T) {
+		err := createTables(driver, connStr, tables)
+		if err != nil {
+			t.Fatal("could not create test table!, reason:", err.Error())
+		}
+
+		ctx := context.Background()
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		c := newTestDB(db, driver)
+
+		query := `SELECT name FROM ` + tables.tableName("users") + ` WHERE id = ` + c.dialect.Placeholder(0)
+		err = c.Prepare(ctx, query)
+		tt.AssertNoErr(t, err)
+
+		u := user{Name: "Prepared User"}
+		err = c.Insert(ctx, tables.table("users"), &u)
+		tt.AssertNoErr(t, err)
+
+		var result struct {
+			Name string `ksql:"name"`
+		}
+		err = c.QueryOne(ctx, &result, query, u.ID)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, result.Name, "Prepared User")
+
+		err = c.Prepare(ctx, `SELECT * FROM not a valid query`)
+		tt.AssertNotEqual(t, err, nil)
+	})
+}
+
+// UnwrapTest runs all tests for making sure the Unwrap method
+// is working for a given adapter and driver.
+func UnwrapTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	t.Run("Unwrap", func(t *testing.T) {
+		ctx := context.Background()
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		c := newTestDB(db, driver)
+
+		handle := c.Unwrap()
+		_, isAdapter := handle.(DBAdapter)
+		tt.AssertEqual(t, isAdapter, false)
+
+		err := c.Transaction(ctx, func(db Provider) error {
+			txHandle := db.(DB).Unwrap()
+			_, isAdapter := txHandle.(DBAdapter)
+			tt.AssertEqual(t, isAdapter, false)
+			tt.AssertNotEqual(t, fmt.Sprintf("%T", txHandle), fmt.Sprintf("%T", handle))
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+	})
+}
+
+// DecimalTest runs all tests for making sure the `decimal` modifier
+// is working for a given adapter and driver.
+func DecimalTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	t.Run("Decimal", func(t *testing.T) {
+		ctx := context.Background()
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		c := newTestDB(db, driver)
+
+		createQuery := map[string]string{
+			// sqlite3 has no decimal type, so TEXT is used to keep the exact values:
+			"sqlite3":   `CREATE TABLE ` + tables.tableName("decimals") + ` (id INTEGER PRIMARY KEY, price TEXT, total TEXT)`,
+			"postgres":  `CREATE TABLE ` + tables.tableName("decimals") + ` (id serial PRIMARY KEY, price NUMERIC(30, 10), total NUMERIC(30, 0))`,
+			"mysql":     `CREATE TABLE ` + tables.tableName("decimals") + ` (id INT AUTO_INCREMENT PRIMARY KEY, price DECIMAL(30, 10), total DECIMAL(30, 0))`,
+			"sqlserver": `CREATE TABLE ` + tables.tableName("decimals") + ` (id INT IDENTITY(1,1) PRIMARY KEY, price DECIMAL(30, 10), total DECIMAL(30, 0))`,
+		}[driver]
+
+		db.ExecContext(ctx, `DROP TABLE `+tables.tableName("decimals"))
+		_, err := db.ExecContext(ctx, createQuery)
+		tt.AssertNoErr(t, err)
+
+		type decimalRecord struct {
+			ID    int      `ksql:"id"`
+			Price string   `ksql:"price,decimal"`
+			Total *big.Int `ksql:"total,decimal"`
+		}
+		decimalsTable := tables.table("decimals")
+
+		t.Run("should write and read decimals without losing precision", func(t *testing.T) {
+			total, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+			record := decimalRecord{
+				Price: "12345678901234567890.0123456789",
+				Total: total,
+			}
+			err := c.Insert(ctx, decimalsTable, &record)
+			tt.AssertNoErr(t, err)
+
+			var result decimalRecord
+			err = c.Find(ctx, decimalsTable, &result, record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Price, "12345678901234567890.0123456789")
+			tt.AssertEqual(t, result.Total.String(), "123456789012345678901234567890")
+
+			result.Price = "0.0000000001"
+			err = c.Patch(ctx, decimalsTable, &result)
+			tt.AssertNoErr(t, err)
+
+			err = c.Find(ctx, decimalsTable, &result, record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Price, "0.0000000001")
+		})
+
+		t.Run("should write and read NULL decimals", func(t *testing.T) {
+			record := decimalRecord{Price: "1.5000000000"}
+			err := c.Insert(ctx, decimalsTable, &record)
+			tt.AssertNoErr(t, err)
+
+			result := decimalRecord{Total: big.NewInt(1)}
+			err = c.Find(ctx, decimalsTable, &result, record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Price, "1.5000000000")
+			tt.AssertEqual(t, result.Total, (*big.Int)(nil))
+		})
+	})
+}
+
+// HstoreTest runs all tests for making sure the `hstore` modifier
+// is working for a given adapter and driver.
+func HstoreTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	if driver != "postgres" {
+		// The hstore type only exists on postgres
+		return
+	}
+
+	t.Run("Hstore", func(t *testing.T) {
+		ctx := context.Background()
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		c := newTestDB(db, driver)
+
+		_, err := db.ExecContext(ctx, `CREATE EXTENSION IF NOT EXISTS hstore`)
+		tt.AssertNoErr(t, err)
+
+		db.ExecContext(ctx, `DROP TABLE `+tables.tableName("hstores"))
+		_, err = db.ExecContext(ctx, `CREATE TABLE `+tables.tableName("hstores")+` (id serial PRIMARY KEY, attrs hstore, optional hstore)`)
+		tt.AssertNoErr(t, err)
+
+		type hstoreRecord struct {
+			ID       int                `ksql:"id"`
+			Attrs    map[string]string  `ksql:"attrs,hstore"`
+			Optional map[string]*string `ksql:"optional,hstore"`
+		}
+		hstoresTable := tables.table("hstores")
+
+		t.Run("should write and read hstore values", func(t *testing.T) {
+			record := hstoreRecord{
+				Attrs: map[string]string{
+					"color":    "blue",
+					`"quoted"`: `back\slash, =>`,
+				},
+				Optional: map[string]*string{
+					"missing": nil,
+				},
+			}
+			err := c.Insert(ctx, hstoresTable, &record)
+			tt.AssertNoErr(t, err)
+
+			var result hstoreRecord
+			err = c.Find(ctx, hstoresTable, &result, record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Attrs, record.Attrs)
+			tt.AssertEqual(t, result.Optional, record.Optional)
+
+			var count int
+			err = c.QueryAggregate(ctx, &count, `SELECT count(*) FROM `+tables.tableName("hstores")+` WHERE attrs -> 'color' = 'blue'`)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, count, 1)
+		})
+
+		t.Run("should write and read NULL hstore values", func(t *testing.T) {
+			var record hstoreRecord
+			err := c.Insert(ctx, hstoresTable, &record)
+			tt.AssertNoErr(t, err)
+
+			result := hstoreRecord{Attrs: map[string]string{"a": "b"}}
+			err = c.Find(ctx, hstoresTable, &result, record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Attrs, map[string]string(nil))
+		})
+	})
+}
+
+func DurationTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	t.Run("Duration", func(t *testing.T) {
+		ctx := context.Background()
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		c := newTestDB(db, driver)
+
+		createQuery := map[string]string{
+			"sqlite3":   `CREATE TABLE ` + tables.tableName("durations") + ` (id INTEGER PRIMARY KEY, timeout INTEGER, ttl INTEGER)`,
+			"postgres":  `CREATE TABLE ` + tables.tableName("durations") + ` (id serial PRIMARY KEY, timeout INTERVAL, ttl INTERVAL)`,
+			"mysql":     `CREATE TABLE ` + tables.tableName("durations") + ` (id INT AUTO_INCREMENT PRIMARY KEY, timeout BIGINT, ttl BIGINT)`,
+			"sqlserver": `CREATE TABLE ` + tables.tableName("durations") + ` (id INT IDENTITY(1,1) PRIMARY KEY, timeout BIGINT, ttl BIGINT)`,
+		}[driver]
+
+		db.ExecContext(ctx, `DROP TABLE `+tables.tableName("durations"))
+		_, err := db.ExecContext(ctx, createQuery)
+		tt.AssertNoErr(t, err)
+
+		type durationRecord struct {
+			ID      int            `ksql:"id"`
+			Timeout time.Duration  `ksql:"timeout,duration=ms"`
+			TTL     *time.Duration `ksql:"ttl,duration"`
+		}
+		durationsTable := tables.table("durations")
+
+		t.Run("should write and read durations", func(t *testing.T) {
+			ttl := 26*time.Hour + 3*time.Minute + 4*time.Second
+			record := durationRecord{
+				Timeout: 1500 * time.Millisecond,
+				TTL:     &ttl,
+			}
+			err := c.Insert(ctx, durationsTable, &record)
+			tt.AssertNoErr(t, err)
+
+			var result durationRecord
+			err = c.Find(ctx, durationsTable, &result, record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Timeout, 1500*time.Millisecond)
+			tt.AssertEqual(t, *result.TTL, ttl)
+
+			result.Timeout = -250 * time.Millisecond
+			err = c.Patch(ctx, durationsTable, &result)
+			tt.AssertNoErr(t, err)
+
+			err = c.Find(ctx, durationsTable, &result, record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Timeout, -250*time.Millisecond)
+		})
+
+		t.Run("should write and read NULL durations", func(t *testing.T) {
+			record := durationRecord{Timeout: time.Second}
+			err := c.Insert(ctx, durationsTable, &record)
+			tt.AssertNoErr(t, err)
+
+			var result durationRecord
+			err = c.Find(ctx, durationsTable, &result, record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Timeout, time.Second)
+			tt.AssertEqual(t, result.TTL == nil, true)
+		})
+	})
+}
+
+// CDCTest runs all tests for making sure the EnableCDC and
+// ReadChanges methods are working for a given adapter and driver.
+func CDCTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	switch driver {
+	case "postgres", "sqlite3", "mysql":
+	default:
+		// The other dialects are not supported
+		return
+	}
+
+	t.Run("CDC", func(t *testing.T) {
+		err := createTables(driver, connStr, tables)
+		if err != nil {
+			t.Fatal("could not create test table!, reason:", err.Error())
+		}
+
+		ctx := context.Background()
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		c := newTestDB(db, driver)
+
+		db.ExecContext(ctx, `DROP TABLE `+tables.tableName("users_changes"))
+
+		err = c.EnableCDC(ctx, tables.table("users"), user{})
+		tt.AssertNoErr(t, err)
+
+		t.Run("should record the changes in order", func(t *testing.T) {
+			u := user{Name: "Bia", Age: 20}
+			err := c.Insert(ctx, tables.table("users"), &u)
+			tt.AssertNoErr(t, err)
+
+			err = c.Patch(ctx, tables.table("users"), struct {
+				ID  uint `ksql:"id"`
+				Age int  `ksql:"age"`
+			}{ID: u.ID, Age: 21})
+			tt.AssertNoErr(t, err)
+
+			err = c.Delete(ctx, tables.table("users"), u.ID)
+			tt.AssertNoErr(t, err)
+
+			events, err := c.ReadChanges(ctx, tables.table("users"), 0, 0)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(events), 3)
+
+			operations := []string{}
+			for _, event := range events {
+				operations = append(operations, event.Operation)
+				tt.AssertEqual(t, event.TableName, tables.tableName("users"))
+
+				var record struct {
+					ID   uint   `ksql:"id"`
+					Name string `ksql:"name"`
+					Age  int    `ksql:"age"`
+				}
+				err = event.Decode(&record)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, record.ID, u.ID)
+				tt.AssertEqual(t, record.Name, "Bia")
+			}
+			tt.AssertEqual(t, operations, []string{ChangeInsert, ChangeUpdate, ChangeDelete})
+
+			nextEvents, err := c.ReadChanges(ctx, tables.table("users"), events[0].ID, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(nextEvents), 1)
+			tt.AssertEqual(t, nextEvents[0].ID, events[1].ID)
+			tt.AssertEqual(t, nextEvents[0].ChangedAt.IsZero(), false)
+		})
+
+		t.Run("should stop recording the changes after DisableCDC", func(t *testing.T) {
+			events, err := c.ReadChanges(ctx, tables.table("users"), 0, 0)
+			tt.AssertNoErr(t, err)
+
+			err = c.DisableCDC(ctx, tables.table("users"))
+			tt.AssertNoErr(t, err)
+
+			err = c.Insert(ctx, tables.table("users"), &user{Name: "Caio"})
+			tt.AssertNoErr(t, err)
+
+			newEvents, err := c.ReadChanges(ctx, tables.table("users"), events[len(events)-1].ID, 0)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(newEvents), 0)
+		})
+	})
+}
+
+// CreateTableTest runs all tests for making sure the tables created
+// with CreateTableQuery are working for a given adapter and driver.
+func CreateTableTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	switch driver {
+	case "postgres", "sqlite3", "mysql", "sqlserver":
+	default:
+		// The other dialects are not supported
+		return
+	}
+
+	t.Run("CreateTable", func(t *testing.T) {
+		ctx := context.Background()
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		c := newTestDB(db, driver)
+
+		type record struct {
+			ID      uint    `ksql:"id"`
+			Name    string  `ksql:"name"`
+			Age     *int    `ksql:"age"`
+			Active  bool    `ksql:"active"`
+			Score   float64 `ksql:"score"`
+			Avatar  []byte  `ksql:"avatar"`
+			Address address `ksql:"address,json"`
+		}
+		recordsTable := tables.table("created_records")
+
+		db.ExecContext(ctx, `DROP TABLE `+tables.tableName("created_records"))
+
+		query, err := c.CreateTableQuery(recordsTable, record{})
+		tt.AssertNoErr(t, err)
+		_, err = c.Exec(ctx, query)
+		tt.AssertNoErr(t, err)
+
+		t.Run("should write and read records on the created table", func(t *testing.T) {
+			age := 42
+			r := record{
+				Name:    "Bia",
+				Age:     &age,
+				Active:  true,
+				Score:   1.5,
+				Avatar:  []byte("fake-avatar"),
+				Address: address{City: "Belo Horizonte"},
+			}
+			err := c.Insert(ctx, recordsTable, &r)
+			tt.AssertNoErr(t, err)
+			tt.AssertNotEqual(t, r.ID, uint(0))
+
+			var result record
+			err = c.QueryOne(ctx, &result, "FROM "+tables.tableName("created_records")+" WHERE id = "+c.dialect.Placeholder(0), r.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result, r)
+		})
+	})
+}
+
+// BlobTest runs all tests for making sure the `blob` modifier
+// and the QueryBlob method are working for a given adapter and driver.
+func BlobTest(
+	t *testing.T,
+	driver string,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+	opts ...AdapterTestOptions,
+) {
+	tables := testTables(opts)
+
+	t.Run("Blob", func(t *testing.T) {
+		ctx := context.Background()
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		c := newTestDB(db, driver)
+
+		createQuery := map[string]string{
+			"sqlite3":   `CREATE TABLE ` + tables.tableName("blobs") + ` (id INTEGER PRIMARY KEY, data BLOB)`,
+			"postgres":  `CREATE TABLE ` + tables.tableName("blobs") + ` (id serial PRIMARY KEY, data BYTEA)`,
+			"mysql":     `CREATE TABLE ` + tables.tableName("blobs") + ` (id INT AUTO_INCREMENT PRIMARY KEY, data LONGBLOB)`,
+			"sqlserver": `CREATE TABLE ` + tables.tableName("blobs") + ` (id INT IDENTITY(1,1) PRIMARY KEY, data VARBINARY(MAX))`,
+		}[driver]
+
+		db.ExecContext(ctx, `DROP TABLE `+tables.tableName("blobs"))
+		_, err := db.ExecContext(ctx, createQuery)
+		tt.AssertNoErr(t, err)
+
+		type blobRecord struct {
+			ID   int       `ksql:"id"`
+			Data io.Reader `ksql:"data,blob"`
+		}
+		blobsTable := tables.table("blobs")
+
+		// Force the values to be read in several chunks:
+		defer func(size int) { blobChunkSize = size }(blobChunkSize)
+		blobChunkSize = 4
+
+		t.Run("should write and read blobs", func(t *testing.T) {
+			content := []byte("binary\x00content\xff")
+
+			record := blobRecord{Data: bytes.NewReader(content)}
+			err := c.Insert(ctx, blobsTable, &record)
+			tt.AssertNoErr(t, err)
+
+			var buf bytes.Buffer
+			err = c.QueryBlob(ctx, &buf, `SELECT data FROM `+tables.tableName("blobs")+` WHERE id = `+c.dialect.Placeholder(0), record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, buf.Bytes(), content)
+
+			var result blobRecord
+			err = c.Find(ctx, blobsTable, &result, record.ID)
+			tt.AssertNoErr(t, err)
+
+			data, err := ioutil.ReadAll(result.Data)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, data, content)
+		})
+
+		t.Run("should write NULL for nil readers", func(t *testing.T) {
+			record := blobRecord{}
+			err := c.Insert(ctx, blobsTable, &record)
+			tt.AssertNoErr(t, err)
+
+			var buf bytes.Buffer
+			err = c.QueryBlob(ctx, &buf, `SELECT data FROM `+tables.tableName("blobs")+` WHERE id = `+c.dialect.Placeholder(0), record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, buf.Len(), 0)
+
+			result := blobRecord{Data: strings.NewReader("not nil")}
+			err = c.Find(ctx, blobsTable, &result, record.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Data, nil)
+		})
+
+		t.Run("should return ErrRecordNotFound if no rows are returned", func(t *testing.T) {
+			var buf bytes.Buffer
+			err := c.QueryBlob(ctx, &buf, `SELECT data FROM `+tables.tableName("blobs")+` WHERE id = `+c.dialect.Placeholder(0), -1)
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+		})
+
+		if driver == "postgres" {
+			t.Run("should write and read large objects", func(t *testing.T) {
+				content := []byte("large object content")
+
+				oid, err := c.InsertLargeObject(ctx, bytes.NewReader(content))
+				tt.AssertNoErr(t, err)
+				defer c.Exec(ctx, `SELECT lo_unlink($1)`, oid)
+
+				var buf bytes.Buffer
+				err = c.QueryBlob(ctx, &buf, `SELECT $1::oid`, oid, LargeObject())
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, buf.Bytes(), content)
+			})
+		}
+	})
+}
+
+func createTables(driver string, connStr string, tables AdapterTestOptions) error {
 	if connStr == "" {
 		return fmt.Errorf("unsupported driver: '%s'", driver)
 	}
@@ -2259,32 +4687,32 @@ func createTables(driver string, connStr string) error {
 	}
 	defer db.Close()
 
-	db.Exec(`DROP TABLE users`)
+	db.Exec(`DROP TABLE ` + tables.tableName("users"))
 
 	switch driver {
 	case "sqlite3":
-		_, err = db.Exec(`CREATE TABLE users (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("users") + ` (
 		  id INTEGER PRIMARY KEY,
 			age INTEGER,
 			name TEXT,
 			address BLOB
 		)`)
 	case "postgres":
-		_, err = db.Exec(`CREATE TABLE users (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("users") + ` (
 		  id serial PRIMARY KEY,
 			age INT,
 			name VARCHAR(50),
 			address jsonb
 		)`)
 	case "mysql":
-		_, err = db.Exec(`CREATE TABLE users (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("users") + ` (
 			id INT AUTO_INCREMENT PRIMARY KEY,
 			age INT,
 			name VARCHAR(50),
 			address JSON
 		)`)
 	case "sqlserver":
-		_, err = db.Exec(`CREATE TABLE users (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("users") + ` (
 			id INT IDENTITY(1,1) PRIMARY KEY,
 			age INT,
 			name VARCHAR(50),
@@ -2295,29 +4723,29 @@ func createTables(driver string, connStr string) error {
 		return fmt.Errorf("failed to create new users table: %s", err.Error())
 	}
 
-	db.Exec(`DROP TABLE posts`)
+	db.Exec(`DROP TABLE ` + tables.tableName("posts"))
 
 	switch driver {
 	case "sqlite3":
-		_, err = db.Exec(`CREATE TABLE posts (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("posts") + ` (
 		  id INTEGER PRIMARY KEY,
 		  user_id INTEGER,
 			title TEXT
 		)`)
 	case "postgres":
-		_, err = db.Exec(`CREATE TABLE posts (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("posts") + ` (
 		  id serial PRIMARY KEY,
 			user_id INT,
 			title VARCHAR(50)
 		)`)
 	case "mysql":
-		_, err = db.Exec(`CREATE TABLE posts (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("posts") + ` (
 			id INT AUTO_INCREMENT PRIMARY KEY,
 			user_id INT,
 			title VARCHAR(50)
 		)`)
 	case "sqlserver":
-		_, err = db.Exec(`CREATE TABLE posts (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("posts") + ` (
 			id INT IDENTITY(1,1) PRIMARY KEY,
 			user_id INT,
 			title VARCHAR(50)
@@ -2327,32 +4755,32 @@ func createTables(driver string, connStr string) error {
 		return fmt.Errorf("failed to create new posts table: %s", err.Error())
 	}
 
-	db.Exec(`DROP TABLE user_permissions`)
+	db.Exec(`DROP TABLE ` + tables.tableName("user_permissions"))
 
 	switch driver {
 	case "sqlite3":
-		_, err = db.Exec(`CREATE TABLE user_permissions (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("user_permissions") + ` (
 			id INTEGER PRIMARY KEY,
 			user_id INTEGER,
 			perm_id INTEGER,
 			UNIQUE (user_id, perm_id)
 		)`)
 	case "postgres":
-		_, err = db.Exec(`CREATE TABLE user_permissions (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("user_permissions") + ` (
 			id serial PRIMARY KEY,
 			user_id INT,
 			perm_id INT,
 			UNIQUE (user_id, perm_id)
 		)`)
 	case "mysql":
-		_, err = db.Exec(`CREATE TABLE user_permissions (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("user_permissions") + ` (
 			id INT AUTO_INCREMENT PRIMARY KEY,
 			user_id INT,
 			perm_id INT,
 			UNIQUE KEY (user_id, perm_id)
 		)`)
 	case "sqlserver":
-		_, err = db.Exec(`CREATE TABLE user_permissions (
+		_, err = db.Exec(`CREATE TABLE ` + tables.tableName("user_permissions") + ` (
 			id INT IDENTITY(1,1) PRIMARY KEY,
 			user_id INT,
 			perm_id INT,
@@ -2368,9 +4796,10 @@ func createTables(driver string, connStr string) error {
 
 func newTestDB(db DBAdapter, driver string) DB {
 	return DB{
-		driver:  driver,
-		dialect: supportedDialects[driver],
-		db:      db,
+		driver:    driver,
+		dialect:   supportedDialects[driver],
+		db:        db,
+		lifecycle: &lifecycle{},
 	}
 }
 
@@ -2380,8 +4809,8 @@ func shiftErrSlice(errs *[]error) error {
 	return err
 }
 
-func getUserByID(db DBAdapter, dialect Dialect, result *user, id uint) error {
-	rows, err := db.QueryContext(context.TODO(), `SELECT id, name, age, address FROM users WHERE id=`+dialect.Placeholder(0), id)
+func getUserByID(db DBAdapter, tables AdapterTestOptions, dialect Dialect, result *user, id uint) error {
+	rows, err := db.QueryContext(context.TODO(), `SELECT id, name, age, address FROM `+tables.tableName("users")+` WHERE id=`+dialect.Placeholder(0), id)
 	if err != nil {
 		return err
 	}
@@ -2395,8 +4824,8 @@ func getUserByID(db DBAdapter, dialect Dialect, result *user, id uint) error {
 	}
 
 	value := jsonSerializable{
-		DriverName: dialect.DriverName(),
-		Attr:       &result.Address,
+		Dialect: dialect,
+		Attr:    &result.Address,
 	}
 
 	err = rows.Scan(&result.ID, &result.Name, &result.Age, &value)
@@ -2407,10 +4836,10 @@ func getUserByID(db DBAdapter, dialect Dialect, result *user, id uint) error {
 	return nil
 }
 
-func getUserByName(db DBAdapter, driver string, result *user, name string) error {
+func getUserByName(db DBAdapter, tables AdapterTestOptions, driver string, result *user, name string) error {
 	dialect := supportedDialects[driver]
 
-	rows, err := db.QueryContext(context.TODO(), `SELECT id, name, age, address FROM users WHERE name=`+dialect.Placeholder(0), name)
+	rows, err := db.QueryContext(context.TODO(), `SELECT id, name, age, address FROM `+tables.tableName("users")+` WHERE name=`+dialect.Placeholder(0), name)
 	if err != nil {
 		return err
 	}
@@ -2436,11 +4865,11 @@ func getUserByName(db DBAdapter, driver string, result *user, name string) error
 	return json.Unmarshal(rawAddr, &result.Address)
 }
 
-func getUserPermissionsByUser(db DBAdapter, driver string, userID int) (results []userPermission, _ error) {
+func getUserPermissionsByUser(db DBAdapter, tables AdapterTestOptions, driver string, userID int) (results []userPermission, _ error) {
 	dialect := supportedDialects[driver]
 
 	rows, err := db.QueryContext(context.TODO(),
-		`SELECT id, user_id, perm_id FROM user_permissions WHERE user_id=`+dialect.Placeholder(0),
+		`SELECT id, user_id, perm_id FROM `+tables.tableName("user_permissions")+` WHERE user_id=`+dialect.Placeholder(0),
 		userID,
 	)
 	if err != nil {
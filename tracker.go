@@ -0,0 +1,214 @@
+package ksql
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// Tracker records which fields of a struct were modified since it
+// started being tracked, it is created by the `ksql.Track()` function.
+//
+// When a Tracker is passed to the Patch method instead of the record
+// itself only the changed columns are included in the SET clause
+// of the UPDATE statement, e.g.:
+//
+//	var user User
+//	err := db.QueryOne(ctx, &user, "FROM users WHERE id = $1", userID)
+//	if err != nil {
+//		return err
+//	}
+//
+//	tracker, err := ksql.Track(&user)
+//	if err != nil {
+//		return err
+//	}
+//
+//	user.Name = "new name"
+//
+//	// Only the `name` column will be updated:
+//	err = db.Patch(ctx, UsersTable, tracker)
+//
+// Unlike on a regular Patch, pointer attributes changed to nil are
+// included in the update and set to NULL.
+//
+// After a successful Patch the Tracker is reset so it
+// will only report the changes made after the update.
+type Tracker struct {
+	record   interface{}
+	snapshot map[string]interface{}
+}
+
+// Track starts tracking the changes made to the input record,
+// which must be a pointer to a struct using the `ksql` tags.
+func Track(record interface{}) (*Tracker, error) {
+	v := reflect.ValueOf(record)
+	t := v.Type()
+	if err := assertStructPtr(t); err != nil {
+		return nil, fmt.Errorf("ksql: expected record to be a pointer to struct, but got: %T", record)
+	}
+
+	if v.IsNil() {
+		return nil, fmt.Errorf("ksql: expected a valid pointer to struct as argument but received a nil pointer: %v", record)
+	}
+
+	info, err := structs.GetTagInfo(t.Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsNestedStruct {
+		return nil, fmt.Errorf("ksql: can't track structs using the `tablename` tag, only structs with `ksql` tags can be tracked")
+	}
+
+	tracker := &Tracker{
+		record: record,
+	}
+	tracker.Reset()
+
+	return tracker, nil
+}
+
+// Record returns the record being tracked
+func (t *Tracker) Record() interface{} {
+	return t.record
+}
+
+// Reset discards the changes recorded so far, so that the current
+// state of the record is considered unchanged.
+func (t *Tracker) Reset() {
+	t.snapshot = takeSnapshot(t.record)
+}
+
+// ChangedColumns returns the sorted list of the
+// columns modified since the last reset.
+func (t *Tracker) ChangedColumns() []string {
+	current := takeSnapshot(t.record)
+
+	var changed []string
+	for col, value := range current {
+		if !reflect.DeepEqual(value, t.snapshot[col]) {
+			changed = append(changed, col)
+		}
+	}
+	sort.Strings(changed)
+
+	return changed
+}
+
+// filterChanges removes from the recordMap all the
+// columns that are neither changed nor ID columns.
+//
+// The changed columns missing from the recordMap, i.e. the nil
+// pointers, are kept with a nil value so they are set to NULL.
+func (t *Tracker) filterChanges(recordMap map[string]interface{}, idColumns []string) map[string]interface{} {
+	filtered := map[string]interface{}{}
+	for _, col := range t.ChangedColumns() {
+		filtered[col] = recordMap[col]
+	}
+
+	for _, col := range idColumns {
+		if value, found := recordMap[col]; found {
+			filtered[col] = value
+		}
+	}
+
+	return filtered
+}
+
+// takeSnapshot copies all the values of the fields
+// with a `ksql` tag into a map.
+//
+// Values that might be changed in place, like slices, maps and
+// pointers, are deep copied so the snapshot won't share memory
+// with the record.
+func takeSnapshot(record interface{}) map[string]interface{} {
+	v := reflect.ValueOf(record).Elem()
+	info, _ := structs.GetTagInfo(v.Type())
+
+	snapshot := map[string]interface{}{}
+	visited := map[uintptr]reflect.Value{}
+	for i := 0; i < v.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid {
+			continue
+		}
+
+		snapshot[fieldInfo.Name] = deepCopy(v.Field(i), visited).Interface()
+	}
+
+	return snapshot
+}
+
+// deepCopy returns a copy of the value that doesn't share memory with it,
+// the unexported fields of structs are copied as they are since they can't
+// be set through reflection.
+//
+// The copies of the pointers and maps are kept on the visited map so
+// cyclic values, e.g. a parent and child pointing to each other, are
+// copied with the same cycles instead of recursing forever.
+func deepCopy(v reflect.Value, visited map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		// A struct and its first field share the same address, so the type is also checked:
+		if c, found := visited[v.Pointer()]; found && c.Type() == v.Type() {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		visited[v.Pointer()] = c
+		c.Elem().Set(deepCopy(v.Elem(), visited))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem(), visited))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), visited))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), visited))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		if c, found := visited[v.Pointer()]; found && c.Type() == v.Type() {
+			return c
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		visited[v.Pointer()] = c
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value(), visited))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i), visited))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,148 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/nullable"
+)
+
+// treeNode is used for testing records with cyclic values
+type treeNode struct {
+	Name     string
+	Parent   *treeNode
+	Children []*treeNode
+}
+
+// secret has no exported fields and therefore can't be compared as JSON
+type secret struct {
+	value map[string]string
+}
+
+func TestTracker(t *testing.T) {
+	type trackedUser struct {
+		ID      int      `ksql:"id"`
+		Name    string   `ksql:"name"`
+		Age     *int     `ksql:"age"`
+		Tags    []string `ksql:"tags,json"`
+		Secret  secret   `ksql:"secret"`
+		Ignored string
+	}
+
+	t.Run("should report the changed columns correctly", func(t *testing.T) {
+		u := trackedUser{
+			ID:   42,
+			Name: "fake-name",
+			Tags: []string{"a", "b"},
+		}
+		tracker, err := Track(&u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, tracker.ChangedColumns(), []string(nil))
+
+		u.Age = nullable.Int(22)
+		u.Tags[0] = "c"
+		u.Ignored = "changed"
+		tt.AssertEqual(t, tracker.ChangedColumns(), []string{"age", "tags"})
+
+		tracker.Reset()
+		tt.AssertEqual(t, tracker.ChangedColumns(), []string(nil))
+
+		u.Secret = secret{value: map[string]string{"key": "value"}}
+		tt.AssertEqual(t, tracker.ChangedColumns(), []string{"secret"})
+
+		tracker.Reset()
+		*u.Age = 23
+		tt.AssertEqual(t, tracker.ChangedColumns(), []string{"age"})
+	})
+
+	t.Run("should report error for invalid inputs", func(t *testing.T) {
+		_, err := Track(trackedUser{})
+		tt.AssertErrContains(t, err, "expected record to be a pointer to struct")
+
+		var nilUser *trackedUser
+		_, err = Track(nilUser)
+		tt.AssertErrContains(t, err, "nil pointer")
+
+		_, err = Track(&struct {
+			User trackedUser `tablename:"u"`
+		}{})
+		tt.AssertErrContains(t, err, "tablename")
+	})
+
+	t.Run("should update only the changed columns on Patch", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				queries = append(queries, query)
+				params = append(params, args)
+				return NewMockResult(0, 1), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		u := trackedUser{ID: 42, Name: "fake-name"}
+		tracker, err := Track(&u)
+		tt.AssertNoErr(t, err)
+
+		// Should not send any query since nothing changed:
+		err = db.Patch(context.Background(), usersTable, tracker)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(queries), 0)
+
+		u.Name = "new-name"
+		err = db.Patch(context.Background(), usersTable, tracker)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{`UPDATE "users" SET "name" = $1 WHERE "id" = $2`})
+		tt.AssertEqual(t, params, [][]interface{}{{"new-name", 42}})
+
+		// The tracker should be reset after a successful update:
+		tt.AssertEqual(t, tracker.ChangedColumns(), []string(nil))
+	})
+
+	t.Run("should set the pointers changed to nil to NULL on Patch", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		db, err := NewWithAdapter(mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				queries = append(queries, query)
+				params = append(params, args)
+				return NewMockResult(0, 1), nil
+			},
+		}, "postgres")
+		tt.AssertNoErr(t, err)
+
+		u := trackedUser{ID: 42, Name: "fake-name", Age: nullable.Int(22), Tags: []string{"a"}}
+		tracker, err := Track(&u)
+		tt.AssertNoErr(t, err)
+
+		u.Age = nil
+		err = db.Patch(context.Background(), usersTable, tracker)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{`UPDATE "users" SET "age" = $1 WHERE "id" = $2`})
+		tt.AssertEqual(t, params, [][]interface{}{{nil, 42}})
+		tt.AssertEqual(t, tracker.ChangedColumns(), []string(nil))
+	})
+	t.Run("should track records with cyclic values", func(t *testing.T) {
+		type treeRecord struct {
+			ID   int       `ksql:"id"`
+			Root *treeNode `ksql:"root"`
+		}
+
+		root := &treeNode{Name: "root"}
+		root.Children = []*treeNode{{Name: "child", Parent: root}}
+		record := treeRecord{ID: 42, Root: root}
+
+		tracker, err := Track(&record)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, tracker.ChangedColumns(), []string(nil))
+
+		snapshot := tracker.snapshot["root"].(*treeNode)
+		tt.AssertEqual(t, snapshot != root, true)
+		tt.AssertEqual(t, snapshot.Children[0].Parent == snapshot, true)
+
+		root.Children[0].Name = "renamed-child"
+		tt.AssertEqual(t, tracker.ChangedColumns(), []string{"root"})
+	})
+}
@@ -0,0 +1,250 @@
+package ksql
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ErrPartialCommit is returned by TransactAll when some of the
+// transactions were committed and others were not, in this case
+// the compensation functions of the committed ones are called.
+var ErrPartialCommit error = fmt.Errorf("ksql: only some of the transactions were committed")
+
+// WithTwoPhaseCommit informs the DB that the database supports prepared
+// transactions, so `ksql.TransactAll()` can use two-phase commit.
+//
+// Only the postgres dialect supports it, and note that postgres disables
+// prepared transactions by default, see the `max_prepared_transactions`
+// configuration.
+func WithTwoPhaseCommit() Option {
+	return func(db *DB) {
+		db.twoPhaseCommit = true
+	}
+}
+
+// MultiTx is passed to the `ksql.TransactAll()` callback
+type MultiTx struct {
+	// Txs contains one transaction for each of the
+	// providers passed to TransactAll, in the same order.
+	Txs []Provider
+
+	compensations map[int][]func(ctx context.Context) error
+}
+
+// Compensate registers a function for undoing the changes made using
+// `Txs[idx]`, it is only called by the best-effort mode of TransactAll
+// when this transaction was committed but some other one was not.
+func (m *MultiTx) Compensate(idx int, fn func(ctx context.Context) error) {
+	m.compensations[idx] = append(m.compensations[idx], fn)
+}
+
+// TransactAll runs the callback with one transaction open on each of
+// the input providers, e.g. one for each shard, and commits all of them
+// if the callback returns no error or rolls all of them back otherwise:
+//
+//	err := ksql.TransactAll(ctx, func(mtx *ksql.MultiTx) error {
+//		err := mtx.Txs[0].Delete(ctx, ordersTable, orderID)
+//		if err != nil {
+//			return err
+//		}
+//
+//		return mtx.Txs[1].Insert(ctx, archivedOrdersTable, &order)
+//	}, euDB, usDB)
+//
+// If all the providers are ksql.DB instances created with the
+// WithTwoPhaseCommit option, two-phase commit is used, i.e. all transactions
+// are prepared with `PREPARE TRANSACTION` before any of them is committed.
+// If a `COMMIT PREPARED` fails the error contains the IDs of the transactions
+// left prepared, which have to be committed manually.
+//
+// Otherwise the transactions are committed one by one, from the last provider
+// to the first, which is a best-effort approach: if one of the commits fails
+// the transactions not committed yet are rolled back, the compensation
+// functions of the ones already committed are called, and the returned
+// error wraps ErrPartialCommit.
+func TransactAll(ctx context.Context, fn func(mtx *MultiTx) error, providers ...Provider) error {
+	if len(providers) == 0 {
+		return fmt.Errorf("ksql: TransactAll requires at least one provider")
+	}
+
+	mtx := &MultiTx{
+		Txs:           make([]Provider, len(providers)),
+		compensations: map[int][]func(ctx context.Context) error{},
+	}
+
+	useTwoPhaseCommit, err := canUseTwoPhaseCommit(providers)
+	if err != nil {
+		return err
+	}
+
+	if useTwoPhaseCommit {
+		return transactAllWithTwoPhaseCommit(ctx, fn, mtx, providers)
+	}
+
+	return transactAllBestEffort(ctx, fn, mtx, providers)
+}
+
+func canUseTwoPhaseCommit(providers []Provider) (bool, error) {
+	for _, provider := range providers {
+		db, ok := provider.(DB)
+		if !ok || !db.twoPhaseCommit {
+			return false, nil
+		}
+
+		if !capabilitiesOf(db.dialect).TwoPhaseCommit {
+			return false, fmt.Errorf(
+				"ksql: two-phase commit is not supported by the %s dialect",
+				db.dialect.DriverName(),
+			)
+		}
+	}
+
+	return true, nil
+}
+
+func transactAllBestEffort(ctx context.Context, fn func(mtx *MultiTx) error, mtx *MultiTx, providers []Provider) error {
+	var fnSucceeded bool
+	committed := make([]bool, len(providers))
+
+	var run func(idx int) error
+	run = func(idx int) error {
+		if idx == len(providers) {
+			err := fn(mtx)
+			fnSucceeded = err == nil
+			return err
+		}
+
+		err := providers[idx].Transaction(ctx, func(tx Provider) error {
+			mtx.Txs[idx] = tx
+			return run(idx + 1)
+		})
+		committed[idx] = err == nil
+		return err
+	}
+
+	err := run(0)
+	if err == nil || !fnSucceeded {
+		return err
+	}
+
+	// If we got here at least one of the commits failed:
+	var committedIdxs []int
+	for idx := len(providers) - 1; idx >= 0; idx-- {
+		if committed[idx] {
+			committedIdxs = append(committedIdxs, idx)
+		}
+	}
+
+	if len(committedIdxs) == 0 {
+		// No partial commits, all of them were rolled back:
+		return err
+	}
+
+	var compensationErrs []string
+	for _, idx := range committedIdxs {
+		for _, compensate := range mtx.compensations[idx] {
+			if compensationErr := compensate(ctx); compensationErr != nil {
+				compensationErrs = append(compensationErrs, fmt.Sprintf("provider %d: %s", idx, compensationErr))
+			}
+		}
+	}
+
+	if len(compensationErrs) > 0 {
+		return fmt.Errorf(
+			"%w: committed providers %v: %s: and the compensations failed: %s",
+			ErrPartialCommit, committedIdxs, err, strings.Join(compensationErrs, "; "),
+		)
+	}
+
+	return fmt.Errorf("%w: committed providers %v: %s", ErrPartialCommit, committedIdxs, err)
+}
+
+func transactAllWithTwoPhaseCommit(ctx context.Context, fn func(mtx *MultiTx) error, mtx *MultiTx, providers []Provider) error {
+	txIDs, err := newPreparedTxIDs(len(providers))
+	if err != nil {
+		return err
+	}
+
+	prepared := make([]bool, len(providers))
+
+	var run func(idx int) error
+	run = func(idx int) error {
+		if idx < len(providers) {
+			return providers[idx].Transaction(ctx, func(tx Provider) error {
+				mtx.Txs[idx] = tx
+				return run(idx + 1)
+			})
+		}
+
+		err := fn(mtx)
+		if err != nil {
+			return err
+		}
+
+		for i, tx := range mtx.Txs {
+			_, err := tx.Exec(ctx, "PREPARE TRANSACTION '"+txIDs[i]+"'")
+			if err != nil {
+				return fmt.Errorf("ksql: error preparing transaction of provider %d: %w", i, err)
+			}
+			prepared[i] = true
+		}
+
+		// After the PREPARE the sessions have no transaction in progress,
+		// so the commits executed by each Transaction call have no effect.
+		return nil
+	}
+
+	err = run(0)
+	if err != nil {
+		// The prepared transactions are no longer bound to their sessions,
+		// so the rollbacks executed by each Transaction call won't affect them:
+		for i, provider := range providers {
+			if prepared[i] {
+				_, rollbackErr := provider.Exec(ctx, "ROLLBACK PREPARED '"+txIDs[i]+"'")
+				if rollbackErr != nil {
+					err = fmt.Errorf("%s: and unable to rollback prepared transaction '%s': %w", err, txIDs[i], rollbackErr)
+				}
+			}
+		}
+		return err
+	}
+
+	var pendingIDs []string
+	var commitErr error
+	for i, provider := range providers {
+		_, err := provider.Exec(ctx, "COMMIT PREPARED '"+txIDs[i]+"'")
+		if err != nil {
+			pendingIDs = append(pendingIDs, txIDs[i])
+			if commitErr == nil {
+				commitErr = err
+			}
+		}
+	}
+
+	if commitErr != nil {
+		return fmt.Errorf(
+			"ksql: all transactions were prepared but some could not be committed, "+
+				"the following prepared transactions must be committed manually: %s: %w",
+			strings.Join(pendingIDs, ", "), commitErr,
+		)
+	}
+
+	return nil
+}
+
+func newPreparedTxIDs(n int) ([]string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("ksql: unable to generate the prepared transaction IDs: %w", err)
+	}
+	prefix := "ksql_" + hex.EncodeToString(b)
+
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s_%d", prefix, i)
+	}
+	return ids, nil
+}
@@ -0,0 +1,149 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestTransactAll(t *testing.T) {
+	newProvider := func(name string, commitErr error, calls *[]string) Mock {
+		return Mock{
+			TransactionFn: func(ctx context.Context, fn func(db Provider) error) error {
+				err := fn(Mock{
+					ExecFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+						*calls = append(*calls, name+": "+query)
+						return NewMockResult(0, 1), nil
+					},
+				})
+				if err != nil {
+					*calls = append(*calls, name+": rollback")
+					return err
+				}
+
+				if commitErr != nil {
+					*calls = append(*calls, name+": commit failed")
+					return commitErr
+				}
+
+				*calls = append(*calls, name+": commit")
+				return nil
+			},
+		}
+	}
+
+	t.Run("should commit all transactions", func(t *testing.T) {
+		var calls []string
+		err := TransactAll(context.Background(), func(mtx *MultiTx) error {
+			for i, tx := range mtx.Txs {
+				_, err := tx.Exec(context.Background(), fmt.Sprintf("query %d", i))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}, newProvider("db0", nil, &calls), newProvider("db1", nil, &calls))
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, calls, []string{
+			"db0: query 0",
+			"db1: query 1",
+			"db1: commit",
+			"db0: commit",
+		})
+	})
+
+	t.Run("should rollback all transactions if the callback fails", func(t *testing.T) {
+		var calls []string
+		err := TransactAll(context.Background(), func(mtx *MultiTx) error {
+			return fmt.Errorf("fake-error")
+		}, newProvider("db0", nil, &calls), newProvider("db1", nil, &calls))
+		tt.AssertErrContains(t, err, "fake-error")
+		tt.AssertEqual(t, errors.Is(err, ErrPartialCommit), false)
+
+		tt.AssertEqual(t, calls, []string{
+			"db1: rollback",
+			"db0: rollback",
+		})
+	})
+
+	t.Run("should call the compensations of the committed transactions on partial commits", func(t *testing.T) {
+		var calls []string
+		err := TransactAll(context.Background(), func(mtx *MultiTx) error {
+			for i := range mtx.Txs {
+				i := i
+				mtx.Compensate(i, func(ctx context.Context) error {
+					calls = append(calls, fmt.Sprintf("compensate db%d", i))
+					return nil
+				})
+			}
+			return nil
+		},
+			newProvider("db0", nil, &calls),
+			newProvider("db1", fmt.Errorf("fake-commit-error"), &calls),
+			newProvider("db2", nil, &calls),
+		)
+		tt.AssertEqual(t, errors.Is(err, ErrPartialCommit), true)
+		tt.AssertErrContains(t, err, "fake-commit-error", "[2]")
+
+		tt.AssertEqual(t, calls, []string{
+			"db2: commit",
+			"db1: commit failed",
+			"db0: rollback",
+			"compensate db2",
+		})
+	})
+
+	t.Run("should use two-phase commit when all DBs support it", func(t *testing.T) {
+		var calls []string
+		newDB := func(name string) DB {
+			db, err := NewWithAdapter(mockTxBeginner{
+				mockDBAdapter: mockDBAdapter{
+					ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+						calls = append(calls, name+": "+query)
+						return NewMockResult(0, 1), nil
+					},
+				},
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					return mockTx{
+						mockDBAdapter: mockDBAdapter{
+							ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+								calls = append(calls, name+" tx: "+query)
+								return NewMockResult(0, 1), nil
+							},
+						},
+					}, nil
+				},
+			}, "postgres", WithTwoPhaseCommit())
+			tt.AssertNoErr(t, err)
+			return db
+		}
+
+		err := TransactAll(context.Background(), func(mtx *MultiTx) error {
+			_, err := mtx.Txs[0].Exec(context.Background(), "fake-query")
+			return err
+		}, newDB("db0"), newDB("db1"))
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, len(calls), 5)
+		tt.AssertEqual(t, calls[0], "db0 tx: fake-query")
+		tt.AssertEqual(t, strings.HasPrefix(calls[1], "db0 tx: PREPARE TRANSACTION 'ksql_"), true)
+		tt.AssertEqual(t, strings.HasPrefix(calls[2], "db1 tx: PREPARE TRANSACTION 'ksql_"), true)
+		tt.AssertEqual(t, strings.HasPrefix(calls[3], "db0: COMMIT PREPARED 'ksql_"), true)
+		tt.AssertEqual(t, strings.HasPrefix(calls[4], "db1: COMMIT PREPARED 'ksql_"), true)
+	})
+
+	t.Run("should report two-phase commit on unsupported dialects", func(t *testing.T) {
+		db, err := NewWithAdapter(mockTxBeginner{}, "sqlite3", WithTwoPhaseCommit())
+		tt.AssertNoErr(t, err)
+
+		err = TransactAll(context.Background(), func(mtx *MultiTx) error {
+			return nil
+		}, db)
+		tt.AssertErrContains(t, err, "two-phase commit", "sqlite3")
+	})
+}
@@ -0,0 +1,40 @@
+package ksql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// trimScanner implements the sql.Scanner interface in order to load
+// the attributes tagged with `trim` without the trailing spaces that
+// the databases add for padding the values of CHAR(n) columns, e.g.
+// on legacy schemas of sqlserver and oracle, so comparing the loaded
+// values with the ones written by the application works as expected.
+type trimScanner struct {
+	Attr reflect.Value
+}
+
+// Scan Implements the Scanner interface
+func (s trimScanner) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		s.Attr.Set(reflect.Zero(s.Attr.Type()))
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("unexpected type received to Scan a trimmed string: %T", value)
+	}
+
+	attr := s.Attr
+	if attr.Kind() == reflect.Ptr {
+		attr.Set(reflect.New(attr.Type().Elem()))
+		attr = attr.Elem()
+	}
+	attr.SetString(strings.TrimRight(text, " "))
+	return nil
+}
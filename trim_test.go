@@ -0,0 +1,50 @@
+package ksql
+
+import (
+	"reflect"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestTrimModifier(t *testing.T) {
+	t.Run("should remove the padding of the values returned by each driver", func(t *testing.T) {
+		tests := []struct {
+			desc         string
+			value        interface{}
+			expectedText string
+		}{
+			{desc: "strings", value: "BR  ", expectedText: "BR"},
+			{desc: "bytes", value: []byte("US "), expectedText: "US"},
+			{desc: "leading spaces", value: "  PT  ", expectedText: "  PT"},
+			{desc: "only spaces", value: "   ", expectedText: ""},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				type code string
+				var c code
+				err := trimScanner{Attr: reflect.ValueOf(&c).Elem()}.Scan(test.value)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, string(c), test.expectedText)
+			})
+		}
+	})
+
+	t.Run("should scan NULL into nil pointers", func(t *testing.T) {
+		s := new(string)
+		err := trimScanner{Attr: reflect.ValueOf(&s).Elem()}.Scan(nil)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, s == nil, true)
+
+		err = trimScanner{Attr: reflect.ValueOf(&s).Elem()}.Scan("BR  ")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, *s, "BR")
+	})
+
+	t.Run("should report error for values that are not text", func(t *testing.T) {
+		var s string
+		err := trimScanner{Attr: reflect.ValueOf(&s).Elem()}.Scan(int64(42))
+		tt.AssertErrContains(t, err, "int64")
+	})
+}
@@ -0,0 +1,39 @@
+package ksql
+
+import (
+	"database/sql/driver"
+)
+
+// TypedParam is sent in place of the values of the attributes tagged
+// with the `sqltype` modifier, e.g. `ksql:"email,sqltype=varchar"`,
+// so the adapter can send the value with the same type of the column.
+//
+// On sqlserver this avoids a well known performance trap: strings are
+// sent as NVARCHAR and times as DATETIMEOFFSET by default, so when
+// the column is a VARCHAR or a DATETIME the database converts the
+// column instead of the param, and the indexes of the column can't
+// be used for seeking the rows, e.g. on the WHERE clause of Patch.
+//
+// The ksqlserver adapter converts it to the param types of the driver,
+// the other adapters receive only the value, since it implements the
+// driver.Valuer interface. The accepted types are varchar, varchar(max)
+// and nvarchar(max) for strings and datetime, datetime2, datetimeoffset
+// and date for times.
+type TypedParam struct {
+	Param   interface{}
+	SQLType string
+}
+
+// Value implements the driver.Valuer interface
+func (p TypedParam) Value() (driver.Value, error) {
+	return driver.DefaultParameterConverter.ConvertValue(p.Param)
+}
+
+// encodeTypedParam wraps the value of an attribute tagged
+// with `sqltype` on the dialects that use the hint
+func encodeTypedParam(dialect Dialect, value interface{}, sqlType string) interface{} {
+	if !capabilitiesOf(dialect).TypedParams {
+		return value
+	}
+	return TypedParam{Param: value, SQLType: sqlType}
+}
@@ -0,0 +1,50 @@
+package ksql
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/vingarcia/ksql/internal/structs"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestTypedParams(t *testing.T) {
+	type user struct {
+		ID    int     `ksql:"id"`
+		Email *string `ksql:"email,sqltype=varchar"`
+	}
+
+	info, err := structs.GetTagInfo(reflect.TypeOf(user{}))
+	tt.AssertNoErr(t, err)
+
+	email := "john@example.com"
+
+	t.Run("should send the values tagged with sqltype as TypedParams on sqlserver", func(t *testing.T) {
+		recordMap := map[string]interface{}{"id": 1, "email": &email}
+		err := encodeColumnValues(supportedDialects["sqlserver"], nil, info, recordMap)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, recordMap, map[string]interface{}{
+			"id":    1,
+			"email": TypedParam{Param: &email, SQLType: "varchar"},
+		})
+	})
+
+	t.Run("should keep the values unchanged on the other dialects", func(t *testing.T) {
+		recordMap := map[string]interface{}{"id": 1, "email": &email}
+		err := encodeColumnValues(supportedDialects["postgres"], nil, info, recordMap)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, recordMap, map[string]interface{}{"id": 1, "email": &email})
+	})
+
+	t.Run("should send only the value to adapters unaware of the TypedParams", func(t *testing.T) {
+		value, err := TypedParam{Param: &email, SQLType: "varchar"}.Value()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, driver.Value(email))
+
+		var nilEmail *string
+		value, err = TypedParam{Param: nilEmail, SQLType: "varchar"}.Value()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, nil)
+	})
+}
@@ -0,0 +1,32 @@
+package ksql
+
+// Unwrapper can be implemented by the DBAdapter in order to expose
+// the native handle it uses with the `DB.Unwrap()` method.
+type Unwrapper interface {
+	Unwrap() interface{}
+}
+
+// Unwrap returns the native handle used by the adapter, e.g. the *sql.DB
+// of the database/sql adapters or the *pgxpool.Pool of kpgx, so that
+// driver specific features not covered by KSQL can be used without
+// maintaining a second connection pool.
+//
+// When called inside a transaction the native transaction is returned
+// instead, e.g. a *sql.Tx or a pgx.Tx, and adapters that don't implement
+// the Unwrapper interface are returned as they are.
+//
+// Each adapter also offers a typed helper for this, e.g. kpgx.PoolFromDB.
+func (c DB) Unwrap() interface{} {
+	base := c.db
+	for {
+		if unwrapper, ok := base.(Unwrapper); ok {
+			return unwrapper.Unwrap()
+		}
+
+		wrapper, ok := base.(adapterWrapper)
+		if !ok {
+			return base
+		}
+		base = wrapper.unwrapAdapter()
+	}
+}
@@ -0,0 +1,35 @@
+package ksql
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type mockUnwrapper struct {
+	mockDBAdapter
+	handle interface{}
+}
+
+func (m mockUnwrapper) Unwrap() interface{} {
+	return m.handle
+}
+
+func TestUnwrap(t *testing.T) {
+	t.Run("should return the native handle even when the adapter is wrapped", func(t *testing.T) {
+		handle := &struct{ Name string }{Name: "native handle"}
+		db, err := NewWithAdapter(WrapAdapter(mockUnwrapper{handle: handle}, AdapterHooks{}), "postgres")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, db.Unwrap(), handle)
+	})
+
+	t.Run("should return the adapter if it doesn't implement the Unwrapper interface", func(t *testing.T) {
+		adapter := mockDBAdapter{}
+		db, err := NewWithAdapter(adapter, "postgres")
+		tt.AssertNoErr(t, err)
+
+		_, ok := db.Unwrap().(mockDBAdapter)
+		tt.AssertEqual(t, ok, true)
+	})
+}
@@ -0,0 +1,323 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// maxParamsPerStatement is kept low enough for all supported
+// dialects, e.g. older versions of sqlite3 accept only 999 params.
+const maxParamsPerStatement = 999
+
+// UpdateMany applies a partial update to each of the records of the input
+// slice, as described on the Patch method, the records can be structs or
+// pointers to structs and the slice can also be passed by reference.
+//
+// If the Provider doesn't implement the BatchProvider interface the
+// records are patched one by one inside a single transaction.
+//
+// If some records fail to be updated it returns a BatchError
+// describing which records failed and why.
+func UpdateMany(ctx context.Context, db Provider, table Table, records interface{}, opts ...QueryOption) error {
+	if batchProvider, ok := db.(BatchProvider); ok {
+		return batchProvider.UpdateMany(ctx, table, records, opts...)
+	}
+
+	return patchOneByOne(ctx, db, table, records, opts)
+}
+
+func patchOneByOne(ctx context.Context, db Provider, table Table, records interface{}, opts []QueryOption) error {
+	v, err := decodeRecordsSlice(records)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(ctx, func(db Provider) error {
+		for i := 0; i < v.Len(); i++ {
+			err := db.Patch(WithQueryOptions(ctx, opts...), table, v.Index(i).Interface())
+			if err != nil {
+				return newBatchError("UpdateMany", []int{i}, err)
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateMany applies a partial update to each of the records of the input
+// slice inside a single transaction, as described on the Patch method.
+//
+// The records are grouped by the set of attributes they update, since nil
+// pointers are ignored, and each group is updated with as few statements as
+// possible using `CASE` expressions, which saves many round trips when
+// updating thousands of records.
+//
+// Unlike Patch, UpdateMany doesn't return ErrRecordNotFound if some records
+// don't exist, use the RowsAffected option for checking how many were updated.
+func (c DB) UpdateMany(ctx context.Context, table Table, records interface{}, opts ...QueryOption) error {
+	if err := checkUpdateByID(c.dialect, "UpdateMany"); err != nil {
+		return err
+	}
+
+	if c.requiresSessionTx() {
+		return c.Transaction(ctx, func(db Provider) error {
+			return db.(DB).UpdateMany(ctx, table, records, opts...)
+		})
+	}
+
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't update in ksql.Table: %s", err)
+	}
+
+	v, err := decodeRecordsSlice(records)
+	if err != nil {
+		return err
+	}
+
+	if v.Len() == 0 {
+		return nil
+	}
+
+	structType := v.Type().Elem()
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	info, err := structs.GetTagInfo(structType)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		record := v.Index(i)
+		if record.Kind() != reflect.Ptr {
+			record = record.Addr()
+		} else if record.IsNil() {
+			// The nil records are reported by groupRecordsByShape:
+			continue
+		}
+		if err := c.validateRecord(ctx, record.Interface()); err != nil {
+			return newBatchError("UpdateMany", []int{i}, err)
+		}
+	}
+
+	o := newQueryOptions(opts)
+	shapes, recordsByShape, indexesByShape, err := groupRecordsByShape(c.dialect, c.converters, v, info, table, o)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
+	var total int64
+	err = c.Transaction(ctx, func(db Provider) error {
+		for _, shape := range shapes {
+			columns := strings.Split(shape, ",")
+			group := recordsByShape[shape]
+			indexes := indexesByShape[shape]
+
+			paramsPerRecord := len(columns)*(len(table.idColumns)+1) + len(table.idColumns)
+			batchSize := maxParamsPerStatement / paramsPerRecord
+			if batchSize < 1 {
+				batchSize = 1
+			}
+
+			for start := 0; start < len(group); start += batchSize {
+				end := start + batchSize
+				if end > len(group) {
+					end = len(group)
+				}
+
+				query, params := buildUpdateManyQuery(c.dialect, table, info, columns, group[start:end])
+				query = addBatchHints(c.dialect, query)
+				query, err := addStatementHints(c.dialect, table, query, o)
+				if err != nil {
+					return err
+				}
+				result, err := db.(DB).execContext(ctx, OpInfo{Method: "UpdateMany", TableName: table.name}, o, query, params...)
+				if err == errDryRun {
+					continue
+				}
+				if err != nil {
+					return newBatchError("UpdateMany", indexes[start:end], err)
+				}
+
+				n, err := result.RowsAffected()
+				if err != nil {
+					return fmt.Errorf(
+						"unexpected error: unable to fetch how many rows were affected by the update: %s",
+						err,
+					)
+				}
+				total += n
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if o.rowsAffected != nil {
+		*o.rowsAffected = total
+	}
+
+	return nil
+}
+
+func decodeRecordsSlice(records interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(records)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("ksql: expected records to be a slice of structs, but got: %T", records)
+	}
+
+	if _, _, err := structs.DecodeAsSliceOfStructs(v.Type()); err != nil {
+		return reflect.Value{}, fmt.Errorf("ksql: expected records to be a slice of structs, but got: %T", records)
+	}
+
+	return v, nil
+}
+
+// groupRecordsByShape returns the shapes in the order they first
+// appear, each shape being the sorted list of updated columns, and
+// the records of each shape along with their indexes on the input.
+func groupRecordsByShape(
+	dialect Dialect,
+	converters map[string]Converter,
+	v reflect.Value,
+	info structs.StructInfo,
+	table Table,
+	opts queryOptions,
+) (
+	shapes []string,
+	recordsByShape map[string][]map[string]interface{},
+	indexesByShape map[string][]int,
+	_ error,
+) {
+	recordsByShape = map[string][]map[string]interface{}{}
+	indexesByShape = map[string][]int{}
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Ptr && elem.IsNil() {
+			return nil, nil, nil, fmt.Errorf("ksql: record %d is a nil pointer", i)
+		}
+
+		recordMap, err := structs.StructToMap(elem.Interface())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		_, err = normalizeIDsAsMap(table.idColumns, recordMap)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid record %d: %w", i, err)
+		}
+
+		removeGeneratedColumns(info, recordMap)
+		err = removeImmutableColumns(info, recordMap, table.idColumns, opts.strictImmutable)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		err = encodeColumnValues(dialect, converters, info, recordMap)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		columns := []string{}
+		for _, col := range sortedKeys(recordMap) {
+			if !containsString(table.idColumns, col) {
+				columns = append(columns, col)
+			}
+		}
+
+		if len(columns) == 0 {
+			// Nothing to update:
+			continue
+		}
+
+		shape := strings.Join(columns, ",")
+
+		if _, found := recordsByShape[shape]; !found {
+			shapes = append(shapes, shape)
+		}
+		recordsByShape[shape] = append(recordsByShape[shape], recordMap)
+		indexesByShape[shape] = append(indexesByShape[shape], i)
+	}
+
+	return shapes, recordsByShape, indexesByShape, nil
+}
+
+// buildUpdateManyQuery builds a query of the form:
+//
+//	UPDATE t SET
+//	  a = CASE WHEN id = $1 THEN $2 WHEN id = $3 THEN $4 ELSE a END,
+//	  ...
+//	WHERE (id = $5) OR (id = $6)
+//
+// The ELSE clause is never used because of the WHERE clause, but it
+// makes Postgres infer the types of the params from the column.
+func buildUpdateManyQuery(
+	dialect Dialect,
+	table Table,
+	info structs.StructInfo,
+	columns []string,
+	records []map[string]interface{},
+) (query string, params []interface{}) {
+	nextPlaceholder := func(value interface{}) string {
+		params = append(params, value)
+		return dialect.Placeholder(len(params) - 1)
+	}
+
+	matchRecord := func(recordMap map[string]interface{}) string {
+		conditions := make([]string, len(table.idColumns))
+		for i, id := range table.idColumns {
+			conditions[i] = dialect.Escape(id) + " = " + nextPlaceholder(recordMap[id])
+		}
+		return strings.Join(conditions, " AND ")
+	}
+
+	setQuery := make([]string, len(columns))
+	for i, col := range columns {
+		escapedCol := dialect.Escape(col)
+
+		var b strings.Builder
+		b.WriteString(escapedCol + " = CASE")
+		for _, recordMap := range records {
+			value := recordMap[col]
+			if info.ByName(col).SerializeAsJSON {
+				value = jsonSerializable{
+					Dialect:   dialect,
+					Attr:      value,
+					NilAsNull: info.ByName(col).NilAsNull,
+				}
+			}
+
+			condition := matchRecord(recordMap)
+			b.WriteString(" WHEN " + condition + " THEN " + nextPlaceholder(value))
+		}
+		b.WriteString(" ELSE " + escapedCol + " END")
+
+		setQuery[i] = b.String()
+	}
+
+	whereQuery := make([]string, len(records))
+	for i, recordMap := range records {
+		whereQuery[i] = "(" + matchRecord(recordMap) + ")"
+	}
+
+	query = fmt.Sprintf(
+		"UPDATE %s SET %s WHERE %s",
+		table.escapedName(dialect),
+		strings.Join(setQuery, ", "),
+		strings.Join(whereQuery, " OR "),
+	)
+
+	return query, params
+}
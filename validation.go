@@ -0,0 +1,151 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// RecordValidator can be implemented by the records in order to be validated
+// by the write operations, i.e. Insert, Patch, Upsert, InsertMany and
+// UpdateMany, before any SQL is generated for them.
+//
+// The Validate method can return a ValidationError or a FieldError for
+// describing which attributes are invalid, any other error is returned
+// wrapped in a ValidationError.
+type RecordValidator interface {
+	Validate() error
+}
+
+// Validator validates the records of the write operations of a DB, it is
+// configured with the WithValidator option, which allows integrating
+// validation libraries driven by struct tags.
+type Validator interface {
+	ValidateRecord(ctx context.Context, record interface{}) error
+}
+
+// ValidatorFunc implements the Validator interface with a function
+type ValidatorFunc func(ctx context.Context, record interface{}) error
+
+// ValidateRecord implements the Validator interface
+func (fn ValidatorFunc) ValidateRecord(ctx context.Context, record interface{}) error {
+	return fn(ctx, record)
+}
+
+// WithValidator configures a validator for the records of the write
+// operations, e.g. for validating the `validate` tags of the
+// go-playground/validator package:
+//
+//	validate := validator.New()
+//	db, err := kpgx.NewFromPgxPool(pool, ksql.WithValidator(
+//		ksql.ValidatorFunc(func(ctx context.Context, record interface{}) error {
+//			return validate.StructCtx(ctx, record)
+//		}),
+//	))
+//
+// The validators run in the order they were added, after the Validate
+// method of the records implementing the RecordValidator interface, and
+// the first error is returned wrapped in a ValidationError.
+func WithValidator(validator Validator) Option {
+	return func(db *DB) {
+		db.validators = append(db.validators, validator)
+	}
+}
+
+// FieldError describes why an attribute of a record is invalid
+type FieldError struct {
+	// Field is the name of the attribute on the struct
+	Field string
+
+	// Column is the name of the column from the ksql tag of the attribute,
+	// it is filled by ksql if the validator only sets the Field.
+	Column string
+
+	Message string
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned by the write operations when a record
+// is rejected by its Validate method or by one of the validators
+// configured with the WithValidator option.
+type ValidationError struct {
+	// Fields describes the invalid attributes, it is empty
+	// if the validator returned an error of a different type.
+	Fields []FieldError
+
+	// Err is the error returned by the validator if it was
+	// neither a ValidationError nor a FieldError.
+	Err error
+}
+
+func (e ValidationError) Error() string {
+	if e.Err != nil {
+		return "ksql: invalid record: " + e.Err.Error()
+	}
+
+	msgs := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		msgs[i] = field.Error()
+	}
+	return "ksql: invalid record: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the error returned by the validator
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validateRecord runs the Validate method of the record, if it implements
+// the RecordValidator interface, and then the configured validators.
+func (c DB) validateRecord(ctx context.Context, record interface{}) error {
+	if validator, ok := record.(RecordValidator); ok {
+		if err := validator.Validate(); err != nil {
+			return newValidationError(record, err)
+		}
+	}
+
+	for _, validator := range c.validators {
+		if err := validator.ValidateRecord(ctx, record); err != nil {
+			return newValidationError(record, err)
+		}
+	}
+
+	return nil
+}
+
+func newValidationError(record interface{}, err error) ValidationError {
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		var fieldErr FieldError
+		if !errors.As(err, &fieldErr) {
+			return ValidationError{Err: err}
+		}
+		validationErr = ValidationError{Fields: []FieldError{fieldErr}}
+	}
+
+	t := reflect.TypeOf(record)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// The slice is copied since it might be reused by the validator:
+	fields := make([]FieldError, len(validationErr.Fields))
+	for i, field := range validationErr.Fields {
+		if field.Column == "" && t.Kind() == reflect.Struct {
+			if f, found := t.FieldByName(field.Field); found {
+				field.Column = strings.Split(f.Tag.Get("ksql"), ",")[0]
+			}
+		}
+		fields[i] = field
+	}
+	validationErr.Fields = fields
+
+	return validationErr
+}
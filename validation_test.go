@@ -0,0 +1,121 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type validatedUser struct {
+	ID   uint   `ksql:"id"`
+	Name string `ksql:"name"`
+	Age  int    `ksql:"age"`
+}
+
+func (u *validatedUser) Validate() error {
+	var fields []FieldError
+	if u.Name == "" {
+		fields = append(fields, FieldError{Field: "Name", Message: "is required"})
+	}
+	if u.Age < 0 {
+		fields = append(fields, FieldError{Field: "Age", Message: "must not be negative"})
+	}
+	if len(fields) > 0 {
+		return ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func TestValidation(t *testing.T) {
+	ctx := context.Background()
+
+	newAdapter := func(queries *int) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, q string, args ...interface{}) (Result, error) {
+				*queries++
+				return NewMockResult(42, 1), nil
+			},
+		}
+	}
+
+	t.Run("should reject the records implementing RecordValidator before sending any query", func(t *testing.T) {
+		var queries int
+		db := newDB(t, "sqlite3", newAdapter(&queries))
+
+		err := db.Insert(ctx, usersTable, &validatedUser{Age: -1})
+		var validationErr ValidationError
+		tt.AssertEqual(t, errors.As(err, &validationErr), true)
+		tt.AssertEqual(t, validationErr.Fields, []FieldError{
+			{Field: "Name", Column: "name", Message: "is required"},
+			{Field: "Age", Column: "age", Message: "must not be negative"},
+		})
+		tt.AssertErrContains(t, err, "invalid record", "Name: is required", "Age: must not be negative")
+
+		err = db.Patch(ctx, usersTable, &validatedUser{ID: 1, Age: 10})
+		tt.AssertErrContains(t, err, "Name: is required")
+
+		err = db.Upsert(ctx, usersTable, &validatedUser{ID: 1, Age: 10})
+		tt.AssertErrContains(t, err, "Name: is required")
+
+		tt.AssertEqual(t, queries, 0)
+
+		err = db.Insert(ctx, usersTable, &validatedUser{Name: "Bia", Age: 22})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, 1)
+	})
+
+	t.Run("should run the validators configured with WithValidator", func(t *testing.T) {
+		var queries int
+		var validated []interface{}
+		db := newDB(t, "sqlite3", newAdapter(&queries), WithValidator(ValidatorFunc(func(ctx context.Context, record interface{}) error {
+			validated = append(validated, record)
+			if u, ok := record.(*user); ok && u.Name == "" {
+				return FieldError{Field: "Name", Message: "is required"}
+			}
+			return nil
+		})))
+
+		u := user{Age: 22}
+		err := db.Insert(ctx, usersTable, &u)
+		var validationErr ValidationError
+		tt.AssertEqual(t, errors.As(err, &validationErr), true)
+		tt.AssertEqual(t, validationErr.Fields, []FieldError{
+			{Field: "Name", Column: "name", Message: "is required"},
+		})
+		tt.AssertEqual(t, validated, []interface{}{&u})
+		tt.AssertEqual(t, queries, 0)
+	})
+
+	t.Run("should wrap the errors that don't describe fields", func(t *testing.T) {
+		var queries int
+		errInvalid := errors.New("the user is banned")
+		db := newDB(t, "sqlite3", newAdapter(&queries), WithValidator(ValidatorFunc(func(ctx context.Context, record interface{}) error {
+			return errInvalid
+		})))
+
+		err := db.Insert(ctx, usersTable, &user{Name: "Bia"})
+		tt.AssertEqual(t, errors.Is(err, errInvalid), true)
+		tt.AssertErrContains(t, err, "ksql: invalid record: the user is banned")
+		tt.AssertEqual(t, queries, 0)
+	})
+
+	t.Run("should report the invalid records of the batch operations", func(t *testing.T) {
+		var queries int
+		db := newDB(t, "sqlite3", newAdapter(&queries))
+
+		err := db.InsertMany(ctx, usersTable, []*validatedUser{{Name: "Bia"}, {Age: 10}})
+		var batchErr BatchError
+		tt.AssertEqual(t, errors.As(err, &batchErr), true)
+		tt.AssertEqual(t, batchErr.Failures[0].Index, 1)
+		tt.AssertErrContains(t, err, "Name: is required")
+
+		queries = 0
+		err = db.UpdateMany(ctx, usersTable, []validatedUser{{ID: 1, Name: "Bia"}, {ID: 2, Age: 10}})
+		tt.AssertEqual(t, errors.As(err, &batchErr), true)
+		tt.AssertEqual(t, batchErr.Failures[0].Index, 1)
+		tt.AssertErrContains(t, err, "Name: is required")
+		tt.AssertEqual(t, queries, 0)
+	})
+}
@@ -0,0 +1,376 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// AdapterHooks describes the callbacks called by the adapter
+// returned from `ksql.WrapAdapter()`, all of them are optional.
+//
+// The Before hooks can return an error to abort the operation before
+// it reaches the database, which is useful for chaos testing, and
+// the context they return is the one passed to the wrapped adapter,
+// which is useful for starting tracing spans for example.
+//
+// The After hooks are called with the error returned by the
+// operation, if any, and can't change the result of the operation.
+//
+// Note that if BeforeRollback returns an error the transaction is left
+// open, and if BeforeCommit returns an error the transaction is rolled back.
+type AdapterHooks struct {
+	BeforeExec func(ctx context.Context, query string, args []interface{}) (context.Context, error)
+	AfterExec  func(ctx context.Context, query string, args []interface{}, err error)
+
+	BeforeQuery func(ctx context.Context, query string, args []interface{}) (context.Context, error)
+	AfterQuery  func(ctx context.Context, query string, args []interface{}, err error)
+
+	BeforeBeginTx func(ctx context.Context) (context.Context, error)
+	AfterBeginTx  func(ctx context.Context, err error)
+
+	BeforeCommit func(ctx context.Context) error
+	AfterCommit  func(ctx context.Context, err error)
+
+	BeforeRollback func(ctx context.Context) error
+	AfterRollback  func(ctx context.Context, err error)
+}
+
+// WrapAdapter returns a DBAdapter that calls the input hooks around
+// each operation sent to the base adapter, including the ones
+// executed inside transactions, e.g.:
+//
+//	db, err := ksql.NewWithAdapter(ksql.WrapAdapter(adapter, ksql.AdapterHooks{
+//		AfterQuery: func(ctx context.Context, query string, args []interface{}, err error) {
+//			log.Println("query:", query, "error:", err)
+//		},
+//	}), "postgres")
+//
+// The returned adapter also forwards the optional features of the base adapter,
+// i.e. transactions, `ksql.Listener`, `ksql.CSVCopier`, `ksql.RowAppender`,
+// `ksql.ConnAcquirer` and `io.Closer`.
+func WrapAdapter(base DBAdapter, hooks AdapterHooks) DBAdapter {
+	return hookedAdapter{
+		base:  base,
+		hooks: hooks,
+	}
+}
+
+type hookedAdapter struct {
+	base  DBAdapter
+	hooks AdapterHooks
+}
+
+// ExecContext implements the DBAdapter interface
+func (h hookedAdapter) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	return hookedExec(ctx, h.base, h.hooks, query, args)
+}
+
+// QueryContext implements the DBAdapter interface
+func (h hookedAdapter) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return hookedQuery(ctx, h.base, h.hooks, query, args)
+}
+
+// BeginTx implements the TxBeginner interface
+func (h hookedAdapter) BeginTx(ctx context.Context) (Tx, error) {
+	return hookedBeginTx(ctx, h.base, h.hooks)
+}
+
+// AcquireConn implements the ConnAcquirer interface
+func (h hookedAdapter) AcquireConn(ctx context.Context) (Conn, error) {
+	acquirer, ok := h.base.(ConnAcquirer)
+	if !ok {
+		return nil, fmt.Errorf("can't acquire connection: The DBAdapter doesn't implement the ConnAcquirer interface")
+	}
+
+	conn, err := acquirer.AcquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return hookedConn{
+		base:  conn,
+		hooks: h.hooks,
+	}, nil
+}
+
+// Listen implements the Listener interface
+func (h hookedAdapter) Listen(ctx context.Context, channel string) (<-chan Notification, error) {
+	listener, ok := h.base.(Listener)
+	if !ok {
+		return nil, fmt.Errorf("can't listen on channel: The DBAdapter doesn't implement the Listener interface")
+	}
+
+	return listener.Listen(ctx, channel)
+}
+
+// CopyFromCSV implements the CSVCopier interface
+func (h hookedAdapter) CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+	return copyFromCSVIfSupported(ctx, h.base, tableName, columns, r)
+}
+
+// AppendRows implements the RowAppender interface
+func (h hookedAdapter) AppendRows(ctx context.Context, tableName string, columns []string, rows [][]interface{}) error {
+	return appendRowsIfSupported(ctx, h.base, tableName, columns, rows)
+}
+
+// Close implements the io.Closer interface
+func (h hookedAdapter) Close() error {
+	closer, ok := h.base.(io.Closer)
+	if !ok {
+		return nil
+	}
+
+	return closer.Close()
+}
+
+func (h hookedAdapter) unwrapAdapter() DBAdapter {
+	return h.base
+}
+
+type hookedConn struct {
+	base  Conn
+	hooks AdapterHooks
+}
+
+// ExecContext implements the DBAdapter interface
+func (h hookedConn) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	return hookedExec(ctx, h.base, h.hooks, query, args)
+}
+
+// QueryContext implements the DBAdapter interface
+func (h hookedConn) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return hookedQuery(ctx, h.base, h.hooks, query, args)
+}
+
+// BeginTx implements the TxBeginner interface
+func (h hookedConn) BeginTx(ctx context.Context) (Tx, error) {
+	return hookedBeginTx(ctx, h.base, h.hooks)
+}
+
+// CopyFromCSV implements the CSVCopier interface
+func (h hookedConn) CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+	return copyFromCSVIfSupported(ctx, h.base, tableName, columns, r)
+}
+
+// AppendRows implements the RowAppender interface
+func (h hookedConn) AppendRows(ctx context.Context, tableName string, columns []string, rows [][]interface{}) error {
+	return appendRowsIfSupported(ctx, h.base, tableName, columns, rows)
+}
+
+// Close implements the Conn interface
+func (h hookedConn) Close() error {
+	return h.base.Close()
+}
+
+func (h hookedConn) unwrapAdapter() DBAdapter {
+	return h.base
+}
+
+type hookedTx struct {
+	base  Tx
+	hooks AdapterHooks
+}
+
+// ExecContext implements the DBAdapter interface
+func (h hookedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	return hookedExec(ctx, h.base, h.hooks, query, args)
+}
+
+// QueryContext implements the DBAdapter interface
+func (h hookedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return hookedQuery(ctx, h.base, h.hooks, query, args)
+}
+
+// Commit implements the Tx interface
+//
+// If the BeforeCommit hook returns an error the transaction
+// is rolled back so it doesn't hold the connection forever.
+func (h hookedTx) Commit(ctx context.Context) error {
+	if h.hooks.BeforeCommit != nil {
+		if err := h.hooks.BeforeCommit(ctx); err != nil {
+			if rollbackErr := h.base.Rollback(ctx); rollbackErr != nil {
+				return fmt.Errorf("unable to rollback after error: %s: %w", err, rollbackErr)
+			}
+			return err
+		}
+	}
+
+	err := h.base.Commit(ctx)
+	if h.hooks.AfterCommit != nil {
+		h.hooks.AfterCommit(ctx, err)
+	}
+
+	return err
+}
+
+// Rollback implements the Tx interface
+func (h hookedTx) Rollback(ctx context.Context) error {
+	if h.hooks.BeforeRollback != nil {
+		if err := h.hooks.BeforeRollback(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := h.base.Rollback(ctx)
+	if h.hooks.AfterRollback != nil {
+		h.hooks.AfterRollback(ctx, err)
+	}
+
+	return err
+}
+
+// CopyFromCSV implements the CSVCopier interface
+func (h hookedTx) CopyFromCSV(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+	return copyFromCSVIfSupported(ctx, h.base, tableName, columns, r)
+}
+
+// AppendRows implements the RowAppender interface
+func (h hookedTx) AppendRows(ctx context.Context, tableName string, columns []string, rows [][]interface{}) error {
+	return appendRowsIfSupported(ctx, h.base, tableName, columns, rows)
+}
+
+func (h hookedTx) unwrapAdapter() DBAdapter {
+	return h.base
+}
+
+func hookedBeginTx(ctx context.Context, base DBAdapter, hooks AdapterHooks) (Tx, error) {
+	txBeginner, ok := base.(TxBeginner)
+	if !ok {
+		return nil, fmt.Errorf("can't start transaction: The DBAdapter doesn't implement the TxBeginner interface")
+	}
+
+	if hooks.BeforeBeginTx != nil {
+		var err error
+		ctx, err = hooks.BeforeBeginTx(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := txBeginner.BeginTx(ctx)
+	if hooks.AfterBeginTx != nil {
+		hooks.AfterBeginTx(ctx, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return hookedTx{
+		base:  tx,
+		hooks: hooks,
+	}, nil
+}
+
+func hookedExec(
+	ctx context.Context,
+	base DBAdapter,
+	hooks AdapterHooks,
+	query string,
+	args []interface{},
+) (Result, error) {
+	if hooks.BeforeExec != nil {
+		var err error
+		ctx, err = hooks.BeforeExec(ctx, query, args)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := base.ExecContext(ctx, query, args...)
+	if hooks.AfterExec != nil {
+		hooks.AfterExec(ctx, query, args, err)
+	}
+
+	return result, err
+}
+
+func hookedQuery(
+	ctx context.Context,
+	base DBAdapter,
+	hooks AdapterHooks,
+	query string,
+	args []interface{},
+) (Rows, error) {
+	if hooks.BeforeQuery != nil {
+		var err error
+		ctx, err = hooks.BeforeQuery(ctx, query, args)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := base.QueryContext(ctx, query, args...)
+	if hooks.AfterQuery != nil {
+		hooks.AfterQuery(ctx, query, args, err)
+	}
+
+	return rows, err
+}
+
+func copyFromCSVIfSupported(ctx context.Context, base DBAdapter, tableName string, columns []string, r io.Reader) error {
+	copier, ok := base.(CSVCopier)
+	if !ok {
+		return fmt.Errorf("can't copy from csv: The DBAdapter doesn't implement the CSVCopier interface")
+	}
+
+	return copier.CopyFromCSV(ctx, tableName, columns, r)
+}
+
+func appendRowsIfSupported(ctx context.Context, base DBAdapter, tableName string, columns []string, rows [][]interface{}) error {
+	appender, ok := base.(RowAppender)
+	if !ok {
+		return fmt.Errorf("can't append rows: The DBAdapter doesn't implement the RowAppender interface")
+	}
+
+	return appender.AppendRows(ctx, tableName, columns, rows)
+}
+
+// adapterWrapper is implemented by the adapters that wrap other
+// adapters and implement all optional interfaces regardless of the
+// base adapter supporting them, e.g. the one returned by WrapAdapter.
+type adapterWrapper interface {
+	unwrapAdapter() DBAdapter
+}
+
+// innermostAdapter returns the adapter wrapped by the
+// adapterWrappers or the input adapter if it is not one.
+func innermostAdapter(db DBAdapter) DBAdapter {
+	for {
+		wrapper, isWrapper := db.(adapterWrapper)
+		if !isWrapper {
+			return db
+		}
+		db = wrapper.unwrapAdapter()
+	}
+}
+
+// getCSVCopier returns the adapter as a CSVCopier
+// only if the innermost adapter supports it.
+func getCSVCopier(db DBAdapter) (CSVCopier, bool) {
+	copier, ok := db.(CSVCopier)
+	if !ok {
+		return nil, false
+	}
+
+	if _, ok := innermostAdapter(db).(CSVCopier); !ok {
+		return nil, false
+	}
+
+	return copier, true
+}
+
+// getRowAppender returns the adapter as a RowAppender
+// only if the innermost adapter supports it.
+func getRowAppender(db DBAdapter) (RowAppender, bool) {
+	appender, ok := db.(RowAppender)
+	if !ok {
+		return nil, false
+	}
+
+	if _, ok := innermostAdapter(db).(RowAppender); !ok {
+		return nil, false
+	}
+
+	return appender, true
+}
@@ -0,0 +1,205 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type hooksTestKey struct{}
+
+func TestWrapAdapter(t *testing.T) {
+	t.Run("should call the hooks around queries and transactions", func(t *testing.T) {
+		var calls []string
+		var ctxValues []interface{}
+		adapter := mockTxBeginner{
+			mockDBAdapter: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					calls = append(calls, "exec: "+query)
+					ctxValues = append(ctxValues, ctx.Value(hooksTestKey{}))
+					return NewMockResult(0, 1), nil
+				},
+			},
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				calls = append(calls, "begin")
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+							calls = append(calls, "query: "+query)
+							ctxValues = append(ctxValues, ctx.Value(hooksTestKey{}))
+							return newMockRows([]string{"id"}), nil
+						},
+					},
+					CommitFn: func(ctx context.Context) error {
+						calls = append(calls, "commit")
+						return nil
+					},
+				}, nil
+			},
+		}
+
+		db, err := NewWithAdapter(WrapAdapter(adapter, AdapterHooks{
+			BeforeExec: func(ctx context.Context, query string, args []interface{}) (context.Context, error) {
+				calls = append(calls, "before exec")
+				return context.WithValue(ctx, hooksTestKey{}, "exec-value"), nil
+			},
+			AfterExec: func(ctx context.Context, query string, args []interface{}, err error) {
+				calls = append(calls, fmt.Sprintf("after exec: %v", err))
+			},
+			BeforeQuery: func(ctx context.Context, query string, args []interface{}) (context.Context, error) {
+				calls = append(calls, "before query")
+				return context.WithValue(ctx, hooksTestKey{}, "query-value"), nil
+			},
+			AfterQuery: func(ctx context.Context, query string, args []interface{}, err error) {
+				calls = append(calls, fmt.Sprintf("after query: %v", err))
+			},
+			BeforeBeginTx: func(ctx context.Context) (context.Context, error) {
+				calls = append(calls, "before begin")
+				return ctx, nil
+			},
+			AfterBeginTx: func(ctx context.Context, err error) {
+				calls = append(calls, fmt.Sprintf("after begin: %v", err))
+			},
+			BeforeCommit: func(ctx context.Context) error {
+				calls = append(calls, "before commit")
+				return nil
+			},
+			AfterCommit: func(ctx context.Context, err error) {
+				calls = append(calls, fmt.Sprintf("after commit: %v", err))
+			},
+		}), "postgres")
+		tt.AssertNoErr(t, err)
+
+		_, err = db.Exec(context.Background(), "DELETE FROM users")
+		tt.AssertNoErr(t, err)
+
+		err = db.Transaction(context.Background(), func(db Provider) error {
+			var users []struct {
+				ID int `ksql:"id"`
+			}
+			return db.Query(context.Background(), &users, "SELECT id FROM users")
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, calls, []string{
+			"before exec",
+			"exec: DELETE FROM users",
+			"after exec: <nil>",
+			"before begin",
+			"begin",
+			"after begin: <nil>",
+			"before query",
+			"query: SELECT id FROM users",
+			"after query: <nil>",
+			"before commit",
+			"commit",
+			"after commit: <nil>",
+		})
+		tt.AssertEqual(t, ctxValues, []interface{}{"exec-value", "query-value"})
+	})
+
+	t.Run("should abort operations if a before hook returns an error", func(t *testing.T) {
+		var rolledBack bool
+		adapter := mockTxBeginner{
+			mockDBAdapter: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					t.Fatalf("the query should not have been executed: %s", query)
+					return nil, nil
+				},
+			},
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+							return NewMockResult(0, 1), nil
+						},
+					},
+					CommitFn: func(ctx context.Context) error {
+						t.Fatalf("the transaction should not have been committed")
+						return nil
+					},
+					RollbackFn: func(ctx context.Context) error {
+						rolledBack = true
+						return nil
+					},
+				}, nil
+			},
+		}
+
+		db, err := NewWithAdapter(WrapAdapter(adapter, AdapterHooks{
+			BeforeExec: func(ctx context.Context, query string, args []interface{}) (context.Context, error) {
+				if query == "DELETE FROM users" {
+					return ctx, fmt.Errorf("fake-chaos-error")
+				}
+				return ctx, nil
+			},
+			BeforeCommit: func(ctx context.Context) error {
+				return fmt.Errorf("fake-commit-error")
+			},
+		}), "postgres")
+		tt.AssertNoErr(t, err)
+
+		_, err = db.Exec(context.Background(), "DELETE FROM users")
+		tt.AssertErrContains(t, err, "fake-chaos-error")
+
+		err = db.Transaction(context.Background(), func(db Provider) error {
+			_, err := db.Exec(context.Background(), "UPDATE users SET age = 42")
+			return err
+		})
+		tt.AssertErrContains(t, err, "fake-commit-error")
+		tt.AssertEqual(t, rolledBack, true)
+	})
+
+	t.Run("should only use the CSVCopier if the base adapter implements it", func(t *testing.T) {
+		var copied string
+		db, err := NewWithAdapter(WrapAdapter(mockCSVCopier{
+			CopyFromCSVFn: func(ctx context.Context, tableName string, columns []string, r io.Reader) error {
+				b, err := ioutil.ReadAll(r)
+				copied = string(b)
+				return err
+			},
+		}, AdapterHooks{}), "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.InsertCSV(context.Background(), usersTable, strings.NewReader("name\nfoo\n"), CSVOptions{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, copied, "foo\n")
+
+		var queries []string
+		db, err = NewWithAdapter(WrapAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					mockDBAdapter: mockDBAdapter{
+						ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+							queries = append(queries, query)
+							return NewMockResult(0, 1), nil
+						},
+					},
+				}, nil
+			},
+		}, AdapterHooks{}), "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.InsertCSV(context.Background(), usersTable, strings.NewReader("name\nfoo\n"), CSVOptions{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{`INSERT INTO "users" ("name") VALUES ($1)`})
+	})
+
+	t.Run("should report missing features of the base adapter", func(t *testing.T) {
+		db, err := NewWithAdapter(WrapAdapter(mockDBAdapter{}, AdapterHooks{}), "postgres")
+		tt.AssertNoErr(t, err)
+
+		err = db.Transaction(context.Background(), func(db Provider) error {
+			return nil
+		})
+		tt.AssertErrContains(t, err, "TxBeginner")
+
+		_, err = db.Listen(context.Background(), "fake-channel")
+		tt.AssertErrContains(t, err, "Listener")
+	})
+}
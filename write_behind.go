@@ -0,0 +1,244 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned by the WriteBehindQueue when
+// there is no room left on the queue for a new write.
+var ErrQueueFull error = fmt.Errorf("ksql: the write-behind queue is full")
+
+// ErrQueueClosed is returned by the WriteBehindQueue
+// when a write is enqueued after the queue was closed.
+var ErrQueueClosed error = fmt.Errorf("ksql: the write-behind queue is closed")
+
+// WriteBehindConfig describes the optional configurations of the WriteBehindQueue
+type WriteBehindConfig struct {
+	// QueueSize is the maximum number of writes waiting
+	// to be flushed, it defaults to 10000
+	QueueSize int
+
+	// BatchSize is the number of pending writes that
+	// triggers a flush, it defaults to 100
+	BatchSize int
+
+	// FlushInterval is the maximum time a write waits
+	// before being flushed, it defaults to 1 second
+	FlushInterval time.Duration
+
+	// OnError is called with the error and the records of each batch
+	// that failed to be written, if the error is a BatchError its indexes
+	// refer to the records slice. The errors are ignored if it is unset.
+	OnError func(err error, table Table, records []interface{})
+}
+
+// SetDefaultValues should be called by all constructors
+// to set default values for the WriteBehindConfig
+func (c *WriteBehindConfig) SetDefaultValues() {
+	if c.QueueSize <= 0 {
+		c.QueueSize = 10000
+	}
+
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+}
+
+// WriteBehindQueue accepts Insert and Patch requests into a bounded
+// in-memory queue and writes them in the background using the
+// `ksql.InsertMany()` and `ksql.UpdateMany()` helpers, which is useful
+// for high-volume writes where losing a few records is acceptable,
+// like telemetry, e.g.:
+//
+//	queue := ksql.NewWriteBehindQueue(db, ksql.WriteBehindConfig{
+//		BatchSize:     500,
+//		FlushInterval: 5 * time.Second,
+//		OnError: func(err error, table ksql.Table, records []interface{}) {
+//			log.Printf("failed to write %d records: %s", len(records), err)
+//		},
+//	})
+//	defer queue.Close(ctx)
+//
+//	err := queue.Insert(EventsTable, &event)
+//
+// Consecutive writes of the same kind, table and record type are written
+// with a single call, so the order of the writes is always preserved.
+type WriteBehindQueue struct {
+	db     Provider
+	config WriteBehindConfig
+
+	mutex   sync.RWMutex
+	closed  bool
+	entries chan writeBehindEntry
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type writeBehindEntry struct {
+	isPatch bool
+	table   Table
+	record  interface{}
+}
+
+// NewWriteBehindQueue instantiates a new WriteBehindQueue and
+// starts the goroutine that flushes the writes to the Provider.
+func NewWriteBehindQueue(db Provider, config WriteBehindConfig) *WriteBehindQueue {
+	config.SetDefaultValues()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &WriteBehindQueue{
+		db:      db,
+		config:  config,
+		entries: make(chan writeBehindEntry, config.QueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	go q.run()
+
+	return q
+}
+
+// Insert enqueues the record for being inserted, it must be a pointer
+// to struct, and it returns ErrQueueFull if the queue has no room for it.
+func (q *WriteBehindQueue) Insert(table Table, record interface{}) error {
+	return q.enqueue(writeBehindEntry{table: table, record: record})
+}
+
+// Patch enqueues the record for being updated as described on the `DB.Patch()`
+// method, and it returns ErrQueueFull if the queue has no room for it.
+func (q *WriteBehindQueue) Patch(table Table, record interface{}) error {
+	return q.enqueue(writeBehindEntry{isPatch: true, table: table, record: record})
+}
+
+func (q *WriteBehindQueue) enqueue(entry writeBehindEntry) error {
+	if err := entry.table.validate(); err != nil {
+		return fmt.Errorf("can't enqueue write to ksql.Table: %s", err)
+	}
+
+	t := reflect.TypeOf(entry.record)
+	if t == nil || assertStructPtr(t) != nil {
+		return fmt.Errorf("ksql: expected record to be a pointer to struct, but got: %T", entry.record)
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case q.entries <- entry:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting new writes and waits until all the pending
+// writes are flushed, if the context is canceled before that the
+// writes in progress are canceled, the remaining ones are reported
+// to the OnError callback, and the error of the context is returned.
+func (q *WriteBehindQueue) Close(ctx context.Context) error {
+	q.mutex.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.entries)
+	}
+	q.mutex.Unlock()
+
+	select {
+	case <-q.done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-q.done
+		return ctx.Err()
+	}
+}
+
+func (q *WriteBehindQueue) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.config.FlushInterval)
+	defer ticker.Stop()
+
+	var pending []writeBehindEntry
+	for {
+		select {
+		case entry, ok := <-q.entries:
+			if !ok {
+				q.flush(pending)
+				return
+			}
+
+			pending = append(pending, entry)
+			if len(pending) >= q.config.BatchSize {
+				q.flush(pending)
+				pending = nil
+			}
+
+		case <-ticker.C:
+			q.flush(pending)
+			pending = nil
+		}
+	}
+}
+
+// flush writes the pending entries grouping the consecutive
+// entries with the same operation, table and record type.
+func (q *WriteBehindQueue) flush(pending []writeBehindEntry) {
+	for start := 0; start < len(pending); {
+		end := start + 1
+		for end < len(pending) && isSameWriteBehindBatch(pending[start], pending[end]) {
+			end++
+		}
+
+		q.writeBatch(pending[start:end])
+		start = end
+	}
+}
+
+// isSameWriteBehindBatch compares the whole Table and not only its name,
+// since tables with the same name might be on different schemas
+// or use different ID columns and sequences.
+func isSameWriteBehindBatch(a writeBehindEntry, b writeBehindEntry) bool {
+	return a.isPatch == b.isPatch &&
+		reflect.DeepEqual(a.table, b.table) &&
+		reflect.TypeOf(a.record) == reflect.TypeOf(b.record)
+}
+
+func (q *WriteBehindQueue) writeBatch(batch []writeBehindEntry) {
+	table := batch[0].table
+
+	records := make([]interface{}, len(batch))
+	slice := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(batch[0].record)), 0, len(batch))
+	for i, entry := range batch {
+		records[i] = entry.record
+		slice = reflect.Append(slice, reflect.ValueOf(entry.record))
+	}
+
+	var err error
+	if batch[0].isPatch {
+		err = UpdateMany(q.ctx, q.db, table, slice.Interface())
+	} else {
+		err = InsertMany(q.ctx, q.db, table, slice.Interface())
+	}
+
+	if err != nil && q.config.OnError != nil {
+		q.config.OnError(err, table, records)
+	}
+}
@@ -0,0 +1,185 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type batchCall struct {
+	method  string
+	table   string
+	records interface{}
+}
+
+// mockBatchProvider records the calls to the
+// methods of the BatchProvider interface
+type mockBatchProvider struct {
+	Mock
+
+	mutex sync.Mutex
+	calls []batchCall
+	err   error
+}
+
+func (m *mockBatchProvider) InsertMany(ctx context.Context, table Table, records interface{}, opts ...QueryOption) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls = append(m.calls, batchCall{method: "InsertMany", table: table.qualifiedName(), records: records})
+	return m.err
+}
+
+func (m *mockBatchProvider) UpdateMany(ctx context.Context, table Table, records interface{}, opts ...QueryOption) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls = append(m.calls, batchCall{method: "UpdateMany", table: table.qualifiedName(), records: records})
+	return m.err
+}
+
+func (m *mockBatchProvider) getCalls() []batchCall {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]batchCall{}, m.calls...)
+}
+
+func TestWriteBehindQueue(t *testing.T) {
+	ctx := context.Background()
+	eventsTable := NewTable("events")
+
+	t.Run("should flush the consecutive writes of the same kind in batches", func(t *testing.T) {
+		db := &mockBatchProvider{}
+		queue := NewWriteBehindQueue(db, WriteBehindConfig{
+			FlushInterval: time.Hour,
+		})
+
+		u1, u2, u3, u4 := &user{Name: "user1"}, &user{Name: "user2"}, &user{ID: 1, Name: "user3"}, &user{Name: "user4"}
+		tt.AssertNoErr(t, queue.Insert(usersTable, u1))
+		tt.AssertNoErr(t, queue.Insert(usersTable, u2))
+		tt.AssertNoErr(t, queue.Patch(usersTable, u3))
+		tt.AssertNoErr(t, queue.Insert(eventsTable, u4))
+
+		err := queue.Close(ctx)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, db.getCalls(), []batchCall{
+			{method: "InsertMany", table: "users", records: []*user{u1, u2}},
+			{method: "UpdateMany", table: "users", records: []*user{u3}},
+			{method: "InsertMany", table: "events", records: []*user{u4}},
+		})
+	})
+
+	t.Run("should not batch the writes to tables with the same name on different schemas", func(t *testing.T) {
+		db := &mockBatchProvider{}
+		queue := NewWriteBehindQueue(db, WriteBehindConfig{
+			FlushInterval: time.Hour,
+		})
+
+		u1, u2 := &user{Name: "user1"}, &user{Name: "user2"}
+		tt.AssertNoErr(t, queue.Insert(usersTable, u1))
+		tt.AssertNoErr(t, queue.Insert(usersTable.WithSchema("app"), u2))
+
+		err := queue.Close(ctx)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, db.getCalls(), []batchCall{
+			{method: "InsertMany", table: "users", records: []*user{u1}},
+			{method: "InsertMany", table: "app.users", records: []*user{u2}},
+		})
+	})
+
+	t.Run("should flush when the batch size is reached", func(t *testing.T) {
+		db := &mockBatchProvider{}
+		queue := NewWriteBehindQueue(db, WriteBehindConfig{
+			BatchSize:     2,
+			FlushInterval: time.Hour,
+		})
+		defer queue.Close(ctx)
+
+		tt.AssertNoErr(t, queue.Insert(usersTable, &user{Name: "user1"}))
+		tt.AssertNoErr(t, queue.Insert(usersTable, &user{Name: "user2"}))
+
+		waitFor(t, func() bool { return len(db.getCalls()) == 1 })
+	})
+
+	t.Run("should flush on every interval", func(t *testing.T) {
+		db := &mockBatchProvider{}
+		queue := NewWriteBehindQueue(db, WriteBehindConfig{
+			FlushInterval: 10 * time.Millisecond,
+		})
+		defer queue.Close(ctx)
+
+		tt.AssertNoErr(t, queue.Insert(usersTable, &user{Name: "user1"}))
+
+		waitFor(t, func() bool { return len(db.getCalls()) == 1 })
+	})
+
+	t.Run("should report the failed batches to the OnError callback", func(t *testing.T) {
+		db := &mockBatchProvider{err: errors.New("fake-error")}
+
+		var reportedErr error
+		var reportedRecords []interface{}
+		queue := NewWriteBehindQueue(db, WriteBehindConfig{
+			OnError: func(err error, table Table, records []interface{}) {
+				reportedErr = err
+				reportedRecords = records
+			},
+		})
+
+		u := &user{Name: "user1"}
+		tt.AssertNoErr(t, queue.Insert(usersTable, u))
+
+		err := queue.Close(ctx)
+		tt.AssertNoErr(t, err)
+		tt.AssertErrContains(t, reportedErr, "fake-error")
+		tt.AssertEqual(t, reportedRecords, []interface{}{u})
+	})
+
+	t.Run("should reject writes when the queue is full or closed", func(t *testing.T) {
+		db := &mockBatchProvider{}
+		queue := NewWriteBehindQueue(db, WriteBehindConfig{
+			QueueSize:     1,
+			BatchSize:     1000,
+			FlushInterval: time.Hour,
+		})
+
+		// The flushing goroutine might take the first entry out
+		// of the queue, so it is filled until it reports an error:
+		var err error
+		for i := 0; i < 3 && err == nil; i++ {
+			err = queue.Insert(usersTable, &user{Name: "user"})
+		}
+		tt.AssertEqual(t, errors.Is(err, ErrQueueFull), true)
+
+		tt.AssertNoErr(t, queue.Close(ctx))
+		err = queue.Insert(usersTable, &user{Name: "user"})
+		tt.AssertEqual(t, errors.Is(err, ErrQueueClosed), true)
+	})
+
+	t.Run("should reject invalid records", func(t *testing.T) {
+		queue := NewWriteBehindQueue(&mockBatchProvider{}, WriteBehindConfig{})
+		defer queue.Close(ctx)
+
+		err := queue.Insert(usersTable, user{Name: "user"})
+		tt.AssertErrContains(t, err, "pointer to struct")
+
+		err = queue.Patch(usersTable, nil)
+		tt.AssertErrContains(t, err, "pointer to struct")
+
+		err = queue.Insert(NewTable(""), &user{Name: "user"})
+		tt.AssertErrContains(t, err, "table name")
+	})
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	for i := 0; i < 200; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for the condition")
+}